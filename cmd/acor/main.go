@@ -3,12 +3,17 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"html"
 	"io"
+	"math"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,15 +37,28 @@
   find-index <input>
   find-set <input>
   find-matches <input>
+  highlight <input> | -
   contains <input>
+  has <keyword>
+  list [-limit n] [-cursor keyword]
+  score <input> | -
   find-parallel <input> | -
   find-index-parallel <input> | -
+  set-weights <keyword=weight>... | -
+  weights
+  set-alias <alias>
+  resolve-alias <alias>
+  delete-alias <alias>
+  history
+  revert <version>
+  sync -f <file> [-dry-run] [-max-deletes n]
   suggest <input>
   suggest-index <input>
   info
   flush
   migrate [options]
   migrate-rollback
+  copy -to-addr addr | -to-addrs addrs | -to-name name [-read-only] [-delete-source]
   schema-version
   version
 
@@ -70,7 +88,19 @@ func writeUsage(w io.Writer) {
 	commandFindIndex         = "find-index"
 	commandFindSet           = "find-set"
 	commandFindMatches       = "find-matches"
+	commandHighlight         = "highlight"
 	commandContains          = "contains"
+	commandHas               = "has"
+	commandList              = "list"
+	commandScore             = "score"
+	commandSetWeights        = "set-weights"
+	commandWeights           = "weights"
+	commandSetAlias          = "set-alias"
+	commandResolveAlias      = "resolve-alias"
+	commandDeleteAlias       = "delete-alias"
+	commandHistory           = "history"
+	commandRevert            = "revert"
+	commandSync              = "sync"
 	commandVersion           = "version"
 	commandFindParallel      = "find-parallel"
 	commandFindIndexParallel = "find-index-parallel"
@@ -81,13 +111,17 @@ func writeUsage(w io.Writer) {
 	commandMigrate           = "migrate"
 	commandMigrateRollback   = "migrate-rollback"
 	commandSchemaVersion     = "schema-version"
+	commandCopy              = "copy"
 
 	defaultCollectionName = "default"
 
-	jsonKeyCount    = "count"
-	jsonKeyMatches  = "matches"
-	jsonKeyStatus   = "status"
-	jsonKeyContains = "contains"
+	jsonKeyCount      = "count"
+	jsonKeyMatches    = "matches"
+	jsonKeyStatus     = "status"
+	jsonKeyContains   = "contains"
+	jsonKeyHas        = "has"
+	jsonKeyAlias      = "alias"
+	jsonKeyCollection = "collection"
 )
 
 // matchJSON is the wire shape for find-matches. acor.Match carries no JSON tags,
@@ -99,6 +133,28 @@ type matchJSON struct {
 	End     int    `json:"end"`
 }
 
+// scoreJSON, keywordScoreJSON, and weightJSON are the wire shapes for score and
+// weights, for the same reason as matchJSON.
+type scoreJSON struct {
+	Total             float64                     `json:"total"`
+	Flagged           bool                        `json:"flagged"`
+	FlaggedCategories []string                    `json:"flagged_categories"`
+	Categories        map[string]float64          `json:"categories"`
+	Keywords          map[string]keywordScoreJSON `json:"keywords"`
+}
+
+type keywordScoreJSON struct {
+	Count    int     `json:"count"`
+	Weight   float64 `json:"weight"`
+	Category string  `json:"category,omitempty"`
+	Score    float64 `json:"score"`
+}
+
+type weightJSON struct {
+	Weight   float64 `json:"weight"`
+	Category string  `json:"category,omitempty"`
+}
+
 type service interface {
 	Add(string) (int, error)
 	AddMany([]string, *acor.BatchOptions) (*acor.BatchResult, error)
@@ -108,7 +164,20 @@ type service interface {
 	FindIndex(string) (map[string][]int, error)
 	FindSet(string) ([]string, error)
 	FindMatches(string, *acor.MatchOptions) ([]acor.Match, error)
+	Highlight(string, string, string) (string, error)
 	Contains(string) (bool, error)
+	Has(string) (bool, error)
+	Keywords(string, int) ([]string, string, error)
+	Score(string, *acor.ScoreOptions) (*acor.ScoreResult, error)
+	SetWeights(map[string]acor.KeywordWeight) error
+	Weights() (map[string]acor.KeywordWeight, error)
+	SetAlias(string) error
+	ResolveAlias(string) (string, error)
+	DeleteAlias(string) (bool, error)
+	Collection() string
+	History(int) ([]acor.HistoryEntry, error)
+	RevertTo(int64) (*acor.VersionDiff, error)
+	SyncReader(io.Reader, *acor.SyncOptions) (*acor.SyncReport, error)
 	FindParallel(string, *acor.ParallelOptions) ([]string, error)
 	FindIndexParallel(string, *acor.ParallelOptions) (map[string][]int, error)
 	Suggest(string) ([]string, error)
@@ -122,26 +191,60 @@ type service interface {
 }
 
 type commandConfig struct {
-	addr         string
-	addrs        string
-	masterName   string
-	ringAddrs    string
-	password     string
-	db           int
-	name         string
-	debug        bool
-	cache        bool
-	preset       string
-	pollInterval time.Duration
-	batchMode    string
-	workers      int
-	chunkSize    int
-	boundary     string
-	overlap      int
-	matchKind    string
-	wholeWord    bool
-	dryRun       bool
-	keepOldKeys  bool
+	addr               string
+	addrs              string
+	masterName         string
+	ringAddrs          string
+	password           string
+	passwordFile       string
+	username           string
+	sentinelUsername   string
+	sentinelPassword   string
+	tls                bool
+	tlsCAFile          string
+	tlsCertFile        string
+	tlsKeyFile         string
+	tlsServerName      string
+	db                 int
+	name               string
+	alias              string
+	history            bool
+	historyMaxEntries  int64
+	limit              int
+	cursor             string
+	syncFile           string
+	maxDeletes         int
+	debug              bool
+	cache              bool
+	preset             string
+	pollInterval       time.Duration
+	batchMode          string
+	workers            int
+	chunkSize          int
+	boundary           string
+	overlap            int
+	matchKind          string
+	wholeWord          bool
+	html               bool
+	color              string
+	category           string
+	defaultWeight      float64
+	decay              float64
+	maxOccurrences     int
+	threshold          float64
+	categoryThresholds string
+	dryRun             bool
+	keepOldKeys        bool
+	toAddr             string
+	toAddrs            string
+	toMasterName       string
+	toUsername         string
+	toPassword         string
+	toDB               int
+	toName             string
+	readOnly           bool
+	deleteSource       bool
+	batchSize          int
 }
 
 type argumentMode int
@@ -159,6 +262,15 @@ type commandSpec struct {
 	mode   argumentMode
 }
 
+// trailingOptions lists the commands whose options may also follow the command
+// name, as in `acor sync -f keywords.txt -dry-run`. Every other command takes
+// its options before the name, where anything after it is an argument.
+var trailingOptions = map[string]bool{
+	commandSync: true,
+	commandList: true,
+	commandCopy: true,
+}
+
 var commandSpecs = map[string]commandSpec{
 	commandAdd:               {runAdd, argumentsOne},
 	commandAddMany:           {runAddMany, argumentsOneOrMore},
@@ -168,7 +280,19 @@ type commandSpec struct {
 	commandFindIndex:         {runFindIndex, argumentsOne},
 	commandFindSet:           {runFindSet, argumentsOne},
 	commandFindMatches:       {runFindMatches, argumentsOne},
+	commandHighlight:         {runHighlight, argumentsOne},
 	commandContains:          {runContains, argumentsOne},
+	commandHas:               {runHas, argumentsOne},
+	commandList:              {runList, argumentsNone},
+	commandScore:             {runScore, argumentsOne},
+	commandSetWeights:        {runSetWeights, argumentsOneOrMore},
+	commandWeights:           {runWeights, argumentsNone},
+	commandSetAlias:          {runSetAlias, argumentsOne},
+	commandResolveAlias:      {runResolveAlias, argumentsOne},
+	commandDeleteAlias:       {runDeleteAlias, argumentsOne},
+	commandHistory:           {runHistory, argumentsNone},
+	commandRevert:            {runRevert, argumentsOne},
+	commandSync:              {runSync, argumentsNone},
 	commandFindParallel:      {runFindParallel, argumentsOne},
 	commandFindIndexParallel: {runFindIndexParallel, argumentsOne},
 	commandSuggest:           {runSuggest, argumentsOne},
@@ -178,6 +302,7 @@ type commandSpec struct {
 	commandMigrate:           {runMigrate, argumentsNone},
 	commandMigrateRollback:   {runMigrateRollback, argumentsNone},
 	commandSchemaVersion:     {runSchemaVersion, argumentsNone},
+	commandCopy:              {runCopy, argumentsNone},
 	commandVersion:           {runVersion, argumentsNone},
 }
 
@@ -186,15 +311,33 @@ func main() {
 }
 
 type commandOptions struct {
-	dryRun           bool
-	keepOldKeys      bool
-	batchMode        acor.BatchMode
-	parallel         acor.ParallelOptions
-	match            acor.MatchOptions
-	batchFlagsSet    bool
-	parallelFlagsSet bool
-	matchFlagsSet    bool
-	pollFlagSet      bool
+	dryRun            bool
+	keepOldKeys       bool
+	batchMode         acor.BatchMode
+	parallel          acor.ParallelOptions
+	match             acor.MatchOptions
+	html              bool
+	color             colorMode
+	category          string
+	score             acor.ScoreOptions
+	batchFlagsSet     bool
+	parallelFlagsSet  bool
+	matchFlagsSet     bool
+	highlightFlagsSet bool
+	scoreFlagsSet     bool
+	categoryFlagSet   bool
+	pollFlagSet       bool
+	limit             int
+	limitFlagSet      bool
+	cursor            string
+	cursorFlagSet     bool
+	syncFile          string
+	maxDeletes        int
+	syncFlagsSet      bool
+	copySource        *acor.AhoCorasickArgs
+	copyDestination   *acor.AhoCorasickArgs
+	copy              acor.CopyOptions
+	copyFlagsSet      bool
 }
 
 func run(args []string, stdout, stderr io.Writer, create func(*acor.AhoCorasickArgs) (service, error)) int {
@@ -225,11 +368,23 @@ func runWithInput(args []string, stdin io.Reader, stdout, stderr io.Writer,
 		writeUsage(stderr)
 		return exitCodeUsage
 	}
+	if trailingOptions[command] && len(remaining) > 1 {
+		// Parse again with the options after the command moved in front of it, so
+		// they are validated exactly like options given in the usual place.
+		leading := args[:len(args)-len(remaining)]
+		config, commandOpts, remaining, err = parseArgs(append(slices.Clone(leading), remaining[1:]...))
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err.Error())
+			return exitCodeUsage
+		}
+		remaining = append([]string{command}, remaining...)
+	}
 
 	// Both flags live on the single global flag set, so reject them rather than
 	// ignore them: a silently dropped -dry-run turns a preview into a real run.
-	if command != commandMigrate && (commandOpts.dryRun || commandOpts.keepOldKeys) {
-		_, _ = fmt.Fprintf(stderr, "-dry-run and -keep-old-keys only apply to the %q command\n", commandMigrate)
+	if command != commandMigrate && (commandOpts.keepOldKeys || (commandOpts.dryRun && command != commandSync)) {
+		_, _ = fmt.Fprintf(stderr, "-dry-run and -keep-old-keys only apply to the %q command (%q also takes -dry-run)\n",
+			commandMigrate, commandSync)
 		return exitCodeUsage
 	}
 
@@ -247,8 +402,16 @@ func runWithInput(args []string, stdin io.Reader, stdout, stderr io.Writer,
 	// run when the CLI misbehaves, which is exactly when Redis may be unreachable.
 	// It still passes through every check above, so stray arguments and misapplied
 	// flags are rejected for it like for any other command.
+	// RevertTo refuses to write without recording (ErrHistoryDisabled), and there
+	// is no reason to make the caller spell out -history for the one command
+	// that needs it.
+	if command == commandRevert && config.History == nil {
+		config.History = &acor.HistoryOptions{}
+	}
+
+	// copy opens its two collections itself, so it has no service to be given.
 	var ac service
-	if command != commandVersion {
+	if command != commandVersion && command != commandCopy {
 		created, createErr := create(config)
 		if createErr != nil {
 			_, _ = fmt.Fprintln(stderr, createErr.Error())
@@ -276,6 +439,7 @@ func newFlagSet() (*flag.FlagSet, *commandConfig) {
 		boundary:  "word",
 		overlap:   acor.DefaultOverlap,
 		matchKind: "overlapping",
+		color:     "auto",
 	}
 	fs := flag.NewFlagSet("acor", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
@@ -284,12 +448,28 @@ func newFlagSet() (*flag.FlagSet, *commandConfig) {
 	fs.StringVar(&config.masterName, "master-name", "", "Redis Sentinel master name")
 	fs.StringVar(&config.ringAddrs, "ring-addrs", "", "Comma-separated shard=addr pairs for Redis Ring mode")
 	fs.StringVar(&config.password, "password", "", "Redis password")
+	fs.StringVar(&config.passwordFile, "password-file", "",
+		"File holding the Redis password, read again for each new connection (instead of -password)")
+	fs.StringVar(&config.username, "username", "", "Redis ACL username")
+	fs.StringVar(&config.sentinelUsername, "sentinel-username", "", "ACL username for the Sentinel servers themselves")
+	fs.StringVar(&config.sentinelPassword, "sentinel-password", "", "Password for the Sentinel servers themselves")
+	fs.BoolVar(&config.tls, "tls", false, "Connect to Redis over TLS, trusting the system roots unless -tls-ca-file is set")
+	fs.StringVar(&config.tlsCAFile, "tls-ca-file", "", "PEM file of the CAs to trust for Redis; implies -tls")
+	fs.StringVar(&config.tlsCertFile, "tls-cert-file", "", "PEM client certificate for Redis, with -tls-key-file; implies -tls")
+	fs.StringVar(&config.tlsKeyFile, "tls-key-file", "", "PEM client key for Redis, with -tls-cert-file; implies -tls")
+	fs.StringVar(&config.tlsServerName, "tls-server-name", "",
+		"Name to verify Redis certificates against (default: each address's host); implies -tls")
 	fs.IntVar(&config.db, "db", 0, "Redis DB number")
 	fs.StringVar(&config.name, "name", defaultCollectionName, "Pattern collection name")
+	fs.StringVar(&config.alias, "alias", "", "Open the collection this alias points at instead of -name")
+	fs.BoolVar(&config.history, "history", false, "Record writes in the collection's version history")
+	fs.Int64Var(&config.historyMaxEntries, "history-max-entries", 0,
+		"History entries to keep; implies -history (0 uses the library default)")
 	fs.BoolVar(&config.debug, "debug", false, "Enable debug logging")
 	fs.BoolVar(&config.cache, "cache", false, "Enable the local V2 matching cache")
 	fs.StringVar(&config.preset, "preset", config.preset, "Local engine preset: none, speed, balanced, or memory-efficient")
-	fs.DurationVar(&config.pollInterval, "invalidation-poll-interval", 0, "Preset mode: poll interval for missed invalidations (for example 30s)")
+	fs.DurationVar(&config.pollInterval, "invalidation-poll-interval", 0,
+		"Preset mode or -alias: poll interval for missed invalidations (for example 30s)")
 	fs.StringVar(&config.batchMode, "batch-mode", config.batchMode, "Batch mode: best-effort or transactional")
 	fs.IntVar(&config.workers, "workers", 0, "Parallel matching workers (0 uses the CPU count)")
 	fs.IntVar(&config.chunkSize, "chunk-size", config.chunkSize, "Parallel matching chunk size in runes")
@@ -300,8 +480,33 @@ func newFlagSet() (*flag.FlagSet, *commandConfig) {
 	fs.BoolVar(&config.wholeWord, "whole-word", false,
 		"find-matches: drop matches whose neighboring runes are word characters "+
 			"(scripts without spaces between words, such as CJK, drop nearly every match)")
-	fs.BoolVar(&config.dryRun, "dry-run", false, "migrate: preview migration without making changes")
+	fs.BoolVar(&config.html, "html", false, "highlight: escape the text for HTML and mark matches with <mark>")
+	fs.StringVar(&config.color, "color", config.color,
+		"highlight: mark matches with ANSI colors: auto (only on a terminal), always, or never")
+	fs.StringVar(&config.category, "category", "", "set-weights: category to file the weighted keywords under")
+	fs.Float64Var(&config.defaultWeight, "default-weight", 0, "score: weight for matched keywords without a stored weight")
+	fs.Float64Var(&config.decay, "decay", 0,
+		"score: each repeat of a keyword adds this fraction of the previous one (0 disables)")
+	fs.IntVar(&config.maxOccurrences, "max-occurrences", 0, "score: occurrences scored per keyword (0 means all)")
+	fs.Float64Var(&config.threshold, "threshold", 0, "score: flag the text when the total reaches this (0 disables)")
+	fs.StringVar(&config.categoryThresholds, "category-thresholds", "",
+		"score: comma-separated category=threshold pairs to flag categories by")
+	fs.IntVar(&config.limit, "limit", 0, "history: newest entries to show; list: keywords per page (0 shows all)")
+	fs.StringVar(&config.cursor, "cursor", "", "list: resume after this keyword, a previous page's next")
+	fs.StringVar(&config.syncFile, "f", "", "sync: file of desired keywords, one per line (- reads stdin)")
+	fs.IntVar(&config.maxDeletes, "max-deletes", 0, "sync: most keywords the sync may remove (-1 for no limit)")
+	fs.BoolVar(&config.dryRun, "dry-run", false, "migrate, sync: preview without making changes")
 	fs.BoolVar(&config.keepOldKeys, "keep-old-keys", false, "migrate: keep V1 keys after migration (for rollback)")
+	fs.StringVar(&config.toAddr, "to-addr", "", "copy: destination Redis address for standalone mode (default: the source's Redis)")
+	fs.StringVar(&config.toAddrs, "to-addrs", "", "copy: comma-separated destination addresses for Sentinel or Cluster mode")
+	fs.StringVar(&config.toMasterName, "to-master-name", "", "copy: destination Sentinel master name")
+	fs.StringVar(&config.toUsername, "to-username", "", "copy: destination Redis ACL username")
+	fs.StringVar(&config.toPassword, "to-password", "", "copy: destination Redis password")
+	fs.IntVar(&config.toDB, "to-db", 0, "copy: destination Redis DB number")
+	fs.StringVar(&config.toName, "to-name", "", "copy: destination collection name (default: the source's)")
+	fs.BoolVar(&config.readOnly, "read-only", false, "copy: refuse writes to the source until the copy is done")
+	fs.BoolVar(&config.deleteSource, "delete-source", false, "copy: delete the source once the copy is verified")
+	fs.IntVar(&config.batchSize, "batch-size", 0, "copy: keywords or output states per staging write (0 uses the library default)")
 	fs.Usage = func() {}
 	return fs, config
 }
@@ -316,10 +521,21 @@ func parseArgs(args []string) (*acor.AhoCorasickArgs, *commandOptions, []string,
 		return nil, nil, nil, err
 	}
 
+	seen := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { seen[f.Name] = true })
+
 	config.name = strings.TrimSpace(config.name)
 	if config.name == "" {
 		config.name = defaultCollectionName
 	}
+	config.alias = strings.TrimSpace(config.alias)
+	if config.alias != "" {
+		if seen["name"] {
+			return nil, nil, nil, errors.New("-name and -alias cannot be used together")
+		}
+		// The library takes one or the other; the default name must not ride along.
+		config.name = ""
+	}
 
 	ringAddrs, err := parseRingAddrs(config.ringAddrs)
 	if err != nil {
@@ -335,12 +551,14 @@ func parseArgs(args []string) (*acor.AhoCorasickArgs, *commandOptions, []string,
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	categoryThresholds, err := parseCategoryThresholds(config.categoryThresholds)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	if validationErr := validateNumericOptions(config); validationErr != nil {
 		return nil, nil, nil, validationErr
 	}
 
-	seen := make(map[string]bool)
-	fs.Visit(func(f *flag.Flag) { seen[f.Name] = true })
 	commandOpts := &commandOptions{
 		dryRun:      config.dryRun,
 		keepOldKeys: config.keepOldKeys,
@@ -357,23 +575,151 @@ func parseArgs(args []string) (*acor.AhoCorasickArgs, *commandOptions, []string,
 		},
 		batchFlagsSet:    seen["batch-mode"],
 		parallelFlagsSet: seen["workers"] || seen["chunk-size"] || seen["boundary"] || seen["overlap"],
+		html:             config.html,
+		color:            enums.color,
 		matchFlagsSet:    seen["match-kind"] || seen["whole-word"],
-		pollFlagSet:      seen["invalidation-poll-interval"],
+		category:         strings.TrimSpace(config.category),
+		score: acor.ScoreOptions{
+			DefaultWeight:      config.defaultWeight,
+			Decay:              config.decay,
+			MaxOccurrences:     config.maxOccurrences,
+			Threshold:          config.threshold,
+			CategoryThresholds: categoryThresholds,
+		},
+		highlightFlagsSet: seen["html"] || seen["color"],
+		scoreFlagsSet: seen["default-weight"] || seen["decay"] || seen["max-occurrences"] ||
+			seen["threshold"] || seen["category-thresholds"],
+		categoryFlagSet: seen["category"],
+		pollFlagSet:     seen["invalidation-poll-interval"],
+		limit:           config.limit,
+		limitFlagSet:    seen["limit"],
+		cursor:          config.cursor,
+		cursorFlagSet:   seen["cursor"],
+		syncFile:        config.syncFile,
+		maxDeletes:      config.maxDeletes,
+		syncFlagsSet:    seen["f"] || seen["max-deletes"],
+		copy: acor.CopyOptions{
+			BatchSize:      config.batchSize,
+			ReadOnlySource: config.readOnly,
+			DeleteSource:   config.deleteSource,
+		},
+		copyFlagsSet: seen["to-addr"] || seen["to-addrs"] || seen["to-master-name"] || seen["to-password"] || seen["to-username"] ||
+			seen["to-db"] || seen["to-name"] || seen["read-only"] || seen["delete-source"] || seen["batch-size"],
 	}
 
-	return &acor.AhoCorasickArgs{
+	var history *acor.HistoryOptions
+	if config.history || config.historyMaxEntries > 0 {
+		history = &acor.HistoryOptions{MaxEntries: config.historyMaxEntries}
+	}
+
+	source := &acor.AhoCorasickArgs{
 		Addr:                     strings.TrimSpace(config.addr),
 		Addrs:                    addrs,
 		MasterName:               strings.TrimSpace(config.masterName),
 		RingAddrs:                ringAddrs,
 		Password:                 config.password,
+		Username:                 strings.TrimSpace(config.username),
+		SentinelUsername:         strings.TrimSpace(config.sentinelUsername),
+		SentinelPassword:         config.sentinelPassword,
+		TLS:                      tlsOptions(config),
 		DB:                       config.db,
 		Name:                     config.name,
+		Alias:                    config.alias,
+		History:                  history,
 		Debug:                    config.debug,
 		EnableCache:              config.cache,
 		Preset:                   enums.preset,
 		InvalidationPollInterval: config.pollInterval,
-	}, commandOpts, fs.Args(), nil
+	}
+	if config.passwordFile != "" {
+		if config.password != "" {
+			return nil, nil, nil, errors.New("-password and -password-file cannot be used together")
+		}
+		source.CredentialsProvider = passwordFileProvider(source.Username, config.passwordFile)
+	}
+	commandOpts.copySource = source
+	if commandOpts.copyDestination, err = copyDestination(config, source); err != nil {
+		return nil, nil, nil, err
+	}
+	return source, commandOpts, fs.Args(), nil
+}
+
+// tlsOptions returns the TLS the -tls flags ask for, or nil for a plaintext
+// connection. Naming any file or server name turns TLS on.
+func tlsOptions(config *commandConfig) *acor.TLSOptions {
+	opts := &acor.TLSOptions{
+		CAFile:     strings.TrimSpace(config.tlsCAFile),
+		CertFile:   strings.TrimSpace(config.tlsCertFile),
+		KeyFile:    strings.TrimSpace(config.tlsKeyFile),
+		ServerName: strings.TrimSpace(config.tlsServerName),
+	}
+	if !config.tls && *opts == (acor.TLSOptions{}) {
+		return nil
+	}
+	return opts
+}
+
+// passwordFileProvider reads the password from path whenever a connection is
+// opened, so a password rotated by rewriting the file is picked up and never
+// appears in the process list. Trailing newlines are not part of it.
+func passwordFileProvider(username, path string) func(context.Context) (string, string, error) {
+	return func(context.Context) (string, string, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("password-file: %w", err)
+		}
+		return username, strings.TrimRight(string(data), "\r\n"), nil
+	}
+}
+
+// copyDestination builds the collection copy writes to: the source's Redis and
+// name unless the -to- options say otherwise. Any destination address replaces
+// the source's topology outright rather than merging with it, and with it the
+// source's credentials; the -tls flags apply to both ends.
+func copyDestination(config *commandConfig, source *acor.AhoCorasickArgs) (*acor.AhoCorasickArgs, error) {
+	dst := &acor.AhoCorasickArgs{
+		Addr:                source.Addr,
+		Addrs:               source.Addrs,
+		MasterName:          source.MasterName,
+		RingAddrs:           source.RingAddrs,
+		Username:            source.Username,
+		Password:            source.Password,
+		CredentialsProvider: source.CredentialsProvider,
+		SentinelUsername:    source.SentinelUsername,
+		SentinelPassword:    source.SentinelPassword,
+		TLS:                 source.TLS,
+		DB:                  source.DB,
+		Name:                source.Name,
+	}
+	if name := strings.TrimSpace(config.toName); name != "" {
+		dst.Name = name
+	}
+	toAddrs := parseCSV(config.toAddrs)
+	if strings.TrimSpace(config.toAddrs) != "" && len(toAddrs) == 0 {
+		return nil, errors.New("to-addrs must contain at least one address")
+	}
+	if config.toAddr != "" || len(toAddrs) > 0 || config.toMasterName != "" {
+		dst.Addr = strings.TrimSpace(config.toAddr)
+		dst.Addrs = toAddrs
+		dst.MasterName = strings.TrimSpace(config.toMasterName)
+		dst.RingAddrs = nil
+		dst.Username, dst.Password, dst.CredentialsProvider = "", "", nil
+		dst.SentinelUsername, dst.SentinelPassword = "", ""
+		dst.DB = 0
+	}
+	// Either one also takes over from a -password-file.
+	if username := strings.TrimSpace(config.toUsername); username != "" {
+		dst.Username = username
+		dst.CredentialsProvider = nil
+	}
+	if config.toPassword != "" {
+		dst.Password = config.toPassword
+		dst.CredentialsProvider = nil
+	}
+	if config.toDB != 0 {
+		dst.DB = config.toDB
+	}
+	return dst, nil
 }
 
 // The string-valued flags each map onto a library enum. The names live in maps
@@ -399,6 +745,11 @@ func parseArgs(args []string) (*acor.AhoCorasickArgs, *commandOptions, []string,
 		"overlapping":      acor.MatchKindOverlapping,
 		"leftmost-longest": acor.MatchKindLeftmostLongest,
 	}
+	colorModeNames = map[string]colorMode{
+		"auto":   colorAuto,
+		"always": colorAlways,
+		"never":  colorNever,
+	}
 )
 
 // parseEnum resolves one flag value against its name table. what is the noun the
@@ -420,6 +771,7 @@ type enumOptions struct {
 	batchMode acor.BatchMode
 	boundary  acor.ChunkBoundary
 	matchKind acor.MatchKind
+	color     colorMode
 }
 
 func parseEnumOptions(config *commandConfig) (*enumOptions, error) {
@@ -439,11 +791,16 @@ func parseEnumOptions(config *commandConfig) (*enumOptions, error) {
 	if err != nil {
 		return nil, err
 	}
+	color, err := parseEnum(config.color, "color mode", colorModeNames)
+	if err != nil {
+		return nil, err
+	}
 	return &enumOptions{
 		preset:    preset,
 		batchMode: batchMode,
 		boundary:  boundary,
 		matchKind: matchKind,
+		color:     color,
 	}, nil
 }
 
@@ -457,6 +814,12 @@ func validateNumericOptions(config *commandConfig) error {
 		return errors.New("overlap must be non-negative and smaller than chunk-size")
 	case config.pollInterval < 0:
 		return errors.New("invalidation-poll-interval must be non-negative")
+	case config.maxOccurrences < 0:
+		return errors.New("max-occurrences must be non-negative")
+	case config.historyMaxEntries < 0:
+		return errors.New("history-max-entries must be non-negative")
+	case config.limit < 0:
+		return errors.New("limit must be non-negative")
 	default:
 		return nil
 	}
@@ -475,6 +838,39 @@ func parseCSV(raw string) []string {
 	return values
 }
 
+// parseCategoryThresholds reads -category-thresholds. Unlike -ring-addrs a blank
+// entry is tolerated, since a trailing comma changes nothing about the thresholds.
+func parseCategoryThresholds(raw string) (map[string]float64, error) {
+	pairs := parseCSV(raw)
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	thresholds := make(map[string]float64, len(pairs))
+	for _, pair := range pairs {
+		category, value, err := splitAssignment(pair)
+		if err != nil {
+			return nil, fmt.Errorf("category-thresholds: %w", err)
+		}
+		thresholds[category] = value
+	}
+	return thresholds, nil
+}
+
+// splitAssignment parses name=number, splitting at the last '=' so the name may
+// itself contain one. The number must be finite.
+func splitAssignment(raw string) (string, float64, error) {
+	idx := strings.LastIndex(raw, "=")
+	if idx < 0 {
+		return "", 0, fmt.Errorf("%q is not a name=number pair", raw)
+	}
+	name := strings.TrimSpace(raw[:idx])
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw[idx+1:]), 64)
+	if name == "" || err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return "", 0, fmt.Errorf("%q is not a name=number pair", raw)
+	}
+	return name, value, nil
+}
+
 func parseRingAddrs(raw string) (map[string]string, error) {
 	if strings.TrimSpace(raw) == "" {
 		return nil, nil
@@ -546,6 +942,40 @@ func validateCommandOptions(command string, config *acor.AhoCorasickArgs, opts *
 		return fmt.Errorf("-match-kind and -whole-word only apply to %q", commandFindMatches)
 	}
 
+	if opts.highlightFlagsSet && command != commandHighlight {
+		return fmt.Errorf("-html and -color only apply to %q", commandHighlight)
+	}
+	if opts.scoreFlagsSet && command != commandScore {
+		return fmt.Errorf("scoring options only apply to %q", commandScore)
+	}
+	if opts.categoryFlagSet && command != commandSetWeights {
+		return fmt.Errorf("-category only applies to %q", commandSetWeights)
+	}
+	if opts.limitFlagSet && command != commandHistory && command != commandList {
+		return fmt.Errorf("-limit only applies to %q and %q", commandHistory, commandList)
+	}
+	if opts.cursorFlagSet && command != commandList {
+		return fmt.Errorf("-cursor only applies to %q", commandList)
+	}
+	if opts.syncFlagsSet && command != commandSync {
+		return fmt.Errorf("-f and -max-deletes only apply to %q", commandSync)
+	}
+	if opts.copyFlagsSet && command != commandCopy {
+		return fmt.Errorf("-to- options, -read-only, -delete-source, and -batch-size only apply to %q", commandCopy)
+	}
+	if command == commandSync && opts.syncFile == "" {
+		return fmt.Errorf("%q requires -f with the desired keywords", commandSync)
+	}
+	if opts.html && opts.color == colorAlways {
+		return errors.New("-html and -color=always cannot be used together")
+	}
+	// Migration rewrites one collection in place, which the library refuses to do
+	// through an alias (ErrMigrationViaAlias).
+	// Copies likewise (ErrInvalidCopy).
+	if config.Alias != "" && (command == commandMigrate || command == commandMigrateRollback || command == commandCopy) {
+		return fmt.Errorf("%q needs the collection's -name, not -alias", command)
+	}
+
 	return validatePresetOptions(command, config, opts)
 }
 
@@ -568,8 +998,8 @@ func validatePresetOptions(command string, config *acor.AhoCorasickArgs, opts *c
 	if config.EnableCache && config.Preset != acor.PresetNone {
 		return errors.New("-cache and -preset cannot be used together; preset mode already uses a local engine")
 	}
-	if opts.pollFlagSet && config.Preset == acor.PresetNone {
-		return errors.New("-invalidation-poll-interval requires -preset")
+	if opts.pollFlagSet && config.Preset == acor.PresetNone && config.Alias == "" {
+		return errors.New("-invalidation-poll-interval requires -preset or -alias")
 	}
 	if config.Preset != acor.PresetNone && presetUnsupported[command] {
 		return fmt.Errorf("%q is unavailable in preset mode", command)
@@ -649,6 +1079,269 @@ func runFindMatches(_ io.Reader, stdout io.Writer, ac service, args []string, op
 	return writeJSON(stdout, map[string][]matchJSON{jsonKeyMatches: out})
 }
 
+func runHighlight(stdin io.Reader, stdout io.Writer, ac service, args []string, opts *commandOptions) error {
+	input, err := textInput(stdin, args[0])
+	if err != nil {
+		return err
+	}
+	var out string
+	if opts.html {
+		// The library copies unmatched text verbatim, so escaping has to happen per
+		// segment here: escaping first would shift the offsets and hide keywords
+		// that contain &, < or >.
+		out, err = highlightHTML(ac, input)
+	} else {
+		open, closeMarker := plainMarkOpen, plainMarkClose
+		if useColor(stdout, opts.color) {
+			open, closeMarker = ansiMarkOpen, ansiMarkClose
+		}
+		out, err = ac.Highlight(input, open, closeMarker)
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err = io.WriteString(stdout, out)
+	return err
+}
+
+// colorMode is the -color flag: whether highlight marks matches with ANSI escapes.
+type colorMode int
+
+const (
+	colorAuto colorMode = iota
+	colorAlways
+	colorNever
+)
+
+const (
+	ansiMarkOpen  = "\x1b[1;31m"
+	ansiMarkClose = "\x1b[0m"
+	// Markdown's bold markers: readable as-is in a log or a pipe, where escapes
+	// would show up as noise.
+	plainMarkOpen  = "**"
+	plainMarkClose = "**"
+	htmlMarkOpen   = "<mark>"
+	htmlMarkClose  = "</mark>"
+)
+
+// useColor resolves -color=auto by asking whether stdout is a terminal. Anything
+// that is not an *os.File (a buffer, a pipe wrapper) is not one. NO_COLOR is
+// honored in auto mode only, so -color=always still means always.
+func useColor(w io.Writer, mode colorMode) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// highlightHTML renders input as HTML with each match in <mark>. It marks the same
+// leftmost-longest set acor.Highlight does, escaping the text around and inside
+// each match.
+func highlightHTML(ac service, input string) (string, error) {
+	matches, err := ac.FindMatches(input, &acor.MatchOptions{Kind: acor.MatchKindLeftmostLongest})
+	if err != nil {
+		return "", err
+	}
+	runes := []rune(input)
+	var b strings.Builder
+	pos := 0
+	for _, m := range matches {
+		b.WriteString(html.EscapeString(string(runes[pos:m.Start])))
+		b.WriteString(htmlMarkOpen)
+		b.WriteString(html.EscapeString(string(runes[m.Start:m.End])))
+		b.WriteString(htmlMarkClose)
+		pos = m.End
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:])))
+	return b.String(), nil
+}
+
+func runScore(stdin io.Reader, stdout io.Writer, ac service, args []string, opts *commandOptions) error {
+	input, err := textInput(stdin, args[0])
+	if err != nil {
+		return err
+	}
+	result, err := ac.Score(input, &opts.score)
+	if err != nil {
+		return err
+	}
+	keywords := make(map[string]keywordScoreJSON, len(result.Keywords))
+	for kw, ks := range result.Keywords {
+		keywords[kw] = keywordScoreJSON{Count: ks.Count, Weight: ks.Weight, Category: ks.Category, Score: ks.Score}
+	}
+	return writeJSON(stdout, &scoreJSON{
+		Total:             result.Total,
+		Flagged:           result.Flagged,
+		FlaggedCategories: result.FlaggedCategories,
+		Categories:        result.Categories,
+		Keywords:          keywords,
+	})
+}
+
+// runSetWeights takes keyword=weight pairs, as arguments or one per line on stdin,
+// all filed under -category. They are written in a single SetWeights call, so one
+// malformed pair leaves every weight unchanged.
+func runSetWeights(stdin io.Reader, stdout io.Writer, ac service, args []string, opts *commandOptions) error {
+	pairs, err := batchKeywords(stdin, args)
+	if err != nil {
+		return err
+	}
+	weights := make(map[string]acor.KeywordWeight, len(pairs))
+	for _, pair := range pairs {
+		keyword, weight, err := splitAssignment(pair)
+		if err != nil {
+			return fmt.Errorf("set-weights: %w", err)
+		}
+		weights[keyword] = acor.KeywordWeight{Weight: weight, Category: opts.category}
+	}
+	if err := ac.SetWeights(weights); err != nil {
+		return err
+	}
+	return writeJSON(stdout, map[string]int{jsonKeyCount: len(weights)})
+}
+
+func runWeights(_ io.Reader, stdout io.Writer, ac service, _ []string, _ *commandOptions) error {
+	weights, err := ac.Weights()
+	if err != nil {
+		return err
+	}
+	out := make(map[string]weightJSON, len(weights))
+	for kw, w := range weights {
+		out[kw] = weightJSON{Weight: w.Weight, Category: w.Category}
+	}
+	return writeJSON(stdout, map[string]map[string]weightJSON{"weights": out})
+}
+
+func runSetAlias(_ io.Reader, stdout io.Writer, ac service, args []string, _ *commandOptions) error {
+	if err := ac.SetAlias(args[0]); err != nil {
+		return err
+	}
+	return writeJSON(stdout, map[string]string{jsonKeyAlias: args[0], jsonKeyCollection: ac.Collection()})
+}
+
+func runResolveAlias(_ io.Reader, stdout io.Writer, ac service, args []string, _ *commandOptions) error {
+	collection, err := ac.ResolveAlias(args[0])
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, map[string]string{jsonKeyAlias: args[0], jsonKeyCollection: collection})
+}
+
+func runDeleteAlias(_ io.Reader, stdout io.Writer, ac service, args []string, _ *commandOptions) error {
+	deleted, err := ac.DeleteAlias(args[0])
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, map[string]bool{"deleted": deleted})
+}
+
+// historyEntryJSON and versionDiffJSON are the wire shapes for history and
+// revert, for the same reason as matchJSON.
+type historyEntryJSON struct {
+	ID          string    `json:"id"`
+	Op          string    `json:"op"`
+	Version     int64     `json:"version"`
+	PrevVersion int64     `json:"prev_version"`
+	Added       []string  `json:"added"`
+	Removed     []string  `json:"removed"`
+	Writer      string    `json:"writer"`
+	Time        time.Time `json:"time"`
+}
+
+type versionDiffJSON struct {
+	From    int64    `json:"from"`
+	To      int64    `json:"to"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+func runHistory(_ io.Reader, stdout io.Writer, ac service, _ []string, opts *commandOptions) error {
+	entries, err := ac.History(opts.limit)
+	if err != nil {
+		return err
+	}
+	out := make([]historyEntryJSON, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, historyEntryJSON{
+			ID: e.ID, Op: e.Op, Version: e.Version, PrevVersion: e.PrevVersion,
+			Added: e.Added, Removed: e.Removed, Writer: e.Writer, Time: e.Time,
+		})
+	}
+	return writeJSON(stdout, map[string][]historyEntryJSON{"history": out})
+}
+
+func runRevert(_ io.Reader, stdout io.Writer, ac service, args []string, _ *commandOptions) error {
+	target, err := strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64)
+	if err != nil {
+		return fmt.Errorf("revert: %q is not a version", args[0])
+	}
+	diff, err := ac.RevertTo(target)
+	if err != nil {
+		return err
+	}
+	// Empty lists rather than null, so a no-op revert reads as one.
+	return writeJSON(stdout, &versionDiffJSON{
+		From: diff.From, To: diff.To, Added: nonNilStrings(diff.Added), Removed: nonNilStrings(diff.Removed),
+	})
+}
+
+// syncReportJSON is the wire shape for sync, for the same reason as matchJSON.
+type syncReportJSON struct {
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+	Unchanged int      `json:"unchanged"`
+	DryRun    bool     `json:"dry_run"`
+}
+
+// runSync reconciles the collection to the -f file. A sync refused by
+// -max-deletes still prints the plan it refused, so the removals can be reviewed
+// before raising the limit.
+func runSync(stdin io.Reader, stdout io.Writer, ac service, _ []string, opts *commandOptions) error {
+	input := stdin
+	if opts.syncFile != "-" {
+		f, err := os.Open(opts.syncFile)
+		if err != nil {
+			return fmt.Errorf("sync: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		input = f
+	}
+	report, err := ac.SyncReader(input, &acor.SyncOptions{DryRun: opts.dryRun, MaxDeletes: opts.maxDeletes})
+	if report != nil {
+		if writeErr := writeJSON(stdout, &syncReportJSON{
+			Added:     nonNilStrings(report.Added),
+			Removed:   nonNilStrings(report.Removed),
+			Unchanged: report.Unchanged,
+			DryRun:    report.DryRun,
+		}); writeErr != nil && err == nil {
+			err = writeErr
+		}
+	}
+	return err
+}
+
+// nonNilStrings keeps an empty list an empty JSON array rather than null.
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
 func runContains(_ io.Reader, stdout io.Writer, ac service, args []string, _ *commandOptions) error {
 	found, err := ac.Contains(args[0])
 	if err != nil {
@@ -657,6 +1350,49 @@ func runContains(_ io.Reader, stdout io.Writer, ac service, args []string, _ *co
 	return writeJSON(stdout, map[string]bool{jsonKeyContains: found})
 }
 
+func runHas(_ io.Reader, stdout io.Writer, ac service, args []string, _ *commandOptions) error {
+	found, err := ac.Has(args[0])
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, map[string]bool{jsonKeyHas: found})
+}
+
+// listPageSize is how many keywords list fetches per call when -limit is unset
+// and it reads the whole collection.
+const listPageSize = 1000
+
+// listJSON is the wire shape for list. Next is set only under -limit, when a page
+// follows; pass it back as -cursor to read that page.
+type listJSON struct {
+	Keywords []string `json:"keywords"`
+	Next     string   `json:"next,omitempty"`
+}
+
+// runList prints one page of keywords under -limit, and otherwise every keyword
+// after -cursor, fetched a page at a time.
+func runList(_ io.Reader, stdout io.Writer, ac service, _ []string, opts *commandOptions) error {
+	if opts.limit > 0 {
+		page, next, err := ac.Keywords(opts.cursor, opts.limit)
+		if err != nil {
+			return err
+		}
+		return writeJSON(stdout, &listJSON{Keywords: nonNilStrings(page), Next: next})
+	}
+	keywords := []string{}
+	for cursor := opts.cursor; ; {
+		page, next, err := ac.Keywords(cursor, listPageSize)
+		if err != nil {
+			return err
+		}
+		keywords = append(keywords, page...)
+		if next == "" {
+			return writeJSON(stdout, &listJSON{Keywords: keywords})
+		}
+		cursor = next
+	}
+}
+
 func runFindIndex(_ io.Reader, stdout io.Writer, ac service, args []string, _ *commandOptions) error {
 	matches, err := ac.FindIndex(args[0])
 	if err != nil {
@@ -747,8 +1483,21 @@ func runSchemaVersion(_ io.Reader, stdout io.Writer, ac service, _ []string, _ *
 	return writeJSON(stdout, map[string]int{"schema_version": schemaVersion})
 }
 
-// runVersion is the only runner that receives a nil service: runWithInput skips
-// create() for it so the build version is reportable without a reachable Redis.
+// copyCollection is acor.CopyCollection, swapped out by tests that have no Redis.
+var copyCollection = acor.CopyCollection
+
+// runCopy receives a nil service, as runVersion does: it opens both of its
+// collections through copyCollection instead.
+func runCopy(_ io.Reader, stdout io.Writer, _ service, _ []string, opts *commandOptions) error {
+	report, err := copyCollection(context.Background(), opts.copySource, opts.copyDestination, opts.copy)
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, report)
+}
+
+// runVersion receives a nil service: runWithInput skips create() for it so the
+// build version is reportable without a reachable Redis.
 func runVersion(_ io.Reader, stdout io.Writer, _ service, _ []string, _ *commandOptions) error {
 	_, _ = fmt.Fprintln(stdout, version)
 	return nil
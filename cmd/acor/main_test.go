@@ -4,9 +4,17 @@
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/skyoo2003/acor/pkg/acor"
 )
@@ -37,6 +45,19 @@ type fakeService struct {
 	lastBatchOpts    *acor.BatchOptions
 	lastParallelOpts *acor.ParallelOptions
 	lastMatchOpts    *acor.MatchOptions
+	scoreResult      *acor.ScoreResult
+	lastScoreOpts    *acor.ScoreOptions
+	weights          map[string]acor.KeywordWeight
+	aliases          map[string]string
+	collection       string
+	history          []acor.HistoryEntry
+	lastLimit        int
+	revertDiff       *acor.VersionDiff
+	lastRevert       int64
+	syncReport       *acor.SyncReport
+	lastSyncOpts     *acor.SyncOptions
+	keywords         []string
+	keywordCalls     int
 }
 
 func (f *fakeService) Add(keyword string) (int, error) {
@@ -96,6 +117,92 @@ func (f *fakeService) FindMatches(input string, opts *acor.MatchOptions) ([]acor
 	return out, nil
 }
 
+// Highlight marks the whole input when the fake has any match, which is enough to
+// show which markers the command chose.
+func (f *fakeService) Highlight(input, open, closeMarker string) (string, error) {
+	f.lastInput = input
+	if f.err != nil {
+		return "", f.err
+	}
+	if len(f.findMatches) == 0 {
+		return input, nil
+	}
+	return open + input + closeMarker, nil
+}
+
+func (f *fakeService) Score(input string, opts *acor.ScoreOptions) (*acor.ScoreResult, error) {
+	f.lastInput = input
+	f.lastScoreOpts = opts
+	return f.scoreResult, f.err
+}
+
+func (f *fakeService) SetWeights(weights map[string]acor.KeywordWeight) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.weights = weights
+	return nil
+}
+
+func (f *fakeService) Weights() (map[string]acor.KeywordWeight, error) {
+	return f.weights, f.err
+}
+
+func (f *fakeService) SetAlias(alias string) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.aliases == nil {
+		f.aliases = make(map[string]string)
+	}
+	f.aliases[alias] = f.collection
+	return nil
+}
+
+func (f *fakeService) ResolveAlias(alias string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	collection, ok := f.aliases[alias]
+	if !ok {
+		return "", acor.ErrAliasNotFound
+	}
+	return collection, nil
+}
+
+func (f *fakeService) DeleteAlias(alias string) (bool, error) {
+	_, ok := f.aliases[alias]
+	delete(f.aliases, alias)
+	return ok, f.err
+}
+
+func (f *fakeService) Collection() string {
+	return f.collection
+}
+
+func (f *fakeService) History(limit int) ([]acor.HistoryEntry, error) {
+	f.lastLimit = limit
+	return f.history, f.err
+}
+
+func (f *fakeService) RevertTo(version int64) (*acor.VersionDiff, error) {
+	f.lastRevert = version
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.revertDiff, nil
+}
+
+func (f *fakeService) SyncReader(r io.Reader, opts *acor.SyncOptions) (*acor.SyncReport, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f.lastKeywords = strings.Fields(string(data))
+	f.lastSyncOpts = opts
+	return f.syncReport, f.err
+}
+
 func (f *fakeService) Contains(input string) (bool, error) {
 	f.lastInput = input
 	if f.err != nil {
@@ -104,6 +211,31 @@ func (f *fakeService) Contains(input string) (bool, error) {
 	return len(f.findMatches) > 0, nil
 }
 
+func (f *fakeService) Has(keyword string) (bool, error) {
+	f.lastKeyword = keyword
+	return slices.Contains(f.keywords, keyword), f.err
+}
+
+// Keywords pages through f.keywords, which tests keep sorted.
+func (f *fakeService) Keywords(cursor string, limit int) ([]string, string, error) {
+	f.keywordCalls++
+	if f.err != nil {
+		return nil, "", f.err
+	}
+	rest := f.keywords
+	if cursor != "" {
+		i, found := slices.BinarySearch(rest, cursor)
+		if found {
+			i++
+		}
+		rest = rest[i:]
+	}
+	if len(rest) <= limit {
+		return rest, "", nil
+	}
+	return rest[:limit], rest[limit-1], nil
+}
+
 func (f *fakeService) FindIndex(input string) (map[string][]int, error) {
 	f.lastInput = input
 	if f.err != nil {
@@ -216,6 +348,47 @@ func TestParseArgs(t *testing.T) {
 	}
 }
 
+func TestParseArgsRedisSecurity(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("rotated\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, _, err := parseArgs([]string{
+		"-addrs", "s1:26379", "-master-name", "mymaster",
+		"-username", "acor", "-password-file", passwordFile,
+		"-sentinel-username", "watcher", "-sentinel-password", "sentinel-secret",
+		"-tls-ca-file", "ca.pem", "-tls-server-name", "redis.internal",
+		"info",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Username != "acor" || parsed.SentinelUsername != "watcher" || parsed.SentinelPassword != "sentinel-secret" {
+		t.Errorf("credentials = %q, sentinel %q/%q", parsed.Username, parsed.SentinelUsername, parsed.SentinelPassword)
+	}
+	// Any -tls-* flag turns TLS on without -tls.
+	if parsed.TLS == nil || *parsed.TLS != (acor.TLSOptions{CAFile: "ca.pem", ServerName: "redis.internal"}) {
+		t.Errorf("TLS = %+v", parsed.TLS)
+	}
+	if parsed.CredentialsProvider == nil {
+		t.Fatal("-password-file set no CredentialsProvider")
+	}
+	user, password, err := parsed.CredentialsProvider(context.Background())
+	if err != nil || user != "acor" || password != "rotated" {
+		t.Errorf("CredentialsProvider = %q, %q, %v; want acor, rotated", user, password, err)
+	}
+
+	if parsed, _, _, err = parseArgs([]string{"-tls", "info"}); err != nil || parsed.TLS == nil || *parsed.TLS != (acor.TLSOptions{}) {
+		t.Errorf("-tls: TLS = %+v, %v; want system roots", parsed.TLS, err)
+	}
+	if parsed, _, _, err = parseArgs([]string{"info"}); err != nil || parsed.TLS != nil {
+		t.Errorf("no TLS flags: TLS = %+v, %v; want nil", parsed.TLS, err)
+	}
+	if _, _, _, err = parseArgs([]string{"-password", "x", "-password-file", passwordFile, "info"}); err == nil {
+		t.Error("-password with -password-file: want an error")
+	}
+}
+
 func TestRunForwardsPresetConfiguration(t *testing.T) {
 	stdout := &bytes.Buffer{}
 	stderr := &bytes.Buffer{}
@@ -721,6 +894,463 @@ func TestRunRejectsMatchFlagsOnOtherCommands(t *testing.T) {
 	}
 }
 
+func TestRunHighlightCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "plain when not a terminal", args: []string{"highlight", "hehe"}, want: "**hehe**\n"},
+		{name: "color always", args: []string{"-color", "always", "highlight", "hehe"},
+			want: ansiMarkOpen + "hehe" + ansiMarkClose + "\n"},
+		{name: "html escapes", args: []string{"-html", "highlight", "he<b>"}, want: "<mark>he</mark>&lt;b&gt;\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeService{findMatches: []string{testKeywordHE}}
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+
+			exitCode := run(tt.args, stdout, stderr, func(*acor.AhoCorasickArgs) (service, error) {
+				return fake, nil
+			})
+
+			if exitCode != 0 {
+				t.Fatalf("expected exit code 0, got %d with stderr %q", exitCode, stderr.String())
+			}
+			if stdout.String() != tt.want {
+				t.Fatalf("stdout = %q, want %q", stdout.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRunRejectsHighlightFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"-html", "find", "text"},
+		{"-color", "never", "find-matches", "text"},
+		{"-html", "-color", "always", "highlight", "text"},
+		{"-color", "sometimes", "highlight", "text"},
+	} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+
+			exitCode := run(args, stdout, stderr, func(*acor.AhoCorasickArgs) (service, error) {
+				return &fakeService{}, nil
+			})
+
+			if exitCode != exitCodeUsage {
+				t.Fatalf("expected exit code %d, got %d with stderr %q", exitCodeUsage, exitCode, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunScoreCommand(t *testing.T) {
+	fake := &fakeService{scoreResult: &acor.ScoreResult{
+		Total:             5,
+		Flagged:           true,
+		FlaggedCategories: []string{"spam"},
+		Categories:        map[string]float64{"spam": 5},
+		Keywords:          map[string]acor.KeywordScore{"spam": {Count: 2, Weight: 2.5, Category: "spam", Score: 5}},
+	}}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	exitCode := run([]string{"-decay", "0.5", "-max-occurrences", "3", "-threshold", "4",
+		"-category-thresholds", "spam=5,toxicity=2", "score", "spam spam"},
+		stdout, stderr, func(*acor.AhoCorasickArgs) (service, error) { return fake, nil })
+
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d with stderr %q", exitCode, stderr.String())
+	}
+	opts := fake.lastScoreOpts
+	if opts == nil || opts.Decay != 0.5 || opts.MaxOccurrences != 3 || opts.Threshold != 4 ||
+		opts.CategoryThresholds["spam"] != 5 || opts.CategoryThresholds["toxicity"] != 2 {
+		t.Fatalf("score options did not reach Score: %+v", opts)
+	}
+	want := `{"total":5,"flagged":true,"flagged_categories":["spam"],"categories":{"spam":5},` +
+		`"keywords":{"spam":{"count":2,"weight":2.5,"category":"spam","score":5}}}` + "\n"
+	if stdout.String() != want {
+		t.Fatalf("stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRunSetWeightsCommand(t *testing.T) {
+	fake := &fakeService{}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	exitCode := runWithInput([]string{"-category", "spam", "set-weights", "-"},
+		strings.NewReader("casino=2\nx=y=1.5\n"), stdout, stderr,
+		func(*acor.AhoCorasickArgs) (service, error) { return fake, nil })
+
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d with stderr %q", exitCode, stderr.String())
+	}
+	want := map[string]acor.KeywordWeight{
+		"casino": {Weight: 2, Category: "spam"},
+		"x=y":    {Weight: 1.5, Category: "spam"},
+	}
+	if len(fake.weights) != len(want) || fake.weights["casino"] != want["casino"] || fake.weights["x=y"] != want["x=y"] {
+		t.Fatalf("weights = %v, want %v", fake.weights, want)
+	}
+	if stdout.String() != "{\"count\":2}\n" {
+		t.Fatalf("stdout = %q", stdout.String())
+	}
+}
+
+func TestRunSetWeightsRejectsMalformedPair(t *testing.T) {
+	fake := &fakeService{}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	exitCode := run([]string{"set-weights", "good=1", "bad"}, stdout, stderr,
+		func(*acor.AhoCorasickArgs) (service, error) { return fake, nil })
+
+	if exitCode != 1 {
+		t.Fatalf("expected exit code 1, got %d", exitCode)
+	}
+	if fake.weights != nil {
+		t.Fatalf("a malformed pair must leave every weight unset, got %v", fake.weights)
+	}
+}
+
+func TestRunWeightsCommand(t *testing.T) {
+	fake := &fakeService{weights: map[string]acor.KeywordWeight{"casino": {Weight: 2, Category: "spam"}}}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	exitCode := run([]string{"weights"}, stdout, stderr,
+		func(*acor.AhoCorasickArgs) (service, error) { return fake, nil })
+
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d with stderr %q", exitCode, stderr.String())
+	}
+	if want := `{"weights":{"casino":{"weight":2,"category":"spam"}}}` + "\n"; stdout.String() != want {
+		t.Fatalf("stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRunAliasCommands(t *testing.T) {
+	fake := &fakeService{collection: "rules-20261017"}
+	create := func(*acor.AhoCorasickArgs) (service, error) { return fake, nil }
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-name", "rules-20261017", "set-alias", "rules"}, `{"alias":"rules","collection":"rules-20261017"}`},
+		{[]string{"resolve-alias", "rules"}, `{"alias":"rules","collection":"rules-20261017"}`},
+		{[]string{"delete-alias", "rules"}, `{"deleted":true}`},
+		{[]string{"delete-alias", "rules"}, `{"deleted":false}`},
+	} {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		if exitCode := run(tc.args, stdout, stderr, create); exitCode != 0 {
+			t.Fatalf("%v: exit code %d with stderr %q", tc.args, exitCode, stderr.String())
+		}
+		if stdout.String() != tc.want+"\n" {
+			t.Fatalf("%v: stdout = %q, want %q", tc.args, stdout.String(), tc.want)
+		}
+	}
+
+	stderr := &bytes.Buffer{}
+	if exitCode := run([]string{"resolve-alias", "rules"}, &bytes.Buffer{}, stderr, create); exitCode != 1 {
+		t.Fatalf("resolve-alias of a deleted alias: exit code %d, want 1", exitCode)
+	}
+}
+
+func TestRunAliasFlag(t *testing.T) {
+	var got *acor.AhoCorasickArgs
+	exitCode := run([]string{"-alias", "rules", "-invalidation-poll-interval", "30s", "find", "text"},
+		&bytes.Buffer{}, &bytes.Buffer{}, func(args *acor.AhoCorasickArgs) (service, error) {
+			got = args
+			return &fakeService{}, nil
+		})
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", exitCode)
+	}
+	if got.Alias != "rules" || got.Name != "" || got.InvalidationPollInterval != 30*time.Second {
+		t.Fatalf("args = Alias %q, Name %q, poll %v; want the alias alone with the poll interval",
+			got.Alias, got.Name, got.InvalidationPollInterval)
+	}
+
+	for _, args := range [][]string{
+		{"-name", "rules-blue", "-alias", "rules", "find", "text"},
+		{"-alias", "rules", "migrate"},
+		{"-alias", "rules", "migrate-rollback"},
+	} {
+		stderr := &bytes.Buffer{}
+		exitCode := run(args, &bytes.Buffer{}, stderr, func(*acor.AhoCorasickArgs) (service, error) {
+			t.Fatal("rejected flag combinations must fail before create()")
+			return nil, nil
+		})
+		if exitCode != exitCodeUsage {
+			t.Errorf("%v: exit code %d, want %d (stderr %q)", args, exitCode, exitCodeUsage, stderr.String())
+		}
+	}
+}
+
+func TestRunHistoryCommands(t *testing.T) {
+	at := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	fake := &fakeService{
+		history: []acor.HistoryEntry{{
+			ID: "1792411200000-0", Op: "add", Version: 42, PrevVersion: 7,
+			Added: []string{"apple"}, Removed: []string{}, Writer: "host:1", Time: at,
+		}},
+		revertDiff: &acor.VersionDiff{From: 42, To: 7, Removed: []string{"apple"}},
+	}
+	var got *acor.AhoCorasickArgs
+	create := func(args *acor.AhoCorasickArgs) (service, error) {
+		got = args
+		return fake, nil
+	}
+
+	stdout := &bytes.Buffer{}
+	if exitCode := run([]string{"-limit", "5", "history"}, stdout, &bytes.Buffer{}, create); exitCode != 0 {
+		t.Fatalf("history: exit code %d", exitCode)
+	}
+	want := `{"history":[{"id":"1792411200000-0","op":"add","version":42,"prev_version":7,` +
+		`"added":["apple"],"removed":[],"writer":"host:1","time":"2026-10-19T12:00:00Z"}]}` + "\n"
+	if stdout.String() != want || fake.lastLimit != 5 {
+		t.Fatalf("history: stdout = %q, limit = %d; want %q, 5", stdout.String(), fake.lastLimit, want)
+	}
+	if got.History != nil {
+		t.Errorf("history: History = %+v, want nil without -history", got.History)
+	}
+
+	stdout.Reset()
+	if exitCode := run([]string{"revert", "7"}, stdout, &bytes.Buffer{}, create); exitCode != 0 {
+		t.Fatalf("revert: exit code %d", exitCode)
+	}
+	if want := `{"from":42,"to":7,"added":[],"removed":["apple"]}` + "\n"; stdout.String() != want {
+		t.Fatalf("revert: stdout = %q, want %q", stdout.String(), want)
+	}
+	if fake.lastRevert != 7 || got.History == nil {
+		t.Errorf("revert: version %d, History %+v; want 7 with history recording on", fake.lastRevert, got.History)
+	}
+
+	if exitCode := run([]string{"-history-max-entries", "50", "add", "x"}, &bytes.Buffer{}, &bytes.Buffer{}, create); exitCode != 0 {
+		t.Fatalf("add with -history-max-entries: exit code %d", exitCode)
+	}
+	if got.History == nil || got.History.MaxEntries != 50 {
+		t.Errorf("History = %+v, want MaxEntries 50", got.History)
+	}
+
+	for _, args := range [][]string{
+		{"revert", "latest"},
+		{"-limit", "5", "info"},
+		{"-limit", "-1", "history"},
+		{"-history-max-entries", "-1", "add", "x"},
+	} {
+		stderr := &bytes.Buffer{}
+		if exitCode := run(args, &bytes.Buffer{}, stderr, create); exitCode == 0 {
+			t.Errorf("%v: exit code 0, want a failure", args)
+		}
+	}
+}
+
+func TestRunListAndHas(t *testing.T) {
+	fake := &fakeService{keywords: []string{"apple", "banana", "cherry"}}
+	create := func(*acor.AhoCorasickArgs) (service, error) { return fake, nil }
+
+	stdout := &bytes.Buffer{}
+	if exitCode := run([]string{"has", "banana"}, stdout, &bytes.Buffer{}, create); exitCode != 0 {
+		t.Fatalf("has: exit code %d", exitCode)
+	}
+	if want := `{"has":true}` + "\n"; stdout.String() != want {
+		t.Fatalf("has: stdout = %q, want %q", stdout.String(), want)
+	}
+
+	// Options may follow the command name, and a page that has a successor names it.
+	stdout.Reset()
+	if exitCode := run([]string{"list", "-limit", "2"}, stdout, &bytes.Buffer{}, create); exitCode != 0 {
+		t.Fatalf("list -limit: exit code %d", exitCode)
+	}
+	if want := `{"keywords":["apple","banana"],"next":"banana"}` + "\n"; stdout.String() != want {
+		t.Fatalf("list -limit: stdout = %q, want %q", stdout.String(), want)
+	}
+	stdout.Reset()
+	if exitCode := run([]string{"-cursor", "banana", "-limit", "2", "list"}, stdout, &bytes.Buffer{}, create); exitCode != 0 {
+		t.Fatalf("list -cursor: exit code %d", exitCode)
+	}
+	if want := `{"keywords":["cherry"]}` + "\n"; stdout.String() != want {
+		t.Fatalf("list -cursor: stdout = %q, want %q", stdout.String(), want)
+	}
+
+	// Without -limit, list reads every page.
+	fake.keywords = make([]string, listPageSize+1)
+	for i := range fake.keywords {
+		fake.keywords[i] = fmt.Sprintf("kw%05d", i)
+	}
+	fake.keywordCalls = 0
+	stdout.Reset()
+	if exitCode := run([]string{"list"}, stdout, &bytes.Buffer{}, create); exitCode != 0 {
+		t.Fatalf("list: exit code %d", exitCode)
+	}
+	var body struct{ Keywords []string }
+	if err := json.Unmarshal(stdout.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(body.Keywords, fake.keywords) || fake.keywordCalls != 2 {
+		t.Errorf("list: %d keywords in %d calls, want %d in 2", len(body.Keywords), fake.keywordCalls, len(fake.keywords))
+	}
+
+	for _, args := range [][]string{
+		{"-cursor", "a", "history"},
+		{"has"},
+		{"list", "extra"},
+	} {
+		if exitCode := run(args, &bytes.Buffer{}, &bytes.Buffer{}, create); exitCode != exitCodeUsage {
+			t.Errorf("%v: exit code %d, want %d", args, exitCode, exitCodeUsage)
+		}
+	}
+}
+
+func TestRunCopy(t *testing.T) {
+	var gotSrc, gotDst *acor.AhoCorasickArgs
+	var gotOpts acor.CopyOptions
+	copyCollection = func(_ context.Context, src, dst *acor.AhoCorasickArgs, opts acor.CopyOptions) (*acor.CopyReport, error) {
+		gotSrc, gotDst, gotOpts = src, dst, opts
+		return &acor.CopyReport{Source: src.Name, Destination: dst.Name, Keywords: 3}, nil
+	}
+	t.Cleanup(func() { copyCollection = acor.CopyCollection })
+	create := func(*acor.AhoCorasickArgs) (service, error) {
+		t.Fatal("copy created a service")
+		return nil, nil
+	}
+
+	stdout := &bytes.Buffer{}
+	args := []string{"-addr", "old:6379", "-password", "secret", "-name", "rules",
+		"copy", "--to-addrs", "n1:6379,n2:6379", "-read-only", "-batch-size", "50"}
+	if exitCode := run(args, stdout, &bytes.Buffer{}, create); exitCode != 0 {
+		t.Fatalf("copy: exit code %d", exitCode)
+	}
+	if gotSrc.Addr != "old:6379" || gotSrc.Name != "rules" {
+		t.Errorf("copy source = %+v", gotSrc)
+	}
+	// A destination address replaces the source's connection, password included.
+	if gotDst.Addr != "" || !slices.Equal(gotDst.Addrs, []string{"n1:6379", "n2:6379"}) ||
+		gotDst.Password != "" || gotDst.Name != "rules" {
+		t.Errorf("copy destination = %+v", gotDst)
+	}
+	if !gotOpts.ReadOnlySource || gotOpts.DeleteSource || gotOpts.BatchSize != 50 {
+		t.Errorf("copy options = %+v", gotOpts)
+	}
+	var report acor.CopyReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil || report.Keywords != 3 {
+		t.Errorf("copy: stdout = %q", stdout.String())
+	}
+
+	// Without a destination address, copy stays on the source's Redis: a rename.
+	if exitCode := run([]string{"-addr", "old:6379", "-name", "rules", "copy", "-to-name", "rules-v2", "-delete-source"},
+		&bytes.Buffer{}, &bytes.Buffer{}, create); exitCode != 0 {
+		t.Fatalf("copy -to-name: exit code %d", exitCode)
+	}
+	if gotDst.Addr != "old:6379" || gotDst.Name != "rules-v2" || !gotOpts.DeleteSource {
+		t.Errorf("copy -to-name destination = %+v, options = %+v", gotDst, gotOpts)
+	}
+
+	// Another Redis takes none of the source's credentials, but keeps its TLS.
+	args = []string{"-addr", "old:6379", "-username", "src", "-password", "secret", "-tls",
+		"copy", "-to-addr", "new:6379", "-to-username", "dst"}
+	if exitCode := run(args, &bytes.Buffer{}, &bytes.Buffer{}, create); exitCode != 0 {
+		t.Fatalf("copy -to-username: exit code %d", exitCode)
+	}
+	if gotDst.Username != "dst" || gotDst.Password != "" || gotDst.TLS == nil || gotSrc.Username != "src" {
+		t.Errorf("copy -to-username destination = %+v, source = %+v", gotDst, gotSrc)
+	}
+
+	for _, args := range [][]string{
+		{"-to-name", "x", "info"},
+		{"-alias", "rules", "copy", "-to-addr", "new:6379"},
+		{"copy", "extra"},
+	} {
+		if exitCode := run(args, &bytes.Buffer{}, &bytes.Buffer{}, create); exitCode != exitCodeUsage {
+			t.Errorf("%v: exit code %d, want %d", args, exitCode, exitCodeUsage)
+		}
+	}
+}
+
+func TestRunSync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keywords.txt")
+	if err := os.WriteFile(path, []byte("banana\ncherry\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeService{syncReport: &acor.SyncReport{Added: []string{"cherry"}, Unchanged: 1, DryRun: true}}
+	create := func(*acor.AhoCorasickArgs) (service, error) { return fake, nil }
+
+	// Options may follow the command name, as in `acor sync -f file -dry-run`.
+	stdout := &bytes.Buffer{}
+	if exitCode := run([]string{"-name", "rules", "sync", "-f", path, "-dry-run"}, stdout, &bytes.Buffer{}, create); exitCode != 0 {
+		t.Fatalf("sync: exit code %d", exitCode)
+	}
+	if want := `{"added":["cherry"],"removed":[],"unchanged":1,"dry_run":true}` + "\n"; stdout.String() != want {
+		t.Fatalf("sync: stdout = %q, want %q", stdout.String(), want)
+	}
+	if !slices.Equal(fake.lastKeywords, []string{"banana", "cherry"}) || !fake.lastSyncOpts.DryRun || fake.lastSyncOpts.MaxDeletes != 0 {
+		t.Errorf("sync: keywords %v, opts %+v", fake.lastKeywords, fake.lastSyncOpts)
+	}
+
+	stdout.Reset()
+	exitCode := runWithInput([]string{"-max-deletes", "-1", "sync", "-f", "-"}, strings.NewReader("apple\n"), stdout, &bytes.Buffer{}, create)
+	if exitCode != 0 || !slices.Equal(fake.lastKeywords, []string{"apple"}) || fake.lastSyncOpts.MaxDeletes != -1 {
+		t.Errorf("sync from stdin: exit code %d, keywords %v, opts %+v", exitCode, fake.lastKeywords, fake.lastSyncOpts)
+	}
+
+	// A refused sync still prints the plan it refused.
+	fake.syncReport = &acor.SyncReport{Removed: []string{"apple", "banana"}}
+	fake.err = acor.ErrSyncDeleteLimit
+	stdout.Reset()
+	stderr := &bytes.Buffer{}
+	if exitCode := run([]string{"sync", "-f", path}, stdout, stderr, create); exitCode != 1 {
+		t.Fatalf("refused sync: exit code %d, want 1", exitCode)
+	}
+	if !strings.Contains(stdout.String(), `"removed":["apple","banana"]`) || !strings.Contains(stderr.String(), "MaxDeletes") {
+		t.Errorf("refused sync: stdout %q, stderr %q", stdout.String(), stderr.String())
+	}
+
+	for _, args := range [][]string{
+		{"sync"},
+		{"sync", "extra"},
+		{"-f", path, "info"},
+		{"-max-deletes", "3", "find", "x"},
+		{"-dry-run", "sync"},
+	} {
+		exitCode := run(args, &bytes.Buffer{}, &bytes.Buffer{}, func(*acor.AhoCorasickArgs) (service, error) {
+			t.Fatalf("%v: rejected usage must fail before create()", args)
+			return nil, nil
+		})
+		if exitCode != exitCodeUsage {
+			t.Errorf("%v: exit code %d, want %d", args, exitCode, exitCodeUsage)
+		}
+	}
+}
+
+func TestRunRejectsScoreFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"-threshold", "1", "find", "text"},
+		{"-category", "spam", "score", "text"},
+		{"-category-thresholds", "spam", "score", "text"},
+		{"-max-occurrences", "-1", "score", "text"},
+	} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+
+			exitCode := run(args, stdout, stderr, func(*acor.AhoCorasickArgs) (service, error) {
+				return &fakeService{}, nil
+			})
+
+			if exitCode != exitCodeUsage {
+				t.Fatalf("expected exit code %d, got %d with stderr %q", exitCodeUsage, exitCode, stderr.String())
+			}
+		})
+	}
+}
+
 func TestRunRejectsMigrateFlagsOnOtherCommands(t *testing.T) {
 	for _, args := range [][]string{
 		{"-dry-run", "flush"},
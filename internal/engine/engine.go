@@ -77,6 +77,11 @@ type matchEngine interface {
 	// build and throw away.
 	findSet(text string) []string
 	findIndex(text string) map[string][]int
+	// count and countStream tally occurrences per keyword without materializing
+	// them, by counting state landings during the scan (see countCollector). The
+	// stream variant exists for the same reason matchStream does.
+	count(text string) *Counts
+	countStream(next func() (rune, bool)) *Counts
 	// matchStream pulls runes from next until it returns ok=false, emitting every
 	// match (overlaps included) to emit in scan order. It stops early if emit
 	// returns false. This is the traversal behind streaming, where input arrives
@@ -87,5 +92,14 @@ type matchEngine interface {
 	// find over the same text while it routed through matchStream. Semantics are
 	// identical; only the rune source differs.
 	matchString(text string, emit func(keyword string, start, end int) bool)
+	// matchFrom is matchString resumed mid-stream: it starts the scan at state with
+	// runeIndex runes already seen, and returns where it left off so the next piece
+	// of the stream can continue from there. matchString is matchFrom from
+	// rootState at offset zero. A stop requested by emit returns the position of
+	// the stopping match rather than the end of text.
+	matchFrom(state, runeIndex int, text string, emit func(keyword string, start, end int) bool) (int, int)
+	rootState() int
+	// keywords lists the keywords the automaton was built from; see outputs.
+	keywords() []string
 	info() *InMemoryInfo
 }
@@ -4,6 +4,7 @@
 
 import (
 	"math"
+	"unicode/utf8"
 )
 
 // bandedDFA wraps a Double-Array Trie with precomputed DFA transitions for
@@ -183,6 +184,51 @@ func (e *balancedEngine) findSet(text string) []string {
 	return c.result()
 }
 
+// count tallies occurrences per keyword; see countCollector. It shares scan, and
+// with it the ASCII byte path, since counting needs no offsets either.
+func (e *balancedEngine) count(text string) *Counts {
+	dat := e.banded.dat
+	var c countCollector
+	if dat.size <= datRootPos+1 {
+		return c.result(&dat.out)
+	}
+
+	e.scan(text, func(state int) bool {
+		c.add(&dat.out, state)
+		return true
+	})
+	return c.result(&dat.out)
+}
+
+func (e *balancedEngine) countStream(next func() (rune, bool)) *Counts {
+	dat := e.banded.dat
+	var c countCollector
+	if dat.size <= datRootPos+1 {
+		return c.result(&dat.out)
+	}
+	bd := e.banded
+
+	state := datRootPos
+	for {
+		ch, ok := next()
+		if !ok {
+			break
+		}
+		code, ok := dat.code(ch)
+		if !ok {
+			state = datRootPos
+			continue
+		}
+
+		nx, hasOut := bd.step(state, code)
+		state = nx
+		if hasOut {
+			c.add(&dat.out, state)
+		}
+	}
+	return c.result(&dat.out)
+}
+
 // scan walks text and calls onOutput for every state carrying keywords, stopping
 // when onOutput returns false. It reports whether it stopped early, which is all
 // a presence check needs, and reports no offsets, which lets the ASCII path walk
@@ -273,14 +319,18 @@ func (e *balancedEngine) findIndex(text string) map[string][]int {
 // loop body deliberately duplicates matchStream's instead of sharing a helper
 // that takes a step closure, which would reintroduce the indirect call per rune.
 func (e *balancedEngine) matchString(text string, emit func(keyword string, start, end int) bool) {
+	e.matchFrom(datRootPos, 0, text, emit)
+}
+
+func (e *balancedEngine) rootState() int { return datRootPos }
+
+func (e *balancedEngine) matchFrom(state, runeIndex int, text string, emit func(keyword string, start, end int) bool) (int, int) {
 	dat := e.banded.dat
 	if dat.size <= datRootPos+1 {
-		return
+		return state, runeIndex + utf8.RuneCountInString(text)
 	}
 	bd := e.banded
 
-	state := datRootPos
-	runeIndex := 0
 	for _, ch := range text {
 		code, ok := dat.code(ch)
 		if !ok {
@@ -293,9 +343,10 @@ func (e *balancedEngine) matchString(text string, emit func(keyword string, star
 		state = next
 		runeIndex++
 		if hasOut && !dat.out.emitChain(state, runeIndex, emit) {
-			return
+			return state, runeIndex
 		}
 	}
+	return state, runeIndex
 }
 
 func (e *balancedEngine) matchStream(next func() (rune, bool), emit func(keyword string, start, end int) bool) {
@@ -332,6 +383,10 @@ func (e *balancedEngine) matchStream(next func() (rune, bool), emit func(keyword
 	}
 }
 
+func (e *balancedEngine) keywords() []string {
+	return e.banded.dat.out.keywordList()
+}
+
 func (e *balancedEngine) info() *InMemoryInfo {
 	dat := e.banded.dat
 	if dat.size <= datRootPos+1 {
@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package engine
+
+import (
+	"cmp"
+	"container/heap"
+	"slices"
+)
+
+// countCollector tallies how often a scan lands on each output-carrying state,
+// behind Count and CountStream. A state's output chain is fixed, so landing on it
+// n times adds n to every keyword on the chain: counting landings during the scan
+// and walking each distinct state's chain once at the end replaces a chain walk
+// per landing with a map increment. On match-dense text, where the same few states
+// are reached over and over, that is most of the work Find spends per occurrence.
+//
+// Like setCollector it stays unallocated until the first hit.
+type countCollector struct {
+	hits map[int32]int
+}
+
+// add records a landing on state. A state carrying no outputs costs nothing: the
+// map engine calls this for every character, matched or not.
+func (c *countCollector) add(o *outputs, state int) {
+	if o.own[state] == 0 && o.outLink[state] == outNone {
+		return
+	}
+	if c.hits == nil {
+		c.hits = make(map[int32]int, findResultHint)
+	}
+	c.hits[int32(state)]++ //nolint:gosec // G115: packed engines bound states by requirePackableStateCount; map-engine node ids are memory-bounded.
+}
+
+// result folds the landings into per-keyword counts. Distinct states' chains
+// overlap — every state whose failure path reaches a keyword reports it — so a
+// keyword's count is the sum over all of them.
+func (c *countCollector) result(o *outputs) *Counts {
+	counts := &Counts{keywords: o.keywords}
+	if c.hits == nil {
+		return counts
+	}
+	counts.byID = make(map[int32]int, len(c.hits))
+	for state, n := range c.hits {
+		for s := int(state); s != outNone; s = int(o.outLink[s]) {
+			if id := o.own[s]; id != 0 {
+				counts.byID[id] += n
+			}
+		}
+	}
+	return counts
+}
+
+// Counts holds per-keyword occurrence counts from one scan, overlaps included.
+// Counts are kept by keyword id until read, so Top can select the most frequent
+// keywords without building a string-keyed entry for every keyword that matched.
+type Counts struct {
+	// keywords is the id-indexed table of the automaton that produced the counts.
+	// Build replaces the table rather than editing it, so holding it stays valid
+	// after a rebuild.
+	keywords []string
+	byID     map[int32]int
+}
+
+// Len reports how many distinct keywords matched.
+func (c *Counts) Len() int {
+	return len(c.byID)
+}
+
+// Map returns every matched keyword with its count. It is never nil.
+func (c *Counts) Map() map[string]int {
+	out := make(map[string]int, len(c.byID))
+	for id, n := range c.byID {
+		out[c.keywords[id]] = n
+	}
+	return out
+}
+
+// Top reports the k most frequent keywords to emit, most frequent first, with
+// ties broken by keyword so the order is deterministic. A k <= 0 or past Len
+// reports every keyword.
+//
+// Selection keeps a size-k min-heap over the counts, so with a large dictionary
+// and a text hitting much of it the cost is O(n log k) rather than a full sort.
+func (c *Counts) Top(k int, emit func(keyword string, count int)) {
+	if k <= 0 || k > len(c.byID) {
+		k = len(c.byID)
+	}
+	if k == 0 {
+		return
+	}
+	h := &countHeap{keywords: c.keywords, items: make([]countItem, 0, k)}
+	for id, n := range c.byID {
+		it := countItem{id: id, n: n}
+		if len(h.items) < k {
+			heap.Push(h, it)
+		} else if h.less(h.items[0], it) {
+			h.items[0] = it
+			heap.Fix(h, 0)
+		}
+	}
+	slices.SortFunc(h.items, func(a, b countItem) int {
+		if a.n != b.n {
+			return cmp.Compare(b.n, a.n)
+		}
+		return cmp.Compare(c.keywords[a.id], c.keywords[b.id])
+	})
+	for _, it := range h.items {
+		emit(c.keywords[it.id], it.n)
+	}
+}
+
+type countItem struct {
+	id int32
+	n  int
+}
+
+// countHeap is a min-heap under Top's ranking: its root is the weakest of the
+// current top k, the one a stronger candidate evicts.
+type countHeap struct {
+	keywords []string
+	items    []countItem
+}
+
+// less orders a below b when a ranks lower: a smaller count, or on equal counts
+// the keyword that sorts later.
+func (h *countHeap) less(a, b countItem) bool {
+	if a.n != b.n {
+		return a.n < b.n
+	}
+	return h.keywords[a.id] > h.keywords[b.id]
+}
+
+func (h *countHeap) Len() int           { return len(h.items) }
+func (h *countHeap) Less(i, j int) bool { return h.less(h.items[i], h.items[j]) }
+func (h *countHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *countHeap) Push(x any)         { h.items = append(h.items, x.(countItem)) }
+func (h *countHeap) Pop() any {
+	it := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return it
+}
@@ -5,6 +5,7 @@
 import (
 	"cmp"
 	"slices"
+	"unicode/utf8"
 )
 
 // flatNode is a trie node using a map for goto transitions (flat array pool).
@@ -335,6 +336,77 @@ func (e *speedEngine) findSet(text string) []string {
 	return c.result()
 }
 
+// count tallies occurrences per keyword; see countCollector. Like findSet it
+// keeps the ASCII byte scan, since counting reports no offsets.
+func (e *speedEngine) count(text string) *Counts {
+	var c countCollector
+	if e.dfa == nil {
+		return c.result(&e.out)
+	}
+
+	state := 0
+	alpha := e.alphaSize
+
+	if e.asciiOnly {
+		for i := 0; i < len(text); i++ {
+			ai, ok := e.codeByte(text[i])
+			if !ok {
+				state = 0
+				continue
+			}
+			v := e.dfa[state*alpha+ai]
+			state = int(v &^ hasOutputBit)
+			if v&hasOutputBit != 0 {
+				c.add(&e.out, state)
+			}
+		}
+	} else {
+		for _, ch := range text {
+			ai, ok := e.code(ch)
+			if !ok {
+				state = 0
+				continue
+			}
+			v := e.dfa[state*alpha+ai]
+			state = int(v &^ hasOutputBit)
+			if v&hasOutputBit != 0 {
+				c.add(&e.out, state)
+			}
+		}
+	}
+
+	return c.result(&e.out)
+}
+
+func (e *speedEngine) countStream(next func() (rune, bool)) *Counts {
+	var c countCollector
+	if e.dfa == nil {
+		return c.result(&e.out)
+	}
+
+	state := 0
+	alpha := e.alphaSize
+
+	for {
+		ch, ok := next()
+		if !ok {
+			break
+		}
+		ai, ok := e.code(ch)
+		if !ok {
+			state = 0
+			continue
+		}
+		v := e.dfa[state*alpha+ai]
+		state = int(v &^ hasOutputBit)
+		if v&hasOutputBit != 0 {
+			c.add(&e.out, state)
+		}
+	}
+
+	return c.result(&e.out)
+}
+
 // contains reports presence and stops at the first hit. It is the byte/rune scan
 // with the output lookup dropped, since hasOutputBit already says whether the
 // state carries keywords.
@@ -410,12 +482,16 @@ func (e *speedEngine) findIndex(text string) map[string][]int {
 // matchString is matchStream over an in-memory string; see the matchEngine
 // interface for why the loop is duplicated rather than shared through a closure.
 func (e *speedEngine) matchString(text string, emit func(keyword string, start, end int) bool) {
+	e.matchFrom(0, 0, text, emit)
+}
+
+func (e *speedEngine) rootState() int { return 0 }
+
+func (e *speedEngine) matchFrom(state, runeIndex int, text string, emit func(keyword string, start, end int) bool) (int, int) {
 	if e.dfa == nil {
-		return
+		return state, runeIndex + utf8.RuneCountInString(text)
 	}
 
-	state := 0
-	runeIndex := 0
 	alpha := e.alphaSize
 
 	for _, ch := range text {
@@ -432,9 +508,10 @@ func (e *speedEngine) matchString(text string, emit func(keyword string, start,
 			continue
 		}
 		if !e.out.emitChain(state, runeIndex, emit) {
-			return
+			return state, runeIndex
 		}
 	}
+	return state, runeIndex
 }
 
 func (e *speedEngine) matchStream(next func() (rune, bool), emit func(keyword string, start, end int) bool) {
@@ -469,6 +546,10 @@ func (e *speedEngine) matchStream(next func() (rune, bool), emit func(keyword st
 	}
 }
 
+func (e *speedEngine) keywords() []string {
+	return e.out.keywordList()
+}
+
 func (e *speedEngine) info() *InMemoryInfo {
 	if e.dfa == nil {
 		return &InMemoryInfo{Preset: e.preset}
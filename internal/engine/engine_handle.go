@@ -60,6 +60,35 @@ func (e *Engine) MatchString(text string, emit func(keyword string, start, end i
 	e.impl.matchString(text, emit)
 }
 
+// Position is where a resumable scan left off: the automaton state and how many
+// runes it has consumed. It is only meaningful to the Engine that produced it.
+type Position struct {
+	state int
+	runes int
+}
+
+// Start returns the position at the beginning of a stream.
+func (e *Engine) Start() Position {
+	return Position{state: e.impl.rootState()}
+}
+
+// Runes reports how many runes have been consumed up to p.
+func (p Position) Runes() int {
+	return p.runes
+}
+
+// MatchFrom is MatchString over the next piece of a stream that began at
+// e.Start(): it resumes at pos, reports matches with offsets counted from the
+// start of the stream, and returns the position to pass with the following piece.
+// A keyword split across two pieces is found when the second is scanned.
+//
+// Pieces must hold whole runes; a rune split across them decodes as two invalid
+// ones, as it would in a string.
+func (e *Engine) MatchFrom(pos Position, text string, emit func(keyword string, start, end int) bool) Position {
+	pos.state, pos.runes = e.impl.matchFrom(pos.state, pos.runes, text, emit)
+	return pos
+}
+
 // Contains reports whether text contains any keyword, stopping at the first hit.
 func (e *Engine) Contains(text string) bool {
 	// An engine that can answer presence without positions does so directly; the
@@ -86,6 +115,18 @@ func (e *Engine) FindSet(text string) []string {
 	return e.impl.findSet(text)
 }
 
+// Count tallies how often each keyword occurs in text, overlaps included, without
+// materializing a match or a keyword string per occurrence. Read the result as a
+// map, or select the most frequent keywords with Counts.Top.
+func (e *Engine) Count(text string) *Counts {
+	return e.impl.count(text)
+}
+
+// CountStream is Count over runes pulled from next, as Stream pulls them.
+func (e *Engine) CountStream(next func() (rune, bool)) *Counts {
+	return e.impl.countStream(next)
+}
+
 // Stream pulls runes from next (rune-global offsets accumulate across calls) and
 // reports every match to emit until next is exhausted or emit returns false.
 // It lets callers scan an io.Reader without materializing the whole input.
@@ -93,6 +134,14 @@ func (e *Engine) Stream(next func() (rune, bool), emit func(keyword string, star
 	e.impl.matchStream(next, emit)
 }
 
+// Keywords returns the keywords the automaton was built from, in no particular
+// order. The slice is the caller's: it is copied from the engine's own table, so
+// a caller can merge several engines' keyword sets without holding their builds'
+// input maps alive.
+func (e *Engine) Keywords() []string {
+	return e.impl.keywords()
+}
+
 // Info returns statistics about the built automaton.
 func (e *Engine) Info() *InMemoryInfo {
 	return e.impl.info()
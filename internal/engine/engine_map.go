@@ -2,6 +2,8 @@
 
 package engine
 
+import "unicode/utf8"
+
 // mapNode is a trie node using Go maps for children (sparse representation).
 type mapNode struct {
 	children map[rune]int
@@ -175,6 +177,69 @@ func (e *memEfficientEngine) findSet(text string) []string {
 	return c.result()
 }
 
+// count tallies occurrences per keyword; see countCollector.
+func (e *memEfficientEngine) count(text string) *Counts {
+	var c countCollector
+	if len(e.trie.nodes) <= 1 {
+		return c.result(&e.trie.out)
+	}
+
+	state := 0
+	for _, ch := range text {
+		if e.bloom.skipAtRoot(state == 0, ch) {
+			continue
+		}
+
+		for {
+			if next, ok := e.trie.nodes[state].children[ch]; ok {
+				state = next
+				break
+			}
+			if state == 0 {
+				break
+			}
+			state = e.trie.nodes[state].fail
+		}
+
+		c.add(&e.trie.out, state)
+	}
+
+	return c.result(&e.trie.out)
+}
+
+func (e *memEfficientEngine) countStream(next func() (rune, bool)) *Counts {
+	var c countCollector
+	if len(e.trie.nodes) <= 1 {
+		return c.result(&e.trie.out)
+	}
+
+	state := 0
+	for {
+		ch, ok := next()
+		if !ok {
+			break
+		}
+		if e.bloom.skipAtRoot(state == 0, ch) {
+			continue
+		}
+
+		for {
+			if nx, ok := e.trie.nodes[state].children[ch]; ok {
+				state = nx
+				break
+			}
+			if state == 0 {
+				break
+			}
+			state = e.trie.nodes[state].fail
+		}
+
+		c.add(&e.trie.out, state)
+	}
+
+	return c.result(&e.trie.out)
+}
+
 func (e *memEfficientEngine) findIndex(text string) map[string][]int {
 	if len(e.trie.nodes) <= 1 {
 		return map[string][]int{}
@@ -211,13 +276,16 @@ func (e *memEfficientEngine) findIndex(text string) map[string][]int {
 // matchString is matchStream over an in-memory string; see the matchEngine
 // interface for why the loop is duplicated rather than shared through a closure.
 func (e *memEfficientEngine) matchString(text string, emit func(keyword string, start, end int) bool) {
+	e.matchFrom(0, 0, text, emit)
+}
+
+func (e *memEfficientEngine) rootState() int { return 0 }
+
+func (e *memEfficientEngine) matchFrom(state, runeIndex int, text string, emit func(keyword string, start, end int) bool) (int, int) {
 	if len(e.trie.nodes) <= 1 {
-		return
+		return state, runeIndex + utf8.RuneCountInString(text)
 	}
 
-	state := 0
-	runeIndex := 0
-
 	for _, ch := range text {
 		if e.bloom.skipAtRoot(state == 0, ch) {
 			runeIndex++
@@ -237,9 +305,10 @@ func (e *memEfficientEngine) matchString(text string, emit func(keyword string,
 
 		runeIndex++
 		if !e.trie.out.emitChain(state, runeIndex, emit) {
-			return
+			return state, runeIndex
 		}
 	}
+	return state, runeIndex
 }
 
 func (e *memEfficientEngine) matchStream(next func() (rune, bool), emit func(keyword string, start, end int) bool) {
@@ -278,6 +347,10 @@ func (e *memEfficientEngine) matchStream(next func() (rune, bool), emit func(key
 	}
 }
 
+func (e *memEfficientEngine) keywords() []string {
+	return e.trie.out.keywordList()
+}
+
 func (e *memEfficientEngine) info() *InMemoryInfo {
 	return &InMemoryInfo{
 		Keywords:    e.trie.out.keywordCount(),
@@ -314,6 +314,28 @@ func TestRebuildResetsInfo(t *testing.T) {
 	}
 }
 
+func TestKeywords_ListsTheBuild(t *testing.T) {
+	for _, p := range allPresets {
+		t.Run(p.String(), func(t *testing.T) {
+			e := New(p)
+			if got := e.Keywords(); got == nil || len(got) != 0 {
+				t.Errorf("unbuilt Keywords = %#v, want empty and non-nil", got)
+			}
+			e.Build(keywordSet("he", "she", "hers", "日本"))
+			got := e.Keywords()
+			sort.Strings(got)
+			if want := []string{"he", "hers", "she", "日本"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("Keywords = %v, want %v", got, want)
+			}
+			// The result is a copy: scribbling on it leaves the engine alone.
+			got[0] = "x"
+			if found := e.Find("he"); len(found) != 1 || found[0] != "he" {
+				t.Errorf("Find after editing Keywords' result = %v, want [he]", found)
+			}
+		})
+	}
+}
+
 func TestStream_EarlyStop(t *testing.T) {
 	kws := keywordSet("ab")
 	for _, p := range allPresets {
@@ -339,3 +361,113 @@ func TestFindMatches_Empty(t *testing.T) {
 		}
 	}
 }
+
+// countFind is the per-occurrence baseline Count must agree with.
+func countFind(e *Engine, text string) map[string]int {
+	want := make(map[string]int)
+	for _, kw := range e.Find(text) {
+		want[kw]++
+	}
+	return want
+}
+
+// TestCountAgreesWithFind pins Count and CountStream against tallying Find on the
+// paths that differ between engines: the ASCII byte scan, multibyte text, runes
+// outside the alphabet, and suffix-nested chains where one landing reports many
+// keywords.
+func TestCountAgreesWithFind(t *testing.T) {
+	nested := make([]string, 0, 16)
+	for i := 1; i <= 16; i++ {
+		nested = append(nested, strings.Repeat("a", i))
+	}
+	cases := []struct {
+		keywords []string
+		texts    []string
+	}{
+		{[]string{"he", "she", "his", "hers"}, []string{"ushers and his hers", "no match at all", "", "☃ she ☃ she"}},
+		{[]string{"한국", "안녕", "국"}, []string{"안녕 한국 한국", "hello", "🦊안녕🦊"}},
+		{nested, []string{strings.Repeat("a", 100), "ab" + strings.Repeat("a", 20)}},
+	}
+	for _, tc := range cases {
+		kws := keywordSet(tc.keywords...)
+		for _, p := range allPresets {
+			e := New(p)
+			e.Build(kws)
+			for _, txt := range tc.texts {
+				want := countFind(e, txt)
+				if got := e.Count(txt).Map(); !reflect.DeepEqual(got, want) {
+					t.Errorf("preset %v: Count(%q) = %v, want %v", p, txt, got, want)
+				}
+				if got := e.CountStream(stringRuneSource(txt)).Map(); !reflect.DeepEqual(got, want) {
+					t.Errorf("preset %v: CountStream(%q) = %v, want %v", p, txt, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestCountTop(t *testing.T) {
+	kws := keywordSet("a", "b", "c", "d")
+	text := "a b b c c c d d d"
+	for _, p := range allPresets {
+		e := New(p)
+		e.Build(kws)
+		counts := e.Count(text)
+		if counts.Len() != 4 {
+			t.Errorf("preset %v: Len = %d, want 4", p, counts.Len())
+		}
+
+		var got []string
+		counts.Top(3, func(keyword string, n int) {
+			got = append(got, fmt.Sprintf("%s=%d", keyword, n))
+		})
+		// c and d tie at 3 and are ordered by keyword; b takes the last slot from a.
+		if want := []string{"c=3", "d=3", "b=2"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("preset %v: Top(3) = %v, want %v", p, got, want)
+		}
+
+		got = got[:0]
+		counts.Top(0, func(keyword string, _ int) { got = append(got, keyword) })
+		if want := []string{"c", "d", "b", "a"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("preset %v: Top(0) = %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestCount_Empty(t *testing.T) {
+	for _, p := range allPresets {
+		e := New(p)
+		e.Build(keywordSet())
+		if got := e.Count("anything").Map(); got == nil || len(got) != 0 {
+			t.Errorf("preset %v: empty automaton Count = %#v, want empty non-nil", p, got)
+		}
+	}
+}
+
+// TestMatchFromAcrossSplits feeds a text in two pieces at every rune boundary and
+// checks the resumed scan reports exactly what one MatchString over the whole text
+// does, including keywords that straddle the split.
+func TestMatchFromAcrossSplits(t *testing.T) {
+	kws := keywordSet("he", "she", "hers", "한국어", "ab")
+	text := "ushers 한국어 abab ☃ she"
+	for _, p := range allPresets {
+		e := New(p)
+		e.Build(kws)
+		want := collectMatches(e, text)
+		for split := range text {
+			var got []match
+			emit := func(keyword string, start, end int) bool {
+				got = append(got, match{Keyword: keyword, Start: start, End: end})
+				return true
+			}
+			pos := e.MatchFrom(e.Start(), text[:split], emit)
+			pos = e.MatchFrom(pos, text[split:], emit)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("preset %v, split at byte %d: got %v, want %v", p, split, got, want)
+			}
+			if n := utf8.RuneCountInString(text); pos.Runes() != n {
+				t.Errorf("preset %v, split at byte %d: Runes = %d, want %d", p, split, pos.Runes(), n)
+			}
+		}
+	}
+}
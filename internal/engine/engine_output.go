@@ -91,6 +91,14 @@ func (o *outputs) keywordCount() int {
 	return len(o.keywords) - 1
 }
 
+// keywordList returns a copy of every keyword in the table, in id order.
+func (o *outputs) keywordList() []string {
+	if len(o.keywords) <= 1 {
+		return []string{}
+	}
+	return append([]string(nil), o.keywords[1:]...)
+}
+
 // memoryBytes estimates the table's footprint: an id and a link per state, a
 // string header and a rune length per keyword.
 func (o *outputs) memoryBytes() int64 {
@@ -93,7 +93,7 @@
 // # Schema Versions
 //
 // V2 (SchemaVersion: 2, default): Optimized schema consolidating a collection
-// into a fixed set of at most 3 keys, whatever the dictionary size. Recommended
+// into a fixed set of at most 4 keys, whatever the dictionary size. Recommended
 // for every use case. Uses Lua scripts for atomic operations.
 //
 // V1 (SchemaVersion: 1): Deprecated legacy schema spread over three fixed Redis keys
@@ -157,6 +157,7 @@
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -202,8 +203,24 @@ func resolveRollbackTimeout(d time.Duration) time.Duration {
 	// ErrRedisRingAddrs is returned when ring mode is specified without at least one
 	// shard address in the RingAddrs field.
 	ErrRedisRingAddrs = errors.New("redis ring requires at least one shard address")
+	// ErrRedisTLSConflict is returned when both TLSConfig and TLS are set, since
+	// either one configures the whole of TLS.
+	ErrRedisTLSConflict = errors.New("redis TLSConfig and TLS are mutually exclusive")
+	// ErrRedisTLSKeyPair is returned when TLSOptions sets one of CertFile and
+	// KeyFile without the other.
+	ErrRedisTLSKeyPair = errors.New("redis TLS CertFile and KeyFile must be set together")
+	// ErrConnectionConflict is returned by Create when Connection is set together
+	// with a field that configures a Redis client of the instance's own, which
+	// the shared client would silently ignore, and by NewConnection for args
+	// that set Connection.
+	ErrConnectionConflict = errors.New("redis Connection cannot be combined with client settings")
+	// ErrConnectionClosed is returned by Create for a Connection already closed,
+	// by a second Connection.Close, and by Watch on an instance whose
+	// Connection closed.
+	ErrConnectionClosed = errors.New("redis connection was already closed")
 	// ErrInvalidName is returned when the collection name contains characters
-	// that conflict with internal delimiters (e.g., ':').
+	// that conflict with internal delimiters (e.g., ':'). The alias methods also
+	// return it for an empty alias.
 	ErrInvalidName = errors.New("collection name must not contain ':'")
 )
 
@@ -274,6 +291,40 @@ type AhoCorasickArgs struct {
 	// fails where the same address in Addr would not. Ring, sentinel, and
 	// standalone all honor it.
 	DB int
+	// Username is the ACL user to authenticate as, with Password, on Redis 6 and
+	// later. Left empty, Password alone authenticates the default user.
+	Username string
+	// CredentialsProvider, when set, is called for each new connection to a
+	// Redis node and returns the username and password it authenticates with,
+	// in place of Username and Password. Use it where passwords rotate: a
+	// connection opened after the rotation picks up the new one without
+	// reopening the instance. An error fails that connection's dial.
+	//
+	// It applies to data nodes in every topology. The sentinels in sentinel mode
+	// authenticate with SentinelUsername and SentinelPassword instead.
+	CredentialsProvider func(ctx context.Context) (username, password string, err error)
+	// SentinelUsername and SentinelPassword authenticate the connections to the
+	// sentinels in sentinel mode, which are separate from the master and
+	// replicas Username and Password reach. Left empty, the sentinels are
+	// contacted without authentication. Other topologies ignore both.
+	SentinelUsername string
+	SentinelPassword string
+	// TLSConfig, when set, makes every connection TLS: to standalone servers,
+	// cluster nodes, ring shards, sentinel masters and replicas, and the
+	// sentinels themselves. Left without a ServerName, each connection verifies
+	// the host of the address it dials. Mutually exclusive with TLS.
+	TLSConfig *tls.Config
+	// TLS builds the TLSConfig from files, for callers that have PEM paths
+	// rather than a *tls.Config. A non-nil TLS turns TLS on even when every
+	// field is empty, trusting the system roots. Setting it with TLSConfig is
+	// ErrRedisTLSConflict; a file that does not load fails Create.
+	TLS *TLSOptions
+	// Connection, when set, is the Redis client the instance uses, shared with
+	// the other instances opened on it, in place of a client of its own. See
+	// Connection. The instance neither configures nor closes it, so every field
+	// above, and the knobs below, must be left unset: Create returns
+	// ErrConnectionConflict otherwise.
+	Connection *Connection
 
 	// The following knobs tune connection resilience. They are passed straight
 	// through to go-redis; a zero value means "use the go-redis default", as
@@ -295,8 +346,13 @@ type AhoCorasickArgs struct {
 	// master/replica in sentinel mode.
 	PoolSize int
 	// Name identifies the pattern collection. All keywords added to this instance
-	// are stored under this namespace in Redis. Required.
+	// are stored under this namespace in Redis. Required unless Alias is set.
 	Name string
+	// Alias opens the collection an alias points at instead of a named one; see
+	// SetAlias. The instance follows the alias: when it is repointed, reads and
+	// writes switch to the new collection without reopening. Mutually exclusive
+	// with Name, and requires the V2 schema. Works with or without a Preset.
+	Alias string
 	// Debug sends the default logger's output to stdout. It has no effect when
 	// Logger is set: a custom logger replaces the default one entirely, so where
 	// its output goes is that implementation's business, not this field's.
@@ -306,7 +362,7 @@ type AhoCorasickArgs struct {
 	// writes to io.Discard.
 	Logger Logger
 	// SchemaVersion specifies the storage schema to use:
-	//   - 0 or 2: V2 schema (default, optimized, at most 3 keys — see SchemaV2)
+	//   - 0 or 2: V2 schema (default, optimized, at most 4 keys — see SchemaV2)
 	//   - 1: V1 schema (deprecated and read-only — see SchemaV1)
 	//
 	// Any other value is rejected by Create.
@@ -358,6 +414,17 @@ type AhoCorasickArgs struct {
 	// When unset (zero), the original Aho-Corasick engine is used.
 	Preset Preset
 
+	// History records every write this instance commits in a bounded history
+	// beside the collection, so History, DiffVersions, and RevertTo can see and
+	// undo them. Nil (the default) records nothing. Each entry holds the keywords
+	// the write added and removed, the version it committed, and the writer; it
+	// is appended in the same atomic step as the write.
+	//
+	// Recording is per instance: a write from an instance without History leaves
+	// a gap that DiffVersions and RevertTo refuse to reach across, so enable it on
+	// every writer of a collection. Requires the V2 schema (ErrHistoryRequiresV2).
+	History *HistoryOptions
+
 	// InvalidationPollInterval enables a background safety net for the Preset
 	// engine: every interval it compares the collection's stored version against
 	// the local one and reloads if they differ. Cross-instance invalidation is
@@ -366,8 +433,20 @@ type AhoCorasickArgs struct {
 	// next local write. This poll bounds that staleness to the interval.
 	//
 	// Disabled by default (zero). Recommended for multi-instance deployments
-	// (e.g. 30 * time.Second). Only applies to Preset mode; ignored otherwise.
+	// (e.g. 30 * time.Second). Only applies to Preset mode, and to instances
+	// opened by Alias, which re-read the alias at the same interval in case its
+	// repointing message was the one dropped; ignored otherwise.
 	InvalidationPollInterval time.Duration
+
+	// ExpirySweepInterval is how often this instance looks for keywords whose TTL
+	// (see AddWithTTL) has run out and removes them. Every instance runs a sweeper,
+	// but they share a lock in Redis, so each collection is swept by one of them
+	// per interval. Sweeping only reclaims storage: an expired keyword stops
+	// matching at its expiry whether or not it has been swept.
+	//
+	// Zero means DefaultExpirySweepInterval; a negative value turns this
+	// instance's sweeper off. Ignored for V1, which stores no expiries.
+	ExpirySweepInterval time.Duration
 }
 
 // AhoCorasick represents an Aho-Corasick automaton backed by Redis.
@@ -396,6 +475,9 @@ type AhoCorasick struct {
 	closeOnce sync.Once
 	mode      backendMode
 	closeFn   func() error
+	history   *historyLog // nil unless args.History is set
+	// stopSweeper stops the expiry sweeper; nil when none runs.
+	stopSweeper context.CancelFunc
 }
 
 // AhoCorasickInfo contains statistics about the Aho-Corasick automaton.
@@ -415,6 +497,9 @@ type AhoCorasickInfo struct {
 	// TrieDepth is the maximum trie depth.
 	// Zero when using the original non-preset engine.
 	TrieDepth int
+	// Tags counts the keywords carrying each tag (see AddWithTags), leaving out
+	// any whose TTL has run out. nil when no keyword is tagged.
+	Tags map[string]int
 }
 
 // Create initializes and returns a new AhoCorasick instance connected to Redis.
@@ -450,8 +535,8 @@ func Create(args *AhoCorasickArgs) (*AhoCorasick, error) {
 // or expired ctx fails the construction.
 //
 // ctx does not bound the returned instance's lifetime. Background work that must
-// outlive construction — the Pub/Sub subscribe, the invalidation listener, and the
-// version poller — runs on an internal context tied to Close instead, so passing a
+// outlive construction — the Pub/Sub subscribe, the invalidation listener, the
+// version poller, and the expiry sweeper — runs on an internal context tied to Close instead, so passing a
 // request-scoped ctx here cannot leave a live instance whose listener has silently
 // stopped. That also means ctx does not bound the subscribe itself.
 // Per-operation cancellation is what the *Context methods (FindContext, AddContext,
@@ -460,12 +545,13 @@ func CreateContext(ctx context.Context, args *AhoCorasickArgs) (*AhoCorasick, er
 	if args == nil {
 		return nil, ErrNilArgs
 	}
-	if strings.Contains(args.Name, ":") {
+	if strings.Contains(args.Name, ":") || strings.Contains(args.Alias, ":") {
 		return nil, ErrInvalidName
 	}
 
 	// --- Preset-Optimized Redis mode ---
-	if args.Preset != PresetNone && args.Preset != presetDefault {
+	preset := args.Preset != PresetNone && args.Preset != presetDefault
+	if preset {
 		if !args.hasAnyRedisConfig() {
 			return nil, ErrPresetRequiresRedis
 		}
@@ -477,11 +563,27 @@ func CreateContext(ctx context.Context, args *AhoCorasickArgs) (*AhoCorasick, er
 		if args.EnableCache {
 			return nil, ErrCacheWithPreset
 		}
-		return createPresetRedis(ctx, args)
 	}
 
-	// --- Branch 3: Original mode (unchanged) ---
-	return createOriginal(ctx, args)
+	var ac *AhoCorasick
+	var err error
+	switch {
+	case args.Alias != "":
+		// --- Alias: either mode, over whichever collection the alias names ---
+		ac, err = createAlias(ctx, args, preset)
+	case preset:
+		ac, err = createPresetRedis(ctx, args)
+	default:
+		// --- Branch 3: Original mode (unchanged) ---
+		ac, err = createOriginal(ctx, args)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Every mode's background work but this one starts inside its constructor;
+	// the sweeper is the same for all of them, so it starts here once.
+	ac.startExpirySweeper(args.ExpirySweepInterval)
+	return ac, nil
 }
 
 // newLogger builds the instance's Logger. A supplied Logger wins outright, which is
@@ -503,7 +605,7 @@ func newLogger(args *AhoCorasickArgs) Logger {
 // engine. ctx covers setup only; newRedisBacked keeps its own Background-derived
 // context for the long-lived Pub/Sub listener and reloads, per CreateContext.
 func createPresetRedis(ctx context.Context, args *AhoCorasickArgs) (*AhoCorasick, error) {
-	rbAC, err := newRedisBacked(ctx, args)
+	rbAC, err := newRedisBacked(ctx, args, &cacheStats{})
 	if err != nil {
 		return nil, err
 	}
@@ -513,11 +615,15 @@ func createPresetRedis(ctx context.Context, args *AhoCorasickArgs) (*AhoCorasick
 		logger:        newLogger(args),
 		schemaVersion: SchemaV2,
 		ops:           rbAC,
-		// Same counters the engine records into, not a second set: newRedisBacked owns
-		// them because it builds the first automaton before this struct exists.
+		// Shared, not owned: rbAC.Close closes it. The per-keyword metadata beside
+		// the trie (weights) is read and written through it in every mode.
+		storage: rbAC.storage,
+		// Same counters the engine records into, not a second set: they go to
+		// newRedisBacked because it builds the first automaton before this struct exists.
 		stats:         rbAC.stats,
 		mode:          modePresetRedis,
 		caseSensitive: args.CaseSensitive,
+		history:       rbAC.history,
 		ctx:           context.Background(),
 		cancel:        func() {},
 		closeFn:       rbAC.Close,
@@ -528,7 +634,7 @@ func createPresetRedis(ctx context.Context, args *AhoCorasickArgs) (*AhoCorasick
 func createOriginal(ctx context.Context, args *AhoCorasickArgs) (*AhoCorasick, error) {
 	logger := newLogger(args)
 
-	redisClient, err := newRedisClient(args)
+	redisClient, storage, err := openRedis(args)
 	if err != nil {
 		return nil, err
 	}
@@ -539,16 +645,18 @@ func createOriginal(ctx context.Context, args *AhoCorasickArgs) (*AhoCorasick, e
 		schemaVersion = SchemaV2
 	case SchemaV1:
 	default:
-		_ = redisClient.Close()
+		_ = storage.Close()
 		return nil, fmt.Errorf("unsupported schema version: %d", schemaVersion)
 	}
 
 	if args.EnableCache && schemaVersion == SchemaV1 {
-		_ = redisClient.Close()
+		_ = storage.Close()
 		return nil, ErrCacheRequiresV2
 	}
-
-	storage := newRedisStorage(redisClient)
+	if args.History != nil && schemaVersion == SchemaV1 {
+		_ = storage.Close()
+		return nil, ErrHistoryRequiresV2
+	}
 
 	var cache *trieCache
 	if args.EnableCache {
@@ -567,6 +675,7 @@ func createOriginal(ctx context.Context, args *AhoCorasickArgs) (*AhoCorasick, e
 		cache:         cache,
 		stats:         &cacheStats{},
 		mode:          modeOriginal,
+		history:       newHistoryLog(args.History),
 	}
 	ac.rollbackTimeout = resolveRollbackTimeout(args.RollbackTimeout)
 	ac.caseSensitive = args.CaseSensitive
@@ -575,7 +684,7 @@ func createOriginal(ctx context.Context, args *AhoCorasickArgs) (*AhoCorasick, e
 	ac.ctx, ac.cancel = context.WithCancel(context.Background()) //nolint:gosec // G118: storing cancel func is intentional for lifecycle management
 
 	if schemaVersion == SchemaV2 {
-		ac.ops = ac.newV2Ops(cache)
+		ac.ops = ac.newV2Ops(ac.name, cache)
 	} else {
 		ac.ops = ac.newV1Ops()
 	}
@@ -613,17 +722,7 @@ func (ac *AhoCorasick) SchemaVersion() int {
 // blocked on Redis.
 func (ac *AhoCorasick) init(ctx context.Context) error {
 	if ac.schemaVersion == SchemaV2 {
-		exists, err := ac.storage.Exists(ctx, trieKey(ac.name))
-		if err != nil {
-			return fmt.Errorf("failed to check trie key: %w", err)
-		}
-		if exists == 0 {
-			err := ac.storage.HSet(ctx, trieKey(ac.name), emptyTrieFields())
-			if err != nil {
-				return fmt.Errorf("failed to initialize V2 trie: %w", err)
-			}
-		}
-		return nil
+		return initV2Trie(ctx, ac.storage, ac.name)
 	}
 
 	prefixKey := prefixKey(ac.name)
@@ -637,14 +736,35 @@ func (ac *AhoCorasick) init(ctx context.Context) error {
 	return nil
 }
 
+// initV2Trie creates the named collection's empty V2 trie hash unless it exists.
+func initV2Trie(ctx context.Context, storage kvStorage, name string) error {
+	exists, err := storage.Exists(ctx, trieKey(name))
+	if err != nil {
+		return fmt.Errorf("failed to check trie key: %w", err)
+	}
+	if exists == 0 {
+		err := storage.HSet(ctx, trieKey(name), emptyTrieFields())
+		if err != nil {
+			return fmt.Errorf("failed to initialize V2 trie: %w", err)
+		}
+	}
+	return nil
+}
+
 // Close closes the Redis client connection. Always call Close when done with
 // an AhoCorasick instance to release resources. Returns ErrRedisAlreadyClosed
 // if the connection was already closed.
+//
+// An instance opened on AhoCorasickArgs.Connection leaves the shared client
+// open and only gives up its subscriptions on it.
 func (ac *AhoCorasick) Close() error {
 	var closeErr error
 	alreadyClosed := true
 	ac.closeOnce.Do(func() {
 		alreadyClosed = false
+		if ac.stopSweeper != nil {
+			ac.stopSweeper()
+		}
 		if ac.cancel != nil {
 			ac.cancel()
 		}
@@ -658,15 +778,19 @@ func (ac *AhoCorasick) Close() error {
 	return closeErr
 }
 
-func (ac *AhoCorasick) newV2Ops(cache *trieCache) operations {
+// newV2Ops builds the V2 strategy for the named collection. The name is a
+// parameter rather than ac.name because an instance opened by Alias serves
+// whichever collection the alias currently names.
+func (ac *AhoCorasick) newV2Ops(name string, cache *trieCache) operations {
 	return &v2Operations{
 		storage:       ac.storage,
 		client:        ac.redisClient,
-		name:          ac.name,
+		name:          name,
 		cache:         cache,
 		logger:        ac.logger,
 		caseSensitive: ac.caseSensitive,
 		stats:         ac.stats,
+		history:       ac.history,
 		// The memo shares the same counters, so an uncached V2 instance still reports a
 		// hit rate: it skips the rebuild even though the freshness read remains.
 		engines: engineMemo{stats: ac.stats},
@@ -676,6 +800,7 @@ func (ac *AhoCorasick) newV2Ops(cache *trieCache) operations {
 func (ac *AhoCorasick) newV1Ops() operations {
 	return &v1Operations{
 		storage:         ac.storage,
+		client:          ac.redisClient,
 		name:            ac.name,
 		logger:          ac.logger,
 		caseSensitive:   ac.caseSensitive,
@@ -771,8 +896,9 @@ func (ac *AhoCorasick) SuggestIndex(input string) (map[string][]int, error) {
 //
 // Only the original V1/V2 Redis-backed mode dumps anything. Preset mode is a no-op —
 // not for want of Redis trie state, which it keeps like V2 does, but because it reads
-// that state through its own engine and createPresetRedis leaves the storage handle
-// these dumps go through unset.
+// that state through its own engine, which these dumps know nothing about. An
+// instance opened by Alias is a no-op too; open the target collection by Name to
+// dump it.
 func (ac *AhoCorasick) Debug() {
 	if ac.mode == modeOriginal && ac.schemaVersion == SchemaV2 {
 		ac.debugV2()
@@ -0,0 +1,436 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	matchengine "github.com/skyoo2003/acor/internal/engine"
+)
+
+const (
+	// fieldAliasCollection is the aliasKey hash field naming the alias's target.
+	fieldAliasCollection = "collection"
+
+	// aliasDrainDelay is how long a collection an alias moved away from stays open
+	// after the switch, so operations that loaded it just before can finish.
+	aliasDrainDelay = 5 * time.Second
+)
+
+// SetAlias points alias at the collection this instance serves, creating the
+// alias or repointing it in one atomic write. Every instance opened with
+// AhoCorasickArgs.Alias set to alias is told through the alias's invalidation
+// channel and switches to this collection without being reopened.
+//
+// That makes blue/green rebuilds a three-step affair: fill a fresh collection
+// ("rules-20261017"), call SetAlias("rules") on it, then Flush the collection the
+// alias used to name once nothing opens it by Name any more. Readers never see a
+// half-built keyword set.
+//
+// The alias name must not itself be a collection; SetAlias returns
+// ErrAliasConflict rather than shadow one. Creating a collection under an
+// existing alias's name is not checked, so keep the two apart by convention (a
+// date or color suffix on collection names does it).
+func (ac *AhoCorasick) SetAlias(alias string) error {
+	return ac.SetAliasContext(ac.ctx, alias)
+}
+
+// SetAliasContext is SetAlias with an explicit context for cancellation.
+func (ac *AhoCorasick) SetAliasContext(ctx context.Context, alias string) error {
+	if err := checkAliasName(alias); err != nil {
+		return err
+	}
+	target := ac.collection()
+	if alias == target {
+		return ErrAliasConflict
+	}
+	// The collection check and the write are one step, so a collection created
+	// under the alias's name in between cannot end up shadowed.
+	key := aliasKey(alias)
+	set, err := ac.storage.HSetUnlessExists(ctx, key, fieldAliasCollection, target, trieKey(alias), prefixKey(alias))
+	if err != nil {
+		return newRedisError("EVAL", key, err)
+	}
+	if !set {
+		return ErrAliasConflict
+	}
+	// Best effort, like every invalidation: a follower that misses it keeps serving
+	// the old collection until its InvalidationPollInterval re-reads the alias.
+	channel := invalidateChannelPrefix + alias
+	if err := ac.storage.Publish(ctx, channel, invalidationPayload(alias, newInvalidationID())); err != nil {
+		ac.logger.Printf("failed to publish alias change: channel=%s error=%v", channel, err)
+	}
+	return nil
+}
+
+// ResolveAlias returns the collection alias points at, or ErrAliasNotFound.
+func (ac *AhoCorasick) ResolveAlias(alias string) (string, error) {
+	return ac.ResolveAliasContext(ac.ctx, alias)
+}
+
+// ResolveAliasContext is ResolveAlias with an explicit context for cancellation.
+func (ac *AhoCorasick) ResolveAliasContext(ctx context.Context, alias string) (string, error) {
+	if err := checkAliasName(alias); err != nil {
+		return "", err
+	}
+	return resolveAlias(ctx, ac.storage, alias)
+}
+
+// DeleteAlias removes alias and reports whether it existed. Instances already
+// opened by it keep serving the collection it last named; only new ones fail,
+// with ErrAliasNotFound.
+func (ac *AhoCorasick) DeleteAlias(alias string) (bool, error) {
+	return ac.DeleteAliasContext(ac.ctx, alias)
+}
+
+// DeleteAliasContext is DeleteAlias with an explicit context for cancellation.
+func (ac *AhoCorasick) DeleteAliasContext(ctx context.Context, alias string) (bool, error) {
+	if err := checkAliasName(alias); err != nil {
+		return false, err
+	}
+	key := aliasKey(alias)
+	n, err := ac.storage.HDel(ctx, key, fieldAliasCollection)
+	if err != nil {
+		return false, newRedisError("HDEL", key, err)
+	}
+	return n > 0, nil
+}
+
+// Collection returns the name of the collection the instance serves: its Name,
+// or for an instance opened by Alias, the collection the alias named when last
+// followed. The answer can change between calls on an alias-opened instance.
+func (ac *AhoCorasick) Collection() string {
+	return ac.collection()
+}
+
+func (ac *AhoCorasick) collection() string {
+	if a, ok := ac.ops.(*aliasOps); ok {
+		return a.target().name
+	}
+	return ac.name
+}
+
+// checkAliasName applies Create's rule for names to an alias, which also has no
+// meaningful empty value.
+func checkAliasName(alias string) error {
+	if alias == "" || strings.Contains(alias, ":") {
+		return ErrInvalidName
+	}
+	return nil
+}
+
+func resolveAlias(ctx context.Context, storage kvStorage, alias string) (string, error) {
+	key := aliasKey(alias)
+	fields, err := storage.HGetAll(ctx, key)
+	if err != nil {
+		return "", newRedisError("HGETALL", key, err)
+	}
+	target := fields[fieldAliasCollection]
+	if target == "" {
+		return "", ErrAliasNotFound
+	}
+	return target, nil
+}
+
+// createAlias opens the collection args.Alias names, in preset or original mode,
+// and starts following the alias. The instance keeps a connection of its own for
+// the alias and for weights; each target collection is opened beside it the way
+// Create would open it by Name, so caching and invalidation behave the same.
+func createAlias(ctx context.Context, args *AhoCorasickArgs, preset bool) (*AhoCorasick, error) {
+	if args.Name != "" {
+		return nil, ErrAliasWithName
+	}
+	switch args.SchemaVersion {
+	case 0, SchemaV2:
+	case SchemaV1:
+		return nil, ErrAliasRequiresV2
+	default:
+		return nil, fmt.Errorf("unsupported schema version: %d", args.SchemaVersion)
+	}
+
+	redisClient, storage, err := openRedis(args)
+	if err != nil {
+		return nil, err
+	}
+
+	ac := &AhoCorasick{
+		redisClient:     redisClient,
+		storage:         storage,
+		name:            args.Alias,
+		logger:          newLogger(args),
+		schemaVersion:   SchemaV2,
+		stats:           &cacheStats{},
+		mode:            modeAlias,
+		caseSensitive:   args.CaseSensitive,
+		rollbackTimeout: resolveRollbackTimeout(args.RollbackTimeout),
+		history:         newHistoryLog(args.History),
+	}
+	// Background, not ctx, as in createOriginal: it outlives Create.
+	ac.ctx, ac.cancel = context.WithCancel(context.Background()) //nolint:gosec // G118: storing cancel func is intentional for lifecycle management
+
+	a := &aliasOps{
+		alias:    args.Alias,
+		storage:  storage,
+		draining: make(map[*aliasTarget]*time.Timer),
+	}
+	if preset {
+		a.open = ac.presetAliasTarget(args)
+	} else {
+		a.open = ac.v2AliasTarget(args)
+	}
+	ac.ops = a
+
+	// Subscribe before the first resolve, so a repoint landing between the two is
+	// not lost. The channel is held in the fields the cache listener would use: an
+	// alias-opened instance has no cache of its own, each target carries one.
+	ac.stopCh = make(chan struct{})
+	ac.pubsub, err = subscribeInvalidations(ac.ctx, storage, args.Alias, ac.stopCh, func(string) {
+		ac.followAlias(a)
+	})
+	if err != nil {
+		ac.cancel()
+		_ = storage.Close()
+		return nil, fmt.Errorf("pub/sub connection failed: %w", err)
+	}
+	if err := a.refresh(ctx); err != nil {
+		ac.stopCacheListener()
+		a.close()
+		ac.cancel()
+		_ = storage.Close()
+		return nil, err
+	}
+	if args.InvalidationPollInterval > 0 {
+		go ac.pollAlias(a, args.InvalidationPollInterval, ac.stopCh)
+	}
+
+	ac.closeFn = func() error {
+		ac.stopCacheListener()
+		a.close()
+		return storage.Close()
+	}
+	return ac, nil
+}
+
+// followAlias brings a up to date with its alias. It runs on the listener and
+// poller goroutines, which have no caller to report to, so a failure is logged
+// and the instance keeps serving its current collection.
+func (ac *AhoCorasick) followAlias(a *aliasOps) {
+	if err := a.refresh(ac.ctx); err != nil {
+		ac.logger.Printf("failed to follow alias: alias=%s error=%v", a.alias, err)
+	}
+}
+
+func (ac *AhoCorasick) pollAlias(a *aliasOps, interval time.Duration, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ac.followAlias(a)
+		case <-stopCh:
+			return
+		case <-ac.ctx.Done():
+			return
+		}
+	}
+}
+
+// presetAliasTarget opens each target as createPresetRedis would: a preset engine
+// with its own connection, listener, and poller, recording into ac.stats so
+// CacheStats stays continuous across switches.
+func (ac *AhoCorasick) presetAliasTarget(args *AhoCorasickArgs) func(context.Context, string) (*aliasTarget, error) {
+	return func(ctx context.Context, name string) (*aliasTarget, error) {
+		targetArgs := *args
+		targetArgs.Name, targetArgs.Alias = name, ""
+		rb, err := newRedisBacked(ctx, &targetArgs, ac.stats)
+		if err != nil {
+			return nil, err
+		}
+		return &aliasTarget{name: name, ops: rb, shutdown: rb.Close}, nil
+	}
+}
+
+// v2AliasTarget opens each target as createOriginal would for V2, sharing the
+// instance's connection. With EnableCache the target gets its own trie cache and
+// listener on its collection's channel.
+func (ac *AhoCorasick) v2AliasTarget(args *AhoCorasickArgs) func(context.Context, string) (*aliasTarget, error) {
+	return func(ctx context.Context, name string) (*aliasTarget, error) {
+		if err := initV2Trie(ctx, ac.storage, name); err != nil {
+			return nil, err
+		}
+		if !args.EnableCache {
+			return &aliasTarget{name: name, ops: ac.newV2Ops(name, nil), shutdown: func() error { return nil }}, nil
+		}
+		cache := &trieCache{}
+		cache.selfSkip.cleanupEvery = args.SelfInvalidationCleanupInterval
+		stopCh := make(chan struct{})
+		sub, err := subscribeInvalidations(ac.ctx, ac.storage, name, stopCh, cacheInvalidator(cache, name, ac.stats))
+		if err != nil {
+			return nil, fmt.Errorf("pub/sub connection failed: %w", err)
+		}
+		return &aliasTarget{
+			name: name,
+			ops:  ac.newV2Ops(name, cache),
+			shutdown: func() error {
+				close(stopCh)
+				return sub.Close()
+			},
+		}, nil
+	}
+}
+
+// aliasTarget is one collection an alias-opened instance serves.
+type aliasTarget struct {
+	name      string
+	ops       operations
+	shutdown  func() error
+	closeOnce sync.Once
+}
+
+func (t *aliasTarget) close() {
+	t.closeOnce.Do(func() { _ = t.shutdown() })
+}
+
+// aliasOps is the strategy of an instance opened by Alias. It forwards every
+// operation to the collection the alias currently names, and refresh swaps that
+// collection underneath — so AhoCorasick's methods, which all dispatch through
+// ac.ops, follow the alias without knowing it exists.
+//
+// Each call loads the current target once, so an operation runs entirely against
+// one collection even if a switch lands mid-call. The target it leaves is closed
+// only after aliasDrainDelay, giving such calls time to finish.
+type aliasOps struct {
+	alias   string
+	storage kvStorage
+	cur     atomic.Pointer[aliasTarget]
+	open    func(ctx context.Context, name string) (*aliasTarget, error)
+
+	// mu serializes refresh and close; readers never take it.
+	mu       sync.Mutex
+	closed   bool
+	draining map[*aliasTarget]*time.Timer
+}
+
+var (
+	_ operations   = (*aliasOps)(nil)
+	_ batchPlanner = (*aliasOps)(nil)
+)
+
+func (a *aliasOps) target() *aliasTarget {
+	return a.cur.Load()
+}
+
+// refresh reads the alias and switches to its target unless that is already the
+// current one. The read happens under mu, so of two overlapping refreshes the one
+// that switches last also read last, and a slow refresh cannot roll the instance
+// back to a target the alias has since left. The new target is opened before the
+// old one is retired: a failed open leaves the instance serving what it served.
+func (a *aliasOps) refresh(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return nil
+	}
+	name, err := resolveAlias(ctx, a.storage, a.alias)
+	if err != nil {
+		return err
+	}
+	old := a.cur.Load()
+	if old != nil && old.name == name {
+		return nil
+	}
+	next, err := a.open(ctx, name)
+	if err != nil {
+		return err
+	}
+	a.cur.Store(next)
+	if old != nil {
+		a.draining[old] = time.AfterFunc(aliasDrainDelay, func() {
+			a.mu.Lock()
+			delete(a.draining, old)
+			a.mu.Unlock()
+			old.close()
+		})
+	}
+	return nil
+}
+
+// close shuts the current target and any still draining.
+func (a *aliasOps) close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closed = true
+	for t, timer := range a.draining {
+		timer.Stop()
+		t.close()
+	}
+	clear(a.draining)
+	if cur := a.cur.Load(); cur != nil {
+		cur.close()
+	}
+}
+
+func (a *aliasOps) add(ctx context.Context, keyword string) (int, error) {
+	return a.target().ops.add(ctx, keyword)
+}
+
+func (a *aliasOps) remove(ctx context.Context, keyword string) (int, error) {
+	return a.target().ops.remove(ctx, keyword)
+}
+
+func (a *aliasOps) find(ctx context.Context, text string) ([]string, error) {
+	return a.target().ops.find(ctx, text)
+}
+
+func (a *aliasOps) findIndex(ctx context.Context, text string) (map[string][]int, error) {
+	return a.target().ops.findIndex(ctx, text)
+}
+
+func (a *aliasOps) suggest(ctx context.Context, input string) ([]string, error) {
+	return a.target().ops.suggest(ctx, input)
+}
+
+func (a *aliasOps) suggestIndex(ctx context.Context, input string) (map[string][]int, error) {
+	return a.target().ops.suggestIndex(ctx, input)
+}
+
+func (a *aliasOps) flush(ctx context.Context) error {
+	return a.target().ops.flush(ctx)
+}
+
+func (a *aliasOps) info(ctx context.Context) (*AhoCorasickInfo, error) {
+	return a.target().ops.info(ctx)
+}
+
+func (a *aliasOps) loadEngine(ctx context.Context) (*matchengine.Engine, error) {
+	return a.target().ops.loadEngine(ctx)
+}
+
+// Both target kinds — v2Operations and redisBackedAC — are batchPlanners, which
+// createAlias guarantees by refusing V1.
+
+func (a *aliasOps) addManyAtomic(ctx context.Context, keywords []string) ([]string, error) {
+	return a.target().ops.(batchPlanner).addManyAtomic(ctx, keywords)
+}
+
+func (a *aliasOps) removeManyAtomic(ctx context.Context, keywords []string) ([]string, error) {
+	return a.target().ops.(batchPlanner).removeManyAtomic(ctx, keywords)
+}
+
+func (a *aliasOps) replaceAtomic(ctx context.Context, op string, desired desiredKeywords) ([]string, []string, error) {
+	return a.target().ops.(batchPlanner).replaceAtomic(ctx, op, desired)
+}
+
+func (a *aliasOps) applyAtomic(ctx context.Context, add, remove []string,
+	expectedVersion int64) ([]string, []string, int64, error) {
+	return a.target().ops.(batchPlanner).applyAtomic(ctx, add, remove, expectedVersion)
+}
+
+func (a *aliasOps) addWithAttrsAtomic(ctx context.Context, keywords []string, attrs keywordAttrs) ([]string, error) {
+	return a.target().ops.(batchPlanner).addWithAttrsAtomic(ctx, keywords, attrs)
+}
@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// createCollection opens name on mr and adds keywords to it.
+func createCollection(t *testing.T, mr *miniredis.Miniredis, name string, keywords ...string) *AhoCorasick {
+	t.Helper()
+	ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: name})
+	if err != nil {
+		t.Fatalf("Create(%s) error: %v", name, err)
+	}
+	t.Cleanup(func() { _ = ac.Close() })
+	for _, kw := range keywords {
+		if _, err := ac.Add(kw); err != nil {
+			t.Fatalf("Add(%s) error: %v", kw, err)
+		}
+	}
+	return ac
+}
+
+func TestAlias_SetResolveDelete(t *testing.T) {
+	mr := miniredis.RunT(t)
+	blue := createCollection(t, mr, "rules-blue")
+
+	if _, err := blue.ResolveAlias("rules"); !errors.Is(err, ErrAliasNotFound) {
+		t.Fatalf("ResolveAlias before SetAlias error = %v, want ErrAliasNotFound", err)
+	}
+	if err := blue.SetAlias("rules"); err != nil {
+		t.Fatalf("SetAlias error: %v", err)
+	}
+	got, err := blue.ResolveAlias("rules")
+	if err != nil || got != "rules-blue" {
+		t.Fatalf("ResolveAlias = %q, %v; want rules-blue", got, err)
+	}
+
+	deleted, err := blue.DeleteAlias("rules")
+	if err != nil || !deleted {
+		t.Fatalf("DeleteAlias = %v, %v; want true", deleted, err)
+	}
+	deleted, err = blue.DeleteAlias("rules")
+	if err != nil || deleted {
+		t.Fatalf("second DeleteAlias = %v, %v; want false", deleted, err)
+	}
+	if _, err := blue.ResolveAlias("rules"); !errors.Is(err, ErrAliasNotFound) {
+		t.Fatalf("ResolveAlias after DeleteAlias error = %v, want ErrAliasNotFound", err)
+	}
+}
+
+func TestAlias_SetRejectsConflicts(t *testing.T) {
+	mr := miniredis.RunT(t)
+	blue := createCollection(t, mr, "rules-blue")
+	createCollection(t, mr, "rules")
+
+	tests := []struct {
+		alias string
+		want  error
+	}{
+		{"rules", ErrAliasConflict},
+		{"rules-blue", ErrAliasConflict},
+		{"", ErrInvalidName},
+		{"a:b", ErrInvalidName},
+	}
+	for _, tt := range tests {
+		if err := blue.SetAlias(tt.alias); !errors.Is(err, tt.want) {
+			t.Errorf("SetAlias(%q) error = %v, want %v", tt.alias, err, tt.want)
+		}
+	}
+	if mr.Exists(aliasKey("rules")) {
+		t.Error("a refused SetAlias wrote the alias")
+	}
+}
+
+func TestAlias_CreateGuards(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	tests := []struct {
+		name string
+		args AhoCorasickArgs
+		want error
+	}{
+		{"with name", AhoCorasickArgs{Addr: mr.Addr(), Name: "x", Alias: "rules"}, ErrAliasWithName},
+		{"v1", AhoCorasickArgs{Addr: mr.Addr(), Alias: "rules", SchemaVersion: SchemaV1}, ErrAliasRequiresV2},
+		{"invalid", AhoCorasickArgs{Addr: mr.Addr(), Alias: "a:b"}, ErrInvalidName},
+		{"missing", AhoCorasickArgs{Addr: mr.Addr(), Alias: "rules"}, ErrAliasNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			ac, err := Create(&args)
+			if !errors.Is(err, tt.want) {
+				if ac != nil {
+					_ = ac.Close()
+				}
+				t.Fatalf("Create error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlias_FollowsRepoint(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args AhoCorasickArgs
+	}{
+		{"v2", AhoCorasickArgs{}},
+		{"v2 cached", AhoCorasickArgs{EnableCache: true}},
+		{"preset", AhoCorasickArgs{Preset: PresetBalanced}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			blue := createCollection(t, mr, "rules-blue", "apple")
+			green := createCollection(t, mr, "rules-green", "banana")
+			if err := blue.SetAlias("rules"); err != nil {
+				t.Fatal(err)
+			}
+
+			args := tc.args
+			args.Addr, args.Alias = mr.Addr(), "rules"
+			ac, err := Create(&args)
+			if err != nil {
+				t.Fatalf("Create by alias error: %v", err)
+			}
+			defer func() { _ = ac.Close() }()
+
+			if got := ac.Collection(); got != "rules-blue" {
+				t.Fatalf("Collection() = %q, want rules-blue", got)
+			}
+			if got, _ := ac.Find("apple banana"); !slices.Equal(got, []string{"apple"}) {
+				t.Fatalf("Find before repoint = %v, want [apple]", got)
+			}
+
+			if err := green.SetAlias("rules"); err != nil {
+				t.Fatal(err)
+			}
+			if !eventually(t, 2*time.Second, func() bool { return ac.Collection() == "rules-green" }) {
+				t.Fatalf("Collection() = %q after repoint, want rules-green", ac.Collection())
+			}
+			if got, _ := ac.Find("apple banana"); !slices.Equal(got, []string{"banana"}) {
+				t.Fatalf("Find after repoint = %v, want [banana]", got)
+			}
+
+			// Writes go to the current target too.
+			if _, err := ac.Add("cherry"); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := green.Find("cherry"); !slices.Equal(got, []string{"cherry"}) {
+				t.Errorf("green.Find(cherry) = %v, want [cherry]", got)
+			}
+			if got, _ := blue.Find("cherry"); len(got) != 0 {
+				t.Errorf("blue.Find(cherry) = %v, want none", got)
+			}
+		})
+	}
+}
+
+func TestAlias_PollCatchesMissedMessage(t *testing.T) {
+	mr := miniredis.RunT(t)
+	blue := createCollection(t, mr, "rules-blue")
+	createCollection(t, mr, "rules-green")
+	if err := blue.SetAlias("rules"); err != nil {
+		t.Fatal(err)
+	}
+
+	ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Alias: "rules", InvalidationPollInterval: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ac.Close() }()
+
+	// Repoint without publishing, as if the message had been dropped.
+	mr.HSet(aliasKey("rules"), fieldAliasCollection, "rules-green")
+	if !eventually(t, 2*time.Second, func() bool { return ac.Collection() == "rules-green" }) {
+		t.Fatalf("Collection() = %q, want rules-green after poll", ac.Collection())
+	}
+}
+
+func TestAlias_WeightsFollowTarget(t *testing.T) {
+	mr := miniredis.RunT(t)
+	blue := createCollection(t, mr, "rules-blue", "apple")
+	if err := blue.SetWeights(map[string]KeywordWeight{"apple": {Weight: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := blue.SetAlias("rules"); err != nil {
+		t.Fatal(err)
+	}
+
+	ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Alias: "rules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ac.Close() }()
+
+	weights, err := ac.Weights()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weights["apple"].Weight != 2 {
+		t.Errorf("Weights()[apple] = %v, want 2", weights["apple"])
+	}
+}
+
+func TestAlias_RefusesMigration(t *testing.T) {
+	mr := miniredis.RunT(t)
+	blue := createCollection(t, mr, "rules-blue")
+	if err := blue.SetAlias("rules"); err != nil {
+		t.Fatal(err)
+	}
+	ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Alias: "rules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ac.Close() }()
+
+	if _, err := ac.MigrateV1ToV2(nil); !errors.Is(err, ErrMigrationViaAlias) {
+		t.Errorf("MigrateV1ToV2 error = %v, want ErrMigrationViaAlias", err)
+	}
+	if err := ac.RollbackToV1(); !errors.Is(err, ErrMigrationViaAlias) {
+		t.Errorf("RollbackToV1 error = %v, want ErrMigrationViaAlias", err)
+	}
+}
@@ -6,6 +6,7 @@
 	"context"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // The per-keyword loop in each of the four batch entry points below is the fallback
@@ -121,14 +122,29 @@ func partitionApplied(candidates, normalized, applied []string) (changed, unchan
 	return changed, unchanged
 }
 
-func (ac *AhoCorasick) addManyBestEffort(ctx context.Context, keywords []string, result *BatchResult) (*BatchResult, error) {
+// addPlanned writes a batch through bp, setting every keyword to expire ttl from
+// now when ttl is non-zero and giving each the tags in tags.
+func addPlanned(ctx context.Context, bp batchPlanner, keywords []string, ttl time.Duration,
+	tags []string) ([]string, error) {
+	if ttl <= 0 && len(tags) == 0 {
+		return bp.addManyAtomic(ctx, keywords)
+	}
+	attrs := keywordAttrs{tags: tags}
+	if ttl > 0 {
+		attrs.expireAt = time.Now().Add(ttl)
+	}
+	return bp.addWithAttrsAtomic(ctx, keywords, attrs)
+}
+
+func (ac *AhoCorasick) addManyBestEffort(ctx context.Context, keywords []string, ttl time.Duration, tags []string,
+	result *BatchResult) (*BatchResult, error) {
 	candidates, normalized := ac.screenBatch(keywords, result)
 
 	if bp, ok := ac.ops.(batchPlanner); ok {
 		if len(candidates) == 0 {
 			return result, nil
 		}
-		added, err := bp.addManyAtomic(ctx, normalized)
+		added, err := addPlanned(ctx, bp, normalized, ttl, tags)
 		if err != nil {
 			// One transaction means one outcome: nothing was written, so every
 			// candidate failed. A partial success cannot happen here.
@@ -163,7 +179,8 @@ func (ac *AhoCorasick) addManyBestEffort(ctx context.Context, keywords []string,
 	return result, nil
 }
 
-func (ac *AhoCorasick) addManyTransactional(ctx context.Context, keywords []string, result *BatchResult) (*BatchResult, error) {
+func (ac *AhoCorasick) addManyTransactional(ctx context.Context, keywords []string, ttl time.Duration, tags []string,
+	result *BatchResult) (*BatchResult, error) {
 	if bp, ok := ac.ops.(batchPlanner); ok {
 		candidates, normalized := ac.screenBatch(keywords, result)
 		if len(result.Failed) > 0 {
@@ -177,7 +194,7 @@ func (ac *AhoCorasick) addManyTransactional(ctx context.Context, keywords []stri
 		}
 		// A single CAS write is already all-or-nothing, so there is nothing to
 		// roll back: either the whole batch committed or none of it did.
-		applied, err := bp.addManyAtomic(ctx, normalized)
+		applied, err := addPlanned(ctx, bp, normalized, ttl, tags)
 		if err != nil {
 			return nil, fmt.Errorf("batch add failed: %w", err)
 		}
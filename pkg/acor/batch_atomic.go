@@ -4,6 +4,9 @@
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -26,8 +29,50 @@ type batchPlanner interface {
 	// removeManyAtomic deletes every keyword in one transaction and returns those
 	// that were actually present. An error means nothing was written.
 	removeManyAtomic(ctx context.Context, keywords []string) ([]string, error)
+	// replaceAtomic rewrites the whole keyword set in one transaction to the one
+	// desired computes from the current snapshot, recording the change as op. It
+	// returns the keywords added and removed; an error means nothing was written.
+	replaceAtomic(ctx context.Context, op string, desired desiredKeywords) (added, removed []string, err error)
+	// applyAtomic removes and then inserts keywords in one transaction, provided
+	// the collection is at expectedVersion when that is non-zero. It returns the
+	// keywords actually changed and the version left behind; an error means
+	// nothing was written.
+	applyAtomic(ctx context.Context, add, remove []string, expectedVersion int64) (added, removed []string, version int64, err error)
+	// addWithAttrsAtomic is addManyAtomic that also sets attrs on every keyword,
+	// new or already present. It returns the keywords that were not present; an
+	// error means nothing was written.
+	addWithAttrsAtomic(ctx context.Context, keywords []string, attrs keywordAttrs) ([]string, error)
 }
 
+// keywordAttrs is what an add sets on each keyword it writes, beyond the keyword
+// itself.
+type keywordAttrs struct {
+	// expireAt sets the keyword to expire then; zero leaves its expiry as it is.
+	expireAt time.Time
+	// tags are added to the keyword's tags, which it keeps.
+	tags []string
+}
+
+// planAddWithAttrs returns the plan for an add that sets attrs on every keyword,
+// new or already present.
+func planAddWithAttrs(attrs keywordAttrs) func(*trieSnapshot, []string) (map[string][]string, []string) {
+	return func(snap *trieSnapshot, keywords []string) (map[string][]string, []string) {
+		outputs, added := planAddLive(snap, keywords)
+		if !attrs.expireAt.IsZero() {
+			snap.setExpiry(keywords, attrs.expireAt.UnixMilli())
+		}
+		snap.addTags(keywords, attrs.tags)
+		return outputs, added
+	}
+}
+
+// desiredKeywords computes the keyword set a replaceAtomic should leave from the
+// snapshot it is about to replace, seen without its expired keywords. It runs again
+// on every conflict retry, against the fresh snapshot; an error aborts the replace.
+// It may also retag the keywords it keeps by giving snap a new Tags map and
+// setting attrsChanged.
+type desiredKeywords func(snap *trieSnapshot) ([]string, error)
+
 var (
 	_ batchPlanner = (*redisBackedAC)(nil)
 	_ batchPlanner = (*v2Operations)(nil)
@@ -36,7 +81,7 @@ type batchPlanner interface {
 // applyManyAtomic runs the shared V2 snapshot-plan-CAS loop. afterCommit is used
 // by preset mode to install the committed snapshot in its local engine.
 func applyManyAtomic(ctx context.Context, storage kvStorage, client redis.UniversalClient, name string,
-	keywords []string, clearOutputs bool,
+	history *historyLog, keywords []string, clearOutputs bool,
 	plan func(*trieSnapshot, []string) (map[string][]string, []string),
 	afterCommit func(*trieSnapshot, int64)) ([]string, error) {
 	var applied []string
@@ -46,11 +91,16 @@ func applyManyAtomic(ctx context.Context, storage kvStorage, client redis.Univer
 			return 0, err
 		}
 		outputs, changed := plan(snap, keywords)
-		if len(changed) == 0 {
+		if len(changed) == 0 && !snap.attrsChanged {
 			applied = nil
 			return 0, nil
 		}
-		newVersion, err := commitV2Write(ctx, client, name, snap, outputs, clearOutputs)
+		// clearOutputs is exactly the remove plan, as in the single-keyword paths.
+		change := history.change(historyOpAdd, changed, nil)
+		if clearOutputs {
+			change = history.change(historyOpRemove, nil, changed)
+		}
+		newVersion, err := commitV2Write(ctx, client, name, snap, outputs, clearOutputs, change)
 		if err != nil {
 			// A lost CAS race retries from a fresh snapshot, so anything this
 			// attempt staged must not leak into the next one.
@@ -69,11 +119,155 @@ func applyManyAtomic(ctx context.Context, storage kvStorage, client redis.Univer
 	return applied, nil
 }
 
+// applyReplaceAtomic is applyManyAtomic for replaceAtomic: each attempt replans
+// the whole trie from nothing, so it needs no add or remove plan of its own and
+// the write clears every old output state.
+//
+// desired sees only the live keywords, and additions are judged against them too,
+// so an expired keyword it keeps is revived; removals are judged against
+// everything stored, so an expired keyword it drops is removed for good. Kept
+// keywords keep their expiries and whatever tags desired left them.
+//
+// committed reports whether anything was written, which a retag alone does without
+// adding or removing a keyword.
+func applyReplaceAtomic(ctx context.Context, storage kvStorage, client redis.UniversalClient, name string,
+	history *historyLog, op string, desired desiredKeywords,
+	afterCommit func(*trieSnapshot, int64)) (added, removed []string, committed bool, err error) {
+	writes, err := retryOnConflict(ctx, func() (int, error) {
+		added, removed = nil, nil
+		snap, err := readTrieSnapshot(ctx, storage, name)
+		if err != nil {
+			return 0, err
+		}
+		now := time.Now()
+		live := snap.live(now)
+		want, err := desired(live)
+		if err != nil {
+			return 0, err
+		}
+		plusKeywords, _ := diffKeywords(live.Keywords, want)
+		_, minusKeywords := diffKeywords(snap.Keywords, want)
+		if len(plusKeywords) == 0 && len(minusKeywords) == 0 && !live.attrsChanged {
+			return 0, nil
+		}
+
+		// Not nil slices or maps: the script stores these as the trie's JSON and
+		// decodes the outputs with cjson, and both must stay arrays and objects
+		// even for an empty set.
+		next := &trieSnapshot{Keywords: []string{}, Prefixes: []string{""}, Version: snap.Version,
+			Expiries: keptExpiries(snap.Expiries, want, now), Tags: keptTags(live.Tags, want)}
+		outputs, _ := planAddMany(next, want)
+		if outputs == nil {
+			outputs = map[string][]string{}
+		}
+		change := history.change(op, plusKeywords, minusKeywords)
+		newVersion, err := commitV2Write(ctx, client, name, next, outputs, true, change)
+		if err != nil {
+			return 0, err
+		}
+		added, removed = plusKeywords, minusKeywords
+		if afterCommit != nil {
+			afterCommit(next, newVersion)
+		}
+		return 1, nil
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return added, removed, writes > 0, nil
+}
+
+// applyChangesetAtomic is applyManyAtomic for applyAtomic. Each attempt plans the
+// removals and then the additions against the same snapshot, so one commit
+// carries both. The removal plan is a full replacement output set, which the add
+// plan then overlays with every state it recomputed; when anything was removed
+// the write clears the old outputs first, as a remove batch does.
+func applyChangesetAtomic(ctx context.Context, storage kvStorage, client redis.UniversalClient, name string,
+	history *historyLog, add, remove []string, expectedVersion int64,
+	afterCommit func(*trieSnapshot, int64)) (added, removed []string, version int64, err error) {
+	_, err = retryOnConflict(ctx, func() (int, error) {
+		added, removed = nil, nil
+		snap, err := readTrieSnapshot(ctx, storage, name)
+		if err != nil {
+			return 0, err
+		}
+		// Not ErrConcurrencyConflict: the caller asked for this version only, so a
+		// fresh snapshot cannot help and the mismatch must not be retried.
+		if expectedVersion != 0 && snap.Version != expectedVersion {
+			return 0, fmt.Errorf("%w: collection is at %d, expected %d",
+				ErrVersionMismatch, snap.Version, expectedVersion)
+		}
+		version = snap.Version
+
+		removeOutputs, minusKeywords := planRemoveMany(snap, remove)
+		addOutputs, plusKeywords := planAddLive(snap, add)
+		if len(plusKeywords) == 0 && len(minusKeywords) == 0 {
+			return 0, nil
+		}
+		outputs := make(map[string][]string, len(removeOutputs)+len(addOutputs))
+		maps.Copy(outputs, removeOutputs)
+		maps.Copy(outputs, addOutputs)
+
+		change := history.change(historyOpApply, plusKeywords, minusKeywords)
+		newVersion, err := commitV2Write(ctx, client, name, snap, outputs, len(minusKeywords) > 0, change)
+		if err != nil {
+			return 0, err
+		}
+		added, removed, version = plusKeywords, minusKeywords, newVersion
+		if afterCommit != nil {
+			afterCommit(snap, newVersion)
+		}
+		return len(added) + len(removed), nil
+	})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return added, removed, version, nil
+}
+
+// keptExpiries returns the expiries in expiries of the keywords in want that have
+// not expired by now.
+func keptExpiries(expiries keywordExpiries, want []string, now time.Time) keywordExpiries {
+	kept := make(keywordExpiries)
+	for _, kw := range want {
+		if at, ok := expiries[kw]; ok && !expiries.expired(kw, now) {
+			kept[kw] = at
+		}
+	}
+	return kept
+}
+
+// diffKeywords returns the keywords in want but not in have, then those in have
+// but not in want, each in the order its source lists them. Empty keywords in
+// want are skipped, as planAddMany skips them.
+func diffKeywords(have, want []string) (plus, minus []string) {
+	haveSet := make(map[string]struct{}, len(have))
+	for _, kw := range have {
+		haveSet[kw] = struct{}{}
+	}
+	wantSet := make(map[string]struct{}, len(want))
+	for _, kw := range want {
+		if _, dup := wantSet[kw]; dup || kw == "" {
+			continue
+		}
+		wantSet[kw] = struct{}{}
+		if _, ok := haveSet[kw]; !ok {
+			plus = append(plus, kw)
+		}
+	}
+	for _, kw := range have {
+		if _, ok := wantSet[kw]; !ok {
+			minus = append(minus, kw)
+		}
+	}
+	return plus, minus
+}
+
 // --- preset mode ---
 
 func (ac *redisBackedAC) addManyAtomic(ctx context.Context, keywords []string) ([]string, error) {
-	added, err := applyManyAtomic(ctx, ac.storage, ac.redisClient, ac.name, keywords,
-		false, planAddMany, ac.applyCommittedWrite)
+	added, err := applyManyAtomic(ctx, ac.storage, ac.redisClient, ac.name, ac.history, keywords,
+		false, planAddLive, ac.applyCommittedWrite)
 	if len(added) > 0 {
 		ac.publishInvalidate(ctx)
 	}
@@ -81,7 +275,7 @@ func (ac *redisBackedAC) addManyAtomic(ctx context.Context, keywords []string) (
 }
 
 func (ac *redisBackedAC) removeManyAtomic(ctx context.Context, keywords []string) ([]string, error) {
-	removed, err := applyManyAtomic(ctx, ac.storage, ac.redisClient, ac.name, keywords,
+	removed, err := applyManyAtomic(ctx, ac.storage, ac.redisClient, ac.name, ac.history, keywords,
 		true, planRemoveMany, ac.applyCommittedWrite)
 	if len(removed) > 0 {
 		ac.publishInvalidate(ctx)
@@ -89,6 +283,37 @@ func (ac *redisBackedAC) removeManyAtomic(ctx context.Context, keywords []string
 	return removed, err
 }
 
+func (ac *redisBackedAC) replaceAtomic(ctx context.Context, op string, desired desiredKeywords) ([]string, []string, error) {
+	added, removed, committed, err := applyReplaceAtomic(ctx, ac.storage, ac.redisClient, ac.name, ac.history,
+		op, desired, ac.applyCommittedWrite)
+	if committed {
+		ac.publishInvalidate(ctx)
+	}
+	return added, removed, err
+}
+
+func (ac *redisBackedAC) applyAtomic(ctx context.Context, add, remove []string,
+	expectedVersion int64) ([]string, []string, int64, error) {
+	added, removed, version, err := applyChangesetAtomic(ctx, ac.storage, ac.redisClient, ac.name, ac.history,
+		add, remove, expectedVersion, ac.applyCommittedWrite)
+	if len(added)+len(removed) > 0 {
+		ac.publishInvalidate(ctx)
+	}
+	return added, removed, version, err
+}
+
+// addWithAttrsAtomic publishes even when no keyword was added: a moved expiry or
+// tag is a change peers must pick up as much as a new keyword, and a TTL add all
+// but always moves one.
+func (ac *redisBackedAC) addWithAttrsAtomic(ctx context.Context, keywords []string, attrs keywordAttrs) ([]string, error) {
+	added, err := applyManyAtomic(ctx, ac.storage, ac.redisClient, ac.name, ac.history, keywords,
+		false, planAddWithAttrs(attrs), ac.applyCommittedWrite)
+	if err == nil {
+		ac.publishInvalidate(ctx)
+	}
+	return added, err
+}
+
 // applyCommittedWrite installs a write's own committed snapshot as the local view,
 // rebuilding the engine once. Every preset-mode write routes through it — single
 // keyword and whole batch alike — so both leave the same local state.
@@ -110,8 +335,8 @@ func (ac *redisBackedAC) applyCommittedWrite(snap *trieSnapshot, newVersion int6
 // --- V2 mode ---
 
 func (o *v2Operations) addManyAtomic(ctx context.Context, keywords []string) ([]string, error) {
-	added, err := applyManyAtomic(ctx, o.storage, o.client, o.name, keywords,
-		false, planAddMany, nil)
+	added, err := applyManyAtomic(ctx, o.storage, o.client, o.name, o.history, keywords,
+		false, planAddLive, nil)
 	if len(added) > 0 {
 		o.publishInvalidate(ctx)
 	}
@@ -119,10 +344,39 @@ func (o *v2Operations) addManyAtomic(ctx context.Context, keywords []string) ([]
 }
 
 func (o *v2Operations) removeManyAtomic(ctx context.Context, keywords []string) ([]string, error) {
-	removed, err := applyManyAtomic(ctx, o.storage, o.client, o.name, keywords,
+	removed, err := applyManyAtomic(ctx, o.storage, o.client, o.name, o.history, keywords,
 		true, planRemoveMany, nil)
 	if len(removed) > 0 {
 		o.publishInvalidate(ctx)
 	}
 	return removed, err
 }
+
+func (o *v2Operations) replaceAtomic(ctx context.Context, op string, desired desiredKeywords) ([]string, []string, error) {
+	added, removed, committed, err := applyReplaceAtomic(ctx, o.storage, o.client, o.name, o.history,
+		op, desired, nil)
+	if committed {
+		o.publishInvalidate(ctx)
+	}
+	return added, removed, err
+}
+
+func (o *v2Operations) applyAtomic(ctx context.Context, add, remove []string,
+	expectedVersion int64) ([]string, []string, int64, error) {
+	added, removed, version, err := applyChangesetAtomic(ctx, o.storage, o.client, o.name, o.history,
+		add, remove, expectedVersion, nil)
+	if len(added)+len(removed) > 0 {
+		o.publishInvalidate(ctx)
+	}
+	return added, removed, version, err
+}
+
+// addWithAttrsAtomic publishes on every success, as the preset one does.
+func (o *v2Operations) addWithAttrsAtomic(ctx context.Context, keywords []string, attrs keywordAttrs) ([]string, error) {
+	added, err := applyManyAtomic(ctx, o.storage, o.client, o.name, o.history, keywords,
+		false, planAddWithAttrs(attrs), nil)
+	if err == nil {
+		o.publishInvalidate(ctx)
+	}
+	return added, err
+}
@@ -4,6 +4,7 @@
 
 import (
 	"sync"
+	"time"
 
 	matchengine "github.com/skyoo2003/acor/internal/engine"
 )
@@ -13,6 +14,12 @@ type trieCache struct {
 	loadMu sync.Mutex
 	engine *matchengine.Engine
 	valid  bool
+	// version is the collection version the engine was loaded at, checked against
+	// WithMinVersion floors.
+	version int64
+	// expiresAt is when the next keyword in the engine expires, or zero. The
+	// engine is invalid from then on, as if a peer had removed the keyword.
+	expiresAt time.Time
 	// selfSkip holds the IDs this instance published, so the listener can skip
 	// the invalidation the publisher already applied. See selfSkipSet.
 	selfSkip selfSkipSet
@@ -22,12 +29,14 @@ type trieCache struct {
 // outputs map. In an Aho-Corasick automaton every keyword has its own terminal
 // state whose output list contains that keyword, so the union of all output
 // values is exactly the keyword set. PresetBalanced matches the redis-backed
-// engine's default (DAT + banded DFA).
-func buildEngineFromOutputs(outputs map[string][]string) *matchengine.Engine {
+// engine's default (DAT + banded DFA). Keywords expired by now are left out.
+func buildEngineFromOutputs(outputs map[string][]string, expiries keywordExpiries, now time.Time) *matchengine.Engine {
 	keywords := make(map[string]struct{})
 	for _, outs := range outputs {
 		for _, kw := range outs {
-			keywords[kw] = struct{}{}
+			if !expiries.expired(kw, now) {
+				keywords[kw] = struct{}{}
+			}
 		}
 	}
 	engine := matchengine.New(enginePreset(PresetBalanced))
@@ -41,18 +50,30 @@ func (c *trieCache) invalidate() {
 	c.valid = false
 }
 
-func (c *trieCache) set(outputs map[string][]string) {
+func (c *trieCache) set(outputs map[string][]string, version int64, expiries keywordExpiries) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.engine = buildEngineFromOutputs(outputs)
+	now := time.Now()
+	c.engine = buildEngineFromOutputs(outputs, expiries, now)
+	c.expiresAt = expiries.next(now)
+	c.version = version
 	c.valid = true
 }
 
-// getEngine returns the cached match engine and whether the cache is valid.
-// The engine is immutable after set() (replaced atomically on reload), so the
-// caller may use the returned engine concurrently without additional locking.
+// loadedVersion returns the version the cached engine was loaded at and whether
+// the cache is valid.
+func (c *trieCache) loadedVersion() (int64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version, c.valid
+}
+
+// getEngine returns the cached match engine and whether the cache is valid,
+// which it stops being once one of its keywords expires. The engine is immutable
+// after set() (replaced atomically on reload), so the caller may use the returned
+// engine concurrently without additional locking.
 func (c *trieCache) getEngine() (*matchengine.Engine, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.engine, c.valid
+	return c.engine, c.valid && (c.expiresAt.IsZero() || time.Now().Before(c.expiresAt))
 }
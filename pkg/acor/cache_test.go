@@ -25,7 +25,7 @@ func TestTrieCache_SetBuildsEngine(t *testing.T) {
 	cache.set(map[string][]string{
 		"ab":  {"ab"},
 		"abc": {"abc"},
-	})
+	}, 0, nil)
 
 	engine, valid := cache.getEngine()
 	if !valid {
@@ -39,7 +39,7 @@ func TestTrieCache_SetBuildsEngine(t *testing.T) {
 func TestTrieCache_SetOverwritesPrevious(t *testing.T) {
 	cache := &trieCache{}
 
-	cache.set(map[string][]string{"old": {"old"}})
+	cache.set(map[string][]string{"old": {"old"}}, 0, nil)
 	engine, valid := cache.getEngine()
 	if !valid {
 		t.Fatal("expected cache to be valid after first set()")
@@ -49,7 +49,7 @@ func TestTrieCache_SetOverwritesPrevious(t *testing.T) {
 	}
 
 	// Set "new" data — should overwrite the previous engine.
-	cache.set(map[string][]string{"new": {"new"}})
+	cache.set(map[string][]string{"new": {"new"}}, 0, nil)
 	engine, _ = cache.getEngine()
 	if got := engine.Find("new"); len(got) != 1 || got[0] != "new" {
 		t.Errorf("expected engine to match [new], got %v", got)
@@ -79,7 +79,7 @@ func TestTrieCache_ConcurrentAccess(t *testing.T) {
 
 		go func() {
 			defer wg.Done()
-			cache.set(map[string][]string{"a": {"a"}})
+			cache.set(map[string][]string{"a": {"a"}}, 0, nil)
 		}()
 
 		go func() {
@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Changeset is a set of keyword additions and removals that Apply commits
+// together.
+type Changeset struct {
+	// Add lists the keywords to insert. Keywords already present are skipped.
+	Add []string
+	// Remove lists the keywords to delete. Keywords not present are skipped.
+	Remove []string
+	// ExpectedVersion, when non-zero, makes the changeset conditional: it commits
+	// only if the collection is still at this version, and otherwise fails with
+	// ErrVersionMismatch. Zero commits against whatever version is current.
+	ExpectedVersion int64
+}
+
+// ChangesetResult describes what Apply committed.
+type ChangesetResult struct {
+	// Added lists the keywords that were inserted, in the order given.
+	Added []string
+	// Removed lists the keywords that were deleted, in the order given.
+	Removed []string
+	// Version is the collection's version after Apply: the one it committed, or
+	// the current one when nothing needed writing. It can be passed as the next
+	// changeset's ExpectedVersion.
+	Version int64
+}
+
+// Apply commits every addition and removal in cs as one optimistic-lock write, so
+// readers see the collection either before the changeset or after all of it —
+// never a rename half done. Keywords are normalized as Add normalizes them; a
+// blank keyword, or one listed in both Add and Remove, fails the whole changeset
+// with nothing written.
+//
+// With cs.ExpectedVersion set, Apply is a compare-and-set: if another writer has
+// moved the collection past that version it returns ErrVersionMismatch rather
+// than retrying. With History set, the write is recorded as an "apply" entry.
+//
+// V1 collections are read-only, so Apply returns ErrV1ReadOnly there.
+func (ac *AhoCorasick) Apply(cs *Changeset) (*ChangesetResult, error) {
+	return ac.ApplyContext(ac.ctx, cs)
+}
+
+// ApplyContext is Apply with an explicit context for cancellation.
+func (ac *AhoCorasick) ApplyContext(ctx context.Context, cs *Changeset) (*ChangesetResult, error) {
+	bp, ok := ac.ops.(batchPlanner)
+	if !ok {
+		return nil, ErrV1ReadOnly
+	}
+	if cs == nil {
+		cs = &Changeset{}
+	}
+	add, err := ac.normalizeChangeset(cs.Add)
+	if err != nil {
+		return nil, err
+	}
+	remove, err := ac.normalizeChangeset(cs.Remove)
+	if err != nil {
+		return nil, err
+	}
+	for _, kw := range remove {
+		if slices.Contains(add, kw) {
+			return nil, fmt.Errorf("%w: %q", ErrChangesetOverlap, kw)
+		}
+	}
+
+	added, removed, version, err := bp.applyAtomic(ctx, add, remove, cs.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if added == nil {
+		added = []string{}
+	}
+	if removed == nil {
+		removed = []string{}
+	}
+	return &ChangesetResult{Added: added, Removed: removed, Version: version}, nil
+}
+
+// Version returns the collection's current version, the value a conditional
+// Changeset compares against. Every committed write changes it.
+func (ac *AhoCorasick) Version() (int64, error) {
+	return ac.VersionContext(ac.ctx)
+}
+
+// VersionContext is Version with an explicit context for cancellation.
+func (ac *AhoCorasick) VersionContext(ctx context.Context) (int64, error) {
+	// V1 keeps no version, and there is nothing to compare against anyway.
+	if _, ok := ac.ops.(batchPlanner); !ok {
+		return 0, ErrV1ReadOnly
+	}
+	snap, err := readTrieSnapshot(ctx, ac.storage, ac.collection())
+	if err != nil {
+		return 0, err
+	}
+	return snap.Version, nil
+}
+
+// normalizeChangeset normalizes one side of a changeset, refusing blanks the way
+// a transactional batch does.
+func (ac *AhoCorasick) normalizeChangeset(keywords []string) ([]string, error) {
+	normalized := make([]string, 0, len(keywords))
+	for _, kw := range keywords {
+		kw = normalizeKeyword(kw, ac.caseSensitive)
+		if kw == "" {
+			return nil, ErrEmptyKeyword
+		}
+		normalized = append(normalized, kw)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestApply_RenameInOneCommit(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args AhoCorasickArgs
+	}{
+		{"v2", AhoCorasickArgs{}},
+		{"preset", AhoCorasickArgs{Preset: PresetBalanced}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			args := tc.args
+			args.Addr, args.Name, args.History = mr.Addr(), "c", &HistoryOptions{}
+			ac, err := Create(&args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = ac.Close() }()
+			if _, err := ac.AddMany([]string{"he", "hers", "his"}, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := ac.Apply(&Changeset{Add: []string{"She", "hers"}, Remove: []string{"he", "absent"}})
+			if err != nil {
+				t.Fatalf("Apply error: %v", err)
+			}
+			if !slices.Equal(result.Added, []string{"she"}) || !slices.Equal(result.Removed, []string{"he"}) {
+				t.Errorf("Apply result = %+v, want +[she] -[he]", result)
+			}
+			if v, err := ac.Version(); err != nil || v != result.Version {
+				t.Errorf("Version() = %d, %v; want the committed %d", v, err, result.Version)
+			}
+			got, err := ac.Find("ushers")
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, []string{"hers", "she"}) {
+				t.Errorf("Find(ushers) = %v, want [hers she]", got)
+			}
+
+			// Both halves land in a single history entry, so no reader could have
+			// seen the collection between them.
+			entries, err := ac.History(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entries[0].Op != "apply" || !slices.Equal(entries[0].Added, []string{"she"}) ||
+				!slices.Equal(entries[0].Removed, []string{"he"}) || entries[0].Version != result.Version {
+				t.Errorf("newest history entry = %+v, want one apply of +[she] -[he]", entries[0])
+			}
+		})
+	}
+}
+
+func TestApply_ExpectedVersion(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "c", "apple")
+
+	start, err := ac.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := ac.Apply(&Changeset{Add: []string{"banana"}, ExpectedVersion: start})
+	if err != nil {
+		t.Fatalf("Apply at the current version error: %v", err)
+	}
+
+	// start is stale now, so the same condition must refuse to write.
+	if _, err := ac.Apply(&Changeset{Remove: []string{"apple"}, ExpectedVersion: start}); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("Apply at a stale version error = %v, want ErrVersionMismatch", err)
+	}
+	if got := storedKeywords(t, ac, "c"); !slices.Equal(got, []string{"apple", "banana"}) {
+		t.Errorf("stored keywords = %v, want the refused changeset unapplied", got)
+	}
+
+	// A changeset with nothing to do still checks the version and reports it.
+	noop, err := ac.Apply(&Changeset{Add: []string{"apple"}, ExpectedVersion: first.Version})
+	if err != nil || noop.Version != first.Version || len(noop.Added)+len(noop.Removed) != 0 {
+		t.Errorf("no-op Apply = %+v, %v; want version %d and no changes", noop, err, first.Version)
+	}
+}
+
+func TestApply_RejectsBadChangesets(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "c", "apple")
+
+	for _, tc := range []struct {
+		cs   *Changeset
+		want error
+	}{
+		{&Changeset{Add: []string{"pear", " "}}, ErrEmptyKeyword},
+		{&Changeset{Add: []string{"Apple"}, Remove: []string{"apple"}}, ErrChangesetOverlap},
+	} {
+		if _, err := ac.Apply(tc.cs); !errors.Is(err, tc.want) {
+			t.Errorf("Apply(%+v) error = %v, want %v", tc.cs, err, tc.want)
+		}
+	}
+	if got := storedKeywords(t, ac, "c"); !slices.Equal(got, []string{"apple"}) {
+		t.Errorf("stored keywords = %v, want [apple]", got)
+	}
+
+	v1, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "old", SchemaVersion: SchemaV1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = v1.Close() }()
+	if _, err := v1.Apply(&Changeset{Add: []string{"x"}}); !errors.Is(err, ErrV1ReadOnly) {
+		t.Errorf("Apply on V1 error = %v, want ErrV1ReadOnly", err)
+	}
+}
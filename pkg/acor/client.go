@@ -4,7 +4,10 @@
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
@@ -13,6 +16,61 @@
 
 const defaultRedisClusterPingTimeout = 5 * time.Second
 
+// TLSOptions turns on TLS for Redis connections from PEM files; see
+// AhoCorasickArgs.TLS. The zero value trusts the system roots and presents no
+// client certificate.
+type TLSOptions struct {
+	// CAFile is a PEM bundle of the CAs that sign the servers' certificates,
+	// trusted in place of the system roots. Set it for a private CA.
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key presented to
+	// servers that require one. Set both or neither.
+	CertFile string
+	KeyFile  string
+	// ServerName is the name every server certificate is verified against.
+	// Left empty, each connection verifies the host it dials, which is what a
+	// cluster or ring whose nodes have certificates of their own needs.
+	ServerName string
+}
+
+// config loads the files o names into a *tls.Config.
+func (o *TLSOptions) config() (*tls.Config, error) {
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, ErrRedisTLSKeyPair
+	}
+	cfg := &tls.Config{ServerName: o.ServerName, MinVersion: tls.VersionTLS12}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis TLS CA file: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("redis TLS CA file %s: no PEM certificates", o.CAFile)
+		}
+	}
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis TLS client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// redisTLSConfig returns the TLS configuration args ask for, or nil for none.
+func redisTLSConfig(args *AhoCorasickArgs) (*tls.Config, error) {
+	switch {
+	case args.TLSConfig != nil && args.TLS != nil:
+		return nil, ErrRedisTLSConflict
+	case args.TLS != nil:
+		return args.TLS.config()
+	default:
+		return args.TLSConfig, nil
+	}
+}
+
 func newRedisClient(args *AhoCorasickArgs) (redis.UniversalClient, error) {
 	addrs := normalizeAddrs(args.Addr, args.Addrs)
 	ringAddrs := normalizeRingAddrs(args.RingAddrs)
@@ -20,17 +78,43 @@ func newRedisClient(args *AhoCorasickArgs) (redis.UniversalClient, error) {
 	if err := validateRedisTopology(args, addrs, ringAddrs); err != nil {
 		return nil, err
 	}
+	tlsConfig, err := redisTLSConfig(args)
+	if err != nil {
+		return nil, err
+	}
+	opts := universalOptions(args, addrs, tlsConfig)
 
 	switch {
 	case len(ringAddrs) > 0:
-		return newRingRedisClient(args, ringAddrs), nil
-	case strings.TrimSpace(args.MasterName) != "":
-		return newSentinelRedisClient(args, addrs), nil
+		return newRingRedisClient(opts, ringAddrs), nil
+	case opts.MasterName != "":
+		return redis.NewFailoverClient(opts.Failover()), nil
 	case selectsCluster(args, ringAddrs):
-		return newClusterRedisClient(args, addrs)
+		return newClusterRedisClient(opts)
 	default:
-		return newStandaloneRedisClient(args, addrs), nil
+		return newStandaloneRedisClient(args, opts), nil
+	}
+}
+
+// openRedis returns the client an instance opened with args uses, and the
+// storage over it: args.Connection's, which the storage's Close leaves open, or
+// a client of the instance's own, which it closes.
+func openRedis(args *AhoCorasickArgs) (redis.UniversalClient, kvStorage, error) {
+	if conn := args.Connection; conn != nil {
+		if args.hasClientConfig() {
+			return nil, nil, ErrConnectionConflict
+		}
+		storage, err := conn.storage()
+		if err != nil {
+			return nil, nil, err
+		}
+		return conn.client, storage, nil
 	}
+	client, err := newRedisClient(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, newRedisStorage(client), nil
 }
 
 // selectsCluster reports whether newRedisClient builds a cluster client for
@@ -81,41 +165,46 @@ func validateRedisTopology(args *AhoCorasickArgs, addrs []string, ringAddrs map[
 // cluster mode. Topology selection stays in newRedisClient rather than using
 // redis.NewUniversalClient, whose "more than one address means cluster" rule
 // differs from the documented behavior of AhoCorasickArgs.
-func universalOptions(args *AhoCorasickArgs, addrs []string) *redis.UniversalOptions {
+func universalOptions(args *AhoCorasickArgs, addrs []string, tlsConfig *tls.Config) *redis.UniversalOptions {
 	return &redis.UniversalOptions{
-		Addrs:        addrs,
-		MasterName:   strings.TrimSpace(args.MasterName),
-		Password:     args.Password,
-		DB:           args.DB,
-		DialTimeout:  args.DialTimeout,
-		ReadTimeout:  args.ReadTimeout,
-		WriteTimeout: args.WriteTimeout,
-		MaxRetries:   args.MaxRetries,
-		PoolSize:     args.PoolSize,
+		Addrs:                      addrs,
+		MasterName:                 strings.TrimSpace(args.MasterName),
+		Username:                   args.Username,
+		Password:                   args.Password,
+		CredentialsProviderContext: args.CredentialsProvider,
+		SentinelUsername:           args.SentinelUsername,
+		SentinelPassword:           args.SentinelPassword,
+		DB:                         args.DB,
+		TLSConfig:                  tlsConfig,
+		DialTimeout:                args.DialTimeout,
+		ReadTimeout:                args.ReadTimeout,
+		WriteTimeout:               args.WriteTimeout,
+		MaxRetries:                 args.MaxRetries,
+		PoolSize:                   args.PoolSize,
 	}
 }
 
 // newRingRedisClient is hand-built: Ring is the one topology UniversalOptions
-// has no converter for.
-func newRingRedisClient(args *AhoCorasickArgs, ringAddrs map[string]string) redis.UniversalClient {
+// has no converter for. It copies every field universalOptions sets that a
+// ring has.
+func newRingRedisClient(opts *redis.UniversalOptions, ringAddrs map[string]string) redis.UniversalClient {
 	return redis.NewRing(&redis.RingOptions{
-		Addrs:        ringAddrs,
-		Password:     args.Password,
-		DB:           args.DB,
-		DialTimeout:  args.DialTimeout,
-		ReadTimeout:  args.ReadTimeout,
-		WriteTimeout: args.WriteTimeout,
-		MaxRetries:   args.MaxRetries,
-		PoolSize:     args.PoolSize,
+		Addrs:                      ringAddrs,
+		Username:                   opts.Username,
+		Password:                   opts.Password,
+		CredentialsProviderContext: opts.CredentialsProviderContext,
+		DB:                         opts.DB,
+		TLSConfig:                  opts.TLSConfig,
+		DialTimeout:                opts.DialTimeout,
+		ReadTimeout:                opts.ReadTimeout,
+		WriteTimeout:               opts.WriteTimeout,
+		MaxRetries:                 opts.MaxRetries,
+		PoolSize:                   opts.PoolSize,
 	})
 }
 
-func newSentinelRedisClient(args *AhoCorasickArgs, addrs []string) redis.UniversalClient {
-	return redis.NewFailoverClient(universalOptions(args, addrs).Failover())
-}
-
-func newClusterRedisClient(args *AhoCorasickArgs, addrs []string) (redis.UniversalClient, error) {
-	client := redis.NewClusterClient(universalOptions(args, addrs).Cluster())
+func newClusterRedisClient(opts *redis.UniversalOptions) (redis.UniversalClient, error) {
+	client := redis.NewClusterClient(opts.Cluster())
 	ctx, cancel := context.WithTimeout(context.Background(), defaultRedisClusterPingTimeout)
 	defer cancel()
 	if err := client.Ping(ctx).Err(); err != nil {
@@ -125,15 +214,16 @@ func newClusterRedisClient(args *AhoCorasickArgs, addrs []string) (redis.Univers
 	return client, nil
 }
 
-func newStandaloneRedisClient(args *AhoCorasickArgs, addrs []string) redis.UniversalClient {
+func newStandaloneRedisClient(args *AhoCorasickArgs, opts *redis.UniversalOptions) redis.UniversalClient {
 	addr := strings.TrimSpace(args.Addr)
-	if addr == "" && len(addrs) > 0 {
-		addr = addrs[0]
+	if addr == "" && len(opts.Addrs) > 0 {
+		addr = opts.Addrs[0]
 	}
 	// A one-element slice, even when empty: Simple() only substitutes its own
 	// default for an empty slice, and go-redis then fills in localhost:6379 as
 	// AhoCorasickArgs.Addr documents.
-	return redis.NewClient(universalOptions(args, []string{addr}).Simple())
+	opts.Addrs = []string{addr}
+	return redis.NewClient(opts.Simple())
 }
 
 func normalizeAddrs(addr string, addrs []string) []string {
@@ -0,0 +1,348 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// Connection is a Redis client that collections share. Pass it as
+// AhoCorasickArgs.Connection to every Create that should use it: a process
+// opening fifty collections then holds one connection pool rather than fifty,
+// and the cache invalidation listeners of all of them share a single Pub/Sub
+// connection, subscribing and unsubscribing channels on it as instances open
+// and close.
+//
+// An instance opened on a Connection never closes it. Close the Connection
+// after every instance using it is closed.
+type Connection struct {
+	client redis.UniversalClient
+	// owned is whether Close closes client: NewConnection built it, while
+	// WrapClient borrowed it from the caller.
+	owned bool
+
+	// writeMu orders the SUBSCRIBE and UNSUBSCRIBE commands sent on pubsub as
+	// the listener changes that called for them, and is held while one is sent.
+	// mu is not, so dispatch never waits on the network.
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	closed  bool
+	// pubsub carries every subscription, opened with the first. Each channel on
+	// it is subscribed while listeners holds an entry for it.
+	pubsub    *redis.PubSub
+	listeners map[string]map[*sharedSubscription]struct{}
+	// ended is closed when dispatch returns, after pubsub closes.
+	ended chan struct{}
+	// confirmed holds the channels Redis has acknowledged subscribing since
+	// the last subscribe for them was sent.
+	confirmed map[string]bool
+}
+
+// NewConnection opens a Connection from the Redis client fields of args, the
+// same ones Create reads: the addresses and topology, credentials, TLS, and
+// the resilience knobs. Fields describing a collection, such as Name or
+// Preset, are ignored. Close closes the client.
+func NewConnection(args *AhoCorasickArgs) (*Connection, error) {
+	if args == nil {
+		return nil, ErrNilArgs
+	}
+	if args.Connection != nil {
+		return nil, ErrConnectionConflict
+	}
+	client, err := newRedisClient(args)
+	if err != nil {
+		return nil, err
+	}
+	return &Connection{client: client, owned: true}, nil
+}
+
+// WrapClient returns a Connection over a client the application already
+// configured, with its hooks, tracing, and TLS, in any of go-redis's
+// topologies. Close leaves client open: the application that built it closes
+// it, after the Connection.
+func WrapClient(client redis.UniversalClient) *Connection {
+	return &Connection{client: client}
+}
+
+// Client returns the client the Connection's collections use.
+func (c *Connection) Client() redis.UniversalClient {
+	return c.client
+}
+
+// Close ends the shared Pub/Sub connection and, for a Connection from
+// NewConnection, closes the client. Instances still open on the Connection
+// stop receiving invalidations, and their Watch channels close. A second Close
+// returns ErrConnectionClosed.
+func (c *Connection) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrConnectionClosed
+	}
+	c.closed = true
+	pubsub := c.pubsub
+	c.mu.Unlock()
+
+	var err error
+	if pubsub != nil {
+		err = pubsub.Close()
+	}
+	if c.owned {
+		err = errors.Join(err, c.client.Close())
+	}
+	return err
+}
+
+// storage returns a kvStorage over the shared client, for an instance opened
+// on the Connection.
+func (c *Connection) storage() (kvStorage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrConnectionClosed
+	}
+	return &redisStorage{client: c.client, conn: c}, nil
+}
+
+// pubsubWriteTimeout bounds each SUBSCRIBE and UNSUBSCRIBE sent on the shared
+// Pub/Sub connection, so a stalled socket holds up opening and closing
+// instances for that long at most.
+const pubsubWriteTimeout = 5 * time.Second
+
+// subscribe adds a listener for channels to the shared Pub/Sub connection,
+// sending SUBSCRIBE for those no other listener holds.
+func (c *Connection) subscribe(ctx context.Context, channels []string) *sharedSubscription {
+	s := &sharedSubscription{
+		conn:     c,
+		channels: channels,
+		ch:       make(chan pubSubMessage, pubsubChannelSize),
+		pending:  make(map[string]struct{}, len(channels)),
+		ready:    make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		s.err = ErrConnectionClosed
+		return s
+	}
+
+	var fresh []string
+	for _, channel := range channels {
+		if len(c.listeners[channel]) == 0 {
+			fresh = append(fresh, channel)
+		}
+		if !c.confirmed[channel] {
+			s.pending[channel] = struct{}{}
+		}
+	}
+	if c.pubsub == nil {
+		c.pubsub = c.client.Subscribe(ctx)
+		c.listeners = make(map[string]map[*sharedSubscription]struct{})
+		c.confirmed = make(map[string]bool)
+		c.ended = make(chan struct{})
+		go c.dispatch(c.pubsub.ChannelWithSubscriptions())
+	}
+	s.ended = c.ended
+	for _, channel := range channels {
+		if c.listeners[channel] == nil {
+			c.listeners[channel] = make(map[*sharedSubscription]struct{})
+		}
+		c.listeners[channel][s] = struct{}{}
+	}
+	if len(s.pending) == 0 {
+		close(s.ready)
+	}
+	pubsub := c.pubsub
+	c.mu.Unlock()
+
+	if len(fresh) > 0 {
+		ctx, cancel := context.WithTimeout(ctx, pubsubWriteTimeout)
+		defer cancel()
+		if err := pubsub.Subscribe(ctx, fresh...); err != nil {
+			// A channel the write did reach stays subscribed with nobody
+			// listening, as after a failed UNSUBSCRIBE.
+			c.mu.Lock()
+			c.remove(s)
+			c.mu.Unlock()
+			s.err = err
+		}
+	}
+	return s
+}
+
+// unsubscribe removes s, sending UNSUBSCRIBE for the channels no listener is
+// left on.
+func (c *Connection) unsubscribe(s *sharedSubscription) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.mu.Lock()
+	idle := c.remove(s)
+	closed, pubsub := c.closed, c.pubsub
+	c.mu.Unlock()
+	if len(idle) > 0 && !closed {
+		// Best effort: a failed write leaves the channel subscribed with nobody
+		// listening, and dispatch drops what arrives on it.
+		ctx, cancel := context.WithTimeout(context.Background(), pubsubWriteTimeout)
+		defer cancel()
+		_ = pubsub.Unsubscribe(ctx, idle...)
+	}
+}
+
+// remove takes s off its channels and returns those no listener is left on.
+// The caller holds mu.
+func (c *Connection) remove(s *sharedSubscription) []string {
+	var idle []string
+	for _, channel := range s.channels {
+		subs, ok := c.listeners[channel]
+		if !ok {
+			continue
+		}
+		delete(subs, s)
+		if len(subs) == 0 {
+			delete(c.listeners, channel)
+			delete(c.confirmed, channel)
+			idle = append(idle, channel)
+		}
+	}
+	return idle
+}
+
+// dispatch delivers what the shared Pub/Sub connection receives, until Close
+// ends it: a subscribe acknowledgement to the listeners waiting on it, and a
+// message to every listener on its channel. go-redis resubscribes after a
+// reconnect, so the acknowledgements repeat then, harmlessly.
+func (c *Connection) dispatch(msgs <-chan interface{}) {
+	for msg := range msgs {
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			if msg.Kind != "subscribe" {
+				continue
+			}
+			c.mu.Lock()
+			if subs, ok := c.listeners[msg.Channel]; ok {
+				c.confirmed[msg.Channel] = true
+				for s := range subs {
+					s.confirm(msg.Channel)
+				}
+			}
+			c.mu.Unlock()
+		case *redis.Message:
+			c.mu.Lock()
+			subs := make([]*sharedSubscription, 0, len(c.listeners[msg.Channel]))
+			for s := range c.listeners[msg.Channel] {
+				subs = append(subs, s)
+			}
+			c.mu.Unlock()
+			for _, s := range subs {
+				s.deliver(pubSubMessage{Channel: msg.Channel, Payload: msg.Payload})
+			}
+		}
+	}
+
+	// The Pub/Sub connection is closed: end every listener's channel, as a
+	// subscription of its own would end when its connection closed.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	close(c.ended)
+	ended := make(map[*sharedSubscription]struct{})
+	for _, subs := range c.listeners {
+		for s := range subs {
+			ended[s] = struct{}{}
+		}
+	}
+	for s := range ended {
+		close(s.ch)
+	}
+	c.listeners = nil
+}
+
+// sharedSubscription is one listener's share of a Connection's Pub/Sub
+// connection. It implements subscription.
+type sharedSubscription struct {
+	conn     *Connection
+	channels []string
+	ch       chan pubSubMessage
+	// err is why the subscription failed, set before subscribe returns it.
+	err error
+	// pending holds the channels not yet acknowledged, and ready is closed when
+	// it empties. Both are guarded by conn.mu.
+	pending map[string]struct{}
+	ready   chan struct{}
+	// ended is the Connection's, closed with its Pub/Sub connection.
+	ended     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// confirm records the acknowledgement of channel. The caller holds conn.mu.
+func (s *sharedSubscription) confirm(channel string) {
+	if _, ok := s.pending[channel]; !ok {
+		return
+	}
+	delete(s.pending, channel)
+	if len(s.pending) == 0 {
+		close(s.ready)
+	}
+}
+
+// deliver hands msg to the listener without waiting: dispatch serves every
+// listener on the Connection, so one that falls behind must not hold up the
+// rest. When the listener's buffer is full, its oldest message makes room for
+// msg stripped of its payload. Every listener reads a message without one as a
+// change it did not make, so whatever the dropped message announced is still
+// acted on. Only dispatch sends on ch, so the room it makes stays free.
+func (s *sharedSubscription) deliver(msg pubSubMessage) {
+	select {
+	case s.ch <- msg:
+		return
+	default:
+	}
+	select {
+	case <-s.ch:
+	default:
+	}
+	select {
+	case s.ch <- pubSubMessage{Channel: msg.Channel}:
+	default:
+	}
+}
+
+// Receive waits until Redis has acknowledged every channel of the
+// subscription, so that a message published after it returns is delivered.
+func (s *sharedSubscription) Receive(ctx context.Context) error {
+	if s.err != nil {
+		return s.err
+	}
+	select {
+	case <-s.ready:
+		return nil
+	case <-s.ended:
+		return ErrConnectionClosed
+	case <-s.done:
+		return ErrConnectionClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *sharedSubscription) Channel() <-chan pubSubMessage {
+	return s.ch
+}
+
+func (s *sharedSubscription) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		if s.err == nil {
+			s.conn.unsubscribe(s)
+		}
+	})
+	return nil
+}
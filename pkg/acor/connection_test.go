@@ -0,0 +1,259 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+)
+
+func newTestConnection(t *testing.T, mr *miniredis.Miniredis) *Connection {
+	t.Helper()
+	conn, err := NewConnection(&AhoCorasickArgs{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("NewConnection: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestConnectionSharedAcrossCollections(t *testing.T) {
+	mr := miniredis.RunT(t)
+	conn := newTestConnection(t, mr)
+
+	// Two cached instances of one collection, and a preset one of another: three
+	// invalidation listeners, two channels.
+	open := func(args AhoCorasickArgs) *AhoCorasick {
+		t.Helper()
+		args.Connection = conn
+		ac, err := Create(&args)
+		if err != nil {
+			t.Fatalf("Create(%s): %v", args.Name, err)
+		}
+		t.Cleanup(func() { _ = ac.Close() })
+		return ac
+	}
+	first := open(AhoCorasickArgs{Name: "rules", EnableCache: true})
+	second := open(AhoCorasickArgs{Name: "rules", EnableCache: true})
+	preset := open(AhoCorasickArgs{Name: "blocklist", Preset: PresetSpeed})
+
+	subs := mr.PubSubNumSub(invalidateChannelPrefix+"rules", invalidateChannelPrefix+"blocklist")
+	if subs[invalidateChannelPrefix+"rules"] != 1 || subs[invalidateChannelPrefix+"blocklist"] != 1 {
+		t.Errorf("subscribers per channel = %v, want one each on the shared Pub/Sub connection", subs)
+	}
+
+	// second caches the empty trie, then picks up first's write through the
+	// invalidation both receive on the one subscription.
+	if got, _ := second.Find("he"); len(got) != 0 {
+		t.Fatalf("Find before Add = %v", got)
+	}
+	if _, err := first.Add("he"); err != nil {
+		t.Fatal(err)
+	}
+	if !eventually(t, 2*time.Second, func() bool {
+		got, _ := second.Find("he")
+		return len(got) == 1
+	}) {
+		t.Error("second instance never saw the first's Add")
+	}
+
+	// A writer with a client of its own reaches the preset engine on the shared one.
+	writer, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "blocklist"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Add("spam"); err != nil {
+		t.Fatal(err)
+	}
+	if !eventually(t, 2*time.Second, func() bool {
+		got, _ := preset.Find("spam")
+		return len(got) == 1
+	}) {
+		t.Error("preset instance never saw the other client's Add")
+	}
+
+	// Closing an instance leaves the client open for the others, and drops the
+	// channel only with its last listener.
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Client().Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("shared client closed with an instance: %v", err)
+	}
+	if got, err := second.Find("he"); err != nil || len(got) != 1 {
+		t.Errorf("Find on the remaining instance = %v, %v", got, err)
+	}
+	if n := mr.PubSubNumSub(invalidateChannelPrefix + "rules")[invalidateChannelPrefix+"rules"]; n != 1 {
+		t.Errorf("subscribers after closing one of two listeners = %d, want 1", n)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !eventually(t, 2*time.Second, func() bool {
+		return mr.PubSubNumSub(invalidateChannelPrefix + "rules")[invalidateChannelPrefix+"rules"] == 0
+	}) {
+		t.Error("channel still subscribed after its last listener closed")
+	}
+}
+
+func TestConnectionSlowListenerDoesNotStallOthers(t *testing.T) {
+	mr := miniredis.RunT(t)
+	conn := newTestConnection(t, mr)
+	ctx := context.Background()
+
+	slow := conn.subscribe(ctx, []string{"slow"})
+	defer slow.Close()
+	fast := conn.subscribe(ctx, []string{"fast"})
+	defer fast.Close()
+	for _, s := range []*sharedSubscription{slow, fast} {
+		if err := s.Receive(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Nobody reads slow, which overflows its buffer several times.
+	for i := 0; i < 3*pubsubChannelSize; i++ {
+		mr.Publish("slow", "payload")
+	}
+	mr.Publish("fast", "payload")
+	select {
+	case msg := <-fast.Channel():
+		if msg.Payload != "payload" {
+			t.Errorf("fast listener got %+v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("a full listener stalled delivery to another")
+	}
+
+	// slow kept a full buffer, and what it lost is marked by a payload-less message.
+	if !eventually(t, 2*time.Second, func() bool { return len(slow.Channel()) == pubsubChannelSize }) {
+		t.Fatalf("slow listener holds %d messages, want %d", len(slow.Channel()), pubsubChannelSize)
+	}
+	var marked bool
+	for len(slow.Channel()) > 0 {
+		if msg := <-slow.Channel(); msg.Payload == "" {
+			marked = true
+		}
+	}
+	if !marked {
+		t.Error("no payload-less message stands for the dropped ones")
+	}
+}
+
+func TestWrapClientLeavesClientOpen(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	conn := WrapClient(client)
+	ac, err := Create(&AhoCorasickArgs{Connection: conn, Name: "rules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ac.Add("he"); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := ac.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An alias-opened instance and its preset target share the client too.
+	if err := ac.SetAlias("live"); err != nil {
+		t.Fatal(err)
+	}
+	aliased, err := Create(&AhoCorasickArgs{Connection: conn, Alias: "live", Preset: PresetBalanced})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := aliased.Find("she"); err != nil || len(got) != 1 {
+		t.Errorf("Find through the alias = %v, %v", got, err)
+	}
+	if err := aliased.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ac.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Close(); !errors.Is(err, ErrConnectionClosed) {
+		t.Errorf("second Close = %v, want ErrConnectionClosed", err)
+	}
+	if n, err := client.Exists(context.Background(), trieKey("rules")).Result(); err != nil || n != 1 {
+		t.Errorf("client after Connection.Close: Exists = %d, %v", n, err)
+	}
+	select {
+	case _, ok := <-changes:
+		if ok {
+			// A change may be pending; the channel must still close.
+			<-changes
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("Watch channel still open after the instance closed")
+	}
+}
+
+func TestConnectionWatchEndsWithConnection(t *testing.T) {
+	mr := miniredis.RunT(t)
+	conn, err := NewConnection(&AhoCorasickArgs{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ac, err := Create(&AhoCorasickArgs{Connection: conn, Name: "rules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ac.Close()
+	changes, err := ac.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Error("Watch delivered a change instead of closing")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Watch channel still open after Connection.Close")
+	}
+	if _, err := ac.Watch(); !errors.Is(err, ErrConnectionClosed) {
+		t.Errorf("Watch after Connection.Close = %v, want ErrConnectionClosed", err)
+	}
+	if _, err := Create(&AhoCorasickArgs{Connection: conn, Name: "other"}); !errors.Is(err, ErrConnectionClosed) {
+		t.Errorf("Create on a closed Connection = %v, want ErrConnectionClosed", err)
+	}
+}
+
+func TestConnectionRejectsClientSettings(t *testing.T) {
+	mr := miniredis.RunT(t)
+	conn := newTestConnection(t, mr)
+
+	for _, args := range []*AhoCorasickArgs{
+		{Connection: conn, Name: "rules", Addr: mr.Addr()},
+		{Connection: conn, Name: "rules", PoolSize: 4},
+		{Connection: conn, Name: "rules", Preset: PresetBalanced, Password: "secret"},
+		{Connection: conn, Alias: "live", TLS: &TLSOptions{}},
+	} {
+		if _, err := Create(args); !errors.Is(err, ErrConnectionConflict) {
+			t.Errorf("Create(%+v) = %v, want ErrConnectionConflict", args, err)
+		}
+	}
+	if _, err := NewConnection(&AhoCorasickArgs{Connection: conn}); !errors.Is(err, ErrConnectionConflict) {
+		t.Errorf("NewConnection with Connection = %v, want ErrConnectionConflict", err)
+	}
+	if _, err := NewConnection(nil); !errors.Is(err, ErrNilArgs) {
+		t.Errorf("NewConnection(nil) = %v, want ErrNilArgs", err)
+	}
+}
@@ -79,10 +79,18 @@ func (ac *AhoCorasick) AddManyContext(ctx context.Context, keywords []string, op
 		Skipped: make([]string, 0),
 	}
 
+	if opts.TTL < 0 {
+		return nil, ErrInvalidTTL
+	}
+	tags, err := normalizeTags(opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+
 	if opts.Mode == BatchModeTransactional {
-		return ac.addManyTransactional(ctx, keywords, result)
+		return ac.addManyTransactional(ctx, keywords, opts.TTL, tags, result)
 	}
-	return ac.addManyBestEffort(ctx, keywords, result)
+	return ac.addManyBestEffort(ctx, keywords, opts.TTL, tags, result)
 }
 
 // RemoveManyContext removes multiple keywords with context for cancellation and
@@ -0,0 +1,513 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Collection copies: CopyCollection reads the source's trie in one snapshot,
+// plans the destination from it once, and writes the destination's output states
+// and keyword index to staging keys beside it in batches, so the copy costs one
+// pass over the dictionary and can cross deployments — a standalone Redis to a
+// cluster, say. copySwapScript then moves the staged keys into place and writes
+// the trie at the source's version in one step, under the read-only and version
+// checks every write makes, recording the copy in the destination's history. The
+// source's expiries, tags, and weights go with its keywords, so a VersionToken
+// taken before the move still reads as current after it.
+
+const (
+	// DefaultCopyBatchSize is how many keywords CopyCollection moves per page
+	// when CopyOptions.BatchSize is zero.
+	DefaultCopyBatchSize = 1000
+
+	// copyReadOnlyTTL is how long a source's read-only marker, or a staging key,
+	// lives unless renewed. CopyCollection renews both after every batch, so only
+	// a copier that has stopped lets them lapse.
+	copyReadOnlyTTL = time.Minute
+)
+
+// CopyOptions configures CopyCollection and Rename.
+type CopyOptions struct {
+	// BatchSize is how many output states, keywords, or weights each staging
+	// write to the destination carries. Zero or negative means
+	// DefaultCopyBatchSize.
+	BatchSize int
+	// ReadOnlySource makes every keyword write to the source — Add, Remove,
+	// Flush, the expiry sweep, and the rest — fail with ErrReadOnly until the copy
+	// returns, so the copy is of one version. Without it writers carry on, and a
+	// write that lands mid-copy fails the verification with ErrCopyMismatch
+	// instead. Weights are not covered; SetWeights still goes through.
+	//
+	// The hold lapses on its own about a minute after a copier dies.
+	ReadOnlySource bool
+	// DeleteSource deletes the source once the copy has been verified, moving the
+	// collection rather than copying it. It implies ReadOnlySource, so no write
+	// can land in the source after it was read and be deleted with it.
+	DeleteSource bool
+	// Progress, when set, is called after each batch of keywords is staged, with
+	// the keywords staged so far and the number the copy will write.
+	Progress func(copied, total int)
+}
+
+// CopyReport describes a finished copy.
+type CopyReport struct {
+	// Source and Destination are the collection names.
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	// Keywords is how many keywords were copied; expired ones are not.
+	Keywords int `json:"keywords"`
+	// Weights is how many stored weights were copied.
+	Weights int `json:"weights"`
+	// Version is the source's version, which the destination now carries too.
+	Version int64 `json:"version"`
+	// Checksum is the hex SHA-256 of the keywords in byte order together with
+	// their expiries and tags, which both collections were verified to share.
+	Checksum string `json:"checksum"`
+	// SourceDeleted reports whether the source was deleted (DeleteSource).
+	SourceDeleted bool `json:"source_deleted"`
+	// DurationMs is how long the copy took, in milliseconds.
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// CopyCollection copies the collection src names into the one dst names, which
+// may be on another Redis deployment. Both are opened for the duration of the
+// call and closed before it returns; their Preset, EnableCache, and background
+// settings are ignored, and History on dst records the copy's writes there.
+//
+// The source's live keywords are read in one snapshot, with their expiries and
+// tags. The destination's output states and keyword index are staged in batches
+// of opts.BatchSize, stored weights follow in batches of the same size, and one
+// script then swaps the staged keys in and sets the destination to the source's
+// version. It refuses with ErrReadOnly while the destination is held read-only,
+// and with ErrInvalidCopy when the destination was written during the copy. Last,
+// the two are compared: the same keyword count and the same checksum, and a
+// source still at the version the copy read. Anything else fails with
+// ErrCopyMismatch.
+//
+// Both collections must be V2, opened by Name rather than Alias, and share case
+// sensitivity, since keywords are copied as they are stored; and the destination
+// must hold no keywords yet. Each of those is ErrInvalidCopy before anything is
+// written.
+func CopyCollection(ctx context.Context, src, dst *AhoCorasickArgs, opts CopyOptions) (*CopyReport, error) {
+	if src == nil || dst == nil {
+		return nil, ErrNilArgs
+	}
+	if err := checkCopyArgs(src, dst); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	batch := opts.BatchSize
+	if batch <= 0 {
+		batch = DefaultCopyBatchSize
+	}
+
+	from, err := openCopyEnd(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = from.Close() }()
+	to, err := openCopyEnd(ctx, dst)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = to.Close() }()
+	if _, ok := from.ops.(*v2Operations); !ok {
+		return nil, fmt.Errorf("%w: source %q is not a V2 collection", ErrInvalidCopy, src.Name)
+	}
+	toOps, ok := to.ops.(*v2Operations)
+	if !ok {
+		return nil, fmt.Errorf("%w: destination %q is not a V2 collection", ErrInvalidCopy, dst.Name)
+	}
+
+	if opts.ReadOnlySource || opts.DeleteSource {
+		release, err := holdReadOnly(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	now := time.Now()
+	old, err := readTrieSnapshot(ctx, to.storage, to.name)
+	if err != nil {
+		return nil, err
+	}
+	if n := len(liveKeywords(old, now)); n > 0 {
+		return nil, fmt.Errorf("%w: destination %q already holds %d keywords", ErrInvalidCopy, dst.Name, n)
+	}
+	source, err := readTrieSnapshot(ctx, from.storage, from.name)
+	if err != nil {
+		return nil, err
+	}
+	snap, outputs := planCopy(source, now)
+	checksum := snapshotChecksum(snap)
+
+	id := newInvalidationID()
+	stage := copyStaging{
+		outputs: copyStagingKey(to.name, id, "outputs"),
+		index:   copyStagingKey(to.name, id, "index"),
+	}
+	// Gone already after a swap; left behind by any failure before it.
+	defer func() {
+		if err := to.storage.Del(context.WithoutCancel(ctx), stage.outputs, stage.index); err != nil {
+			to.logger.Printf("failed to delete copy staging keys: error=%v", err)
+		}
+	}()
+	renew := func() error {
+		if !opts.ReadOnlySource && !opts.DeleteSource {
+			return nil
+		}
+		if err := from.storage.PExpire(ctx, readOnlyKey(from.name), copyReadOnlyTTL); err != nil {
+			return newRedisError("PEXPIRE", readOnlyKey(from.name), err)
+		}
+		return nil
+	}
+	report := &CopyReport{Source: src.Name, Destination: dst.Name, Version: source.Version}
+	err = stage.write(ctx, to.storage, snap.Keywords, outputs, batch, func(staged int) error {
+		report.Keywords = staged
+		if opts.Progress != nil {
+			opts.Progress(staged, len(snap.Keywords))
+		}
+		return renew()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if report.Weights, err = copyWeights(ctx, from, to, batch); err != nil {
+		return nil, err
+	}
+	if err := swapCopy(ctx, toOps, stage, old, snap); err != nil {
+		return nil, err
+	}
+	toOps.publishInvalidate(ctx)
+
+	if err := verifyCopy(ctx, from, to, source.Version, snap, checksum); err != nil {
+		return nil, err
+	}
+	report.Checksum = checksum
+
+	if opts.DeleteSource {
+		if err := deleteCollection(ctx, from); err != nil {
+			return nil, err
+		}
+		report.SourceDeleted = true
+	}
+	report.DurationMs = time.Since(start).Milliseconds()
+	return report, nil
+}
+
+// Rename moves the collection args names to newName on the same deployment:
+// CopyCollection with DeleteSource set, so the source is held read-only until it
+// has been copied, verified, and deleted. Instances still open on the old name
+// see an empty collection afterwards; reopen them under newName, or put an alias
+// in front of the collection beforehand (see SetAlias) so they need not care.
+func Rename(ctx context.Context, args *AhoCorasickArgs, newName string, opts CopyOptions) (*CopyReport, error) {
+	if args == nil {
+		return nil, ErrNilArgs
+	}
+	dst := *args
+	dst.Name = newName
+	opts.DeleteSource = true
+	return CopyCollection(ctx, args, &dst, opts)
+}
+
+// checkCopyArgs rejects what CopyCollection cannot copy faithfully, before
+// either end is opened.
+func checkCopyArgs(src, dst *AhoCorasickArgs) error {
+	switch {
+	case src.Alias != "" || dst.Alias != "":
+		return fmt.Errorf("%w: open both collections by Name; ResolveAlias finds the one an alias names", ErrInvalidCopy)
+	case src.SchemaVersion == SchemaV1 || dst.SchemaVersion == SchemaV1:
+		return fmt.Errorf("%w: V1 collections cannot be copied; migrate with MigrateV1ToV2 first", ErrInvalidCopy)
+	case src.CaseSensitive != dst.CaseSensitive:
+		return fmt.Errorf("%w: source and destination differ in case sensitivity", ErrInvalidCopy)
+	case src.Name == dst.Name && sameDeployment(src, dst):
+		return fmt.Errorf("%w: source and destination are the same collection", ErrInvalidCopy)
+	}
+	return nil
+}
+
+// sameDeployment reports whether a and b connect to the same Redis, as far as
+// their settings tell.
+func sameDeployment(a, b *AhoCorasickArgs) bool {
+	return a.Addr == b.Addr && slices.Equal(a.Addrs, b.Addrs) && a.MasterName == b.MasterName &&
+		maps.Equal(a.RingAddrs, b.RingAddrs) && a.DB == b.DB
+}
+
+// openCopyEnd opens one end of a copy as a plain V2 instance, without the local
+// engine, cache, or background work args may ask for: a copy reads and writes
+// Redis directly and should leave nothing running behind it.
+func openCopyEnd(ctx context.Context, args *AhoCorasickArgs) (*AhoCorasick, error) {
+	plain := *args
+	plain.Preset = PresetNone
+	plain.EnableCache = false
+	plain.InvalidationPollInterval = 0
+	plain.ExpirySweepInterval = -1
+	return CreateContext(ctx, &plain)
+}
+
+// holdReadOnly sets ac's read-only marker and returns the func that clears it,
+// failing with ErrReadOnly when another copy already holds it.
+func holdReadOnly(ctx context.Context, ac *AhoCorasick) (func(), error) {
+	key := readOnlyKey(ac.name)
+	ok, err := ac.storage.SetNX(ctx, key, newInvalidationID(), copyReadOnlyTTL)
+	if err != nil {
+		return nil, newRedisError("SET", key, err)
+	}
+	if !ok {
+		return nil, ErrReadOnly
+	}
+	return func() {
+		// The caller's ctx may be what ended the copy; clear the marker anyway.
+		if err := ac.storage.Del(context.WithoutCancel(ctx), key); err != nil {
+			ac.logger.Printf("failed to clear read-only marker: key=%s error=%v", key, err)
+		}
+	}, nil
+}
+
+// liveKeywords returns snap's keywords that have not expired by now.
+func liveKeywords(snap *trieSnapshot, now time.Time) []string {
+	live := make([]string, 0, len(snap.Keywords))
+	for _, kw := range snap.Keywords {
+		if !snap.Expiries.expired(kw, now) {
+			live = append(live, kw)
+		}
+	}
+	return live
+}
+
+// planCopy plans the destination of a copy of source: its keywords live at now,
+// with their expiries and tags, added to an empty trie at source's version. It
+// returns the planned trie and its output states.
+func planCopy(source *trieSnapshot, now time.Time) (*trieSnapshot, map[string][]string) {
+	snap := &trieSnapshot{Prefixes: []string{""}, Version: source.Version}
+	outputs, _ := planAddMany(snap, liveKeywords(source, now))
+	for _, kw := range snap.Keywords {
+		if at, ok := source.Expiries[kw]; ok {
+			snap.setExpiry([]string{kw}, at)
+		}
+		snap.addTags([]string{kw}, source.Tags[kw])
+	}
+	return snap, outputs
+}
+
+// copyStaging names the keys a copy builds the destination's outputs hash and
+// keyword index in before copySwapScript moves them into place.
+type copyStaging struct {
+	outputs, index string
+}
+
+// write stages outputs and keywords, batch fields or members per write, each
+// staging key renewed to expire copyReadOnlyTTL after its last write. After each
+// batch of keywords it calls staged with the number staged so far.
+func (s copyStaging) write(ctx context.Context, storage kvStorage, keywords []string,
+	outputs map[string][]string, batch int, staged func(int) error) error {
+	states := slices.Sorted(maps.Keys(outputs))
+	for chunk := range slices.Chunk(states, batch) {
+		values := make([]interface{}, 0, 2*len(chunk))
+		for _, state := range chunk {
+			encoded, err := toJSON(outputs[state])
+			if err != nil {
+				return newOperationError("marshal", SchemaV2, err)
+			}
+			values = append(values, state, encoded)
+		}
+		if err := storage.HSet(ctx, s.outputs, values...); err != nil {
+			return newRedisError("HSET", s.outputs, err)
+		}
+		if err := storage.PExpire(ctx, s.outputs, copyReadOnlyTTL); err != nil {
+			return newRedisError("PEXPIRE", s.outputs, err)
+		}
+	}
+	done := 0
+	for chunk := range slices.Chunk(keywords, batch) {
+		members := make([]*zMember, len(chunk))
+		for i, kw := range chunk {
+			members[i] = &zMember{Member: kw}
+		}
+		if err := storage.ZAdd(ctx, s.index, members...); err != nil {
+			return newRedisError("ZADD", s.index, err)
+		}
+		if err := storage.PExpire(ctx, s.index, copyReadOnlyTTL); err != nil {
+			return newRedisError("PEXPIRE", s.index, err)
+		}
+		done += len(chunk)
+		if err := staged(done); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copySwapScript puts a staged copy in place of the destination's keys. KEYS are
+// the destination's trie, outputs, read-only marker, and index keys, the staged
+// outputs and index, and, when history is on, the history stream. ARGV are the
+// version the destination was read at, the source's version, the keyword,
+// prefix, expiry, and tag JSON of the new trie, the JSON array of stored keywords
+// it drops, and the history MaxLen, op, and writer.
+//
+// Like v2WriteScript it returns -1 while the destination is read-only and 0 when
+// a write has moved its version on since it was read, writing nothing either
+// way, so the copy neither lands on a collection that took a write of its own
+// nor skips the history entry. A staged key that was never written, because the
+// copy had no keywords, leaves the destination without that key.
+var copySwapScript = redis.NewScript(`
+	local trieKey = KEYS[1]
+	if redis.call('EXISTS', KEYS[3]) == 1 then
+		return -1
+	end
+	local current = redis.call('HGET', trieKey, 'version')
+	if current and current ~= ARGV[1] then
+		return 0
+	end
+
+	local historyKey = KEYS[7]
+	if historyKey then
+		redis.call('XADD', historyKey, 'MAXLEN', ARGV[8], '*',
+			'op', ARGV[9], 'version', ARGV[2], 'prev', ARGV[1],
+			'added', ARGV[3], 'removed', ARGV[7], 'writer', ARGV[10])
+	end
+
+	redis.call('DEL', trieKey, KEYS[2], KEYS[4])
+	for i, key in ipairs({KEYS[2], KEYS[4]}) do
+		local staged = KEYS[4 + i]
+		if redis.call('EXISTS', staged) == 1 then
+			redis.call('RENAME', staged, key)
+			redis.call('PERSIST', key)
+		end
+	end
+	redis.call('HSET', trieKey, 'keywords', ARGV[3], 'prefixes', ARGV[4], 'version', ARGV[2], 'indexed', '1')
+	if ARGV[5] ~= '{}' then
+		redis.call('HSET', trieKey, 'expiries', ARGV[5])
+	end
+	if ARGV[6] ~= '{}' then
+		redis.call('HSET', trieKey, 'tags', ARGV[6])
+	end
+	return 1
+`)
+
+// swapCopy runs copySwapScript to replace o's collection, last read as old, with
+// the staged copy of snap.
+func swapCopy(ctx context.Context, o *v2Operations, stage copyStaging, old, snap *trieSnapshot) error {
+	args, err := marshalTrieArgs(o.name, snap, nil, snap.Version, false,
+		o.history.change(historyOpCopy, snap.Keywords, old.Keywords))
+	if err != nil {
+		return err
+	}
+	keys := []string{args.TrieKey, args.OutputsKey, args.ReadOnlyKey, args.IndexKey, stage.outputs, stage.index}
+	argv := []interface{}{old.Version, snap.Version, args.Keywords, args.Prefixes, args.Expiries, args.Tags,
+		args.Removed}
+	if h := args.History; h != nil {
+		keys = append(keys, h.Key)
+		argv = append(argv, h.MaxLen, h.Op, h.Writer)
+	}
+	result, err := copySwapScript.Run(ctx, o.client, keys, argv...).Int64()
+	if err != nil {
+		return newRedisError("EVAL", args.TrieKey, err)
+	}
+	switch result {
+	case 0:
+		return fmt.Errorf("%w: destination %q was written during the copy", ErrInvalidCopy, o.name)
+	case -1:
+		return ErrReadOnly
+	}
+	RecordVersion(ctx, snap.Version)
+	return nil
+}
+
+// copyWeights copies the stored weights of from to to, a page of HSCAN at a time,
+// and returns how many there were.
+func copyWeights(ctx context.Context, from, to *AhoCorasick, batch int) (int, error) {
+	srcKey, dstKey := weightsKey(from.name), weightsKey(to.name)
+	copied := 0
+	var cursor uint64
+	for {
+		fields, next, err := from.storage.HScan(ctx, srcKey, cursor, int64(batch))
+		if err != nil {
+			return 0, newRedisError("HSCAN", srcKey, err)
+		}
+		if len(fields) > 0 {
+			values := make([]interface{}, len(fields))
+			for i, f := range fields {
+				values[i] = f
+			}
+			if err := to.storage.HSet(ctx, dstKey, values...); err != nil {
+				return 0, newRedisError("HSET", dstKey, err)
+			}
+			copied += len(fields) / 2
+		}
+		if next == 0 {
+			return copied, nil
+		}
+		cursor = next
+	}
+}
+
+// verifyCopy compares a finished copy with the source it was planned from, snap
+// with checksum at source version version, and returns ErrCopyMismatch unless
+// both still agree. Each end is read once.
+func verifyCopy(ctx context.Context, from, to *AhoCorasick, version int64, snap *trieSnapshot, checksum string) error {
+	now, err := readStoredVersion(ctx, from.storage, from.name)
+	if err != nil {
+		return err
+	}
+	if now != version {
+		return fmt.Errorf("%w: source %q was written during the copy", ErrCopyMismatch, from.name)
+	}
+	copied, err := readTrieSnapshot(ctx, to.storage, to.name)
+	if err != nil {
+		return err
+	}
+	if len(copied.Keywords) != len(snap.Keywords) {
+		return fmt.Errorf("%w: source holds %d keywords, destination %d", ErrCopyMismatch,
+			len(snap.Keywords), len(copied.Keywords))
+	}
+	if sum := snapshotChecksum(copied); sum != checksum {
+		return fmt.Errorf("%w: checksum %s, destination %s", ErrCopyMismatch, checksum, sum)
+	}
+	return nil
+}
+
+// snapshotChecksum hashes snap's keywords in byte order, each with its expiry
+// and tags.
+func snapshotChecksum(snap *trieSnapshot) string {
+	h := sha256.New()
+	for _, kw := range slices.Sorted(slices.Values(snap.Keywords)) {
+		expiry := ""
+		if at, ok := snap.Expiries[kw]; ok {
+			expiry = strconv.FormatInt(at, 10)
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00%s\n", kw, expiry, strings.Join(snap.Tags[kw], "\x00"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// deleteCollection removes every key of ac's collection but its read-only marker,
+// which the copy holding it clears on its way out, and tells the collection's
+// other instances.
+func deleteCollection(ctx context.Context, ac *AhoCorasick) error {
+	name := ac.name
+	ops, ok := ac.ops.(*v2Operations)
+	if !ok {
+		return fmt.Errorf("%w: source %q is not a V2 collection", ErrInvalidCopy, name)
+	}
+	err := ac.storage.Del(ctx, trieKey(name), outputsKey(name), nodesKey(name), weightsKey(name), historyKey(name),
+		indexKey(name))
+	if err != nil {
+		return newRedisError("DEL", trieKey(name), err)
+	}
+	ops.publishInvalidate(ctx)
+	return nil
+}
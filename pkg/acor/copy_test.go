@@ -0,0 +1,240 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestCopyCollection_CopiesKeywordsAttributesAndVersion(t *testing.T) {
+	srcRedis, dstRedis := miniredis.RunT(t), miniredis.RunT(t)
+	src := createCollection(t, srcRedis, "rules", "apple", "banana", "cherry", "date", "elder")
+	if _, err := src.AddWithTTL("fig", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.AddWithTags("grape", "fruit", "purple"); err != nil {
+		t.Fatal(err)
+	}
+	weights := map[string]KeywordWeight{"apple": {Weight: 2, Category: "fruit"}}
+	if err := src.SetWeights(weights); err != nil {
+		t.Fatal(err)
+	}
+	version, err := src.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var progress []int
+	report, err := CopyCollection(context.Background(),
+		&AhoCorasickArgs{Addr: srcRedis.Addr(), Name: "rules"},
+		&AhoCorasickArgs{Addr: dstRedis.Addr(), Name: "rules"},
+		CopyOptions{BatchSize: 3, Progress: func(copied, total int) {
+			if total != 7 {
+				t.Errorf("Progress total = %d, want 7", total)
+			}
+			progress = append(progress, copied)
+		}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(progress, []int{3, 6, 7}) {
+		t.Errorf("Progress calls = %v, want [3 6 7]", progress)
+	}
+	if report.Keywords != 7 || report.Weights != 1 || report.Version != version || report.Checksum == "" || report.SourceDeleted {
+		t.Errorf("report = %+v", report)
+	}
+
+	dst := createCollection(t, dstRedis, "rules")
+	if got, err := dst.Version(); err != nil || got != version {
+		t.Errorf("destination Version() = %d, %v; want %d", got, err, version)
+	}
+	if got, err := dst.Find("apple fig grape"); err != nil || !slices.Equal(got, []string{"apple", "fig", "grape"}) {
+		t.Errorf("destination Find = %v, %v", got, err)
+	}
+	if got, err := dst.ListTag("purple"); err != nil || !slices.Equal(got, []string{"grape"}) {
+		t.Errorf("destination ListTag(purple) = %v, %v; want [grape]", got, err)
+	}
+	if got, err := dst.Weights(); err != nil || !reflect.DeepEqual(got, weights) {
+		t.Errorf("destination Weights() = %v, %v; want %v", got, err, weights)
+	}
+	srcSnap, _ := readTrieSnapshot(context.Background(), src.storage, "rules")
+	dstSnap, _ := readTrieSnapshot(context.Background(), dst.storage, "rules")
+	if !reflect.DeepEqual(srcSnap.Expiries, dstSnap.Expiries) {
+		t.Errorf("destination expiries = %v, want %v", dstSnap.Expiries, srcSnap.Expiries)
+	}
+	if got := indexMembers(t, dstRedis, "rules"); len(got) != 7 {
+		t.Errorf("destination index = %v, want the 7 copied keywords", got)
+	}
+	for _, key := range dstRedis.Keys() {
+		if strings.Contains(key, ":copy:") {
+			t.Errorf("staging key %s survived the copy", key)
+		}
+	}
+}
+
+func TestCopyCollection_RecordsOneHistoryEntry(t *testing.T) {
+	mr := miniredis.RunT(t)
+	createCollection(t, mr, "src", "apple", "banana", "cherry")
+
+	dstArgs := &AhoCorasickArgs{Addr: mr.Addr(), Name: "dst", History: &HistoryOptions{}}
+	report, err := CopyCollection(context.Background(),
+		&AhoCorasickArgs{Addr: mr.Addr(), Name: "src"}, dstArgs, CopyOptions{BatchSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := Create(dstArgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = dst.Close() }()
+	entries, err := dst.History(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("History() = %+v, want one entry", entries)
+	}
+	e := entries[0]
+	if e.Op != historyOpCopy || e.Version != report.Version ||
+		!slices.Equal(e.Added, []string{"apple", "banana", "cherry"}) || len(e.Removed) != 0 {
+		t.Errorf("history entry = %+v", e)
+	}
+}
+
+func TestCopyCollection_RefusesADestinationWrittenDuringTheCopy(t *testing.T) {
+	mr := miniredis.RunT(t)
+	createCollection(t, mr, "src", "apple", "banana", "cherry")
+	dst := createCollection(t, mr, "dst")
+
+	_, err := CopyCollection(context.Background(),
+		&AhoCorasickArgs{Addr: mr.Addr(), Name: "src"},
+		&AhoCorasickArgs{Addr: mr.Addr(), Name: "dst"},
+		CopyOptions{BatchSize: 2, Progress: func(copied, _ int) {
+			if copied == 2 {
+				if _, err := dst.Add("zebra"); err != nil {
+					t.Error(err)
+				}
+			}
+		}})
+	if !errors.Is(err, ErrInvalidCopy) {
+		t.Errorf("CopyCollection error = %v, want ErrInvalidCopy", err)
+	}
+	page, _, err := dst.Keywords("", 10)
+	if err != nil || !slices.Equal(page, []string{"zebra"}) {
+		t.Errorf("destination Keywords = %v, %v; want only its own write", page, err)
+	}
+	for _, key := range mr.Keys() {
+		if strings.Contains(key, ":copy:") {
+			t.Errorf("staging key %s survived the refused copy", key)
+		}
+	}
+}
+
+func TestCopyCollection_ReadOnlySource(t *testing.T) {
+	mr := miniredis.RunT(t)
+	src := createCollection(t, mr, "src", "apple", "banana", "cherry")
+
+	var writeErr error
+	_, err := CopyCollection(context.Background(),
+		&AhoCorasickArgs{Addr: mr.Addr(), Name: "src"},
+		&AhoCorasickArgs{Addr: mr.Addr(), Name: "dst"},
+		CopyOptions{BatchSize: 2, ReadOnlySource: true, Progress: func(int, int) {
+			if writeErr == nil {
+				_, writeErr = src.Add("date")
+			}
+		}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(writeErr, ErrReadOnly) {
+		t.Errorf("Add during the copy error = %v, want ErrReadOnly", writeErr)
+	}
+	if n, err := src.Add("date"); err != nil || n != 1 {
+		t.Errorf("Add after the copy = %d, %v; want 1", n, err)
+	}
+}
+
+func TestCopyCollection_DetectsAWriteDuringTheCopy(t *testing.T) {
+	mr := miniredis.RunT(t)
+	src := createCollection(t, mr, "src", "apple", "banana", "cherry")
+
+	_, err := CopyCollection(context.Background(),
+		&AhoCorasickArgs{Addr: mr.Addr(), Name: "src"},
+		&AhoCorasickArgs{Addr: mr.Addr(), Name: "dst"},
+		CopyOptions{BatchSize: 2, Progress: func(copied, _ int) {
+			if copied == 2 {
+				if _, err := src.Add("aardvark"); err != nil {
+					t.Error(err)
+				}
+			}
+		}})
+	if !errors.Is(err, ErrCopyMismatch) {
+		t.Errorf("CopyCollection error = %v, want ErrCopyMismatch", err)
+	}
+}
+
+func TestRename(t *testing.T) {
+	mr := miniredis.RunT(t)
+	createCollection(t, mr, "old", "apple", "banana")
+
+	report, err := Rename(context.Background(), &AhoCorasickArgs{Addr: mr.Addr(), Name: "old"}, "new", CopyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Keywords != 2 || !report.SourceDeleted {
+		t.Errorf("report = %+v", report)
+	}
+	if mr.Exists(trieKey("old")) || mr.Exists(readOnlyKey("old")) {
+		t.Error("the old collection's keys survived the rename")
+	}
+	renamed := createCollection(t, mr, "new")
+	if n, err := renamed.Count(); err != nil || n != 2 {
+		t.Errorf("Count() after the rename = %d, %v; want 2", n, err)
+	}
+}
+
+func TestCopyCollection_Refusals(t *testing.T) {
+	mr := miniredis.RunT(t)
+	createCollection(t, mr, "src", "apple")
+	createCollection(t, mr, "full", "banana")
+	at := func(name string) *AhoCorasickArgs { return &AhoCorasickArgs{Addr: mr.Addr(), Name: name} }
+
+	for _, tc := range []struct {
+		name     string
+		src, dst *AhoCorasickArgs
+	}{
+		{"same collection", at("src"), at("src")},
+		{"non-empty destination", at("src"), at("full")},
+		{"case sensitivity differs", at("src"), &AhoCorasickArgs{Addr: mr.Addr(), Name: "dst", CaseSensitive: true}},
+		{"alias", &AhoCorasickArgs{Addr: mr.Addr(), Alias: "rules"}, at("dst")},
+	} {
+		if _, err := CopyCollection(context.Background(), tc.src, tc.dst, CopyOptions{}); !errors.Is(err, ErrInvalidCopy) {
+			t.Errorf("%s: CopyCollection error = %v, want ErrInvalidCopy", tc.name, err)
+		}
+	}
+
+	mr.Set(readOnlyKey("src"), "held")
+	if _, err := CopyCollection(context.Background(), at("src"), at("dst"), CopyOptions{ReadOnlySource: true}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("CopyCollection of a held source error = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestDeleteCollection_RefusesV1(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "legacy", SchemaVersion: SchemaV1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ac.Close() }()
+	if err := deleteCollection(context.Background(), ac); !errors.Is(err, ErrInvalidCopy) {
+		t.Errorf("deleteCollection of a V1 collection error = %v, want ErrInvalidCopy", err)
+	}
+}
@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	matchengine "github.com/skyoo2003/acor/internal/engine"
+)
+
+// KeywordCount is one entry of TopKeywords: a keyword and how often it occurred.
+type KeywordCount struct {
+	// Keyword is the matched dictionary entry, as it was added.
+	Keyword string
+	// Count is how many times it occurred, overlaps included.
+	Count int
+}
+
+// CountMatches returns how many times each keyword occurs in text, overlaps
+// included, so "hers" counts both "he" and "hers". Keywords that do not occur are
+// absent; the map is never nil.
+//
+// Find allocates a string per occurrence and FindIndex a start offset per
+// occurrence, only for a caller to fold them into counts. CountMatches counts
+// inside the automaton's scan instead, so its cost follows the number of distinct
+// keywords matched, not the number of matches. Use it for analytics over
+// match-dense text, and TopKeywords when the dictionary is large enough that even
+// the distinct set is more than the caller wants.
+func (ac *AhoCorasick) CountMatches(text string) (map[string]int, error) {
+	return ac.CountMatchesContext(ac.ctx, text)
+}
+
+// CountMatchesContext is CountMatches with an explicit context for cancellation.
+func (ac *AhoCorasick) CountMatchesContext(ctx context.Context, text string) (map[string]int, error) {
+	counts, err := ac.count(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return counts.Map(), nil
+}
+
+// CountStream is CountMatches over an io.Reader, scanned without loading the
+// whole input into memory. Like FindStream it keeps one automaton state across
+// the input, so no occurrence is lost at a buffer boundary.
+func (ac *AhoCorasick) CountStream(r io.Reader) (map[string]int, error) {
+	return ac.CountStreamContext(ac.ctx, r)
+}
+
+// CountStreamContext is CountStream with an explicit context. The context is
+// checked between runes, so a canceled context stops the scan and returns
+// ctx.Err().
+func (ac *AhoCorasick) CountStreamContext(ctx context.Context, r io.Reader) (map[string]int, error) {
+	counts, err := ac.countStream(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return counts.Map(), nil
+}
+
+// CountStreamWithLimits is CountStream bounded by limits, for input that is
+// not trusted to be small. A scan that hits a limit stops there and returns
+// the counts so far with a *ScanLimitError. MaxMatches bounds the occurrences
+// counted, all keywords together. A nil limits scans the whole input, as
+// CountStream does.
+func (ac *AhoCorasick) CountStreamWithLimits(r io.Reader, limits *ScanLimits) (map[string]int, error) {
+	return ac.CountStreamWithLimitsContext(ac.ctx, r, limits)
+}
+
+// CountStreamWithLimitsContext is CountStreamWithLimits with an explicit
+// context. A context canceled during the scan is reported as a
+// *ScanLimitError, as FindMatches reports it under limits.
+func (ac *AhoCorasick) CountStreamWithLimitsContext(ctx context.Context, r io.Reader,
+	limits *ScanLimits) (map[string]int, error) {
+	if limits == nil {
+		return ac.CountStreamContext(ctx, r)
+	}
+	counts := make(map[string]int)
+	// A bounded scan reports each match to be counted against MaxMatches, which
+	// the engine's counting scan does not, so the occurrences are tallied here.
+	err := ac.FindStreamWithLimitsContext(ctx, r, limits, func(m Match) bool {
+		counts[m.Keyword]++
+		return true
+	})
+	var limitErr *ScanLimitError
+	if err != nil && !errors.As(err, &limitErr) {
+		return nil, err
+	}
+	return counts, err
+}
+
+// TopKeywords returns the k most frequent keywords in text, most frequent first,
+// with ties ordered by keyword. A k <= 0 returns every matched keyword in that
+// order. Counting is the same as CountMatches; only the k survivors are turned
+// into strings, which keeps the result small when a large dictionary matches
+// widely.
+func (ac *AhoCorasick) TopKeywords(text string, k int) ([]KeywordCount, error) {
+	return ac.TopKeywordsContext(ac.ctx, text, k)
+}
+
+// TopKeywordsContext is TopKeywords with an explicit context for cancellation.
+func (ac *AhoCorasick) TopKeywordsContext(ctx context.Context, text string, k int) ([]KeywordCount, error) {
+	counts, err := ac.count(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return topKeywords(counts, k), nil
+}
+
+// TopKeywordsStream is TopKeywords over an io.Reader; see CountStream.
+func (ac *AhoCorasick) TopKeywordsStream(r io.Reader, k int) ([]KeywordCount, error) {
+	return ac.TopKeywordsStreamContext(ac.ctx, r, k)
+}
+
+// TopKeywordsStreamContext is TopKeywordsStream with an explicit context,
+// checked between runes.
+func (ac *AhoCorasick) TopKeywordsStreamContext(ctx context.Context, r io.Reader, k int) ([]KeywordCount, error) {
+	counts, err := ac.countStream(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return topKeywords(counts, k), nil
+}
+
+// count runs the engine's counting scan over the normalized text.
+func (ac *AhoCorasick) count(ctx context.Context, text string) (*matchengine.Counts, error) {
+	eng, err := ac.ops.loadEngine(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// See FindMatchesContext: honor an already-canceled ctx at the match boundary.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return eng.Count(normalizeText(text, ac.caseSensitive)), nil
+}
+
+// countStream runs the engine's counting scan over r. A nil reader counts as
+// empty input.
+func (ac *AhoCorasick) countStream(ctx context.Context, r io.Reader) (*matchengine.Counts, error) {
+	eng, err := ac.ops.loadEngine(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return eng.Count(""), nil
+	}
+	next, scanErr := ac.streamRunes(ctx, r)
+	counts := eng.CountStream(next)
+	if err := scanErr(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func topKeywords(counts *matchengine.Counts, k int) []KeywordCount {
+	n := counts.Len()
+	if k > 0 && k < n {
+		n = k
+	}
+	top := make([]KeywordCount, 0, n)
+	counts.Top(k, func(keyword string, count int) {
+		top = append(top, KeywordCount{Keyword: keyword, Count: count})
+	})
+	return top
+}
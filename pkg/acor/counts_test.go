@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCountMatches(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "he", "she", "hers")
+	text := "She said HERS, then he said hers"
+
+	got, err := ac.CountMatches(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"she": 1, "he": 5, "hers": 2} // "then" holds a "he" too
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CountMatches = %v, want %v", got, want)
+	}
+
+	// Same input through the reader path, split across many small reads.
+	streamed, err := ac.CountStream(&wrappedEOFReader{data: []byte(text)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(streamed, want) {
+		t.Errorf("CountStream = %v, want %v", streamed, want)
+	}
+
+	none, err := ac.CountMatches("no match at all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if none == nil || len(none) != 0 {
+		t.Errorf("CountMatches on no match = %#v, want empty non-nil", none)
+	}
+}
+
+func TestTopKeywords(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "a", "b", "c")
+	text := "a b b c c c"
+
+	got, err := ac.TopKeywords(text, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []KeywordCount{{"c", 3}, {"b", 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TopKeywords(2) = %v, want %v", got, want)
+	}
+
+	all, err := ac.TopKeywordsStream(strings.NewReader(text), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[2] != (KeywordCount{"a", 1}) {
+		t.Errorf("TopKeywordsStream(0) = %v, want all three, a last", all)
+	}
+}
+
+func TestCountStream_Canceled(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "a")
+	// Load the engine first so cancellation is observed by the scan, not the load.
+	if _, err := ac.CountMatches("a"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ac.CountStreamContext(ctx, strings.NewReader("aaaa")); !errors.Is(err, context.Canceled) {
+		t.Errorf("CountStreamContext on canceled ctx: err = %v, want context.Canceled", err)
+	}
+}
@@ -36,7 +36,7 @@
 	// concurrent writers to the collection.
 	ErrConcurrencyConflict = errors.New("concurrency conflict - please retry")
 	// ErrPresetRequiresRedis is returned when a Preset is specified without
-	// any Redis address.
+	// any Redis address or Connection.
 	ErrPresetRequiresRedis = errors.New("Preset requires a Redis address")
 	// ErrPresetRequiresV2 is returned when a Preset is set with SchemaVersion=1.
 	ErrPresetRequiresV2 = errors.New("Preset engine requires V2 schema")
@@ -63,6 +63,95 @@
 	// Reads, Suggest, Info, and Flush still work, and MigrateV1ToV2 converts the
 	// collection in place — which is the supported way forward.
 	ErrV1ReadOnly = errors.New("V1 collections are read-only; migrate with MigrateV1ToV2")
+	// ErrInvalidWeight is returned by SetWeights for a weight that is NaN or
+	// infinite. Either would poison every score it touched: NaN compares false
+	// against every threshold, and an infinity never stops being the total.
+	ErrInvalidWeight = errors.New("keyword weight must be a finite number")
+	// ErrMaxMatches is the cause of a ScanLimitError when a scan produced more
+	// matches than ScanLimits.MaxMatches.
+	ErrMaxMatches = errors.New("scan exceeded the match limit")
+	// ErrMaxTextRunes is the cause of a ScanLimitError when the text was longer
+	// than ScanLimits.MaxTextRunes.
+	ErrMaxTextRunes = errors.New("scan exceeded the text size limit")
+	// ErrAliasNotFound is returned by ResolveAlias, and by Create with Alias set,
+	// when no alias of that name exists.
+	ErrAliasNotFound = errors.New("alias not found")
+	// ErrAliasConflict is returned by SetAlias when the alias name is already a
+	// collection, or is the collection being aliased. Aliases and collections share
+	// one namespace — key prefix and invalidation channel alike — so letting a name
+	// be both would make every instance opened by it ambiguous.
+	ErrAliasConflict = errors.New("alias name is already a collection")
+	// ErrAliasWithName is returned by Create when both Name and Alias are set. An
+	// instance serves one collection, named either directly or through an alias.
+	ErrAliasWithName = errors.New("Name and Alias are mutually exclusive")
+	// ErrAliasRequiresV2 is returned by Create when Alias is set with SchemaVersion=1.
+	// Following an alias means opening its targets on the fly, and V1 collections
+	// are read-only leftovers to migrate, not build and swap.
+	ErrAliasRequiresV2 = errors.New("alias requires V2 schema")
+	// ErrMigrationViaAlias is returned when MigrateV1ToV2 or RollbackToV1 is called
+	// on an instance opened by Alias. Migration rewrites one collection in place;
+	// open that collection by Name so the alias cannot move it mid-run.
+	ErrMigrationViaAlias = errors.New("schema migration requires an instance opened by Name, not Alias")
+	// ErrHistoryRequiresV2 is returned by Create when History is set with
+	// SchemaVersion=1. V1 collections take no writes, so there is nothing to record.
+	ErrHistoryRequiresV2 = errors.New("version history requires V2 schema")
+	// ErrHistoryDisabled is returned by RevertTo on an instance created without
+	// History. History, DiffVersions, and reading other writers' entries work
+	// regardless; only writing through history needs it on.
+	ErrHistoryDisabled = errors.New("version history is not enabled on this instance")
+	// ErrVersionNotFound is returned by DiffVersions and RevertTo for a version the
+	// collection's history does not hold: trimmed by HistoryOptions.MaxEntries,
+	// written before history was enabled, or never a version of the collection.
+	ErrVersionNotFound = errors.New("version not found in history")
+	// ErrHistoryGap is returned by DiffVersions and RevertTo when the history
+	// between two versions is incomplete, because a write in between came from an
+	// instance without History set. The keyword sets on either side of the gap
+	// can no longer be related, so nothing is diffed or reverted across it.
+	ErrHistoryGap = errors.New("version history has an unrecorded write")
+	// ErrSyncDeleteLimit is returned by Sync when reconciling would remove more
+	// keywords than SyncOptions.MaxDeletes allows. Nothing is written; the report
+	// returned beside it lists what the sync would have done.
+	ErrSyncDeleteLimit = errors.New("sync would remove more keywords than MaxDeletes allows")
+	// ErrVersionMismatch is returned by Apply when Changeset.ExpectedVersion is set
+	// and the collection has since moved to another version. Nothing is written;
+	// re-read the collection, rebuild the changeset, and try again.
+	ErrVersionMismatch = errors.New("collection is not at the expected version")
+	// ErrChangesetOverlap is returned by Apply when a keyword appears in both
+	// Changeset.Add and Changeset.Remove, which leaves its outcome ambiguous.
+	ErrChangesetOverlap = errors.New("changeset adds and removes the same keyword")
+	// ErrInvalidTTL is returned by AddWithTTL, and by AddMany with BatchOptions.TTL,
+	// when the TTL is negative, or zero in AddWithTTL's case. Nothing is written.
+	ErrInvalidTTL = errors.New("keyword TTL must be positive")
+	// ErrInvalidTag is returned by AddWithTags, by AddMany with BatchOptions.Tags,
+	// and by RemoveTag and ListTag for a tag that is empty or only whitespace.
+	// Nothing is written.
+	ErrInvalidTag = errors.New("tag must not be empty")
+	// ErrInvalidUnion is returned by NewUnion for an empty member list, a nil
+	// member, members differing in case sensitivity, or two members serving the
+	// same collection.
+	ErrInvalidUnion = errors.New("invalid collection union")
+	// ErrInvalidLimit is returned by Keywords for a page size that is zero or
+	// negative.
+	ErrInvalidLimit = errors.New("page size must be positive")
+	// ErrReadOnly is returned by writes to a collection that CopyCollection is
+	// holding read-only (CopyOptions.ReadOnlySource), and by a CopyCollection that
+	// asks for a source another copy already holds. Nothing is written; retry once
+	// the copy is done.
+	ErrReadOnly = errors.New("collection is read-only while it is copied")
+	// ErrInvalidCopy is returned by CopyCollection and Rename before anything is
+	// copied: for a source and destination that are the same collection, differ in
+	// case sensitivity, or are opened by alias or on V1, or for a destination that
+	// already holds keywords or takes a write of its own during the copy.
+	ErrInvalidCopy = errors.New("invalid collection copy")
+	// ErrCopyMismatch is returned by CopyCollection when the destination does not
+	// end up with the source's keyword count and checksum. The destination is left
+	// as written, for inspection; Flush it before copying again.
+	ErrCopyMismatch = errors.New("copied collection does not match its source")
+	// ErrWatchViaAlias is returned by Watch on an instance opened by Alias. A
+	// repoint moves the instance to a collection whose writes are published on
+	// another channel, so a watch could not follow it; open the target by Name,
+	// and watch the alias with ResolveAlias.
+	ErrWatchViaAlias = errors.New("watch requires an instance opened by Name, not Alias")
 )
 
 // OperationError represents an error that occurred during an automaton operation.
@@ -89,6 +178,32 @@ func (e *OperationError) Error() string {
 // Unwrap returns the underlying error for use with errors.Is and errors.As.
 func (e *OperationError) Unwrap() error { return e.Err }
 
+// ScanLimitError is returned when a scan bounded by ScanLimits stops before the
+// end of its text: a limit was hit, or the context was canceled or timed out
+// during the scan. It carries what the scan found up to that point, so a caller
+// can still act on a partial result — redact what was found, or report it as
+// truncated.
+//
+// Err is ErrMaxMatches, ErrMaxTextRunes, or the context's error, so errors.Is
+// against any of those, context.DeadlineExceeded included, sees through it.
+type ScanLimitError struct {
+	// Err is the limit that stopped the scan.
+	Err error
+	// Matches are the matches found before the scan stopped, with the call's
+	// MatchOptions applied as they would be to a complete result.
+	Matches []Match
+	// Runes is how many runes of the text were scanned; Matches lie within them.
+	Runes int
+}
+
+// Error returns a message naming the limit and how far the scan got.
+func (e *ScanLimitError) Error() string {
+	return fmt.Sprintf("scan stopped after %d runes with %d matches: %v", e.Runes, len(e.Matches), e.Err)
+}
+
+// Unwrap returns the limit that stopped the scan for use with errors.Is.
+func (e *ScanLimitError) Unwrap() error { return e.Err }
+
 // RedisError represents an error that occurred during a Redis operation.
 // It includes the operation type, key, and underlying error.
 type RedisError struct {
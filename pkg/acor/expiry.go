@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hash/maphash"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Keyword expiry: a keyword added with a TTL carries its expiry time in the trie
+// hash's fieldExpiries, written by the same script and under the same version as
+// the keyword set itself. Every reader therefore gets the expiry times together
+// with the keywords they belong to, and leaves an expired keyword out of any
+// engine it builds — the keyword stops matching at its expiry, on every instance,
+// whether or not anything has removed it yet.
+//
+// Removing it is the sweeper's job: housekeeping that commits an ordinary
+// versioned write, recorded in history as an "expire" entry. Until then the
+// keyword is still stored, and a write treats it as absent: adding it again brings
+// it back, with no expiry unless the add sets one.
+
+// DefaultExpirySweepInterval is how often an instance tries to reap expired
+// keywords when AhoCorasickArgs.ExpirySweepInterval is zero.
+const DefaultExpirySweepInterval = time.Minute
+
+// keywordExpiries maps each keyword added with a TTL to its expiry time, in Unix
+// milliseconds. A keyword without an entry never expires.
+type keywordExpiries map[string]int64
+
+// parseExpiries decodes a trie hash's fieldExpiries value; an absent field
+// decodes to no expiries.
+func parseExpiries(raw string) (keywordExpiries, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var expiries keywordExpiries
+	if err := json.Unmarshal([]byte(raw), &expiries); err != nil {
+		return nil, newOperationError("unmarshal", SchemaV2, err)
+	}
+	return expiries, nil
+}
+
+// encode renders e as v2WriteScript stores it, "{}" when empty.
+func (e keywordExpiries) encode() (string, error) {
+	if len(e) == 0 {
+		return "{}", nil
+	}
+	return toJSON(e)
+}
+
+// expired reports whether keyword has an expiry at or before now.
+func (e keywordExpiries) expired(keyword string, now time.Time) bool {
+	at, ok := e[keyword]
+	return ok && at <= now.UnixMilli()
+}
+
+// countExpired returns how many keywords have expired by now.
+func (e keywordExpiries) countExpired(now time.Time) int {
+	n := 0
+	for kw := range e {
+		if e.expired(kw, now) {
+			n++
+		}
+	}
+	return n
+}
+
+// next returns the earliest expiry still after now, or the zero time when no
+// keyword is left to expire. An engine built at now is exact until then.
+func (e keywordExpiries) next(now time.Time) time.Time {
+	var earliest int64
+	for _, at := range e {
+		if at > now.UnixMilli() && (earliest == 0 || at < earliest) {
+			earliest = at
+		}
+	}
+	if earliest == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(earliest)
+}
+
+// live returns keywordSet without the keywords expired by now. With none expired
+// it returns keywordSet itself rather than a copy.
+func (e keywordExpiries) live(keywordSet map[string]struct{}, now time.Time) map[string]struct{} {
+	if e.countExpired(now) == 0 {
+		return keywordSet
+	}
+	live := make(map[string]struct{}, len(keywordSet))
+	for kw := range keywordSet {
+		if !e.expired(kw, now) {
+			live[kw] = struct{}{}
+		}
+	}
+	return live
+}
+
+// digestExpiries fingerprints the raw fieldExpiries value together with how many
+// of its keywords have expired by now, so a memoized engine is rebuilt when one of
+// them expires even though the stored bytes have not changed. No expiries digest
+// to zero, leaving the outputs digest they are added to as it was.
+func digestExpiries(raw string, expiries keywordExpiries, now time.Time) uint64 {
+	if raw == "" {
+		return 0
+	}
+	return maphash.String(engineDigestSeed, strconv.Itoa(expiries.countExpired(now))+":"+raw)
+}
+
+// live returns a copy of s whose Keywords leave out those expired by now: the
+// keyword set a reader sees, and the one a replace plans from.
+func (s *trieSnapshot) live(now time.Time) *trieSnapshot {
+	live := *s
+	live.Keywords = make([]string, 0, len(s.Keywords))
+	for _, kw := range s.Keywords {
+		if !s.Expiries.expired(kw, now) {
+			live.Keywords = append(live.Keywords, kw)
+		}
+	}
+	return &live
+}
+
+// reviveExpired clears the expiry of every keyword in keywords that has expired
+// by now but is still stored, and returns them. An add of such a keyword is a
+// real addition — no reader could see it — though the trie already holds it.
+func (s *trieSnapshot) reviveExpired(keywords []string, now time.Time) []string {
+	var revived []string
+	for _, kw := range keywords {
+		if s.Expiries.expired(kw, now) {
+			delete(s.Expiries, kw)
+			s.attrsChanged = true
+			revived = append(revived, kw)
+		}
+	}
+	return revived
+}
+
+// setExpiry sets every keyword in keywords to expire at expireAt, Unix
+// milliseconds.
+func (s *trieSnapshot) setExpiry(keywords []string, expireAt int64) {
+	for _, kw := range keywords {
+		if kw == "" || s.Expiries[kw] == expireAt {
+			continue
+		}
+		if s.Expiries == nil {
+			s.Expiries = keywordExpiries{}
+		}
+		s.Expiries[kw] = expireAt
+		s.attrsChanged = true
+	}
+}
+
+// planAddLive is planAddMany with expired keywords counted as absent: those in
+// keywords are revived and reported added along with the new ones. Unlike the
+// planners in v2_plan.go it reads the clock, so each retry judges expiry afresh.
+func planAddLive(snap *trieSnapshot, keywords []string) (map[string][]string, []string) {
+	revived := snap.reviveExpired(keywords, time.Now())
+	outputs, added := planAddMany(snap, keywords)
+	return outputs, append(added, revived...)
+}
+
+// AddWithTTL inserts keyword as Add does and sets it to expire ttl from now. From
+// its expiry on it matches nothing on any instance; the expiry sweeper removes it
+// from storage some time later (see AhoCorasickArgs.ExpirySweepInterval).
+//
+// A keyword already present keeps its place and takes the new expiry, replacing
+// any earlier one, and the call returns 0. A plain Add leaves an existing expiry
+// alone; to make a keyword permanent again, Remove it and Add it. Expiry times are
+// kept to the millisecond and judged by each instance's own clock, so keep the
+// clocks of a fleet in sync.
+//
+// ttl must be positive (ErrInvalidTTL). V1 collections are read-only, so
+// AddWithTTL returns ErrV1ReadOnly there.
+func (ac *AhoCorasick) AddWithTTL(keyword string, ttl time.Duration) (int, error) {
+	return ac.AddWithTTLContext(ac.ctx, keyword, ttl)
+}
+
+// AddWithTTLContext is AddWithTTL with an explicit context for cancellation.
+func (ac *AhoCorasick) AddWithTTLContext(ctx context.Context, keyword string, ttl time.Duration) (int, error) {
+	bp, ok := ac.ops.(batchPlanner)
+	if !ok {
+		return 0, ErrV1ReadOnly
+	}
+	if ttl <= 0 {
+		return 0, ErrInvalidTTL
+	}
+	keyword = normalizeKeyword(keyword, ac.caseSensitive)
+	if keyword == "" {
+		return 0, nil
+	}
+	added, err := bp.addWithAttrsAtomic(ctx, []string{keyword}, keywordAttrs{expireAt: time.Now().Add(ttl)})
+	return len(added), err
+}
+
+// startExpirySweeper runs sweepExpired every interval until Close. V1 stores no
+// expiries, so it gets no sweeper, and a negative interval turns it off.
+func (ac *AhoCorasick) startExpirySweeper(interval time.Duration) {
+	if _, ok := ac.ops.(batchPlanner); !ok || interval < 0 {
+		return
+	}
+	if interval == 0 {
+		interval = DefaultExpirySweepInterval
+	}
+	// Its own context rather than ac.ctx: preset mode leaves that uncancelable.
+	ctx, cancel := context.WithCancel(context.Background())
+	ac.stopSweeper = cancel
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_, err := ac.sweepExpired(ctx, interval)
+				if err != nil && ctx.Err() == nil && ac.logger != nil {
+					ac.logger.Printf("expiry sweep failed: collection=%s error=%v", ac.collection(), err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// sweepExpired removes the collection's expired keywords and returns them. The
+// check costs one HGET; only when something has expired does the instance take the
+// sweep lock, held for lockTTL so that of a fleet sweeping on the same interval
+// one instance does the work per interval. The removal itself is a replace to the
+// live keyword set, so it commits, records history, and invalidates peers as any
+// write does, and re-judges expiry on a conflict retry: a keyword re-added in the
+// meantime is kept.
+func (ac *AhoCorasick) sweepExpired(ctx context.Context, lockTTL time.Duration) ([]string, error) {
+	bp, ok := ac.ops.(batchPlanner)
+	if !ok {
+		return nil, nil
+	}
+	name := ac.collection()
+	raw, err := ac.storage.HGet(ctx, trieKey(name), fieldExpiries)
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newRedisError("HGET", trieKey(name), err)
+	}
+	expiries, err := parseExpiries(raw)
+	if err != nil || expiries.countExpired(time.Now()) == 0 {
+		return nil, err
+	}
+
+	won, err := ac.storage.SetNX(ctx, expirySweepKey(name), "1", lockTTL)
+	if err != nil {
+		return nil, newRedisError("SET", expirySweepKey(name), err)
+	}
+	if !won {
+		return nil, nil
+	}
+	// replaceAtomic plans from the live view, so the keywords it is handed are
+	// exactly the ones to keep.
+	_, removed, err := bp.replaceAtomic(ctx, historyOpExpire, func(live *trieSnapshot) ([]string, error) {
+		return live.Keywords, nil
+	})
+	return removed, err
+}
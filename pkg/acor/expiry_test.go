@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestAddWithTTL_StopsMatchingAtExpiry(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args AhoCorasickArgs
+	}{
+		{"v2", AhoCorasickArgs{}},
+		{"cached", AhoCorasickArgs{EnableCache: true}},
+		{"preset", AhoCorasickArgs{Preset: PresetBalanced}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			args := tc.args
+			// The background sweeper stays off, so expiry alone must hide the keyword.
+			args.Addr, args.Name, args.ExpirySweepInterval = mr.Addr(), "c", -1
+			ac, err := Create(&args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = ac.Close() }()
+
+			if _, err := ac.Add("apple"); err != nil {
+				t.Fatal(err)
+			}
+			if n, err := ac.AddWithTTL("Pie", 30*time.Millisecond); err != nil || n != 1 {
+				t.Fatalf("AddWithTTL = %d, %v; want 1", n, err)
+			}
+			if got, _ := ac.Find("apple pie"); !slices.Equal(got, []string{"apple", "pie"}) {
+				t.Fatalf("Find before expiry = %v, want [apple pie]", got)
+			}
+
+			time.Sleep(50 * time.Millisecond)
+			if got, err := ac.Find("apple pie"); err != nil || !slices.Equal(got, []string{"apple"}) {
+				t.Errorf("Find after expiry = %v, %v; want [apple]", got, err)
+			}
+			if got := storedKeywords(t, ac, "c"); !slices.Equal(got, []string{"apple", "pie"}) {
+				t.Errorf("stored keywords = %v, want pie kept until swept", got)
+			}
+
+			// Adding an expired keyword again brings it back for good.
+			if n, err := ac.Add("pie"); err != nil || n != 1 {
+				t.Errorf("Add of the expired keyword = %d, %v; want 1", n, err)
+			}
+			if got, _ := ac.Find("apple pie"); !slices.Equal(got, []string{"apple", "pie"}) {
+				t.Errorf("Find after re-adding = %v, want [apple pie]", got)
+			}
+		})
+	}
+}
+
+func TestAddWithTTL_ExtendsExistingKeyword(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "c", "apple")
+
+	if n, err := ac.AddWithTTL("apple", 30*time.Millisecond); err != nil || n != 0 {
+		t.Fatalf("AddWithTTL of a present keyword = %d, %v; want 0", n, err)
+	}
+	if n, err := ac.AddWithTTL("apple", time.Hour); err != nil || n != 0 {
+		t.Fatalf("second AddWithTTL = %d, %v; want 0", n, err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got, _ := ac.Find("apple"); !slices.Equal(got, []string{"apple"}) {
+		t.Errorf("Find = %v; the later TTL should have replaced the first", got)
+	}
+
+	// Remove then Add leaves the keyword with no expiry at all.
+	if _, err := ac.Remove("apple"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ac.Add("apple"); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := readTrieSnapshot(context.Background(), ac.storage, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Expiries) != 0 {
+		t.Errorf("expiries after Remove and Add = %v, want none", snap.Expiries)
+	}
+	if mr.HGet(trieKey("c"), fieldExpiries) != "" {
+		t.Error("an empty expiry set should leave no field in the trie hash")
+	}
+}
+
+func TestAddMany_TTL(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "c", "apple")
+
+	result, err := ac.AddMany([]string{"apple", "pear"}, &BatchOptions{TTL: 30 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(result.Added, []string{"pear"}) || !slices.Equal(result.Skipped, []string{"apple"}) {
+		t.Errorf("AddMany with TTL = %+v, want +[pear] skipped [apple]", result)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got, _ := ac.Find("apple pear"); len(got) != 0 {
+		t.Errorf("Find after expiry = %v; the TTL covers the skipped keyword too", got)
+	}
+
+	if _, err := ac.AddMany([]string{"x"}, &BatchOptions{TTL: -time.Second}); !errors.Is(err, ErrInvalidTTL) {
+		t.Errorf("AddMany with a negative TTL error = %v, want ErrInvalidTTL", err)
+	}
+	if _, err := ac.AddWithTTL("x", 0); !errors.Is(err, ErrInvalidTTL) {
+		t.Errorf("AddWithTTL(0) error = %v, want ErrInvalidTTL", err)
+	}
+
+	v1, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "old", SchemaVersion: SchemaV1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = v1.Close() }()
+	if _, err := v1.AddWithTTL("x", time.Second); !errors.Is(err, ErrV1ReadOnly) {
+		t.Errorf("AddWithTTL on V1 error = %v, want ErrV1ReadOnly", err)
+	}
+}
+
+func TestSweepExpired_OneInstancePerInterval(t *testing.T) {
+	mr := miniredis.RunT(t)
+	open := func() *AhoCorasick {
+		ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "c", History: &HistoryOptions{},
+			ExpirySweepInterval: -1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = ac.Close() })
+		return ac
+	}
+	a, b := open(), open()
+	ctx := context.Background()
+
+	if _, err := a.Add("apple"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.AddMany([]string{"pie", "tart"}, &BatchOptions{TTL: 30 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := a.sweepExpired(ctx, time.Minute); err != nil || len(removed) != 0 {
+		t.Fatalf("sweep before anything expired = %v, %v; want nothing", removed, err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	removed, err := a.sweepExpired(ctx, time.Minute)
+	slices.Sort(removed)
+	if err != nil || !slices.Equal(removed, []string{"pie", "tart"}) {
+		t.Fatalf("sweep = %v, %v; want [pie tart]", removed, err)
+	}
+	if got := storedKeywords(t, a, "c"); !slices.Equal(got, []string{"apple"}) {
+		t.Errorf("stored keywords after sweep = %v, want [apple]", got)
+	}
+	entries, err := a.History(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries[0].Op != "expire" || len(entries[0].Removed) != 2 {
+		t.Errorf("newest history entry = %+v, want an expire of two keywords", entries[0])
+	}
+
+	// a holds the lock for the interval, so b leaves the next expiry alone.
+	if _, err := b.AddWithTTL("cake", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if removed, err := b.sweepExpired(ctx, time.Minute); err != nil || len(removed) != 0 {
+		t.Errorf("sweep under another instance's lock = %v, %v; want nothing", removed, err)
+	}
+	mr.FastForward(time.Minute)
+	if removed, err := b.sweepExpired(ctx, time.Minute); err != nil || !slices.Equal(removed, []string{"cake"}) {
+		t.Errorf("sweep after the lock lapsed = %v, %v; want [cake]", removed, err)
+	}
+}
+
+func TestExpirySweeper_RunsInBackground(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "c", ExpirySweepInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ac.Close() }()
+
+	if _, err := ac.AddWithTTL("pie", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(storedKeywords(t, ac, "c")) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the sweeper never removed the expired keyword")
+		}
+		// The lock outlives each sweep in miniredis's frozen clock; release it.
+		mr.FastForward(time.Second)
+		time.Sleep(5 * time.Millisecond)
+	}
+}
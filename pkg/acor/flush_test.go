@@ -3,6 +3,7 @@
 package acor
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/alicebob/miniredis/v2"
@@ -90,3 +91,24 @@ func TestV1FlushClearsPersistedMatches(t *testing.T) {
 		t.Fatalf("expected no matches after V1 Flush, got %v", results)
 	}
 }
+
+// TestFlush_ReadOnly checks the marker in the flush itself, with history off as
+// well as on.
+func TestFlush_ReadOnly(t *testing.T) {
+	for _, history := range []*HistoryOptions{nil, {}} {
+		mr := miniredis.RunT(t)
+		ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "held", History: history})
+		if err != nil {
+			t.Fatal(err)
+		}
+		addAll(t, ac, "apple")
+		mr.Set(readOnlyKey("held"), "copy")
+		if err := ac.Flush(); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("history %v: Flush error = %v, want ErrReadOnly", history != nil, err)
+		}
+		if n, err := ac.Count(); err != nil || n != 1 {
+			t.Errorf("history %v: Count after a refused Flush = %d, %v; want 1", history != nil, n, err)
+		}
+		_ = ac.Close()
+	}
+}
@@ -362,7 +362,7 @@ func TestV1FlushIgnoresItsContext(t *testing.T) {
 // TestV2NeverWritesTheNodesKey pins the correction to SchemaV2 and to
 // MigrationResult.KeysAfter, both of which said V2 is "3 Redis keys". Only
 // MigrateV1ToV2 writes {name}:nodes (keys.go:54 has no other writer), so a
-// natively built collection tops out at two.
+// natively built collection tops out at three: :trie, :outputs and :index.
 func TestV2NeverWritesTheNodesKey(t *testing.T) {
 	ac, mr := createAhoCorasick(t)
 	defer mr.Close()
@@ -380,8 +380,8 @@ func TestV2NeverWritesTheNodesKey(t *testing.T) {
 			t.Errorf("Add wrote %s; the doc says only MigrateV1ToV2 creates it", key)
 		}
 	}
-	if got := len(mr.Keys()); got != 2 {
-		t.Errorf("V2 after one Add holds %d keys (%v), want 2 — :trie and :outputs", got, mr.Keys())
+	if got := len(mr.Keys()); got != 3 {
+		t.Errorf("V2 after one Add holds %d keys (%v), want 3 — :trie, :outputs and :index", got, mr.Keys())
 	}
 }
 
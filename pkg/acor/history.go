@@ -0,0 +1,363 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultHistoryMaxEntries is the number of history entries kept when
+// HistoryOptions.MaxEntries is unset.
+const DefaultHistoryMaxEntries = 1000
+
+// History entry ops: what kind of write recorded the entry.
+const (
+	historyOpAdd    = "add"
+	historyOpRemove = "remove"
+	historyOpFlush  = "flush"
+	historyOpRevert = "revert"
+	historyOpSync   = "sync"
+	historyOpApply  = "apply"
+	historyOpExpire = "expire"
+	historyOpUntag  = "untag"
+	historyOpCopy   = "copy"
+)
+
+// History stream entry fields, as the write scripts record them.
+const (
+	fieldHistoryOp      = "op"
+	fieldHistoryVersion = "version"
+	fieldHistoryPrev    = "prev"
+	fieldHistoryAdded   = "added"
+	fieldHistoryRemoved = "removed"
+	fieldHistoryWriter  = "writer"
+)
+
+// HistoryOptions turns on version history; see AhoCorasickArgs.History.
+type HistoryOptions struct {
+	// MaxEntries bounds the history: every recorded write trims it to the newest
+	// MaxEntries entries, so RevertTo and DiffVersions reach back at most that
+	// many writes. Defaults to DefaultHistoryMaxEntries if zero or negative.
+	MaxEntries int64
+	// Writer identifies this instance in the entries it records. Defaults to
+	// "<hostname>:<pid>".
+	Writer string
+}
+
+// HistoryEntry is one committed write to a collection, as History returns it.
+type HistoryEntry struct {
+	// ID is the entry's Redis stream ID.
+	ID string
+	// Op is the kind of write: "add" and "remove" for Add, AddWithTTL,
+	// AddWithTags, Remove, AddMany, and RemoveMany, "flush" for Flush, "revert" for
+	// RevertTo, "sync" for Sync, "apply" for Apply, "expire" for the expiry sweeper,
+	// "untag" for RemoveTag, and "copy" for the write CopyCollection ends with. An "add" that only moved an expiry or a tag lists
+	// nothing in Added.
+	Op string
+	// Version is the collection version the write committed.
+	Version int64
+	// PrevVersion is the version the write replaced.
+	PrevVersion int64
+	// Added lists the keywords the write added.
+	Added []string
+	// Removed lists the keywords the write removed.
+	Removed []string
+	// Writer is the HistoryOptions.Writer of the instance that made the write.
+	Writer string
+	// Time is when Redis recorded the write, to the millisecond.
+	Time time.Time
+}
+
+// VersionDiff is the keyword difference between two versions of a collection.
+type VersionDiff struct {
+	// From and To are the versions compared.
+	From, To int64
+	// Added lists the keywords present at To but not at From, sorted.
+	Added []string
+	// Removed lists the keywords present at From but not at To, sorted.
+	Removed []string
+}
+
+// historyLog is an instance's resolved HistoryOptions. A nil *historyLog records
+// nothing, so write paths call change on it unconditionally.
+type historyLog struct {
+	maxEntries int64
+	writer     string
+}
+
+func newHistoryLog(opts *HistoryOptions) *historyLog {
+	if opts == nil {
+		return nil
+	}
+	h := &historyLog{maxEntries: opts.MaxEntries, writer: opts.Writer}
+	if h.maxEntries <= 0 {
+		h.maxEntries = DefaultHistoryMaxEntries
+	}
+	if h.writer == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			host = "unknown"
+		}
+		h.writer = host + ":" + strconv.Itoa(os.Getpid())
+	}
+	return h
+}
+
+// historyChange is the change set of one commitV2Write: the keywords it adds
+// and removes, which keep the keyword index in step, and, when log is set, the
+// history entry to record.
+type historyChange struct {
+	log     *historyLog
+	op      string
+	added   []string
+	removed []string
+}
+
+// change returns the change set of a write, recorded in history unless h is nil.
+func (h *historyLog) change(op string, added, removed []string) *historyChange {
+	return &historyChange{log: h, op: op, added: added, removed: removed}
+}
+
+// scriptArgs is the history entry c records, for v2WriteScript. Its keyword
+// lists are the ones the script applies to the index.
+func (c *historyChange) scriptArgs(name string) *v2HistoryArgs {
+	return &v2HistoryArgs{
+		Key:    historyKey(name),
+		MaxLen: c.log.maxEntries,
+		Op:     c.op,
+		Writer: c.log.writer,
+	}
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// History returns the collection's recorded writes, newest first: at most limit
+// of them, or every retained entry when limit <= 0. Entries exist only for
+// writes made by instances created with AhoCorasickArgs.History set; a
+// collection that never had one returns an empty slice.
+func (ac *AhoCorasick) History(limit int) ([]HistoryEntry, error) {
+	return ac.HistoryContext(ac.ctx, limit)
+}
+
+// HistoryContext is History with an explicit context for cancellation.
+func (ac *AhoCorasick) HistoryContext(ctx context.Context, limit int) ([]HistoryEntry, error) {
+	return readHistory(ctx, ac.storage, ac.collection(), int64(limit))
+}
+
+// DiffVersions returns the keywords added and removed going from version a to
+// version b. Either may be any version History reports, as an entry's Version or
+// PrevVersion, and a may be the later of the two.
+//
+// The difference is composed from the entries between the two versions, so it
+// needs every write in between recorded: ErrVersionNotFound when either version
+// has been trimmed from the history or was never in it, and ErrHistoryGap when a
+// write in between was made by an instance without History set.
+func (ac *AhoCorasick) DiffVersions(a, b int64) (*VersionDiff, error) {
+	return ac.DiffVersionsContext(ac.ctx, a, b)
+}
+
+// DiffVersionsContext is DiffVersions with an explicit context for cancellation.
+func (ac *AhoCorasick) DiffVersionsContext(ctx context.Context, a, b int64) (*VersionDiff, error) {
+	entries, err := readHistory(ctx, ac.storage, ac.collection(), 0)
+	if err != nil {
+		return nil, err
+	}
+	return diffVersions(entries, a, b)
+}
+
+// RevertTo restores the collection's keyword set to the one it held at version,
+// undoing every recorded write since in a single atomic write. It returns what
+// the revert changed: From is the version it replaced, To the version restored,
+// and Added and Removed the keywords it put back and took out.
+//
+// The revert is a write like any other. It commits a fresh version rather than
+// reusing the old one, is recorded as a "revert" entry, and can itself be
+// reverted. Weights are not versioned and stay as they are. Reverting to the
+// current version changes nothing.
+//
+// RevertTo requires AhoCorasickArgs.History (ErrHistoryDisabled), since an
+// unrecorded revert would break the history it was computed from, and a V2
+// collection. It fails as DiffVersions does when the history between version and
+// now is incomplete.
+func (ac *AhoCorasick) RevertTo(version int64) (*VersionDiff, error) {
+	return ac.RevertToContext(ac.ctx, version)
+}
+
+// RevertToContext is RevertTo with an explicit context for cancellation.
+func (ac *AhoCorasick) RevertToContext(ctx context.Context, version int64) (*VersionDiff, error) {
+	bp, ok := ac.ops.(batchPlanner)
+	if !ok || ac.history == nil {
+		return nil, ErrHistoryDisabled
+	}
+	name := ac.collection()
+	var from int64
+	added, removed, err := bp.replaceAtomic(ctx, historyOpRevert, func(snap *trieSnapshot) ([]string, error) {
+		entries, err := readHistory(ctx, ac.storage, name, 0)
+		if err != nil {
+			return nil, err
+		}
+		diff, err := diffVersions(entries, version, snap.Version)
+		if err != nil {
+			return nil, err
+		}
+		from = snap.Version
+		return revertKeywords(snap.Keywords, diff), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+	return &VersionDiff{From: from, To: version, Added: added, Removed: removed}, nil
+}
+
+// revertKeywords undoes diff, a change from some earlier version to the one
+// keywords belongs to, returning the earlier keyword set.
+func revertKeywords(keywords []string, diff *VersionDiff) []string {
+	undo := make(map[string]struct{}, len(diff.Added))
+	for _, kw := range diff.Added {
+		undo[kw] = struct{}{}
+	}
+	out := make([]string, 0, len(keywords)+len(diff.Removed))
+	for _, kw := range keywords {
+		if _, drop := undo[kw]; !drop {
+			out = append(out, kw)
+		}
+	}
+	return append(out, diff.Removed...)
+}
+
+// readHistory loads up to limit entries of name's history, newest first.
+func readHistory(ctx context.Context, storage kvStorage, name string, limit int64) ([]HistoryEntry, error) {
+	key := historyKey(name)
+	raw, err := storage.XRevRange(ctx, key, limit)
+	if err != nil {
+		return nil, newRedisError("XREVRANGE", key, err)
+	}
+	entries := make([]HistoryEntry, 0, len(raw))
+	for _, r := range raw {
+		e, err := decodeHistoryEntry(r)
+		if err != nil {
+			return nil, newOperationError("unmarshal", SchemaV2, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func decodeHistoryEntry(r streamEntry) (HistoryEntry, error) {
+	e := HistoryEntry{
+		ID:     r.ID,
+		Op:     r.Values[fieldHistoryOp],
+		Writer: r.Values[fieldHistoryWriter],
+	}
+	var err error
+	if e.Version, err = strconv.ParseInt(r.Values[fieldHistoryVersion], 10, 64); err != nil {
+		return e, fmt.Errorf("history entry %s: version: %w", r.ID, err)
+	}
+	if e.PrevVersion, err = strconv.ParseInt(r.Values[fieldHistoryPrev], 10, 64); err != nil {
+		return e, fmt.Errorf("history entry %s: prev: %w", r.ID, err)
+	}
+	if err := json.Unmarshal([]byte(r.Values[fieldHistoryAdded]), &e.Added); err != nil {
+		return e, fmt.Errorf("history entry %s: added: %w", r.ID, err)
+	}
+	if err := json.Unmarshal([]byte(r.Values[fieldHistoryRemoved]), &e.Removed); err != nil {
+		return e, fmt.Errorf("history entry %s: removed: %w", r.ID, err)
+	}
+	// Auto-generated stream IDs lead with the server's clock in milliseconds.
+	ms, _, _ := strings.Cut(r.ID, "-")
+	if n, err := strconv.ParseInt(ms, 10, 64); err == nil {
+		e.Time = time.UnixMilli(n)
+	}
+	return e, nil
+}
+
+// diffVersions composes the entries between versions a and b. entries is
+// newest first, as readHistory returns it.
+func diffVersions(entries []HistoryEntry, a, b int64) (*VersionDiff, error) {
+	chron := slices.Clone(entries)
+	slices.Reverse(chron)
+
+	lo, err := historyPosition(chron, a)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := historyPosition(chron, b)
+	if err != nil {
+		return nil, err
+	}
+	from, to := a, b
+	if lo > hi {
+		lo, hi, from, to = hi, lo, b, a
+	}
+
+	// net holds each keyword whose presence changed: true if added since from,
+	// false if removed since. A keyword added and then removed again drops out.
+	net := make(map[string]bool)
+	flip := func(kw string, added bool) {
+		if was, ok := net[kw]; ok && was != added {
+			delete(net, kw)
+			return
+		}
+		net[kw] = added
+	}
+	prev := from
+	for _, e := range chron[lo:hi] {
+		if e.PrevVersion != prev {
+			return nil, fmt.Errorf("%w: version %d was followed by a write from version %d", ErrHistoryGap, prev, e.PrevVersion)
+		}
+		for _, kw := range e.Added {
+			flip(kw, true)
+		}
+		for _, kw := range e.Removed {
+			flip(kw, false)
+		}
+		prev = e.Version
+	}
+	if prev != to {
+		return nil, fmt.Errorf("%w: version %d is not reached from version %d", ErrHistoryGap, to, from)
+	}
+
+	diff := &VersionDiff{From: a, To: b}
+	for kw, added := range net {
+		// Composed from the earlier version to the later one; swap back if the
+		// caller asked the other way round.
+		if added == (from == a) {
+			diff.Added = append(diff.Added, kw)
+		} else {
+			diff.Removed = append(diff.Removed, kw)
+		}
+	}
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Removed)
+	return diff, nil
+}
+
+// historyPosition returns where version sits in chron, the entries oldest first:
+// i+1 when chron[i] committed it, or i when chron[i] was written over it and no
+// entry committed it (the state before the oldest entry, or before a gap).
+func historyPosition(chron []HistoryEntry, version int64) (int, error) {
+	for i, e := range chron {
+		if e.Version == version {
+			return i + 1, nil
+		}
+	}
+	for i, e := range chron {
+		if e.PrevVersion == version {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %d", ErrVersionNotFound, version)
+}
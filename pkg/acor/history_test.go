@@ -0,0 +1,331 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// storedKeywords returns the keywords in name's trie hash, sorted.
+func storedKeywords(t *testing.T, ac *AhoCorasick, name string) []string {
+	t.Helper()
+	snap, err := readTrieSnapshot(context.Background(), ac.storage, name)
+	if err != nil {
+		t.Fatalf("readTrieSnapshot error: %v", err)
+	}
+	slices.Sort(snap.Keywords)
+	return snap.Keywords
+}
+
+func createWithHistory(t *testing.T, mr *miniredis.Miniredis, name string, opts *HistoryOptions) *AhoCorasick {
+	t.Helper()
+	ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: name, History: opts})
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	t.Cleanup(func() { _ = ac.Close() })
+	return ac
+}
+
+func TestHistory_RecordsWrites(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createWithHistory(t, mr, "h", &HistoryOptions{Writer: "deploy-1"})
+
+	if _, err := ac.Add("apple"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ac.AddMany([]string{"banana", "cherry"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ac.Remove("apple"); err != nil {
+		t.Fatal(err)
+	}
+	// A no-op write commits nothing and so records nothing.
+	if _, err := ac.Remove("apple"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ac.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := ac.History(0)
+	if err != nil {
+		t.Fatalf("History error: %v", err)
+	}
+	want := []struct {
+		op             string
+		added, removed []string
+	}{
+		{"flush", []string{}, []string{"banana", "cherry"}},
+		{"remove", []string{}, []string{"apple"}},
+		{"add", []string{"banana", "cherry"}, []string{}},
+		{"add", []string{"apple"}, []string{}},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("History returned %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		slices.Sort(e.Removed)
+		if e.Op != w.op || !slices.Equal(e.Added, w.added) || !slices.Equal(e.Removed, w.removed) {
+			t.Errorf("entry %d = %s +%v -%v, want %s +%v -%v", i, e.Op, e.Added, e.Removed, w.op, w.added, w.removed)
+		}
+		if e.Writer != "deploy-1" || e.Time.IsZero() {
+			t.Errorf("entry %d writer = %q, time = %v", i, e.Writer, e.Time)
+		}
+		if i > 0 && entries[i-1].PrevVersion != e.Version {
+			t.Errorf("entry %d PrevVersion = %d, want entry %d's Version %d", i-1, entries[i-1].PrevVersion, i, e.Version)
+		}
+	}
+
+	snap, err := readTrieSnapshot(context.Background(), ac.storage, "h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries[0].Version != snap.Version {
+		t.Errorf("newest entry Version = %d, want the stored version %d", entries[0].Version, snap.Version)
+	}
+
+	limited, err := ac.History(2)
+	if err != nil || len(limited) != 2 || limited[0].ID != entries[0].ID {
+		t.Errorf("History(2) = %d entries, %v; want the newest 2", len(limited), err)
+	}
+}
+
+func TestHistory_MaxEntriesTrims(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createWithHistory(t, mr, "h", &HistoryOptions{MaxEntries: 3})
+	for _, kw := range []string{"a", "b", "c", "d", "e"} {
+		if _, err := ac.Add(kw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := ac.History(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || !slices.Equal(entries[0].Added, []string{"e"}) {
+		t.Fatalf("History = %+v, want the newest 3 entries", entries)
+	}
+	if _, err := ac.RevertTo(entries[2].PrevVersion); err != nil {
+		t.Errorf("RevertTo oldest retained version error: %v", err)
+	}
+	if _, err := ac.DiffVersions(entries[2].PrevVersion-1, entries[0].Version); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("DiffVersions of a trimmed version error = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestHistory_RevertTo(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args AhoCorasickArgs
+	}{
+		{"v2", AhoCorasickArgs{}},
+		{"v2 cached", AhoCorasickArgs{EnableCache: true}},
+		{"preset", AhoCorasickArgs{Preset: PresetBalanced}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			args := tc.args
+			args.Addr, args.Name, args.History = mr.Addr(), "h", &HistoryOptions{}
+			ac, err := Create(&args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = ac.Close() }()
+
+			if _, err := ac.AddMany([]string{"apple", "banana"}, nil); err != nil {
+				t.Fatal(err)
+			}
+			good, err := ac.History(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The bad import: new keywords plus a removal.
+			if _, err := ac.AddMany([]string{"cherry", "durian"}, nil); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ac.Remove("apple"); err != nil {
+				t.Fatal(err)
+			}
+
+			diff, err := ac.RevertTo(good[0].Version)
+			if err != nil {
+				t.Fatalf("RevertTo error: %v", err)
+			}
+			if !slices.Equal(diff.Added, []string{"apple"}) || !slices.Equal(diff.Removed, []string{"cherry", "durian"}) {
+				t.Errorf("RevertTo diff = +%v -%v, want +[apple] -[cherry durian]", diff.Added, diff.Removed)
+			}
+			if diff.To != good[0].Version {
+				t.Errorf("RevertTo diff.To = %d, want %d", diff.To, good[0].Version)
+			}
+			if got := storedKeywords(t, ac, "h"); !slices.Equal(got, []string{"apple", "banana"}) {
+				t.Errorf("stored keywords after revert = %v, want [apple banana]", got)
+			}
+			got, err := ac.Find("apple banana cherry")
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, []string{"apple", "banana"}) {
+				t.Errorf("Find after revert = %v, want [apple banana]", got)
+			}
+
+			// The revert is recorded, and can itself be undone.
+			latest, err := ac.History(1)
+			if err != nil || latest[0].Op != "revert" {
+				t.Fatalf("newest entry = %+v, %v; want a revert", latest, err)
+			}
+			if _, err := ac.RevertTo(latest[0].PrevVersion); err != nil {
+				t.Fatalf("reverting the revert error: %v", err)
+			}
+			if got := storedKeywords(t, ac, "h"); !slices.Equal(got, []string{"banana", "cherry", "durian"}) {
+				t.Errorf("stored keywords after reverting the revert = %v", got)
+			}
+		})
+	}
+}
+
+func TestHistory_RevertFlush(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createWithHistory(t, mr, "h", &HistoryOptions{})
+	if _, err := ac.AddMany([]string{"apple", "banana"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ac.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ac.History(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ac.RevertTo(entries[0].PrevVersion); err != nil {
+		t.Fatalf("RevertTo before the flush error: %v", err)
+	}
+	if got := storedKeywords(t, ac, "h"); !slices.Equal(got, []string{"apple", "banana"}) {
+		t.Errorf("stored keywords = %v, want [apple banana]", got)
+	}
+}
+
+func TestHistory_DiffVersions(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createWithHistory(t, mr, "h", &HistoryOptions{})
+	for _, step := range []func() error{
+		func() error { _, err := ac.AddMany([]string{"a", "b"}, nil); return err },
+		func() error { _, err := ac.Remove("a"); return err },
+		func() error { _, err := ac.Add("c"); return err },
+		func() error { _, err := ac.Add("a"); return err },
+	} {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := ac.History(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, last := entries[3].Version, entries[0].Version
+
+	diff, err := ac.DiffVersions(first, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a was removed and re-added, so only c differs.
+	if !slices.Equal(diff.Added, []string{"c"}) || len(diff.Removed) != 0 {
+		t.Errorf("DiffVersions(first, last) = +%v -%v, want +[c]", diff.Added, diff.Removed)
+	}
+	back, err := ac.DiffVersions(last, entries[3].PrevVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back.Added) != 0 || !slices.Equal(back.Removed, []string{"a", "b", "c"}) {
+		t.Errorf("DiffVersions(last, initial) = +%v -%v, want -[a b c]", back.Added, back.Removed)
+	}
+	if same, err := ac.DiffVersions(last, last); err != nil || len(same.Added)+len(same.Removed) != 0 {
+		t.Errorf("DiffVersions(last, last) = %+v, %v; want empty", same, err)
+	}
+	if _, err := ac.DiffVersions(first, 12345); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("DiffVersions(unknown) error = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestHistory_GapFromUnrecordedWriter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createWithHistory(t, mr, "h", &HistoryOptions{})
+	plain := createCollection(t, mr, "h")
+
+	if _, err := ac.Add("apple"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ac.History(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plain.Add("banana"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ac.Add("cherry"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ac.RevertTo(entries[0].Version); !errors.Is(err, ErrHistoryGap) {
+		t.Fatalf("RevertTo across an unrecorded write error = %v, want ErrHistoryGap", err)
+	}
+	if got := storedKeywords(t, ac, "h"); !slices.Equal(got, []string{"apple", "banana", "cherry"}) {
+		t.Errorf("stored keywords = %v, want them untouched", got)
+	}
+	if _, err := plain.RevertTo(entries[0].Version); !errors.Is(err, ErrHistoryDisabled) {
+		t.Errorf("RevertTo without History error = %v, want ErrHistoryDisabled", err)
+	}
+}
+
+func TestHistory_RequiresV2(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "h", SchemaVersion: SchemaV1, History: &HistoryOptions{}})
+	if !errors.Is(err, ErrHistoryRequiresV2) {
+		if ac != nil {
+			_ = ac.Close()
+		}
+		t.Fatalf("Create error = %v, want ErrHistoryRequiresV2", err)
+	}
+}
+
+func TestHistory_FollowsAlias(t *testing.T) {
+	mr := miniredis.RunT(t)
+	blue := createWithHistory(t, mr, "rules-blue", &HistoryOptions{})
+	if err := blue.SetAlias("rules"); err != nil {
+		t.Fatal(err)
+	}
+	ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Alias: "rules", History: &HistoryOptions{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ac.Close() }()
+
+	if _, err := ac.Add("apple"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := blue.History(0)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("target History = %+v, %v; want the write made through the alias", entries, err)
+	}
+	if _, err := ac.RevertTo(entries[0].PrevVersion); err != nil {
+		t.Fatalf("RevertTo through the alias error: %v", err)
+	}
+	if got := storedKeywords(t, ac, "rules-blue"); len(got) != 0 {
+		t.Errorf("stored keywords = %v, want none", got)
+	}
+}
+
+func TestDiffKeywords(t *testing.T) {
+	plus, minus := diffKeywords([]string{"a", "b", "c"}, []string{"c", "d", "", "d", "a"})
+	if !slices.Equal(plus, []string{"d"}) || !slices.Equal(minus, []string{"b"}) {
+		t.Errorf("diffKeywords = +%v -%v, want +[d] -[b]", plus, minus)
+	}
+}
@@ -10,6 +10,17 @@
 	fieldKeywords = "keywords"
 	fieldPrefixes = "prefixes"
 	fieldVersion  = "version"
+	// fieldExpiries holds the expiry time of each keyword added with a TTL, as a
+	// JSON object of keyword to Unix milliseconds. Absent when none has one.
+	fieldExpiries = "expiries"
+	// fieldTags holds the tags of each tagged keyword, as a JSON object of keyword
+	// to its sorted tags. Absent when no keyword has one.
+	fieldTags = "tags"
+	// fieldIndexed marks a trie whose keyword set indexKey mirrors. Only the
+	// write scripts set it, each time they bring the index in step, so a trie
+	// written any other way (created, migrated, restored) has none until its
+	// next scripted write.
+	fieldIndexed = "indexed"
 
 	// emptyKeywordsJSON and emptyStringArrayJSON are the default JSON values
 	// stored in an empty V2 trie hash: no keywords, and the root prefix only.
@@ -55,6 +66,58 @@ func nodesKey(name string) string {
 	return keyPrefix(name) + ":nodes"
 }
 
+// indexKey is a sorted set of a V2 collection's stored keywords, all at score
+// 0, so ZRANGEBYLEX pages them in byte order. The write scripts keep it in step
+// with the trie's keyword list while fieldIndexed is set; listing reads it
+// instead of decoding that list.
+func indexKey(name string) string {
+	return keyPrefix(name) + ":index"
+}
+
+// weightsKey holds the scoring weight of each keyword, one hash field per
+// normalized keyword. It sits beside the trie rather than inside it, so setting a
+// weight neither bumps the trie version nor invalidates any cached automaton.
+func weightsKey(name string) string {
+	return keyPrefix(name) + ":weights"
+}
+
+// historyKey is the stream of committed change sets recorded when
+// AhoCorasickArgs.History is set. Flush leaves it in place, so a flush can be
+// reverted like any other write.
+func historyKey(name string) string {
+	return keyPrefix(name) + ":history"
+}
+
+// expirySweepKey is the lock the expiry sweepers of every instance contend for,
+// so that one of them reaps a collection per sweep interval. It holds no data
+// and expires on its own.
+func expirySweepKey(name string) string {
+	return keyPrefix(name) + ":expiry-sweep"
+}
+
+// readOnlyKey marks a collection read-only while CopyCollection copies it with
+// CopyOptions.ReadOnlySource. v2WriteScript refuses to commit while it exists, and
+// it expires on its own, so a copier that dies frees the collection within
+// copyReadOnlyTTL.
+func readOnlyKey(name string) string {
+	return keyPrefix(name) + ":readonly"
+}
+
+// copyStagingKey is one of the keys CopyCollection builds a destination's part
+// in, "outputs" or "index", before swapping it in; id keeps concurrent copies
+// apart. It shares the collection's hash tag, so the swap can RENAME it into
+// place on a cluster, and it expires on its own if the copier dies.
+func copyStagingKey(name, id, part string) string {
+	return keyPrefix(name) + ":copy:" + id + ":" + part
+}
+
+// aliasKey holds an alias: a hash whose fieldAliasCollection names the collection
+// the alias points at. It is keyed under the alias's own prefix, so SetAlias can
+// refuse a name whose trie key exists there.
+func aliasKey(alias string) string {
+	return keyPrefix(alias) + ":alias"
+}
+
 // emptyTrieFields returns the hash fields written to initialize an empty V2
 // trie. The version is stamped fresh on each call.
 func emptyTrieFields() map[string]interface{} {
@@ -0,0 +1,369 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Keyword listing: Has, Count, and Keywords answer questions about the keyword
+// set without handing the caller — or, where the data lives in Redis, the
+// client — the whole of it. Preset mode answers from its local copy. Every
+// other mode asks Redis, where V1's set answers Has and Count natively and
+// keywordQueryScript answers a Has, Count, or Keywords page inside Redis, so only
+// the answer crosses the network.
+//
+// KEYS[1] is the V2 trie hash or the V1 keyword set, as ARGV[1] ("trie" or
+// "set") says; for a trie, KEYS[2] is its keyword index (see indexKey). ARGV[2]
+// is the query ("has", "count", or "page"), ARGV[3] the current time in Unix
+// milliseconds, against which expired keywords are left out. For "has", ARGV[4]
+// is the keyword; for "page", ARGV[4] is the cursor and ARGV[5] the page size,
+// and the reply holds one keyword more than that when there is another page.
+//
+// A trie marked indexed is answered from the index: ZSCORE for a Has, ZCARD for a
+// Count, and ZRANGEBYLEX for a page, so none of them decodes the keyword list.
+// The index keeps expired keywords until the sweep drops them, so Count subtracts
+// the expired ones and a page reads on past them. A trie not yet indexed, left by
+// a release before the index or by migration, falls back to decoding its keyword
+// list until its next write builds the index.
+//
+// Keywords are compared byte by byte, as ZRANGEBYLEX orders them, rather than
+// with Lua's string operators, which collate by the server's locale and so could
+// order a page differently from one computed locally.
+var keywordQueryScript = redis.NewScript(`
+	local keywords, rawExpiries, expiries
+	local indexed = false
+	if ARGV[1] == 'set' then
+		keywords = redis.call('SMEMBERS', KEYS[1])
+	else
+		local fields = redis.call('HMGET', KEYS[1], 'indexed', 'expiries')
+		indexed = fields[1] ~= false
+		rawExpiries = fields[2]
+		if not indexed then
+			local raw = redis.call('HGET', KEYS[1], 'keywords')
+			keywords = raw and cjson.decode(raw) or {}
+		end
+	end
+
+	-- The expiries are decoded on first use, so a Has for a keyword that is not
+	-- stored never pays for them.
+	local now = tonumber(ARGV[3])
+	local function decodeExpiries()
+		if expiries == nil then
+			expiries = rawExpiries and cjson.decode(rawExpiries) or {}
+		end
+		return expiries
+	end
+	local function live(kw)
+		local at = decodeExpiries()[kw]
+		return at == nil or at > now
+	end
+
+	local query = ARGV[2]
+	if indexed then
+		local index = KEYS[2]
+		if query == 'has' then
+			if redis.call('ZSCORE', index, ARGV[4]) then
+				return live(ARGV[4]) and 1 or 0
+			end
+			return 0
+		end
+		if query == 'count' then
+			local n = redis.call('ZCARD', index)
+			if rawExpiries then
+				for kw, at in pairs(decodeExpiries()) do
+					if at <= now and redis.call('ZSCORE', index, kw) then
+						n = n - 1
+					end
+				end
+			end
+			return n
+		end
+
+		local want = tonumber(ARGV[5]) + 1
+		local start = ARGV[4] == '' and '-' or '(' .. ARGV[4]
+		local out = {}
+		while #out < want do
+			local batch = redis.call('ZRANGEBYLEX', index, start, '+', 'LIMIT', 0, want - #out)
+			for _, kw in ipairs(batch) do
+				if live(kw) then
+					out[#out + 1] = kw
+				end
+			end
+			if #batch == 0 or #out >= want then
+				break
+			end
+			start = '(' .. batch[#batch]
+		end
+		return out
+	end
+
+	if query == 'has' then
+		for _, kw in ipairs(keywords) do
+			if kw == ARGV[4] then
+				return live(kw) and 1 or 0
+			end
+		end
+		return 0
+	end
+	if query == 'count' then
+		local n = 0
+		for _, kw in ipairs(keywords) do
+			if live(kw) then
+				n = n + 1
+			end
+		end
+		return n
+	end
+
+	local function before(a, b)
+		for i = 1, math.min(#a, #b) do
+			local x, y = string.byte(a, i), string.byte(b, i)
+			if x ~= y then
+				return x < y
+			end
+		end
+		return #a < #b
+	end
+	local cursor = ARGV[4]
+	local page = {}
+	for _, kw in ipairs(keywords) do
+		if live(kw) and (cursor == '' or before(cursor, kw)) then
+			page[#page + 1] = kw
+		end
+	end
+	table.sort(page, before)
+	local out = {}
+	for i = 1, math.min(#page, tonumber(ARGV[5]) + 1) do
+		out[i] = page[i]
+	end
+	return out
+`)
+
+// keywordQueryTrie and keywordQuerySet name the storage keywordQueryScript reads.
+const (
+	keywordQueryTrie = "trie"
+	keywordQuerySet  = "set"
+)
+
+// runKeywordQuery evaluates keywordQueryScript against keys, the first of which
+// names the collection's keyword storage.
+func runKeywordQuery(ctx context.Context, client redis.UniversalClient, keys []string, source, query string, args ...interface{}) *redis.Cmd {
+	argv := append([]interface{}{source, query, time.Now().UnixMilli()}, args...)
+	return keywordQueryScript.Run(ctx, client, keys, argv...)
+}
+
+// queryKeywordPage runs a "page" query and splits its reply into the page and the
+// cursor for the next one.
+func queryKeywordPage(ctx context.Context, client redis.UniversalClient, keys []string, source, cursor string, limit int) ([]string, string, error) {
+	page, err := runKeywordQuery(ctx, client, keys, source, "page", cursor, limit).StringSlice()
+	if err != nil {
+		return nil, "", newRedisError("EVAL", keys[0], err)
+	}
+	return splitPage(page, limit)
+}
+
+// splitPage trims a page fetched with one keyword to spare back to limit,
+// returning the cursor that resumes after it, or "" when nothing follows.
+func splitPage(page []string, limit int) ([]string, string, error) {
+	if len(page) <= limit {
+		return page, "", nil
+	}
+	page = page[:limit]
+	return page, page[limit-1], nil
+}
+
+// --- V2 ---
+
+// v2QueryKeys returns the keys keywordQueryScript reads for a V2 collection.
+func v2QueryKeys(name string) []string {
+	return []string{trieKey(name), indexKey(name)}
+}
+
+func (o *v2Operations) has(ctx context.Context, keyword string) (bool, error) {
+	n, err := runKeywordQuery(ctx, o.client, v2QueryKeys(o.name), keywordQueryTrie, "has", keyword).Int()
+	if err != nil {
+		return false, newRedisError("EVAL", trieKey(o.name), err)
+	}
+	return n == 1, nil
+}
+
+func (o *v2Operations) count(ctx context.Context) (int, error) {
+	n, err := runKeywordQuery(ctx, o.client, v2QueryKeys(o.name), keywordQueryTrie, "count").Int()
+	if err != nil {
+		return 0, newRedisError("EVAL", trieKey(o.name), err)
+	}
+	return n, nil
+}
+
+func (o *v2Operations) keywordPage(ctx context.Context, cursor string, limit int) ([]string, string, error) {
+	return queryKeywordPage(ctx, o.client, v2QueryKeys(o.name), keywordQueryTrie, cursor, limit)
+}
+
+// --- V1 ---
+
+func (o *v1Operations) has(ctx context.Context, keyword string) (bool, error) {
+	ok, err := o.storage.SIsMember(ctx, keywordKey(o.name), keyword)
+	if err != nil {
+		return false, newRedisError("SISMEMBER", keywordKey(o.name), err)
+	}
+	return ok, nil
+}
+
+func (o *v1Operations) count(ctx context.Context) (int, error) {
+	n, err := o.storage.SCard(ctx, keywordKey(o.name))
+	if err != nil {
+		return 0, newRedisError("SCARD", keywordKey(o.name), err)
+	}
+	return int(n), nil
+}
+
+func (o *v1Operations) keywordPage(ctx context.Context, cursor string, limit int) ([]string, string, error) {
+	return queryKeywordPage(ctx, o.client, []string{keywordKey(o.name)}, keywordQuerySet, cursor, limit)
+}
+
+// --- Preset ---
+
+func (ac *redisBackedAC) has(ctx context.Context, keyword string) (bool, error) {
+	if err := ac.ensureValid(ctx); err != nil {
+		return false, err
+	}
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	_, ok := ac.keywordSet[keyword]
+	return ok && !ac.expiries.expired(keyword, time.Now()), nil
+}
+
+func (ac *redisBackedAC) count(ctx context.Context) (int, error) {
+	if err := ac.ensureValid(ctx); err != nil {
+		return 0, err
+	}
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	now := time.Now()
+	n := 0
+	for kw := range ac.keywordSet {
+		if !ac.expiries.expired(kw, now) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// keywordPage sorts the keyword set once per reload, so paging through a
+// dictionary costs one sort rather than one per page.
+func (ac *redisBackedAC) keywordPage(ctx context.Context, cursor string, limit int) ([]string, string, error) {
+	if err := ac.ensureValid(ctx); err != nil {
+		return nil, "", err
+	}
+	ac.mu.RLock()
+	// A reload between sortKeywords and RLock drops the order again.
+	for ac.sortedKeywords == nil {
+		ac.mu.RUnlock()
+		ac.sortKeywords()
+		ac.mu.RLock()
+	}
+	defer ac.mu.RUnlock()
+	start := 0
+	if cursor != "" {
+		start, _ = slices.BinarySearch(ac.sortedKeywords, cursor)
+		if start < len(ac.sortedKeywords) && ac.sortedKeywords[start] == cursor {
+			start++
+		}
+	}
+	now := time.Now()
+	page := make([]string, 0, min(limit+1, len(ac.sortedKeywords)-start))
+	for _, kw := range ac.sortedKeywords[start:] {
+		if len(page) > limit {
+			break
+		}
+		if !ac.expiries.expired(kw, now) {
+			page = append(page, kw)
+		}
+	}
+	return splitPage(page, limit)
+}
+
+// sortKeywords builds sortedKeywords from the keyword set, unless another caller
+// already has.
+func (ac *redisBackedAC) sortKeywords() {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	if ac.sortedKeywords != nil {
+		return
+	}
+	ac.sortedKeywords = make([]string, 0, len(ac.keywordSet))
+	for kw := range ac.keywordSet {
+		ac.sortedKeywords = append(ac.sortedKeywords, kw)
+	}
+	slices.Sort(ac.sortedKeywords)
+}
+
+// --- Alias ---
+
+func (a *aliasOps) has(ctx context.Context, keyword string) (bool, error) {
+	return a.target().ops.has(ctx, keyword)
+}
+
+func (a *aliasOps) count(ctx context.Context) (int, error) {
+	return a.target().ops.count(ctx)
+}
+
+func (a *aliasOps) keywordPage(ctx context.Context, cursor string, limit int) ([]string, string, error) {
+	return a.target().ops.keywordPage(ctx, cursor, limit)
+}
+
+// Has reports whether keyword is in the collection, normalized as Add would store
+// it. A keyword whose TTL has run out is not. Preset mode answers from its local
+// copy; the other modes ask Redis, which answers without sending the keyword set.
+func (ac *AhoCorasick) Has(keyword string) (bool, error) {
+	return ac.HasContext(ac.ctx, keyword)
+}
+
+// HasContext is Has with an explicit context for cancellation.
+func (ac *AhoCorasick) HasContext(ctx context.Context, keyword string) (bool, error) {
+	keyword = normalizeKeyword(keyword, ac.caseSensitive)
+	if keyword == "" {
+		return false, nil
+	}
+	return ac.ops.has(ctx, keyword)
+}
+
+// Count returns how many keywords the collection holds, leaving out those whose
+// TTL has run out. Like Has it never transfers the keyword set.
+func (ac *AhoCorasick) Count() (int, error) {
+	return ac.CountContext(ac.ctx)
+}
+
+// CountContext is Count with an explicit context for cancellation.
+func (ac *AhoCorasick) CountContext(ctx context.Context) (int, error) {
+	return ac.ops.count(ctx)
+}
+
+// Keywords returns up to limit keywords following cursor, in byte order, and the
+// cursor for the page after them. Start with an empty cursor and pass each call's
+// next to the following one; an empty next means the last page has been read.
+//
+// The order is that of the keywords alone, so it is the same in every mode and
+// on every instance, and a keyword added or removed between pages moves no other
+// keyword across a page boundary. A keyword added behind the cursor is not seen
+// by the pass in progress. Keywords whose TTL has run out are left out.
+//
+// Preset mode pages through its local copy. Elsewhere Redis selects each page and
+// sends only that page. limit must be positive, or Keywords returns
+// ErrInvalidLimit.
+func (ac *AhoCorasick) Keywords(cursor string, limit int) (keywords []string, next string, err error) {
+	return ac.KeywordsContext(ac.ctx, cursor, limit)
+}
+
+// KeywordsContext is Keywords with an explicit context for cancellation.
+func (ac *AhoCorasick) KeywordsContext(ctx context.Context, cursor string, limit int) (keywords []string, next string, err error) {
+	if limit <= 0 {
+		return nil, "", ErrInvalidLimit
+	}
+	return ac.ops.keywordPage(ctx, cursor, limit)
+}
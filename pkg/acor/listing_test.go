@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// readAllPages pages through ac with limit, returning every page in order.
+func readAllPages(t *testing.T, ac *AhoCorasick, limit int) [][]string {
+	t.Helper()
+	var pages [][]string
+	cursor := ""
+	for {
+		page, next, err := ac.Keywords(cursor, limit)
+		if err != nil {
+			t.Fatalf("Keywords(%q, %d) error: %v", cursor, limit, err)
+		}
+		pages = append(pages, page)
+		if next == "" {
+			return pages
+		}
+		cursor = next
+	}
+}
+
+func TestListing_AgreesAcrossModes(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args AhoCorasickArgs
+	}{
+		{"v1", AhoCorasickArgs{SchemaVersion: SchemaV1}},
+		{"v2", AhoCorasickArgs{}},
+		{"cached", AhoCorasickArgs{EnableCache: true}},
+		{"preset", AhoCorasickArgs{Preset: PresetBalanced}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			args := tc.args
+			args.Addr, args.Name = mr.Addr(), "c"
+			ac, err := Create(&args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = ac.Close() }()
+			if tc.args.SchemaVersion == SchemaV1 {
+				ac = v1Writable(t, ac)
+			}
+			for _, kw := range []string{"Banana", "date", "äpfel", "apple", "cherry"} {
+				if _, err := ac.Add(kw); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if ok, err := ac.Has("APPLE"); err != nil || !ok {
+				t.Errorf("Has(APPLE) = %v, %v; want true", ok, err)
+			}
+			if ok, err := ac.Has("fig"); err != nil || ok {
+				t.Errorf("Has(fig) = %v, %v; want false", ok, err)
+			}
+			if n, err := ac.Count(); err != nil || n != 5 {
+				t.Errorf("Count() = %d, %v; want 5", n, err)
+			}
+
+			got := readAllPages(t, ac, 2)
+			want := [][]string{{"apple", "banana"}, {"cherry", "date"}, {"äpfel"}}
+			if !slices.EqualFunc(got, want, slices.Equal) {
+				t.Errorf("pages = %v, want %v", got, want)
+			}
+
+			// A cursor that is no longer stored still resumes right after it.
+			if _, err := ac.Remove("banana"); err != nil {
+				t.Fatal(err)
+			}
+			page, next, err := ac.Keywords("banana", 10)
+			if err != nil || !slices.Equal(page, []string{"cherry", "date", "äpfel"}) || next != "" {
+				t.Errorf("Keywords(banana, 10) after removing banana = %v, %q, %v", page, next, err)
+			}
+		})
+	}
+}
+
+func TestListing_LeavesOutExpiredKeywords(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "c", "keep")
+	if _, err := ac.AddWithTTL("gone", 30*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := ac.Has("gone"); !ok {
+		t.Fatal("Has(gone) before its TTL = false")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if ok, err := ac.Has("gone"); err != nil || ok {
+		t.Errorf("Has(gone) after its TTL = %v, %v; want false", ok, err)
+	}
+	if n, err := ac.Count(); err != nil || n != 1 {
+		t.Errorf("Count() = %d, %v; want 1", n, err)
+	}
+	if page, _, err := ac.Keywords("", 10); err != nil || !slices.Equal(page, []string{"keep"}) {
+		t.Errorf("Keywords = %v, %v; want [keep]", page, err)
+	}
+}
+
+func TestListing_EmptyAndInvalid(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "c")
+
+	page, next, err := ac.Keywords("", 10)
+	if err != nil || page == nil || len(page) != 0 || next != "" {
+		t.Errorf("Keywords on an empty collection = %#v, %q, %v; want an empty page", page, next, err)
+	}
+	if ok, err := ac.Has(""); err != nil || ok {
+		t.Errorf("Has(\"\") = %v, %v; want false", ok, err)
+	}
+	if _, _, err := ac.Keywords("", 0); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Keywords with limit 0 error = %v, want ErrInvalidLimit", err)
+	}
+}
+
+// indexMembers returns the members of collection name's keyword index.
+func indexMembers(t *testing.T, mr *miniredis.Miniredis, name string) []string {
+	t.Helper()
+	if !mr.Exists(indexKey(name)) {
+		return nil
+	}
+	members, err := mr.ZMembers(indexKey(name))
+	if err != nil {
+		t.Fatalf("ZMembers(%s) error: %v", indexKey(name), err)
+	}
+	return members
+}
+
+func TestListing_IndexFollowsWrites(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "c", "he", "she")
+	check := func(step string, want ...string) {
+		t.Helper()
+		if got := indexMembers(t, mr, "c"); !slices.Equal(got, want) {
+			t.Errorf("index after %s = %v, want %v", step, got, want)
+		}
+	}
+	check("Add", "he", "she")
+
+	if _, err := ac.Remove("he"); err != nil {
+		t.Fatal(err)
+	}
+	check("Remove", "she")
+
+	if _, err := ac.AddMany([]string{"his", "hers"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	check("AddMany", "hers", "his", "she")
+
+	if _, err := ac.Apply(&Changeset{Add: []string{"he"}, Remove: []string{"his"}}); err != nil {
+		t.Fatal(err)
+	}
+	check("Apply", "he", "hers", "she")
+
+	if _, err := ac.Sync([]string{"he", "ushers"}, &SyncOptions{MaxDeletes: 2}); err != nil {
+		t.Fatal(err)
+	}
+	check("Sync", "he", "ushers")
+
+	if err := ac.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	check("Flush")
+	if page, _, err := ac.Keywords("", 10); err != nil || len(page) != 0 {
+		t.Errorf("Keywords after Flush = %v, %v; want an empty page", page, err)
+	}
+}
+
+// A trie written before the index existed has no indexed marker. It is read
+// from its keyword list until its next write builds the index.
+func TestListing_UnindexedTrieFallsBack(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "c", "banana", "apple", "cherry")
+	mr.HDel(trieKey("c"), fieldIndexed)
+	mr.Del(indexKey("c"))
+
+	if ok, err := ac.Has("apple"); err != nil || !ok {
+		t.Errorf("Has(apple) = %v, %v; want true", ok, err)
+	}
+	if n, err := ac.Count(); err != nil || n != 3 {
+		t.Errorf("Count() = %d, %v; want 3", n, err)
+	}
+	got := readAllPages(t, ac, 2)
+	want := [][]string{{"apple", "banana"}, {"cherry"}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+
+	if _, err := ac.Add("date"); err != nil {
+		t.Fatal(err)
+	}
+	if mr.HGet(trieKey("c"), fieldIndexed) == "" {
+		t.Error("the write after the fallback left the trie unindexed")
+	}
+	if got, want := indexMembers(t, mr, "c"), []string{"apple", "banana", "cherry", "date"}; !slices.Equal(got, want) {
+		t.Errorf("index after the write = %v, want %v", got, want)
+	}
+}
+
+// Expired keywords stay in the index until the sweep drops them, so a page has to
+// read past them to fill up.
+func TestListing_PagesReadPastExpiredIndexEntries(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "c", "a", "e")
+	for _, kw := range []string{"b", "c", "d"} {
+		if _, err := ac.AddWithTTL(kw, 30*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if got := indexMembers(t, mr, "c"); len(got) != 5 {
+		t.Fatalf("index = %v; the test needs the expired keywords still in it", got)
+	}
+	got := readAllPages(t, ac, 1)
+	want := [][]string{{"a"}, {"e"}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+	if n, err := ac.Count(); err != nil || n != 2 {
+		t.Errorf("Count() = %d, %v; want 2", n, err)
+	}
+	if ok, err := ac.Has("c"); err != nil || ok {
+		t.Errorf("Has(c) = %v, %v; want false", ok, err)
+	}
+}
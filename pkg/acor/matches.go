@@ -10,6 +10,9 @@
 	"io"
 	"slices"
 	"unicode"
+	"unicode/utf8"
+
+	matchengine "github.com/skyoo2003/acor/internal/engine"
 )
 
 // Match is a single keyword occurrence in the searched text. Start and End are
@@ -23,6 +26,9 @@ type Match struct {
 	Start int
 	// End is the rune offset where the match ends, exclusive.
 	End int
+	// Collection names the collection holding Keyword. Only a Union's searches
+	// set it; a single collection's leave it empty.
+	Collection string
 }
 
 // matchResultHint is the starting capacity for a match slice. Text that matches
@@ -68,6 +74,48 @@ type MatchOptions struct {
 	// misclassifies — e.g. return false for CJK ideographs so a CJK term bounded
 	// by spaces or ASCII is reported. Ignored unless WholeWord is true.
 	WordRune func(rune) bool
+	// Limits bounds the scan itself. nil scans the whole text unchecked, which is
+	// the fastest path; see ScanLimits for what a bounded scan costs.
+	Limits *ScanLimits
+	// Tags, when non-empty, restricts matches to keywords carrying at least one of
+	// these tags (see AddWithTags); an untagged keyword never matches. The filter
+	// runs as the automaton reports matches, before WholeWord and
+	// MatchKindLeftmostLongest, so a longer keyword outside the tags cannot hide a
+	// shorter one inside them. Outside preset mode it costs one more read, of the
+	// collection's tags. A blank tag is ErrInvalidTag.
+	Tags []string
+}
+
+// defaultScanCheckEvery is how many runes a bounded scan reads between context
+// checks when ScanLimits.CheckEvery is unset. ctx.Err takes a lock on a
+// cancelable context, so checking every rune would cost more than the scan; at
+// this spacing a canceled scan still stops within microseconds.
+const defaultScanCheckEvery = 4096
+
+// ScanLimits bounds a FindMatches, FindStreamWithLimits, or CountStreamWithLimits
+// scan of text that is not trusted to be small:
+// an upload that turns out to be 500 MB, or a dictionary whose overlapping
+// matches run into the millions. Without limits a scan runs to the end of its
+// text, and a canceled context is noticed only before it starts.
+//
+// A scan that hits a limit stops there and returns a *ScanLimitError holding the
+// matches found so far. FindMatches also returns those matches alongside the
+// error, and FindMatchesAppend its extended dst, so the earlier contents of dst
+// survive a limit. Bounded scans pull runes one at a time so the context can
+// be checked between them, which makes them slower than an unbounded scan of the
+// same text; set limits where the bound matters more than the throughput.
+type ScanLimits struct {
+	// MaxMatches stops the scan when it would report more than this many matches.
+	// Matches are counted as the automaton reports them, overlaps included, before
+	// Tags, WholeWord, or MatchKindLeftmostLongest drop any, so the bound holds the
+	// scan's work to the same size whatever the filters keep. Zero means no limit.
+	MaxMatches int
+	// MaxTextRunes stops the scan when the text is longer than this many runes,
+	// after scanning that many. Zero means no limit.
+	MaxTextRunes int
+	// CheckEvery is how many runes the scan reads between context checks. Zero uses
+	// a default of 4096.
+	CheckEvery int
 }
 
 // FindMatches searches text and returns matches carrying each keyword and its
@@ -108,14 +156,28 @@ func (ac *AhoCorasick) findMatches(ctx context.Context, dst []Match, text string
 		}
 		return dst, nil
 	}
-	norm := normalizeText(text, ac.caseSensitive)
 
 	eng, err := ac.ops.loadEngine(ctx)
 	if err != nil {
 		return nil, err
 	}
-	// Honor an already-canceled ctx at the match boundary; the in-memory scan
-	// itself isn't ctx-threaded (mirrors find/findIndex).
+	var wantTags map[string]struct{}
+	var tags keywordTags
+	if opts != nil && len(opts.Tags) > 0 {
+		normalized, err := normalizeTags(opts.Tags)
+		if err != nil {
+			return nil, err
+		}
+		wantTags = make(map[string]struct{}, len(normalized))
+		for _, tag := range normalized {
+			wantTags[tag] = struct{}{}
+		}
+		if tags, err = ac.loadTags(ctx); err != nil {
+			return nil, err
+		}
+	}
+	// Honor an already-canceled ctx at the match boundary; an unbounded in-memory
+	// scan isn't ctx-threaded (mirrors find/findIndex).
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
@@ -126,13 +188,24 @@ func (ac *AhoCorasick) findMatches(ctx context.Context, dst []Match, text string
 	// results.
 	base := len(dst)
 	matches := dst
-	eng.MatchString(norm, func(keyword string, start, end int) bool {
+	collect := func(keyword string, start, end int) bool {
+		if wantTags != nil && !tags.hasAny(keyword, wantTags) {
+			return true
+		}
 		if matches == nil {
 			matches = make([]Match, 0, matchResultHint)
 		}
 		matches = append(matches, Match{Keyword: keyword, Start: start, End: end})
 		return true
-	})
+	}
+	var norm string
+	var limitErr *ScanLimitError
+	if opts != nil && opts.Limits != nil {
+		norm, limitErr = scanTextLimited(ctx, eng, text, ac.caseSensitive, opts.Limits, collect)
+	} else {
+		norm = normalizeText(text, ac.caseSensitive)
+		eng.MatchString(norm, collect)
+	}
 	if matches == nil {
 		matches = []Match{}
 	}
@@ -155,9 +228,92 @@ func (ac *AhoCorasick) findMatches(ctx context.Context, dst []Match, text string
 		// found still aliases it, source and destination coincide and it is a no-op.
 		matches = append(matches[:base], found...)
 	}
+	if limitErr != nil {
+		limitErr.Matches = matches[base:]
+		return matches, limitErr
+	}
 	return matches, nil
 }
 
+// scanLimited runs a scan of the runes src yields under limits, reporting matches
+// to collect. It returns nil when src ran out, and otherwise a *ScanLimitError
+// with Err and Runes set; the caller fills in Matches once it has filtered them.
+//
+// The scan goes through the engine's pull-based stream so the source can count
+// runes and poll ctx between them; MatchString walks its string in a loop that
+// takes no callbacks per rune.
+func scanLimited(ctx context.Context, eng *matchengine.Engine, src func() (rune, bool), limits *ScanLimits,
+	collect func(string, int, int) bool) *ScanLimitError {
+	every := limits.CheckEvery
+	if every <= 0 {
+		every = defaultScanCheckEvery
+	}
+	var stop error
+	runes, found := 0, 0
+
+	next := func() (rune, bool) {
+		// The rune is read before the limit is checked: a text exactly
+		// MaxTextRunes long is scanned whole, not cut off.
+		r, ok := src()
+		if !ok {
+			return 0, false
+		}
+		if limits.MaxTextRunes > 0 && runes == limits.MaxTextRunes {
+			stop = ErrMaxTextRunes
+			return 0, false
+		}
+		if runes%every == 0 {
+			if err := ctx.Err(); err != nil {
+				stop = err
+				return 0, false
+			}
+		}
+		runes++
+		return r, true
+	}
+	eng.Stream(next, func(keyword string, start, end int) bool {
+		if limits.MaxMatches > 0 && found == limits.MaxMatches {
+			stop = ErrMaxMatches
+			return false
+		}
+		found++
+		return collect(keyword, start, end)
+	})
+	if stop == nil {
+		return nil
+	}
+	return &ScanLimitError{Err: stop, Runes: runes}
+}
+
+// scanTextLimited is scanLimited over text, folding each rune as normalizeText
+// would as the scan reaches it, so a scan a limit stops early neither copies
+// nor folds the rest of the text. It returns the normalized text up to the
+// rune after the last one scanned, which is as far as the match filters look.
+func scanTextLimited(ctx context.Context, eng *matchengine.Engine, text string, caseSensitive bool,
+	limits *ScanLimits, collect func(string, int, int) bool) (string, *ScanLimitError) {
+	pos := 0
+	src := func() (rune, bool) {
+		if pos == len(text) {
+			return 0, false
+		}
+		// DecodeRuneInString yields RuneError for invalid UTF-8 exactly as a range
+		// loop does, so offsets agree with the unbounded scan.
+		r, size := utf8.DecodeRuneInString(text[pos:])
+		pos += size
+		if !caseSensitive {
+			// The fold strings.ToLower applies rune by rune; see streamRunes.
+			r = unicode.ToLower(r)
+		}
+		return r, true
+	}
+	limitErr := scanLimited(ctx, eng, src, limits, collect)
+	if pos < len(text) {
+		_, size := utf8.DecodeRuneInString(text[pos:])
+		pos += size
+	}
+	return normalizeText(text[:pos], caseSensitive), limitErr
+}
+
 // FindSet returns each matched keyword once, in first-match order.
 //
 // Find reports one entry per occurrence, which is rarely what a content filter
@@ -243,15 +399,67 @@ func (ac *AhoCorasick) FindStreamContext(ctx context.Context, r io.Reader, onMat
 		return err
 	}
 
+	next, scanErr := ac.streamRunes(ctx, r)
+	eng.Stream(next, func(keyword string, start, end int) bool {
+		return onMatch(Match{Keyword: keyword, Start: start, End: end})
+	})
+	return scanErr()
+}
+
+// FindStreamWithLimits is FindStream bounded by limits, for input that is not
+// trusted to be small. A scan that hits a limit stops there and returns a
+// *ScanLimitError; its Matches is empty, since onMatch has already seen every
+// match found. A nil limits scans the whole input, as FindStream does.
+func (ac *AhoCorasick) FindStreamWithLimits(r io.Reader, limits *ScanLimits, onMatch func(Match) bool) error {
+	return ac.FindStreamWithLimitsContext(ac.ctx, r, limits, onMatch)
+}
+
+// FindStreamWithLimitsContext is FindStreamWithLimits with an explicit context.
+// A context canceled during the scan is reported as a *ScanLimitError, as
+// FindMatches reports it under limits.
+func (ac *AhoCorasick) FindStreamWithLimitsContext(ctx context.Context, r io.Reader, limits *ScanLimits,
+	onMatch func(Match) bool) error {
+	if limits == nil {
+		return ac.FindStreamContext(ctx, r, onMatch)
+	}
+	if r == nil || onMatch == nil {
+		return nil
+	}
+
+	eng, err := ac.ops.loadEngine(ctx)
+	if err != nil {
+		return err
+	}
+
+	// scanLimited polls ctx itself, every CheckEvery runes; the stream's own
+	// check would report a cancellation as a bare ctx.Err.
+	next, scanErr := ac.streamRunes(context.WithoutCancel(ctx), r)
+	limitErr := scanLimited(ctx, eng, next, limits, func(keyword string, start, end int) bool {
+		return onMatch(Match{Keyword: keyword, Start: start, End: end})
+	})
+	if err := scanErr(); err != nil {
+		return err
+	}
+	if limitErr != nil {
+		return limitErr
+	}
+	return nil
+}
+
+// streamRunes adapts r to the rune-pull source the engine's stream scans take,
+// folding case as normalizeText would. The source stops at end of input, on a read
+// error, or once ctx is canceled, checked between runes; scanErr then reports
+// which, nil meaning the input simply ran out.
+func (ac *AhoCorasick) streamRunes(ctx context.Context, r io.Reader) (next func() (rune, bool), scanErr func() error) {
 	br := bufio.NewReader(r)
 	caseInsensitive := !ac.caseSensitive
-	var scanErr error
+	var err error
 
 	// bufio.Reader.ReadRune handles runes split across buffer refills, so the
 	// stream is decoded exactly like a range loop over the full string.
-	next := func() (rune, bool) {
-		if err := ctx.Err(); err != nil {
-			scanErr = err
+	next = func() (rune, bool) {
+		if e := ctx.Err(); e != nil {
+			err = e
 			return 0, false
 		}
 		ru, _, e := br.ReadRune()
@@ -259,7 +467,7 @@ func (ac *AhoCorasick) FindStreamContext(ctx context.Context, r io.Reader, onMat
 			// errors.Is, not ==: a decorator reader may return a wrapped io.EOF at
 			// end of input, which is a normal completion, not a scan failure.
 			if !errors.Is(e, io.EOF) {
-				scanErr = e
+				err = e
 			}
 			return 0, false
 		}
@@ -272,11 +480,7 @@ func (ac *AhoCorasick) FindStreamContext(ctx context.Context, r io.Reader, onMat
 		}
 		return ru, true
 	}
-
-	eng.Stream(next, func(keyword string, start, end int) bool {
-		return onMatch(Match{Keyword: keyword, Start: start, End: end})
-	})
-	return scanErr
+	return next, func() error { return err }
 }
 
 // cmpLeftmostLongest orders matches by start ascending, and among matches at the
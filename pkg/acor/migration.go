@@ -43,8 +43,12 @@
 
 // requireRedisBacked rejects the migration entry points in preset mode, where
 // createPresetRedis leaves redisClient nil — every Redis call below it would
-// otherwise dereference a nil interface.
+// otherwise dereference a nil interface — and on instances opened by alias, whose
+// ac.name is the alias rather than a collection.
 func (ac *AhoCorasick) requireRedisBacked() error {
+	if ac.mode == modeAlias {
+		return ErrMigrationViaAlias
+	}
 	if ac.mode != modeOriginal || ac.redisClient == nil {
 		return ErrMigrationRequiresRedis
 	}
@@ -74,8 +78,8 @@ func (ac *AhoCorasick) releaseMigrationLock() error {
 }
 
 // MigrateV1ToV2 migrates the collection from V1 schema to V2 schema.
-// V2 offers better performance and occupies at most 3 Redis keys instead of a
-// count that grows with the dictionary — see SchemaV2 for which of the three a
+// V2 offers better performance and occupies at most 4 Redis keys instead of a
+// count that grows with the dictionary — see SchemaV2 for which of them a
 // migrated collection actually ends up with.
 //
 // The migration process:
@@ -318,6 +322,8 @@ func (ac *AhoCorasick) MigrateV1ToV2(opts *MigrationOptions) (*MigrationResult,
 			}
 		}
 
+		// The new trie is not marked indexed, so its first write builds the index.
+		pipe.Del(ac.ctx, indexKey(ac.name))
 		pipe.Rename(ac.ctx, tempTrieKey, trieKey(ac.name))
 		if len(outputs) > 0 {
 			pipe.Rename(ac.ctx, tempOutputsKey, outputsKey(ac.name))
@@ -340,7 +346,7 @@ func (ac *AhoCorasick) MigrateV1ToV2(opts *MigrationOptions) (*MigrationResult,
 
 	// Swap ops to v2Operations so the instance uses V2 schema operations
 	// going forward. The cache is already set up if EnableCache was true.
-	ac.ops = ac.newV2Ops(ac.cache)
+	ac.ops = ac.newV2Ops(ac.name, ac.cache)
 
 	result.Status = migrationStatusSuccess
 	result.DurationMs = time.Since(start).Milliseconds()
@@ -382,7 +388,7 @@ func (ac *AhoCorasick) RollbackToV1() error {
 		return errors.New("V1 keys not found - rollback not possible")
 	}
 
-	if _, err := ac.redisClient.Del(ac.ctx, trieKey(ac.name), outputsKey(ac.name), nodesKey(ac.name)).Result(); err != nil {
+	if _, err := ac.redisClient.Del(ac.ctx, trieKey(ac.name), outputsKey(ac.name), nodesKey(ac.name), indexKey(ac.name)).Result(); err != nil {
 		return fmt.Errorf("failed to delete V2 keys: %w", err)
 	}
 
@@ -10,13 +10,30 @@
 const (
 	modeOriginal    backendMode = iota // V1 or V2 Redis-backed (original behavior)
 	modePresetRedis                    // Redis persistence + local matchEngine
+	modeAlias                          // either of the above, following an alias's target
 )
 
-// hasAnyRedisConfig returns true if any Redis connection field is set.
+// hasAnyRedisConfig returns true if any Redis connection field is set, or a
+// shared Connection is.
 func (a *AhoCorasickArgs) hasAnyRedisConfig() bool {
+	return a.Connection != nil || a.Addr != "" || len(a.Addrs) > 0 ||
+		a.MasterName != "" || len(a.RingAddrs) > 0 ||
+		a.Password != "" || a.DB != 0 ||
+		a.Username != "" || a.CredentialsProvider != nil ||
+		a.TLSConfig != nil || a.TLS != nil
+}
+
+// hasClientConfig returns true if any field that newRedisClient reads is set,
+// the ones a shared Connection would leave unread.
+func (a *AhoCorasickArgs) hasClientConfig() bool {
 	return a.Addr != "" || len(a.Addrs) > 0 ||
 		a.MasterName != "" || len(a.RingAddrs) > 0 ||
-		a.Password != "" || a.DB != 0
+		a.Password != "" || a.DB != 0 ||
+		a.Username != "" || a.CredentialsProvider != nil ||
+		a.SentinelUsername != "" || a.SentinelPassword != "" ||
+		a.TLSConfig != nil || a.TLS != nil ||
+		a.DialTimeout != 0 || a.ReadTimeout != 0 || a.WriteTimeout != 0 ||
+		a.MaxRetries != 0 || a.PoolSize != 0
 }
 
 // normalizeKeyword trims whitespace and optionally lowercases a keyword.
@@ -26,6 +26,11 @@ type operations interface {
 	suggestIndex(ctx context.Context, input string) (map[string][]int, error)
 	flush(ctx context.Context) error
 	info(ctx context.Context) (*AhoCorasickInfo, error)
+	// has, count, and keywordPage back Has, Count, and Keywords; see listing.go.
+	// keyword arrives normalized, and limit positive.
+	has(ctx context.Context, keyword string) (bool, error)
+	count(ctx context.Context) (int, error)
+	keywordPage(ctx context.Context, cursor string, limit int) ([]string, string, error)
 	// loadEngine returns an immutable in-memory match engine snapshot for the
 	// current keyword set. FindMatches, Contains, and FindStream all scan through
 	// it, so match semantics stay identical to find/findIndex across every mode.
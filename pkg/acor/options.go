@@ -2,7 +2,10 @@
 
 package acor
 
-import "runtime"
+import (
+	"runtime"
+	"time"
+)
 
 // BatchMode defines the behavior when errors occur during batch operations.
 type BatchMode int
@@ -25,6 +28,15 @@ type BatchOptions struct {
 	// value, not a pointer, so it is never nil: unset means the zero value,
 	// BatchModeBestEffort. Passing a nil *BatchOptions selects the same mode.
 	Mode BatchMode
+	// TTL, when positive, sets every keyword AddMany writes to expire that long
+	// after the call, as AddWithTTL does — keywords already present included,
+	// though they are still reported as Skipped. Zero adds keywords that never
+	// expire; negative is ErrInvalidTTL. RemoveMany ignores it.
+	TTL time.Duration
+	// Tags are added to every keyword AddMany writes, as AddWithTags does —
+	// keywords already present included, though they are still reported as
+	// Skipped. A blank tag is ErrInvalidTag. RemoveMany ignores them.
+	Tags []string
 }
 
 // ChunkBoundary defines how text is split into chunks for parallel processing.
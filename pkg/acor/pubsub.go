@@ -18,21 +18,28 @@ func (ac *AhoCorasick) startCacheListener() error {
 	// Bound alongside cache for the same reason: the callback runs on its own
 	// goroutine, so it reads neither field live.
 	stats := ac.stats
-	pubsub, err := subscribeInvalidations(ac.ctx, ac.storage, ac.name, ac.stopCh, func(payload string) {
+	pubsub, err := subscribeInvalidations(ac.ctx, ac.storage, ac.name, ac.stopCh, cacheInvalidator(cache, ac.name, stats))
+	if err != nil {
+		return fmt.Errorf("pub/sub connection failed: %w", err)
+	}
+
+	ac.pubsub = pubsub
+	return nil
+}
+
+// cacheInvalidator returns the listener callback that drops cache when a peer
+// invalidates the named collection. Instances opened by Alias run one per target
+// collection, so it takes everything it needs as arguments rather than from ac.
+func cacheInvalidator(cache *trieCache, name string, stats *cacheStats) func(payload string) {
+	return func(payload string) {
 		if cache == nil {
 			return
 		}
-		if !foreignInvalidation(payload, ac.name, &cache.selfSkip, stats) {
+		if !foreignInvalidation(payload, name, &cache.selfSkip, stats) {
 			return
 		}
 		cache.invalidate()
-	})
-	if err != nil {
-		return fmt.Errorf("pub/sub connection failed: %w", err)
 	}
-
-	ac.pubsub = pubsub
-	return nil
 }
 
 // stopCacheListener is idempotent: RollbackToV1 stops the listener mid-life and
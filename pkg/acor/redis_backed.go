@@ -33,12 +33,21 @@ type redisBackedAC struct {
 	storage     kvStorage
 	redisClient redis.UniversalClient
 
-	keywordSet   map[string]struct{}
+	keywordSet map[string]struct{}
+	// sortedKeywords is keywordSet in byte order for Keywords, built on first use
+	// after each reload; nil until then.
+	sortedKeywords []string
+	// expiries came with keywordSet; nextExpiry is when the engine built from
+	// them next loses a keyword, or zero.
+	expiries     keywordExpiries
+	nextExpiry   time.Time
+	tags         keywordTags
 	localVersion int64
 	stale        bool
 	pollInterval time.Duration
 
-	stats *cacheStats
+	stats   *cacheStats
+	history *historyLog // nil unless AhoCorasickArgs.History is set
 
 	selfSkip    selfSkipSet
 	reloadGroup singleflight.Group
@@ -52,8 +61,10 @@ type redisBackedAC struct {
 
 // newRedisBacked creates a Redis-backed Aho-Corasick engine. It loads the
 // current keywords from Redis, builds the local automaton, and starts a
-// Pub/Sub listener for cross-instance invalidation.
-func newRedisBacked(ctx context.Context, args *AhoCorasickArgs) (*redisBackedAC, error) {
+// Pub/Sub listener for cross-instance invalidation. stats receives the engine's
+// rebuild and invalidation counters; the caller passes them in because the first
+// rebuild happens here, before any AhoCorasick exists to hold them.
+func newRedisBacked(ctx context.Context, args *AhoCorasickArgs, stats *cacheStats) (*redisBackedAC, error) {
 	if args == nil {
 		return nil, ErrNilArgs
 	}
@@ -66,12 +77,11 @@ func newRedisBacked(ctx context.Context, args *AhoCorasickArgs) (*redisBackedAC,
 		preset = PresetBalanced
 	}
 
-	redisClient, err := newRedisClient(args)
+	redisClient, storage, err := openRedis(args)
 	if err != nil {
 		return nil, err
 	}
 
-	storage := newRedisStorage(redisClient)
 	// Background, not ctx: ctx is the construction context (see CreateContext) and
 	// may be request-scoped, while acCtx drives the subscribe, listener, and poller
 	// until Close. Deriving it from ctx would stop them the moment the caller's
@@ -87,7 +97,8 @@ func newRedisBacked(ctx context.Context, args *AhoCorasickArgs) (*redisBackedAC,
 		storage:       storage,
 		redisClient:   redisClient,
 		keywordSet:    make(map[string]struct{}),
-		stats:         &cacheStats{},
+		stats:         stats,
+		history:       newHistoryLog(args.History),
 		pollInterval:  args.InvalidationPollInterval,
 		ctx:           acCtx,
 		cancel:        acCancel,
@@ -164,8 +175,8 @@ func buildEngine(preset Preset, keywordSet map[string]struct{}) *matchengine.Eng
 	return e
 }
 
-// rebuildEngine replaces the engine from the current keyword set and records the
-// build. Every build in this mode routes through here, not just the reload path: a
+// rebuildEngine replaces the engine from the current keyword set, less any keyword
+// expired by now, and records the build. Every build in this mode routes through here, not just the reload path: a
 // single-keyword write and a flush rebuild directly, and a build the counters miss
 // inflates the mean rebuild cost that a write is supposed to explain.
 //
@@ -175,9 +186,27 @@ func buildEngine(preset Preset, keywordSet map[string]struct{}) *matchengine.Eng
 // Caller holds ac.mu.
 func (ac *redisBackedAC) rebuildEngine() {
 	start := time.Now()
-	engine := buildEngine(ac.preset, ac.keywordSet)
+	engine := buildEngine(ac.preset, ac.expiries.live(ac.keywordSet, start))
 	ac.stats.recordRebuild(time.Since(start))
 	ac.engine = engine
+	ac.nextExpiry = ac.expiries.next(start)
+}
+
+// retireExpired rebuilds the engine once a keyword in it has expired. The expiry
+// times came with the keywords, so this needs no Redis.
+func (ac *redisBackedAC) retireExpired() {
+	due := func() bool { return !ac.nextExpiry.IsZero() && !time.Now().Before(ac.nextExpiry) }
+	ac.mu.RLock()
+	expired := due()
+	ac.mu.RUnlock()
+	if !expired {
+		return
+	}
+	ac.mu.Lock()
+	if due() {
+		ac.rebuildEngine()
+	}
+	ac.mu.Unlock()
 }
 
 func (ac *redisBackedAC) applyReload(snap *trieSnapshot) {
@@ -186,6 +215,9 @@ func (ac *redisBackedAC) applyReload(snap *trieSnapshot) {
 		keywordSet[kw] = struct{}{}
 	}
 	ac.keywordSet = keywordSet
+	ac.sortedKeywords = nil
+	ac.expiries = snap.Expiries
+	ac.tags = snap.Tags
 	ac.rebuildEngine()
 	ac.localVersion = snap.Version
 	ac.stale = false
@@ -224,9 +256,14 @@ func (ac *redisBackedAC) markStale() {
 }
 
 func (ac *redisBackedAC) ensureValid(ctx context.Context) error {
+	if err := ac.catchUpMinVersion(ctx); err != nil {
+		return err
+	}
+
 	ac.mu.RLock()
 	if !ac.stale {
 		ac.mu.RUnlock()
+		ac.retireExpired()
 		ac.stats.hit()
 		return nil
 	}
@@ -256,6 +293,27 @@ func (ac *redisBackedAC) ensureValid(ctx context.Context) error {
 	return err
 }
 
+// catchUpMinVersion marks the engine stale when ctx asks for a version the local
+// state may not include yet (see WithMinVersion), so the reload in ensureValid
+// fetches it now rather than when the invalidation arrives. Already stale needs no
+// check: the reload reads Redis anyway.
+func (ac *redisBackedAC) catchUpMinVersion(ctx context.Context) error {
+	ac.mu.RLock()
+	local, stale := ac.localVersion, ac.stale
+	ac.mu.RUnlock()
+	if stale {
+		return nil
+	}
+	behind, err := behindMinVersion(ctx, ac.storage, ac.name, local)
+	if err != nil {
+		return err
+	}
+	if behind {
+		ac.markStale()
+	}
+	return nil
+}
+
 // --- Pub/Sub ---
 
 // publishRetryAttempts / publishRetryBackoff give the fire-and-forget invalidation
@@ -4,6 +4,7 @@
 
 import (
 	"context"
+	"time"
 )
 
 // redisBackedAC implements the operations interface directly, so AhoCorasick
@@ -33,17 +34,18 @@ func (ac *redisBackedAC) tryAdd(ctx context.Context, keyword string) (int, error
 		return 0, err
 	}
 
-	outputs, changed := planAdd(snap, keyword)
-	if !changed {
+	outputs, added := planAddLive(snap, []string{keyword})
+	if len(added) == 0 {
 		return 0, nil
 	}
 
-	newVersion, err := commitV2Write(ctx, ac.redisClient, ac.name, snap, outputs, false)
+	change := ac.history.change(historyOpAdd, added, nil)
+	newVersion, err := commitV2Write(ctx, ac.redisClient, ac.name, snap, outputs, false, change)
 	if err != nil {
 		return 0, err
 	}
 
-	// planAdd folded the keyword into snap, so the snapshot is the authoritative
+	// planAddLive folded the keyword into snap, so the snapshot is the authoritative
 	// post-write state; see applyCommittedWrite for why the local set is not.
 	ac.applyCommittedWrite(snap, newVersion)
 	return 1, nil
@@ -74,7 +76,8 @@ func (ac *redisBackedAC) tryRemove(ctx context.Context, keyword string) (int, er
 		return 0, nil
 	}
 
-	newVersion, err := commitV2Write(ctx, ac.redisClient, ac.name, snap, outputs, true)
+	change := ac.history.change(historyOpRemove, nil, []string{keyword})
+	newVersion, err := commitV2Write(ctx, ac.redisClient, ac.name, snap, outputs, true, change)
 	if err != nil {
 		return 0, err
 	}
@@ -126,13 +129,18 @@ func (ac *redisBackedAC) findIndex(ctx context.Context, text string) (map[string
 
 // flush removes all keywords from the automaton.
 func (ac *redisBackedAC) flush(ctx context.Context) error {
-	if err := flushV2Keys(ctx, ac.storage, ac.name); err != nil {
+	version, err := flushV2Keys(ctx, ac.redisClient, ac.name, ac.history)
+	if err != nil {
 		return err
 	}
 
 	ac.mu.Lock()
 	ac.keywordSet = make(map[string]struct{})
+	ac.sortedKeywords = nil
+	ac.expiries = nil
+	ac.tags = nil
 	ac.rebuildEngine()
+	ac.localVersion = version
 	ac.stale = false
 	ac.mu.Unlock()
 
@@ -144,6 +152,7 @@ func (ac *redisBackedAC) flush(ctx context.Context) error {
 func (ac *redisBackedAC) info(_ context.Context) (*AhoCorasickInfo, error) {
 	ac.mu.RLock()
 	mi := ac.engine.Info()
+	tags := ac.tags.counts(ac.expiries, time.Now())
 	ac.mu.RUnlock()
 	return &AhoCorasickInfo{
 		Keywords:    mi.Keywords,
@@ -151,6 +160,7 @@ func (ac *redisBackedAC) info(_ context.Context) (*AhoCorasickInfo, error) {
 		Preset:      presetFromEngine(mi.Preset),
 		MemoryBytes: mi.MemoryBytes,
 		TrieDepth:   mi.TrieDepth,
+		Tags:        tags,
 	}, nil
 }
 
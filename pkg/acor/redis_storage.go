@@ -4,13 +4,19 @@
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 type redisStorage struct {
 	client redis.UniversalClient
+	// conn, when set, is the shared Connection client came from: Subscribe goes
+	// through its Pub/Sub connection, and Close leaves client open.
+	conn *Connection
 }
 
 // newRedisStorage returns a kvStorage backed by the given Redis client.
@@ -22,10 +28,63 @@ func (s *redisStorage) HGetAll(ctx context.Context, key string) (map[string]stri
 	return s.client.HGetAll(ctx, key).Result()
 }
 
+func (s *redisStorage) HGet(ctx context.Context, key, field string) (string, error) {
+	return s.client.HGet(ctx, key, field).Result()
+}
+
+func (s *redisStorage) HMGet(ctx context.Context, key string, fields ...string) (map[string]string, error) {
+	values, err := s.client.HMGet(ctx, key, fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]string, len(values))
+	for i, v := range values {
+		if str, ok := v.(string); ok {
+			found[fields[i]] = str
+		}
+	}
+	return found, nil
+}
+
+func (s *redisStorage) HScan(ctx context.Context, key string, cursor uint64, count int64) ([]string, uint64, error) {
+	return s.client.HScan(ctx, key, cursor, "", count).Result()
+}
+
 func (s *redisStorage) HSet(ctx context.Context, key string, values ...interface{}) error {
 	return s.client.HSet(ctx, key, values...).Err()
 }
 
+// hsetUnlessExistsScript is HSetUnlessExists: KEYS[1] is the hash and the rest
+// the guards; ARGV the field and value. It returns 0 without writing when a
+// guard exists.
+var hsetUnlessExistsScript = redis.NewScript(`
+	for i = 2, #KEYS do
+		if redis.call('EXISTS', KEYS[i]) == 1 then
+			return 0
+		end
+	end
+	redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+	return 1
+`)
+
+func (s *redisStorage) HSetUnlessExists(ctx context.Context, key, field, value string, guards ...string) (bool, error) {
+	keys := append([]string{key}, guards...)
+	n, err := hsetUnlessExistsScript.Run(ctx, s.client, keys, field, value).Int64()
+	return n == 1, err
+}
+
+func (s *redisStorage) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (s *redisStorage) PExpire(ctx context.Context, key string, ttl time.Duration) error {
+	return s.client.PExpire(ctx, key, ttl).Err()
+}
+
+func (s *redisStorage) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	return s.client.HDel(ctx, key, fields...).Result()
+}
+
 func (s *redisStorage) SAdd(ctx context.Context, key string, members ...interface{}) error {
 	return s.client.SAdd(ctx, key, members...).Err()
 }
@@ -82,6 +141,28 @@ func (s *redisStorage) Exists(ctx context.Context, keys ...string) (int64, error
 	return s.client.Exists(ctx, keys...).Result()
 }
 
+func (s *redisStorage) XRevRange(ctx context.Context, key string, count int64) ([]streamEntry, error) {
+	var msgs []redis.XMessage
+	var err error
+	if count > 0 {
+		msgs, err = s.client.XRevRangeN(ctx, key, "+", "-", count).Result()
+	} else {
+		msgs, err = s.client.XRevRange(ctx, key, "+", "-").Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]streamEntry, len(msgs))
+	for i, msg := range msgs {
+		values := make(map[string]string, len(msg.Values))
+		for field, v := range msg.Values {
+			values[field] = fmt.Sprint(v)
+		}
+		entries[i] = streamEntry{ID: msg.ID, Values: values}
+	}
+	return entries, nil
+}
+
 func (s *redisStorage) TxPipelined(ctx context.Context, fn func(pipeliner) error) error {
 	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
 		return fn(&redisPipeliner{pipe: pipe})
@@ -98,10 +179,16 @@ func (s *redisStorage) Publish(ctx context.Context, channel string, message inte
 }
 
 func (s *redisStorage) Subscribe(ctx context.Context, channels ...string) subscription {
+	if s.conn != nil {
+		return s.conn.subscribe(ctx, channels)
+	}
 	return &redisSubscription{pubsub: s.client.Subscribe(ctx, channels...), done: make(chan struct{})}
 }
 
 func (s *redisStorage) Close() error {
+	if s.conn != nil {
+		return nil
+	}
 	return s.client.Close()
 }
 
@@ -113,6 +200,14 @@ func (r *redisStringMapResult) Val() map[string]string {
 	return r.cmd.Val()
 }
 
+type redisStringResult struct {
+	cmd *redis.StringCmd
+}
+
+func (r *redisStringResult) Val() string {
+	return r.cmd.Val()
+}
+
 type redisSubscription struct {
 	pubsub    *redis.PubSub
 	ch        chan pubSubMessage
@@ -193,7 +288,16 @@ func (p *redisPipeliner) HGetAll(ctx context.Context, key string) stringMapResul
 	return &redisStringMapResult{cmd: p.pipe.HGetAll(ctx, key)}
 }
 
+func (p *redisPipeliner) HGet(ctx context.Context, key, field string) stringResult {
+	return &redisStringResult{cmd: p.pipe.HGet(ctx, key, field)}
+}
+
+// Exec reports redis.Nil, an unset HGet field, as success: the pipeliner
+// contract reads it as an empty result rather than a failure.
 func (p *redisPipeliner) Exec(ctx context.Context) error {
 	_, err := p.pipe.Exec(ctx)
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
 	return err
 }
@@ -0,0 +1,314 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+)
+
+// testPKI is a private CA with a server certificate for 127.0.0.1 and a
+// client certificate, all written as PEM files into a test's temp dir.
+type testPKI struct {
+	pool              *x509.CertPool
+	server            tls.Certificate
+	caFile            string
+	certFile, keyFile string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "acor test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &testPKI{pool: x509.NewCertPool(), caFile: filepath.Join(dir, "ca.pem")}
+	p.pool.AddCert(ca)
+	writePEM(t, p.caFile, "CERTIFICATE", caDER)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage, name string) (certFile, keyFile string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certFile, keyFile = filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+		writePEM(t, certFile, "CERTIFICATE", der)
+		writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+		return certFile, keyFile
+	}
+	serverCert, serverKey := issue(2, x509.ExtKeyUsageServerAuth, "server")
+	p.certFile, p.keyFile = issue(3, x509.ExtKeyUsageClientAuth, "client")
+	if p.server, err = tls.LoadX509KeyPair(serverCert, serverKey); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func writePEM(t *testing.T, path, kind string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runTLSRedis starts a miniredis that requires TLS, a client certificate
+// signed by p's CA, and the ACL user acor.
+func runTLSRedis(t *testing.T, p *testPKI) *miniredis.Miniredis {
+	t.Helper()
+	mr, err := miniredis.RunTLS(&tls.Config{
+		Certificates: []tls.Certificate{p.server},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    p.pool,
+		MinVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mr.Close)
+	mr.RequireUserAuth("acor", "secret")
+	return mr
+}
+
+func TestCreateOverTLSWithACLUser(t *testing.T) {
+	p := newTestPKI(t)
+	mr := runTLSRedis(t, p)
+
+	ac, err := Create(&AhoCorasickArgs{
+		Addr:     mr.Addr(),
+		Username: "acor",
+		Password: "secret",
+		TLS:      &TLSOptions{CAFile: p.caFile, CertFile: p.certFile, KeyFile: p.keyFile},
+		Name:     "tls",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ac.Close() }()
+	if _, err := ac.Add("she"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ac.Find("ushers")
+	if err != nil || len(got) != 1 || got[0] != "she" {
+		t.Fatalf("Find = %v, %v; want [she]", got, err)
+	}
+}
+
+func TestCreateOverTLSRejectsUntrustedServer(t *testing.T) {
+	p := newTestPKI(t)
+	mr := runTLSRedis(t, p)
+	other := newTestPKI(t)
+
+	tests := []struct {
+		name string
+		args *AhoCorasickArgs
+	}{
+		{"system roots", &AhoCorasickArgs{TLS: &TLSOptions{CertFile: p.certFile, KeyFile: p.keyFile}}},
+		{"another CA", &AhoCorasickArgs{TLS: &TLSOptions{CAFile: other.caFile, CertFile: p.certFile, KeyFile: p.keyFile}}},
+		{"no client certificate", &AhoCorasickArgs{TLS: &TLSOptions{CAFile: p.caFile}}},
+		{"no TLS", &AhoCorasickArgs{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args.Addr, tt.args.Username, tt.args.Password, tt.args.Name = mr.Addr(), "acor", "secret", "tls"
+			tt.args.DialTimeout, tt.args.ReadTimeout, tt.args.MaxRetries = time.Second, time.Second, -1
+			if ac, err := Create(tt.args); err == nil {
+				_ = ac.Close()
+				t.Fatal("Create succeeded; want a connection error")
+			}
+		})
+	}
+}
+
+func TestCredentialsProviderReplacesPassword(t *testing.T) {
+	mr := createTestRedisServer(t)
+	defer mr.Close()
+	mr.RequireUserAuth("acor", "rotated")
+
+	var calls atomic.Int32
+	errRotation := errors.New("vault unavailable")
+	client, err := newRedisClient(&AhoCorasickArgs{
+		Addr:     mr.Addr(),
+		Username: "acor",
+		Password: "stale",
+		CredentialsProvider: func(context.Context) (string, string, error) {
+			calls.Add(1)
+			return "acor", "rotated", nil
+		},
+		MaxRetries: -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("Ping with provided credentials: %v", err)
+	}
+	if calls.Load() == 0 {
+		t.Fatal("CredentialsProvider was not called")
+	}
+
+	second, err := newRedisClient(&AhoCorasickArgs{
+		Addr: mr.Addr(),
+		CredentialsProvider: func(context.Context) (string, string, error) {
+			return "", "", errRotation
+		},
+		MaxRetries: -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = second.Close() }()
+	if err := second.Ping(context.Background()).Err(); !errors.Is(err, errRotation) {
+		t.Fatalf("Ping with a failing provider = %v, want %v", err, errRotation)
+	}
+}
+
+func TestRedisAuthAndTLSReachEveryTopology(t *testing.T) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	provider := func(context.Context) (string, string, error) { return "acor", "secret", nil }
+	args := func() *AhoCorasickArgs {
+		return &AhoCorasickArgs{
+			Username:            "acor",
+			Password:            "secret",
+			CredentialsProvider: provider,
+			SentinelUsername:    "watcher",
+			SentinelPassword:    "sentinel-secret",
+			TLSConfig:           tlsConfig,
+		}
+	}
+	type conn struct {
+		username, password string
+		provided           bool
+		tls                *tls.Config
+	}
+	check := func(t *testing.T, got conn) {
+		t.Helper()
+		if got.username != "acor" || got.password != "secret" || !got.provided || got.tls != tlsConfig {
+			t.Fatalf("connection options = %+v; want acor/secret, a provider, and the TLSConfig", got)
+		}
+	}
+
+	t.Run("standalone", func(t *testing.T) {
+		a := args()
+		a.Addr = "127.0.0.1:6379"
+		client, err := newRedisClient(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = client.Close() }()
+		o := client.(*redis.Client).Options()
+		check(t, conn{o.Username, o.Password, o.CredentialsProviderContext != nil, o.TLSConfig})
+	})
+	t.Run("ring", func(t *testing.T) {
+		a := args()
+		a.RingAddrs = map[string]string{"shard-1": "127.0.0.1:7000"}
+		client, err := newRedisClient(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = client.Close() }()
+		o := client.(*redis.Ring).Options()
+		check(t, conn{o.Username, o.Password, o.CredentialsProviderContext != nil, o.TLSConfig})
+	})
+	// The cluster client pings at creation and the failover client hides its
+	// sentinel options, so those two are checked at the options they are
+	// built from.
+	t.Run("cluster", func(t *testing.T) {
+		o := universalOptions(args(), []string{"127.0.0.1:7000"}, tlsConfig).Cluster()
+		check(t, conn{o.Username, o.Password, o.CredentialsProviderContext != nil, o.TLSConfig})
+	})
+	t.Run("sentinel", func(t *testing.T) {
+		a := args()
+		a.MasterName = "mymaster"
+		o := universalOptions(a, []string{"127.0.0.1:26379"}, tlsConfig).Failover()
+		check(t, conn{o.Username, o.Password, o.CredentialsProviderContext != nil, o.TLSConfig})
+		if o.SentinelUsername != "watcher" || o.SentinelPassword != "sentinel-secret" {
+			t.Fatalf("sentinel credentials = %q/%q, want watcher/sentinel-secret", o.SentinelUsername, o.SentinelPassword)
+		}
+	})
+}
+
+func TestNewRedisClientRejectsInvalidTLS(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		args *AhoCorasickArgs
+		err  error
+	}{
+		{"TLSConfig and TLS", &AhoCorasickArgs{TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12}, TLS: &TLSOptions{}}, ErrRedisTLSConflict},
+		{"cert without key", &AhoCorasickArgs{TLS: &TLSOptions{CertFile: "client.pem"}}, ErrRedisTLSKeyPair},
+		{"key without cert", &AhoCorasickArgs{TLS: &TLSOptions{KeyFile: "client-key.pem"}}, ErrRedisTLSKeyPair},
+		{"missing CA file", &AhoCorasickArgs{TLS: &TLSOptions{CAFile: filepath.Join(dir, "missing.pem")}}, os.ErrNotExist},
+		{"CA file without certificates", &AhoCorasickArgs{TLS: &TLSOptions{CAFile: notPEM}}, nil},
+		{"missing key pair", &AhoCorasickArgs{TLS: &TLSOptions{CertFile: notPEM, KeyFile: notPEM}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := newRedisClient(tt.args)
+			if err == nil {
+				_ = client.Close()
+				t.Fatal("newRedisClient succeeded; want an error")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
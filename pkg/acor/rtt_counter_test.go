@@ -6,6 +6,7 @@
 	"context"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // Round-trip counting for the performance claims published in README.md and
@@ -34,10 +35,10 @@ func (c *rttCounter) reset()     { c.n.Store(0) }
 // counted. Call it after Create and before the operation under test.
 //
 // ac.storage is not sufficient on its own: newV2Ops/newV1Ops copy the reference
-// at construction (acor.go:561), and preset mode leaves ac.storage nil entirely,
-// holding storage on redisBackedAC instead. All live handles must be wrapped or
-// the operation under test is measured through an unwrapped path and silently
-// reports zero.
+// at construction (acor.go:566), and preset mode only shares redisBackedAC's
+// handle, which its read and write paths reach through the strategy. All live
+// handles must be wrapped or the operation under test is measured through an
+// unwrapped path and silently reports zero.
 func countRTT(tb testing.TB, ac *AhoCorasick) *rttCounter {
 	tb.Helper()
 	c := &rttCounter{}
@@ -87,11 +88,46 @@ func (s *countingStorage) HGetAll(ctx context.Context, key string) (map[string]s
 	return s.inner.HGetAll(ctx, key)
 }
 
+func (s *countingStorage) HGet(ctx context.Context, key, field string) (string, error) {
+	s.c.add()
+	return s.inner.HGet(ctx, key, field)
+}
+
+func (s *countingStorage) HMGet(ctx context.Context, key string, fields ...string) (map[string]string, error) {
+	s.c.add()
+	return s.inner.HMGet(ctx, key, fields...)
+}
+
+func (s *countingStorage) HScan(ctx context.Context, key string, cursor uint64, count int64) ([]string, uint64, error) {
+	s.c.add()
+	return s.inner.HScan(ctx, key, cursor, count)
+}
+
+func (s *countingStorage) PExpire(ctx context.Context, key string, ttl time.Duration) error {
+	s.c.add()
+	return s.inner.PExpire(ctx, key, ttl)
+}
+
+func (s *countingStorage) HSetUnlessExists(ctx context.Context, key, field, value string, guards ...string) (bool, error) {
+	s.c.add()
+	return s.inner.HSetUnlessExists(ctx, key, field, value, guards...)
+}
+
+func (s *countingStorage) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	s.c.add()
+	return s.inner.SetNX(ctx, key, value, ttl)
+}
+
 func (s *countingStorage) HSet(ctx context.Context, key string, values ...interface{}) error {
 	s.c.add()
 	return s.inner.HSet(ctx, key, values...)
 }
 
+func (s *countingStorage) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	s.c.add()
+	return s.inner.HDel(ctx, key, fields...)
+}
+
 func (s *countingStorage) SAdd(ctx context.Context, key string, members ...interface{}) error {
 	s.c.add()
 	return s.inner.SAdd(ctx, key, members...)
@@ -157,6 +193,11 @@ func (s *countingStorage) Exists(ctx context.Context, keys ...string) (int64, er
 	return s.inner.Exists(ctx, keys...)
 }
 
+func (s *countingStorage) XRevRange(ctx context.Context, key string, count int64) ([]streamEntry, error) {
+	s.c.add()
+	return s.inner.XRevRange(ctx, key, count)
+}
+
 // TxPipelined is one round trip for the whole transaction, however many
 // commands the callback queues.
 func (s *countingStorage) TxPipelined(ctx context.Context, fn func(pipeliner) error) error {
@@ -202,6 +243,10 @@ func (p *countingPipeliner) HGetAll(ctx context.Context, key string) stringMapRe
 	return p.inner.HGetAll(ctx, key)
 }
 
+func (p *countingPipeliner) HGet(ctx context.Context, key, field string) stringResult {
+	return p.inner.HGet(ctx, key, field)
+}
+
 func (p *countingPipeliner) ZAdd(ctx context.Context, key string, members ...*zMember) error {
 	return p.inner.ZAdd(ctx, key, members...)
 }
@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindMatches_MaxMatches(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "a", "aa")
+	got, err := ac.FindMatches("aaaa", &MatchOptions{Limits: &ScanLimits{MaxMatches: 3}})
+	var limitErr *ScanLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrMaxMatches) {
+		t.Fatalf("err = %v, want *ScanLimitError wrapping ErrMaxMatches", err)
+	}
+	want := []Match{{Keyword: "a", Start: 0, End: 1}, {Keyword: "aa", Start: 0, End: 2}, {Keyword: "a", Start: 1, End: 2}}
+	if !reflect.DeepEqual(limitErr.Matches, want) {
+		t.Errorf("partial Matches = %v, want %v", limitErr.Matches, want)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("returned matches = %v, want the partial result", got)
+	}
+
+	// A limit the text does not exceed is not an error.
+	got, err = ac.FindMatches("aa", &MatchOptions{Limits: &ScanLimits{MaxMatches: 3}})
+	if err != nil || len(got) != 3 {
+		t.Errorf("FindMatches at exactly the limit = %v, %v; want 3 matches, nil", got, err)
+	}
+}
+
+func TestFindMatches_MaxTextRunes(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "é", "ab")
+	_, err := ac.FindMatches("éab éab", &MatchOptions{
+		Kind:   MatchKindLeftmostLongest,
+		Limits: &ScanLimits{MaxTextRunes: 4},
+	})
+	var limitErr *ScanLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrMaxTextRunes) {
+		t.Fatalf("err = %v, want *ScanLimitError wrapping ErrMaxTextRunes", err)
+	}
+	if limitErr.Runes != 4 {
+		t.Errorf("Runes = %d, want 4", limitErr.Runes)
+	}
+	want := []Match{{Keyword: "é", Start: 0, End: 1}, {Keyword: "ab", Start: 1, End: 3}}
+	if !reflect.DeepEqual(limitErr.Matches, want) {
+		t.Errorf("partial Matches = %v, want %v", limitErr.Matches, want)
+	}
+
+	if _, err := ac.FindMatches("éab", &MatchOptions{Limits: &ScanLimits{MaxTextRunes: 3}}); err != nil {
+		t.Errorf("text at exactly the limit: err = %v", err)
+	}
+}
+
+// TestFindMatches_LimitsAgreeWithUnbounded pins the bounded scan, which decodes
+// runes itself and goes through the stream path, against the unbounded one.
+func TestFindMatches_LimitsAgreeWithUnbounded(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "he", "she", "hers", "한국")
+	for _, text := range []string{"ushers", "한국 SHE \xff hers", ""} {
+		want, err := ac.FindMatches(text, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ac.FindMatches(text, &MatchOptions{Limits: &ScanLimits{MaxMatches: 100, CheckEvery: 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("bounded FindMatches(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+// cancelAfterCtx reports itself canceled once Err has been polled n times, so a
+// test can cancel in the middle of a scan deterministically.
+type cancelAfterCtx struct {
+	context.Context
+	n int
+}
+
+func (c *cancelAfterCtx) Err() error {
+	if c.n--; c.n < 0 {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+func TestFindMatches_CanceledMidScan(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "x")
+	// Warm the engine so the only Err polls left are the boundary check and the scan's.
+	if _, err := ac.Find("x"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := &cancelAfterCtx{Context: context.Background(), n: 3}
+	_, err := ac.FindMatchesContext(ctx, strings.Repeat("x", 100), &MatchOptions{Limits: &ScanLimits{CheckEvery: 10}})
+	var limitErr *ScanLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want *ScanLimitError wrapping context.DeadlineExceeded", err)
+	}
+	if limitErr.Runes == 0 || limitErr.Runes >= 100 || len(limitErr.Matches) != limitErr.Runes {
+		t.Errorf("stopped after %d runes with %d matches; want a partial scan with one match per rune",
+			limitErr.Runes, len(limitErr.Matches))
+	}
+}
+
+func TestFindMatches_LimitedWholeWordSeesPastTheCut(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "cat")
+	// The scan stops after "CAT CAT", but the second match runs into "alog" and
+	// is not a whole word.
+	_, err := ac.FindMatches("CAT CATALOG", &MatchOptions{WholeWord: true, Limits: &ScanLimits{MaxTextRunes: 7}})
+	var limitErr *ScanLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrMaxTextRunes) {
+		t.Fatalf("err = %v, want *ScanLimitError wrapping ErrMaxTextRunes", err)
+	}
+	if want := []Match{{Keyword: "cat", Start: 0, End: 3}}; !reflect.DeepEqual(limitErr.Matches, want) {
+		t.Errorf("partial Matches = %v, want %v", limitErr.Matches, want)
+	}
+}
+
+func TestStreamsWithLimits(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "he", "she")
+	var got []Match
+	err := ac.FindStreamWithLimits(strings.NewReader("SHE he she"), &ScanLimits{MaxTextRunes: 6},
+		func(m Match) bool {
+			got = append(got, m)
+			return true
+		})
+	var limitErr *ScanLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrMaxTextRunes) || limitErr.Runes != 6 {
+		t.Fatalf("FindStreamWithLimits err = %v, want ErrMaxTextRunes after 6 runes", err)
+	}
+	want := []Match{{Keyword: "she", Start: 0, End: 3}, {Keyword: "he", Start: 1, End: 3}, {Keyword: "he", Start: 4, End: 6}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("matches before the limit = %v, want %v", got, want)
+	}
+
+	counts, err := ac.CountStreamWithLimits(strings.NewReader("she she she"), &ScanLimits{MaxMatches: 3})
+	if !errors.Is(err, ErrMaxMatches) {
+		t.Fatalf("CountStreamWithLimits err = %v, want ErrMaxMatches", err)
+	}
+	if !reflect.DeepEqual(counts, map[string]int{"she": 2, "he": 1}) {
+		t.Errorf("counts before the limit = %v", counts)
+	}
+	counts, err = ac.CountStreamWithLimits(strings.NewReader("she"), &ScanLimits{MaxMatches: 3})
+	if err != nil || !reflect.DeepEqual(counts, map[string]int{"she": 1, "he": 1}) {
+		t.Errorf("CountStreamWithLimits under the limit = %v, %v", counts, err)
+	}
+}
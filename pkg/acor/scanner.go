@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"unicode/utf8"
+
+	matchengine "github.com/skyoo2003/acor/internal/engine"
+)
+
+// Scanner is a push-style counterpart to FindStream for input that arrives
+// through callbacks — TCP reassembly, consumer batches, HTTP body chunks — where
+// FindStream would need a goroutine and a pipe to turn the pushes back into an
+// io.Reader. Write each chunk as it arrives; matches are reported to the
+// Scanner's callback during the Write that completes them, with rune offsets
+// counted from the start of the stream.
+//
+// A Scanner carries the automaton state across writes, so a keyword split across
+// two chunks is found, and holds back the bytes of a rune split across them until
+// the rest arrives. Call Flush at the end of the stream to scan any such bytes
+// that never got completed, and Reset to reuse the Scanner for the next stream.
+//
+// A Scanner scans against the keyword set as it was when NewScanner was called:
+// keywords added or removed afterwards are not seen until a new Scanner is made.
+// Holding that snapshot costs nothing per Scanner — the automaton is shared — so a
+// Scanner is a few dozen bytes plus its callback, and many thousands can be open at
+// once. A Scanner is not safe for concurrent use; give each stream its own.
+//
+// As with FindStream, whole-word and leftmost-longest options are not applied.
+type Scanner struct {
+	eng  *matchengine.Engine
+	pos  matchengine.Position
+	emit func(keyword string, start, end int) bool
+	// caseSensitive mirrors the collection's setting, for normalizeText.
+	caseSensitive bool
+	// pending holds the leading bytes of a rune the last Write ended inside.
+	pending  [utf8.UTFMax]byte
+	npending uint8
+}
+
+// NewScanner returns a Scanner over the collection's current keyword set that
+// reports every match, overlaps included, to onMatch in scan order.
+func (ac *AhoCorasick) NewScanner(onMatch func(Match)) (*Scanner, error) {
+	return ac.NewScannerContext(ac.ctx, onMatch)
+}
+
+// NewScannerContext is NewScanner with an explicit context for loading the
+// keyword set. The Scanner does not keep ctx: writes are in-memory scans.
+func (ac *AhoCorasick) NewScannerContext(ctx context.Context, onMatch func(Match)) (*Scanner, error) {
+	eng, err := ac.ops.loadEngine(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Scanner{
+		eng: eng,
+		pos: eng.Start(),
+		emit: func(keyword string, start, end int) bool {
+			onMatch(Match{Keyword: keyword, Start: start, End: end})
+			return true
+		},
+		caseSensitive: ac.caseSensitive,
+	}, nil
+}
+
+// Write scans p as the next chunk of the stream. It always consumes all of p and
+// never fails, so a Scanner can sit behind io.Copy or an io.MultiWriter.
+func (s *Scanner) Write(p []byte) (int, error) {
+	n := len(p)
+	if s.npending > 0 {
+		// Finish the rune the previous chunk ended inside, one byte at a time so
+		// that no byte of p is taken past its end.
+		for len(p) > 0 && !utf8.FullRune(s.pending[:s.npending]) {
+			s.pending[s.npending] = p[0]
+			s.npending++
+			p = p[1:]
+		}
+		if !utf8.FullRune(s.pending[:s.npending]) {
+			return n, nil
+		}
+		s.scan(s.pending[:s.npending])
+		s.npending = 0
+	}
+	tail := incompleteTail(p)
+	s.scan(p[:len(p)-tail])
+	s.npending = uint8(copy(s.pending[:], p[len(p)-tail:])) //nolint:gosec // G115: at most utf8.UTFMax-1 bytes.
+	return n, nil
+}
+
+// Flush scans bytes held back from the last Write as the start of a split rune.
+// At the end of the stream they will never be completed, so they are decoded as
+// invalid UTF-8, exactly as FindMatches would decode them in a string.
+func (s *Scanner) Flush() {
+	if s.npending > 0 {
+		s.scan(s.pending[:s.npending])
+		s.npending = 0
+	}
+}
+
+// Reset starts a new stream: the automaton returns to its initial state, offsets
+// restart at zero, and held-back bytes are dropped. The keyword snapshot is kept.
+func (s *Scanner) Reset() {
+	s.pos = s.eng.Start()
+	s.npending = 0
+}
+
+// Offset reports how many runes the Scanner has consumed since it was created or
+// last Reset. Bytes held back for a split rune are not counted until it completes.
+func (s *Scanner) Offset() int {
+	return s.pos.Runes()
+}
+
+func (s *Scanner) scan(p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	// normalizeText, not a per-rune fold: strings.ToLower is strings.Map over
+	// unicode.ToLower, so folding a chunk of whole runes agrees with folding the
+	// whole stream at once.
+	s.pos = s.eng.MatchFrom(s.pos, normalizeText(string(p), s.caseSensitive), s.emit)
+}
+
+// incompleteTail reports how many bytes at the end of p begin a rune that p cuts
+// short. Bytes that can never start a valid rune are not held back: they decode
+// as invalid runes now, as they would at any other position.
+func incompleteTail(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i > len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if utf8.FullRune(p[i:]) {
+				return 0
+			}
+			return len(p) - i
+		}
+	}
+	return 0
+}
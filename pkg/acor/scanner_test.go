@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// TestScanner_AcrossChunkBoundaries writes a text in two chunks at every byte
+// offset, so keywords and multibyte runes straddle the split, and checks the
+// Scanner reports what FindMatches does over the whole text.
+func TestScanner_AcrossChunkBoundaries(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "he", "hers", "한국어", "café")
+	text := "USHERS 한국어 CAFÉ \xff he"
+	want, err := ac.FindMatches(text, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Match
+	sc, err := ac.NewScanner(func(m Match) { got = append(got, m) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for split := 0; split <= len(text); split++ {
+		got = got[:0]
+		sc.Reset()
+		if _, err := sc.Write([]byte(text[:split])); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sc.Write([]byte(text[split:])); err != nil {
+			t.Fatal(err)
+		}
+		sc.Flush()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("split at byte %d: got %v, want %v", split, got, want)
+		}
+	}
+}
+
+func TestScanner_ByteAtATime(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "한국어")
+	var got []Match
+	sc, err := ac.NewScanner(func(m Match) { got = append(got, m) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(sc, io.LimitReader(oneByteReader{strings.NewReader("x 한국어")}, 100)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []Match{{Keyword: "한국어", Start: 2, End: 5}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if sc.Offset() != 5 {
+		t.Errorf("Offset = %d, want 5", sc.Offset())
+	}
+}
+
+// oneByteReader hands out a single byte per Read, so io.Copy writes byte by byte.
+type oneByteReader struct{ r io.Reader }
+
+func (o oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return o.r.Read(p[:1])
+}
+
+func TestScanner_FlushDecodesTruncatedRune(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "ab")
+	var got []Match
+	sc, err := ac.NewScanner(func(m Match) { got = append(got, m) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "\xed\x95" is the first two bytes of a three-byte rune; the stream ends there.
+	if _, err := sc.Write([]byte("ab\xed\x95")); err != nil {
+		t.Fatal(err)
+	}
+	if sc.Offset() != 2 {
+		t.Errorf("Offset before Flush = %d, want 2 (split rune held back)", sc.Offset())
+	}
+	sc.Flush()
+	// Two invalid bytes decode as two runes, as they would in a string.
+	if sc.Offset() != 4 || len(got) != 1 {
+		t.Errorf("after Flush: Offset = %d, matches = %v; want 4 and one match", sc.Offset(), got)
+	}
+}
+
+// TestScanner_SnapshotAndFootprint pins the two properties that let a server keep
+// one Scanner per open connection: it keeps scanning the keyword set it was made
+// with, and it stays small.
+func TestScanner_SnapshotAndFootprint(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "old")
+	var got []Match
+	sc, err := ac.NewScanner(func(m Match) { got = append(got, m) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	addAll(t, ac, "new")
+	if _, err := sc.Write([]byte("old new")); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Keyword != "old" {
+		t.Errorf("got %v, want only the keyword from the snapshot", got)
+	}
+
+	if size := unsafe.Sizeof(Scanner{}); size > 64 {
+		t.Errorf("Scanner is %d bytes, want at most 64", size)
+	}
+}
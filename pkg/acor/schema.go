@@ -27,11 +27,12 @@
 	// as JSON through Lua scripts, so the key count no longer grows with the
 	// dictionary. Recommended for every use case.
 	//
-	// That set is at most three keys — {name}:trie, {name}:outputs and
-	// {name}:nodes — but a collection rarely holds all three. A fresh one has only
-	// :trie, and adding keywords brings up :outputs. Only MigrateV1ToV2 writes
-	// :nodes; a collection built natively by Add never has it. Size a key-count
-	// budget on three and expect to see fewer.
+	// That set is at most four keys — {name}:trie, {name}:outputs, {name}:index
+	// and {name}:nodes — but a collection rarely holds all four. A fresh one has
+	// only :trie, and adding keywords brings up :outputs and the :index that Has,
+	// Count and Keywords read. Only MigrateV1ToV2 writes :nodes; a collection built
+	// natively by Add never has it. Size a key-count budget on four and expect to
+	// see fewer.
 	SchemaV2 = 2
 )
 
@@ -93,9 +94,10 @@ type MigrationResult struct {
 	// dictionary with many shared prefixes. Use it to convey the scale of the
 	// reduction, not to reconcile against DBSIZE.
 	KeysBefore int `json:"keys_before"`
-	// KeysAfter is the size of the V2 key set, and is always 3 — a constant, not a
-	// count of what the migration left behind. The migration writes :nodes and
-	// :outputs only when there is something to put in them, and with
+	// KeysAfter is the number of V2 keys a migration writes, and is always 3 — a
+	// constant, not a count of what the migration left behind. The :index key is
+	// not among them: the first write after the migration builds it. The migration
+	// writes :nodes and :outputs only when there is something to put in them, and with
 	// KeepOldKeys the V1 keys are still there too, so the collection can hold
 	// either fewer keys than this or many more. See SchemaV2.
 	KeysAfter int `json:"keys_after"`
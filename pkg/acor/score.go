@@ -0,0 +1,282 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"slices"
+)
+
+// KeywordWeight is the scoring metadata stored with a keyword.
+type KeywordWeight struct {
+	// Weight is what one occurrence of the keyword adds to a score. Negative weights
+	// are allowed, for terms that argue against a classification.
+	Weight float64
+	// Category groups keywords for ScoreResult.Categories, e.g. "spam" or
+	// "toxicity". Empty means the keyword counts toward the total only.
+	Category string
+}
+
+// weightJSON is the stored shape of a KeywordWeight. KeywordWeight carries no
+// JSON tags, so encoding it directly would freeze its Go field names into Redis.
+type weightJSON struct {
+	Weight   float64 `json:"weight"`
+	Category string  `json:"category,omitempty"`
+}
+
+// ScoreOptions tunes Score. A nil *ScoreOptions scores every occurrence at its
+// stored weight, with keywords lacking a weight contributing nothing and no
+// thresholds applied.
+type ScoreOptions struct {
+	// DefaultWeight applies to matched keywords that have no stored weight.
+	DefaultWeight float64
+	// Decay gives repeated occurrences of the same keyword diminishing returns: the
+	// n-th occurrence (counting from zero) adds Weight*Decay^n. A value outside
+	// (0, 1) disables decay, so every occurrence adds the full weight.
+	Decay float64
+	// MaxOccurrences caps how many occurrences of one keyword are scored. Counts
+	// in the breakdown still report every occurrence. Zero means no cap.
+	MaxOccurrences int
+	// Threshold sets ScoreResult.Flagged when the total reaches it. Zero disables
+	// the check.
+	Threshold float64
+	// CategoryThresholds lists a category in ScoreResult.FlaggedCategories when its
+	// score reaches the threshold given for it.
+	CategoryThresholds map[string]float64
+}
+
+// KeywordScore is one keyword's share of a ScoreResult.
+type KeywordScore struct {
+	// Count is how many times the keyword occurred, overlaps included.
+	Count int
+	// Weight is the weight applied: the stored one, or ScoreOptions.DefaultWeight.
+	Weight float64
+	// Category is the keyword's stored category, if any.
+	Category string
+	// Score is what the keyword contributed to the total after decay and capping.
+	Score float64
+}
+
+// ScoreResult is the outcome of Score.
+type ScoreResult struct {
+	// Total is the sum of every keyword's Score.
+	Total float64
+	// Keywords holds the breakdown for every keyword that matched, weighted or not.
+	Keywords map[string]KeywordScore
+	// Categories sums keyword scores per non-empty category.
+	Categories map[string]float64
+	// Flagged reports whether Total reached ScoreOptions.Threshold.
+	Flagged bool
+	// FlaggedCategories lists, sorted, the categories whose score reached their
+	// entry in ScoreOptions.CategoryThresholds.
+	FlaggedCategories []string
+}
+
+// SetWeights stores a weight, and optionally a category, for each keyword. A
+// keyword is normalized the way Add normalizes it, so a weight set as "Spam" on a
+// case-insensitive collection applies to matches of "spam".
+//
+// Weights live beside the keyword set rather than in it: a weight may be set
+// before its keyword is added and survives the keyword's removal, and setting one
+// does not touch the trie or invalidate any cached automaton. Flush drops them
+// together with the keywords.
+//
+// The whole map is written in one command, so it lands entirely or not at all.
+// An empty keyword fails with ErrEmptyKeyword and a NaN or infinite weight with
+// ErrInvalidWeight, before anything is written. V1 collections refuse with
+// ErrV1ReadOnly.
+func (ac *AhoCorasick) SetWeights(weights map[string]KeywordWeight) error {
+	return ac.SetWeightsContext(ac.ctx, weights)
+}
+
+// SetWeightsContext is SetWeights with an explicit context for cancellation.
+func (ac *AhoCorasick) SetWeightsContext(ctx context.Context, weights map[string]KeywordWeight) error {
+	if ac.schemaVersion == SchemaV1 {
+		return ErrV1ReadOnly
+	}
+	if len(weights) == 0 {
+		return nil
+	}
+	values := make([]interface{}, 0, 2*len(weights))
+	for keyword, w := range weights {
+		keyword = normalizeKeyword(keyword, ac.caseSensitive)
+		if keyword == "" {
+			return ErrEmptyKeyword
+		}
+		if math.IsNaN(w.Weight) || math.IsInf(w.Weight, 0) {
+			return &OperationError{Op: "setWeights", Keyword: keyword, Schema: SchemaV2, Err: ErrInvalidWeight}
+		}
+		data, err := json.Marshal(weightJSON(w))
+		if err != nil {
+			return newOperationError("marshal", SchemaV2, err)
+		}
+		values = append(values, keyword, string(data))
+	}
+	key := weightsKey(ac.collection())
+	if err := ac.storage.HSet(ctx, key, values...); err != nil {
+		return newRedisError("HSET", key, err)
+	}
+	return nil
+}
+
+// DeleteWeights removes the stored weights of keywords and returns how many
+// existed. The keywords themselves stay in the collection and score at
+// ScoreOptions.DefaultWeight from then on.
+func (ac *AhoCorasick) DeleteWeights(keywords ...string) (int, error) {
+	return ac.DeleteWeightsContext(ac.ctx, keywords...)
+}
+
+// DeleteWeightsContext is DeleteWeights with an explicit context for cancellation.
+func (ac *AhoCorasick) DeleteWeightsContext(ctx context.Context, keywords ...string) (int, error) {
+	if ac.schemaVersion == SchemaV1 {
+		return 0, ErrV1ReadOnly
+	}
+	fields := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		if keyword = normalizeKeyword(keyword, ac.caseSensitive); keyword != "" {
+			fields = append(fields, keyword)
+		}
+	}
+	if len(fields) == 0 {
+		return 0, nil
+	}
+	key := weightsKey(ac.collection())
+	n, err := ac.storage.HDel(ctx, key, fields...)
+	if err != nil {
+		return 0, newRedisError("HDEL", key, err)
+	}
+	return int(n), nil
+}
+
+// Weights returns every stored weight, keyed by normalized keyword.
+func (ac *AhoCorasick) Weights() (map[string]KeywordWeight, error) {
+	return ac.WeightsContext(ac.ctx)
+}
+
+// WeightsContext is Weights with an explicit context for cancellation.
+func (ac *AhoCorasick) WeightsContext(ctx context.Context) (map[string]KeywordWeight, error) {
+	key := weightsKey(ac.collection())
+	raw, err := ac.storage.HGetAll(ctx, key)
+	if err != nil {
+		return nil, newRedisError("HGETALL", key, err)
+	}
+	return ac.decodeWeights(raw)
+}
+
+// weightsOf returns the stored weights of keywords, reading only their fields
+// of the weights hash.
+func (ac *AhoCorasick) weightsOf(ctx context.Context, keywords []string) (map[string]KeywordWeight, error) {
+	if len(keywords) == 0 {
+		return map[string]KeywordWeight{}, nil
+	}
+	key := weightsKey(ac.collection())
+	raw, err := ac.storage.HMGet(ctx, key, keywords...)
+	if err != nil {
+		return nil, newRedisError("HMGET", key, err)
+	}
+	return ac.decodeWeights(raw)
+}
+
+func (ac *AhoCorasick) decodeWeights(raw map[string]string) (map[string]KeywordWeight, error) {
+	weights := make(map[string]KeywordWeight, len(raw))
+	for keyword, data := range raw {
+		var w weightJSON
+		if err := json.Unmarshal([]byte(data), &w); err != nil {
+			return nil, newOperationError("unmarshal", ac.schemaVersion, err)
+		}
+		weights[keyword] = KeywordWeight(w)
+	}
+	return weights, nil
+}
+
+// Score classifies text by summing the weights of the keywords it contains, with
+// a per-keyword and a per-category breakdown. Occurrences are counted in a single
+// scan of the automaton, overlaps included — "hers" counts both "he" and "hers" —
+// so nested keywords each contribute. The weights cost one more read, of the
+// matched keywords' entries only, so a large weights hash does not make every
+// Score slower.
+//
+// Use opts to give repeats diminishing returns and to apply thresholds, so the
+// classification decision is made here rather than re-derived by every caller.
+func (ac *AhoCorasick) Score(text string, opts *ScoreOptions) (*ScoreResult, error) {
+	return ac.ScoreContext(ac.ctx, text, opts)
+}
+
+// ScoreContext is Score with an explicit context for cancellation.
+func (ac *AhoCorasick) ScoreContext(ctx context.Context, text string, opts *ScoreOptions) (*ScoreResult, error) {
+	if opts == nil {
+		opts = &ScoreOptions{}
+	}
+	result := &ScoreResult{
+		Keywords:          map[string]KeywordScore{},
+		Categories:        map[string]float64{},
+		FlaggedCategories: []string{},
+	}
+	if text == "" {
+		return result, nil
+	}
+	norm := normalizeText(text, ac.caseSensitive)
+
+	eng, err := ac.ops.loadEngine(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// See FindMatchesContext: honor an already-canceled ctx at the match boundary.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	counts := eng.Count(norm).Map()
+	matched := make([]string, 0, len(counts))
+	for keyword := range counts {
+		matched = append(matched, keyword)
+	}
+	weights, err := ac.weightsOf(ctx, matched)
+	if err != nil {
+		return nil, err
+	}
+
+	for keyword, count := range counts {
+		w, ok := weights[keyword]
+		if !ok {
+			w.Weight = opts.DefaultWeight
+		}
+		ks := KeywordScore{
+			Count:    count,
+			Weight:   w.Weight,
+			Category: w.Category,
+			Score:    scoreOccurrences(w.Weight, count, opts),
+		}
+		result.Keywords[keyword] = ks
+		result.Total += ks.Score
+		if ks.Category != "" {
+			result.Categories[ks.Category] += ks.Score
+		}
+	}
+
+	result.Flagged = opts.Threshold != 0 && result.Total >= opts.Threshold
+	for category, threshold := range opts.CategoryThresholds {
+		if result.Categories[category] >= threshold {
+			result.FlaggedCategories = append(result.FlaggedCategories, category)
+		}
+	}
+	slices.Sort(result.FlaggedCategories)
+	return result, nil
+}
+
+// scoreOccurrences is what count occurrences of a keyword weighing weight add up
+// to under opts' cap and decay. With decay d the sum weight*(1+d+…+d^(n-1)) is a
+// geometric series, so it is computed in closed form rather than per occurrence.
+func scoreOccurrences(weight float64, count int, opts *ScoreOptions) float64 {
+	n := count
+	if opts.MaxOccurrences > 0 && n > opts.MaxOccurrences {
+		n = opts.MaxOccurrences
+	}
+	d := opts.Decay
+	if d <= 0 || d >= 1 {
+		return weight * float64(n)
+	}
+	return weight * (1 - math.Pow(d, float64(n))) / (1 - d)
+}
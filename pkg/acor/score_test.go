@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestScore_WeightsAndCategories(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "viagra", "casino", "idiot", "hello")
+	if err := ac.SetWeights(map[string]KeywordWeight{
+		"Viagra": {Weight: 3, Category: "spam"},
+		"casino": {Weight: 2, Category: "spam"},
+		"idiot":  {Weight: 5, Category: "toxicity"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ac.Score("VIAGRA casino casino, you idiot. hello", &ScoreOptions{
+		Threshold:          10,
+		CategoryThresholds: map[string]float64{"spam": 7, "toxicity": 6},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Total != 12 || !got.Flagged {
+		t.Errorf("Total = %v, Flagged = %v; want 12, true", got.Total, got.Flagged)
+	}
+	wantCategories := map[string]float64{"spam": 7, "toxicity": 5}
+	if !reflect.DeepEqual(got.Categories, wantCategories) {
+		t.Errorf("Categories = %v, want %v", got.Categories, wantCategories)
+	}
+	if !reflect.DeepEqual(got.FlaggedCategories, []string{"spam"}) {
+		t.Errorf("FlaggedCategories = %v, want [spam]", got.FlaggedCategories)
+	}
+	wantCasino := KeywordScore{Count: 2, Weight: 2, Category: "spam", Score: 4}
+	if got.Keywords["casino"] != wantCasino {
+		t.Errorf("casino = %+v, want %+v", got.Keywords["casino"], wantCasino)
+	}
+	// Unweighted matches are reported with zero weight rather than dropped.
+	if hello := got.Keywords["hello"]; hello.Count != 1 || hello.Score != 0 {
+		t.Errorf("hello = %+v, want one unweighted occurrence", hello)
+	}
+}
+
+func TestScore_DiminishingReturns(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "buy")
+	if err := ac.SetWeights(map[string]KeywordWeight{"buy": {Weight: 4}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts *ScoreOptions
+		want float64
+	}{
+		{"no decay", nil, 16},
+		{"halving", &ScoreOptions{Decay: 0.5}, 4 + 2 + 1 + 0.5},
+		{"capped", &ScoreOptions{MaxOccurrences: 2}, 8},
+		{"capped and halving", &ScoreOptions{Decay: 0.5, MaxOccurrences: 2}, 6},
+	}
+	for _, tt := range tests {
+		got, err := ac.Score("buy buy buy buy", tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if math.Abs(got.Total-tt.want) > 1e-9 {
+			t.Errorf("%s: Total = %v, want %v", tt.name, got.Total, tt.want)
+		}
+		if got.Keywords["buy"].Count != 4 {
+			t.Errorf("%s: Count = %d, want every occurrence counted", tt.name, got.Keywords["buy"].Count)
+		}
+	}
+}
+
+func TestScore_DefaultWeight(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "foo")
+	got, err := ac.Score("foo foo", &ScoreOptions{DefaultWeight: 1.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Total != 3 {
+		t.Errorf("Total = %v, want 3", got.Total)
+	}
+}
+
+func TestScore_ReadsMatchedWeightsOnly(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "foo", "bar")
+	if err := ac.SetWeights(map[string]KeywordWeight{"foo": {Weight: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	// A corrupt entry for a keyword the text does not contain is never read.
+	mr.HSet(weightsKey(ac.collection()), "bar", "not json")
+	got, err := ac.Score("foo", nil)
+	if err != nil || got.Total != 2 {
+		t.Errorf("Score = %+v, %v; want a total of 2", got, err)
+	}
+	if _, err := ac.Score("bar", nil); err == nil {
+		t.Error("Score over the corrupt weight succeeded, want the decode error")
+	}
+}
+
+func TestSetWeights_Validation(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	if err := ac.SetWeights(map[string]KeywordWeight{" ": {Weight: 1}}); !errors.Is(err, ErrEmptyKeyword) {
+		t.Errorf("empty keyword: err = %v, want ErrEmptyKeyword", err)
+	}
+	if err := ac.SetWeights(map[string]KeywordWeight{"x": {Weight: math.NaN()}}); !errors.Is(err, ErrInvalidWeight) {
+		t.Errorf("NaN weight: err = %v, want ErrInvalidWeight", err)
+	}
+	weights, err := ac.Weights()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(weights) != 0 {
+		t.Errorf("rejected SetWeights wrote %v", weights)
+	}
+}
+
+func TestWeights_DeleteAndFlush(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	if err := ac.SetWeights(map[string]KeywordWeight{"a": {Weight: 1}, "b": {Weight: 2, Category: "c"}}); err != nil {
+		t.Fatal(err)
+	}
+	n, err := ac.DeleteWeights("A", "missing")
+	if err != nil || n != 1 {
+		t.Fatalf("DeleteWeights = %d, %v; want 1, nil", n, err)
+	}
+	weights, err := ac.Weights()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(weights, map[string]KeywordWeight{"b": {Weight: 2, Category: "c"}}) {
+		t.Errorf("Weights = %v", weights)
+	}
+
+	if err := ac.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	weights, err = ac.Weights()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(weights) != 0 {
+		t.Errorf("Flush left weights %v", weights)
+	}
+}
+
+func TestScore_PresetMode(t *testing.T) {
+	mr := createTestRedisServer(t)
+	defer mr.Close()
+	ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "score-preset", Preset: PresetBalanced})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ac.Close()
+
+	addAll(t, ac, "spam")
+	if err := ac.SetWeights(map[string]KeywordWeight{"spam": {Weight: 2, Category: "spam"}}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ac.Score("spam spam", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Total != 4 || got.Categories["spam"] != 4 {
+		t.Errorf("Score = %+v, want total and spam category 4", got)
+	}
+}
+
+func TestSetWeights_V1ReadOnly(t *testing.T) {
+	ac, mr := createAhoCorasickV1(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	if err := ac.SetWeights(map[string]KeywordWeight{"a": {Weight: 1}}); !errors.Is(err, ErrV1ReadOnly) {
+		t.Errorf("SetWeights on V1: err = %v, want ErrV1ReadOnly", err)
+	}
+	if _, err := ac.Score("a", nil); err != nil {
+		t.Errorf("Score on V1: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"strings"
+)
+
+// SnippetUnit selects what SnippetOptions.Before and After count.
+type SnippetUnit int
+
+const (
+	// SnippetRunes counts context in runes. It is the default.
+	SnippetRunes SnippetUnit = iota
+	// SnippetWords counts context in words, where a word is a maximal run of word
+	// runes (the same classification MatchOptions.WholeWord uses by default). The
+	// window stops at the edge of the last counted word, so it never ends mid-word,
+	// and the punctuation or space beyond it is left out.
+	SnippetWords
+)
+
+// SnippetOptions tunes FindSnippets. A nil *SnippetOptions yields one snippet
+// per match with no surrounding context.
+type SnippetOptions struct {
+	// Before is how much context to keep ahead of each match, in Unit. Negative
+	// values are treated as zero.
+	Before int
+	// After is how much context to keep behind each match, in Unit. Negative
+	// values are treated as zero.
+	After int
+	// Unit selects whether Before and After count runes (default) or words.
+	Unit SnippetUnit
+	// MergeAdjacent folds snippets whose windows overlap or touch into one
+	// snippet carrying all of their matches, so a dense passage renders as a single
+	// excerpt instead of several that repeat the same text.
+	MergeAdjacent bool
+}
+
+// Snippet is one excerpt of the searched text around one or more matches.
+type Snippet struct {
+	// Text is the excerpt, taken from the text as passed in, so it keeps the
+	// caller's case even on a case-insensitive collection.
+	Text string
+	// Start is the rune offset in the searched text where Text begins, inclusive.
+	Start int
+	// End is the rune offset in the searched text where Text ends, exclusive.
+	End int
+	// Matches are the matches the excerpt was built around, in start order. Their
+	// offsets index the searched text, not Text; subtract Start to place them
+	// inside the excerpt.
+	Matches []Match
+}
+
+// FindSnippets returns keyword-in-context excerpts of text: each match with the
+// context opts asks for on either side. Snippets are built from the
+// leftmost-longest non-overlapping matches, the same set Highlight marks, because
+// an excerpt per nested match ("he" inside "hers") would repeat the same text for
+// no extra information.
+//
+// Snippets are returned in text order. Without MergeAdjacent two snippets may
+// overlap when their matches sit closer together than the requested context.
+func (ac *AhoCorasick) FindSnippets(text string, opts *SnippetOptions) ([]Snippet, error) {
+	return ac.FindSnippetsContext(ac.ctx, text, opts)
+}
+
+// FindSnippetsContext is FindSnippets with an explicit context for cancellation.
+func (ac *AhoCorasick) FindSnippetsContext(ctx context.Context, text string, opts *SnippetOptions) ([]Snippet, error) {
+	matches, err := ac.findMatches(ctx, nil, text, &MatchOptions{Kind: MatchKindLeftmostLongest})
+	if err != nil {
+		return nil, err
+	}
+	snippets := make([]Snippet, 0, len(matches))
+	if len(matches) == 0 {
+		return snippets, nil
+	}
+	if opts == nil {
+		opts = &SnippetOptions{}
+	}
+
+	runes := []rune(text)
+	for _, m := range matches {
+		start, end := snippetWindow(runes, m, opts)
+		if opts.MergeAdjacent && len(snippets) > 0 {
+			last := &snippets[len(snippets)-1]
+			if start <= last.End {
+				last.End = max(last.End, end)
+				last.Matches = append(last.Matches, m)
+				continue
+			}
+		}
+		snippets = append(snippets, Snippet{Start: start, End: end, Matches: []Match{m}})
+	}
+	for i := range snippets {
+		snippets[i].Text = string(runes[snippets[i].Start:snippets[i].End])
+	}
+	return snippets, nil
+}
+
+// snippetWindow widens m by the context opts asks for, clamped to the text.
+func snippetWindow(runes []rune, m Match, opts *SnippetOptions) (int, int) {
+	before, after := max(opts.Before, 0), max(opts.After, 0)
+	if opts.Unit != SnippetWords {
+		return max(m.Start-before, 0), min(m.End+after, len(runes))
+	}
+
+	start := m.Start
+	for range before {
+		i := start
+		for i > 0 && !isWordRune(runes[i-1]) {
+			i--
+		}
+		if i == 0 {
+			break
+		}
+		for i > 0 && isWordRune(runes[i-1]) {
+			i--
+		}
+		start = i
+	}
+	end := m.End
+	for range after {
+		i := end
+		for i < len(runes) && !isWordRune(runes[i]) {
+			i++
+		}
+		if i == len(runes) {
+			break
+		}
+		for i < len(runes) && isWordRune(runes[i]) {
+			i++
+		}
+		end = i
+	}
+	return start, end
+}
+
+// Highlight returns text with every match wrapped in open and close, e.g.
+// "<mark>" and "</mark>" or a pair of ANSI escapes. Matches are the
+// leftmost-longest non-overlapping set, since nested markers around overlapping
+// matches would not balance. Text between matches is copied unchanged, so a
+// caller rendering into HTML must escape it first or use FindMatches and escape
+// each segment.
+func (ac *AhoCorasick) Highlight(text, open, closeMarker string) (string, error) {
+	return ac.HighlightContext(ac.ctx, text, open, closeMarker)
+}
+
+// HighlightContext is Highlight with an explicit context for cancellation.
+func (ac *AhoCorasick) HighlightContext(ctx context.Context, text, open, closeMarker string) (string, error) {
+	matches, err := ac.findMatches(ctx, nil, text, &MatchOptions{Kind: MatchKindLeftmostLongest})
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(text) + len(matches)*(len(open)+len(closeMarker)))
+	// Match offsets count runes and the copy slices bytes, so walk the two in step.
+	// Matches are sorted and disjoint, so one forward pass covers them all.
+	next, pos, runeIdx := 0, 0, 0
+	for byteIdx := range text {
+		if next == len(matches) {
+			break
+		}
+		switch runeIdx {
+		case matches[next].Start:
+			b.WriteString(text[pos:byteIdx])
+			b.WriteString(open)
+			pos = byteIdx
+		case matches[next].End:
+			b.WriteString(text[pos:byteIdx])
+			b.WriteString(closeMarker)
+			pos = byteIdx
+			next++
+			// A match may start exactly where the previous one ended.
+			if next < len(matches) && matches[next].Start == runeIdx {
+				b.WriteString(open)
+			}
+		}
+		runeIdx++
+	}
+	b.WriteString(text[pos:])
+	if next < len(matches) {
+		// The last match runs to the end of the text, past the final rune the loop saw.
+		b.WriteString(closeMarker)
+	}
+	return b.String(), nil
+}
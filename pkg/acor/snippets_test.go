@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindSnippets_Runes(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "spam")
+	got, err := ac.FindSnippets("buy SPAM now", &SnippetOptions{Before: 2, After: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Snippet{{
+		Text:    "y SPAM no",
+		Start:   2,
+		End:     11,
+		Matches: []Match{{Keyword: "spam", Start: 4, End: 8}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindSnippets = %+v, want %+v", got, want)
+	}
+}
+
+func TestFindSnippets_Words(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "fox")
+	got, err := ac.FindSnippets("the quick brown fox jumps, over it", &SnippetOptions{
+		Before: 2,
+		After:  2,
+		Unit:   SnippetWords,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Text != "quick brown fox jumps, over" {
+		t.Errorf("FindSnippets words = %+v", got)
+	}
+}
+
+func TestFindSnippets_WordsClampAtEdges(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "fox")
+	got, err := ac.FindSnippets("  fox!  ", &SnippetOptions{Before: 3, After: 3, Unit: SnippetWords})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Text != "fox" {
+		t.Errorf("FindSnippets = %+v, want only the match", got)
+	}
+}
+
+func TestFindSnippets_MergeAdjacent(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "ab", "cd", "zz")
+	text := "ab-cd.........zz"
+	opts := &SnippetOptions{Before: 1, After: 1}
+
+	separate, err := ac.FindSnippets(text, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(separate) != 3 {
+		t.Fatalf("without merge: %d snippets, want 3: %+v", len(separate), separate)
+	}
+
+	opts.MergeAdjacent = true
+	merged, err := ac.FindSnippets(text, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 2 {
+		t.Fatalf("with merge: %d snippets, want 2: %+v", len(merged), merged)
+	}
+	if merged[0].Text != "ab-cd." || len(merged[0].Matches) != 2 {
+		t.Errorf("merged snippet = %+v", merged[0])
+	}
+}
+
+func TestFindSnippets_NoMatches(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "spam")
+	got, err := ac.FindSnippets("clean text", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || len(got) != 0 {
+		t.Errorf("FindSnippets = %#v, want empty non-nil", got)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	ac, mr := createAhoCorasick(t)
+	defer mr.Close()
+	defer ac.Close()
+
+	addAll(t, ac, "he", "hers", "she", "é")
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", ""},
+		{"nothing", "nothing"},
+		{"ushers", "u[she]rs"},
+		{"HERS and he", "[HERS] and [he]"},
+		{"café hers", "caf[é] [hers]"},
+		{"éhe", "[é][he]"},
+	}
+	for _, tt := range tests {
+		got, err := ac.Highlight(tt.text, "[", "]")
+		if err != nil {
+			t.Fatalf("Highlight(%q): %v", tt.text, err)
+		}
+		if got != tt.want {
+			t.Errorf("Highlight(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
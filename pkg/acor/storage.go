@@ -2,7 +2,10 @@
 
 package acor
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // zMember represents a sorted set member with score, compatible with Redis ZSET operations.
 type zMember struct {
@@ -29,8 +32,25 @@ type zMember struct {
 type kvStorage interface {
 	// HGetAll retrieves all field-value pairs from a hash.
 	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	// HGet retrieves one field of a hash, failing with redis.Nil when it is unset.
+	HGet(ctx context.Context, key, field string) (string, error)
+	// HMGet retrieves the given fields of a hash, leaving out those that are unset.
+	HMGet(ctx context.Context, key string, fields ...string) (map[string]string, error)
+	// HScan returns one page of a hash's fields and values, alternating, and the
+	// cursor for the next page; a returned cursor of 0 means the scan is done.
+	HScan(ctx context.Context, key string, cursor uint64, count int64) ([]string, uint64, error)
 	// HSet sets multiple field-value pairs in a hash.
 	HSet(ctx context.Context, key string, values ...interface{}) error
+	// HSetUnlessExists sets one field of a hash unless any of the guard keys
+	// exists, checking and setting in one atomic step, and reports whether it did.
+	HSetUnlessExists(ctx context.Context, key, field, value string, guards ...string) (bool, error)
+	// SetNX sets key to value with the given expiry unless it already exists,
+	// reporting whether it did.
+	SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+	// PExpire sets a key's expiry.
+	PExpire(ctx context.Context, key string, ttl time.Duration) error
+	// HDel removes fields from a hash. Returns the number of fields removed.
+	HDel(ctx context.Context, key string, fields ...string) (int64, error)
 	// SAdd adds members to a set.
 	SAdd(ctx context.Context, key string, members ...interface{}) error
 	// SMembers retrieves all members of a set.
@@ -57,6 +77,9 @@ type kvStorage interface {
 	Del(ctx context.Context, keys ...string) error
 	// Exists checks if one or more keys exist. Returns the count of existing keys.
 	Exists(ctx context.Context, keys ...string) (int64, error)
+	// XRevRange returns up to count stream entries, newest first; count <= 0
+	// returns the whole stream.
+	XRevRange(ctx context.Context, key string, count int64) ([]streamEntry, error)
 	// TxPipelined executes commands in a transaction pipeline.
 	TxPipelined(ctx context.Context, fn func(pipeliner) error) error
 	// Pipeline returns a non-transactional pipeline for batching commands.
@@ -78,6 +101,22 @@ type stringMapResult interface {
 	Val() map[string]string
 }
 
+// stringResult is the deferred result of a pipeline HGet, as stringMapResult is
+// of an HGetAll.
+type stringResult interface {
+	// Val returns the field's value, or "" when it is unset. Must be called after
+	// Exec on the pipeline.
+	Val() string
+}
+
+// streamEntry is one entry of a Redis stream.
+type streamEntry struct {
+	// ID is the entry ID Redis assigned, "<milliseconds>-<sequence>".
+	ID string
+	// Values holds the entry's field-value pairs.
+	Values map[string]string
+}
+
 // pubSubMessage represents a message received from a pub/sub subscription.
 type pubSubMessage struct {
 	// Channel is the name of the pub/sub channel the message was published to.
@@ -106,6 +145,9 @@ type pipeliner interface {
 	// HGetAll retrieves all field-value pairs from a hash in the pipeline.
 	// Returns a deferred result that can be read after Exec is called.
 	HGetAll(ctx context.Context, key string) stringMapResult
+	// HGet retrieves one hash field in the pipeline. An unset field is not an
+	// error here: Exec succeeds and the result reads empty.
+	HGet(ctx context.Context, key, field string) stringResult
 	// ZAdd adds sorted set members in the pipeline.
 	ZAdd(ctx context.Context, key string, members ...*zMember) error
 	// Del deletes keys in the pipeline.
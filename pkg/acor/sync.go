@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// SyncOptions configures Sync.
+type SyncOptions struct {
+	// DryRun computes and reports the difference without writing anything.
+	DryRun bool
+	// MaxDeletes is the most keywords one Sync may remove. A sync that would
+	// remove more writes nothing and returns ErrSyncDeleteLimit. Zero, the
+	// default, allows no removals at all, so an empty or truncated desired set
+	// cannot wipe a collection by accident; set a negative value to lift the
+	// limit.
+	MaxDeletes int
+}
+
+// SyncReport describes what a Sync changed, or with DryRun would change.
+type SyncReport struct {
+	// Added lists the desired keywords the collection did not hold, sorted.
+	Added []string
+	// Removed lists the held keywords the desired set does not contain, sorted.
+	Removed []string
+	// Unchanged counts the desired keywords the collection already held.
+	Unchanged int
+	// DryRun reports whether the sync only planned its changes.
+	DryRun bool
+}
+
+// Sync makes the collection hold exactly the desired keywords: every keyword
+// not yet present is added and every keyword not desired is removed, all in one
+// optimistic-lock commit, so readers see either the old set or the new one.
+// Keywords are normalized as Add normalizes them, and blank ones are skipped.
+// A nil opts is the zero SyncOptions.
+//
+// Expired keywords count as absent: one desired again is added back, and one
+// not desired is dropped for good and listed in the report's Removed, though
+// it does not count toward opts.MaxDeletes.
+//
+// The report lists what changed. When the removals exceed opts.MaxDeletes the
+// error is ErrSyncDeleteLimit and the report is still returned, describing the
+// sync that was refused. With History set, the commit is recorded as a "sync"
+// entry and can be undone with RevertTo.
+//
+// V1 collections are read-only, so Sync returns ErrV1ReadOnly there.
+func (ac *AhoCorasick) Sync(desired []string, opts *SyncOptions) (*SyncReport, error) {
+	return ac.SyncContext(ac.ctx, desired, opts)
+}
+
+// SyncContext is Sync with an explicit context for cancellation.
+func (ac *AhoCorasick) SyncContext(ctx context.Context, desired []string, opts *SyncOptions) (*SyncReport, error) {
+	if opts == nil {
+		opts = &SyncOptions{}
+	}
+	bp, ok := ac.ops.(batchPlanner)
+	if !ok {
+		return nil, ErrV1ReadOnly
+	}
+	want := make([]string, 0, len(desired))
+	for _, kw := range desired {
+		if kw = normalizeKeyword(kw, ac.caseSensitive); kw != "" {
+			want = append(want, kw)
+		}
+	}
+
+	if opts.DryRun {
+		snap, err := readTrieSnapshot(ctx, ac.storage, ac.collection())
+		if err != nil {
+			return nil, err
+		}
+		report := newSyncReport(snap.live(time.Now()).Keywords, want)
+		report.DryRun = true
+		return report, checkDeleteLimit(report, opts.MaxDeletes)
+	}
+
+	// The limit is checked against each attempt's own snapshot: a conflict retry
+	// may find more to remove than the first look did.
+	var planned *SyncReport
+	added, removed, err := bp.replaceAtomic(ctx, historyOpSync, func(live *trieSnapshot) ([]string, error) {
+		planned = newSyncReport(live.Keywords, want)
+		if err := checkDeleteLimit(planned, opts.MaxDeletes); err != nil {
+			return nil, err
+		}
+		return want, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrSyncDeleteLimit) {
+			return planned, err
+		}
+		return nil, err
+	}
+	return committedSyncReport(added, removed, want), nil
+}
+
+// SyncReader is Sync with the desired keywords read from r, one per line, as
+// they would sit in a dictionary file kept under version control. Surrounding
+// whitespace is trimmed and blank lines are skipped.
+func (ac *AhoCorasick) SyncReader(r io.Reader, opts *SyncOptions) (*SyncReport, error) {
+	return ac.SyncReaderContext(ac.ctx, r, opts)
+}
+
+// SyncReaderContext is SyncReader with an explicit context for cancellation.
+func (ac *AhoCorasick) SyncReaderContext(ctx context.Context, r io.Reader, opts *SyncOptions) (*SyncReport, error) {
+	var desired []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		desired = append(desired, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read desired keywords: %w", err)
+	}
+	return ac.SyncContext(ctx, desired, opts)
+}
+
+// newSyncReport plans a sync from the stored keywords to want.
+func newSyncReport(stored, want []string) *SyncReport {
+	added, removed := diffKeywords(stored, want)
+	slices.Sort(added)
+	slices.Sort(removed)
+	return &SyncReport{
+		Added:     added,
+		Removed:   removed,
+		Unchanged: len(stored) - len(removed),
+	}
+}
+
+// committedSyncReport reports what a replace committed. removed also lists the
+// expired keywords the commit dropped for good, which no plan counts.
+func committedSyncReport(added, removed, want []string) *SyncReport {
+	added, removed = slices.Clone(added), slices.Clone(removed)
+	slices.Sort(added)
+	slices.Sort(removed)
+	desired := make(map[string]struct{}, len(want))
+	for _, kw := range want {
+		desired[kw] = struct{}{}
+	}
+	return &SyncReport{
+		Added:     added,
+		Removed:   removed,
+		Unchanged: len(desired) - len(added),
+	}
+}
+
+func checkDeleteLimit(report *SyncReport, maxDeletes int) error {
+	if maxDeletes >= 0 && len(report.Removed) > maxDeletes {
+		return fmt.Errorf("%w: sync would remove %d keywords, limit is %d",
+			ErrSyncDeleteLimit, len(report.Removed), maxDeletes)
+	}
+	return nil
+}
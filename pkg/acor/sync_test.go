@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestSync_Reconciles(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args AhoCorasickArgs
+	}{
+		{"v2", AhoCorasickArgs{}},
+		{"preset", AhoCorasickArgs{Preset: PresetBalanced}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			args := tc.args
+			args.Addr, args.Name = mr.Addr(), "s"
+			ac, err := Create(&args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = ac.Close() }()
+			if _, err := ac.AddMany([]string{"apple", "banana", "cherry"}, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := ac.Sync([]string{"Banana", "cherry", "durian", " ", "durian"}, &SyncOptions{MaxDeletes: 1})
+			if err != nil {
+				t.Fatalf("Sync error: %v", err)
+			}
+			if !slices.Equal(report.Added, []string{"durian"}) || !slices.Equal(report.Removed, []string{"apple"}) ||
+				report.Unchanged != 2 || report.DryRun {
+				t.Errorf("Sync report = %+v, want +[durian] -[apple] unchanged 2", report)
+			}
+			if got := storedKeywords(t, ac, "s"); !slices.Equal(got, []string{"banana", "cherry", "durian"}) {
+				t.Errorf("stored keywords = %v, want [banana cherry durian]", got)
+			}
+			got, err := ac.Find("apple durian")
+			if err != nil || !slices.Equal(got, []string{"durian"}) {
+				t.Errorf("Find after Sync = %v, %v; want [durian]", got, err)
+			}
+
+			again, err := ac.Sync([]string{"banana", "cherry", "durian"}, nil)
+			if err != nil || len(again.Added)+len(again.Removed) != 0 || again.Unchanged != 3 {
+				t.Errorf("repeated Sync = %+v, %v; want no changes", again, err)
+			}
+		})
+	}
+}
+
+func TestSync_DryRunWritesNothing(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "s", "apple", "banana")
+
+	report, err := ac.Sync([]string{"banana", "cherry"}, &SyncOptions{DryRun: true, MaxDeletes: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.DryRun || !slices.Equal(report.Added, []string{"cherry"}) || !slices.Equal(report.Removed, []string{"apple"}) {
+		t.Errorf("dry-run report = %+v", report)
+	}
+	if got := storedKeywords(t, ac, "s"); !slices.Equal(got, []string{"apple", "banana"}) {
+		t.Errorf("stored keywords after dry run = %v, want them untouched", got)
+	}
+}
+
+func TestSync_DeleteLimit(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "s", "apple", "banana", "cherry")
+
+	// The zero SyncOptions allows no removals, so an empty file cannot wipe the
+	// collection.
+	for _, opts := range []*SyncOptions{nil, {MaxDeletes: 2}, {DryRun: true}} {
+		report, err := ac.Sync(nil, opts)
+		if !errors.Is(err, ErrSyncDeleteLimit) {
+			t.Fatalf("Sync(nil, %+v) error = %v, want ErrSyncDeleteLimit", opts, err)
+		}
+		if report == nil || len(report.Removed) != 3 {
+			t.Errorf("Sync(nil, %+v) report = %+v, want the refused plan", opts, report)
+		}
+	}
+	if got := storedKeywords(t, ac, "s"); len(got) != 3 {
+		t.Errorf("stored keywords = %v, want all three kept", got)
+	}
+
+	if _, err := ac.Sync(nil, &SyncOptions{MaxDeletes: -1}); err != nil {
+		t.Fatalf("Sync with no limit error: %v", err)
+	}
+	if got := storedKeywords(t, ac, "s"); len(got) != 0 {
+		t.Errorf("stored keywords = %v, want none", got)
+	}
+}
+
+func TestSync_ExpiredKeywords(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "s", "apple")
+	for _, kw := range []string{"banana", "cherry"} {
+		if _, err := ac.AddWithTTL(kw, 10*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	// banana is desired again and comes back as an addition; cherry is not and
+	// goes for good without counting against the zero MaxDeletes.
+	dry, err := ac.Sync([]string{"apple", "banana"}, &SyncOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run error: %v", err)
+	}
+	if !slices.Equal(dry.Added, []string{"banana"}) || len(dry.Removed) != 0 || dry.Unchanged != 1 {
+		t.Errorf("dry-run report = %+v, want +[banana] unchanged 1", dry)
+	}
+	report, err := ac.Sync([]string{"apple", "banana"}, nil)
+	if err != nil {
+		t.Fatalf("Sync error: %v", err)
+	}
+	if !slices.Equal(report.Added, []string{"banana"}) || !slices.Equal(report.Removed, []string{"cherry"}) ||
+		report.Unchanged != 1 {
+		t.Errorf("Sync report = %+v, want +[banana] -[cherry] unchanged 1", report)
+	}
+	if got := storedKeywords(t, ac, "s"); !slices.Equal(got, []string{"apple", "banana"}) {
+		t.Errorf("stored keywords = %v, want [apple banana]", got)
+	}
+}
+
+func TestSync_ReaderAndHistory(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createWithHistory(t, mr, "s", &HistoryOptions{})
+	if _, err := ac.Add("apple"); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ac.SyncReader(strings.NewReader("banana\r\n\n  cherry  \n"), &SyncOptions{MaxDeletes: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(report.Added, []string{"banana", "cherry"}) || !slices.Equal(report.Removed, []string{"apple"}) {
+		t.Errorf("SyncReader report = %+v", report)
+	}
+
+	entries, err := ac.History(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries[0].Op != "sync" {
+		t.Fatalf("newest entry op = %q, want sync", entries[0].Op)
+	}
+	if _, err := ac.RevertTo(entries[0].PrevVersion); err != nil {
+		t.Fatalf("RevertTo before the sync error: %v", err)
+	}
+	if got := storedKeywords(t, ac, "s"); !slices.Equal(got, []string{"apple"}) {
+		t.Errorf("stored keywords after revert = %v, want [apple]", got)
+	}
+}
+
+func TestSync_V1ReadOnly(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "s", SchemaVersion: SchemaV1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ac.Close() }()
+	if _, err := ac.Sync([]string{"apple"}, nil); !errors.Is(err, ErrV1ReadOnly) {
+		t.Errorf("Sync on V1 error = %v, want ErrV1ReadOnly", err)
+	}
+}
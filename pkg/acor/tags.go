@@ -0,0 +1,286 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Keyword tags: a keyword can carry any number of tags, naming the logical lists
+// it belongs to — "profanity:de", "customer:42" — so several lists share one
+// collection and one scan. The tags live in the trie hash's fieldTags, written by
+// the same script and under the same version as the keyword set, so removing a
+// keyword removes its tags with it and a tag never names a keyword that is gone.
+//
+// A tag is an opaque string, compared exactly: unlike keywords, tags are not
+// folded to lower case on a case-insensitive collection.
+
+// keywordTags maps each tagged keyword to its tags, sorted and without
+// duplicates. A keyword without an entry carries no tag.
+type keywordTags map[string][]string
+
+// parseTags decodes a trie hash's fieldTags value; an absent field decodes to no
+// tags.
+func parseTags(raw string) (keywordTags, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var tags keywordTags
+	if err := json.Unmarshal([]byte(raw), &tags); err != nil {
+		return nil, newOperationError("unmarshal", SchemaV2, err)
+	}
+	return tags, nil
+}
+
+// encode renders t as v2WriteScript stores it, "{}" when empty.
+func (t keywordTags) encode() (string, error) {
+	if len(t) == 0 {
+		return "{}", nil
+	}
+	return toJSON(t)
+}
+
+// hasAny reports whether keyword carries at least one of the tags in want.
+func (t keywordTags) hasAny(keyword string, want map[string]struct{}) bool {
+	for _, tag := range t[keyword] {
+		if _, ok := want[tag]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// counts returns how many keywords carry each tag, leaving out those expired by
+// now, or nil when no live keyword is tagged.
+func (t keywordTags) counts(expiries keywordExpiries, now time.Time) map[string]int {
+	var counts map[string]int
+	for kw, tags := range t {
+		if expiries.expired(kw, now) {
+			continue
+		}
+		if counts == nil {
+			counts = make(map[string]int)
+		}
+		for _, tag := range tags {
+			counts[tag]++
+		}
+	}
+	return counts
+}
+
+// normalizeTag trims tag, failing with ErrInvalidTag when nothing is left.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", ErrInvalidTag
+	}
+	return tag, nil
+}
+
+// normalizeTags trims every tag in tags and returns them sorted and without
+// duplicates; a blank one fails the whole set with ErrInvalidTag.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, tag)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+// addTags gives every keyword in keywords the tags in tags, which must already be
+// normalized, on top of those it has.
+func (s *trieSnapshot) addTags(keywords, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	for _, kw := range keywords {
+		if kw == "" {
+			continue
+		}
+		have := s.Tags[kw]
+		merged := append(slices.Clone(have), tags...)
+		slices.Sort(merged)
+		merged = slices.Compact(merged)
+		if slices.Equal(merged, have) {
+			continue
+		}
+		if s.Tags == nil {
+			s.Tags = keywordTags{}
+		}
+		s.Tags[kw] = merged
+		s.attrsChanged = true
+	}
+}
+
+// keptTags returns the entries of tags for the keywords in want.
+func keptTags(tags keywordTags, want []string) keywordTags {
+	kept := make(keywordTags)
+	for _, kw := range want {
+		if t, ok := tags[kw]; ok {
+			kept[kw] = t
+		}
+	}
+	return kept
+}
+
+// AddWithTags inserts keyword as Add does and gives it tags. A keyword already
+// present keeps its place and its other tags, gains any of tags it lacked, and the
+// call returns 0. With no tags it is Add.
+//
+// Tags are trimmed, and a blank one fails the call with ErrInvalidTag before
+// anything is written. V1 collections are read-only, so AddWithTags returns
+// ErrV1ReadOnly there.
+func (ac *AhoCorasick) AddWithTags(keyword string, tags ...string) (int, error) {
+	return ac.AddWithTagsContext(ac.ctx, keyword, tags...)
+}
+
+// AddWithTagsContext is AddWithTags with an explicit context for cancellation.
+func (ac *AhoCorasick) AddWithTagsContext(ctx context.Context, keyword string, tags ...string) (int, error) {
+	bp, ok := ac.ops.(batchPlanner)
+	if !ok {
+		return 0, ErrV1ReadOnly
+	}
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return 0, err
+	}
+	keyword = normalizeKeyword(keyword, ac.caseSensitive)
+	if keyword == "" {
+		return 0, nil
+	}
+	added, err := bp.addWithAttrsAtomic(ctx, []string{keyword}, keywordAttrs{tags: tags})
+	return len(added), err
+}
+
+// ListTag returns the keywords carrying tag, sorted. It reads the stored
+// collection rather than any local copy, so it costs one read of the trie hash in
+// every mode. Keywords whose TTL has run out are left out, as they are from
+// matching. V1 collections hold no tags and list nothing.
+func (ac *AhoCorasick) ListTag(tag string) ([]string, error) {
+	return ac.ListTagContext(ac.ctx, tag)
+}
+
+// ListTagContext is ListTag with an explicit context for cancellation.
+func (ac *AhoCorasick) ListTagContext(ctx context.Context, tag string) ([]string, error) {
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	keywords := []string{}
+	if _, ok := ac.ops.(batchPlanner); !ok {
+		return keywords, nil
+	}
+	snap, err := readTrieSnapshot(ctx, ac.storage, ac.collection())
+	if err != nil {
+		return nil, err
+	}
+	for _, kw := range snap.live(time.Now()).Keywords {
+		if slices.Contains(snap.Tags[kw], tag) {
+			keywords = append(keywords, kw)
+		}
+	}
+	slices.Sort(keywords)
+	return keywords, nil
+}
+
+// RemoveTag drops tag from every keyword carrying it and removes the keywords it
+// leaves with no tag at all, returning how many it removed. A keyword that also
+// belongs to another list keeps its place under its remaining tags, so dropping
+// one list never takes a keyword away from another; a keyword that never had a
+// tag is untouched.
+//
+// Everything lands in one versioned write, recorded in history as "untag", so no
+// reader sees the tag half gone. Like Sync it replaces the keyword set, which also
+// reaps any keyword whose TTL has run out. V1 collections are read-only, so
+// RemoveTag returns ErrV1ReadOnly there.
+func (ac *AhoCorasick) RemoveTag(tag string) (int, error) {
+	return ac.RemoveTagContext(ac.ctx, tag)
+}
+
+// RemoveTagContext is RemoveTag with an explicit context for cancellation.
+func (ac *AhoCorasick) RemoveTagContext(ctx context.Context, tag string) (int, error) {
+	bp, ok := ac.ops.(batchPlanner)
+	if !ok {
+		return 0, ErrV1ReadOnly
+	}
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return 0, err
+	}
+	// untagged is rebuilt on every attempt: a retry plans from a fresh snapshot.
+	var untagged map[string]struct{}
+	_, removed, err := bp.replaceAtomic(ctx, historyOpUntag, func(live *trieSnapshot) ([]string, error) {
+		untagged = make(map[string]struct{})
+		tags := make(keywordTags, len(live.Tags))
+		for kw, kwTags := range live.Tags {
+			if !slices.Contains(kwTags, tag) {
+				tags[kw] = kwTags
+				continue
+			}
+			live.attrsChanged = true
+			rest := slices.DeleteFunc(slices.Clone(kwTags), func(t string) bool { return t == tag })
+			if len(rest) == 0 {
+				untagged[kw] = struct{}{}
+				continue
+			}
+			tags[kw] = rest
+		}
+		live.Tags = tags
+		keep := make([]string, 0, len(live.Keywords))
+		for _, kw := range live.Keywords {
+			if _, ok := untagged[kw]; !ok {
+				keep = append(keep, kw)
+			}
+		}
+		return keep, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, kw := range removed {
+		if _, ok := untagged[kw]; ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// loadTags returns the keyword tags FindMatches filters against. Preset mode
+// keeps them with its automaton, so a filtered read stays off Redis there; every
+// other mode reads the trie's tags field.
+func (ac *AhoCorasick) loadTags(ctx context.Context) (keywordTags, error) {
+	ops, name := ac.ops, ac.name
+	if a, ok := ops.(*aliasOps); ok {
+		t := a.target()
+		ops, name = t.ops, t.name
+	}
+	if rb, ok := ops.(*redisBackedAC); ok {
+		rb.mu.RLock()
+		defer rb.mu.RUnlock()
+		return rb.tags, nil
+	}
+	raw, err := ac.storage.HGet(ctx, trieKey(name), fieldTags)
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newRedisError("HGET", trieKey(name), err)
+	}
+	return parseTags(raw)
+}
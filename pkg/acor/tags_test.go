@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func matchedKeywords(matches []Match) []string {
+	keywords := make([]string, 0, len(matches))
+	for _, m := range matches {
+		keywords = append(keywords, m.Keyword)
+	}
+	return keywords
+}
+
+func TestFindMatches_Tags(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args AhoCorasickArgs
+	}{
+		{"v2", AhoCorasickArgs{}},
+		{"cached", AhoCorasickArgs{EnableCache: true}},
+		{"preset", AhoCorasickArgs{Preset: PresetBalanced}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			args := tc.args
+			args.Addr, args.Name = mr.Addr(), "c"
+			ac, err := Create(&args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = ac.Close() }()
+
+			if _, err := ac.AddWithTags("he", "en"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ac.AddMany([]string{"hers", "sie"}, &BatchOptions{Tags: []string{"de"}}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ac.AddWithTags("Hers", " en "); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ac.Add("his"); err != nil {
+				t.Fatal(err)
+			}
+
+			text := "hers sie his"
+			for _, f := range []struct {
+				tags []string
+				want []string
+			}{
+				{nil, []string{"he", "hers", "sie", "his"}},
+				{[]string{"en"}, []string{"he", "hers"}},
+				{[]string{"de"}, []string{"hers", "sie"}},
+				{[]string{"de", "en"}, []string{"he", "hers", "sie"}},
+				{[]string{"fr"}, []string{}},
+			} {
+				got, err := ac.FindMatches(text, &MatchOptions{Tags: f.tags})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if keywords := matchedKeywords(got); !slices.Equal(keywords, f.want) {
+					t.Errorf("FindMatches with tags %v = %v, want %v", f.tags, keywords, f.want)
+				}
+			}
+
+			// The filter runs before leftmost-longest, so "hers" outside the tag
+			// does not hide "he" inside it.
+			if _, err := ac.RemoveTag("en"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ac.AddWithTags("he", "short"); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ac.FindMatches("hers", &MatchOptions{Tags: []string{"short"}, Kind: MatchKindLeftmostLongest})
+			if err != nil || !slices.Equal(matchedKeywords(got), []string{"he"}) {
+				t.Errorf("leftmost-longest within a tag = %v, %v; want [he]", got, err)
+			}
+		})
+	}
+}
+
+func TestRemoveTag_KeepsKeywordsOfOtherLists(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createWithHistory(t, mr, "c", &HistoryOptions{})
+
+	if _, err := ac.Add("plain"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ac.AddMany([]string{"damn", "mist"}, &BatchOptions{Tags: []string{"de"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ac.AddMany([]string{"damn", "heck"}, &BatchOptions{Tags: []string{"en"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := ac.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"de": 2, "en": 2}; !maps.Equal(info.Tags, want) {
+		t.Errorf("Info().Tags = %v, want %v", info.Tags, want)
+	}
+	if got, err := ac.ListTag("de"); err != nil || !slices.Equal(got, []string{"damn", "mist"}) {
+		t.Errorf("ListTag(de) = %v, %v; want [damn mist]", got, err)
+	}
+
+	n, err := ac.RemoveTag("de")
+	if err != nil || n != 1 {
+		t.Fatalf("RemoveTag(de) = %d, %v; want 1", n, err)
+	}
+	if got := storedKeywords(t, ac, "c"); !slices.Equal(got, []string{"damn", "heck", "plain"}) {
+		t.Errorf("stored keywords = %v; damn belongs to en too, plain to no list", got)
+	}
+	if got, _ := ac.ListTag("de"); len(got) != 0 {
+		t.Errorf("ListTag(de) after RemoveTag = %v, want none", got)
+	}
+	if got, _ := ac.ListTag("en"); !slices.Equal(got, []string{"damn", "heck"}) {
+		t.Errorf("ListTag(en) after RemoveTag = %v, want [damn heck]", got)
+	}
+	entries, err := ac.History(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries[0].Op != "untag" || !slices.Equal(entries[0].Removed, []string{"mist"}) {
+		t.Errorf("newest history entry = %+v, want an untag removing mist", entries[0])
+	}
+
+	// Dropping the last list a keyword is in removes it; its tags go with it, so
+	// re-adding it starts untagged.
+	if n, err := ac.RemoveTag("en"); err != nil || n != 2 {
+		t.Fatalf("RemoveTag(en) = %d, %v; want 2", n, err)
+	}
+	if _, err := ac.Add("damn"); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := ac.Info(); info.Tags != nil {
+		t.Errorf("Info().Tags with nothing tagged = %v, want nil", info.Tags)
+	}
+	if mr.HGet(trieKey("c"), fieldTags) != "" {
+		t.Error("an empty tag set should leave no field in the trie hash")
+	}
+}
+
+func TestRemoveTag_RetagOnlyReachesPresetPeer(t *testing.T) {
+	mr := miniredis.RunT(t)
+	writer := createCollection(t, mr, "c")
+	reader, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "c", Preset: PresetBalanced})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	if _, err := writer.AddWithTags("damn", "de", "en"); err != nil {
+		t.Fatal(err)
+	}
+	// RemoveTag here removes no keyword, only a tag; the peer must still hear of it.
+	ctx, token := WithVersionToken(t.Context())
+	if n, err := writer.RemoveTagContext(ctx, "de"); err != nil || n != 0 {
+		t.Fatalf("RemoveTag = %d, %v; want 0", n, err)
+	}
+	got, err := reader.FindMatchesContext(WithMinVersion(t.Context(), token.Version()), "damn",
+		&MatchOptions{Tags: []string{"de"}})
+	if err != nil || len(got) != 0 {
+		t.Errorf("preset FindMatches with the removed tag = %v, %v; want nothing", got, err)
+	}
+}
+
+func TestTags_Errors(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "c", "apple")
+
+	if _, err := ac.AddWithTags("pear", "fruit", " "); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("AddWithTags with a blank tag error = %v, want ErrInvalidTag", err)
+	}
+	if _, err := ac.AddMany([]string{"pear"}, &BatchOptions{Tags: []string{""}}); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("AddMany with a blank tag error = %v, want ErrInvalidTag", err)
+	}
+	if got := storedKeywords(t, ac, "c"); !slices.Equal(got, []string{"apple"}) {
+		t.Errorf("stored keywords = %v, want nothing written", got)
+	}
+	if _, err := ac.FindMatches("apple", &MatchOptions{Tags: []string{""}}); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("FindMatches with a blank tag error = %v, want ErrInvalidTag", err)
+	}
+	if _, err := ac.RemoveTag(""); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("RemoveTag(\"\") error = %v, want ErrInvalidTag", err)
+	}
+
+	v1, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "old", SchemaVersion: SchemaV1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = v1.Close() }()
+	if _, err := v1.AddWithTags("x", "t"); !errors.Is(err, ErrV1ReadOnly) {
+		t.Errorf("AddWithTags on V1 error = %v, want ErrV1ReadOnly", err)
+	}
+	if _, err := v1.RemoveTag("t"); !errors.Is(err, ErrV1ReadOnly) {
+		t.Errorf("RemoveTag on V1 error = %v, want ErrV1ReadOnly", err)
+	}
+	if got, err := v1.ListTag("t"); err != nil || len(got) != 0 {
+		t.Errorf("ListTag on V1 = %v, %v; want nothing", got, err)
+	}
+}
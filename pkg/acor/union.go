@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	matchengine "github.com/skyoo2003/acor/internal/engine"
+)
+
+// Union searches several collections in one pass. It builds a single automaton
+// over the keywords of all its members and reports each match labeled with the
+// collection that holds the keyword, so scanning a text against N lists costs
+// one scan rather than N.
+//
+// A Union holds no state of its own in Redis. Each search asks every member for
+// its current engine, which is how each member already stays fresh: a member
+// with a cache returns its cached engine until an invalidation replaces it, and
+// one without re-reads Redis. When any member's engine differs from the one the
+// merged automaton was built from, the Union rebuilds before scanning, so a
+// write to any member is visible to the next search once that member sees it.
+//
+// A Union is safe for concurrent use. Closing it is unnecessary, and closing a
+// member is the caller's business: a Union over a closed member fails as the
+// member's own searches do.
+type Union struct {
+	members []*AhoCorasick
+
+	mu    sync.Mutex
+	built *unionEngine
+}
+
+// unionEngine is the merged automaton together with what it was built from.
+// It is immutable once built; a rebuild replaces it.
+type unionEngine struct {
+	engine *matchengine.Engine
+	// from holds each member's engine at build time, in member order.
+	from []*matchengine.Engine
+	// labels holds each member's collection name at build time, in member order.
+	labels []string
+	// owners maps each keyword to the indexes of the members holding it, ascending.
+	owners map[string][]int
+}
+
+// NewUnion returns a Union over collections, which are searched in the order
+// given: a keyword held by several members yields one match per member, in that
+// order.
+//
+// The members must agree on case sensitivity, since one automaton cannot fold
+// case for some keywords and not others, and must serve distinct collections.
+// Otherwise, or with no members at all, NewUnion fails with ErrInvalidUnion.
+// Members may mix modes: a preset member and a cached V2 member can share a
+// Union.
+func NewUnion(collections ...*AhoCorasick) (*Union, error) {
+	if len(collections) == 0 {
+		return nil, fmt.Errorf("%w: no collections", ErrInvalidUnion)
+	}
+	seen := make(map[string]struct{}, len(collections))
+	for _, ac := range collections {
+		if ac == nil {
+			return nil, fmt.Errorf("%w: nil collection", ErrInvalidUnion)
+		}
+		if ac.caseSensitive != collections[0].caseSensitive {
+			return nil, fmt.Errorf("%w: %q and %q differ in case sensitivity",
+				ErrInvalidUnion, collections[0].Collection(), ac.Collection())
+		}
+		name := ac.Collection()
+		if _, dup := seen[name]; dup {
+			return nil, fmt.Errorf("%w: collection %q given twice", ErrInvalidUnion, name)
+		}
+		seen[name] = struct{}{}
+	}
+	return &Union{members: slices.Clone(collections)}, nil
+}
+
+// Collections returns the names of the member collections, in member order.
+func (u *Union) Collections() []string {
+	names := make([]string, len(u.members))
+	for i, ac := range u.members {
+		names[i] = ac.Collection()
+	}
+	return names
+}
+
+// FindMatches searches text against every member and returns the matches in scan
+// order, each with Collection set to the member holding its keyword.
+//
+// opts applies as it does to AhoCorasick.FindMatches, with each member's matches
+// filtered on their own: Tags are checked against the member's tags, and
+// MatchKindLeftmostLongest picks non-overlapping matches per collection, so a
+// long keyword in one list never hides a short one in another. The result is
+// then exactly the members' own FindMatches results merged, but found in one scan.
+// ScanLimits.MaxMatches counts keywords as the merged automaton reports them, so a
+// keyword held by two members counts once.
+//
+// Every member reads under the same context, so a WithMinVersion floor reaches
+// all of them. Versions count per collection: a token from a write to one member
+// says nothing about the others, and at worst makes them refresh needlessly.
+func (u *Union) FindMatches(text string, opts *MatchOptions) ([]Match, error) {
+	return u.FindMatchesContext(u.members[0].ctx, text, opts)
+}
+
+// FindMatchesContext is FindMatches with an explicit context for cancellation.
+func (u *Union) FindMatchesContext(ctx context.Context, text string, opts *MatchOptions) ([]Match, error) {
+	if text == "" {
+		return []Match{}, nil
+	}
+
+	built, err := u.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var wantTags map[string]struct{}
+	var tags []keywordTags
+	if opts != nil && len(opts.Tags) > 0 {
+		normalized, err := normalizeTags(opts.Tags)
+		if err != nil {
+			return nil, err
+		}
+		wantTags = make(map[string]struct{}, len(normalized))
+		for _, tag := range normalized {
+			wantTags[tag] = struct{}{}
+		}
+		tags = make([]keywordTags, len(u.members))
+		for i, ac := range u.members {
+			if tags[i], err = ac.loadTags(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var matches []Match
+	collect := func(keyword string, start, end int) bool {
+		for _, i := range built.owners[keyword] {
+			if wantTags != nil && !tags[i].hasAny(keyword, wantTags) {
+				continue
+			}
+			if matches == nil {
+				matches = make([]Match, 0, matchResultHint)
+			}
+			matches = append(matches, Match{Keyword: keyword, Start: start, End: end, Collection: built.labels[i]})
+		}
+		return true
+	}
+	var norm string
+	var limitErr *ScanLimitError
+	if opts != nil && opts.Limits != nil {
+		norm, limitErr = scanTextLimited(ctx, built.engine, text, u.members[0].caseSensitive, opts.Limits, collect)
+	} else {
+		norm = normalizeText(text, u.members[0].caseSensitive)
+		built.engine.MatchString(norm, collect)
+	}
+	if matches == nil {
+		matches = []Match{}
+	}
+	if opts != nil {
+		if opts.WholeWord && len(matches) > 0 {
+			isWord := isWordRune
+			if opts.WordRune != nil {
+				isWord = opts.WordRune
+			}
+			matches = filterWholeWord(matches, []rune(norm), isWord)
+		}
+		if opts.Kind == MatchKindLeftmostLongest {
+			matches = leftmostLongestPerCollection(matches, built.labels)
+		}
+	}
+	if limitErr != nil {
+		limitErr.Matches = matches
+		return matches, limitErr
+	}
+	return matches, nil
+}
+
+// leftmostLongestPerCollection applies leftmostLongest to each collection's
+// matches on its own and merges the survivors by start, in member order among
+// matches at the same start.
+func leftmostLongestPerCollection(ms []Match, labels []string) []Match {
+	out := make([]Match, 0, len(ms))
+	for _, label := range labels {
+		var own []Match
+		for _, m := range ms {
+			if m.Collection == label {
+				own = append(own, m)
+			}
+		}
+		out = append(out, leftmostLongest(own)...)
+	}
+	// Stable, so matches at one start keep the member order they were appended in.
+	slices.SortStableFunc(out, func(a, b Match) int { return cmp.Compare(a.Start, b.Start) })
+	return out
+}
+
+// load returns the merged automaton, rebuilding it first when any member's engine
+// has changed since the last build. Member engines are loaded outside the lock so
+// a slow member read does not hold up searches that find nothing changed.
+func (u *Union) load(ctx context.Context) (*unionEngine, error) {
+	from := make([]*matchengine.Engine, len(u.members))
+	for i, ac := range u.members {
+		eng, err := ac.ops.loadEngine(ctx)
+		if err != nil {
+			return nil, err
+		}
+		from[i] = eng
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.built != nil && slices.Equal(u.built.from, from) {
+		return u.built, nil
+	}
+	built := &unionEngine{
+		from:   from,
+		labels: make([]string, len(u.members)),
+		owners: make(map[string][]int),
+	}
+	keywords := make(map[string]struct{})
+	for i, eng := range from {
+		built.labels[i] = u.members[i].Collection()
+		for _, kw := range eng.Keywords() {
+			keywords[kw] = struct{}{}
+			built.owners[kw] = append(built.owners[kw], i)
+		}
+	}
+	built.engine = matchengine.New(enginePreset(PresetBalanced))
+	built.engine.Build(keywords)
+	u.built = built
+	return built, nil
+}
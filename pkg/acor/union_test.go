@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestUnion_LabelsMatchesWithTheirCollection(t *testing.T) {
+	mr := miniredis.RunT(t)
+	en := createCollection(t, mr, "en", "he", "hers", "damn")
+	de := createCollection(t, mr, "de", "he", "mist")
+	u, err := NewUnion(en, de)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Collections(); !slices.Equal(got, []string{"en", "de"}) {
+		t.Errorf("Collections() = %v, want [en de]", got)
+	}
+
+	got, err := u.FindMatches("hers mist", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Match{
+		{Keyword: "he", Start: 0, End: 2, Collection: "en"},
+		{Keyword: "he", Start: 0, End: 2, Collection: "de"},
+		{Keyword: "hers", Start: 0, End: 4, Collection: "en"},
+		{Keyword: "mist", Start: 5, End: 9, Collection: "de"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindMatches = %v, want %v", got, want)
+	}
+
+	// Leftmost-longest runs per collection: en's "hers" does not hide de's "he".
+	got, err = u.FindMatches("hers", &MatchOptions{Kind: MatchKindLeftmostLongest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Match{
+		{Keyword: "hers", Start: 0, End: 4, Collection: "en"},
+		{Keyword: "he", Start: 0, End: 2, Collection: "de"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("leftmost-longest FindMatches = %v, want %v", got, want)
+	}
+
+	// A member's own search is unlabeled.
+	own, err := en.FindMatches("he", nil)
+	if err != nil || len(own) != 1 || own[0].Collection != "" {
+		t.Errorf("member FindMatches = %v, %v; want one unlabeled match", own, err)
+	}
+}
+
+func TestUnion_TagsFilterPerMember(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a := createCollection(t, mr, "a")
+	b := createCollection(t, mr, "b")
+	if _, err := a.AddWithTags("spam", "ads"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Add("spam"); err != nil {
+		t.Fatal(err)
+	}
+	u, err := NewUnion(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := u.FindMatches("spam", &MatchOptions{Tags: []string{"ads"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Match{{Keyword: "spam", Start: 0, End: 4, Collection: "a"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tag-filtered FindMatches = %v, want %v", got, want)
+	}
+}
+
+func TestUnion_RefreshesWhenAMemberChanges(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args AhoCorasickArgs
+	}{
+		{"v2", AhoCorasickArgs{}},
+		{"cached", AhoCorasickArgs{EnableCache: true}},
+		{"preset", AhoCorasickArgs{Preset: PresetBalanced}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			fixed := createCollection(t, mr, "fixed", "apple")
+			args := tc.args
+			args.Addr, args.Name = mr.Addr(), "live"
+			live, err := Create(&args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = live.Close() }()
+
+			u, err := NewUnion(fixed, live)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := u.FindMatches("apple pear", nil); err != nil || len(got) != 1 {
+				t.Fatalf("FindMatches before the write = %v, %v; want only apple", got, err)
+			}
+
+			// The write goes through another instance, so the member only learns of
+			// it the way it learns of any peer's write.
+			writer := createCollection(t, mr, "live")
+			if _, err := writer.Add("pear"); err != nil {
+				t.Fatal(err)
+			}
+			want := []Match{
+				{Keyword: "apple", Start: 0, End: 5, Collection: "fixed"},
+				{Keyword: "pear", Start: 6, End: 10, Collection: "live"},
+			}
+			var got []Match
+			ok := eventually(t, 2*time.Second, func() bool {
+				got, err = u.FindMatches("apple pear", nil)
+				return err == nil && reflect.DeepEqual(got, want)
+			})
+			if !ok {
+				t.Errorf("FindMatches after the write = %v, %v; want %v", got, err, want)
+			}
+		})
+	}
+}
+
+func TestNewUnion_Errors(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a := createCollection(t, mr, "a")
+	again := createCollection(t, mr, "a")
+	sensitive, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "s", CaseSensitive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = sensitive.Close() }()
+
+	for _, tc := range []struct {
+		name    string
+		members []*AhoCorasick
+	}{
+		{"none", nil},
+		{"nil member", []*AhoCorasick{a, nil}},
+		{"same collection twice", []*AhoCorasick{a, again}},
+		{"mixed case sensitivity", []*AhoCorasick{a, sensitive}},
+	} {
+		if _, err := NewUnion(tc.members...); !errors.Is(err, ErrInvalidUnion) {
+			t.Errorf("%s: NewUnion error = %v, want ErrInvalidUnion", tc.name, err)
+		}
+	}
+}
@@ -23,6 +23,7 @@
 // v1_fixture_test.go, so no production binary carries a way to write V1.
 type v1Operations struct {
 	storage         kvStorage
+	client          redis.UniversalClient
 	name            string
 	logger          Logger
 	caseSensitive   bool
@@ -47,7 +47,7 @@ func TestV2PublishInvalidate(t *testing.T) {
 	defer mr.Close()
 
 	cache := &trieCache{}
-	cache.set(map[string][]string{"a": {"a"}})
+	cache.set(map[string][]string{"a": {"a"}}, 0, nil)
 
 	client := newTestRedisClient(mr.Addr())
 	defer func() { _ = client.Close() }()
@@ -92,7 +92,7 @@ func TestV2FetchTrieData(t *testing.T) {
 		logger:  &testLogger{},
 	}
 
-	prefixes, outputs, err := ops.fetchTrieData(context.Background())
+	prefixes, outputs, _, _, err := ops.fetchTrieData(context.Background())
 	if err != nil {
 		t.Fatalf("fetchTrieData() error: %v", err)
 	}
@@ -198,7 +198,7 @@ func TestV2PublishInvalidateWithPublishError(t *testing.T) {
 	mr.Close()
 
 	cache := &trieCache{}
-	cache.set(map[string][]string{"a": {"a"}})
+	cache.set(map[string][]string{"a": {"a"}}, 0, nil)
 
 	ops := &v2Operations{
 		storage: newRedisStorage(newTestRedisClient("localhost:1")),
@@ -111,8 +111,9 @@ func TestV2FlushRedisError(t *testing.T) {
 	ac := setupV2WithError(t)
 	defer func() { _ = ac.redisClient.Close() }()
 
+	// Flush is one script, read-only check included.
 	err := ac.Flush()
-	assertRedisError(t, err, "TXPIPELINED")
+	assertRedisError(t, err, "EVAL")
 }
 
 func TestV2ErrorsAreUnwrappable(t *testing.T) {
@@ -576,7 +576,7 @@ func TestV2FetchTrieDataBadJSON(t *testing.T) {
 		logger:  &testLogger{},
 	}
 
-	_, _, err := ops.fetchTrieData(context.Background())
+	_, _, _, _, err := ops.fetchTrieData(context.Background())
 	if err == nil {
 		t.Fatal("expected error for bad JSON in prefixes")
 	}
@@ -605,7 +605,7 @@ func TestV2FetchTrieDataBadOutputsJSON(t *testing.T) {
 		logger:  &testLogger{},
 	}
 
-	_, _, err := ops.fetchTrieData(context.Background())
+	_, _, _, _, err := ops.fetchTrieData(context.Background())
 	if err == nil {
 		t.Fatal("expected error for bad JSON in outputs")
 	}
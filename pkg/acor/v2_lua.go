@@ -16,27 +16,102 @@
 // first: add rewrites the states it touched, remove replaces the whole set.
 // That is the clearOutputs flag.
 //
+// ARGV[7] and ARGV[8] are the trie's keyword expiries and tags, written alongside
+// the keywords they belong to; an empty object drops its field, so a collection
+// without a TTL'd or tagged keyword carries none.
+//
+// The third key is the collection's read-only marker (see readOnlyKey): while it
+// exists the script writes nothing and returns -1.
+//
+// The fourth is the keyword index (see indexKey). ARGV[9] and ARGV[10] are the
+// JSON arrays of keywords the write adds and removes, applied to the index when
+// the trie is marked indexed. Otherwise the index is rebuilt from the new
+// keyword list and the trie marked, which is how a collection written before
+// the index existed gets one.
+//
+// A fifth key, when passed, is the collection's history stream: the same change
+// set, with ARGV[11..13], is appended to it in the same atomic step, so a
+// recorded entry exists exactly when its write does.
+//
 // Precompiled with redis.NewScript so calls go out as EVALSHA.
 var v2WriteScript = redis.NewScript(`
 	local trieKey = KEYS[1]
 	local outputsKey = KEYS[2]
+	local indexKey = KEYS[4]
 	local oldVersion = ARGV[1]
 	local newVersion = ARGV[2]
 	local keywords = ARGV[3]
 	local prefixes = ARGV[4]
 	local outputsJson = ARGV[5]
 	local clearOutputs = ARGV[6] == '1'
+	local expiries = ARGV[7]
+	local tags = ARGV[8]
+
+	if redis.call('EXISTS', KEYS[3]) == 1 then
+		return -1
+	end
 
 	local currentVersion = redis.call('HGET', trieKey, 'version')
 	if currentVersion and currentVersion ~= oldVersion then
 		return 0
 	end
 
+	-- Decode before writing anything: a cjson error aborts the script without
+	-- rolling back the commands already run.
+	local outputs = cjson.decode(outputsJson)
+	local indexed = redis.call('HEXISTS', trieKey, 'indexed') == 1
+	local indexAdd, indexRemove = nil, {}
+	if indexed then
+		indexAdd = cjson.decode(ARGV[9])
+		indexRemove = cjson.decode(ARGV[10])
+	else
+		indexAdd = cjson.decode(keywords)
+	end
+
+	-- Record first: XADD is the one call here that can fail on a well-formed
+	-- request (a non-stream value at the key), and nothing is written yet.
+	local historyKey = KEYS[5]
+	if historyKey then
+		redis.call('XADD', historyKey, 'MAXLEN', ARGV[11], '*',
+			'op', ARGV[12], 'version', newVersion, 'prev', oldVersion,
+			'added', ARGV[9], 'removed', ARGV[10], 'writer', ARGV[13])
+	end
+
 	redis.call('HSET', trieKey, 'keywords', keywords, 'prefixes', prefixes, 'version', newVersion)
+	if expiries == '' or expiries == '{}' then
+		redis.call('HDEL', trieKey, 'expiries')
+	else
+		redis.call('HSET', trieKey, 'expiries', expiries)
+	end
+	if tags == '' or tags == '{}' then
+		redis.call('HDEL', trieKey, 'tags')
+	else
+		redis.call('HSET', trieKey, 'tags', tags)
+	end
 
-	-- Decode before the DEL: a cjson error aborts the script without rolling
-	-- back the commands already run, so nothing destructive may precede it.
-	local outputs = cjson.decode(outputsJson)
+	-- In batches, since unpack is bounded by the Lua stack.
+	local function apply(cmd, members)
+		local args = {}
+		for _, kw in ipairs(members) do
+			if cmd == 'ZADD' then
+				args[#args + 1] = 0
+			end
+			args[#args + 1] = kw
+			if #args >= 1000 then
+				redis.call(cmd, indexKey, unpack(args))
+				args = {}
+			end
+		end
+		if #args > 0 then
+			redis.call(cmd, indexKey, unpack(args))
+		end
+	end
+	if not indexed then
+		redis.call('DEL', indexKey)
+		redis.call('HSET', trieKey, 'indexed', '1')
+	end
+	apply('ZREM', indexRemove)
+	apply('ZADD', indexAdd)
 
 	if clearOutputs then
 		redis.call('DEL', outputsKey)
@@ -55,6 +130,11 @@
 type v2ScriptArgs struct {
 	TrieKey    string
 	OutputsKey string
+	// ReadOnlyKey is the collection's read-only marker, checked before anything
+	// is written.
+	ReadOnlyKey string
+	// IndexKey is the collection's keyword index, kept in step with Keywords.
+	IndexKey   string
 	OldVersion int64
 	NewVersion int64
 	Keywords   string // JSON array of keywords
@@ -63,16 +143,72 @@ type v2ScriptArgs struct {
 	// ClearOutputs drops the outputs hash before writing, for removes where a
 	// state's output list may have shrunk to nothing.
 	ClearOutputs bool
+	// Expiries is the JSON object of keyword expiry times to store with the trie;
+	// empty or "{}" stores none.
+	Expiries string
+	// Tags is the JSON object of keyword tags to store with the trie; empty or
+	// "{}" stores none.
+	Tags    string
+	Added   string // JSON array of keywords the write added
+	Removed string // JSON array of keywords the write removed
+	// History is the entry to append to the history stream, or nil to record
+	// nothing.
+	History *v2HistoryArgs
+}
+
+// v2HistoryArgs is a history entry as v2WriteScript appends it to a stream; its
+// keyword lists are the write's Added and Removed.
+type v2HistoryArgs struct {
+	Key    string
+	MaxLen int64
+	Op     string
+	Writer string
 }
 
 // runV2Script evaluates v2WriteScript and returns its reply: 1 when the write
-// committed, 0 when the optimistic-lock version check failed.
+// committed, 0 when the optimistic-lock version check failed, and -1 when the
+// collection is read-only.
 //
 // ClearOutputs goes out as a bool: go-redis encodes it as the "1"/"0" the
 // script compares against, so there is no flag string to keep in sync.
 func runV2Script(ctx context.Context, client redis.UniversalClient, args *v2ScriptArgs) (int64, error) {
-	return v2WriteScript.Run(ctx, client,
-		[]string{args.TrieKey, args.OutputsKey},
-		args.OldVersion, args.NewVersion, args.Keywords,
-		args.Prefixes, args.Outputs, args.ClearOutputs).Int64()
+	keys := []string{args.TrieKey, args.OutputsKey, args.ReadOnlyKey, args.IndexKey}
+	argv := []interface{}{args.OldVersion, args.NewVersion, args.Keywords,
+		args.Prefixes, args.Outputs, args.ClearOutputs, args.Expiries, args.Tags,
+		args.Added, args.Removed}
+	if h := args.History; h != nil {
+		keys = append(keys, h.Key)
+		argv = append(argv, h.MaxLen, h.Op, h.Writer)
+	}
+	return v2WriteScript.Run(ctx, client, keys, argv...).Int64()
 }
+
+// v2FlushScript is flushV2Keys: it empties the collection and, given an op,
+// appends the keywords it held as one history entry, reading them inside the
+// script so no write can land between the read and the reset.
+//
+// KEYS are the trie, outputs, nodes, weights, history, read-only, and index
+// keys; ARGV the new version, history MaxLen, op, writer, and the empty trie's
+// keywords and prefixes. An empty op records no history. The emptied index
+// matches the emptied trie, which is marked indexed. Like v2WriteScript it
+// returns -1 without writing while the collection is read-only.
+var v2FlushScript = redis.NewScript(`
+	local trieKey = KEYS[1]
+	local historyKey = KEYS[5]
+	local newVersion = ARGV[1]
+
+	if redis.call('EXISTS', KEYS[6]) == 1 then
+		return -1
+	end
+
+	if ARGV[3] ~= '' then
+		local current = redis.call('HMGET', trieKey, 'keywords', 'version')
+		redis.call('XADD', historyKey, 'MAXLEN', ARGV[2], '*',
+			'op', ARGV[3], 'version', newVersion, 'prev', current[2] or '0',
+			'added', '[]', 'removed', current[1] or '[]', 'writer', ARGV[4])
+	end
+
+	redis.call('DEL', trieKey, KEYS[2], KEYS[3], KEYS[4], KEYS[7])
+	redis.call('HSET', trieKey, 'keywords', ARGV[5], 'prefixes', ARGV[6], 'version', newVersion, 'indexed', '1')
+	return 1
+`)
@@ -48,7 +48,7 @@ func TestV2WriteScriptClearOutputs(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			args, err := marshalTrieArgs("test", snap, map[string]string{"42": `["he"]`}, snap.Version+1, tc.clearOutputs)
+			args, err := marshalTrieArgs("test", snap, map[string]string{"42": `["he"]`}, snap.Version+1, tc.clearOutputs, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -106,7 +106,7 @@ func TestLuaScriptInt64SafetyAdd(t *testing.T) {
 	}
 	snap.Version = largeVersionAbove2to53
 
-	args, err := marshalTrieArgs("test", snap, map[string]string{}, largeVersionAbove2to53+1, false)
+	args, err := marshalTrieArgs("test", snap, map[string]string{}, largeVersionAbove2to53+1, false, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -144,7 +144,7 @@ func TestLuaScriptInt64SafetyRemove(t *testing.T) {
 	}
 	snap.Version = largeVersionAbove2to53
 
-	args, err := marshalTrieArgs("test", snap, map[string]string{}, largeVersionAbove2to53+1, true)
+	args, err := marshalTrieArgs("test", snap, map[string]string{}, largeVersionAbove2to53+1, true, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -32,6 +32,7 @@ type v2Operations struct {
 	caseSensitive bool
 	engines       engineMemo
 	stats         *cacheStats
+	history       *historyLog // nil unless AhoCorasickArgs.History is set
 }
 
 // --- operations interface methods ---
@@ -93,7 +94,7 @@ func (o *v2Operations) remove(ctx context.Context, keyword string) (int, error)
 }
 
 func (o *v2Operations) flush(ctx context.Context) error {
-	if err := flushV2Keys(ctx, o.storage, o.name); err != nil {
+	if _, err := flushV2Keys(ctx, o.client, o.name, o.history); err != nil {
 		return err
 	}
 
@@ -122,9 +123,19 @@ func (o *v2Operations) info(ctx context.Context) (*AhoCorasickInfo, error) {
 		}
 	}
 
+	expiries, err := parseExpiries(result[fieldExpiries])
+	if err != nil {
+		return nil, err
+	}
+	tags, err := parseTags(result[fieldTags])
+	if err != nil {
+		return nil, err
+	}
+
 	return &AhoCorasickInfo{
 		Keywords: len(keywords),
 		Nodes:    len(prefixes),
+		Tags:     tags.counts(expiries, time.Now()),
 	}, nil
 }
 
@@ -149,9 +160,15 @@ func (o *v2Operations) suggest(ctx context.Context, input string) ([]string, err
 		}
 	}
 
+	expiries, err := parseExpiries(result[fieldExpiries])
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
 	results := make([]string, 0, len(keywords))
 	for _, kw := range keywords {
-		if strings.HasPrefix(kw, input) {
+		if strings.HasPrefix(kw, input) && !expiries.expired(kw, now) {
 			results = append(results, kw)
 		}
 	}
@@ -174,29 +191,40 @@ func (o *v2Operations) suggestIndex(ctx context.Context, input string) (map[stri
 
 // --- cache helpers ---
 
-// fetchTrieData loads trie prefixes and outputs from storage using a pipeline.
-func (o *v2Operations) fetchTrieData(ctx context.Context) (prefixes []string, outputs map[string][]string, err error) {
+// fetchTrieData loads trie prefixes, outputs, version, and expiries from storage
+// using a pipeline. The pipeline is not a transaction, so a write can land between
+// its two reads; the trie hash is read first, which keeps the version no newer than
+// the outputs and errs toward an extra refresh, never a missed one.
+func (o *v2Operations) fetchTrieData(ctx context.Context) (prefixes []string, outputs map[string][]string,
+	version int64, expiries keywordExpiries, err error) {
 	pipe := o.storage.Pipeline()
 	trieResult := pipe.HGetAll(ctx, trieKey(o.name))
 	outputsResult := pipe.HGetAll(ctx, outputsKey(o.name))
 	if err := pipe.Exec(ctx); err != nil {
-		return nil, nil, newRedisError("PIPELINE", trieKey(o.name), err)
+		return nil, nil, 0, nil, newRedisError("PIPELINE", trieKey(o.name), err)
 	}
 
 	trieData := trieResult.Val()
 	if data, ok := trieData[fieldPrefixes]; ok {
 		if unmarshalErr := json.Unmarshal([]byte(data), &prefixes); unmarshalErr != nil {
-			return nil, nil, newOperationError("unmarshal", SchemaV2, unmarshalErr)
+			return nil, nil, 0, nil, newOperationError("unmarshal", SchemaV2, unmarshalErr)
 		}
 	}
+	if data, ok := trieData[fieldVersion]; ok {
+		// Unparseable reads as zero, as in readTrieSnapshot.
+		_ = json.Unmarshal([]byte(data), &version)
+	}
+	if expiries, err = parseExpiries(trieData[fieldExpiries]); err != nil {
+		return nil, nil, 0, nil, err
+	}
 
 	parsed, parseErr := parseOutputs(outputsResult.Val())
 	if parseErr != nil {
-		return nil, nil, parseErr
+		return nil, nil, 0, nil, parseErr
 	}
 	outputs = parsed
 
-	return prefixes, outputs, nil
+	return prefixes, outputs, version, expiries, nil
 }
 
 // parseOutputs unmarshals the per-state JSON arrays of the V2 outputs hash.
@@ -212,29 +240,32 @@ func parseOutputs(raw map[string]string) (map[string][]string, error) {
 	return outputs, nil
 }
 
-// fetchRawOutputs reads just the outputs hash, unparsed.
+// fetchRawOutputs reads the outputs hash and the trie's expiries, both unparsed.
 //
-// The engine is built from the union of the outputs values alone, so the trie
-// hash that fetchTrieData also pipelines is dead weight on the read path. This
-// stays one round trip while transferring and parsing less.
-func (o *v2Operations) fetchRawOutputs(ctx context.Context) (map[string]string, error) {
-	raw, err := o.storage.HGetAll(ctx, outputsKey(o.name))
-	if err != nil {
-		return nil, newRedisError("HGETALL", outputsKey(o.name), err)
+// The engine is built from the union of the outputs values alone, less any
+// expired keyword, so the rest of the trie hash that fetchTrieData also pipelines
+// is dead weight on the read path. This stays one round trip while transferring
+// and parsing less.
+func (o *v2Operations) fetchRawOutputs(ctx context.Context) (raw map[string]string, expiries string, err error) {
+	pipe := o.storage.Pipeline()
+	outputsResult := pipe.HGetAll(ctx, outputsKey(o.name))
+	expiriesResult := pipe.HGet(ctx, trieKey(o.name), fieldExpiries)
+	if err := pipe.Exec(ctx); err != nil {
+		return nil, "", newRedisError("PIPELINE", outputsKey(o.name), err)
 	}
-	return raw, nil
+	return outputsResult.Val(), expiriesResult.Val(), nil
 }
 
 // loadCache fetches trie data and populates the cache.
 func (o *v2Operations) loadCache(ctx context.Context) error {
-	_, outputs, err := o.fetchTrieData(ctx)
+	_, outputs, version, expiries, err := o.fetchTrieData(ctx)
 	if err != nil {
 		return err
 	}
 	// Timed around set alone, which is where the automaton is built. fetchTrieData
 	// above is Redis I/O, and folding it in would report the network as build time.
 	start := time.Now()
-	o.cache.set(outputs)
+	o.cache.set(outputs, version, expiries)
 	o.stats.recordRebuild(time.Since(start))
 	return nil
 }
@@ -257,19 +288,28 @@ func (o *v2Operations) loadEngine(ctx context.Context) (*matchengine.Engine, err
 		// payload: repeating the unmarshal and automaton build over identical
 		// bytes is what made uncached V2 Find slower than V1, which memoizes
 		// its own engine the same way.
-		raw, err := o.fetchRawOutputs(ctx)
+		raw, rawExpiries, err := o.fetchRawOutputs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		expiries, err := parseExpiries(rawExpiries)
 		if err != nil {
 			return nil, err
 		}
-		return o.engines.engineFor(digestRawOutputs(raw), func() (*matchengine.Engine, error) {
+		now := time.Now()
+		digest := digestRawOutputs(raw) + digestExpiries(rawExpiries, expiries, now)
+		return o.engines.engineFor(digest, func() (*matchengine.Engine, error) {
 			outputs, parseErr := parseOutputs(raw)
 			if parseErr != nil {
 				return nil, parseErr
 			}
-			return buildEngineFromOutputs(outputs), nil
+			return buildEngineFromOutputs(outputs, expiries, now), nil
 		})
 	}
 
+	if err := o.catchUpMinVersion(ctx); err != nil {
+		return nil, err
+	}
 	if engine, valid := o.cache.getEngine(); valid {
 		o.stats.hit()
 		return engine, nil
@@ -299,6 +339,24 @@ func (o *v2Operations) loadEngine(ctx context.Context) (*matchengine.Engine, err
 	return engine, nil
 }
 
+// catchUpMinVersion invalidates the cache when ctx asks for a version it may not
+// include yet (see WithMinVersion), so loadEngine reloads it now rather than when
+// the invalidation arrives. An invalid cache reloads anyway and needs no check.
+func (o *v2Operations) catchUpMinVersion(ctx context.Context) error {
+	version, valid := o.cache.loadedVersion()
+	if !valid {
+		return nil
+	}
+	behind, err := behindMinVersion(ctx, o.storage, o.name, version)
+	if err != nil {
+		return err
+	}
+	if behind {
+		o.cache.invalidate()
+	}
+	return nil
+}
+
 // --- publishInvalidate ---
 
 // publishInvalidate invalidates the local cache and publishes an invalidation
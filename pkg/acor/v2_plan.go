@@ -10,28 +10,20 @@
 // redisBackedAC (preset mode, local engine) — plan identically and differ only
 // in what they do after the Lua script commits, so the planning lives here once.
 //
-// The *Many variants are the primitives and the single-keyword forms wrap them.
+// The *Many variants are the primitives and planRemove wraps one of them; adds go
+// through planAddLive (expiry.go), which also reads the clock.
 // Planning a batch in one pass is what keeps AddMany linear: every plan call
 // rebuilds the keyword and prefix sets and recomputes outputs for every existing
 // prefix, so doing that per keyword made a batch quadratic.
 
-// planAdd computes the trie mutation for inserting keyword. On success snap is
-// updated in place and outputs maps each state whose output list changed to its
-// new value. changed is false when the keyword is already present, in which case
-// snap is untouched and no write is needed.
-func planAdd(snap *trieSnapshot, keyword string) (outputs map[string][]string, changed bool) {
-	outputs, added := planAddMany(snap, []string{keyword})
-	return outputs, len(added) > 0
-}
-
 // planAddMany computes one trie mutation covering every keyword in keywords. It
 // returns the states whose output lists changed and the keywords that were
 // actually new; added is empty (and outputs nil) when every keyword was already
 // present, in which case snap is untouched.
 //
-// The result is identical to applying planAdd to each keyword in turn, since the
-// single-keyword path also recomputes every prefix against the final keyword set.
-// Folding N passes into one changes cost, not outcome.
+// The result is identical to planning each keyword in turn, since every prefix is
+// recomputed against the final keyword set either way. Folding N passes into one
+// changes cost, not outcome.
 func planAddMany(snap *trieSnapshot, keywords []string) (outputs map[string][]string, added []string) {
 	keywordSet := make(map[string]struct{}, len(snap.Keywords)+len(keywords))
 	for _, kw := range snap.Keywords {
@@ -108,7 +100,8 @@ func planRemove(snap *trieSnapshot, keyword string) (outputs map[string][]string
 
 // planRemoveMany computes one trie mutation deleting every keyword in keywords,
 // returning the full replacement output set and the keywords actually present.
-// removed is empty (and outputs nil) when none of them were.
+// removed is empty (and outputs nil) when none of them were. A removed keyword's
+// expiry goes with it.
 func planRemoveMany(snap *trieSnapshot, keywords []string) (outputs map[string][]string, removed []string) {
 	doomed := make(map[string]struct{}, len(keywords))
 	for _, kw := range keywords {
@@ -161,6 +154,16 @@ func planRemoveMany(snap *trieSnapshot, keywords []string) (outputs map[string][
 		}
 	}
 
+	for _, kw := range removed {
+		if _, ok := snap.Expiries[kw]; ok {
+			delete(snap.Expiries, kw)
+			snap.attrsChanged = true
+		}
+		if _, ok := snap.Tags[kw]; ok {
+			delete(snap.Tags, kw)
+			snap.attrsChanged = true
+		}
+	}
 	snap.Keywords = newKeywords
 	snap.Prefixes = newPrefixes
 	return outputs, removed
@@ -51,6 +51,13 @@ type trieSnapshot struct {
 	Keywords []string
 	Prefixes []string
 	Version  int64
+	// Expiries holds the expiry of each keyword added with a TTL; see expiry.go.
+	Expiries keywordExpiries
+	// Tags holds the tags of each tagged keyword; see tags.go.
+	Tags keywordTags
+	// attrsChanged marks a plan that moved an expiry or a tag, which needs a write
+	// even when no keyword was added or removed.
+	attrsChanged bool
 }
 
 // readTrieSnapshot loads and deserializes the trie hash from Redis.
@@ -77,18 +84,28 @@ func readTrieSnapshot(ctx context.Context, storage kvStorage, name string) (*tri
 			snap.Version = 0
 		}
 	}
+	if snap.Expiries, err = parseExpiries(trieData[fieldExpiries]); err != nil {
+		return nil, err
+	}
+	if snap.Tags, err = parseTags(trieData[fieldTags]); err != nil {
+		return nil, err
+	}
 
+	// A write that finds nothing to change leaves this as its token's version.
+	RecordVersion(ctx, snap.Version)
 	return snap, nil
 }
 
-// marshalTrieArgs serializes a snapshot and its output states into the complete
-// script arguments for collection name: nothing is left for the caller to patch
-// in afterwards.
+// marshalTrieArgs serializes a snapshot, its output states, and the write's change
+// set (nil when no keyword changed) into the complete script arguments for
+// collection name: nothing is left for the caller to patch in afterwards.
 func marshalTrieArgs(name string, snap *trieSnapshot, outputs map[string]string,
-	newVersion int64, clearOutputs bool) (*v2ScriptArgs, error) {
+	newVersion int64, clearOutputs bool, change *historyChange) (*v2ScriptArgs, error) {
 	args := &v2ScriptArgs{
 		TrieKey:      trieKey(name),
 		OutputsKey:   outputsKey(name),
+		ReadOnlyKey:  readOnlyKey(name),
+		IndexKey:     indexKey(name),
 		OldVersion:   snap.Version,
 		NewVersion:   newVersion,
 		ClearOutputs: clearOutputs,
@@ -103,6 +120,24 @@ func marshalTrieArgs(name string, snap *trieSnapshot, outputs map[string]string,
 	if args.Outputs, err = toJSON(outputs); err != nil {
 		return nil, newOperationError("marshal", SchemaV2, err)
 	}
+	if args.Expiries, err = snap.Expiries.encode(); err != nil {
+		return nil, newOperationError("marshal", SchemaV2, err)
+	}
+	if args.Tags, err = snap.Tags.encode(); err != nil {
+		return nil, newOperationError("marshal", SchemaV2, err)
+	}
+	if change == nil {
+		change = &historyChange{}
+	}
+	if args.Added, err = toJSON(nonNil(change.added)); err != nil {
+		return nil, newOperationError("marshal", SchemaV2, err)
+	}
+	if args.Removed, err = toJSON(nonNil(change.removed)); err != nil {
+		return nil, newOperationError("marshal", SchemaV2, err)
+	}
+	if change.log != nil {
+		args.History = change.scriptArgs(name)
+	}
 	return args, nil
 }
 
@@ -115,11 +150,12 @@ func toJSON(v any) (string, error) {
 }
 
 // commitV2Write stamps a fresh version onto a planned mutation and commits it
-// through script under optimistic locking. It returns the version it wrote, or
-// ErrConcurrencyConflict when another writer won the race and the caller should
-// re-read the snapshot and retry.
+// through script under optimistic locking, applying change to the keyword index
+// and recording it in the collection's history when it has a log. It returns the
+// version it wrote, or ErrConcurrencyConflict when another writer won the race
+// and the caller should re-read the snapshot and retry.
 func commitV2Write(ctx context.Context, client redis.UniversalClient, name string,
-	snap *trieSnapshot, outputs map[string][]string, clearOutputs bool) (int64, error) {
+	snap *trieSnapshot, outputs map[string][]string, clearOutputs bool, change *historyChange) (int64, error) {
 	newVersion, err := generateVersion()
 	if err != nil {
 		return 0, err
@@ -134,7 +170,7 @@ func commitV2Write(ctx context.Context, client redis.UniversalClient, name strin
 		encoded[state] = jsonOuts
 	}
 
-	args, err := marshalTrieArgs(name, snap, encoded, newVersion, clearOutputs)
+	args, err := marshalTrieArgs(name, snap, encoded, newVersion, clearOutputs, change)
 	if err != nil {
 		return 0, err
 	}
@@ -143,35 +179,50 @@ func commitV2Write(ctx context.Context, client redis.UniversalClient, name strin
 	if err != nil {
 		return 0, newRedisError("EVAL", trieKey(name), err)
 	}
-	if result == 0 {
+	switch result {
+	case 0:
 		return 0, ErrConcurrencyConflict
+	case -1:
+		return 0, ErrReadOnly
 	}
+	RecordVersion(ctx, newVersion)
 	return newVersion, nil
 }
 
-// flushV2Keys resets a collection's V2 keys to empty: the outputs and nodes
-// hashes are dropped and the trie hash is replaced with emptyTrieFields.
+// flushV2Keys resets a collection's V2 keys to empty: the outputs, nodes, and
+// weights hashes are dropped and the trie hash is replaced with emptyTrieFields.
+// It returns the version the empty trie was stamped with.
 //
 // The trie key is deleted rather than only overwritten, so fields no longer
 // written by this version (the pre-v0.11 "suffixes") don't survive a flush. The
 // one thing that costs is the key's TTL, which acor never sets itself; a caller
 // that expires collections externally has to re-apply it after Flush.
 //
+// The reset runs as v2FlushScript, which checks the read-only marker in the
+// same step, leaving a read-only collection alone with ErrReadOnly. With
+// history on, it also records what was flushed.
+//
 // Shared by both V2 write paths, which differ only in the local state they reset
 // afterwards.
-func flushV2Keys(ctx context.Context, storage kvStorage, name string) error {
+func flushV2Keys(ctx context.Context, client redis.UniversalClient, name string, history *historyLog) (int64, error) {
 	tKey := trieKey(name)
-	err := storage.TxPipelined(ctx, func(pipe pipeliner) error {
-		// nodesKey is only written during migration; including it here ensures a clean state.
-		if err := pipe.Del(ctx, outputsKey(name), nodesKey(name), tKey); err != nil {
-			return err
-		}
-		return pipe.HSet(ctx, tKey, emptyTrieFields())
-	})
+	version := time.Now().UnixNano()
+	var maxEntries int64
+	var op, writer string
+	if history != nil {
+		maxEntries, op, writer = history.maxEntries, historyOpFlush, history.writer
+	}
+	result, err := v2FlushScript.Run(ctx, client,
+		[]string{tKey, outputsKey(name), nodesKey(name), weightsKey(name), historyKey(name), readOnlyKey(name), indexKey(name)},
+		version, maxEntries, op, writer, emptyKeywordsJSON, emptyStringArrayJSON).Int64()
 	if err != nil {
-		return newRedisError("TXPIPELINED", tKey, err)
+		return 0, newRedisError("EVAL", tKey, err)
 	}
-	return nil
+	if result == -1 {
+		return 0, ErrReadOnly
+	}
+	RecordVersion(ctx, version)
+	return version, nil
 }
 
 // retryOnConflict runs attempt until it stops reporting a lost optimistic-lock
@@ -217,12 +268,13 @@ func (o *v2Operations) tryAddV2(ctx context.Context, keyword string) (int, error
 		return 0, err
 	}
 
-	outputs, changed := planAdd(snap, keyword)
-	if !changed {
+	outputs, added := planAddLive(snap, []string{keyword})
+	if len(added) == 0 {
 		return 0, nil
 	}
 
-	if _, err := commitV2Write(ctx, o.client, o.name, snap, outputs, false); err != nil {
+	change := o.history.change(historyOpAdd, added, nil)
+	if _, err := commitV2Write(ctx, o.client, o.name, snap, outputs, false, change); err != nil {
 		return 0, err
 	}
 
@@ -241,7 +293,8 @@ func (o *v2Operations) tryRemoveV2(ctx context.Context, keyword string) (int, er
 		return 0, nil
 	}
 
-	if _, err := commitV2Write(ctx, o.client, o.name, snap, outputs, true); err != nil {
+	change := o.history.change(historyOpRemove, nil, []string{keyword})
+	if _, err := commitV2Write(ctx, o.client, o.name, snap, outputs, true, change); err != nil {
 		return 0, err
 	}
 
@@ -69,7 +69,7 @@ func TestLuaVersionComparisonAbove2to53(t *testing.T) {
 
 	// Build args with the matching large oldVersion — should succeed
 	snap.Version = largeOldVersion
-	args, err := marshalTrieArgs("test", snap, map[string]string{}, largeNewVersion, false)
+	args, err := marshalTrieArgs("test", snap, map[string]string{}, largeNewVersion, false, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -84,7 +84,7 @@ func TestLuaVersionComparisonAbove2to53(t *testing.T) {
 
 	// Now try with oldVersion that no longer matches — should detect conflict
 	snap.Version = largeOldVersion // trie now has largeNewVersion
-	args2, err := marshalTrieArgs("test", snap, map[string]string{}, largeNewVersion+1, false)
+	args2, err := marshalTrieArgs("test", snap, map[string]string{}, largeNewVersion+1, false, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -174,7 +174,7 @@ func TestLuaVersionStringComparison(t *testing.T) {
 
 			newVersion := tt.oldVersion + 1
 
-			args, err := marshalTrieArgs("test", snap, map[string]string{}, newVersion, false)
+			args, err := marshalTrieArgs("test", snap, map[string]string{}, newVersion, false, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -225,7 +225,7 @@ func TestLuaVersionRemoveScriptPrecision(t *testing.T) {
 	snap.Version = largeVersion
 
 	newVersion := largeVersion + 1
-	args, err := marshalTrieArgs("test", snap, map[string]string{}, newVersion, true)
+	args, err := marshalTrieArgs("test", snap, map[string]string{}, newVersion, true, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -239,7 +239,7 @@ func TestLuaVersionRemoveScriptPrecision(t *testing.T) {
 	}
 
 	// Stale version should be rejected
-	args2, err := marshalTrieArgs("test", snap, map[string]string{}, newVersion+1, true)
+	args2, err := marshalTrieArgs("test", snap, map[string]string{}, newVersion+1, true, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Read-your-writes across instances: a write records the version it committed in
+// a VersionToken, and a read given that version through WithMinVersion makes sure
+// the local state it answers from is no older. Redis itself is never behind a
+// committed write, so only the two modes that answer from local state — EnableCache
+// and Preset — have anything to check. Uncached V2 reads Redis on every call and
+// V1 takes no writes.
+//
+// Versions are not ordered: they carry random high bits (see generateVersion),
+// so "at least version v" cannot be a numeric comparison. Local state at exactly v
+// is enough; otherwise one HGET of the stored version settles it, since local
+// state that matches Redis already includes every committed write.
+
+type versionTokenKey struct{}
+
+type minVersionKey struct{}
+
+// VersionToken captures the collection version seen by the operations run under
+// the context WithVersionToken returned: the version a write committed, or for a
+// write that changed nothing, the version it found. Pass it to WithMinVersion on
+// any instance to read at least that state.
+//
+// A token is safe for concurrent use but holds only the latest version recorded,
+// so give each request its own.
+type VersionToken struct {
+	version atomic.Int64
+}
+
+// Version returns the latest version recorded, or zero when none was.
+func (t *VersionToken) Version() int64 {
+	return t.version.Load()
+}
+
+// WithVersionToken returns a context whose writes record their version in the
+// returned token.
+func WithVersionToken(ctx context.Context) (context.Context, *VersionToken) {
+	token := &VersionToken{}
+	return context.WithValue(ctx, versionTokenKey{}, token), token
+}
+
+// RecordVersion stores version in the VersionToken ctx carries, if any. Every
+// write in this package records its own version; RecordVersion is for code that
+// commits elsewhere on the caller's behalf, such as a client of a remote server
+// relaying the version in its response.
+func RecordVersion(ctx context.Context, version int64) {
+	if token, ok := ctx.Value(versionTokenKey{}).(*VersionToken); ok && version != 0 {
+		token.version.Store(version)
+	}
+}
+
+// WithMinVersion returns a context under which reads answer from state at least
+// as new as version, typically a VersionToken's from a write made through another
+// instance. A cached or preset instance whose local state might be older refreshes
+// it from Redis first, so the read blocks for that round trip instead of missing
+// the write; a failed refresh fails the read. Zero sets no floor.
+func WithMinVersion(ctx context.Context, version int64) context.Context {
+	return context.WithValue(ctx, minVersionKey{}, version)
+}
+
+// MinVersion returns the floor WithMinVersion set on ctx, or zero.
+func MinVersion(ctx context.Context) int64 {
+	version, _ := ctx.Value(minVersionKey{}).(int64)
+	return version
+}
+
+// readStoredVersion fetches just the version field of name's trie hash, so
+// checking freshness does not transfer the keyword list. A missing collection
+// reads as version zero, as readTrieSnapshot reports it. A version field that
+// does not parse is an *OperationError: read as zero, it would satisfy any
+// floor.
+func readStoredVersion(ctx context.Context, storage kvStorage, name string) (int64, error) {
+	raw, err := storage.HGet(ctx, trieKey(name), fieldVersion)
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, newRedisError("HGET", trieKey(name), err)
+	}
+	var version int64
+	if err := json.Unmarshal([]byte(raw), &version); err != nil {
+		return 0, newOperationError("unmarshal", SchemaV2, err)
+	}
+	return version, nil
+}
+
+// behindMinVersion reports whether local state at version local may predate the
+// floor ctx sets, costing a round trip only when local is not the floor itself.
+func behindMinVersion(ctx context.Context, storage kvStorage, name string, local int64) (bool, error) {
+	floor := MinVersion(ctx)
+	if floor == 0 || floor == local {
+		return false, nil
+	}
+	stored, err := readStoredVersion(ctx, storage, name)
+	if err != nil {
+		return false, err
+	}
+	return stored != local, nil
+}
@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestVersionToken_RecordsWrites(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac := createCollection(t, mr, "c", "apple")
+	current := func() int64 {
+		t.Helper()
+		v, err := ac.Version()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	for _, tc := range []struct {
+		name  string
+		write func(ctx context.Context) error
+	}{
+		{"add", func(ctx context.Context) error { _, err := ac.AddContext(ctx, "banana"); return err }},
+		{"no-op add", func(ctx context.Context) error { _, err := ac.AddContext(ctx, "banana"); return err }},
+		{"remove", func(ctx context.Context) error { _, err := ac.RemoveContext(ctx, "apple"); return err }},
+		{"flush", func(ctx context.Context) error { return ac.FlushContext(ctx) }},
+	} {
+		ctx, token := WithVersionToken(context.Background())
+		if err := tc.write(ctx); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got, want := token.Version(), current(); got == 0 || got != want {
+			t.Errorf("%s: token version = %d, want the collection's %d", tc.name, got, want)
+		}
+	}
+
+	// Outside a token's context there is nowhere to record, and nothing breaks.
+	RecordVersion(context.Background(), 42)
+	if got := MinVersion(WithMinVersion(context.Background(), 42)); got != 42 {
+		t.Errorf("MinVersion = %d, want 42", got)
+	}
+}
+
+// closeInvalidations stops ac receiving Pub/Sub invalidations, so a peer's write
+// stays invisible to its local state until something else refreshes it.
+func closeInvalidations(t *testing.T, ac *AhoCorasick) {
+	t.Helper()
+	sub := ac.pubsub
+	if rb, ok := ac.ops.(*redisBackedAC); ok {
+		sub = rb.pubsub
+	}
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMinVersion_CatchesUpLaggingReader(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args AhoCorasickArgs
+	}{
+		{"cached", AhoCorasickArgs{EnableCache: true}},
+		{"preset", AhoCorasickArgs{Preset: PresetBalanced}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			writer := createCollection(t, mr, "c")
+			args := tc.args
+			// No retries: the last check below expects the dead server to fail fast.
+			args.Addr, args.Name, args.MaxRetries = mr.Addr(), "c", -1
+			reader, err := Create(&args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = reader.Close() }()
+			if got, err := reader.Find("apple pie"); err != nil || len(got) != 0 {
+				t.Fatalf("warm-up Find = %v, %v", got, err)
+			}
+			closeInvalidations(t, reader)
+
+			ctx, token := WithVersionToken(context.Background())
+			if _, err := writer.AddContext(ctx, "apple"); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := reader.Find("apple pie"); len(got) != 0 {
+				t.Fatalf("lagging reader already sees %v; the test needs it stale", got)
+			}
+
+			readCtx := WithMinVersion(context.Background(), token.Version())
+			got, err := reader.FindContext(readCtx, "apple pie")
+			if err != nil || !slices.Equal(got, []string{"apple"}) {
+				t.Errorf("Find at the write's version = %v, %v; want [apple]", got, err)
+			}
+
+			// Once caught up, the floor is the local version and costs no check, so
+			// it keeps working with Redis gone.
+			mr.Close()
+			if got, err := reader.FindContext(readCtx, "apple pie"); err != nil || len(got) != 1 {
+				t.Errorf("Find at the reached version without Redis = %v, %v", got, err)
+			}
+			if _, err := reader.FindContext(WithMinVersion(context.Background(), 1), "apple"); err == nil {
+				t.Error("Find at an unreached version without Redis succeeded, want the refresh error")
+			}
+		})
+	}
+}
+
+func TestMinVersion_CorruptStoredVersion(t *testing.T) {
+	mr := miniredis.RunT(t)
+	createCollection(t, mr, "c", "apple")
+	reader, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "c", EnableCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = reader.Close() }()
+	if _, err := reader.Find("apple"); err != nil {
+		t.Fatal(err)
+	}
+	mr.HSet(trieKey("c"), fieldVersion, "not a version")
+
+	_, err = reader.FindContext(WithMinVersion(context.Background(), 1), "apple")
+	var opErr *OperationError
+	if !errors.As(err, &opErr) {
+		t.Errorf("Find with a corrupt stored version = %v, want an *OperationError", err)
+	}
+}
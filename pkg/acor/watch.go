@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import "context"
+
+// Watch reports writes to the collection as they are published. The returned
+// channel receives a value after each write, by this instance or any other, to
+// the collection; writes that land while a value is still waiting to be
+// received share it, so a receiver learns that something changed, not how
+// many times. Read Version, and History when writers record it, to learn what.
+//
+// The channel is closed once the watch ends: when the instance is closed, or
+// when the subscription's connection is lost. Delivery is Redis Pub/Sub's, so a
+// write published while the connection was down is never reported; a watcher
+// that must not miss one compares Version on reconnect.
+//
+// V1 collections take no writes and return ErrV1ReadOnly; an instance opened
+// by Alias returns ErrWatchViaAlias. Each watch holds its own Pub/Sub
+// connection until it ends, except on an instance opened on a Connection,
+// where it subscribes on the Connection's shared one.
+func (ac *AhoCorasick) Watch() (<-chan struct{}, error) {
+	return ac.WatchContext(ac.ctx)
+}
+
+// WatchContext is Watch with an explicit context: canceling ctx also ends the
+// watch and closes the channel.
+func (ac *AhoCorasick) WatchContext(ctx context.Context) (<-chan struct{}, error) {
+	if _, ok := ac.ops.(*aliasOps); ok {
+		return nil, ErrWatchViaAlias
+	}
+	if _, ok := ac.ops.(batchPlanner); !ok {
+		return nil, ErrV1ReadOnly
+	}
+	// The watch ends with whichever of ctx and the instance ends first.
+	ctx, cancel := context.WithCancel(ctx)
+	stopWithInstance := context.AfterFunc(ac.ctx, cancel)
+
+	channel := invalidateChannelPrefix + ac.name
+	pubsub := ac.storage.Subscribe(ctx, channel)
+	if err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		stopWithInstance()
+		cancel()
+		return nil, newRedisError("SUBSCRIBE", channel, err)
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		defer cancel()
+		defer stopWithInstance()
+		defer func() { _ = pubsub.Close() }()
+		msgs := pubsub.Channel()
+		for {
+			select {
+			case _, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes, nil
+}
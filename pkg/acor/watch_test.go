@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package acor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestWatch_ReportsWritesFromAnyInstance(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args AhoCorasickArgs
+	}{
+		{"v2", AhoCorasickArgs{}},
+		{"preset", AhoCorasickArgs{Preset: PresetBalanced}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			args := tc.args
+			args.Addr, args.Name = mr.Addr(), "rules"
+			watcher, err := Create(&args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writer, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "rules"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = writer.Close() }()
+
+			changes, err := watcher.Watch()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, ac := range []*AhoCorasick{writer, watcher} {
+				if _, err := ac.Add("he"); err != nil {
+					t.Fatal(err)
+				}
+				select {
+				case <-changes:
+				case <-time.After(5 * time.Second):
+					t.Fatal("no change reported for a write")
+				}
+				_, _ = ac.Remove("he")
+				<-changes
+			}
+
+			_ = watcher.Close()
+			for range changes {
+			}
+		})
+	}
+}
+
+func TestWatch_EndsWithItsContext(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ac, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "rules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ac.Close() }()
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := ac.WatchContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Error("received a change with nothing written")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestWatch_Unsupported(t *testing.T) {
+	mr := miniredis.RunT(t)
+	target, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "rules-v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = target.Close() }()
+	if err := target.SetAlias("rules"); err != nil {
+		t.Fatal(err)
+	}
+	viaAlias, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Alias: "rules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = viaAlias.Close() }()
+	if _, err := viaAlias.Watch(); !errors.Is(err, ErrWatchViaAlias) {
+		t.Errorf("Watch via alias = %v, want ErrWatchViaAlias", err)
+	}
+
+	v1, err := Create(&AhoCorasickArgs{Addr: mr.Addr(), Name: "legacy", SchemaVersion: SchemaV1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = v1.Close() }()
+	if _, err := v1.Watch(); !errors.Is(err, ErrV1ReadOnly) {
+		t.Errorf("Watch on V1 = %v, want ErrV1ReadOnly", err)
+	}
+}
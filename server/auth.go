@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/skyoo2003/acor/server/auth"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// authRoutes names the operation behind each Acor RPC, with the Permission it
+// needs. Both transports look requests up here, the HTTP middleware through the
+// RPC's binding in acor.proto, so a route and its RPC are always guarded alike,
+// and limited alike by the rate-limit middleware and interceptor.
+var authRoutes = map[string]auth.Operation{
+	"Add":          {Name: "add", Permission: auth.Write},
+	"Remove":       {Name: "remove", Permission: auth.Write},
+	"Find":         {Name: "find", Permission: auth.Read},
+	"FindIndex":    {Name: "find-index", Permission: auth.Read},
+	"FindAcross":   {Name: "find-across", Permission: auth.Read},
+	"Suggest":      {Name: "suggest", Permission: auth.Read},
+	"SuggestIndex": {Name: "suggest-index", Permission: auth.Read},
+	"Info":         {Name: "info", Permission: auth.Read},
+	"Flush":        {Name: "flush", Permission: auth.Admin},
+	"Score":        {Name: "score", Permission: auth.Read},
+	"SetWeights":   {Name: "set-weights", Permission: auth.Write},
+	"Weights":      {Name: "weights", Permission: auth.Read},
+	"SetAlias":     {Name: "set-alias", Permission: auth.Admin},
+	"ResolveAlias": {Name: "resolve-alias", Permission: auth.Read},
+	"DeleteAlias":  {Name: "delete-alias", Permission: auth.Admin},
+	"Watch":        {Name: "watch", Permission: auth.Read},
+}
+
+// authPaths indexes authRoutes by the HTTP path each RPC is bound to. The
+// middleware matches paths exactly, so a binding must not template its path.
+var authPaths = func() map[string]auth.Operation {
+	paths := make(map[string]auth.Operation, len(authRoutes))
+	for _, b := range httpBindings() {
+		paths[b.path] = authRoutes[string(b.rpc.Name())]
+	}
+	return paths
+}()
+
+// authCollections returns the collections op touches. Most operations touch
+// the collection the service serves. FindAcross touches those it names, or,
+// naming none, every collection of a *Collections — or auth.Any for a service
+// that cannot list them. Setting or deleting an alias also touches the alias's
+// own name, which shares the collections' namespace.
+func authCollections(service Service, op auth.Operation, named []string, alias string) []string {
+	switch op.Name {
+	case "find-across":
+		if len(named) > 0 {
+			return named
+		}
+		if c, ok := service.(*Collections); ok {
+			return c.Names()
+		}
+		return []string{auth.Any}
+	case "set-alias", "delete-alias":
+		return []string{service.Collection(), alias}
+	}
+	return []string{service.Collection()}
+}
+
+// AuthHTTPMiddleware returns middleware that runs every request for a route of
+// NewHTTPHandler through guard before it reaches the handler. /healthz and
+// paths the handler does not serve pass through unchecked. A rejected request
+// gets a 401 or 403 problem document; an admitted one carries its
+// auth.Identity in its context.
+//
+// Wrap the handler for service itself, so the middleware sees the collection
+// the routes operate on.
+func AuthHTTPMiddleware(service Service, guard *auth.Guard) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op, ok := authPaths[r.URL.Path]
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var body struct {
+				Collections []string `json:"collections"`
+				Alias       string   `json:"alias"`
+			}
+			if op.Name == "find-across" || op.Name == "set-alias" || op.Name == "delete-alias" {
+				peekJSON(r, &body)
+			}
+			req := &auth.Request{
+				Credentials: auth.Credentials{
+					Authorization: r.Header.Get("Authorization"),
+					APIKey:        r.Header.Get("X-API-Key"),
+				},
+				Operation:   op,
+				Collections: authCollections(service, op, body.Collections, body.Alias),
+				Transport:   "http",
+				Target:      r.URL.Path,
+				RemoteAddr:  r.RemoteAddr,
+			}
+			if r.TLS != nil {
+				req.Credentials.VerifiedChains = r.TLS.VerifiedChains
+			}
+			id, err := guard.Check(r.Context(), req)
+			if err != nil {
+				e := classifyError(err)
+				if e.reason == acorv1.ErrorReason_UNAUTHENTICATED {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="acor"`)
+				}
+				writeProblem(w, e)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), id)))
+		})
+	}
+}
+
+// peekJSON decodes what it can of r's body into v and puts the body back for the
+// handler, which decodes it again and reports anything wrong with it. It reads
+// no more than the handler's cap; past that, v is left as it was.
+func peekJSON(r *http.Request, v any) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodyBytes))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+	if err == nil {
+		_ = json.Unmarshal(data, v)
+	}
+}
+
+// AuthUnaryInterceptor returns a gRPC interceptor that runs every Acor RPC
+// through guard, as AuthHTTPMiddleware does its routes. Other services on the
+// same server, such as grpc.health.v1, pass through unchecked. A rejected call
+// fails with Unauthenticated or PermissionDenied; an admitted one carries its
+// auth.Identity in its context.
+//
+// Credentials come from the "authorization" and "x-api-key" metadata and from
+// the client certificate chains verified by the server's TLS credentials.
+func AuthUnaryInterceptor(service Service, guard *auth.Guard) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var named []string
+		var alias string
+		switch r := req.(type) {
+		case *acorv1.FindAcrossRequest:
+			named = r.GetCollections()
+		case *acorv1.AliasRequest:
+			alias = r.GetAlias()
+		}
+		ctx, err := guardRPC(ctx, service, guard, info.FullMethod, named, alias)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamInterceptor is AuthUnaryInterceptor for the streaming RPCs, such as
+// Watch. The guard runs before the handler reads the request.
+func AuthStreamInterceptor(service Service, guard *auth.Guard) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := guardRPC(ss.Context(), service, guard, info.FullMethod, nil, "")
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// guardRPC runs a call to fullMethod through guard and returns the context to
+// handle it with. Methods outside the Acor service pass through unchecked.
+func guardRPC(ctx context.Context, service Service, guard *auth.Guard, fullMethod string, named []string, alias string) (context.Context, error) {
+	rpc, ok := strings.CutPrefix(fullMethod, "/"+acorv1.Acor_ServiceDesc.ServiceName+"/")
+	op, known := authRoutes[rpc]
+	if !ok || !known {
+		return ctx, nil
+	}
+	check := &auth.Request{
+		Operation:   op,
+		Collections: authCollections(service, op, named, alias),
+		Transport:   "grpc",
+		Target:      fullMethod,
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		check.Credentials.Authorization = firstValue(md, "authorization")
+		check.Credentials.APIKey = firstValue(md, "x-api-key")
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			check.RemoteAddr = p.Addr.String()
+		}
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			check.Credentials.VerifiedChains = tlsInfo.State.VerifiedChains
+		}
+	}
+	id, err := guard.Check(ctx, check)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return auth.NewContext(ctx, id), nil
+}
+
+// contextStream is a grpc.ServerStream whose handler sees ctx.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package auth authenticates callers of the acor server and authorizes what they
+// may do to which collection. An Authenticator turns a request's Credentials into
+// an Identity; a Policy decides whether that Identity may perform an Operation on
+// a collection; a Guard does both and audit-logs every denial. The server package
+// applies a Guard to its HTTP handler and gRPC service alike, so a caller gets
+// the same answer on either.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+)
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request carries
+	// none of the credentials it understands. Chain moves on to the next
+	// Authenticator; a Guard with nothing left to try rejects the request.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned by an Authenticator for credentials it
+	// understands but does not accept: an unknown key, a bad signature, an
+	// expired token. Chain stops there rather than trying the next one.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrPermissionDenied is returned by Guard.Check when the caller is
+	// authenticated but the Policy grants it no right to the operation.
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// Identity is an authenticated caller.
+type Identity struct {
+	// Subject names the caller: the API key's owner, the token's "sub" claim,
+	// or the client certificate's URI SAN or common name.
+	Subject string
+	// Method is the Authenticator that accepted the caller: "api-key",
+	// "hmac-token", "jwt", or "mtls".
+	Method string
+}
+
+// Credentials are what a request presents to authenticate, gathered by the
+// transport: HTTP headers or gRPC metadata, and the TLS connection.
+type Credentials struct {
+	// Authorization is the Authorization header, or the gRPC "authorization"
+	// metadata.
+	Authorization string
+	// APIKey is the X-API-Key header, or the gRPC "x-api-key" metadata.
+	APIKey string
+	// VerifiedChains are the client certificate chains the TLS handshake
+	// verified, leaf first. Unverified peer certificates are never used.
+	VerifiedChains [][]*x509.Certificate
+}
+
+// Authenticator turns Credentials into an Identity. It returns ErrNoCredentials
+// when the request carries nothing it understands, and an error wrapping
+// ErrInvalidCredentials when it does but they do not check out.
+type Authenticator interface {
+	Authenticate(ctx context.Context, creds *Credentials) (*Identity, error)
+}
+
+// AuthenticatorFunc adapts a function to an Authenticator.
+type AuthenticatorFunc func(ctx context.Context, creds *Credentials) (*Identity, error)
+
+// Authenticate calls f.
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, creds *Credentials) (*Identity, error) {
+	return f(ctx, creds)
+}
+
+// Chain returns an Authenticator that tries each of authenticators in order
+// and answers with the first one that does not return ErrNoCredentials. A
+// server accepting both API keys and tokens chains the two.
+func Chain(authenticators ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, creds *Credentials) (*Identity, error) {
+		for _, a := range authenticators {
+			id, err := a.Authenticate(ctx, creds)
+			if !errors.Is(err, ErrNoCredentials) {
+				return id, err
+			}
+		}
+		return nil, ErrNoCredentials
+	})
+}
+
+// APIKeys returns an Authenticator for static API keys sent in the X-API-Key
+// header. keys maps each key to the subject it identifies. Keys are held and
+// compared as SHA-256 digests, so the lookup takes the same time whichever key,
+// or none, matches.
+func APIKeys(keys map[string]string) Authenticator {
+	digests := make(map[[sha256.Size]byte]string, len(keys))
+	for key, subject := range keys {
+		digests[sha256.Sum256([]byte(key))] = subject
+	}
+	return AuthenticatorFunc(func(_ context.Context, creds *Credentials) (*Identity, error) {
+		if creds.APIKey == "" {
+			return nil, ErrNoCredentials
+		}
+		subject, ok := digests[sha256.Sum256([]byte(creds.APIKey))]
+		if !ok {
+			return nil, ErrInvalidCredentials
+		}
+		return &Identity{Subject: subject, Method: "api-key"}, nil
+	})
+}
+
+type identityKey struct{}
+
+// NewContext returns a copy of ctx carrying id.
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the Identity a Guard admitted the request as, or nil when
+// the request did not pass through one.
+func FromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+	return id
+}
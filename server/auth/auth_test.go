@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/skyoo2003/acor/server/logging"
+)
+
+func TestAPIKeys(t *testing.T) {
+	keys := APIKeys(map[string]string{"k-reader": "reader"})
+	ctx := context.Background()
+
+	id, err := keys.Authenticate(ctx, &Credentials{APIKey: "k-reader"})
+	if err != nil || id.Subject != "reader" || id.Method != "api-key" {
+		t.Errorf("known key = %+v, %v", id, err)
+	}
+	if _, err := keys.Authenticate(ctx, &Credentials{APIKey: "k-other"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown key error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := keys.Authenticate(ctx, &Credentials{Authorization: "Bearer k-reader"}); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("no key error = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestChain(t *testing.T) {
+	chain := Chain(APIKeys(map[string]string{"k": "svc"}), ClientCertificates())
+	ctx := context.Background()
+	leaf := &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}}
+
+	if id, err := chain.Authenticate(ctx, &Credentials{VerifiedChains: [][]*x509.Certificate{{leaf}}}); err != nil || id.Subject != "billing" {
+		t.Errorf("certificate through the chain = %+v, %v", id, err)
+	}
+	// A bad key stops the chain even though a certificate would have passed.
+	_, err := chain.Authenticate(ctx, &Credentials{APIKey: "bad", VerifiedChains: [][]*x509.Certificate{{leaf}}})
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("bad key with a certificate error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := chain.Authenticate(ctx, &Credentials{}); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("nothing presented error = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestClientCertificatesPrefersURISAN(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/ns/prod/sa/indexer")
+	leaf := &x509.Certificate{Subject: pkix.Name{CommonName: "indexer"}, URIs: []*url.URL{spiffe}}
+	id, err := ClientCertificates().Authenticate(context.Background(), &Credentials{VerifiedChains: [][]*x509.Certificate{{leaf}}})
+	if err != nil || id.Subject != spiffe.String() || id.Method != "mtls" {
+		t.Errorf("Authenticate = %+v, %v", id, err)
+	}
+}
+
+func TestGuardAuditsDenials(t *testing.T) {
+	var buf bytes.Buffer
+	guard := &Guard{
+		Authenticator: APIKeys(map[string]string{"k": "reader"}),
+		Policy:        &Policy{Grants: []Grant{{Subject: "reader", Collection: Any, Permission: Read}}},
+		Logger:        logging.NewLogger(&buf, "info"),
+	}
+	ctx := context.Background()
+	find := Operation{Name: "find", Permission: Read}
+	flush := Operation{Name: "flush", Permission: Admin}
+
+	if id, err := guard.Check(ctx, &Request{Credentials: Credentials{APIKey: "k"}, Operation: find, Collections: []string{"rules"}}); err != nil || id.Subject != "reader" {
+		t.Fatalf("allowed Check = %+v, %v", id, err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("an allowed request was logged: %s", buf.String())
+	}
+
+	_, err := guard.Check(ctx, &Request{Credentials: Credentials{APIKey: "k"}, Operation: flush, Collections: []string{"rules"},
+		Transport: "http", Target: "/v1/flush", RemoteAddr: "10.0.0.7:51234"})
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("flush error = %v, want ErrPermissionDenied", err)
+	}
+	for _, want := range []string{`"audit":"access_denied"`, `"subject":"reader"`, `"operation":"flush"`, `"target":"/v1/flush"`, `"remote_addr":"10.0.0.7:51234"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("audit record %s lacks %s", buf.String(), want)
+		}
+	}
+
+	buf.Reset()
+	if _, err := guard.Check(ctx, &Request{Operation: find, Collections: []string{"rules"}}); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("anonymous error = %v, want ErrNoCredentials", err)
+	}
+	if !strings.Contains(buf.String(), `"audit":"access_denied"`) || strings.Contains(buf.String(), `"subject"`) {
+		t.Errorf("anonymous audit record = %s", buf.String())
+	}
+}
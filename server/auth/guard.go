@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/skyoo2003/acor/server/logging"
+)
+
+// Guard admits a request: it authenticates the caller and checks the Policy for
+// the operation and collections the request names. The server package runs one
+// Guard in front of both transports.
+type Guard struct {
+	// Authenticator identifies the caller. Chain several to accept more than
+	// one kind of credential.
+	Authenticator Authenticator
+	// Policy decides what an identified caller may do. A nil Policy denies
+	// everything.
+	Policy *Policy
+	// Logger, when set, receives an audit record for every denied request.
+	Logger *logging.Logger
+}
+
+// Request describes one request to a Guard.
+type Request struct {
+	Credentials Credentials
+	Operation   Operation
+	// Collections are the collections the operation touches.
+	Collections []string
+	// Transport is "http", "grpc", or "resp", and Target the path, full RPC
+	// method, or command name; both are for the audit record only.
+	Transport string
+	Target    string
+	// RemoteAddr is the caller's address, for the audit record.
+	RemoteAddr string
+}
+
+// Check authenticates req and authorizes its operation. It returns the caller's
+// Identity, or an error wrapping ErrNoCredentials or ErrInvalidCredentials when
+// the caller is not authenticated, or ErrPermissionDenied when it is but may not
+// do this. A denial is logged before Check returns.
+func (g *Guard) Check(ctx context.Context, req *Request) (*Identity, error) {
+	if g.Authenticator == nil {
+		return nil, g.deny(req, nil, ErrNoCredentials)
+	}
+	id, err := g.Authenticator.Authenticate(ctx, &req.Credentials)
+	if err != nil {
+		if !errors.Is(err, ErrNoCredentials) && !errors.Is(err, ErrInvalidCredentials) {
+			err = fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+		}
+		return nil, g.deny(req, nil, err)
+	}
+	if !g.Policy.Allowed(id, req.Operation, req.Collections) {
+		return nil, g.deny(req, id, fmt.Errorf("%w: %s needs %s on %v",
+			ErrPermissionDenied, req.Operation.Name, req.Operation.Permission, req.Collections))
+	}
+	return id, nil
+}
+
+// deny writes the audit record for a rejected request and returns err.
+func (g *Guard) deny(req *Request, id *Identity, err error) error {
+	if g.Logger == nil {
+		return err
+	}
+	event := g.Logger.Warn().
+		Str("audit", "access_denied").
+		Str("transport", req.Transport).
+		Str("target", req.Target).
+		Str("operation", req.Operation.Name).
+		Strs("collections", req.Collections).
+		Str("remote_addr", req.RemoteAddr).
+		Err(err)
+	if id != nil {
+		event = event.Str("subject", id.Subject).Str("auth_method", id.Method)
+	}
+	event.Msg("access denied")
+	return err
+}
@@ -0,0 +1,325 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TokenOptions are the claim checks HMACTokens and JWKS apply on top of the
+// signature. Every token must carry "sub" and "exp"; the zero TokenOptions
+// checks nothing more.
+type TokenOptions struct {
+	// Issuer, when set, must equal the token's "iss".
+	Issuer string
+	// Audience, when set, must be the token's "aud" or one of its entries.
+	Audience string
+	// Leeway tolerates clock skew on "exp" and "nbf".
+	Leeway time.Duration
+}
+
+// HMACTokens returns an Authenticator for bearer tokens signed with a shared
+// secret: JWTs with alg HS256, HS384, or HS512, in "Authorization: Bearer". It
+// accepts no other algorithm, so a token cannot pick a weaker check than the
+// server means to run.
+func HMACTokens(secret []byte, opts TokenOptions) Authenticator {
+	v := &tokenVerifier{opts: opts, method: "hmac-token", verify: func(header *tokenHeader, signed, sig []byte) error {
+		newHash, ok := hmacAlgs[header.Alg]
+		if !ok {
+			return fmt.Errorf("%w: alg %q is not an HMAC algorithm", ErrInvalidCredentials, header.Alg)
+		}
+		mac := hmac.New(newHash, secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidCredentials)
+		}
+		return nil
+	}}
+	return AuthenticatorFunc(v.authenticate)
+}
+
+var hmacAlgs = map[string]func() hash.Hash{"HS256": sha256.New, "HS384": sha512.New384, "HS512": sha512.New}
+
+// JWKS returns an Authenticator for JWTs in "Authorization: Bearer", verified
+// against the public keys of the JSON Web Key Set in the file at path. RSA keys
+// verify RS256, and EC keys on P-256 or P-384 verify ES256 or ES384. A token's
+// "kid" selects the key; a token without one is accepted only when the set
+// holds a single key.
+//
+// The file is read once. Keys rotated into it take effect when the server
+// builds a new Authenticator.
+func JWKS(path string, opts TokenOptions) (Authenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: read JWKS: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("auth: parse JWKS %s: %w", path, err)
+	}
+	v := &tokenVerifier{opts: opts, method: "jwt", verify: func(header *tokenHeader, signed, sig []byte) error {
+		key, err := keys.find(header.Kid)
+		if err != nil {
+			return err
+		}
+		return key.verify(header.Alg, signed, sig)
+	}}
+	return AuthenticatorFunc(v.authenticate), nil
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type tokenClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *int64          `json:"exp"`
+	NotBefore *int64          `json:"nbf"`
+}
+
+// tokenVerifier checks the JWT in a bearer Authorization: verify checks the
+// signature, and authenticate the claims after it.
+type tokenVerifier struct {
+	opts   TokenOptions
+	method string
+	verify func(header *tokenHeader, signed, sig []byte) error
+}
+
+func (v *tokenVerifier) authenticate(_ context.Context, creds *Credentials) (*Identity, error) {
+	token, ok := bearerToken(creds.Authorization)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+	}
+	if err := v.verify(&header, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := v.checkClaims(&claims, time.Now()); err != nil {
+		return nil, err
+	}
+	return &Identity{Subject: claims.Subject, Method: v.method}, nil
+}
+
+func (v *tokenVerifier) checkClaims(c *tokenClaims, now time.Time) error {
+	switch {
+	case c.Subject == "":
+		return fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	case c.ExpiresAt == nil:
+		return fmt.Errorf("%w: token has no expiry", ErrInvalidCredentials)
+	case now.After(time.Unix(*c.ExpiresAt, 0).Add(v.opts.Leeway)):
+		return fmt.Errorf("%w: token expired", ErrInvalidCredentials)
+	case c.NotBefore != nil && now.Before(time.Unix(*c.NotBefore, 0).Add(-v.opts.Leeway)):
+		return fmt.Errorf("%w: token not yet valid", ErrInvalidCredentials)
+	case v.opts.Issuer != "" && c.Issuer != v.opts.Issuer:
+		return fmt.Errorf("%w: token issuer %q", ErrInvalidCredentials, c.Issuer)
+	case v.opts.Audience != "" && !hasAudience(c.Audience, v.opts.Audience):
+		return fmt.Errorf("%w: token is not for this audience", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+// hasAudience reports whether aud, a string or an array of them, names want.
+func hasAudience(aud json.RawMessage, want string) bool {
+	var one string
+	if json.Unmarshal(aud, &one) == nil {
+		return one == want
+	}
+	var many []string
+	return json.Unmarshal(aud, &many) == nil && slices.Contains(many, want)
+}
+
+func bearerToken(authorization string) (string, bool) {
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+// --- JWKS ---
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey is one key of a JWKS, holding an *rsa.PublicKey or an
+// *ecdsa.PublicKey.
+type publicKey struct {
+	kid string
+	key crypto.PublicKey
+}
+
+type keySet []publicKey
+
+func parseJWKS(data []byte) (keySet, error) {
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var set keySet
+	for _, jwk := range doc.Keys {
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jwk.Kid, err)
+		}
+		set = append(set, publicKey{kid: jwk.Kid, key: key})
+	}
+	if len(set) == 0 {
+		return nil, errors.New("no keys")
+	}
+	return set, nil
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA exponent out of range")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("malformed key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (s keySet) find(kid string) (publicKey, error) {
+	if kid == "" {
+		if len(s) == 1 {
+			return s[0], nil
+		}
+		return publicKey{}, fmt.Errorf("%w: token names no key", ErrInvalidCredentials)
+	}
+	for _, k := range s {
+		if k.kid == kid {
+			return k, nil
+		}
+	}
+	return publicKey{}, fmt.Errorf("%w: unknown key %q", ErrInvalidCredentials, kid)
+}
+
+// verify checks sig over signed with alg, which must be the algorithm the key
+// is for.
+func (k publicKey) verify(alg string, signed, sig []byte) error {
+	bad := fmt.Errorf("%w: bad signature", ErrInvalidCredentials)
+	switch key := k.key.(type) {
+	case *rsa.PublicKey:
+		if alg != "RS256" {
+			break
+		}
+		digest := sha256.Sum256(signed)
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) != nil {
+			return bad
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		var digest []byte
+		switch {
+		case alg == "ES256" && key.Curve == elliptic.P256():
+			d := sha256.Sum256(signed)
+			digest = d[:]
+		case alg == "ES384" && key.Curve == elliptic.P384():
+			d := sha512.Sum384(signed)
+			digest = d[:]
+		default:
+			return fmt.Errorf("%w: alg %q does not match key %q", ErrInvalidCredentials, alg, k.kid)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return bad
+		}
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return bad
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: alg %q does not match key %q", ErrInvalidCredentials, alg, k.kid)
+}
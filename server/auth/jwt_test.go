@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var b64 = base64.RawURLEncoding
+
+// signToken builds a compact JWT whose signature sign computes.
+func signToken(t *testing.T, header, claims map[string]any, sign func(signed []byte) []byte) string {
+	t.Helper()
+	h, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := b64.EncodeToString(h) + "." + b64.EncodeToString(c)
+	return signed + "." + b64.EncodeToString(sign([]byte(signed)))
+}
+
+func hs256(secret []byte) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func bearer(token string) *Credentials { return &Credentials{Authorization: "Bearer " + token} }
+
+func TestHMACTokens(t *testing.T) {
+	secret := []byte("s3cret")
+	tokens := HMACTokens(secret, TokenOptions{Issuer: "acor-ci", Audience: "acor"})
+	ctx := context.Background()
+	valid := map[string]any{"sub": "ci", "iss": "acor-ci", "aud": []string{"acor", "other"}, "exp": time.Now().Add(time.Hour).Unix()}
+	hs := map[string]any{"alg": "HS256", "typ": "JWT"}
+
+	id, err := tokens.Authenticate(ctx, bearer(signToken(t, hs, valid, hs256(secret))))
+	if err != nil || id.Subject != "ci" || id.Method != "hmac-token" {
+		t.Fatalf("valid token = %+v, %v", id, err)
+	}
+
+	with := func(key string, value any) map[string]any {
+		claims := map[string]any{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+	for name, token := range map[string]string{
+		"wrong secret":   signToken(t, hs, valid, hs256([]byte("other"))),
+		"expired":        signToken(t, hs, with("exp", time.Now().Add(-time.Minute).Unix()), hs256(secret)),
+		"no expiry":      signToken(t, hs, with("exp", nil), hs256(secret)),
+		"not yet valid":  signToken(t, hs, with("nbf", time.Now().Add(time.Hour).Unix()), hs256(secret)),
+		"wrong issuer":   signToken(t, hs, with("iss", "elsewhere"), hs256(secret)),
+		"wrong audience": signToken(t, hs, with("aud", "other"), hs256(secret)),
+		"no subject":     signToken(t, hs, with("sub", nil), hs256(secret)),
+		"alg none":       signToken(t, map[string]any{"alg": "none"}, valid, func([]byte) []byte { return nil }),
+		"malformed":      "not-a-token",
+	} {
+		if _, err := tokens.Authenticate(ctx, bearer(token)); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: error = %v, want ErrInvalidCredentials", name, err)
+		}
+	}
+	if _, err := tokens.Authenticate(ctx, &Credentials{Authorization: "Basic dXNlcjpwYXNz"}); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Basic credentials error = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pad := func(b []byte) []byte { return append(make([]byte, 32-len(b)), b...) }
+	jwks := map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "r1", "n": b64.EncodeToString(rsaKey.N.Bytes()), "e": b64.EncodeToString([]byte{1, 0, 1})},
+		{"kty": "EC", "kid": "e1", "crv": "P-256", "x": b64.EncodeToString(pad(ecKey.X.Bytes())), "y": b64.EncodeToString(pad(ecKey.Y.Bytes()))},
+	}}
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := JWKS(path, TokenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	claims := map[string]any{"sub": "svc", "exp": time.Now().Add(time.Hour).Unix()}
+	rs256 := func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		sig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+	es256 := func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(pad(r.Bytes()), pad(s.Bytes())...)
+	}
+
+	for name, token := range map[string]string{
+		"RS256": signToken(t, map[string]any{"alg": "RS256", "kid": "r1"}, claims, rs256),
+		"ES256": signToken(t, map[string]any{"alg": "ES256", "kid": "e1"}, claims, es256),
+	} {
+		if id, err := tokens.Authenticate(ctx, bearer(token)); err != nil || id.Subject != "svc" || id.Method != "jwt" {
+			t.Errorf("%s: Authenticate = %+v, %v", name, id, err)
+		}
+	}
+
+	for name, token := range map[string]string{
+		// An HMAC token "signed" with the public key must not pass as RS256.
+		"HS256 against an RSA key": signToken(t, map[string]any{"alg": "HS256", "kid": "r1"}, claims, hs256(rsaKey.N.Bytes())),
+		"alg of the other key":     signToken(t, map[string]any{"alg": "ES256", "kid": "r1"}, claims, es256),
+		"unknown kid":              signToken(t, map[string]any{"alg": "RS256", "kid": "r2"}, claims, rs256),
+		"no kid with two keys":     signToken(t, map[string]any{"alg": "RS256"}, claims, rs256),
+	} {
+		if _, err := tokens.Authenticate(ctx, bearer(token)); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: error = %v, want ErrInvalidCredentials", name, err)
+		}
+	}
+
+	if _, err := JWKS(filepath.Join(t.TempDir(), "missing.json"), TokenOptions{}); err == nil {
+		t.Error("JWKS of a missing file succeeded")
+	}
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+// ClientCertificates returns an Authenticator for mutual TLS. It identifies the
+// caller by the leaf of the first chain the TLS handshake verified: its first
+// URI SAN (a SPIFFE ID, say) when it has one, its subject common name otherwise.
+//
+// It trusts the handshake and nothing else, so the listener must verify client
+// certificates — tls.Config.ClientAuth set to tls.RequireAndVerifyClientCert or
+// tls.VerifyClientCertIfGiven, with ClientCAs set. A connection whose
+// certificate was not verified presents no chain and gets ErrNoCredentials.
+func ClientCertificates() Authenticator {
+	return AuthenticatorFunc(func(_ context.Context, creds *Credentials) (*Identity, error) {
+		if len(creds.VerifiedChains) == 0 || len(creds.VerifiedChains[0]) == 0 {
+			return nil, ErrNoCredentials
+		}
+		leaf := creds.VerifiedChains[0][0]
+		subject := leaf.Subject.CommonName
+		if len(leaf.URIs) > 0 {
+			subject = leaf.URIs[0].String()
+		}
+		if subject == "" {
+			return nil, fmt.Errorf("%w: client certificate names no subject", ErrInvalidCredentials)
+		}
+		return &Identity{Subject: subject, Method: "mtls"}, nil
+	})
+}
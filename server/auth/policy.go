@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Permission is a level of access to a collection. Each level includes the ones
+// below it: Admin grants Write, and Write grants Read.
+type Permission int
+
+const (
+	// Read allows searching, suggesting, scoring, and reading info, weights,
+	// and aliases.
+	Read Permission = iota + 1
+	// Write allows adding and removing keywords and setting weights.
+	Write
+	// Admin allows flushing the collection and setting or deleting aliases.
+	Admin
+)
+
+var permissionNames = map[Permission]string{Read: "read", Write: "write", Admin: "admin"}
+
+// String returns "read", "write", or "admin".
+func (p Permission) String() string {
+	if name, ok := permissionNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Permission(%d)", int(p))
+}
+
+// MarshalText encodes p by name, so a Policy reads naturally as JSON or YAML.
+func (p Permission) MarshalText() ([]byte, error) {
+	if _, ok := permissionNames[p]; !ok {
+		return nil, fmt.Errorf("auth: invalid permission %d", int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText decodes "read", "write", or "admin".
+func (p *Permission) UnmarshalText(text []byte) error {
+	for level, name := range permissionNames {
+		if name == string(text) {
+			*p = level
+			return nil
+		}
+	}
+	return fmt.Errorf("auth: unknown permission %q", text)
+}
+
+// Operation is one thing a caller asks the server to do, and the Permission it
+// needs on each collection it touches.
+type Operation struct {
+	// Name is the operation's route name: "add", "find-across", "set-alias".
+	Name       string
+	Permission Permission
+}
+
+// Any matches every subject or every collection in a Grant.
+const Any = "*"
+
+// Grant gives a subject a Permission on a collection.
+type Grant struct {
+	// Subject is the Identity.Subject the grant applies to, or Any for every
+	// authenticated caller.
+	Subject string `json:"subject"`
+	// Collection is the collection the grant covers, or Any for all of them.
+	Collection string `json:"collection"`
+	// Permission is the highest level granted.
+	Permission Permission `json:"permission"`
+	// Operations, when set, narrows the grant to the named operations, each of
+	// which must still be within Permission.
+	Operations []string `json:"operations,omitempty"`
+}
+
+// Policy decides what each authenticated caller may do. It denies by default:
+// an operation is allowed on a collection only when some Grant allows it.
+type Policy struct {
+	Grants []Grant `json:"grants"`
+}
+
+// Allowed reports whether id may perform op on every one of collections.
+func (p *Policy) Allowed(id *Identity, op Operation, collections []string) bool {
+	if p == nil || id == nil || len(collections) == 0 {
+		return false
+	}
+	for _, c := range collections {
+		if !slices.ContainsFunc(p.Grants, func(g Grant) bool { return g.allows(id, op, c) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Grant) allows(id *Identity, op Operation, collection string) bool {
+	return (g.Subject == Any || g.Subject == id.Subject) &&
+		(g.Collection == Any || g.Collection == collection) &&
+		g.Permission >= op.Permission &&
+		(len(g.Operations) == 0 || slices.Contains(g.Operations, op.Name))
+}
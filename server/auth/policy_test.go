@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPolicyAllowed(t *testing.T) {
+	var policy Policy
+	err := json.Unmarshal([]byte(`{"grants": [
+		{"subject": "editor", "collection": "rules", "permission": "write"},
+		{"subject": "ops", "collection": "*", "permission": "admin", "operations": ["flush"]},
+		{"subject": "*", "collection": "public", "permission": "read"}
+	]}`), &policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	find := Operation{Name: "find", Permission: Read}
+	add := Operation{Name: "add", Permission: Write}
+	flush := Operation{Name: "flush", Permission: Admin}
+	editor, ops, guest := &Identity{Subject: "editor"}, &Identity{Subject: "ops"}, &Identity{Subject: "guest"}
+
+	for _, tc := range []struct {
+		id          *Identity
+		op          Operation
+		collections []string
+		want        bool
+	}{
+		{editor, add, []string{"rules"}, true},
+		{editor, find, []string{"rules"}, true}, // write includes read
+		{editor, flush, []string{"rules"}, false},
+		{editor, add, []string{"other"}, false},
+		{editor, find, []string{"rules", "public"}, true},
+		{editor, find, []string{"rules", "other"}, false}, // every collection must be granted
+		{ops, flush, []string{"anything"}, true},
+		{ops, find, []string{"anything"}, false}, // narrowed to flush
+		{guest, find, []string{"public"}, true},
+		{guest, find, []string{Any}, false}, // "all collections" needs a grant on Any
+		{nil, find, []string{"public"}, false},
+		{editor, find, nil, false},
+	} {
+		if got := policy.Allowed(tc.id, tc.op, tc.collections); got != tc.want {
+			t.Errorf("Allowed(%+v, %s, %v) = %v, want %v", tc.id, tc.op.Name, tc.collections, got, tc.want)
+		}
+	}
+}
+
+func TestPermissionText(t *testing.T) {
+	var p Permission
+	if err := p.UnmarshalText([]byte("owner")); err == nil {
+		t.Error("UnmarshalText(owner) succeeded")
+	}
+	data, err := json.Marshal(Grant{Subject: "a", Collection: "b", Permission: Admin})
+	if err != nil || string(data) != `{"subject":"a","collection":"b","permission":"admin"}` {
+		t.Errorf("Marshal = %s, %v", data, err)
+	}
+}
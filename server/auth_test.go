@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/skyoo2003/acor/server/auth"
+	"github.com/skyoo2003/acor/server/logging"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// testGuard admits "k-read" to read and "k-write" to write the "rules"
+// collection, and "k-admin" to administer every collection.
+func testGuard(logs *bytes.Buffer) *auth.Guard {
+	return &auth.Guard{
+		Authenticator: auth.APIKeys(map[string]string{"k-read": "reader", "k-write": "writer", "k-admin": "admin"}),
+		Policy: &auth.Policy{Grants: []auth.Grant{
+			{Subject: "reader", Collection: "rules", Permission: auth.Read},
+			{Subject: "writer", Collection: "rules", Permission: auth.Write},
+			{Subject: "admin", Collection: auth.Any, Permission: auth.Admin},
+		}},
+		Logger: logging.NewLogger(logs, "info"),
+	}
+}
+
+func TestAuthHTTPMiddleware(t *testing.T) {
+	service := &fakeCrossService{fakeService: fakeService{addCount: 1, collection: "rules", aliases: map[string]string{}}}
+	var logs bytes.Buffer
+	server := httptest.NewServer(AuthHTTPMiddleware(service, testGuard(&logs))(NewHTTPHandler(service)))
+	defer server.Close()
+
+	post := func(path, key, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	for _, tc := range []struct {
+		path, key, body string
+		want            int
+	}{
+		{"/v1/find", "", `{"input":"he"}`, http.StatusUnauthorized},
+		{"/v1/find", "k-wrong", `{"input":"he"}`, http.StatusUnauthorized},
+		{"/v1/find", "k-read", `{"input":"he"}`, http.StatusOK},
+		{"/v1/add", "k-read", `{"keyword":"he"}`, http.StatusForbidden},
+		{"/v1/add", "k-write", `{"keyword":"he"}`, http.StatusOK},
+		{"/v1/flush", "k-write", ``, http.StatusForbidden},
+		{"/v1/flush", "k-admin", ``, http.StatusOK},
+		// The alias's own name needs admin too; the writer has none anywhere.
+		{"/v1/set-alias", "k-write", `{"alias":"live"}`, http.StatusForbidden},
+		{"/v1/set-alias", "k-admin", `{"alias":"live"}`, http.StatusOK},
+		// find-across is checked against the collections the body names.
+		{"/v1/find-across", "k-read", `{"input":"he","collections":["rules"]}`, http.StatusOK},
+		{"/v1/find-across", "k-read", `{"input":"he","collections":["rules","private"]}`, http.StatusForbidden},
+		{"/v1/find-across", "k-read", `{"input":"he"}`, http.StatusForbidden},
+	} {
+		if resp := post(tc.path, tc.key, tc.body); resp.StatusCode != tc.want {
+			t.Errorf("POST %s as %q: status %d, want %d", tc.path, tc.key, resp.StatusCode, tc.want)
+		} else if tc.want == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("POST %s as %q: 401 without WWW-Authenticate", tc.path, tc.key)
+		}
+	}
+	if service.lastCollections == nil || service.lastCollections[0] != "rules" {
+		t.Errorf("find-across reached the handler with %v; the peeked body was not put back", service.lastCollections)
+	}
+
+	resp, err := http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /healthz without credentials: status %d, want 200", resp.StatusCode)
+	}
+	if n := strings.Count(logs.String(), `"audit":"access_denied"`); n != 7 {
+		t.Errorf("%d denials audited, want 7:\n%s", n, logs.String())
+	}
+}
+
+func TestAuthUnaryInterceptor(t *testing.T) {
+	service := &fakeService{addCount: 1, collection: "rules", findMatches: []string{keywordHE}}
+	var logs bytes.Buffer
+	client := newGRPCTestClient(t, service, grpc.UnaryInterceptor(AuthUnaryInterceptor(service, testGuard(&logs))))
+	as := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+	}
+
+	if _, err := client.Find(context.Background(), &acorv1.InputRequest{Input: inputHEHE}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Find without credentials: %v, want Unauthenticated", err)
+	}
+	if _, err := client.Find(as("k-read"), &acorv1.InputRequest{Input: inputHEHE}); err != nil {
+		t.Errorf("Find as reader: %v", err)
+	}
+	if _, err := client.Add(as("k-read"), &acorv1.KeywordRequest{Keyword: keywordHE}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Add as reader: %v, want PermissionDenied", err)
+	}
+	if _, err := client.Add(as("k-write"), &acorv1.KeywordRequest{Keyword: keywordHE}); err != nil {
+		t.Errorf("Add as writer: %v", err)
+	}
+	if _, err := client.Flush(as("k-write"), &acorv1.EmptyRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Flush as writer: %v, want PermissionDenied", err)
+	}
+	if !strings.Contains(logs.String(), `"target":"/acor.server.v1.Acor/Flush"`) {
+		t.Errorf("Flush denial not audited:\n%s", logs.String())
+	}
+}
+
+func TestAuthRoutesCoverTheService(t *testing.T) {
+	for _, m := range acorv1.Acor_ServiceDesc.Methods {
+		if _, ok := authRoutes[m.MethodName]; !ok {
+			t.Errorf("RPC %s has no auth operation", m.MethodName)
+		}
+	}
+	for _, m := range acorv1.Acor_ServiceDesc.Streams {
+		if _, ok := authRoutes[m.StreamName]; !ok {
+			t.Errorf("streaming RPC %s has no auth operation", m.StreamName)
+		}
+	}
+	mux := NewHTTPHandler(&fakeService{}).(*http.ServeMux)
+	for path := range authPaths {
+		if _, pattern := mux.Handler(httptest.NewRequest(http.MethodPost, path, nil)); pattern != path {
+			t.Errorf("auth path %s is not a route of NewHTTPHandler", path)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package client calls an acor server. A RemoteAhoCorasick offers the methods
+// of *acor.AhoCorasick that the server's API can answer, under the same names
+// and signatures, over gRPC or HTTP/JSON. Code written against Collection runs
+// on an embedded collection or a remote one by swapping the constructor:
+//
+//	func open(remote bool) (client.Collection, error) {
+//		if remote {
+//			return client.NewGRPC("acor.internal:9090", nil)
+//		}
+//		return acor.Create(&acor.AhoCorasickArgs{Addr: "localhost:6379", Name: "rules"})
+//	}
+//
+// Calls are retried when the server says retrying can succeed, and when the
+// server cannot be reached; each attempt has a deadline of its own. Read-your-
+// writes tokens work as on an embedded collection: a write records its version
+// in the context's acor.VersionToken, and a read sends acor.WithMinVersion's
+// floor along.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// Collection is the method set RemoteAhoCorasick shares with *acor.AhoCorasick.
+type Collection interface {
+	Add(keyword string) (int, error)
+	AddContext(ctx context.Context, keyword string) (int, error)
+	AddMany(keywords []string, opts *acor.BatchOptions) (*acor.BatchResult, error)
+	AddManyContext(ctx context.Context, keywords []string, opts *acor.BatchOptions) (*acor.BatchResult, error)
+	Remove(keyword string) (int, error)
+	RemoveContext(ctx context.Context, keyword string) (int, error)
+	RemoveMany(keywords []string, opts *acor.BatchOptions) (*acor.BatchResult, error)
+	RemoveManyContext(ctx context.Context, keywords []string, opts *acor.BatchOptions) (*acor.BatchResult, error)
+	Find(text string) ([]string, error)
+	FindContext(ctx context.Context, text string) ([]string, error)
+	FindIndex(text string) (map[string][]int, error)
+	FindIndexContext(ctx context.Context, text string) (map[string][]int, error)
+	FindMatches(text string, opts *acor.MatchOptions) ([]acor.Match, error)
+	FindMatchesContext(ctx context.Context, text string, opts *acor.MatchOptions) ([]acor.Match, error)
+	FindStream(r io.Reader, onMatch func(acor.Match) bool) error
+	FindStreamContext(ctx context.Context, r io.Reader, onMatch func(acor.Match) bool) error
+	Contains(text string) (bool, error)
+	ContainsContext(ctx context.Context, text string) (bool, error)
+	CountMatches(text string) (map[string]int, error)
+	CountMatchesContext(ctx context.Context, text string) (map[string]int, error)
+	Suggest(input string) ([]string, error)
+	SuggestContext(ctx context.Context, input string) ([]string, error)
+	SuggestIndex(input string) (map[string][]int, error)
+	SuggestIndexContext(ctx context.Context, input string) (map[string][]int, error)
+	Info() (*acor.AhoCorasickInfo, error)
+	InfoContext(ctx context.Context) (*acor.AhoCorasickInfo, error)
+	Flush() error
+	FlushContext(ctx context.Context) error
+	Score(text string, opts *acor.ScoreOptions) (*acor.ScoreResult, error)
+	ScoreContext(ctx context.Context, text string, opts *acor.ScoreOptions) (*acor.ScoreResult, error)
+	SetWeights(weights map[string]acor.KeywordWeight) error
+	SetWeightsContext(ctx context.Context, weights map[string]acor.KeywordWeight) error
+	Weights() (map[string]acor.KeywordWeight, error)
+	WeightsContext(ctx context.Context) (map[string]acor.KeywordWeight, error)
+	SetAlias(alias string) error
+	SetAliasContext(ctx context.Context, alias string) error
+	ResolveAlias(alias string) (string, error)
+	ResolveAliasContext(ctx context.Context, alias string) (string, error)
+	DeleteAlias(alias string) (bool, error)
+	DeleteAliasContext(ctx context.Context, alias string) (bool, error)
+	Watch() (<-chan struct{}, error)
+	WatchContext(ctx context.Context) (<-chan struct{}, error)
+	Close() error
+}
+
+var (
+	_ Collection = (*acor.AhoCorasick)(nil)
+	_ Collection = (*RemoteAhoCorasick)(nil)
+)
+
+// Defaults for the zero Options.
+const (
+	DefaultTimeout             = 10 * time.Second
+	DefaultMaxAttempts         = 3
+	DefaultRetryBackoff        = 100 * time.Millisecond
+	DefaultMaxIdleConnsPerHost = 64
+)
+
+// Options configures a RemoteAhoCorasick. A nil *Options, like every zero
+// field, means the defaults.
+type Options struct {
+	// Timeout bounds each attempt of a call, within any deadline the call's
+	// context already has. Zero means DefaultTimeout, negative no bound of its
+	// own. A watch is not bounded.
+	Timeout time.Duration
+	// MaxAttempts is how many times a call is tried in all. Zero means
+	// DefaultMaxAttempts; 1 never retries.
+	MaxAttempts int
+	// RetryBackoff is the wait before retrying a call the server could not be
+	// reached for, doubling with each attempt. A retry the server asked for
+	// waits as long as it said instead. Zero means DefaultRetryBackoff.
+	RetryBackoff time.Duration
+
+	// APIKey is sent as X-API-Key, or "x-api-key" metadata on gRPC.
+	APIKey string
+	// Token is sent as a bearer token in Authorization.
+	Token string
+	// TLSConfig secures the connection: on gRPC, nil dials plaintext; on HTTP,
+	// it configures the client NewHTTP builds for an https URL.
+	TLSConfig *tls.Config
+
+	// HTTPClient makes NewHTTP's requests. Nil builds a client whose transport
+	// keeps MaxIdleConnsPerHost idle connections to the server.
+	HTTPClient *http.Client
+	// MaxIdleConnsPerHost sizes the connection pool of the client NewHTTP
+	// builds. Zero means DefaultMaxIdleConnsPerHost.
+	MaxIdleConnsPerHost int
+	// DialOptions are applied after NewGRPC's own, so a credentials option
+	// among them replaces the one TLSConfig selects.
+	DialOptions []grpc.DialOption
+}
+
+func (o *Options) withDefaults() Options {
+	var opts Options
+	if o != nil {
+		opts = *o
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = DefaultRetryBackoff
+	}
+	if opts.MaxIdleConnsPerHost <= 0 {
+		opts.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	return opts
+}
+
+// RemoteAhoCorasick is a keyword collection served by an acor server. Its
+// methods behave as *acor.AhoCorasick's do, except where their documentation
+// says otherwise. It is safe for concurrent use.
+type RemoteAhoCorasick struct {
+	transport transport
+	opts      Options
+	// ctx is the context of the methods that take none. Close cancels it,
+	// which also ends every watch.
+	ctx    context.Context
+	cancel context.CancelFunc
+	closed atomic.Bool
+}
+
+func newRemote(t transport, opts Options) *RemoteAhoCorasick {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RemoteAhoCorasick{transport: t, opts: opts, ctx: ctx, cancel: cancel}
+}
+
+// NewGRPC returns a collection served over gRPC at target, in the syntax of
+// grpc.NewClient. The connection is made on first use and shared by every call.
+func NewGRPC(target string, opts *Options) (*RemoteAhoCorasick, error) {
+	o := opts.withDefaults()
+	creds := insecure.NewCredentials()
+	if o.TLSConfig != nil {
+		creds = credentials.NewTLS(o.TLSConfig)
+	}
+	conn, err := grpc.NewClient(target, append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, o.DialOptions...)...)
+	if err != nil {
+		return nil, err
+	}
+	t := &grpcTransport{conn: conn}
+	if o.APIKey != "" {
+		t.credentials = append(t.credentials, "x-api-key", o.APIKey)
+	}
+	if o.Token != "" {
+		t.credentials = append(t.credentials, "authorization", "Bearer "+o.Token)
+	}
+	return newRemote(t, o), nil
+}
+
+// NewHTTP returns a collection served over HTTP/JSON at baseURL, the server's
+// root: "https://acor.internal" for routes under https://acor.internal/v1/.
+func NewHTTP(baseURL string, opts *Options) (*RemoteAhoCorasick, error) {
+	o := opts.withDefaults()
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("base URL %q is not an http or https URL", baseURL)
+	}
+	t := &httpTransport{client: o.HTTPClient, base: strings.TrimSuffix(u.String(), "/"), header: make(http.Header)}
+	if t.client == nil {
+		pool := http.DefaultTransport.(*http.Transport).Clone()
+		pool.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+		pool.TLSClientConfig = o.TLSConfig
+		t.client, t.owned = &http.Client{Transport: pool}, true
+	}
+	if o.APIKey != "" {
+		t.header.Set("X-API-Key", o.APIKey)
+	}
+	if o.Token != "" {
+		t.header.Set("Authorization", "Bearer "+o.Token)
+	}
+	return newRemote(t, o), nil
+}
+
+// Close releases the connections and ends every watch. A second Close returns
+// ErrClosed.
+func (rc *RemoteAhoCorasick) Close() error {
+	if rc.closed.Swap(true) {
+		return ErrClosed
+	}
+	rc.cancel()
+	return rc.transport.close()
+}
+
+// call makes a unary RPC, retrying it while the failure is one a retry can
+// get past and attempts remain.
+func (rc *RemoteAhoCorasick) call(ctx context.Context, rpc string, req, resp proto.Message) error {
+	if rc.closed.Load() {
+		return ErrClosed
+	}
+	backoff := rc.opts.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := rc.attempt(ctx, rpc, req, resp)
+		if err == nil || attempt == rc.opts.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+		var wait time.Duration
+		var serverErr *Error
+		switch {
+		case errors.As(err, &serverErr) && serverErr.RetryAfter > 0:
+			wait = serverErr.RetryAfter
+		case errors.Is(err, ErrUnavailable):
+			wait = backoff
+			backoff *= 2
+		default:
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+func (rc *RemoteAhoCorasick) attempt(ctx context.Context, rpc string, req, resp proto.Message) error {
+	if rc.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rc.opts.Timeout)
+		defer cancel()
+	}
+	return rc.transport.invoke(ctx, rpc, req, resp)
+}
+
+// Add inserts a keyword. Every call the client makes can be repeated safely,
+// retries included: an Add or Remove repeated after its first attempt took
+// effect reports a count of zero, as a duplicate does.
+func (rc *RemoteAhoCorasick) Add(keyword string) (int, error) {
+	return rc.AddContext(rc.ctx, keyword)
+}
+
+// AddContext is Add with an explicit context.
+func (rc *RemoteAhoCorasick) AddContext(ctx context.Context, keyword string) (int, error) {
+	return rc.write(ctx, "Add", keyword)
+}
+
+// Remove deletes a keyword.
+func (rc *RemoteAhoCorasick) Remove(keyword string) (int, error) {
+	return rc.RemoveContext(rc.ctx, keyword)
+}
+
+// RemoveContext is Remove with an explicit context.
+func (rc *RemoteAhoCorasick) RemoveContext(ctx context.Context, keyword string) (int, error) {
+	return rc.write(ctx, "Remove", keyword)
+}
+
+func (rc *RemoteAhoCorasick) write(ctx context.Context, rpc, keyword string) (int, error) {
+	resp := &acorv1.CountResponse{}
+	if err := rc.call(ctx, rpc, &acorv1.KeywordRequest{Keyword: keyword}, resp); err != nil {
+		return 0, err
+	}
+	acor.RecordVersion(ctx, resp.GetVersion())
+	return int(resp.GetCount()), nil
+}
+
+// AddMany adds keywords one request at a time, reporting each as the library's
+// best-effort mode does. The server has no batch RPC, so a transactional batch,
+// a TTL, and tags return ErrUnsupported.
+func (rc *RemoteAhoCorasick) AddMany(keywords []string, opts *acor.BatchOptions) (*acor.BatchResult, error) {
+	return rc.AddManyContext(rc.ctx, keywords, opts)
+}
+
+// AddManyContext is AddMany with an explicit context.
+func (rc *RemoteAhoCorasick) AddManyContext(ctx context.Context, keywords []string, opts *acor.BatchOptions) (*acor.BatchResult, error) {
+	if opts != nil && (opts.TTL != 0 || len(opts.Tags) > 0) {
+		return nil, ErrUnsupported
+	}
+	return rc.writeMany(ctx, "Add", keywords, opts)
+}
+
+// RemoveMany removes keywords one request at a time, as AddMany adds them.
+func (rc *RemoteAhoCorasick) RemoveMany(keywords []string, opts *acor.BatchOptions) (*acor.BatchResult, error) {
+	return rc.RemoveManyContext(rc.ctx, keywords, opts)
+}
+
+// RemoveManyContext is RemoveMany with an explicit context.
+func (rc *RemoteAhoCorasick) RemoveManyContext(ctx context.Context, keywords []string, opts *acor.BatchOptions) (*acor.BatchResult, error) {
+	return rc.writeMany(ctx, "Remove", keywords, opts)
+}
+
+func (rc *RemoteAhoCorasick) writeMany(ctx context.Context, rpc string, keywords []string, opts *acor.BatchOptions) (*acor.BatchResult, error) {
+	if opts != nil && opts.Mode == acor.BatchModeTransactional {
+		return nil, ErrUnsupported
+	}
+	result := &acor.BatchResult{
+		Added:   make([]string, 0),
+		Removed: make([]string, 0),
+		Failed:  make([]acor.KeywordError, 0),
+		Skipped: make([]string, 0),
+	}
+	changed := &result.Added
+	if rpc == "Remove" {
+		changed = &result.Removed
+	}
+	for _, keyword := range keywords {
+		count, err := rc.write(ctx, rpc, keyword)
+		switch {
+		case err != nil:
+			result.Failed = append(result.Failed, acor.KeywordError{Keyword: keyword, Error: err})
+		case count == 0:
+			result.Skipped = append(result.Skipped, keyword)
+		default:
+			*changed = append(*changed, keyword)
+		}
+	}
+	return result, nil
+}
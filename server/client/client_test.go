@@ -0,0 +1,573 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	"github.com/skyoo2003/acor/server"
+	"github.com/skyoo2003/acor/server/limit"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// memoryService is a collection held in memory that finds keywords by brute
+// force, in the automaton's scan order.
+type memoryService struct {
+	mu       sync.Mutex
+	keywords []string
+	version  int64
+	weights  map[string]acor.KeywordWeight
+	aliases  map[string]string
+	watchers map[chan struct{}]struct{}
+	// findErrs are returned by the next Find calls, one each.
+	findErrs  []error
+	findCalls int
+	// block, when set, holds Find until it is closed. The server detaches the
+	// service from the request's cancellation, so ctx cannot end the wait.
+	block      chan struct{}
+	minVersion int64
+	scoreOpts  *acor.ScoreOptions
+}
+
+func newMemoryService() *memoryService {
+	return &memoryService{
+		weights:  map[string]acor.KeywordWeight{},
+		aliases:  map[string]string{},
+		watchers: map[chan struct{}]struct{}{},
+	}
+}
+
+// set changes the service under its lock, as the server may be reading it.
+func (m *memoryService) set(f func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f()
+}
+
+func (m *memoryService) commit(ctx context.Context) {
+	m.version++
+	acor.RecordVersion(ctx, m.version)
+	for ch := range m.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (m *memoryService) AddContext(ctx context.Context, keyword string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if keyword == "" {
+		return 0, acor.ErrEmptyKeyword
+	}
+	if slices.Contains(m.keywords, keyword) {
+		return 0, nil
+	}
+	m.keywords = append(m.keywords, keyword)
+	m.commit(ctx)
+	return 1, nil
+}
+
+func (m *memoryService) RemoveContext(ctx context.Context, keyword string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.Index(m.keywords, keyword)
+	if i < 0 {
+		return 0, nil
+	}
+	m.keywords = slices.Delete(m.keywords, i, i+1)
+	m.commit(ctx)
+	return 1, nil
+}
+
+// scan reports each occurrence by end offset, longest keyword first.
+func (m *memoryService) scan(text string) []acor.Match {
+	byLength := slices.Clone(m.keywords)
+	slices.SortFunc(byLength, func(a, b string) int { return len([]rune(b)) - len([]rune(a)) })
+	runes := []rune(text)
+	var matches []acor.Match
+	for end := 1; end <= len(runes); end++ {
+		for _, kw := range byLength {
+			n := len([]rune(kw))
+			if n <= end && string(runes[end-n:end]) == kw {
+				matches = append(matches, acor.Match{Keyword: kw, Start: end - n, End: end})
+			}
+		}
+	}
+	return matches
+}
+
+func (m *memoryService) FindContext(ctx context.Context, text string) ([]string, error) {
+	m.mu.Lock()
+	m.findCalls++
+	m.minVersion = acor.MinVersion(ctx)
+	block := m.block
+	var err error
+	if len(m.findErrs) > 0 {
+		err, m.findErrs = m.findErrs[0], m.findErrs[1:]
+	}
+	m.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	found := []string{}
+	for _, match := range m.scan(text) {
+		found = append(found, match.Keyword)
+	}
+	return found, nil
+}
+
+func (m *memoryService) FindIndexContext(ctx context.Context, text string) (map[string][]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.minVersion = acor.MinVersion(ctx)
+	index := map[string][]int{}
+	for _, match := range m.scan(text) {
+		index[match.Keyword] = append(index[match.Keyword], match.Start)
+	}
+	return index, nil
+}
+
+func (m *memoryService) SuggestContext(_ context.Context, input string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	found := []string{}
+	for _, kw := range m.keywords {
+		if strings.HasPrefix(kw, input) {
+			found = append(found, kw)
+		}
+	}
+	return found, nil
+}
+
+func (m *memoryService) SuggestIndexContext(ctx context.Context, input string) (map[string][]int, error) {
+	found, _ := m.SuggestContext(ctx, input)
+	index := map[string][]int{}
+	for _, kw := range found {
+		index[kw] = []int{0}
+	}
+	return index, nil
+}
+
+func (m *memoryService) FlushContext(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keywords = nil
+	m.commit(ctx)
+	return nil
+}
+
+func (m *memoryService) Info() (*acor.AhoCorasickInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &acor.AhoCorasickInfo{Keywords: len(m.keywords)}, nil
+}
+
+func (m *memoryService) Score(_ string, opts *acor.ScoreOptions) (*acor.ScoreResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scoreOpts = opts
+	return &acor.ScoreResult{
+		Total:             3,
+		Flagged:           true,
+		FlaggedCategories: []string{"spam"},
+		Categories:        map[string]float64{"spam": 3},
+		Keywords:          map[string]acor.KeywordScore{"he": {Count: 2, Weight: 1.5, Category: "spam", Score: 3}},
+	}, nil
+}
+
+func (m *memoryService) SetWeights(weights map[string]acor.KeywordWeight) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for kw, w := range weights {
+		m.weights[kw] = w
+	}
+	return nil
+}
+
+func (m *memoryService) Weights() (map[string]acor.KeywordWeight, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return maps.Clone(m.weights), nil
+}
+
+func (m *memoryService) SetAlias(alias string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.aliases[alias] = m.Collection()
+	return nil
+}
+
+func (m *memoryService) ResolveAlias(alias string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	collection, ok := m.aliases[alias]
+	if !ok {
+		return "", acor.ErrAliasNotFound
+	}
+	return collection, nil
+}
+
+func (m *memoryService) DeleteAlias(alias string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.aliases[alias]
+	delete(m.aliases, alias)
+	return ok, nil
+}
+
+func (m *memoryService) Collection() string { return "rules" }
+
+func (m *memoryService) WatchContext(ctx context.Context) (<-chan struct{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	m.watchers[ch] = struct{}{}
+	context.AfterFunc(ctx, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.watchers, ch)
+		close(ch)
+	})
+	return ch, nil
+}
+
+func (m *memoryService) VersionContext(context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.version, nil
+}
+
+func (m *memoryService) HistoryContext(context.Context, int) ([]acor.HistoryEntry, error) {
+	return nil, nil
+}
+
+// transports serve a service and return a client of it over each protocol.
+var transports = map[string]func(t *testing.T, service server.Service, opts *Options) *RemoteAhoCorasick{
+	"grpc": func(t *testing.T, service server.Service, opts *Options) *RemoteAhoCorasick {
+		lis := bufconn.Listen(1 << 20)
+		srv := server.NewGRPCServer(service)
+		go func() { _ = srv.Serve(lis) }()
+		t.Cleanup(srv.Stop)
+		var o Options
+		if opts != nil {
+			o = *opts
+		}
+		o.DialOptions = append(o.DialOptions, grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }))
+		return newTestRemote(t)(NewGRPC("passthrough:///bufnet", &o))
+	},
+	"http": func(t *testing.T, service server.Service, opts *Options) *RemoteAhoCorasick {
+		return serveHTTP(t, server.NewHTTPHandler(service), opts)
+	},
+}
+
+func serveHTTP(t *testing.T, handler http.Handler, opts *Options) *RemoteAhoCorasick {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return newTestRemote(t)(NewHTTP(srv.URL, opts))
+}
+
+func newTestRemote(t *testing.T) func(*RemoteAhoCorasick, error) *RemoteAhoCorasick {
+	return func(rc *RemoteAhoCorasick, err error) *RemoteAhoCorasick {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = rc.Close() })
+		return rc
+	}
+}
+
+func TestRemoteAhoCorasick(t *testing.T) {
+	for name, dial := range transports {
+		t.Run(name, func(t *testing.T) {
+			service := newMemoryService()
+			rc := dial(t, service, nil)
+
+			for _, kw := range []string{"he", "she", "his", "hers"} {
+				if n, err := rc.Add(kw); n != 1 || err != nil {
+					t.Fatalf("Add(%q) = %d, %v", kw, n, err)
+				}
+			}
+			if n, err := rc.Add("he"); n != 0 || err != nil {
+				t.Errorf("Add(he) again = %d, %v; want 0", n, err)
+			}
+			batch, err := rc.AddMany([]string{"he", "hi"}, nil)
+			if err != nil || !reflect.DeepEqual(batch.Added, []string{"hi"}) || !reflect.DeepEqual(batch.Skipped, []string{"he"}) {
+				t.Errorf("AddMany = %+v, %v", batch, err)
+			}
+
+			if got, err := rc.Find("ushers"); err != nil || !reflect.DeepEqual(got, []string{"she", "he", "hers"}) {
+				t.Errorf("Find = %v, %v", got, err)
+			}
+			want := map[string][]int{"she": {1}, "he": {2}, "hers": {2}}
+			if got, err := rc.FindIndex("ushers"); err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("FindIndex = %v, %v", got, err)
+			}
+			matches, err := rc.FindMatches("ushers", nil)
+			if want := service.scan("ushers"); err != nil || !reflect.DeepEqual(matches, want) {
+				t.Errorf("FindMatches = %v, %v; want %v", matches, err, want)
+			}
+			matches, err = rc.FindMatches("ushers", &acor.MatchOptions{Kind: acor.MatchKindLeftmostLongest})
+			if want := []acor.Match{{Keyword: "she", Start: 1, End: 4}}; err != nil || !reflect.DeepEqual(matches, want) {
+				t.Errorf("FindMatches leftmost-longest = %v, %v", matches, err)
+			}
+			matches, err = rc.FindMatches("the hi", &acor.MatchOptions{WholeWord: true})
+			if want := []acor.Match{{Keyword: "hi", Start: 4, End: 6}}; err != nil || !reflect.DeepEqual(matches, want) {
+				t.Errorf("FindMatches whole-word = %v, %v", matches, err)
+			}
+			var streamed []acor.Match
+			err = rc.FindStream(strings.NewReader("ushers"), func(m acor.Match) bool {
+				streamed = append(streamed, m)
+				return false
+			})
+			if want := []acor.Match{{Keyword: "she", Start: 1, End: 4}}; err != nil || !reflect.DeepEqual(streamed, want) {
+				t.Errorf("FindStream = %v, %v", streamed, err)
+			}
+			if ok, err := rc.Contains("this"); !ok || err != nil {
+				t.Errorf("Contains = %v, %v", ok, err)
+			}
+			if got, err := rc.CountMatches("hehe"); err != nil || !reflect.DeepEqual(got, map[string]int{"he": 2}) {
+				t.Errorf("CountMatches = %v, %v", got, err)
+			}
+			if got, err := rc.Suggest("he"); err != nil || !reflect.DeepEqual(got, []string{"he", "hers"}) {
+				t.Errorf("Suggest = %v, %v", got, err)
+			}
+			if got, err := rc.SuggestIndex("she"); err != nil || !reflect.DeepEqual(got, map[string][]int{"she": {0}}) {
+				t.Errorf("SuggestIndex = %v, %v", got, err)
+			}
+
+			batch, err = rc.RemoveMany([]string{"hi", "hi"}, nil)
+			if err != nil || !reflect.DeepEqual(batch.Removed, []string{"hi"}) || !reflect.DeepEqual(batch.Skipped, []string{"hi"}) {
+				t.Errorf("RemoveMany = %+v, %v", batch, err)
+			}
+			if n, err := rc.Remove("his"); n != 1 || err != nil {
+				t.Errorf("Remove = %d, %v", n, err)
+			}
+			if info, err := rc.Info(); err != nil || info.Keywords != 3 {
+				t.Errorf("Info = %+v, %v; want 3 keywords", info, err)
+			}
+
+			weights := map[string]acor.KeywordWeight{"he": {Weight: 1.5, Category: "spam"}}
+			if err := rc.SetWeights(weights); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := rc.Weights(); err != nil || !reflect.DeepEqual(got, weights) {
+				t.Errorf("Weights = %v, %v", got, err)
+			}
+			opts := &acor.ScoreOptions{DefaultWeight: 1, Decay: 0.5, MaxOccurrences: 4, Threshold: 2, CategoryThresholds: map[string]float64{"spam": 1}}
+			score, err := rc.Score("hehe", opts)
+			var sent *acor.ScoreOptions
+			service.set(func() { sent = service.scoreOpts })
+			if err != nil || !reflect.DeepEqual(sent, opts) {
+				t.Fatalf("Score sent %+v, %v; want %+v", sent, err, opts)
+			}
+			if want, _ := service.Score("", nil); !reflect.DeepEqual(score, want) {
+				t.Errorf("Score = %+v, want %+v", score, want)
+			}
+
+			if err := rc.SetAlias("live"); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := rc.ResolveAlias("live"); got != "rules" || err != nil {
+				t.Errorf("ResolveAlias = %q, %v", got, err)
+			}
+			if ok, err := rc.DeleteAlias("live"); !ok || err != nil {
+				t.Errorf("DeleteAlias = %v, %v", ok, err)
+			}
+
+			if err := rc.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if info, err := rc.Info(); err != nil || info.Keywords != 0 {
+				t.Errorf("Info after Flush = %+v, %v", info, err)
+			}
+		})
+	}
+}
+
+func TestRemoteErrors(t *testing.T) {
+	for name, dial := range transports {
+		t.Run(name, func(t *testing.T) {
+			service := newMemoryService()
+			rc := dial(t, service, &Options{Timeout: 50 * time.Millisecond})
+
+			_, err := rc.Add("")
+			var serverErr *Error
+			if !errors.Is(err, acor.ErrEmptyKeyword) || !errors.As(err, &serverErr) ||
+				serverErr.Reason != acorv1.ErrorReason_EMPTY_KEYWORD || serverErr.Field != "keyword" {
+				t.Errorf("Add(\"\") = %#v, want EMPTY_KEYWORD on keyword", err)
+			}
+			if _, err := rc.ResolveAlias("missing"); !errors.Is(err, acor.ErrAliasNotFound) {
+				t.Errorf("ResolveAlias(missing) = %v, want ErrAliasNotFound", err)
+			}
+			if _, err := rc.FindMatches("he", &acor.MatchOptions{Tags: []string{"t"}}); !errors.Is(err, ErrUnsupported) {
+				t.Errorf("FindMatches with tags = %v, want ErrUnsupported", err)
+			}
+			if _, err := rc.AddMany([]string{"he"}, &acor.BatchOptions{Mode: acor.BatchModeTransactional}); !errors.Is(err, ErrUnsupported) {
+				t.Errorf("transactional AddMany = %v, want ErrUnsupported", err)
+			}
+
+			release := make(chan struct{})
+			service.set(func() { service.block = release })
+			if _, err := rc.Find("he"); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Find past the timeout = %v, want DeadlineExceeded", err)
+			}
+			service.set(func() { service.block = nil })
+			close(release)
+
+			if err := rc.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := rc.Find("he"); !errors.Is(err, ErrClosed) {
+				t.Errorf("Find after Close = %v, want ErrClosed", err)
+			}
+			if err := rc.Close(); !errors.Is(err, ErrClosed) {
+				t.Errorf("second Close = %v, want ErrClosed", err)
+			}
+		})
+	}
+}
+
+func TestRemoteRetriesWhatTheServerSays(t *testing.T) {
+	service := newMemoryService()
+	limited := &limit.Error{Kind: limit.KindCollection, Operation: "find", RetryAfter: 10 * time.Millisecond}
+	calls := func() (n int) {
+		service.set(func() { n = service.findCalls })
+		return n
+	}
+	service.set(func() { service.findErrs = []error{limited, limited} })
+	rc := transports["grpc"](t, service, nil)
+	if _, err := rc.Find("he"); err != nil || calls() != 3 {
+		t.Errorf("Find = %v after %d calls; want success on the third", err, calls())
+	}
+
+	service.set(func() { service.findErrs, service.findCalls = []error{limited}, 0 })
+	rc = transports["grpc"](t, service, &Options{MaxAttempts: 1})
+	_, err := rc.Find("he")
+	var serverErr *Error
+	if !errors.As(err, &serverErr) || serverErr.Reason != acorv1.ErrorReason_RATE_LIMITED || serverErr.RetryAfter != limited.RetryAfter {
+		t.Errorf("Find with one attempt = %#v, want RATE_LIMITED after %v", err, limited.RetryAfter)
+	}
+
+	service.set(func() { service.findErrs, service.findCalls = []error{acor.ErrEmptyKeyword}, 0 })
+	if _, err := rc.Find("he"); !errors.Is(err, acor.ErrEmptyKeyword) || calls() != 1 {
+		t.Errorf("Find = %v after %d calls; a reason without RetryInfo is final", err, calls())
+	}
+}
+
+func TestRemoteRetriesAnUnreachableServer(t *testing.T) {
+	handler := server.NewHTTPHandler(newMemoryService())
+	var failures atomic.Int32
+	failures.Store(2)
+	proxy := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures.Add(-1) >= 0 {
+			http.Error(w, "upstream down", http.StatusBadGateway)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	rc := serveHTTP(t, proxy, &Options{RetryBackoff: time.Millisecond})
+	if _, err := rc.Add("he"); err != nil {
+		t.Errorf("Add through two 502s = %v", err)
+	}
+
+	failures.Store(5)
+	if _, err := rc.Add("he"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Add past MaxAttempts = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestRemoteReadYourWrites(t *testing.T) {
+	for name, dial := range transports {
+		t.Run(name, func(t *testing.T) {
+			service := newMemoryService()
+			rc := dial(t, service, nil)
+
+			ctx, token := acor.WithVersionToken(context.Background())
+			if _, err := rc.AddContext(ctx, "he"); err != nil {
+				t.Fatal(err)
+			}
+			var version, minVersion int64
+			service.set(func() { version = service.version })
+			if token.Version() != version || version == 0 {
+				t.Errorf("token = %d, want the write's version %d", token.Version(), version)
+			}
+			if _, err := rc.FindMatchesContext(acor.WithMinVersion(context.Background(), token.Version()), "he", nil); err != nil {
+				t.Fatal(err)
+			}
+			service.set(func() { minVersion = service.minVersion })
+			if minVersion != version {
+				t.Errorf("the read sent min_version %d, want %d", minVersion, version)
+			}
+		})
+	}
+}
+
+func TestRemoteWatch(t *testing.T) {
+	for name, dial := range transports {
+		t.Run(name, func(t *testing.T) {
+			rc := dial(t, newMemoryService(), nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			changes, err := rc.WatchContext(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			closing, err := rc.Watch()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := rc.Add("he"); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-changes:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no change reported for Add")
+			}
+
+			cancel()
+			waitClosed(t, changes, "canceling the context")
+			_ = rc.Close()
+			waitClosed(t, closing, "Close")
+		})
+	}
+}
+
+func waitClosed(t *testing.T, ch <-chan struct{}, after string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("watch still open after %s", after)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+var (
+	// ErrUnsupported is returned for what a RemoteAhoCorasick cannot do over the
+	// server's API: an option the API has no field for, such as MatchOptions.Tags
+	// or a transactional batch. It is also what an *Error with the UNSUPPORTED
+	// reason unwraps to.
+	ErrUnsupported = errors.New("not supported by the remote collection")
+	// ErrUnavailable wraps a call that did not reach the server, or reached a
+	// proxy that could not reach it. Such calls are retried.
+	ErrUnavailable = errors.New("server unavailable")
+	// ErrClosed is returned by calls made after Close, and by a second Close.
+	ErrClosed = errors.New("client closed")
+)
+
+// reasonErrors maps the reasons whose failure the library has a sentinel for
+// to that sentinel, so errors.Is answers the same for a remote collection as
+// for an embedded one.
+var reasonErrors = map[acorv1.ErrorReason]error{
+	acorv1.ErrorReason_EMPTY_KEYWORD:        acor.ErrEmptyKeyword,
+	acorv1.ErrorReason_INVALID_WEIGHT:       acor.ErrInvalidWeight,
+	acorv1.ErrorReason_ALIAS_NOT_FOUND:      acor.ErrAliasNotFound,
+	acorv1.ErrorReason_ALIAS_CONFLICT:       acor.ErrAliasConflict,
+	acorv1.ErrorReason_V1_READ_ONLY:         acor.ErrV1ReadOnly,
+	acorv1.ErrorReason_READ_ONLY:            acor.ErrReadOnly,
+	acorv1.ErrorReason_CONCURRENCY_CONFLICT: acor.ErrConcurrencyConflict,
+	acorv1.ErrorReason_UNSUPPORTED:          ErrUnsupported,
+	acorv1.ErrorReason_DEADLINE_EXCEEDED:    context.DeadlineExceeded,
+	acorv1.ErrorReason_CANCELED:             context.Canceled,
+}
+
+// Error is a failure the server reported. Reason is the same on either
+// transport; match on it, or with errors.Is on the library sentinel it
+// unwraps to, rather than on Message.
+type Error struct {
+	// Reason is the failure's reason, ERROR_REASON_UNSPECIFIED when the server
+	// answered without one.
+	Reason acorv1.ErrorReason
+	// Message is the server's description, the underlying error's text.
+	Message string
+	// Operation names the collection operation that failed, when known.
+	Operation string
+	// Field is the request field the server rejected, for the reasons that
+	// name one.
+	Field string
+	// RetryAfter is how long the server asked to wait before retrying, or zero
+	// when retrying the same request cannot succeed.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Reason == acorv1.ErrorReason_ERROR_REASON_UNSPECIFIED {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Reason, e.Message)
+}
+
+// Unwrap returns the library sentinel for Reason, or nil when it has none.
+func (e *Error) Unwrap() error {
+	return reasonErrors[e.Reason]
+}
+
+// unavailable wraps err, a call that never got an answer from the server, as
+// ErrUnavailable.
+func unavailable(err error) error {
+	return fmt.Errorf("%w: %w", ErrUnavailable, err)
+}
@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// Find returns every keyword occurrence in text, in scan order.
+func (rc *RemoteAhoCorasick) Find(text string) ([]string, error) {
+	return rc.FindContext(rc.ctx, text)
+}
+
+// FindContext is Find with an explicit context.
+func (rc *RemoteAhoCorasick) FindContext(ctx context.Context, text string) ([]string, error) {
+	return rc.matches(ctx, "Find", text)
+}
+
+// FindIndex returns the rune offsets at which each keyword starts in text.
+func (rc *RemoteAhoCorasick) FindIndex(text string) (map[string][]int, error) {
+	return rc.FindIndexContext(rc.ctx, text)
+}
+
+// FindIndexContext is FindIndex with an explicit context.
+func (rc *RemoteAhoCorasick) FindIndexContext(ctx context.Context, text string) (map[string][]int, error) {
+	return rc.indexes(ctx, "FindIndex", text)
+}
+
+// Suggest returns the keywords that start with input.
+func (rc *RemoteAhoCorasick) Suggest(input string) ([]string, error) {
+	return rc.SuggestContext(rc.ctx, input)
+}
+
+// SuggestContext is Suggest with an explicit context.
+func (rc *RemoteAhoCorasick) SuggestContext(ctx context.Context, input string) ([]string, error) {
+	return rc.matches(ctx, "Suggest", input)
+}
+
+// SuggestIndex is Suggest with each keyword mapped to [0].
+func (rc *RemoteAhoCorasick) SuggestIndex(input string) (map[string][]int, error) {
+	return rc.SuggestIndexContext(rc.ctx, input)
+}
+
+// SuggestIndexContext is SuggestIndex with an explicit context.
+func (rc *RemoteAhoCorasick) SuggestIndexContext(ctx context.Context, input string) (map[string][]int, error) {
+	return rc.indexes(ctx, "SuggestIndex", input)
+}
+
+func (rc *RemoteAhoCorasick) matches(ctx context.Context, rpc, input string) ([]string, error) {
+	resp := &acorv1.MatchesResponse{}
+	if err := rc.call(ctx, rpc, &acorv1.InputRequest{Input: input, MinVersion: acor.MinVersion(ctx)}, resp); err != nil {
+		return nil, err
+	}
+	if resp.Matches == nil {
+		return []string{}, nil
+	}
+	return resp.Matches, nil
+}
+
+func (rc *RemoteAhoCorasick) indexes(ctx context.Context, rpc, input string) (map[string][]int, error) {
+	resp := &acorv1.MatchIndexesResponse{}
+	if err := rc.call(ctx, rpc, &acorv1.InputRequest{Input: input, MinVersion: acor.MinVersion(ctx)}, resp); err != nil {
+		return nil, err
+	}
+	out := make(map[string][]int, len(resp.GetMatches()))
+	for kw, p := range resp.GetMatches() {
+		positions := make([]int, len(p.GetPositions()))
+		for i, pos := range p.GetPositions() {
+			positions[i] = int(pos)
+		}
+		out[kw] = positions
+	}
+	return out, nil
+}
+
+// FindMatches returns the matches in text with their rune-offset spans, in
+// scan order. It is built from FindIndex, so the Kind, WholeWord, and WordRune
+// options are applied by the client; Tags and Limits need the collection
+// itself, and return ErrUnsupported.
+func (rc *RemoteAhoCorasick) FindMatches(text string, opts *acor.MatchOptions) ([]acor.Match, error) {
+	return rc.FindMatchesContext(rc.ctx, text, opts)
+}
+
+// FindMatchesContext is FindMatches with an explicit context.
+func (rc *RemoteAhoCorasick) FindMatchesContext(ctx context.Context, text string, opts *acor.MatchOptions) ([]acor.Match, error) {
+	if opts != nil && (len(opts.Tags) > 0 || opts.Limits != nil) {
+		return nil, ErrUnsupported
+	}
+	if text == "" {
+		return []acor.Match{}, nil
+	}
+	index, err := rc.FindIndexContext(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]acor.Match, 0, len(index))
+	for kw, starts := range index {
+		n := utf8.RuneCountInString(kw)
+		for _, start := range starts {
+			matches = append(matches, acor.Match{Keyword: kw, Start: start, End: start + n})
+		}
+	}
+	// Scan order: by end, and at one end the longer keyword first, as the
+	// automaton reports a keyword before its suffixes.
+	slices.SortFunc(matches, func(a, b acor.Match) int {
+		if a.End != b.End {
+			return cmp.Compare(a.End, b.End)
+		}
+		return cmp.Compare(a.Start, b.Start)
+	})
+	if opts == nil {
+		return matches, nil
+	}
+	if opts.WholeWord && len(matches) > 0 {
+		isWord := opts.WordRune
+		if isWord == nil {
+			isWord = isWordRune
+		}
+		runes := []rune(text)
+		kept := matches[:0]
+		for _, m := range matches {
+			if (m.Start == 0 || !isWord(runes[m.Start-1])) && (m.End >= len(runes) || !isWord(runes[m.End])) {
+				kept = append(kept, m)
+			}
+		}
+		matches = kept
+	}
+	if opts.Kind == acor.MatchKindLeftmostLongest {
+		slices.SortFunc(matches, func(a, b acor.Match) int {
+			if a.Start != b.Start {
+				return cmp.Compare(a.Start, b.Start)
+			}
+			return cmp.Compare(b.End, a.End)
+		})
+		kept, lastEnd := matches[:0], 0
+		for _, m := range matches {
+			if m.Start >= lastEnd {
+				kept = append(kept, m)
+				lastEnd = m.End
+			}
+		}
+		matches = kept
+	}
+	return matches, nil
+}
+
+// isWordRune is the library's default word rune for WholeWord.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mark, r) || r == '_'
+}
+
+// FindStream calls onMatch for each match in r, in scan order, until it
+// returns false. The server takes text in one request, so unlike the library's
+// FindStream this reads r to the end before the first match is reported; the
+// server's request size caps bound how much it can be.
+func (rc *RemoteAhoCorasick) FindStream(r io.Reader, onMatch func(acor.Match) bool) error {
+	return rc.FindStreamContext(rc.ctx, r, onMatch)
+}
+
+// FindStreamContext is FindStream with an explicit context.
+func (rc *RemoteAhoCorasick) FindStreamContext(ctx context.Context, r io.Reader, onMatch func(acor.Match) bool) error {
+	if r == nil || onMatch == nil {
+		return nil
+	}
+	text, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	matches, err := rc.FindMatchesContext(ctx, string(text), nil)
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		if !onMatch(m) {
+			break
+		}
+	}
+	return nil
+}
+
+// Contains reports whether text contains any keyword.
+func (rc *RemoteAhoCorasick) Contains(text string) (bool, error) {
+	return rc.ContainsContext(rc.ctx, text)
+}
+
+// ContainsContext is Contains with an explicit context.
+func (rc *RemoteAhoCorasick) ContainsContext(ctx context.Context, text string) (bool, error) {
+	if text == "" {
+		return false, nil
+	}
+	found, err := rc.FindContext(ctx, text)
+	return len(found) > 0, err
+}
+
+// CountMatches returns how many times each keyword occurs in text.
+func (rc *RemoteAhoCorasick) CountMatches(text string) (map[string]int, error) {
+	return rc.CountMatchesContext(rc.ctx, text)
+}
+
+// CountMatchesContext is CountMatches with an explicit context.
+func (rc *RemoteAhoCorasick) CountMatchesContext(ctx context.Context, text string) (map[string]int, error) {
+	index, err := rc.FindIndexContext(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(index))
+	for kw, starts := range index {
+		counts[kw] = len(starts)
+	}
+	return counts, nil
+}
@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"context"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// Info returns the collection's size. The server reports Keywords and Nodes;
+// the other fields stay zero.
+func (rc *RemoteAhoCorasick) Info() (*acor.AhoCorasickInfo, error) {
+	return rc.InfoContext(rc.ctx)
+}
+
+// InfoContext is Info with an explicit context.
+func (rc *RemoteAhoCorasick) InfoContext(ctx context.Context) (*acor.AhoCorasickInfo, error) {
+	resp := &acorv1.InfoResponse{}
+	if err := rc.call(ctx, "Info", &acorv1.EmptyRequest{}, resp); err != nil {
+		return nil, err
+	}
+	return &acor.AhoCorasickInfo{Keywords: int(resp.GetKeywords()), Nodes: int(resp.GetNodes())}, nil
+}
+
+// Flush deletes every keyword of the collection.
+func (rc *RemoteAhoCorasick) Flush() error {
+	return rc.FlushContext(rc.ctx)
+}
+
+// FlushContext is Flush with an explicit context.
+func (rc *RemoteAhoCorasick) FlushContext(ctx context.Context) error {
+	resp := &acorv1.StatusResponse{}
+	if err := rc.call(ctx, "Flush", &acorv1.EmptyRequest{}, resp); err != nil {
+		return err
+	}
+	acor.RecordVersion(ctx, resp.GetVersion())
+	return nil
+}
+
+// Score scores text against the collection's keyword weights.
+func (rc *RemoteAhoCorasick) Score(text string, opts *acor.ScoreOptions) (*acor.ScoreResult, error) {
+	return rc.ScoreContext(rc.ctx, text, opts)
+}
+
+// ScoreContext is Score with an explicit context.
+func (rc *RemoteAhoCorasick) ScoreContext(ctx context.Context, text string, opts *acor.ScoreOptions) (*acor.ScoreResult, error) {
+	req := &acorv1.ScoreRequest{Input: text}
+	if opts != nil {
+		req.DefaultWeight = opts.DefaultWeight
+		req.Decay = opts.Decay
+		req.MaxOccurrences = int64(opts.MaxOccurrences)
+		req.Threshold = opts.Threshold
+		req.CategoryThresholds = opts.CategoryThresholds
+	}
+	resp := &acorv1.ScoreResponse{}
+	if err := rc.call(ctx, "Score", req, resp); err != nil {
+		return nil, err
+	}
+	result := &acor.ScoreResult{
+		Total:             resp.GetTotal(),
+		Flagged:           resp.GetFlagged(),
+		FlaggedCategories: resp.GetFlaggedCategories(),
+		Categories:        resp.GetCategories(),
+		Keywords:          make(map[string]acor.KeywordScore, len(resp.GetKeywords())),
+	}
+	if result.Categories == nil {
+		result.Categories = map[string]float64{}
+	}
+	for kw, ks := range resp.GetKeywords() {
+		result.Keywords[kw] = acor.KeywordScore{
+			Count:    int(ks.GetCount()),
+			Weight:   ks.GetWeight(),
+			Category: ks.GetCategory(),
+			Score:    ks.GetScore(),
+		}
+	}
+	return result, nil
+}
+
+// SetWeights stores a weight, and optionally a category, for each keyword.
+func (rc *RemoteAhoCorasick) SetWeights(weights map[string]acor.KeywordWeight) error {
+	return rc.SetWeightsContext(rc.ctx, weights)
+}
+
+// SetWeightsContext is SetWeights with an explicit context.
+func (rc *RemoteAhoCorasick) SetWeightsContext(ctx context.Context, weights map[string]acor.KeywordWeight) error {
+	req := &acorv1.SetWeightsRequest{Weights: make(map[string]*acorv1.KeywordWeight, len(weights))}
+	for kw, w := range weights {
+		req.Weights[kw] = &acorv1.KeywordWeight{Weight: w.Weight, Category: w.Category}
+	}
+	return rc.call(ctx, "SetWeights", req, &acorv1.CountResponse{})
+}
+
+// Weights returns every stored keyword weight.
+func (rc *RemoteAhoCorasick) Weights() (map[string]acor.KeywordWeight, error) {
+	return rc.WeightsContext(rc.ctx)
+}
+
+// WeightsContext is Weights with an explicit context.
+func (rc *RemoteAhoCorasick) WeightsContext(ctx context.Context) (map[string]acor.KeywordWeight, error) {
+	resp := &acorv1.WeightsResponse{}
+	if err := rc.call(ctx, "Weights", &acorv1.EmptyRequest{}, resp); err != nil {
+		return nil, err
+	}
+	weights := make(map[string]acor.KeywordWeight, len(resp.GetWeights()))
+	for kw, w := range resp.GetWeights() {
+		weights[kw] = acor.KeywordWeight{Weight: w.GetWeight(), Category: w.GetCategory()}
+	}
+	return weights, nil
+}
+
+// SetAlias points alias at the collection the server serves.
+func (rc *RemoteAhoCorasick) SetAlias(alias string) error {
+	return rc.SetAliasContext(rc.ctx, alias)
+}
+
+// SetAliasContext is SetAlias with an explicit context.
+func (rc *RemoteAhoCorasick) SetAliasContext(ctx context.Context, alias string) error {
+	return rc.call(ctx, "SetAlias", &acorv1.AliasRequest{Alias: alias}, &acorv1.AliasResponse{})
+}
+
+// ResolveAlias returns the collection alias points at.
+func (rc *RemoteAhoCorasick) ResolveAlias(alias string) (string, error) {
+	return rc.ResolveAliasContext(rc.ctx, alias)
+}
+
+// ResolveAliasContext is ResolveAlias with an explicit context.
+func (rc *RemoteAhoCorasick) ResolveAliasContext(ctx context.Context, alias string) (string, error) {
+	resp := &acorv1.AliasResponse{}
+	if err := rc.call(ctx, "ResolveAlias", &acorv1.AliasRequest{Alias: alias}, resp); err != nil {
+		return "", err
+	}
+	return resp.GetCollection(), nil
+}
+
+// DeleteAlias removes alias, reporting whether it existed.
+func (rc *RemoteAhoCorasick) DeleteAlias(alias string) (bool, error) {
+	return rc.DeleteAliasContext(rc.ctx, alias)
+}
+
+// DeleteAliasContext is DeleteAlias with an explicit context.
+func (rc *RemoteAhoCorasick) DeleteAliasContext(ctx context.Context, alias string) (bool, error) {
+	resp := &acorv1.DeleteAliasResponse{}
+	if err := rc.call(ctx, "DeleteAlias", &acorv1.AliasRequest{Alias: alias}, resp); err != nil {
+		return false, err
+	}
+	return resp.GetDeleted(), nil
+}
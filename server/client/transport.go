@@ -0,0 +1,317 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// errorDomain is the google.rpc.ErrorInfo domain the server's reasons carry.
+const errorDomain = "acor.server.v1"
+
+// transport makes the server's RPCs over one protocol. A failure the server
+// reported is an *Error, a call that never reached it wraps ErrUnavailable,
+// and a call its context ended returns the context's error.
+type transport interface {
+	// invoke makes the unary RPC named rpc, filling resp from the answer.
+	invoke(ctx context.Context, rpc string, req, resp proto.Message) error
+	// watch opens a Watch. recv returns its events in order, and an error once
+	// the stream ends.
+	watch(ctx context.Context, req *acorv1.WatchRequest) (recv func() (*acorv1.ChangeEvent, error), err error)
+	close() error
+}
+
+// grpcTransport calls the Acor service on a ClientConn, which multiplexes every
+// call over its connections.
+type grpcTransport struct {
+	conn *grpc.ClientConn
+	// credentials are metadata key-value pairs sent with every call.
+	credentials []string
+}
+
+func (t *grpcTransport) outgoing(ctx context.Context) context.Context {
+	if len(t.credentials) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, t.credentials...)
+}
+
+func (t *grpcTransport) invoke(ctx context.Context, rpc string, req, resp proto.Message) error {
+	method := "/" + acorv1.Acor_ServiceDesc.ServiceName + "/" + rpc
+	return grpcErr(ctx, t.conn.Invoke(t.outgoing(ctx), method, req, resp))
+}
+
+func (t *grpcTransport) watch(ctx context.Context, req *acorv1.WatchRequest) (func() (*acorv1.ChangeEvent, error), error) {
+	stream, err := acorv1.NewAcorClient(t.conn).Watch(t.outgoing(ctx), req)
+	if err != nil {
+		return nil, grpcErr(ctx, err)
+	}
+	return func() (*acorv1.ChangeEvent, error) {
+		e, err := stream.Recv()
+		if err != nil {
+			return nil, grpcErr(ctx, err)
+		}
+		return e, nil
+	}, nil
+}
+
+func (t *grpcTransport) close() error {
+	return t.conn.Close()
+}
+
+// grpcErr converts the error of a call made under ctx: the details of an
+// acor status become an *Error, and an UNAVAILABLE status without them is a
+// connection failure.
+func grpcErr(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, io.EOF) {
+		return err
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	e := &Error{Message: st.Message()}
+	var known bool
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			if reason, ok := acorv1.ErrorReason_value[d.GetReason()]; ok && d.GetDomain() == errorDomain {
+				e.Reason = acorv1.ErrorReason(reason)
+				e.Operation = d.GetMetadata()["operation"]
+				known = true
+			}
+		case *errdetails.RetryInfo:
+			e.RetryAfter = d.GetRetryDelay().AsDuration()
+		case *errdetails.BadRequest:
+			if v := d.GetFieldViolations(); len(v) > 0 {
+				e.Field = v[0].GetField()
+			}
+		}
+	}
+	if !known {
+		switch st.Code() {
+		case codes.Unavailable:
+			return unavailable(err)
+		case codes.Unimplemented:
+			// A server older than the RPC.
+			e.Reason = acorv1.ErrorReason_UNSUPPORTED
+		}
+	}
+	return e
+}
+
+// route is the HTTP method and path of one RPC.
+type route struct {
+	method, path string
+}
+
+// routes maps each RPC to its route, read from the google.api.http options in
+// acor.proto as the server reads them.
+var routes = sync.OnceValue(func() map[string]route {
+	methods := acorv1.File_acor_v1_acor_proto.Services().ByName("Acor").Methods()
+	out := make(map[string]route, methods.Len())
+	for i := 0; i < methods.Len(); i++ {
+		m := methods.Get(i)
+		rule, _ := proto.GetExtension(m.Options(), annotations.E_Http).(*annotations.HttpRule)
+		switch p := rule.GetPattern().(type) {
+		case *annotations.HttpRule_Get:
+			out[string(m.Name())] = route{http.MethodGet, p.Get}
+		case *annotations.HttpRule_Post:
+			out[string(m.Name())] = route{http.MethodPost, p.Post}
+		}
+	}
+	return out
+})
+
+// httpTransport calls the HTTP/JSON API. The server's JSON is each message
+// under its proto field names with 64-bit integers as numbers, which is what
+// encoding/json makes of the generated structs' tags; only the index matches
+// differ, as plain arrays.
+type httpTransport struct {
+	client *http.Client
+	base   string
+	header http.Header
+	// owned is set when the transport built client, and may close its idle
+	// connections.
+	owned bool
+}
+
+func (t *httpTransport) do(ctx context.Context, rpc string, query url.Values, req proto.Message, accept string) (*http.Response, error) {
+	r, ok := routes()[rpc]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	target := t.base + r.path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var body io.Reader
+	if r.method == http.MethodPost && req != nil {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, r.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range t.header {
+		httpReq.Header[k] = v
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Accept", accept)
+	res, err := t.client.Do(httpReq)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, unavailable(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, problemErr(res)
+	}
+	return res, nil
+}
+
+func (t *httpTransport) invoke(ctx context.Context, rpc string, req, resp proto.Message) error {
+	res, err := t.do(ctx, rpc, nil, req, "application/json")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if indexes, ok := resp.(*acorv1.MatchIndexesResponse); ok {
+		var body struct {
+			Matches map[string][]int64 `json:"matches"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			return err
+		}
+		indexes.Matches = make(map[string]*acorv1.Positions, len(body.Matches))
+		for kw, positions := range body.Matches {
+			indexes.Matches[kw] = &acorv1.Positions{Positions: positions}
+		}
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(resp)
+}
+
+// sseEvent is the data of a Server-Sent Event on /v1/watch, whose kind is a
+// lower-case name rather than the enum.
+type sseEvent struct {
+	Kind          string   `json:"kind"`
+	Version       int64    `json:"version"`
+	PrevVersion   int64    `json:"prev_version"`
+	Op            string   `json:"op"`
+	Added         []string `json:"added"`
+	Removed       []string `json:"removed"`
+	KeywordsKnown bool     `json:"keywords_known"`
+}
+
+func (t *httpTransport) watch(ctx context.Context, req *acorv1.WatchRequest) (func() (*acorv1.ChangeEvent, error), error) {
+	var query url.Values
+	if v := req.GetFromVersion(); v != 0 {
+		query = url.Values{"from_version": {strconv.FormatInt(v, 10)}}
+	}
+	res, err := t.do(ctx, "Watch", query, nil, "text/event-stream")
+	if err != nil {
+		return nil, err
+	}
+	lines := bufio.NewScanner(res.Body)
+	return func() (*acorv1.ChangeEvent, error) {
+		var data []byte
+		for lines.Scan() {
+			line := lines.Text()
+			if rest, ok := strings.CutPrefix(line, "data:"); ok {
+				data = append(data, strings.TrimPrefix(rest, " ")...)
+				continue
+			}
+			if line != "" || data == nil {
+				continue // another field, a keep-alive comment, or no event yet
+			}
+			var e sseEvent
+			if err := json.Unmarshal(data, &e); err != nil {
+				res.Body.Close()
+				return nil, err
+			}
+			kind := acorv1.ChangeEvent_Kind(acorv1.ChangeEvent_Kind_value[strings.ToUpper(e.Kind)])
+			return &acorv1.ChangeEvent{
+				Kind: kind, Version: e.Version, PrevVersion: e.PrevVersion, Op: e.Op,
+				Added: e.Added, Removed: e.Removed, KeywordsKnown: e.KeywordsKnown,
+			}, nil
+		}
+		res.Body.Close()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err := lines.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}, nil
+}
+
+func (t *httpTransport) close() error {
+	if t.owned {
+		t.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// problemErr reads the problem document of a failed response. A gateway or
+// proxy failure without one is ErrUnavailable.
+func problemErr(res *http.Response) error {
+	var p struct {
+		Detail        string `json:"detail"`
+		Code          string `json:"code"`
+		Operation     string `json:"operation"`
+		InvalidParams []struct {
+			Name string `json:"name"`
+		} `json:"invalid_params"`
+	}
+	_ = json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&p)
+	reason, known := acorv1.ErrorReason_value[p.Code]
+	if !known {
+		switch res.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return unavailable(errors.New(res.Status))
+		}
+		return &Error{Message: res.Status}
+	}
+	e := &Error{Reason: acorv1.ErrorReason(reason), Message: p.Detail, Operation: p.Operation}
+	if len(p.InvalidParams) > 0 {
+		e.Field = p.InvalidParams[0].Name
+	}
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return e
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"context"
+
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// Watch reports writes to the collection, as the library's Watch does: the
+// channel receives a value after each write, writes landing while a value is
+// still waiting share it, and the channel is closed when the watch ends. That
+// is when the context is canceled, the RemoteAhoCorasick is closed, or the
+// stream to the server breaks; a watch is not retried, so compare versions
+// on reconnect as with an embedded collection.
+//
+// Watch returns once the server has the watch open, so every write that
+// commits after it returns is reported.
+func (rc *RemoteAhoCorasick) Watch() (<-chan struct{}, error) {
+	return rc.WatchContext(rc.ctx)
+}
+
+// WatchContext is Watch with an explicit context.
+func (rc *RemoteAhoCorasick) WatchContext(ctx context.Context) (<-chan struct{}, error) {
+	if rc.closed.Load() {
+		return nil, ErrClosed
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(rc.ctx, cancel)
+	end := func() {
+		stop()
+		cancel()
+	}
+	recv, err := rc.transport.watch(ctx, &acorv1.WatchRequest{})
+	if err == nil {
+		// A watch from no version opens with a resync at the current one, sent
+		// once the server is subscribed.
+		_, err = recv()
+	}
+	if err != nil {
+		end()
+		return nil, err
+	}
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		defer end()
+		for {
+			if _, err := recv(); err != nil {
+				return
+			}
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return changes, nil
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+)
+
+// ErrUnknownCollection is returned by FindAcrossContext for a requested
+// collection the server does not hold. It is a client mistake, so the HTTP
+// handler answers it with a 400 and the gRPC server with InvalidArgument.
+var ErrUnknownCollection = errors.New("unknown collection")
+
+// ErrFindAcrossUnsupported is returned by API.FindAcross when the Service does
+// not implement CrossFinder. The HTTP handler answers it with a 501 and the gRPC
+// server with Unimplemented.
+var ErrFindAcrossUnsupported = errors.New("service does not search across collections")
+
+// CrossFinder is implemented by a Service that can search several collections in
+// one pass. The multi-collection routes use it when the Service has it; a
+// *Collections does.
+type CrossFinder interface {
+	// FindAcrossContext searches input against the named collections, or all of
+	// them when collections is empty, returning matches labeled with their
+	// collection in scan order.
+	FindAcrossContext(ctx context.Context, collections []string, input string) ([]acor.Match, error)
+}
+
+// Collections is a Service over a primary collection that can also search a set
+// of others alongside it. Every single-collection route goes to the primary;
+// the multi-collection routes search the primary and the others through one
+// acor.Union, so the scan costs the same however many of them a request names.
+type Collections struct {
+	Service
+	primary *acor.AhoCorasick
+	members atomic.Pointer[unionMembers]
+}
+
+// unionMembers is the Union a Collections searches, with its members' names.
+type unionMembers struct {
+	union *acor.Union
+	names map[string]struct{}
+}
+
+// NewCollections returns a Collections serving primary, which also searches
+// others. The members must satisfy acor.NewUnion: the same case sensitivity and
+// distinct collections.
+func NewCollections(primary *acor.AhoCorasick, others ...*acor.AhoCorasick) (*Collections, error) {
+	c := &Collections{Service: primary, primary: primary}
+	if err := c.SetOthers(others...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// SetOthers replaces the collections searched alongside the primary, as a
+// configuration reload does. Requests already searching finish against the
+// previous set, so close a collection it drops only once those have had time to
+// finish. On error the previous set stays in place.
+func (c *Collections) SetOthers(others ...*acor.AhoCorasick) error {
+	union, err := acor.NewUnion(append([]*acor.AhoCorasick{c.primary}, others...)...)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]struct{})
+	for _, name := range union.Collections() {
+		names[name] = struct{}{}
+	}
+	c.members.Store(&unionMembers{union: union, names: names})
+	return nil
+}
+
+// Names returns the collections c searches, the primary first.
+func (c *Collections) Names() []string {
+	return c.members.Load().union.Collections()
+}
+
+// FindAcrossContext implements CrossFinder. Collections are named as they were
+// when NewCollections or the last SetOthers ran; a name it does not know fails
+// the whole call with ErrUnknownCollection before anything is scanned.
+func (c *Collections) FindAcrossContext(ctx context.Context, collections []string, input string) ([]acor.Match, error) {
+	members := c.members.Load()
+	var want map[string]struct{}
+	if len(collections) > 0 {
+		want = make(map[string]struct{}, len(collections))
+		for _, name := range collections {
+			if _, ok := members.names[name]; !ok {
+				return nil, fmt.Errorf("%w: %q", ErrUnknownCollection, name)
+			}
+			want[name] = struct{}{}
+		}
+	}
+	matches, err := members.union.FindMatchesContext(ctx, input, nil)
+	if err != nil || want == nil {
+		return matches, err
+	}
+	kept := matches[:0]
+	for _, m := range matches {
+		if _, ok := want[m.Collection]; ok {
+			kept = append(kept, m)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// fakeCrossService is a fakeService that also searches across collections,
+// holding the same matches for every input.
+type fakeCrossService struct {
+	fakeService
+	acrossMatches   []acor.Match
+	lastCollections []string
+}
+
+func (f *fakeCrossService) FindAcrossContext(_ context.Context, collections []string, input string) ([]acor.Match, error) {
+	f.lastInput = input
+	f.lastCollections = collections
+	for _, name := range collections {
+		if name == "missing" {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownCollection, name)
+		}
+	}
+	return f.acrossMatches, nil
+}
+
+var acrossMatches = []acor.Match{
+	{Keyword: keywordHE, Start: 0, End: 2, Collection: "en"},
+	{Keyword: keywordHE, Start: 0, End: 2, Collection: "de"},
+}
+
+func TestHTTPHandlerFindAcross(t *testing.T) {
+	service := &fakeCrossService{acrossMatches: acrossMatches}
+	server := httptest.NewServer(NewHTTPHandler(service))
+	defer server.Close()
+
+	var body FindAcrossResponse
+	doJSONRequest(t, http.MethodPost, server.URL+"/v1/find-across",
+		FindAcrossRequest{Input: inputHEHE, Collections: []string{"en", "de"}}, &body)
+	want := []CollectionMatch{
+		{Collection: "en", Keyword: keywordHE, Start: 0, End: 2},
+		{Collection: "de", Keyword: keywordHE, Start: 0, End: 2},
+	}
+	if !reflect.DeepEqual(body.Matches, want) {
+		t.Fatalf("find-across matches = %+v, want %+v", body.Matches, want)
+	}
+	if service.lastInput != inputHEHE || !slices.Equal(service.lastCollections, []string{"en", "de"}) {
+		t.Fatalf("find-across searched %q in %v", service.lastInput, service.lastCollections)
+	}
+
+	resp := doRawRequest(t, http.MethodPost, server.URL+"/v1/find-across",
+		mustJSONReader(t, FindAcrossRequest{Input: inputHEHE, Collections: []string{"missing"}}))
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("find-across of an unknown collection: status %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestHTTPHandlerFindAcrossUnsupported(t *testing.T) {
+	server := httptest.NewServer(NewHTTPHandler(&fakeService{}))
+	defer server.Close()
+
+	resp := doRawRequest(t, http.MethodPost, server.URL+"/v1/find-across",
+		mustJSONReader(t, FindAcrossRequest{Input: inputHEHE}))
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotImplemented {
+		t.Fatalf("find-across on a single-collection service: status %d, want 501", resp.StatusCode)
+	}
+}
+
+func TestGRPCServerFindAcross(t *testing.T) {
+	ctx := context.Background()
+	client := newGRPCTestClient(t, &fakeCrossService{acrossMatches: acrossMatches})
+
+	resp, err := client.FindAcross(ctx, &acorv1.FindAcrossRequest{Input: inputHEHE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.GetMatches(); len(got) != 2 || got[0].GetCollection() != "en" || got[1].GetCollection() != "de" ||
+		got[1].GetKeyword() != keywordHE || got[1].GetEnd() != 2 {
+		t.Fatalf("find-across = %+v", got)
+	}
+
+	_, err = client.FindAcross(ctx, &acorv1.FindAcrossRequest{Input: inputHEHE, Collections: []string{"missing"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("find-across of an unknown collection: expected InvalidArgument, got %v", err)
+	}
+
+	single := newGRPCTestClient(t, &fakeService{})
+	if _, err := single.FindAcross(ctx, &acorv1.FindAcrossRequest{Input: inputHEHE}); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("find-across on a single-collection service: expected Unimplemented, got %v", err)
+	}
+}
+
+func TestCollectionsSetOthers(t *testing.T) {
+	mr := miniredis.RunT(t)
+	open := func(name string, caseSensitive bool) *acor.AhoCorasick {
+		t.Helper()
+		ac, err := acor.Create(&acor.AhoCorasickArgs{Addr: mr.Addr(), Name: name, CaseSensitive: caseSensitive})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = ac.Close() })
+		if _, err := ac.Add(keywordHE); err != nil {
+			t.Fatal(err)
+		}
+		return ac
+	}
+	ctx := context.Background()
+	primary, en, de := open("primary", false), open("en", false), open("de", false)
+
+	c, err := NewCollections(primary, en)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetOthers(de); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Names(); !slices.Equal(got, []string{"primary", "de"}) {
+		t.Fatalf("Names() = %v after SetOthers(de)", got)
+	}
+	if _, err := c.FindAcrossContext(ctx, []string{"en"}, inputHEHE); !errors.Is(err, ErrUnknownCollection) {
+		t.Errorf("find-across of a dropped collection: %v, want ErrUnknownCollection", err)
+	}
+	matches, err := c.FindAcrossContext(ctx, []string{"de"}, inputHEHE)
+	if err != nil || len(matches) == 0 || matches[0].Collection != "de" {
+		t.Errorf("find-across of an added collection = %+v, %v", matches, err)
+	}
+
+	if err := c.SetOthers(open("cased", true)); !errors.Is(err, acor.ErrInvalidUnion) {
+		t.Errorf("SetOthers of a case-sensitive collection: %v, want ErrInvalidUnion", err)
+	}
+	if got := c.Names(); !slices.Equal(got, []string{"primary", "de"}) {
+		t.Errorf("Names() = %v after a failed SetOthers, want it unchanged", got)
+	}
+}
@@ -0,0 +1,443 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package config reads an acor server's settings from a YAML, TOML, or JSON
+// file, with environment variables overriding it. A Config holds what a
+// server main needs to open its collections, listen, log, trace, and limit
+// requests; Load reports every problem in the file at once rather than the
+// first. A Reloader re-reads the file on SIGHUP and hands the settings that can
+// change without a restart to the running server.
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	"github.com/skyoo2003/acor/server/limit"
+	"github.com/skyoo2003/acor/server/logging"
+	"github.com/skyoo2003/acor/server/tracing"
+)
+
+// ErrUnknownFormat is returned by Load for a file whose extension is not
+// .yaml, .yml, .toml, or .json.
+var ErrUnknownFormat = errors.New("config: unknown file format")
+
+// Config is an acor server's settings. Keys in a file are the json tag names,
+// in every format.
+type Config struct {
+	// Redis is where every collection is stored.
+	Redis Redis `json:"redis"`
+	// Collections are the collections the server opens. The first is the one
+	// the single-collection routes serve; find-across searches all of them.
+	Collections []Collection `json:"collections"`
+	Listen      Listen       `json:"listen"`
+	Log         Log          `json:"log"`
+	Tracing     Tracing      `json:"tracing"`
+	// Limits are the request limits, as limit.New takes them.
+	Limits limit.Config `json:"limits"`
+}
+
+// Redis mirrors the connection fields of acor.AhoCorasickArgs, which document
+// each of them.
+type Redis struct {
+	Addr             string            `json:"addr"`
+	Addrs            []string          `json:"addrs"`
+	MasterName       string            `json:"master_name"`
+	RingAddrs        map[string]string `json:"ring_addrs"`
+	Username         string            `json:"username"`
+	Password         string            `json:"password"`
+	SentinelUsername string            `json:"sentinel_username"`
+	SentinelPassword string            `json:"sentinel_password"`
+	DB               int               `json:"db"`
+	TLS              RedisTLS          `json:"tls"`
+	DialTimeout      Duration          `json:"dial_timeout"`
+	ReadTimeout      Duration          `json:"read_timeout"`
+	WriteTimeout     Duration          `json:"write_timeout"`
+	MaxRetries       int               `json:"max_retries"`
+	PoolSize         int               `json:"pool_size"`
+}
+
+// RedisTLS mirrors acor.TLSOptions. Enabled turns TLS on with the system roots
+// when no file is named; naming any field turns it on as well.
+type RedisTLS struct {
+	Enabled    bool   `json:"enabled"`
+	CAFile     string `json:"ca_file"`
+	CertFile   string `json:"cert_file"`
+	KeyFile    string `json:"key_file"`
+	ServerName string `json:"server_name"`
+}
+
+// options returns t as acor.AhoCorasickArgs.TLS takes it.
+func (t RedisTLS) options() *acor.TLSOptions {
+	opts := &acor.TLSOptions{CAFile: t.CAFile, CertFile: t.CertFile, KeyFile: t.KeyFile, ServerName: t.ServerName}
+	if !t.Enabled && *opts == (acor.TLSOptions{}) {
+		return nil
+	}
+	return opts
+}
+
+// Collection mirrors the per-collection fields of acor.AhoCorasickArgs.
+type Collection struct {
+	Name  string `json:"name"`
+	Alias string `json:"alias"`
+	// Preset is "none", "speed", "balanced", or "memory-efficient", as the
+	// acor CLI's -preset flag takes it.
+	Preset                   string   `json:"preset"`
+	SchemaVersion            int      `json:"schema_version"`
+	CaseSensitive            bool     `json:"case_sensitive"`
+	EnableCache              bool     `json:"enable_cache"`
+	RollbackTimeout          Duration `json:"rollback_timeout"`
+	InvalidationPollInterval Duration `json:"invalidation_poll_interval"`
+	ExpirySweepInterval      Duration `json:"expiry_sweep_interval"`
+	// History, when set, records the collection's writes; see
+	// acor.HistoryOptions.
+	History *History `json:"history"`
+}
+
+// History mirrors acor.HistoryOptions.
+type History struct {
+	MaxEntries int64  `json:"max_entries"`
+	Writer     string `json:"writer"`
+}
+
+// Listen is where the server accepts connections.
+type Listen struct {
+	HTTPAddr string `json:"http_addr"`
+	GRPCAddr string `json:"grpc_addr"`
+	// RESPAddr, when set, is where server.RESPServer accepts Redis clients.
+	RESPAddr string `json:"resp_addr"`
+	TLS      TLS    `json:"tls"`
+}
+
+// TLS names the files of the server's certificate. Leaving CertFile empty
+// serves plaintext.
+type TLS struct {
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+	// ClientCAFile, when set, is the CA bundle client certificates must chain
+	// to: the server then requires one, as auth.ClientCertificates needs.
+	ClientCAFile string `json:"client_ca_file"`
+}
+
+// Log is the server's logging.
+type Log struct {
+	// Level is a level logging.ParseLevel accepts. Empty means info.
+	Level string `json:"level"`
+}
+
+// Tracing mirrors tracing.Config.
+type Tracing struct {
+	Enabled     bool    `json:"enabled"`
+	ServiceName string  `json:"service_name"`
+	Endpoint    string  `json:"endpoint"`
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+// Duration is a time.Duration written as a string time.ParseDuration reads,
+// such as "250ms" or "1m30s".
+type Duration time.Duration
+
+// MarshalText writes d as time.Duration.String does.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText parses a time.ParseDuration string.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// presets are the Collection.Preset names, as the acor CLI spells them.
+var presets = map[string]acor.Preset{
+	"":                 acor.PresetNone,
+	"none":             acor.PresetNone,
+	"speed":            acor.PresetSpeed,
+	"balanced":         acor.PresetBalanced,
+	"memory-efficient": acor.PresetMemoryEfficient,
+}
+
+// Load reads the file at path, applies the environment's overrides (see
+// EnvPrefix), and validates the result. The format follows the extension. An
+// empty path reads no file, so the settings come from the environment alone.
+//
+// Every problem found is reported, joined into one error: keys the schema does
+// not have, values of the wrong type, environment variables that do not parse,
+// and each failed check of Validate.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	var errs []error
+	if path != "" {
+		if err := decodeFile(path, cfg, &errs); err != nil {
+			return nil, err
+		}
+	}
+	errs = append(errs, applyEnv(cfg, os.Environ())...)
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		if path != "" {
+			return nil, fmt.Errorf("config: %s:\n%w", path, err)
+		}
+		return nil, fmt.Errorf("config:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// decodeFile decodes the file at path into cfg, appending a problem with its
+// contents to errs. It returns an error only when the file cannot be read or
+// parsed at all.
+//
+// Every format is decoded to plain values and then through encoding/json, so
+// the json tags name the keys whichever format the file is in.
+func decodeFile(path string, cfg *Config, errs *[]error) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	var doc any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		var table map[string]any
+		_, err = toml.Decode(string(data), &table)
+		doc = table
+	case ".json":
+		err = json.Unmarshal(data, &doc)
+	default:
+		return fmt.Errorf("%w %q: %s", ErrUnknownFormat, ext, path)
+	}
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	if doc == nil {
+		return nil
+	}
+	doc = plain(doc)
+	*errs = append(*errs, check(doc, reflect.TypeFor[Config](), "")...)
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(cfg); err != nil {
+		// A value of the wrong type does not stop the decode, so the rest of
+		// the file still reaches Validate.
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			return fmt.Errorf("config: %s: %w", path, err)
+		}
+		*errs = append(*errs, fmt.Errorf("%s: want %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value))
+	}
+	return nil
+}
+
+// plain turns the maps a YAML decoder produces for non-string keys into maps
+// keyed by string, which encoding/json can write.
+func plain(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = plain(e)
+		}
+		return m
+	case map[string]any:
+		for k, e := range v {
+			v[k] = plain(e)
+		}
+		return v
+	case []any:
+		for i, e := range v {
+			v[i] = plain(e)
+		}
+		return v
+	case []map[string]any:
+		// TOML's arrays of tables.
+		list := make([]any, len(v))
+		for i, e := range v {
+			list[i] = plain(e)
+		}
+		return list
+	}
+	return v
+}
+
+// Validate checks c for settings that cannot work, reporting every one it
+// finds, joined into one error. It checks what can be known before connecting;
+// acor.Create still checks the Redis topology.
+func (c *Config) Validate() error {
+	var p problems
+	c.validateCollections(&p)
+	if c.Log.Level != "" {
+		if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+			p.add("log.level", "unknown level %q", c.Log.Level)
+		}
+	}
+	if c.Tracing.Enabled && c.Tracing.Endpoint == "" {
+		p.add("tracing.endpoint", "is required when tracing is enabled")
+	}
+	if r := c.Tracing.SampleRatio; r < 0 || r > 1 {
+		p.add("tracing.sample_ratio", "must be between 0 and 1, not %g", r)
+	}
+	if t := c.Redis.TLS; (t.CertFile == "") != (t.KeyFile == "") {
+		p.add("redis.tls", "cert_file and key_file must be set together")
+	}
+	if t := c.Listen.TLS; (t.CertFile == "") != (t.KeyFile == "") {
+		p.add("listen.tls", "cert_file and key_file must be set together")
+	} else if t.ClientCAFile != "" && t.CertFile == "" {
+		p.add("listen.tls.client_ca_file", "requires cert_file")
+	}
+	c.validateLimits(&p)
+	return errors.Join(p...)
+}
+
+// problems collects what Validate finds, each prefixed by the key it concerns.
+type problems []error
+
+func (p *problems) add(field, format string, args ...any) {
+	*p = append(*p, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+}
+
+func (c *Config) validateCollections(p *problems) {
+	if len(c.Collections) == 0 {
+		p.add("collections", "at least one collection is required")
+	}
+	seen := make(map[Collection]int, len(c.Collections))
+	for i, col := range c.Collections {
+		field := fmt.Sprintf("collections[%d]", i)
+		switch {
+		case col.Name == "" && col.Alias == "":
+			p.add(field, "name or alias is required")
+		case col.Name != "" && col.Alias != "":
+			p.add(field, "name and alias are mutually exclusive")
+		}
+		key := Collection{Name: col.Name, Alias: col.Alias}
+		if first, dup := seen[key]; dup {
+			p.add(field, "same collection as collections[%d]", first)
+		} else {
+			seen[key] = i
+		}
+		if _, ok := presets[col.Preset]; !ok {
+			p.add(field+".preset", "unknown preset %q", col.Preset)
+		}
+		if v := col.SchemaVersion; v != 0 && v != acor.SchemaV1 && v != acor.SchemaV2 {
+			p.add(field+".schema_version", "must be 1 or 2, not %d", v)
+		}
+		if col.History != nil && col.History.MaxEntries < 0 {
+			p.add(field+".history.max_entries", "must not be negative")
+		}
+		if i > 0 && col.CaseSensitive != c.Collections[0].CaseSensitive {
+			// acor.NewUnion refuses the mix; find-across needs one automaton.
+			p.add(field+".case_sensitive", "must match collections[0]")
+		}
+	}
+}
+
+func (c *Config) validateLimits(p *problems) {
+	rate := func(field string, r limit.Rate) {
+		switch {
+		case r.PerSecond < 0 || r.Burst < 0:
+			p.add(field, "must not be negative")
+		case (r.PerSecond > 0) != (r.Burst > 0):
+			// A Rate missing either one limits nothing, which is never what
+			// setting the other meant.
+			p.add(field, "per_second and burst must be set together")
+		}
+	}
+	rate("limits.per_identity", c.Limits.PerIdentity)
+	rate("limits.per_collection", c.Limits.PerCollection)
+	for _, name := range slices.Sorted(maps.Keys(c.Limits.Operations)) {
+		op := c.Limits.Operations[name]
+		field := "limits.operations." + name
+		rate(field+".rate", op.Rate)
+		if op.MaxTextBytes < 0 || op.MaxBatch < 0 || op.MaxConcurrent < 0 {
+			p.add(field, "sizes and caps must not be negative")
+		}
+	}
+}
+
+// Args returns the acor.AhoCorasickArgs that open c.Collections[i].
+func (c *Config) Args(i int) *acor.AhoCorasickArgs {
+	col := c.Collections[i]
+	args := &acor.AhoCorasickArgs{
+		Addr:                     c.Redis.Addr,
+		Addrs:                    c.Redis.Addrs,
+		MasterName:               c.Redis.MasterName,
+		RingAddrs:                c.Redis.RingAddrs,
+		Username:                 c.Redis.Username,
+		Password:                 c.Redis.Password,
+		SentinelUsername:         c.Redis.SentinelUsername,
+		SentinelPassword:         c.Redis.SentinelPassword,
+		DB:                       c.Redis.DB,
+		TLS:                      c.Redis.TLS.options(),
+		DialTimeout:              time.Duration(c.Redis.DialTimeout),
+		ReadTimeout:              time.Duration(c.Redis.ReadTimeout),
+		WriteTimeout:             time.Duration(c.Redis.WriteTimeout),
+		MaxRetries:               c.Redis.MaxRetries,
+		PoolSize:                 c.Redis.PoolSize,
+		Name:                     col.Name,
+		Alias:                    col.Alias,
+		Preset:                   presets[col.Preset],
+		SchemaVersion:            col.SchemaVersion,
+		CaseSensitive:            col.CaseSensitive,
+		EnableCache:              col.EnableCache,
+		RollbackTimeout:          time.Duration(col.RollbackTimeout),
+		InvalidationPollInterval: time.Duration(col.InvalidationPollInterval),
+		ExpirySweepInterval:      time.Duration(col.ExpirySweepInterval),
+	}
+	if col.History != nil {
+		args.History = &acor.HistoryOptions{MaxEntries: col.History.MaxEntries, Writer: col.History.Writer}
+	}
+	return args
+}
+
+// TracingConfig returns c.Tracing as tracing.NewTracer takes it.
+func (c *Config) TracingConfig() *tracing.Config {
+	t := c.Tracing
+	return &tracing.Config{Enabled: t.Enabled, ServiceName: t.ServiceName, Endpoint: t.Endpoint, SampleRatio: t.SampleRatio}
+}
+
+// Config loads the certificate t names, returning nil when it names none.
+func (t TLS) Config() (*tls.Config, error) {
+	if t.CertFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if t.ClientCAFile != "" {
+		pem, err := os.ReadFile(t.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("config: %s holds no PEM certificates", t.ClientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
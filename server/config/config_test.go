@@ -0,0 +1,409 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	"github.com/skyoo2003/acor/server/limit"
+)
+
+const yamlConfig = `
+redis:
+  addrs: [redis-1:6379, redis-2:6379]
+  password: secret
+  dial_timeout: 2s
+  pool_size: 20
+collections:
+  - name: rules
+    preset: balanced
+    invalidation_poll_interval: 30s
+    history:
+      max_entries: 500
+  - alias: blocklist
+listen:
+  http_addr: ":8080"
+  grpc_addr: ":9090"
+  resp_addr: ":6380"
+log:
+  level: debug
+tracing:
+  enabled: true
+  service_name: acor
+  endpoint: otel:4317
+  sample_ratio: 0.25
+limits:
+  per_identity: {per_second: 50, burst: 100}
+  operations:
+    find: {max_text_bytes: 65536, max_concurrent: 32}
+`
+
+const tomlConfig = `
+[redis]
+addrs = ["redis-1:6379", "redis-2:6379"]
+password = "secret"
+dial_timeout = "2s"
+pool_size = 20
+
+[[collections]]
+name = "rules"
+preset = "balanced"
+invalidation_poll_interval = "30s"
+history = { max_entries = 500 }
+
+[[collections]]
+alias = "blocklist"
+
+[listen]
+http_addr = ":8080"
+grpc_addr = ":9090"
+resp_addr = ":6380"
+
+[log]
+level = "debug"
+
+[tracing]
+enabled = true
+service_name = "acor"
+endpoint = "otel:4317"
+sample_ratio = 0.25
+
+[limits.per_identity]
+per_second = 50
+burst = 100
+
+[limits.operations.find]
+max_text_bytes = 65536
+max_concurrent = 32
+`
+
+const jsonConfig = `{
+  "redis": {"addrs": ["redis-1:6379", "redis-2:6379"], "password": "secret", "dial_timeout": "2s", "pool_size": 20},
+  "collections": [
+    {"name": "rules", "preset": "balanced", "invalidation_poll_interval": "30s", "history": {"max_entries": 500}},
+    {"alias": "blocklist"}
+  ],
+  "listen": {"http_addr": ":8080", "grpc_addr": ":9090", "resp_addr": ":6380"},
+  "log": {"level": "debug"},
+  "tracing": {"enabled": true, "service_name": "acor", "endpoint": "otel:4317", "sample_ratio": 0.25},
+  "limits": {
+    "per_identity": {"per_second": 50, "burst": 100},
+    "operations": {"find": {"max_text_bytes": 65536, "max_concurrent": 32}}
+  }
+}`
+
+var wantConfig = &Config{
+	Redis: Redis{
+		Addrs:       []string{"redis-1:6379", "redis-2:6379"},
+		Password:    "secret",
+		DialTimeout: Duration(2 * time.Second),
+		PoolSize:    20,
+	},
+	Collections: []Collection{
+		{Name: "rules", Preset: "balanced", InvalidationPollInterval: Duration(30 * time.Second), History: &History{MaxEntries: 500}},
+		{Alias: "blocklist"},
+	},
+	Listen:  Listen{HTTPAddr: ":8080", GRPCAddr: ":9090", RESPAddr: ":6380"},
+	Log:     Log{Level: "debug"},
+	Tracing: Tracing{Enabled: true, ServiceName: "acor", Endpoint: "otel:4317", SampleRatio: 0.25},
+	Limits: limit.Config{
+		PerIdentity: limit.Rate{PerSecond: 50, Burst: 100},
+		Operations:  map[string]limit.OperationLimits{"find": {MaxTextBytes: 65536, MaxConcurrent: 32}},
+	},
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFormats(t *testing.T) {
+	for name, content := range map[string]string{
+		"acor.yaml": yamlConfig,
+		"acor.toml": tomlConfig,
+		"acor.json": jsonConfig,
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Load(writeFile(t, name, content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg, wantConfig) {
+				t.Errorf("Load = %+v, want %+v", cfg, wantConfig)
+			}
+		})
+	}
+
+	if _, err := Load(writeFile(t, "acor.ini", "")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Load(acor.ini) = %v, want ErrUnknownFormat", err)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	path := writeFile(t, "acor.yaml", `
+redis:
+  adr: localhost:6379
+  db: zero
+  dial_timeout: 5 seconds
+collections:
+  - name: rules
+    preset: quick
+  - name: rules
+    case_sensitive: true
+log:
+  level: loud
+tracing:
+  enabled: true
+limits:
+  per_identity: {per_second: 10}
+  operations:
+    find: {max_batch: -1}
+`)
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("Load succeeded, want every problem reported")
+	}
+	for _, want := range []string{
+		"redis.adr: unknown key",
+		`redis.dial_timeout: time: unknown unit " seconds"`,
+		"redis.db: want int, got string",
+		`collections[0].preset: unknown preset "quick"`,
+		"collections[1]: same collection as collections[0]",
+		"collections[1].case_sensitive: must match collections[0]",
+		`log.level: unknown level "loud"`,
+		"tracing.endpoint: is required when tracing is enabled",
+		"limits.per_identity: per_second and burst must be set together",
+		"limits.operations.find: sizes and caps must not be negative",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not report %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("ACOR_REDIS_ADDRS", "env-1:6379, env-2:6379")
+	t.Setenv("ACOR_REDIS_RING_ADDRS", "a=ring-a:6379,b=ring-b:6379")
+	t.Setenv("ACOR_REDIS_READ_TIMEOUT", "750ms")
+	t.Setenv("ACOR_COLLECTIONS_0_HISTORY_WRITER", "node-1")
+	t.Setenv("ACOR_COLLECTIONS_2_NAME", "extra")
+	t.Setenv("ACOR_LOG_LEVEL", "warn")
+	t.Setenv("ACOR_LIMITS_PER_IDENTITY_BURST", "10")
+	t.Setenv("ACOR_LISTEN_TLS_CERT_FILE", "")
+	t.Setenv("ACOR_API_KEY", "not a setting")
+
+	cfg, err := Load(writeFile(t, "acor.yaml", yamlConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cfg.Redis.Addrs, []string{"env-1:6379", "env-2:6379"}) {
+		t.Errorf("redis.addrs = %q", cfg.Redis.Addrs)
+	}
+	if cfg.Redis.RingAddrs["b"] != "ring-b:6379" || cfg.Redis.ReadTimeout != Duration(750*time.Millisecond) {
+		t.Errorf("redis = %+v", cfg.Redis)
+	}
+	if h := cfg.Collections[0].History; h.Writer != "node-1" || h.MaxEntries != 500 {
+		t.Errorf("collections[0].history = %+v, want the file's entry with the writer set", h)
+	}
+	if len(cfg.Collections) != 3 || cfg.Collections[2].Name != "extra" {
+		t.Errorf("collections = %+v, want a third from the environment", cfg.Collections)
+	}
+	if cfg.Log.Level != "warn" || cfg.Limits.PerIdentity != (limit.Rate{PerSecond: 50, Burst: 10}) {
+		t.Errorf("log = %+v, limits.per_identity = %+v", cfg.Log, cfg.Limits.PerIdentity)
+	}
+
+	t.Setenv("ACOR_REDIS_DB", "one")
+	t.Setenv("ACOR_TRACING_SAMPLE_RATIO", "2")
+	_, err = Load(writeFile(t, "acor.yaml", yamlConfig))
+	for _, want := range []string{`ACOR_REDIS_DB: "one" is not an integer`, "tracing.sample_ratio: must be between 0 and 1"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want it to report %q", err, want)
+		}
+	}
+}
+
+func TestLoadEnvListIndex(t *testing.T) {
+	// Entries 3 and 2 extend the file's two in either order; 5 would leave a gap.
+	t.Setenv("ACOR_COLLECTIONS_3_NAME", "fourth")
+	t.Setenv("ACOR_COLLECTIONS_2_NAME", "third")
+	cfg, err := Load(writeFile(t, "acor.yaml", yamlConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Collections) != 4 || cfg.Collections[2].Name != "third" || cfg.Collections[3].Name != "fourth" {
+		t.Errorf("collections = %+v, want two more from the environment", cfg.Collections)
+	}
+
+	t.Setenv("ACOR_COLLECTIONS_5_NAME", "sixth")
+	t.Setenv("ACOR_COLLECTIONS_999999999999_NAME", "far")
+	t.Setenv("ACOR_COLLECTIONS_99999999999999999999999_NAME", "farther")
+	_, err = Load(writeFile(t, "acor.yaml", yamlConfig))
+	for _, want := range []string{
+		"ACOR_COLLECTIONS_5_NAME: index past the end of the list: the next entry is 4",
+		"ACOR_COLLECTIONS_999999999999_NAME: index past the end of the list",
+		"ACOR_COLLECTIONS_99999999999999999999999_NAME: index past the end of the list",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want it to report %q", err, want)
+		}
+	}
+}
+
+func TestLoadEnvAlone(t *testing.T) {
+	t.Setenv("ACOR_REDIS_ADDR", "localhost:6379")
+	t.Setenv("ACOR_COLLECTIONS_0_NAME", "rules")
+	t.Setenv("ACOR_COLLECTIONS_0_PRESET", "speed")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := cfg.Args(0)
+	if args.Addr != "localhost:6379" || args.Name != "rules" || args.Preset != acor.PresetSpeed {
+		t.Errorf("Args(0) = %+v", args)
+	}
+}
+
+func TestArgs(t *testing.T) {
+	args := wantConfig.Args(0)
+	if !slices.Equal(args.Addrs, wantConfig.Redis.Addrs) || args.Password != "secret" || args.DialTimeout != 2*time.Second ||
+		args.PoolSize != 20 || args.Name != "rules" || args.Preset != acor.PresetBalanced ||
+		args.InvalidationPollInterval != 30*time.Second || args.History == nil || args.History.MaxEntries != 500 {
+		t.Errorf("Args(0) = %+v", args)
+	}
+	if args := wantConfig.Args(1); args.Alias != "blocklist" || args.Name != "" || args.History != nil {
+		t.Errorf("Args(1) = %+v", args)
+	}
+	if tc := wantConfig.TracingConfig(); !tc.Enabled || tc.Endpoint != "otel:4317" || tc.SampleRatio != 0.25 {
+		t.Errorf("TracingConfig() = %+v", tc)
+	}
+	if args.TLS != nil {
+		t.Errorf("Args(0).TLS = %+v, want nil without redis.tls", args.TLS)
+	}
+
+	secured := *wantConfig
+	secured.Redis.Username, secured.Redis.SentinelPassword = "acor", "sentinel-secret"
+	secured.Redis.TLS = RedisTLS{CAFile: "ca.pem"}
+	args = secured.Args(0)
+	if args.Username != "acor" || args.SentinelPassword != "sentinel-secret" ||
+		args.TLS == nil || *args.TLS != (acor.TLSOptions{CAFile: "ca.pem"}) {
+		t.Errorf("Args(0) with credentials and TLS = %+v", args)
+	}
+	secured.Redis.TLS = RedisTLS{Enabled: true}
+	if args := secured.Args(0); args.TLS == nil || *args.TLS != (acor.TLSOptions{}) {
+		t.Errorf("Args(0).TLS with tls.enabled = %+v, want system roots", args.TLS)
+	}
+}
+
+func TestReloader(t *testing.T) {
+	path := writeFile(t, "acor.yaml", yamlConfig)
+	current, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied []*Config
+	var applyErr error
+	r := NewReloader(path, current, func(cfg *Config) error {
+		applied = append(applied, cfg)
+		return applyErr
+	})
+
+	edited := strings.NewReplacer("level: debug", "level: error", "alias: blocklist", "name: spam",
+		"password: secret", "password: rotated").Replace(yamlConfig)
+	if err := os.WriteFile(path, []byte(edited), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	restart, err := r.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(restart, []string{"redis"}) {
+		t.Errorf("restart = %q, want [redis]", restart)
+	}
+	got := r.Current()
+	if len(applied) != 1 || got != applied[0] {
+		t.Fatalf("Current() is not the config applied")
+	}
+	if got.Log.Level != "error" || got.Collections[1].Name != "spam" || got.Redis.Password != "secret" {
+		t.Errorf("reloaded log = %+v, collections[1] = %+v, redis.password = %q; want the new level and collection, the running password",
+			got.Log, got.Collections[1], got.Redis.Password)
+	}
+
+	// A failed apply and an invalid file both leave the running config alone.
+	applyErr = errors.New("open spam: refused")
+	if err := os.WriteFile(path, []byte(yamlConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reload(); !errors.Is(err, applyErr) {
+		t.Errorf("Reload = %v, want the apply error", err)
+	}
+	if err := os.WriteFile(path, []byte("log: {level: loud}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reload(); err == nil {
+		t.Error("Reload of an invalid file succeeded")
+	}
+	if r.Current() != got || len(applied) != 2 {
+		t.Errorf("Current() changed after failed reloads, or an invalid file was applied")
+	}
+}
+
+func TestReloaderRunOnSIGHUP(t *testing.T) {
+	path := writeFile(t, "acor.yaml", yamlConfig)
+	current, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewReloader(path, current, func(*Config) error { return nil })
+	// Until Run subscribes, SIGHUP would end the test binary; a subscription
+	// of the test's own stops that.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	reports := make(chan error, 1)
+	done := make(chan struct{})
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go func() {
+		defer close(done)
+		r.Run(ctx, func(_ []string, err error) { reports <- err })
+	}()
+
+	// Run may not have subscribed yet, so signal until it reports.
+	deadline := time.After(5 * time.Second)
+	for reported := false; !reported; {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case err := <-reports:
+			if err != nil {
+				t.Fatal(err)
+			}
+			reported = true
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no reload after SIGHUP")
+		}
+	}
+	if r.Current() == current {
+		t.Error("Current() is unchanged after the reload")
+	}
+	cancel()
+	<-done
+}
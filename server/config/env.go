@@ -0,0 +1,281 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix starts the name of every environment variable Load reads. The rest
+// of the name is the setting's key path in upper case, joined by underscores:
+// ACOR_REDIS_ADDR sets redis.addr, ACOR_LOG_LEVEL sets log.level, and
+// ACOR_COLLECTIONS_0_NAME sets the first collection's name.
+//
+// A list is written comma-separated (ACOR_REDIS_ADDRS=a:6379,b:6379) and a map
+// as comma-separated key=value pairs. Maps of settings, such as
+// limits.operations, come from the file only. A variable that names no setting
+// is ignored, since the prefix is not this package's alone.
+const EnvPrefix = "ACOR_"
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// keyName returns the key f is written as, or "" for a field with none.
+func keyName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" || !f.IsExported() {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// textual reports whether t is set from a string by its UnmarshalText.
+func textual(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// check walks a decoded document against the type it will be decoded into. It
+// reports each key t has no field for and each value UnmarshalText refuses,
+// removing the latter so that the decode after it, which would stop at the
+// first, sees the rest of the document.
+func check(doc any, t reflect.Type, path string) []error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var errs []error
+	switch {
+	case textual(t):
+		return nil
+	case t.Kind() == reflect.Struct:
+		m, ok := doc.(map[string]any)
+		if !ok {
+			return nil
+		}
+		fields := make(map[string]reflect.StructField, t.NumField())
+		for i := range t.NumField() {
+			if name := keyName(t.Field(i)); name != "" {
+				fields[name] = t.Field(i)
+			}
+		}
+		for _, k := range slices.Sorted(maps.Keys(m)) {
+			f, ok := fields[k]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown key", join(path, k)))
+				continue
+			}
+			if ft := f.Type; textual(ft) {
+				if err := checkText(m[k], ft); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", join(path, k), err))
+					delete(m, k)
+				}
+				continue
+			}
+			errs = append(errs, check(m[k], f.Type, join(path, k))...)
+		}
+	case t.Kind() == reflect.Map:
+		if m, ok := doc.(map[string]any); ok {
+			for _, k := range slices.Sorted(maps.Keys(m)) {
+				errs = append(errs, check(m[k], t.Elem(), join(path, k))...)
+			}
+		}
+	case t.Kind() == reflect.Slice:
+		if list, ok := doc.([]any); ok {
+			for i, v := range list {
+				errs = append(errs, check(v, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+func checkText(v any, t reflect.Type) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("%v is not a string", v)
+	}
+	return reflect.New(t).Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// applyEnv sets the fields of cfg that environ's EnvPrefix variables name,
+// returning a problem for each value that does not parse.
+//
+// A variable may add only the entry just past the end of a list. environ is in
+// no particular order, so one naming an entry further on is tried again once
+// the others are set, in case they add the entries before it.
+func applyEnv(cfg *Config, environ []string) []error {
+	var errs []error
+	for pending := environ; len(pending) > 0; {
+		var retry []string
+		var gaps []error
+		for _, kv := range pending {
+			name, value, _ := strings.Cut(kv, "=")
+			rest, ok := strings.CutPrefix(name, EnvPrefix)
+			if !ok || rest == "" {
+				continue
+			}
+			err := setPath(reflect.ValueOf(cfg).Elem(), rest, value)
+			switch {
+			case err == nil, err == errNoSetting:
+			case errors.Is(err, errIndexPastEnd):
+				retry = append(retry, kv)
+				gaps = append(gaps, fmt.Errorf("%s: %w", name, err))
+			default:
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+		if len(retry) == len(pending) {
+			errs = append(errs, gaps...)
+			break
+		}
+		pending = retry
+	}
+	return errs
+}
+
+var (
+	// errNoSetting is setPath's answer for a name that matches no setting.
+	errNoSetting = errors.New("names no setting")
+	// errIndexPastEnd is setPath's answer for a list index that would leave
+	// entries before it unset.
+	errIndexPastEnd = errors.New("index past the end of the list")
+)
+
+// setPath sets the setting that rest, an environment variable name without its
+// prefix, names within v.
+func setPath(v reflect.Value, rest, value string) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if rest == "" {
+		return setValue(v, value)
+	}
+	switch {
+	case textual(v.Type()):
+	case v.Kind() == reflect.Struct:
+		// A key may hold underscores itself, so every field whose name the rest
+		// starts with is tried until one takes the value.
+		t := v.Type()
+		for i := range t.NumField() {
+			key := strings.ToUpper(keyName(t.Field(i)))
+			if key == "" {
+				continue
+			}
+			after, ok := strings.CutPrefix(rest, key)
+			if !ok || (after != "" && after[0] != '_') {
+				continue
+			}
+			field := v.Field(i)
+			if after == "" {
+				return setValue(field, value)
+			}
+			if err := setPath(field, after[1:], value); err != errNoSetting {
+				return err
+			}
+		}
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct:
+		index, after, _ := strings.Cut(rest, "_")
+		// An index too large for an int parses as the largest, which is past
+		// the end.
+		i, err := strconv.Atoi(index)
+		if (err != nil && !errors.Is(err, strconv.ErrRange)) || i < 0 {
+			return errNoSetting
+		}
+		// The entry is set on a copy, so a name that turns out to match no
+		// setting adds no entry.
+		entry := reflect.New(v.Type().Elem()).Elem()
+		if i < v.Len() {
+			entry.Set(v.Index(i))
+		}
+		if err := setPath(entry, after, value); err != nil {
+			return err
+		}
+		switch {
+		case i < v.Len():
+			v.Index(i).Set(entry)
+		case i == v.Len():
+			v.Set(reflect.Append(v, entry))
+		default:
+			return fmt.Errorf("%w: the next entry is %d", errIndexPastEnd, v.Len())
+		}
+		return nil
+	}
+	return errNoSetting
+}
+
+// setValue parses value into v, a leaf setting.
+func setValue(v reflect.Value, value string) error {
+	if textual(v.Type()) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a bool", value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return errNoSetting
+		}
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return errNoSetting
+		}
+		m := make(map[string]string)
+		for _, pair := range strings.Split(value, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			k, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("%q is not a key=value pair", pair)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return errNoSetting
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+)
+
+// Reloader re-reads a configuration file while the server runs. Log.Level,
+// Limits, and the collections after the first take effect on reload; the rest
+// of a Config is read once, since changing it means reconnecting to Redis,
+// reopening listeners, or replacing the collection every route serves.
+type Reloader struct {
+	path  string
+	apply func(*Config) error
+
+	mu      sync.Mutex
+	current *Config
+}
+
+// NewReloader returns a Reloader for the file at path, whose settings are now
+// current. apply puts a reloaded Config into effect: it sets the logger's
+// level, the limiter's config, and the collections find-across searches. It
+// may compare against Current, which still returns the previous Config while
+// apply runs.
+func NewReloader(path string, current *Config, apply func(*Config) error) *Reloader {
+	return &Reloader{path: path, apply: apply, current: current}
+}
+
+// Current returns the Config last put into effect.
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload loads the file again, as Load does, and passes it to apply. Settings
+// that need a restart keep their current values in what apply receives;
+// restart names those that changed in the file, so they can be reported. An
+// invalid file or a failed apply leaves the current Config in place.
+func (r *Reloader) Reload() (restart []string, err error) {
+	next, err := Load(r.path)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keep(&restart, "redis", r.current.Redis, &next.Redis)
+	keep(&restart, "collections[0]", r.current.Collections[0], &next.Collections[0])
+	keep(&restart, "listen", r.current.Listen, &next.Listen)
+	keep(&restart, "tracing", r.current.Tracing, &next.Tracing)
+	if err := r.apply(next); err != nil {
+		return restart, fmt.Errorf("config: apply %s: %w", r.path, err)
+	}
+	r.current = next
+	return restart, nil
+}
+
+// keep sets loaded back to running, naming it in restart, when they differ.
+func keep[T any](restart *[]string, name string, running T, loaded *T) {
+	if !reflect.DeepEqual(running, *loaded) {
+		*restart = append(*restart, name)
+		*loaded = running
+	}
+}
+
+// Run reloads on every SIGHUP until ctx is done, passing each outcome to
+// report. Connections and requests in flight are left alone.
+func (r *Reloader) Run(ctx context.Context, report func(restart []string, err error)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			report(r.Reload())
+		}
+	}
+}
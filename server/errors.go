@@ -0,0 +1,300 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	"github.com/skyoo2003/acor/server/auth"
+	"github.com/skyoo2003/acor/server/limit"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// Both adapters describe a failure the same way: classifyError reduces an error
+// to an acorv1.ErrorReason, and reasonClasses says what that reason is on each
+// transport. The reasons and their meaning are documented on the ErrorReason
+// enum in acor.proto; keep the two in step.
+
+// errorDomain is the google.rpc.ErrorInfo domain of every reason.
+const errorDomain = "acor.server.v1"
+
+// problemTypeBase prefixes a reason to form its RFC 9457 problem type, which
+// resolves to the reason's entry in the server error reference.
+const problemTypeBase = "https://skyoo2003.github.io/acor/server/errors/#"
+
+// reasonClass is what one ErrorReason means on each transport.
+type reasonClass struct {
+	title      string
+	httpStatus int
+	grpcCode   codes.Code
+	// retryDelay is how long a client should wait before retrying, or zero when
+	// retrying the same request cannot succeed.
+	retryDelay time.Duration
+	// field is the request field a BadRequest violation names, if any.
+	field string
+}
+
+var reasonClasses = map[acorv1.ErrorReason]reasonClass{
+	acorv1.ErrorReason_INVALID_BODY:         {"Invalid request body", http.StatusBadRequest, codes.InvalidArgument, 0, ""},
+	acorv1.ErrorReason_BODY_TOO_LARGE:       {"Request body too large", http.StatusRequestEntityTooLarge, codes.ResourceExhausted, 0, ""},
+	acorv1.ErrorReason_METHOD_NOT_ALLOWED:   {"Method not allowed", http.StatusMethodNotAllowed, codes.Unimplemented, 0, ""},
+	acorv1.ErrorReason_EMPTY_KEYWORD:        {"Empty keyword", http.StatusBadRequest, codes.InvalidArgument, 0, "keyword"},
+	acorv1.ErrorReason_INVALID_WEIGHT:       {"Invalid weight", http.StatusBadRequest, codes.InvalidArgument, 0, "weights"},
+	acorv1.ErrorReason_INVALID_ARGUMENT:     {"Invalid argument", http.StatusBadRequest, codes.InvalidArgument, 0, ""},
+	acorv1.ErrorReason_UNKNOWN_COLLECTION:   {"Unknown collection", http.StatusBadRequest, codes.InvalidArgument, 0, "collections"},
+	acorv1.ErrorReason_ALIAS_NOT_FOUND:      {"Alias not found", http.StatusNotFound, codes.NotFound, 0, ""},
+	acorv1.ErrorReason_ALIAS_CONFLICT:       {"Alias conflicts with a collection", http.StatusConflict, codes.AlreadyExists, 0, ""},
+	acorv1.ErrorReason_V1_READ_ONLY:         {"Collection is read-only on V1", http.StatusConflict, codes.FailedPrecondition, 0, ""},
+	acorv1.ErrorReason_READ_ONLY:            {"Collection is being copied", http.StatusServiceUnavailable, codes.Unavailable, 5 * time.Second, ""},
+	acorv1.ErrorReason_CONCURRENCY_CONFLICT: {"Concurrency conflict", http.StatusConflict, codes.Aborted, time.Second, ""},
+	acorv1.ErrorReason_SCAN_LIMIT_EXCEEDED:  {"Scan limit exceeded", http.StatusUnprocessableEntity, codes.ResourceExhausted, 0, ""},
+	acorv1.ErrorReason_UNSUPPORTED:          {"Not supported by this server", http.StatusNotImplemented, codes.Unimplemented, 0, ""},
+	acorv1.ErrorReason_REDIS_UNAVAILABLE:    {"Redis unavailable", http.StatusServiceUnavailable, codes.Unavailable, time.Second, ""},
+	acorv1.ErrorReason_DEADLINE_EXCEEDED:    {"Deadline exceeded", http.StatusGatewayTimeout, codes.DeadlineExceeded, time.Second, ""},
+	acorv1.ErrorReason_INTERNAL:             {"Internal error", http.StatusInternalServerError, codes.Internal, 0, ""},
+	acorv1.ErrorReason_UNAUTHENTICATED:      {"Unauthenticated", http.StatusUnauthorized, codes.Unauthenticated, 0, ""},
+	acorv1.ErrorReason_PERMISSION_DENIED:    {"Permission denied", http.StatusForbidden, codes.PermissionDenied, 0, ""},
+	acorv1.ErrorReason_RATE_LIMITED:         {"Rate limited", http.StatusTooManyRequests, codes.ResourceExhausted, time.Second, ""},
+	acorv1.ErrorReason_REQUEST_TOO_LARGE:    {"Request too large", http.StatusRequestEntityTooLarge, codes.ResourceExhausted, 0, ""},
+	acorv1.ErrorReason_CONCURRENCY_LIMITED:  {"Too many concurrent requests", http.StatusTooManyRequests, codes.ResourceExhausted, time.Second, ""},
+	acorv1.ErrorReason_CANCELED:             {"Client closed request", statusClientClosedRequest, codes.Canceled, 0, ""},
+}
+
+// statusClientClosedRequest is the status nginx made the convention for a
+// request the client abandoned. net/http has no name for it.
+const statusClientClosedRequest = 499
+
+// sentinelReasons maps the errors a Service can return to their reason. The
+// first match wins, so a sentinel wrapped in a RedisError or OperationError is
+// classified by the sentinel.
+var sentinelReasons = []struct {
+	err    error
+	reason acorv1.ErrorReason
+}{
+	{acor.ErrEmptyKeyword, acorv1.ErrorReason_EMPTY_KEYWORD},
+	{acor.ErrInvalidWeight, acorv1.ErrorReason_INVALID_WEIGHT},
+	{acor.ErrInvalidTTL, acorv1.ErrorReason_INVALID_ARGUMENT},
+	{acor.ErrInvalidTag, acorv1.ErrorReason_INVALID_ARGUMENT},
+	{acor.ErrInvalidLimit, acorv1.ErrorReason_INVALID_ARGUMENT},
+	{acor.ErrInvalidName, acorv1.ErrorReason_INVALID_ARGUMENT},
+	{acor.ErrInvalidChunkSize, acorv1.ErrorReason_INVALID_ARGUMENT},
+	{acor.ErrChangesetOverlap, acorv1.ErrorReason_INVALID_ARGUMENT},
+	{ErrUnknownCollection, acorv1.ErrorReason_UNKNOWN_COLLECTION},
+	{acor.ErrAliasNotFound, acorv1.ErrorReason_ALIAS_NOT_FOUND},
+	{acor.ErrAliasConflict, acorv1.ErrorReason_ALIAS_CONFLICT},
+	{acor.ErrV1ReadOnly, acorv1.ErrorReason_V1_READ_ONLY},
+	{acor.ErrReadOnly, acorv1.ErrorReason_READ_ONLY},
+	{acor.ErrConcurrencyConflict, acorv1.ErrorReason_CONCURRENCY_CONFLICT},
+	{acor.ErrMaxMatches, acorv1.ErrorReason_SCAN_LIMIT_EXCEEDED},
+	{acor.ErrMaxTextRunes, acorv1.ErrorReason_SCAN_LIMIT_EXCEEDED},
+	{ErrFindAcrossUnsupported, acorv1.ErrorReason_UNSUPPORTED},
+	{ErrWatchUnsupported, acorv1.ErrorReason_UNSUPPORTED},
+	{ErrMatchesUnsupported, acorv1.ErrorReason_UNSUPPORTED},
+	{acor.ErrWatchViaAlias, acorv1.ErrorReason_UNSUPPORTED},
+	{acor.ErrSuggestRequiresRedis, acorv1.ErrorReason_UNSUPPORTED},
+	{context.DeadlineExceeded, acorv1.ErrorReason_DEADLINE_EXCEEDED},
+	{context.Canceled, acorv1.ErrorReason_CANCELED},
+	{auth.ErrNoCredentials, acorv1.ErrorReason_UNAUTHENTICATED},
+	{auth.ErrInvalidCredentials, acorv1.ErrorReason_UNAUTHENTICATED},
+	{auth.ErrPermissionDenied, acorv1.ErrorReason_PERMISSION_DENIED},
+}
+
+// apiError is a failure classified for the wire.
+type apiError struct {
+	reason acorv1.ErrorReason
+	detail string
+	// operation is the OperationError.Op, and redisCommand the RedisError.Op, of
+	// the error, when it has them.
+	operation    string
+	redisCommand string
+	// retryDelay and field, when set, override the reason's own: a limit.Error
+	// knows when its bucket refills and which field was too large.
+	retryDelay time.Duration
+	field      string
+}
+
+func (e *apiError) class() reasonClass {
+	class := reasonClasses[e.reason]
+	if e.retryDelay > 0 {
+		class.retryDelay = e.retryDelay
+	}
+	if e.field != "" {
+		class.field = e.field
+	}
+	return class
+}
+
+// classifyError reduces a Service error to its reason: a sentinel first, then a
+// RedisError, and INTERNAL for anything else. A limit.Error has reasons of its
+// own. The detail is the error's own text.
+func classifyError(err error) *apiError {
+	e := &apiError{reason: acorv1.ErrorReason_INTERNAL, detail: err.Error()}
+	var limitErr *limit.Error
+	if errors.As(err, &limitErr) {
+		e.operation = limitErr.Operation
+		e.retryDelay = limitErr.RetryAfter
+		e.field = limitErr.Field
+		switch limitErr.Kind {
+		case limit.KindText, limit.KindBatch:
+			e.reason = acorv1.ErrorReason_REQUEST_TOO_LARGE
+		case limit.KindConcurrency:
+			e.reason = acorv1.ErrorReason_CONCURRENCY_LIMITED
+		default:
+			e.reason = acorv1.ErrorReason_RATE_LIMITED
+		}
+		return e
+	}
+	var opErr *acor.OperationError
+	if errors.As(err, &opErr) {
+		e.operation = opErr.Op
+	}
+	var redisErr *acor.RedisError
+	if errors.As(err, &redisErr) {
+		e.redisCommand = redisErr.Op
+		e.reason = acorv1.ErrorReason_REDIS_UNAVAILABLE
+	}
+	for _, s := range sentinelReasons {
+		if errors.Is(err, s.err) {
+			e.reason = s.reason
+			break
+		}
+	}
+	return e
+}
+
+// ErrorResponse is the body of every error the HTTP handler writes: an RFC 9457
+// problem document, served as application/problem+json. Code is the failure's
+// ErrorReason name from acor.proto and Type its URI in the error reference; match
+// on either, not on Detail, which is the underlying error's text.
+type ErrorResponse struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+	Code   string `json:"code"`
+	// Error repeats Detail for clients of the earlier {"error": ...} body.
+	Error string `json:"error"`
+	// Operation names the collection operation that failed, when known.
+	Operation string `json:"operation,omitempty"`
+	// InvalidParams names the request field at fault, for the reasons that have
+	// one.
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
+// InvalidParam is one request field an ErrorResponse rejects, and why.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// problem renders e as an RFC 9457 problem document.
+func (e *apiError) problem() *ErrorResponse {
+	class := e.class()
+	name := e.reason.String()
+	p := &ErrorResponse{
+		Type:      problemTypeBase + strings.ToLower(name),
+		Title:     class.title,
+		Status:    class.httpStatus,
+		Detail:    e.detail,
+		Code:      name,
+		Error:     e.detail,
+		Operation: e.operation,
+	}
+	if class.field != "" {
+		p.InvalidParams = []InvalidParam{{Name: class.field, Reason: e.detail}}
+	}
+	return p
+}
+
+// grpcStatus renders e as a gRPC status carrying an ErrorInfo, plus a RetryInfo
+// or BadRequest where its reason calls for one.
+func (e *apiError) grpcStatus() *status.Status {
+	class := e.class()
+	info := &errdetails.ErrorInfo{Reason: e.reason.String(), Domain: errorDomain}
+	if e.operation != "" || e.redisCommand != "" {
+		info.Metadata = make(map[string]string)
+		if e.operation != "" {
+			info.Metadata["operation"] = e.operation
+		}
+		if e.redisCommand != "" {
+			info.Metadata["redis_command"] = e.redisCommand
+		}
+	}
+	details := []protoadapt.MessageV1{info}
+	if class.retryDelay > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(class.retryDelay)})
+	}
+	if class.field != "" {
+		details = append(details, &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: class.field, Description: e.detail, Reason: e.reason.String()},
+		}})
+	}
+	st := status.New(class.grpcCode, e.detail)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		return withDetails
+	}
+	return st
+}
+
+// statusProblem reverses grpcStatus, so the REST gateway reports a failed RPC as
+// the hand-written routes would. A status without an ErrorInfo of this domain
+// comes from the gateway itself, which fails on a request it cannot decode or a
+// call it cannot make in-process.
+func statusProblem(st *status.Status) *apiError {
+	e := &apiError{reason: acorv1.ErrorReason_INTERNAL, detail: st.Message()}
+	switch st.Code() {
+	case codes.InvalidArgument:
+		e.reason = acorv1.ErrorReason_INVALID_BODY
+	case codes.Unimplemented:
+		e.reason = acorv1.ErrorReason_UNSUPPORTED
+	}
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			if reason, ok := acorv1.ErrorReason_value[d.GetReason()]; ok && d.GetDomain() == errorDomain {
+				e.reason = acorv1.ErrorReason(reason)
+				e.operation = d.GetMetadata()["operation"]
+				e.redisCommand = d.GetMetadata()["redis_command"]
+			}
+		case *errdetails.RetryInfo:
+			e.retryDelay = d.GetRetryDelay().AsDuration()
+		case *errdetails.BadRequest:
+			if v := d.GetFieldViolations(); len(v) > 0 {
+				e.field = v[0].GetField()
+			}
+		}
+	}
+	return e
+}
+
+// writeProblem writes e as a problem document, with a Retry-After header for a
+// reason worth retrying.
+func writeProblem(w http.ResponseWriter, e *apiError) {
+	p := e.problem()
+	if delay := e.class().retryDelay; delay > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// grpcError converts a Service error into the status error a gRPC handler
+// returns.
+func grpcError(err error) error {
+	return classifyError(err).grpcStatus().Err()
+}
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// redisDown is a Redis failure as an acor write reports it.
+var redisDown = &acor.OperationError{Op: "add", Keyword: keywordHE, Schema: 2,
+	Err: &acor.RedisError{Op: "EVALSHA", Key: "{rules}:trie", Err: errors.New("connection refused")}}
+
+func TestReasonClassesCoverTheProto(t *testing.T) {
+	for value, name := range acorv1.ErrorReason_name {
+		reason := acorv1.ErrorReason(value)
+		if _, ok := reasonClasses[reason]; ok == (reason == acorv1.ErrorReason_ERROR_REASON_UNSPECIFIED) {
+			t.Errorf("reasonClasses has %s = %v", name, ok)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want acorv1.ErrorReason
+	}{
+		{acor.ErrEmptyKeyword, acorv1.ErrorReason_EMPTY_KEYWORD},
+		{fmt.Errorf("set weights: %w", acor.ErrInvalidWeight), acorv1.ErrorReason_INVALID_WEIGHT},
+		{&acor.OperationError{Op: "add", Err: acor.ErrV1ReadOnly}, acorv1.ErrorReason_V1_READ_ONLY},
+		{&acor.OperationError{Op: "add", Err: acor.ErrConcurrencyConflict}, acorv1.ErrorReason_CONCURRENCY_CONFLICT},
+		{&acor.ScanLimitError{Err: acor.ErrMaxTextRunes}, acorv1.ErrorReason_SCAN_LIMIT_EXCEEDED},
+		{&acor.RedisError{Op: "HGET", Err: context.DeadlineExceeded}, acorv1.ErrorReason_DEADLINE_EXCEEDED},
+		{&acor.RedisError{Op: "HGET", Err: context.Canceled}, acorv1.ErrorReason_CANCELED},
+		{redisDown, acorv1.ErrorReason_REDIS_UNAVAILABLE},
+		{errors.New("boom"), acorv1.ErrorReason_INTERNAL},
+	} {
+		if got := classifyError(tc.err).reason; got != tc.want {
+			t.Errorf("classifyError(%v) = %s, want %s", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestHTTPHandlerProblemDocuments(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		err        error
+		status     int
+		code       string
+		retryAfter string
+		operation  string
+		param      string
+	}{
+		{"empty keyword", acor.ErrEmptyKeyword, http.StatusBadRequest, "EMPTY_KEYWORD", "", "", "keyword"},
+		{"v1 read-only", acor.ErrV1ReadOnly, http.StatusConflict, "V1_READ_ONLY", "", "", ""},
+		{"conflict", acor.ErrConcurrencyConflict, http.StatusConflict, "CONCURRENCY_CONFLICT", "1", "", ""},
+		{"redis down", redisDown, http.StatusServiceUnavailable, "REDIS_UNAVAILABLE", "1", "add", ""},
+		{"canceled", context.Canceled, statusClientClosedRequest, "CANCELED", "", "", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(NewHTTPHandler(&fakeService{addErr: tc.err}))
+			defer server.Close()
+
+			resp := doRawRequest(t, http.MethodPost, server.URL+"/v1/add", mustJSONReader(t, KeywordRequest{Keyword: keywordHE}))
+			defer func() { _ = resp.Body.Close() }()
+			var body ErrorResponse
+			decodeJSONResponse(t, resp, &body)
+			if resp.StatusCode != tc.status || body.Status != tc.status {
+				t.Errorf("status = %d, body status %d; want %d", resp.StatusCode, body.Status, tc.status)
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			if got := resp.Header.Get("Retry-After"); got != tc.retryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tc.retryAfter)
+			}
+			if body.Code != tc.code || body.Type != problemTypeBase+strings.ToLower(tc.code) || body.Title == "" {
+				t.Errorf("code %q, type %q, title %q", body.Code, body.Type, body.Title)
+			}
+			if body.Detail != tc.err.Error() || body.Error != body.Detail || body.Operation != tc.operation {
+				t.Errorf("detail %q, error %q, operation %q", body.Detail, body.Error, body.Operation)
+			}
+			if tc.param == "" && len(body.InvalidParams) != 0 ||
+				tc.param != "" && (len(body.InvalidParams) != 1 || body.InvalidParams[0].Name != tc.param) {
+				t.Errorf("invalid_params = %+v, want %q", body.InvalidParams, tc.param)
+			}
+		})
+	}
+}
+
+func TestGRPCServerStatusDetails(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := newGRPCTestClient(t, &fakeService{addErr: redisDown}).
+		Add(ctx, &acorv1.KeywordRequest{Keyword: keywordHE})
+	st := status.Convert(err)
+	if st.Code() != codes.Unavailable {
+		t.Fatalf("code = %s, want Unavailable", st.Code())
+	}
+	var info *errdetails.ErrorInfo
+	var retry *errdetails.RetryInfo
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.RetryInfo:
+			retry = d
+		}
+	}
+	if info.GetReason() != "REDIS_UNAVAILABLE" || info.GetDomain() != errorDomain ||
+		info.GetMetadata()["operation"] != "add" || info.GetMetadata()["redis_command"] != "EVALSHA" {
+		t.Errorf("ErrorInfo = %v", info)
+	}
+	if retry.GetRetryDelay().AsDuration() != time.Second {
+		t.Errorf("RetryInfo = %v, want a 1s delay", retry)
+	}
+
+	_, err = newGRPCTestClient(t, &fakeCrossService{}).
+		FindAcross(ctx, &acorv1.FindAcrossRequest{Input: inputHEHE, Collections: []string{"missing"}})
+	st = status.Convert(err)
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			violations = br.GetFieldViolations()
+		}
+	}
+	if st.Code() != codes.InvalidArgument || len(violations) != 1 || violations[0].GetField() != "collections" ||
+		violations[0].GetReason() != "UNKNOWN_COLLECTION" {
+		t.Errorf("FindAcross of an unknown collection = %s, violations %v", st.Code(), violations)
+	}
+}
@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// httpBinding is the HTTP route of one Acor RPC, read from its google.api.http
+// option in acor.proto.
+type httpBinding struct {
+	rpc    protoreflect.MethodDescriptor
+	method string
+	path   string
+}
+
+// httpBindings lists the route of every Acor RPC, in the order acor.proto
+// declares them. An RPC without a binding is left out.
+func httpBindings() []httpBinding {
+	methods := acorv1.File_acor_v1_acor_proto.Services().ByName("Acor").Methods()
+	bindings := make([]httpBinding, 0, methods.Len())
+	for i := 0; i < methods.Len(); i++ {
+		m := methods.Get(i)
+		rule, _ := proto.GetExtension(m.Options(), annotations.E_Http).(*annotations.HttpRule)
+		b := httpBinding{rpc: m}
+		switch p := rule.GetPattern().(type) {
+		case *annotations.HttpRule_Get:
+			b.method, b.path = http.MethodGet, p.Get
+		case *annotations.HttpRule_Post:
+			b.method, b.path = http.MethodPost, p.Post
+		case *annotations.HttpRule_Put:
+			b.method, b.path = http.MethodPut, p.Put
+		case *annotations.HttpRule_Delete:
+			b.method, b.path = http.MethodDelete, p.Delete
+		case *annotations.HttpRule_Patch:
+			b.method, b.path = http.MethodPatch, p.Patch
+		default:
+			continue
+		}
+		bindings = append(bindings, b)
+	}
+	return bindings
+}
+
+// newGateway returns the REST gateway generated from the bindings, calling the
+// gRPC adapter in-process. It serves an RPC that has no hand-written handler,
+// so a new RPC is reachable over HTTP as soon as acor.proto binds it. Failures
+// are problem documents, as on the hand-written routes.
+func newGateway(service Service) http.Handler {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, gatewayJSON{}),
+		runtime.WithErrorHandler(func(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+			writeProblem(w, statusProblem(status.Convert(err)))
+		}),
+		runtime.WithRoutingErrorHandler(func(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, code int) {
+			if code == http.StatusMethodNotAllowed {
+				writeMethodNotAllowed(w)
+				return
+			}
+			http.NotFound(w, r)
+		}),
+	)
+	// Registering against a server, rather than a connection, cannot fail.
+	_ = acorv1.RegisterAcorHandlerServer(context.Background(), mux, &grpcServer{service: service})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Read the body here so the 1 MiB cap reports as it does on the
+		// hand-written routes; the gateway would fold it into INVALID_BODY.
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+		if err != nil {
+			writeDecodeError(w, err, err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// gatewayJSON is the gateway's marshaler. It reads requests as protojson, and
+// writes responses as the hand-written routes do: fields by their proto names,
+// zero values included, and 64-bit integers as JSON numbers rather than the
+// strings protojson uses.
+type gatewayJSON struct{}
+
+func (gatewayJSON) ContentType(any) string { return "application/json" }
+
+func (gatewayJSON) Marshal(v any) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return json.Marshal(jsonMessage(m.ProtoReflect()))
+	}
+	return json.Marshal(v)
+}
+
+func (gatewayJSON) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("gateway: cannot decode into %T", v)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	return protojson.Unmarshal(data, m)
+}
+
+func (j gatewayJSON) NewDecoder(r io.Reader) runtime.Decoder {
+	return runtime.DecoderFunc(func(v any) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return j.Unmarshal(data, v)
+	})
+}
+
+func (j gatewayJSON) NewEncoder(w io.Writer) runtime.Encoder {
+	return runtime.EncoderFunc(func(v any) error {
+		data, err := j.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+}
+
+// jsonMessage renders m as encoding/json renders the hand-written responses.
+// An unset message field is left out.
+func jsonMessage(m protoreflect.Message) map[string]any {
+	fields := m.Descriptor().Fields()
+	out := make(map[string]any, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		v := m.Get(fd)
+		switch {
+		case fd.IsList():
+			list := make([]any, v.List().Len())
+			for j := range list {
+				list[j] = jsonValue(fd, v.List().Get(j))
+			}
+			out[string(fd.Name())] = list
+		case fd.IsMap():
+			entries := make(map[string]any, v.Map().Len())
+			v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				entries[k.String()] = jsonValue(fd.MapValue(), v)
+				return true
+			})
+			out[string(fd.Name())] = entries
+		case fd.Message() != nil && !m.Has(fd):
+		default:
+			out[string(fd.Name())] = jsonValue(fd, v)
+		}
+	}
+	return out
+}
+
+func jsonValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return jsonMessage(v.Message())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int32(v.Enum())
+	default:
+		return v.Interface()
+	}
+}
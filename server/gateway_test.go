@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+func TestHTTPBindingsAreTheRoutes(t *testing.T) {
+	bindings := httpBindings()
+	if rpcs := len(acorv1.Acor_ServiceDesc.Methods) + len(acorv1.Acor_ServiceDesc.Streams); len(bindings) != rpcs {
+		t.Errorf("%d bindings for %d RPCs; every RPC needs a google.api.http option", len(bindings), rpcs)
+	}
+	mux := NewHTTPHandler(&fakeService{}).(*http.ServeMux)
+	for _, b := range bindings {
+		if strings.Contains(b.path, "{") {
+			t.Errorf("%s is bound to the template %s; the middleware needs literal paths", b.rpc.Name(), b.path)
+		}
+		if _, pattern := mux.Handler(httptest.NewRequest(b.method, b.path, nil)); pattern != b.path {
+			t.Errorf("%s %s (%s) is not a route of NewHTTPHandler", b.method, b.path, b.rpc.Name())
+		}
+		// The hand-written handlers check the method themselves; it must be the
+		// one the binding names.
+		wrong := http.MethodPost
+		if b.method == http.MethodPost {
+			wrong = http.MethodGet
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(wrong, b.path, nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s: status %d, want 405", wrong, b.path, rec.Code)
+		}
+	}
+}
+
+func TestGatewayAnswersAsTheHandWrittenRoutes(t *testing.T) {
+	service := &fakeService{
+		addCount: 1, version: 42, findMatches: []string{keywordHE},
+		info:    &acor.AhoCorasickInfo{Keywords: 3, Nodes: 7},
+		aliases: map[string]string{"live": "rules"},
+	}
+	handWritten, gateway := NewHTTPHandler(service), newGateway(service)
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodPost, "/v1/add", `{"keyword":"he"}`},
+		{http.MethodPost, "/v1/find", `{"input":"hehe","min_version":42}`},
+		{http.MethodGet, "/v1/info", ``},
+		{http.MethodPost, "/v1/resolve-alias", `{"alias":"live"}`},
+	} {
+		answers := make([]any, 2)
+		for i, h := range []http.Handler{handWritten, gateway} {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+			if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+				t.Fatalf("%s %s: %d %s %s", tc.method, tc.path, rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &answers[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !reflect.DeepEqual(answers[0], answers[1]) {
+			t.Errorf("%s %s: hand-written %v, gateway %v", tc.method, tc.path, answers[0], answers[1])
+		}
+	}
+}
+
+func TestGatewayProblems(t *testing.T) {
+	gateway := newGateway(&fakeService{addErr: acor.ErrEmptyKeyword})
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{http.MethodPost, "/v1/add", `{"keyword":""}`, http.StatusBadRequest, "EMPTY_KEYWORD"},
+		{http.MethodPost, "/v1/add", `{"keyword":`, http.StatusBadRequest, "INVALID_BODY"},
+		{http.MethodPost, "/v1/add", `{"keyword":"` + strings.Repeat("a", maxRequestBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, "BODY_TOO_LARGE"},
+		{http.MethodGet, "/v1/add", ``, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED"},
+		{http.MethodGet, "/v1/watch", ``, http.StatusNotImplemented, "UNSUPPORTED"},
+	} {
+		rec := httptest.NewRecorder()
+		gateway.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		var problem ErrorResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+		if rec.Code != tc.status || problem.Code != tc.code || rec.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s %s: %d %q, want %d %s", tc.method, tc.path, rec.Code, problem.Code, tc.status, tc.code)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHTTPHandler(&fakeService{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: %d", rec.Code)
+	}
+	var doc struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want 3.x", doc.OpenAPI)
+	}
+	for _, b := range httpBindings() {
+		if _, ok := doc.Paths[b.path][strings.ToLower(b.method)]; !ok {
+			t.Errorf("%s %s is not in the document", b.method, b.path)
+		}
+	}
+	for _, ref := range bytes.Split(rec.Body.Bytes(), []byte(`"$ref":"#/components/schemas/`))[1:] {
+		name := string(ref[:bytes.IndexByte(ref, '"')])
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("schema %s is referenced but not defined", name)
+		}
+	}
+	var find struct {
+		Properties map[string]struct {
+			Type   string `json:"type"`
+			Format string `json:"format"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(doc.Components.Schemas["InputRequest"], &find); err != nil {
+		t.Fatal(err)
+	}
+	if p := find.Properties["min_version"]; p.Type != "integer" || p.Format != "int64" {
+		t.Errorf("InputRequest.min_version = %+v, want an int64 integer", p)
+	}
+}
+
+func TestGRPCServerReflection(t *testing.T) {
+	conn := newGRPCTestConn(t, &fakeService{})
+	stream, err := grpc_reflection_v1.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&grpc_reflection_v1.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_ListServices{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		found = found || s.GetName() == acorv1.Acor_ServiceDesc.ServiceName
+	}
+	if !found {
+		t.Errorf("reflection lists %v, want %s among them", resp.GetListServicesResponse().GetService(), acorv1.Acor_ServiceDesc.ServiceName)
+	}
+}
@@ -6,9 +6,9 @@
 	"context"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/reflection"
 
+	"github.com/skyoo2003/acor/pkg/acor"
 	"github.com/skyoo2003/acor/server/health"
 	"github.com/skyoo2003/acor/server/logging"
 	"github.com/skyoo2003/acor/server/metrics"
@@ -32,11 +32,13 @@ type grpcServer struct {
 }
 
 // NewGRPCServer returns a *grpc.Server serving the acor.server.v1.Acor service
-// defined in server/proto/acor/v1/acor.proto. Callers pass any grpc.ServerOption
-// (TLS, interceptors, ...) and are responsible for Serve/Stop.
+// defined in server/proto/acor/v1/acor.proto, and server reflection so tools
+// such as grpcurl can discover it. Callers pass any grpc.ServerOption (TLS,
+// interceptors, ...) and are responsible for Serve/Stop.
 func NewGRPCServer(service Service, opts ...grpc.ServerOption) *grpc.Server {
 	s := grpc.NewServer(opts...)
 	acorv1.RegisterAcorServer(s, &grpcServer{service: service})
+	reflection.Register(s)
 	return s
 }
 
@@ -50,6 +52,7 @@ func NewGRPCServer(service Service, opts ...grpc.ServerOption) *grpc.Server {
 func NewGRPCServerWithObservability(ctx context.Context, service Service, obs *Observability, opts ...grpc.ServerOption) *grpc.Server {
 	var serverOpts []grpc.ServerOption
 	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
 
 	if obs != nil {
 		if obs.Tracer != nil {
@@ -57,18 +60,24 @@ func NewGRPCServerWithObservability(ctx context.Context, service Service, obs *O
 		}
 		if obs.Metrics != nil {
 			unary = append(unary, obs.Metrics.GRPCServer.UnaryServerInterceptor())
+			stream = append(stream, obs.Metrics.GRPCServer.StreamServerInterceptor())
 		}
 		if obs.Logger != nil {
 			unary = append(unary, logging.GRPCUnaryInterceptor(obs.Logger))
+			stream = append(stream, logging.GRPCStreamInterceptor(obs.Logger))
 		}
 	}
 	if len(unary) > 0 {
 		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(unary...))
 	}
+	if len(stream) > 0 {
+		serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(stream...))
+	}
 	serverOpts = append(serverOpts, opts...)
 
 	s := grpc.NewServer(serverOpts...)
 	acorv1.RegisterAcorServer(s, &grpcServer{service: service})
+	reflection.Register(s)
 
 	if obs != nil {
 		if obs.Metrics != nil {
@@ -81,50 +90,69 @@ func NewGRPCServerWithObservability(ctx context.Context, service Service, obs *O
 	return s
 }
 
-func (s *grpcServer) Add(_ context.Context, req *acorv1.KeywordRequest) (*acorv1.CountResponse, error) {
-	count, err := s.service.Add(req.GetKeyword())
+func (s *grpcServer) Add(ctx context.Context, req *acorv1.KeywordRequest) (*acorv1.CountResponse, error) {
+	ctx, token := acor.WithVersionToken(serviceContext(ctx))
+	count, err := s.service.AddContext(ctx, req.GetKeyword())
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, grpcError(err)
 	}
-	return &acorv1.CountResponse{Count: int64(count)}, nil
+	return &acorv1.CountResponse{Count: int64(count), Version: token.Version()}, nil
 }
 
-func (s *grpcServer) Remove(_ context.Context, req *acorv1.KeywordRequest) (*acorv1.CountResponse, error) {
-	count, err := s.service.Remove(req.GetKeyword())
+func (s *grpcServer) Remove(ctx context.Context, req *acorv1.KeywordRequest) (*acorv1.CountResponse, error) {
+	ctx, token := acor.WithVersionToken(serviceContext(ctx))
+	count, err := s.service.RemoveContext(ctx, req.GetKeyword())
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, grpcError(err)
 	}
-	return &acorv1.CountResponse{Count: int64(count)}, nil
+	return &acorv1.CountResponse{Count: int64(count), Version: token.Version()}, nil
 }
 
-func (s *grpcServer) Find(_ context.Context, req *acorv1.InputRequest) (*acorv1.MatchesResponse, error) {
-	matches, err := s.service.Find(req.GetInput())
+func (s *grpcServer) Find(ctx context.Context, req *acorv1.InputRequest) (*acorv1.MatchesResponse, error) {
+	matches, err := s.service.FindContext(acor.WithMinVersion(ctx, req.GetMinVersion()), req.GetInput())
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, grpcError(err)
 	}
 	return &acorv1.MatchesResponse{Matches: matches}, nil
 }
 
-func (s *grpcServer) FindIndex(_ context.Context, req *acorv1.InputRequest) (*acorv1.MatchIndexesResponse, error) {
-	matches, err := s.service.FindIndex(req.GetInput())
+func (s *grpcServer) FindIndex(ctx context.Context, req *acorv1.InputRequest) (*acorv1.MatchIndexesResponse, error) {
+	matches, err := s.service.FindIndexContext(acor.WithMinVersion(ctx, req.GetMinVersion()), req.GetInput())
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, grpcError(err)
 	}
 	return &acorv1.MatchIndexesResponse{Matches: toPositions(matches)}, nil
 }
 
-func (s *grpcServer) Suggest(_ context.Context, req *acorv1.InputRequest) (*acorv1.MatchesResponse, error) {
-	matches, err := s.service.Suggest(req.GetInput())
+func (s *grpcServer) FindAcross(ctx context.Context, req *acorv1.FindAcrossRequest) (*acorv1.FindAcrossResponse, error) {
+	resp, err := NewAPI(s.service).FindAcross(ctx, &FindAcrossRequest{Input: req.GetInput(), Collections: req.GetCollections()})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	matches := make([]*acorv1.CollectionMatch, len(resp.Matches))
+	for i, m := range resp.Matches {
+		matches[i] = &acorv1.CollectionMatch{
+			Collection: m.Collection,
+			Keyword:    m.Keyword,
+			Start:      int64(m.Start),
+			End:        int64(m.End),
+		}
+	}
+	return &acorv1.FindAcrossResponse{Matches: matches}, nil
+}
+
+func (s *grpcServer) Suggest(ctx context.Context, req *acorv1.InputRequest) (*acorv1.MatchesResponse, error) {
+	matches, err := s.service.SuggestContext(acor.WithMinVersion(ctx, req.GetMinVersion()), req.GetInput())
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, grpcError(err)
 	}
 	return &acorv1.MatchesResponse{Matches: matches}, nil
 }
 
-func (s *grpcServer) SuggestIndex(_ context.Context, req *acorv1.InputRequest) (*acorv1.MatchIndexesResponse, error) {
-	matches, err := s.service.SuggestIndex(req.GetInput())
+func (s *grpcServer) SuggestIndex(ctx context.Context, req *acorv1.InputRequest) (*acorv1.MatchIndexesResponse, error) {
+	matches, err := s.service.SuggestIndexContext(acor.WithMinVersion(ctx, req.GetMinVersion()), req.GetInput())
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, grpcError(err)
 	}
 	return &acorv1.MatchIndexesResponse{Matches: toPositions(matches)}, nil
 }
@@ -132,16 +160,109 @@ func (s *grpcServer) SuggestIndex(_ context.Context, req *acorv1.InputRequest) (
 func (s *grpcServer) Info(_ context.Context, _ *acorv1.EmptyRequest) (*acorv1.InfoResponse, error) {
 	info, err := s.service.Info()
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, grpcError(err)
 	}
 	return &acorv1.InfoResponse{Keywords: int64(info.Keywords), Nodes: int64(info.Nodes)}, nil
 }
 
-func (s *grpcServer) Flush(_ context.Context, _ *acorv1.EmptyRequest) (*acorv1.StatusResponse, error) {
-	if err := s.service.Flush(); err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+func (s *grpcServer) Flush(ctx context.Context, _ *acorv1.EmptyRequest) (*acorv1.StatusResponse, error) {
+	ctx, token := acor.WithVersionToken(serviceContext(ctx))
+	if err := s.service.FlushContext(ctx); err != nil {
+		return nil, grpcError(err)
+	}
+	return &acorv1.StatusResponse{Status: "ok", Version: token.Version()}, nil
+}
+
+func (s *grpcServer) Score(_ context.Context, req *acorv1.ScoreRequest) (*acorv1.ScoreResponse, error) {
+	result, err := s.service.Score(req.GetInput(), &acor.ScoreOptions{
+		DefaultWeight:      req.GetDefaultWeight(),
+		Decay:              req.GetDecay(),
+		MaxOccurrences:     int(req.GetMaxOccurrences()),
+		Threshold:          req.GetThreshold(),
+		CategoryThresholds: req.GetCategoryThresholds(),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	keywords := make(map[string]*acorv1.KeywordScore, len(result.Keywords))
+	for kw, ks := range result.Keywords {
+		keywords[kw] = &acorv1.KeywordScore{
+			Count:    int64(ks.Count),
+			Weight:   ks.Weight,
+			Category: ks.Category,
+			Score:    ks.Score,
+		}
+	}
+	return &acorv1.ScoreResponse{
+		Total:             result.Total,
+		Flagged:           result.Flagged,
+		FlaggedCategories: result.FlaggedCategories,
+		Categories:        result.Categories,
+		Keywords:          keywords,
+	}, nil
+}
+
+func (s *grpcServer) SetWeights(_ context.Context, req *acorv1.SetWeightsRequest) (*acorv1.CountResponse, error) {
+	weights := make(map[string]acor.KeywordWeight, len(req.GetWeights()))
+	for kw, w := range req.GetWeights() {
+		weights[kw] = acor.KeywordWeight{Weight: w.GetWeight(), Category: w.GetCategory()}
+	}
+	if err := s.service.SetWeights(weights); err != nil {
+		return nil, grpcError(err)
+	}
+	return &acorv1.CountResponse{Count: int64(len(weights))}, nil
+}
+
+func (s *grpcServer) Weights(_ context.Context, _ *acorv1.EmptyRequest) (*acorv1.WeightsResponse, error) {
+	weights, err := s.service.Weights()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	out := make(map[string]*acorv1.KeywordWeight, len(weights))
+	for kw, w := range weights {
+		out[kw] = &acorv1.KeywordWeight{Weight: w.Weight, Category: w.Category}
+	}
+	return &acorv1.WeightsResponse{Weights: out}, nil
+}
+
+func (s *grpcServer) SetAlias(_ context.Context, req *acorv1.AliasRequest) (*acorv1.AliasResponse, error) {
+	if err := s.service.SetAlias(req.GetAlias()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &acorv1.AliasResponse{Alias: req.GetAlias(), Collection: s.service.Collection()}, nil
+}
+
+func (s *grpcServer) ResolveAlias(_ context.Context, req *acorv1.AliasRequest) (*acorv1.AliasResponse, error) {
+	collection, err := s.service.ResolveAlias(req.GetAlias())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &acorv1.AliasResponse{Alias: req.GetAlias(), Collection: collection}, nil
+}
+
+func (s *grpcServer) DeleteAlias(_ context.Context, req *acorv1.AliasRequest) (*acorv1.DeleteAliasResponse, error) {
+	deleted, err := s.service.DeleteAlias(req.GetAlias())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &acorv1.DeleteAliasResponse{Deleted: deleted}, nil
+}
+
+func (s *grpcServer) Watch(req *acorv1.WatchRequest, stream grpc.ServerStreamingServer[acorv1.ChangeEvent]) error {
+	err := NewAPI(s.service).Watch(stream.Context(), &WatchRequest{FromVersion: req.GetFromVersion()}, func(e *ChangeEvent) error {
+		kind := acorv1.ChangeEvent_CHANGE
+		if e.Kind == EventResync {
+			kind = acorv1.ChangeEvent_RESYNC
+		}
+		return stream.Send(&acorv1.ChangeEvent{
+			Kind: kind, Version: e.Version, PrevVersion: e.PrevVersion, Op: e.Op,
+			Added: e.Added, Removed: e.Removed, KeywordsKnown: e.KeywordsKnown,
+		})
+	})
+	if err != nil && stream.Context().Err() == nil {
+		return grpcError(err)
 	}
-	return &acorv1.StatusResponse{Status: "ok"}, nil
+	return nil
 }
 
 // toPositions converts native match-index offsets to their protobuf wrapper.
@@ -18,11 +18,18 @@
 	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
 )
 
-func newGRPCTestClient(t *testing.T, service Service) acorv1.AcorClient {
+func newGRPCTestClient(t *testing.T, service Service, opts ...grpc.ServerOption) acorv1.AcorClient {
+	t.Helper()
+	return acorv1.NewAcorClient(newGRPCTestConn(t, service, opts...))
+}
+
+// newGRPCTestConn serves NewGRPCServer over an in-memory listener and returns
+// a connection to it.
+func newGRPCTestConn(t *testing.T, service Service, opts ...grpc.ServerOption) *grpc.ClientConn {
 	t.Helper()
 
 	lis := bufconn.Listen(1 << 20)
-	srv := NewGRPCServer(service)
+	srv := NewGRPCServer(service, opts...)
 	go func() { _ = srv.Serve(lis) }()
 	t.Cleanup(srv.Stop)
 
@@ -35,7 +42,7 @@ func newGRPCTestClient(t *testing.T, service Service) acorv1.AcorClient {
 		t.Fatal(err)
 	}
 	t.Cleanup(func() { _ = conn.Close() })
-	return acorv1.NewAcorClient(conn)
+	return conn
 }
 
 func TestGRPCServerAddFindRemove(t *testing.T) {
@@ -77,6 +84,27 @@ func TestGRPCServerAddFindRemove(t *testing.T) {
 	}
 }
 
+func TestGRPCServerVersionTokens(t *testing.T) {
+	service := &fakeService{addCount: 1, version: 1792411200000000000}
+	client := newGRPCTestClient(t, service)
+	ctx := context.Background()
+
+	addResp, err := client.Add(ctx, &acorv1.KeywordRequest{Keyword: keywordHE})
+	if err != nil || addResp.GetVersion() != service.version {
+		t.Fatalf("add = %v, %v; want version %d", addResp, err, service.version)
+	}
+	flushResp, err := client.Flush(ctx, &acorv1.EmptyRequest{})
+	if err != nil || flushResp.GetVersion() != service.version {
+		t.Fatalf("flush = %v, %v; want version %d", flushResp, err, service.version)
+	}
+	if _, err := client.Find(ctx, &acorv1.InputRequest{Input: inputHEHE, MinVersion: addResp.GetVersion()}); err != nil {
+		t.Fatal(err)
+	}
+	if service.lastMinVersion != addResp.GetVersion() {
+		t.Errorf("find read at min version %d, want %d", service.lastMinVersion, addResp.GetVersion())
+	}
+}
+
 func TestGRPCServerSuggestInfoFlush(t *testing.T) {
 	client := newGRPCTestClient(t, &fakeService{
 		suggestMatches: []string{keywordHE, "her"},
@@ -118,6 +146,78 @@ func TestGRPCServerSuggestInfoFlush(t *testing.T) {
 	}
 }
 
+func TestGRPCServerScoreAndWeights(t *testing.T) {
+	service := &fakeService{scoreResult: &acor.ScoreResult{
+		Total:      4,
+		Categories: map[string]float64{"spam": 4},
+		Keywords:   map[string]acor.KeywordScore{"spam": {Count: 2, Weight: 2, Category: "spam", Score: 4}},
+	}}
+	client := newGRPCTestClient(t, service)
+	ctx := context.Background()
+
+	setResp, err := client.SetWeights(ctx, &acorv1.SetWeightsRequest{
+		Weights: map[string]*acorv1.KeywordWeight{"spam": {Weight: 2, Category: "spam"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setResp.GetCount() != 1 {
+		t.Fatalf("set weights count = %d, want 1", setResp.GetCount())
+	}
+
+	wResp, err := client.Weights(ctx, &acorv1.EmptyRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := wResp.GetWeights()["spam"]; w.GetWeight() != 2 || w.GetCategory() != "spam" {
+		t.Fatalf("weights = %v", wResp.GetWeights())
+	}
+
+	sResp, err := client.Score(ctx, &acorv1.ScoreRequest{Input: "spam spam", MaxOccurrences: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sResp.GetTotal() != 4 || sResp.GetKeywords()["spam"].GetCount() != 2 || sResp.GetCategories()["spam"] != 4 {
+		t.Fatalf("score = %+v", sResp)
+	}
+	if service.lastScoreOpts.MaxOccurrences != 5 {
+		t.Fatalf("max occurrences = %d, want 5", service.lastScoreOpts.MaxOccurrences)
+	}
+}
+
+func TestGRPCServerAliases(t *testing.T) {
+	client := newGRPCTestClient(t, &fakeService{collection: "rules-20261017"})
+	ctx := context.Background()
+
+	setResp, err := client.SetAlias(ctx, &acorv1.AliasRequest{Alias: "rules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setResp.GetAlias() != "rules" || setResp.GetCollection() != "rules-20261017" {
+		t.Fatalf("set alias = %+v", setResp)
+	}
+
+	rResp, err := client.ResolveAlias(ctx, &acorv1.AliasRequest{Alias: "rules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rResp.GetCollection() != "rules-20261017" {
+		t.Fatalf("resolve alias = %+v", rResp)
+	}
+
+	dResp, err := client.DeleteAlias(ctx, &acorv1.AliasRequest{Alias: "rules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dResp.GetDeleted() {
+		t.Fatal("delete alias reported the alias missing")
+	}
+
+	if _, err := client.ResolveAlias(ctx, &acorv1.AliasRequest{Alias: "rules"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("resolve of a deleted alias: expected NotFound code, got %v", err)
+	}
+}
+
 func TestGRPCServerPropagatesErrors(t *testing.T) {
 	client := newGRPCTestClient(t, &fakeService{addErr: errors.New("add failed")})
 
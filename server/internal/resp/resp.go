@@ -0,0 +1,244 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package resp reads and writes the Redis serialization protocol, RESP2 and
+// RESP3, for the server's Redis-compatible listener. It is internal to the
+// server module and covers only what that listener needs: commands in, replies
+// out.
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrProtocol reports input that is not a RESP command. The connection cannot
+// be resynchronized after it, so the caller replies and closes.
+var ErrProtocol = errors.New("protocol error")
+
+// maxArgs bounds the elements of one command array, so a forged length cannot
+// make the reader allocate without bound before any argument arrives.
+const maxArgs = 4096
+
+// Reader reads commands from a client.
+type Reader struct {
+	r *bufio.Reader
+	// maxBulk is the largest argument accepted, and maxCommand the most all of a
+	// command's arguments may hold together, in bytes.
+	maxBulk, maxCommand int
+}
+
+// NewReader returns a Reader over r accepting arguments of up to maxBulk bytes
+// and commands of up to maxCommand bytes of arguments in all.
+func NewReader(r io.Reader, maxBulk, maxCommand int) *Reader {
+	return &Reader{r: bufio.NewReader(r), maxBulk: maxBulk, maxCommand: maxCommand}
+}
+
+// ReadCommand reads one command: an array of bulk strings, as client libraries
+// send, or an inline command, a line of space-separated words, as typed into
+// telnet. It returns io.EOF when the client closes between commands. An empty
+// inline line is skipped.
+//
+// A command whose arguments together run past maxCommand bytes is an
+// ErrProtocol as soon as the argument that crosses the line announces its
+// length, before that argument is read.
+func (r *Reader) ReadCommand() ([]string, error) {
+	for {
+		line, err := r.line()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != '*' {
+			if args := strings.Fields(line); len(args) > 0 {
+				return args, nil
+			}
+			continue
+		}
+		n, err := r.length(line[1:], maxArgs)
+		if err != nil {
+			return nil, err
+		}
+		if n <= 0 {
+			continue
+		}
+		args := make([]string, n)
+		budget := r.maxCommand
+		for i := range args {
+			if args[i], err = r.bulk(budget); err != nil {
+				return nil, err
+			}
+			budget -= len(args[i])
+		}
+		return args, nil
+	}
+}
+
+// Buffered returns how many bytes have arrived but not been read. A server
+// flushes its replies once it reaches zero, so a pipeline's replies go out
+// together.
+func (r *Reader) Buffered() int {
+	return r.r.Buffered()
+}
+
+// line reads a line without its CRLF. A lone LF ends a line too, for inline
+// commands typed into a terminal.
+func (r *Reader) line() (string, error) {
+	line, err := r.r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return "", fmt.Errorf("%w: line too long", ErrProtocol)
+	}
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			return "", io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r"), nil
+}
+
+func (r *Reader) length(s string, limit int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n > limit {
+		return 0, fmt.Errorf("%w: invalid length %q", ErrProtocol, s)
+	}
+	return n, nil
+}
+
+// bulk reads one bulk string of at most budget bytes.
+func (r *Reader) bulk(budget int) (string, error) {
+	line, err := r.line()
+	if err != nil {
+		return "", err
+	}
+	if len(line) == 0 || line[0] != '$' {
+		return "", fmt.Errorf("%w: expected '$', got %q", ErrProtocol, line)
+	}
+	n, err := r.length(line[1:], r.maxBulk)
+	if err != nil {
+		return "", err
+	}
+	if n < 0 {
+		return "", fmt.Errorf("%w: null bulk string in a command", ErrProtocol)
+	}
+	if n > budget {
+		return "", fmt.Errorf("%w: command larger than %d bytes", ErrProtocol, r.maxCommand)
+	}
+	buf := make([]byte, n+2)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	if buf[n] != '\r' || buf[n+1] != '\n' {
+		return "", fmt.Errorf("%w: bulk string not terminated by CRLF", ErrProtocol)
+	}
+	return string(buf[:n]), nil
+}
+
+// Writer writes replies in the protocol version the client chose with HELLO.
+// The RESP3 types it writes have a RESP2 form, so a reply is built the same
+// way for either: a map goes out as a flat array of keys and values, and a
+// null as a null bulk string.
+type Writer struct {
+	w *bufio.Writer
+	// Proto is 2 or 3. It starts at 2, as a Redis connection does.
+	Proto int
+}
+
+// NewWriter returns a RESP2 Writer over w. Replies are buffered until Flush.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w), Proto: 2}
+}
+
+// Flush writes the buffered replies.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+func (w *Writer) header(kind byte, n int) {
+	_ = w.w.WriteByte(kind)
+	_, _ = w.w.WriteString(strconv.Itoa(n))
+	_, _ = w.w.WriteString("\r\n")
+}
+
+// Status writes a simple string, such as OK.
+func (w *Writer) Status(s string) {
+	_ = w.w.WriteByte('+')
+	_, _ = w.w.WriteString(clean(s))
+	_, _ = w.w.WriteString("\r\n")
+}
+
+// Error writes an error reply. code is its first word, which clients match on,
+// as Redis's own ERR and WRONGTYPE.
+func (w *Writer) Error(code, msg string) {
+	_ = w.w.WriteByte('-')
+	_, _ = w.w.WriteString(code)
+	if msg != "" {
+		_ = w.w.WriteByte(' ')
+		_, _ = w.w.WriteString(clean(msg))
+	}
+	_, _ = w.w.WriteString("\r\n")
+}
+
+// Int writes an integer.
+func (w *Writer) Int(n int64) {
+	_ = w.w.WriteByte(':')
+	_, _ = w.w.WriteString(strconv.FormatInt(n, 10))
+	_, _ = w.w.WriteString("\r\n")
+}
+
+// Bulk writes a bulk string.
+func (w *Writer) Bulk(s string) {
+	w.header('$', len(s))
+	_, _ = w.w.WriteString(s)
+	_, _ = w.w.WriteString("\r\n")
+}
+
+// Null writes a null: RESP3's own, or a RESP2 null bulk string.
+func (w *Writer) Null() {
+	if w.Proto >= 3 {
+		_, _ = w.w.WriteString("_\r\n")
+		return
+	}
+	_, _ = w.w.WriteString("$-1\r\n")
+}
+
+// Array starts an array of n elements, which the next n replies written fill.
+func (w *Writer) Array(n int) {
+	w.header('*', n)
+}
+
+// Map starts a map of n pairs, each written as a key reply then a value reply.
+func (w *Writer) Map(n int) {
+	if w.Proto >= 3 {
+		w.header('%', n)
+		return
+	}
+	w.header('*', 2*n)
+}
+
+// Strings writes an array of bulk strings.
+func (w *Writer) Strings(list []string) {
+	w.Array(len(list))
+	for _, s := range list {
+		w.Bulk(s)
+	}
+}
+
+// clean keeps a simple string or error on its line: RESP gives them no length,
+// so a CR or LF inside would end the reply early.
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' {
+			return ' '
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCommand(t *testing.T) {
+	input := "*3\r\n$8\r\nACOR.ADD\r\n$5\r\nrules\r\n$6\r\nhe\r\nsh\r\n" +
+		"\r\n" +
+		"PING  hello\n" +
+		"*0\r\n" +
+		"*1\r\n$0\r\n\r\n"
+	r := NewReader(strings.NewReader(input), 64, 128)
+	for _, want := range [][]string{
+		{"ACOR.ADD", "rules", "he\r\nsh"},
+		{"PING", "hello"},
+		{""},
+	} {
+		got, err := r.ReadCommand()
+		if err != nil {
+			t.Fatalf("ReadCommand: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("ReadCommand = %q, want %q", got, want)
+		}
+	}
+	if _, err := r.ReadCommand(); !errors.Is(err, io.EOF) {
+		t.Fatalf("ReadCommand at end = %v, want io.EOF", err)
+	}
+}
+
+func TestReadCommandErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, input string
+		want        error
+	}{
+		{"bad array length", "*x\r\n", ErrProtocol},
+		{"too many elements", "*4097\r\n", ErrProtocol},
+		{"not a bulk string", "*1\r\n:1\r\n", ErrProtocol},
+		{"null bulk string", "*1\r\n$-1\r\n", ErrProtocol},
+		{"argument too large", "*1\r\n$65\r\n", ErrProtocol},
+		{"command too large", "*3\r\n$64\r\n" + strings.Repeat("a", 64) + "\r\n$64\r\n" + strings.Repeat("a", 64) +
+			"\r\n$1\r\n", ErrProtocol},
+		{"missing CRLF", "*1\r\n$2\r\nabcd\r\n", ErrProtocol},
+		{"truncated argument", "*1\r\n$4\r\nab", io.ErrUnexpectedEOF},
+		{"truncated line", "*2", io.ErrUnexpectedEOF},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewReader(strings.NewReader(tc.input), 64, 128).ReadCommand()
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("ReadCommand = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	write := func(w *Writer) {
+		w.Map(2)
+		w.Bulk("keywords")
+		w.Int(2)
+		w.Bulk("list")
+		w.Strings([]string{"he", ""})
+		w.Null()
+		w.Status("OK")
+		w.Error("ERR", "two\r\nlines")
+	}
+	for _, tc := range []struct {
+		proto int
+		want  string
+	}{
+		{2, "*4\r\n$8\r\nkeywords\r\n:2\r\n$4\r\nlist\r\n*2\r\n$2\r\nhe\r\n$0\r\n\r\n$-1\r\n+OK\r\n-ERR two  lines\r\n"},
+		{3, "%2\r\n$8\r\nkeywords\r\n:2\r\n$4\r\nlist\r\n*2\r\n$2\r\nhe\r\n$0\r\n\r\n_\r\n+OK\r\n-ERR two  lines\r\n"},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.Proto = tc.proto
+		write(w)
+		if buf.Len() != 0 {
+			t.Fatalf("RESP%d: wrote %q before Flush", tc.proto, buf.String())
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tc.want {
+			t.Errorf("RESP%d:\n got %q\nwant %q", tc.proto, got, tc.want)
+		}
+	}
+}
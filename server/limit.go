@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+
+	"github.com/skyoo2003/acor/server/auth"
+	"github.com/skyoo2003/acor/server/limit"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// limitBody is what the limiter reads of a request: the text it carries, the
+// items it batches, and the names that say which collections it touches.
+type limitBody struct {
+	Keyword     string                     `json:"keyword"`
+	Input       string                     `json:"input"`
+	Collections []string                   `json:"collections"`
+	Alias       string                     `json:"alias"`
+	Weights     map[string]json.RawMessage `json:"weights"`
+}
+
+// limitRequest describes a request for op to the limiter. The caller is known by
+// its authenticated subject when an auth guard ran first, and by remoteAddr's
+// host otherwise.
+func limitRequest(ctx context.Context, service Service, op auth.Operation, body *limitBody, remoteAddr string) *limit.Request {
+	req := &limit.Request{
+		Operation:   op.Name,
+		Identity:    remoteHost(remoteAddr),
+		Collections: authCollections(service, op, body.Collections, body.Alias),
+	}
+	if id := auth.FromContext(ctx); id != nil {
+		req.Identity = id.Subject
+	}
+	switch {
+	case body.Input != "":
+		req.TextBytes, req.TextField = len(body.Input), "input"
+	case body.Keyword != "":
+		req.TextBytes, req.TextField = len(body.Keyword), "keyword"
+	}
+	switch {
+	case len(body.Weights) > 0:
+		req.Batch, req.BatchField = len(body.Weights), "weights"
+	case len(body.Collections) > 0:
+		req.Batch, req.BatchField = len(body.Collections), "collections"
+	}
+	return req
+}
+
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// RateLimitHTTPMiddleware returns middleware that admits every request for a
+// route of NewHTTPHandler through limiter before it reaches the handler.
+// /healthz and paths the handler does not serve pass through unlimited. A
+// request over a rate or concurrency limit gets a 429, and one over a size
+// limit a 413, each as a problem document; a 429 carries Retry-After.
+//
+// Wrap the handler for service itself, as with AuthHTTPMiddleware. Put this
+// middleware inside the auth middleware so callers are limited by their
+// authenticated subject rather than their address.
+func RateLimitHTTPMiddleware(service Service, limiter *limit.Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op, ok := authPaths[r.URL.Path]
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var body limitBody
+			if r.Method == http.MethodPost {
+				peekJSON(r, &body)
+			}
+			release, err := limiter.Acquire(limitRequest(r.Context(), service, op, &body, r.RemoteAddr))
+			if err != nil {
+				writeProblem(w, classifyError(err))
+				return
+			}
+			defer release()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitUnaryInterceptor returns a gRPC interceptor that admits every Acor
+// RPC through limiter, as RateLimitHTTPMiddleware does its routes. A call over
+// a limit fails with ResourceExhausted, carrying a RetryInfo when waiting can
+// help or a BadRequest naming the field that was too large.
+func RateLimitUnaryInterceptor(service Service, limiter *limit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var body limitBody
+		switch r := req.(type) {
+		case *acorv1.KeywordRequest:
+			body.Keyword = r.GetKeyword()
+		case *acorv1.InputRequest:
+			body.Input = r.GetInput()
+		case *acorv1.ScoreRequest:
+			body.Input = r.GetInput()
+		case *acorv1.FindAcrossRequest:
+			body.Input, body.Collections = r.GetInput(), r.GetCollections()
+		case *acorv1.SetWeightsRequest:
+			body.Weights = make(map[string]json.RawMessage, len(r.GetWeights()))
+			for k := range r.GetWeights() {
+				body.Weights[k] = nil
+			}
+		case *acorv1.AliasRequest:
+			body.Alias = r.GetAlias()
+		}
+		release, err := acquireRPC(ctx, service, limiter, info.FullMethod, &body)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStreamInterceptor is RateLimitUnaryInterceptor for the streaming
+// RPCs. A stream holds its slot against MaxConcurrent until it ends, which
+// makes MaxConcurrent on "watch" a cap on open watches.
+func RateLimitStreamInterceptor(service Service, limiter *limit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		release, err := acquireRPC(ss.Context(), service, limiter, info.FullMethod, &limitBody{})
+		if err != nil {
+			return err
+		}
+		defer release()
+		return handler(srv, ss)
+	}
+}
+
+// acquireRPC admits a call to fullMethod through limiter. Methods outside the
+// Acor service pass through unlimited.
+func acquireRPC(ctx context.Context, service Service, limiter *limit.Limiter, fullMethod string, body *limitBody) (func(), error) {
+	rpc, ok := strings.CutPrefix(fullMethod, "/"+acorv1.Acor_ServiceDesc.ServiceName+"/")
+	op, known := authRoutes[rpc]
+	if !ok || !known {
+		return func() {}, nil
+	}
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+	release, err := limiter.Acquire(limitRequest(ctx, service, op, body, remoteAddr))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return release, nil
+}
@@ -0,0 +1,291 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package limit keeps one caller, or one collection, from taking the acor
+// server from everyone else. A Limiter admits each request against token-bucket
+// rates per identity and per collection, size limits on its text and batch, and
+// a cap on how many requests of an operation run at once. The server package
+// applies one Limiter to its HTTP handler and gRPC service alike.
+package limit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/skyoo2003/acor/server/metrics"
+)
+
+// ErrLimited is wrapped by every *Error, so errors.Is(err, ErrLimited) spots a
+// request a Limiter turned away.
+var ErrLimited = errors.New("request limited")
+
+// Kind names which limit turned a request away.
+type Kind string
+
+const (
+	// KindIdentity is the caller's rate.
+	KindIdentity Kind = "identity"
+	// KindCollection is a collection's rate, shared by every caller.
+	KindCollection Kind = "collection"
+	// KindOperation is the caller's rate for one operation.
+	KindOperation Kind = "operation"
+	// KindConcurrency is an operation's cap on requests in flight.
+	KindConcurrency Kind = "concurrency"
+	// KindText is an operation's maximum text size.
+	KindText Kind = "text"
+	// KindBatch is an operation's maximum batch size.
+	KindBatch Kind = "batch"
+)
+
+// Error reports a request a Limiter turned away.
+type Error struct {
+	Kind Kind
+	// Operation is the operation that was limited.
+	Operation string
+	// RetryAfter is how long until the same request could pass: when the bucket
+	// will hold a token again, or a nominal second for a concurrency cap. It is
+	// zero for a size limit, which the same request will always exceed.
+	RetryAfter time.Duration
+	// Field names the request field a size limit applies to.
+	Field string
+	// Detail says what was exceeded.
+	Detail string
+}
+
+// Error returns the kind of limit and what was exceeded.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s limit on %s: %s", e.Kind, e.Operation, e.Detail)
+}
+
+// Unwrap returns ErrLimited.
+func (e *Error) Unwrap() error { return ErrLimited }
+
+// Rate is a token bucket: a steady PerSecond, with bursts up to Burst. The zero
+// Rate is unlimited.
+type Rate struct {
+	PerSecond float64 `json:"per_second"`
+	Burst     int     `json:"burst"`
+}
+
+func (r Rate) enabled() bool { return r.PerSecond > 0 && r.Burst > 0 }
+
+// OperationLimits are the limits on one operation.
+type OperationLimits struct {
+	// Rate limits each caller's requests of this operation, on top of
+	// Config.PerIdentity.
+	Rate Rate `json:"rate"`
+	// MaxTextBytes caps the text a request carries: the input to search or the
+	// keyword to add or remove. Zero leaves only the server's body cap.
+	MaxTextBytes int `json:"max_text_bytes"`
+	// MaxBatch caps how many items a request carries: weights to set,
+	// collections to search. Zero is unlimited.
+	MaxBatch int `json:"max_batch"`
+	// MaxConcurrent caps how many requests of this operation run at once,
+	// across all callers. Zero is unlimited.
+	MaxConcurrent int `json:"max_concurrent"`
+}
+
+// Config is what a Limiter enforces. The zero Config limits nothing.
+type Config struct {
+	// PerIdentity limits each caller's requests, whatever the operation.
+	PerIdentity Rate `json:"per_identity"`
+	// PerCollection limits the requests touching each collection, whoever
+	// makes them.
+	PerCollection Rate `json:"per_collection"`
+	// Operations holds limits per operation name: "find", "add", "flush".
+	Operations map[string]OperationLimits `json:"operations"`
+}
+
+// Request describes one request to a Limiter.
+type Request struct {
+	Operation string
+	// Identity keys the caller's buckets: its authenticated subject, or its
+	// address when there is none.
+	Identity string
+	// Collections are the collections the request touches.
+	Collections []string
+	// TextBytes is the size of the request's text, and TextField the field
+	// that holds it.
+	TextBytes int
+	TextField string
+	// Batch is how many items the request carries, and BatchField the field
+	// that holds them.
+	Batch      int
+	BatchField string
+}
+
+// Limiter admits requests under a Config. It is safe for concurrent use.
+type Limiter struct {
+	cfg     Config
+	metrics *metrics.Registry
+	now     func() time.Time
+
+	mu       sync.Mutex
+	buckets  map[bucketKey]*bucket
+	sweepAt  int
+	inFlight map[string]int
+}
+
+type bucketKey struct {
+	kind Kind
+	key  string
+}
+
+// minSweep is the bucket count below which a Limiter does not bother dropping
+// idle buckets.
+const minSweep = 1024
+
+// New returns a Limiter enforcing cfg. reg, when non-nil, receives
+// acor_requests_limited_total and acor_requests_in_flight.
+func New(cfg Config, reg *metrics.Registry) *Limiter {
+	return &Limiter{
+		cfg:      cfg,
+		metrics:  reg,
+		now:      time.Now,
+		buckets:  make(map[bucketKey]*bucket),
+		sweepAt:  minSweep,
+		inFlight: make(map[string]int),
+	}
+}
+
+// Acquire admits req or returns an *Error saying which limit it hit. An
+// admitted request holds a slot against its operation's MaxConcurrent until
+// it calls release; calls after the first do nothing. Size limits are checked
+// first, so a request that can never pass takes no tokens.
+func (l *Limiter) Acquire(req *Request) (release func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	op := l.cfg.Operations[req.Operation]
+	if op.MaxTextBytes > 0 && req.TextBytes > op.MaxTextBytes {
+		return nil, l.reject(&Error{Kind: KindText, Operation: req.Operation, Field: req.TextField,
+			Detail: fmt.Sprintf("%s is %d bytes, more than %d", req.TextField, req.TextBytes, op.MaxTextBytes)})
+	}
+	if op.MaxBatch > 0 && req.Batch > op.MaxBatch {
+		return nil, l.reject(&Error{Kind: KindBatch, Operation: req.Operation, Field: req.BatchField,
+			Detail: fmt.Sprintf("%s holds %d items, more than %d", req.BatchField, req.Batch, op.MaxBatch)})
+	}
+
+	if op.MaxConcurrent > 0 && l.inFlight[req.Operation] >= op.MaxConcurrent {
+		return nil, l.reject(&Error{Kind: KindConcurrency, Operation: req.Operation, RetryAfter: time.Second,
+			Detail: fmt.Sprintf("%d already running", op.MaxConcurrent)})
+	}
+
+	// Every bucket is checked before any is drawn from, so a request turned
+	// away by one limit costs nothing against the others.
+	now := l.now()
+	type draw struct {
+		b    *bucket
+		kind Kind
+	}
+	var draws []draw
+	add := func(kind Kind, key string, rate Rate) {
+		if rate.enabled() {
+			draws = append(draws, draw{l.bucket(kind, key, rate, now), kind})
+		}
+	}
+	add(KindIdentity, req.Identity, l.cfg.PerIdentity)
+	add(KindOperation, req.Operation+"\x00"+req.Identity, op.Rate)
+	for _, c := range req.Collections {
+		add(KindCollection, c, l.cfg.PerCollection)
+	}
+	for _, d := range draws {
+		if wait := d.b.wait(); wait > 0 {
+			return nil, l.reject(&Error{Kind: d.kind, Operation: req.Operation, RetryAfter: wait,
+				Detail: fmt.Sprintf("retry in %s", wait.Round(time.Millisecond))})
+		}
+	}
+	for _, d := range draws {
+		d.b.tokens--
+	}
+
+	l.inFlight[req.Operation]++
+	l.setInFlight(req.Operation)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.inFlight[req.Operation]--
+			l.setInFlight(req.Operation)
+		})
+	}, nil
+}
+
+// SetConfig replaces the limits l enforces, as a configuration reload does.
+// Callers keep the tokens they have, up to the new Burst, and earn more at the
+// new rate from then on. Requests already admitted keep their concurrency
+// slots, so lowering MaxConcurrent turns new requests away until enough of
+// them finish.
+func (l *Limiter) SetConfig(cfg Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+}
+
+// bucket returns the bucket for key, refilled to now, creating it full. It
+// drops buckets that have refilled completely once their number doubles, which
+// forgets nothing: a full bucket is what a new one would be. A bucket created
+// under an earlier Config takes on rate, keeping no more tokens than its Burst.
+func (l *Limiter) bucket(kind Kind, key string, rate Rate, now time.Time) *bucket {
+	k := bucketKey{kind, key}
+	b, ok := l.buckets[k]
+	if !ok {
+		if len(l.buckets) >= l.sweepAt {
+			for k, b := range l.buckets {
+				if b.refill(now) {
+					delete(l.buckets, k)
+				}
+			}
+			l.sweepAt = max(minSweep, 2*len(l.buckets))
+		}
+		b = &bucket{rate: rate, tokens: float64(rate.Burst), last: now}
+		l.buckets[k] = b
+	}
+	b.refill(now)
+	if b.rate != rate {
+		b.rate = rate
+		b.tokens = math.Min(b.tokens, float64(rate.Burst))
+	}
+	return b
+}
+
+func (l *Limiter) reject(err *Error) error {
+	if l.metrics != nil {
+		l.metrics.RequestsLimitedTotal.WithLabelValues(err.Operation, string(err.Kind)).Inc()
+	}
+	return err
+}
+
+func (l *Limiter) setInFlight(op string) {
+	if l.metrics != nil {
+		l.metrics.RequestsInFlight.WithLabelValues(op).Set(float64(l.inFlight[op]))
+	}
+}
+
+// bucket is one token bucket.
+type bucket struct {
+	rate   Rate
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since the last refill and reports whether the
+// bucket is full.
+func (b *bucket) refill(now time.Time) bool {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(b.rate.Burst), b.tokens+elapsed.Seconds()*b.rate.PerSecond)
+		b.last = now
+	}
+	return b.tokens >= float64(b.rate.Burst)
+}
+
+// wait returns how long until the bucket holds a whole token, zero if it
+// already does.
+func (b *bucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate.PerSecond * float64(time.Second))
+}
@@ -0,0 +1,198 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package limit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/skyoo2003/acor/server/metrics"
+)
+
+// clock is a Limiter's time, advanced by hand.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter(cfg Config) (*Limiter, *clock, *metrics.Registry) {
+	reg := metrics.NewRegistry(prometheus.NewRegistry())
+	l := New(cfg, reg)
+	c := &clock{t: time.Unix(1_700_000_000, 0)}
+	l.now = c.now
+	return l, c, reg
+}
+
+func limitKind(t *testing.T, err error) Kind {
+	t.Helper()
+	var limitErr *Error
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrLimited) {
+		t.Fatalf("error = %v, want a *limit.Error", err)
+	}
+	return limitErr.Kind
+}
+
+func TestIdentityRate(t *testing.T) {
+	l, c, reg := newTestLimiter(Config{PerIdentity: Rate{PerSecond: 2, Burst: 2}})
+	find := &Request{Operation: "find", Identity: "alice"}
+	for i := 0; i < 2; i++ {
+		release, err := l.Acquire(find)
+		if err != nil {
+			t.Fatalf("request %d within the burst: %v", i, err)
+		}
+		release()
+	}
+	_, err := l.Acquire(find)
+	if kind := limitKind(t, err); kind != KindIdentity {
+		t.Errorf("kind = %s, want identity", kind)
+	}
+	var limitErr *Error
+	errors.As(err, &limitErr)
+	if limitErr.RetryAfter != 500*time.Millisecond {
+		t.Errorf("RetryAfter = %s, want 500ms at 2/s", limitErr.RetryAfter)
+	}
+
+	// Another caller has a bucket of its own.
+	if _, err := l.Acquire(&Request{Operation: "find", Identity: "bob"}); err != nil {
+		t.Errorf("bob: %v", err)
+	}
+	c.advance(500 * time.Millisecond)
+	if _, err := l.Acquire(find); err != nil {
+		t.Errorf("after the refill: %v", err)
+	}
+	if got := testutil.ToFloat64(reg.RequestsLimitedTotal.WithLabelValues("find", "identity")); got != 1 {
+		t.Errorf("acor_requests_limited_total = %v, want 1", got)
+	}
+}
+
+func TestRejectedRequestTakesNoTokens(t *testing.T) {
+	l, _, _ := newTestLimiter(Config{
+		PerIdentity:   Rate{PerSecond: 1, Burst: 5},
+		PerCollection: Rate{PerSecond: 1, Burst: 1},
+	})
+	if _, err := l.Acquire(&Request{Operation: "find", Identity: "alice", Collections: []string{"rules"}}); err != nil {
+		t.Fatal(err)
+	}
+	// "rules" is spent, so this fails on it; alice's bucket must not pay.
+	_, err := l.Acquire(&Request{Operation: "find", Identity: "alice", Collections: []string{"other", "rules"}})
+	if kind := limitKind(t, err); kind != KindCollection {
+		t.Fatalf("kind = %s, want collection", kind)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := l.Acquire(&Request{Operation: "find", Identity: "alice", Collections: []string{"c" + string(rune('a'+i))}}); err != nil {
+			t.Fatalf("request %d: %v; a rejected request took alice's tokens", i, err)
+		}
+	}
+	// "other" was not drawn from either.
+	if _, err := l.Acquire(&Request{Operation: "find", Identity: "bob", Collections: []string{"other"}}); err != nil {
+		t.Errorf("other: %v", err)
+	}
+}
+
+func TestOperationLimits(t *testing.T) {
+	l, _, reg := newTestLimiter(Config{Operations: map[string]OperationLimits{
+		"find":        {MaxTextBytes: 8},
+		"set-weights": {MaxBatch: 2},
+		"flush":       {MaxConcurrent: 1},
+		"add":         {Rate: Rate{PerSecond: 1, Burst: 1}},
+	}})
+
+	_, err := l.Acquire(&Request{Operation: "find", TextBytes: 9, TextField: "input"})
+	var limitErr *Error
+	if kind := limitKind(t, err); kind != KindText || !errors.As(err, &limitErr) || limitErr.Field != "input" || limitErr.RetryAfter != 0 {
+		t.Errorf("9-byte find: %+v", limitErr)
+	}
+	if _, err := l.Acquire(&Request{Operation: "find", TextBytes: 8}); err != nil {
+		t.Errorf("8-byte find: %v", err)
+	}
+	_, err = l.Acquire(&Request{Operation: "set-weights", Batch: 3, BatchField: "weights"})
+	if kind := limitKind(t, err); kind != KindBatch {
+		t.Errorf("kind = %s, want batch", kind)
+	}
+
+	release, err := l.Acquire(&Request{Operation: "flush"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(reg.RequestsInFlight.WithLabelValues("flush")); got != 1 {
+		t.Errorf("acor_requests_in_flight = %v, want 1", got)
+	}
+	_, err = l.Acquire(&Request{Operation: "flush"})
+	if kind := limitKind(t, err); kind != KindConcurrency {
+		t.Errorf("kind = %s, want concurrency", kind)
+	}
+	release()
+	release() // a second call is a no-op
+	if got := testutil.ToFloat64(reg.RequestsInFlight.WithLabelValues("flush")); got != 0 {
+		t.Errorf("acor_requests_in_flight = %v after release, want 0", got)
+	}
+	if _, err := l.Acquire(&Request{Operation: "flush"}); err != nil {
+		t.Errorf("flush after release: %v", err)
+	}
+
+	// An operation's rate is per caller and leaves other operations alone.
+	if _, err := l.Acquire(&Request{Operation: "add", Identity: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Acquire(&Request{Operation: "add", Identity: "alice"}); limitKind(t, err) != KindOperation {
+		t.Errorf("second add: %v", err)
+	}
+	if _, err := l.Acquire(&Request{Operation: "add", Identity: "bob"}); err != nil {
+		t.Errorf("bob's add: %v", err)
+	}
+	if _, err := l.Acquire(&Request{Operation: "find", Identity: "alice"}); err != nil {
+		t.Errorf("alice's find: %v", err)
+	}
+}
+
+func TestIdleBucketsAreSwept(t *testing.T) {
+	l, c, _ := newTestLimiter(Config{PerIdentity: Rate{PerSecond: 1, Burst: 1}})
+	for i := 0; i < minSweep; i++ {
+		if _, err := l.Acquire(&Request{Operation: "find", Identity: string(rune(0x1000 + i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.advance(time.Second)
+	if _, err := l.Acquire(&Request{Operation: "find", Identity: "late"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(l.buckets); n != 1 {
+		t.Errorf("%d buckets after the sweep, want 1", n)
+	}
+}
+
+func TestSetConfig(t *testing.T) {
+	l, c, _ := newTestLimiter(Config{PerIdentity: Rate{PerSecond: 1, Burst: 4}})
+	find := &Request{Operation: "find", Identity: "alice"}
+	if _, err := l.Acquire(find); err != nil {
+		t.Fatal(err)
+	}
+
+	// Three tokens are left; the new Burst keeps one of them.
+	l.SetConfig(Config{PerIdentity: Rate{PerSecond: 10, Burst: 1}, Operations: map[string]OperationLimits{
+		"find": {MaxTextBytes: 8},
+	}})
+	if _, err := l.Acquire(find); err != nil {
+		t.Fatal(err)
+	}
+	_, err := l.Acquire(find)
+	var limitErr *Error
+	if kind := limitKind(t, err); kind != KindIdentity || !errors.As(err, &limitErr) || limitErr.RetryAfter != 100*time.Millisecond {
+		t.Errorf("third find: %+v, want an identity limit retrying at the new rate", limitErr)
+	}
+	c.advance(100 * time.Millisecond)
+	if _, err := l.Acquire(&Request{Operation: "find", Identity: "alice", TextBytes: 9}); limitKind(t, err) != KindText {
+		t.Errorf("9-byte find under the new config: %v", err)
+	}
+
+	l.SetConfig(Config{})
+	for i := 0; i < 10; i++ {
+		if _, err := l.Acquire(find); err != nil {
+			t.Fatalf("find %d under the zero Config: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	"github.com/skyoo2003/acor/server/limit"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// testLimits allows each caller two requests, then one a minute; caps keywords
+// at 8 bytes, and weight batches at 2.
+var testLimits = limit.Config{
+	PerIdentity: limit.Rate{PerSecond: 1.0 / 60, Burst: 2},
+	Operations: map[string]limit.OperationLimits{
+		"add":         {MaxTextBytes: 8},
+		"set-weights": {MaxBatch: 2},
+	},
+}
+
+func TestRateLimitHTTPMiddleware(t *testing.T) {
+	service := &fakeService{addCount: 1, collection: "rules"}
+	handler := NewHTTPHandler(service)
+	// The auth guard runs first, so callers are limited by subject.
+	handler = RateLimitHTTPMiddleware(service, limit.New(testLimits, nil))(handler)
+	handler = AuthHTTPMiddleware(service, testGuard(new(bytes.Buffer)))(handler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	post := func(path, key, body string) (*http.Response, *ErrorResponse) {
+		t.Helper()
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-API-Key", key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		var problem ErrorResponse
+		if resp.StatusCode != http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp, &problem
+	}
+
+	// Size limits turn a request away before it takes a token.
+	resp, problem := post("/v1/add", "k-write", `{"keyword":"much too long"}`)
+	if resp.StatusCode != http.StatusRequestEntityTooLarge || problem.Code != "REQUEST_TOO_LARGE" ||
+		len(problem.InvalidParams) != 1 || problem.InvalidParams[0].Name != "keyword" {
+		t.Errorf("long keyword: %d %+v", resp.StatusCode, problem)
+	}
+	if resp.Header.Get("Retry-After") != "" {
+		t.Error("a size limit set Retry-After")
+	}
+
+	for i := 0; i < 2; i++ {
+		if resp, problem := post("/v1/add", "k-write", `{"keyword":"he"}`); resp.StatusCode != http.StatusOK {
+			t.Fatalf("add %d: %d %+v", i, resp.StatusCode, problem)
+		}
+	}
+	resp, problem = post("/v1/add", "k-write", `{"keyword":"he"}`)
+	if resp.StatusCode != http.StatusTooManyRequests || problem.Code != "RATE_LIMITED" || problem.Operation != "add" {
+		t.Errorf("third add: %d %+v", resp.StatusCode, problem)
+	}
+	if got := resp.Header.Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want 60", got)
+	}
+	if service.lastKeyword != "he" {
+		t.Errorf("handler saw keyword %q", service.lastKeyword)
+	}
+
+	// The reader is a different subject from the same address.
+	if resp, problem := post("/v1/find", "k-read", `{"input":"he"}`); resp.StatusCode != http.StatusOK {
+		t.Errorf("reader's find: %d %+v", resp.StatusCode, problem)
+	}
+}
+
+func TestRateLimitUnaryInterceptor(t *testing.T) {
+	service := &fakeService{addCount: 1, collection: "rules", weights: map[string]acor.KeywordWeight{}}
+	limiter := limit.New(testLimits, nil)
+	client := newGRPCTestClient(t, service, grpc.UnaryInterceptor(RateLimitUnaryInterceptor(service, limiter)))
+	// Without an auth guard, the caller is known by its address.
+	ctx := context.Background()
+
+	_, err := client.SetWeights(ctx, &acorv1.SetWeightsRequest{Weights: map[string]*acorv1.KeywordWeight{"a": {}, "b": {}, "c": {}}})
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("three weights: %v, want ResourceExhausted", err)
+	}
+	var field string
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			field = br.GetFieldViolations()[0].GetField()
+		}
+	}
+	if field != "weights" {
+		t.Errorf("BadRequest field = %q, want weights", field)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Add(ctx, &acorv1.KeywordRequest{Keyword: keywordHE}); err != nil {
+			t.Fatalf("add %d: %v", i, err)
+		}
+	}
+	_, err = client.Add(ctx, &acorv1.KeywordRequest{Keyword: keywordHE})
+	st = status.Convert(err)
+	var delay float64
+	for _, d := range st.Details() {
+		if ri, ok := d.(*errdetails.RetryInfo); ok {
+			delay = ri.GetRetryDelay().AsDuration().Seconds()
+		}
+	}
+	if st.Code() != codes.ResourceExhausted || delay < 59 || delay > 60 {
+		t.Errorf("third add: %v with retry delay %vs, want ResourceExhausted after 60s", err, delay)
+	}
+}
@@ -24,6 +24,18 @@ func GRPCUnaryInterceptor(logger *Logger) grpc.UnaryServerInterceptor {
 	)
 }
 
+// GRPCStreamInterceptor is GRPCUnaryInterceptor for streaming RPCs: one record
+// per stream, when it ends.
+func GRPCStreamInterceptor(logger *Logger) grpc.StreamServerInterceptor {
+	if logger == nil {
+		panic("logging: nil logger passed to GRPCStreamInterceptor")
+	}
+	return grpclog.StreamServerInterceptor(
+		zerologAdapter(logger),
+		grpclog.WithLogOnEvents(grpclog.FinishCall),
+	)
+}
+
 // zerologAdapter bridges the middleware's Logger contract to zerolog.
 func zerologAdapter(logger *Logger) grpclog.Logger {
 	return grpclog.LoggerFunc(func(_ context.Context, level grpclog.Level, msg string, fields ...any) {
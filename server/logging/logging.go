@@ -3,31 +3,74 @@
 package logging
 
 import (
+	"fmt"
 	"io"
+	"sync/atomic"
 
 	"github.com/rs/zerolog"
 )
 
 type Logger struct {
 	zerolog.Logger
+	level *atomic.Int32
 }
 
-// NewLogger returns a JSON logger writing to w at any level
-// zerolog.ParseLevel accepts. ParseLevel also parses numeric strings, so the
-// result is range-checked: "99" would otherwise silence every event rather
-// than fall back to info like any other unrecognized value.
+// NewLogger returns a JSON logger writing to w at any level ParseLevel
+// accepts, falling back to info for any other value.
+//
+// The level lives outside the zerolog.Logger, so SetLevel changes it for this
+// logger and every one derived from it, including those already handed out.
 func NewLogger(w io.Writer, level string) *Logger {
-	zl := zerolog.New(w).With().Timestamp().Logger()
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		parsed = zerolog.InfoLevel
+	}
+	l := &Logger{level: new(atomic.Int32)}
+	l.level.Store(int32(parsed))
+	// The zerolog level only stops what no SetLevel could let through; the
+	// hook drops what the current level does not.
+	l.Logger = zerolog.New(w).With().Timestamp().Logger().Level(zerolog.TraceLevel).Hook(levelHook{l.level})
+	return l
+}
 
+// ParseLevel parses a level name as zerolog.ParseLevel does. ParseLevel also
+// parses numeric strings, so the result is range-checked: "99" would otherwise
+// silence every event rather than be rejected like any other unknown value.
+func ParseLevel(level string) (zerolog.Level, error) {
 	parsed, err := zerolog.ParseLevel(level)
 	if err != nil || parsed == zerolog.NoLevel ||
 		parsed < zerolog.TraceLevel || parsed > zerolog.Disabled {
-		parsed = zerolog.InfoLevel
+		return zerolog.NoLevel, fmt.Errorf("logging: unknown level %q", level)
+	}
+	return parsed, nil
+}
+
+// SetLevel changes the level of l and of every logger derived from it. An
+// unknown level returns an error and leaves the level as it was.
+func (l *Logger) SetLevel(level string) error {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
 	}
+	l.level.Store(int32(parsed))
+	return nil
+}
 
-	return &Logger{zl.Level(parsed)}
+// GetLevel returns the level l currently logs at, which is SetLevel's rather
+// than the embedded zerolog.Logger's.
+func (l *Logger) GetLevel() zerolog.Level {
+	return zerolog.Level(l.level.Load())
 }
 
 func (l *Logger) WithTraceID(traceID, spanID string) *Logger {
-	return &Logger{l.Logger.With().Str("trace_id", traceID).Str("span_id", spanID).Logger()}
+	return &Logger{l.Logger.With().Str("trace_id", traceID).Str("span_id", spanID).Logger(), l.level}
+}
+
+// levelHook discards events below the level it reads.
+type levelHook struct{ level *atomic.Int32 }
+
+func (h levelHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	if current := zerolog.Level(h.level.Load()); current == zerolog.Disabled || level < current {
+		e.Discard()
+	}
 }
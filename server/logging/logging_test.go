@@ -93,3 +93,38 @@ func TestNewLoggerAllLevels(t *testing.T) {
 		})
 	}
 }
+
+func TestSetLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger(buf, "warn")
+	derived := logger.WithTraceID("abc123", "def456")
+
+	if err := logger.SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	derived.Debug().Msg("dbg")
+	if buf.Len() == 0 {
+		t.Error("debug log from a derived logger should appear after SetLevel(debug)")
+	}
+	if got := logger.GetLevel().String(); got != "debug" {
+		t.Errorf("GetLevel() = %q, want debug", got)
+	}
+
+	if err := logger.SetLevel("99"); err == nil {
+		t.Error("SetLevel(99) should fail")
+	}
+	buf.Reset()
+	logger.Debug().Msg("dbg")
+	if buf.Len() == 0 {
+		t.Error("a failed SetLevel should leave the level unchanged")
+	}
+
+	if err := logger.SetLevel("disabled"); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	derived.Error().Msg("err")
+	if buf.Len() > 0 {
+		t.Error("nothing should be logged once disabled")
+	}
+}
@@ -15,6 +15,12 @@ type Registry struct {
 	RedisOperationDuration *prometheus.HistogramVec
 	KeywordsTotal          prometheus.Gauge
 	TrieNodesTotal         prometheus.Gauge
+	// RequestsLimitedTotal counts requests a limit.Limiter turned away, by
+	// operation and by the limit that did it.
+	RequestsLimitedTotal *prometheus.CounterVec
+	// RequestsInFlight is how many requests per operation a limit.Limiter has
+	// admitted and not yet seen finish.
+	RequestsInFlight *prometheus.GaugeVec
 	// GRPCServer holds the standard grpc_server_* Prometheus metrics. Install it
 	// on a gRPC server via its UnaryServerInterceptor().
 	GRPCServer *grpcprom.ServerMetrics
@@ -80,5 +86,21 @@ func NewRegistry(registerer prometheus.Registerer) *Registry {
 				Help:      "Number of trie nodes",
 			},
 		),
+		RequestsLimitedTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "requests_limited_total",
+				Help:      "Requests rejected by a rate limit, size limit, or concurrency cap",
+			},
+			[]string{"operation", "limit"},
+		),
+		RequestsInFlight: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "requests_in_flight",
+				Help:      "Requests admitted by the limiter and still running",
+			},
+			[]string{"operation"},
+		),
 	}
 }
@@ -31,6 +31,9 @@ func TestNewRegistry(t *testing.T) {
 	if reg.TrieNodesTotal == nil {
 		t.Error("expected TrieNodesTotal to be initialized")
 	}
+	if reg.RequestsLimitedTotal == nil || reg.RequestsInFlight == nil {
+		t.Error("expected the limiter metrics to be initialized")
+	}
 	if reg.GRPCServer == nil {
 		t.Error("expected GRPCServer to be initialized")
 	}
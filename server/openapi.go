@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// openAPIOverrides are the schemas of the fields whose JSON on the hand-written
+// routes is not what their proto type says: index matches are plain arrays
+// rather than Positions, and a watch event's kind is its lower-case name.
+var openAPIOverrides = map[protoreflect.FullName]map[string]any{
+	"acor.server.v1.MatchIndexesResponse.matches": {
+		"type":                 "object",
+		"additionalProperties": map[string]any{"type": "array", "items": map[string]any{"type": "integer", "format": "int64"}},
+	},
+	"acor.server.v1.ChangeEvent.kind": {"type": "string", "enum": []string{EventChange, EventResync}},
+}
+
+// problemSchema describes ErrorResponse.
+var problemSchema = map[string]any{
+	"type":     "object",
+	"required": []string{"type", "title", "status", "detail", "code"},
+	"properties": map[string]any{
+		"type":      map[string]any{"type": "string", "format": "uri"},
+		"title":     map[string]any{"type": "string"},
+		"status":    map[string]any{"type": "integer"},
+		"detail":    map[string]any{"type": "string"},
+		"code":      map[string]any{"type": "string"},
+		"error":     map[string]any{"type": "string", "deprecated": true},
+		"operation": map[string]any{"type": "string"},
+		"invalid_params": map[string]any{"type": "array", "items": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"name":   map[string]any{"type": "string"},
+				"reason": map[string]any{"type": "string"},
+			},
+		}},
+	},
+}
+
+// openAPIDocument is the OpenAPI 3 description of the routes NewHTTPHandler
+// serves, built once from the bindings and messages in acor.proto.
+var openAPIDocument = sync.OnceValue(func() []byte {
+	schemas := map[string]any{"Problem": problemSchema}
+	paths := make(map[string]any)
+	for _, b := range httpBindings() {
+		paths[b.path] = map[string]any{strings.ToLower(b.method): openAPIOperation(b, schemas)}
+	}
+	doc, err := json.Marshal(map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "acor",
+			"version":     "v1",
+			"description": "HTTP/JSON API of the acor server, generated from the google.api.http bindings in acor.proto.",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	})
+	if err != nil {
+		panic(err) // every value above is a map, slice, or string
+	}
+	return doc
+})
+
+// openAPIOperation describes the route of b, adding the messages it uses to
+// schemas. A POST carries its request as the body, and any other method as
+// query parameters; a streaming RPC answers with Server-Sent Events.
+func openAPIOperation(b httpBinding, schemas map[string]any) map[string]any {
+	in, out := b.rpc.Input(), b.rpc.Output()
+	op := map[string]any{"operationId": string(b.rpc.Name())}
+	if b.method == http.MethodPost {
+		if in.Fields().Len() > 0 {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": openAPIRef(in, schemas)}},
+			}
+		}
+	} else {
+		var params []any
+		for i := 0; i < in.Fields().Len(); i++ {
+			fd := in.Fields().Get(i)
+			params = append(params, map[string]any{"name": string(fd.Name()), "in": "query", "schema": openAPIField(fd, schemas)})
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+	}
+	contentType := "application/json"
+	if b.rpc.IsStreamingServer() {
+		contentType = "text/event-stream"
+	}
+	op["responses"] = map[string]any{
+		"200": map[string]any{
+			"description": "OK",
+			"content":     map[string]any{contentType: map[string]any{"schema": openAPIRef(out, schemas)}},
+		},
+		"default": map[string]any{
+			"description": "A problem document naming the failure's reason.",
+			"content":     map[string]any{"application/problem+json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Problem"}}},
+		},
+	}
+	return op
+}
+
+// openAPIRef returns a reference to the schema of md, adding it to schemas
+// first if it is not there yet.
+func openAPIRef(md protoreflect.MessageDescriptor, schemas map[string]any) map[string]any {
+	name := string(md.Name())
+	if _, ok := schemas[name]; !ok {
+		props := make(map[string]any, md.Fields().Len())
+		schemas[name] = map[string]any{"type": "object", "properties": props}
+		for i := 0; i < md.Fields().Len(); i++ {
+			fd := md.Fields().Get(i)
+			props[string(fd.Name())] = openAPIField(fd, schemas)
+		}
+	}
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func openAPIField(fd protoreflect.FieldDescriptor, schemas map[string]any) map[string]any {
+	if s, ok := openAPIOverrides[fd.FullName()]; ok {
+		return s
+	}
+	switch {
+	case fd.IsMap():
+		return map[string]any{"type": "object", "additionalProperties": openAPIField(fd.MapValue(), schemas)}
+	case fd.IsList():
+		return map[string]any{"type": "array", "items": openAPIScalar(fd, schemas)}
+	}
+	return openAPIScalar(fd, schemas)
+}
+
+func openAPIScalar(fd protoreflect.FieldDescriptor, schemas map[string]any) map[string]any {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return openAPIRef(fd.Message(), schemas)
+	case protoreflect.EnumKind:
+		var names []string
+		for i := 0; i < fd.Enum().Values().Len(); i++ {
+			names = append(names, string(fd.Enum().Values().Get(i).Name()))
+		}
+		return map[string]any{"type": "string", "enum": names}
+	case protoreflect.BoolKind:
+		return map[string]any{"type": "boolean"}
+	case protoreflect.StringKind:
+		return map[string]any{"type": "string"}
+	case protoreflect.BytesKind:
+		return map[string]any{"type": "string", "format": "byte"}
+	case protoreflect.DoubleKind:
+		return map[string]any{"type": "number", "format": "double"}
+	case protoreflect.FloatKind:
+		return map[string]any{"type": "number", "format": "float"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]any{"type": "integer", "format": "int32"}
+	}
+	return map[string]any{"type": "integer", "format": "int64"}
+}
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPIDocument())
+}
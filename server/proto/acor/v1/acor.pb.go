@@ -2,13 +2,14 @@
 
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.11
+// 	protoc-gen-go v1.36.12
 // 	protoc        v7.35.1
 // source: acor/v1/acor.proto
 
 package acorv1
 
 import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -23,6 +24,222 @@
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// ErrorReason is the google.rpc.ErrorInfo reason of a failed RPC, and the
+// "code" member of an HTTP problem document. Each names one failure a client
+// can act on; the status code listed with it is what the RPC returns, and the
+// HTTP status what the JSON adapter returns. Reasons are stable: a new failure
+// gets a new reason rather than changing what an existing one means.
+type ErrorReason int32
+
+const (
+	ErrorReason_ERROR_REASON_UNSPECIFIED ErrorReason = 0
+	// The request body is not one valid JSON value. HTTP only: 400.
+	ErrorReason_INVALID_BODY ErrorReason = 1
+	// The request body reached the 1 MiB cap. HTTP only: 413.
+	ErrorReason_BODY_TOO_LARGE ErrorReason = 2
+	// The path does not take the request's method. HTTP only: 405.
+	ErrorReason_METHOD_NOT_ALLOWED ErrorReason = 3
+	// A keyword in the request is empty (acor.ErrEmptyKeyword). INVALID_ARGUMENT,
+	// 400, with a BadRequest violation on "keyword".
+	ErrorReason_EMPTY_KEYWORD ErrorReason = 4
+	// A weight is NaN or infinite (acor.ErrInvalidWeight). INVALID_ARGUMENT, 400,
+	// with a BadRequest violation on "weights".
+	ErrorReason_INVALID_WEIGHT ErrorReason = 5
+	// Some other argument the collection rejects: a TTL, tag, page size, or
+	// name. INVALID_ARGUMENT, 400.
+	ErrorReason_INVALID_ARGUMENT ErrorReason = 6
+	// FindAcross named a collection the server does not hold.
+	// INVALID_ARGUMENT, 400, with a BadRequest violation on "collections".
+	ErrorReason_UNKNOWN_COLLECTION ErrorReason = 7
+	// The alias does not exist (acor.ErrAliasNotFound). NOT_FOUND, 404.
+	ErrorReason_ALIAS_NOT_FOUND ErrorReason = 8
+	// The alias name is already a collection (acor.ErrAliasConflict).
+	// ALREADY_EXISTS, 409.
+	ErrorReason_ALIAS_CONFLICT ErrorReason = 9
+	// The collection is on the read-only V1 schema (acor.ErrV1ReadOnly). It stays
+	// that way until it is migrated, so retrying does not help.
+	// FAILED_PRECONDITION, 409.
+	ErrorReason_V1_READ_ONLY ErrorReason = 10
+	// The collection is held read-only while it is copied (acor.ErrReadOnly).
+	// UNAVAILABLE, 503, with a RetryInfo.
+	ErrorReason_READ_ONLY ErrorReason = 11
+	// A write kept losing the optimistic-locking race after its own retries
+	// (acor.ErrConcurrencyConflict). ABORTED, 409, with a RetryInfo.
+	ErrorReason_CONCURRENCY_CONFLICT ErrorReason = 12
+	// A scan stopped at one of the collection's ScanLimits (acor.ErrMaxMatches,
+	// acor.ErrMaxTextRunes). RESOURCE_EXHAUSTED, 422.
+	ErrorReason_SCAN_LIMIT_EXCEEDED ErrorReason = 13
+	// The server's collection cannot do this: FindAcross on a single collection,
+	// or Suggest on a Preset one. UNIMPLEMENTED, 501.
+	ErrorReason_UNSUPPORTED ErrorReason = 14
+	// Redis failed the operation (acor.RedisError). UNAVAILABLE, 503, with a
+	// RetryInfo.
+	ErrorReason_REDIS_UNAVAILABLE ErrorReason = 15
+	// The operation ran out of time. DEADLINE_EXCEEDED, 504, with a RetryInfo.
+	ErrorReason_DEADLINE_EXCEEDED ErrorReason = 16
+	// Anything else. INTERNAL, 500.
+	ErrorReason_INTERNAL ErrorReason = 17
+	// The request carries no credentials the server accepts, or ones that do not
+	// check out. UNAUTHENTICATED, 401.
+	ErrorReason_UNAUTHENTICATED ErrorReason = 18
+	// The caller is authenticated, but the server's policy does not let it
+	// perform this operation on every collection it touches.
+	// PERMISSION_DENIED, 403.
+	ErrorReason_PERMISSION_DENIED ErrorReason = 19
+	// The caller, or a collection the request touches, is over its request rate.
+	// RESOURCE_EXHAUSTED, 429, with a RetryInfo saying when a token frees up.
+	ErrorReason_RATE_LIMITED ErrorReason = 20
+	// The request's text or batch is larger than the server allows for this
+	// operation. RESOURCE_EXHAUSTED, 413, with a BadRequest violation on the
+	// field at fault.
+	ErrorReason_REQUEST_TOO_LARGE ErrorReason = 21
+	// The operation already has as many requests running as the server allows.
+	// RESOURCE_EXHAUSTED, 429, with a RetryInfo.
+	ErrorReason_CONCURRENCY_LIMITED ErrorReason = 22
+	// The caller gave up on the request before it finished (context.Canceled).
+	// No one is waiting for the answer, so this is not a server fault.
+	// CANCELLED, 499.
+	ErrorReason_CANCELED ErrorReason = 23
+)
+
+// Enum value maps for ErrorReason.
+var (
+	ErrorReason_name = map[int32]string{
+		0:  "ERROR_REASON_UNSPECIFIED",
+		1:  "INVALID_BODY",
+		2:  "BODY_TOO_LARGE",
+		3:  "METHOD_NOT_ALLOWED",
+		4:  "EMPTY_KEYWORD",
+		5:  "INVALID_WEIGHT",
+		6:  "INVALID_ARGUMENT",
+		7:  "UNKNOWN_COLLECTION",
+		8:  "ALIAS_NOT_FOUND",
+		9:  "ALIAS_CONFLICT",
+		10: "V1_READ_ONLY",
+		11: "READ_ONLY",
+		12: "CONCURRENCY_CONFLICT",
+		13: "SCAN_LIMIT_EXCEEDED",
+		14: "UNSUPPORTED",
+		15: "REDIS_UNAVAILABLE",
+		16: "DEADLINE_EXCEEDED",
+		17: "INTERNAL",
+		18: "UNAUTHENTICATED",
+		19: "PERMISSION_DENIED",
+		20: "RATE_LIMITED",
+		21: "REQUEST_TOO_LARGE",
+		22: "CONCURRENCY_LIMITED",
+		23: "CANCELED",
+	}
+	ErrorReason_value = map[string]int32{
+		"ERROR_REASON_UNSPECIFIED": 0,
+		"INVALID_BODY":             1,
+		"BODY_TOO_LARGE":           2,
+		"METHOD_NOT_ALLOWED":       3,
+		"EMPTY_KEYWORD":            4,
+		"INVALID_WEIGHT":           5,
+		"INVALID_ARGUMENT":         6,
+		"UNKNOWN_COLLECTION":       7,
+		"ALIAS_NOT_FOUND":          8,
+		"ALIAS_CONFLICT":           9,
+		"V1_READ_ONLY":             10,
+		"READ_ONLY":                11,
+		"CONCURRENCY_CONFLICT":     12,
+		"SCAN_LIMIT_EXCEEDED":      13,
+		"UNSUPPORTED":              14,
+		"REDIS_UNAVAILABLE":        15,
+		"DEADLINE_EXCEEDED":        16,
+		"INTERNAL":                 17,
+		"UNAUTHENTICATED":          18,
+		"PERMISSION_DENIED":        19,
+		"RATE_LIMITED":             20,
+		"REQUEST_TOO_LARGE":        21,
+		"CONCURRENCY_LIMITED":      22,
+		"CANCELED":                 23,
+	}
+)
+
+func (x ErrorReason) Enum() *ErrorReason {
+	p := new(ErrorReason)
+	*p = x
+	return p
+}
+
+func (x ErrorReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_acor_v1_acor_proto_enumTypes[0].Descriptor()
+}
+
+func (ErrorReason) Type() protoreflect.EnumType {
+	return &file_acor_v1_acor_proto_enumTypes[0]
+}
+
+func (x ErrorReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorReason.Descriptor instead.
+func (ErrorReason) EnumDescriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{0}
+}
+
+type ChangeEvent_Kind int32
+
+const (
+	ChangeEvent_KIND_UNSPECIFIED ChangeEvent_Kind = 0
+	// A committed write. added and removed list its keywords when
+	// keywords_known is set; otherwise the write was made without version
+	// history and only its version is known.
+	ChangeEvent_CHANGE ChangeEvent_Kind = 1
+	// The client's state cannot be brought up to date by changes: it resumed
+	// from a version the history no longer reaches, or from none. Reload the
+	// collection; changes that follow build on version.
+	ChangeEvent_RESYNC ChangeEvent_Kind = 2
+)
+
+// Enum value maps for ChangeEvent_Kind.
+var (
+	ChangeEvent_Kind_name = map[int32]string{
+		0: "KIND_UNSPECIFIED",
+		1: "CHANGE",
+		2: "RESYNC",
+	}
+	ChangeEvent_Kind_value = map[string]int32{
+		"KIND_UNSPECIFIED": 0,
+		"CHANGE":           1,
+		"RESYNC":           2,
+	}
+)
+
+func (x ChangeEvent_Kind) Enum() *ChangeEvent_Kind {
+	p := new(ChangeEvent_Kind)
+	*p = x
+	return p
+}
+
+func (x ChangeEvent_Kind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChangeEvent_Kind) Descriptor() protoreflect.EnumDescriptor {
+	return file_acor_v1_acor_proto_enumTypes[1].Descriptor()
+}
+
+func (ChangeEvent_Kind) Type() protoreflect.EnumType {
+	return &file_acor_v1_acor_proto_enumTypes[1]
+}
+
+func (x ChangeEvent_Kind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChangeEvent_Kind.Descriptor instead.
+func (ChangeEvent_Kind) EnumDescriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{22, 0}
+}
+
 type KeywordRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Keyword       string                 `protobuf:"bytes,1,opt,name=keyword,proto3" json:"keyword,omitempty"`
@@ -67,9 +284,13 @@ func (x *KeywordRequest) GetKeyword() string {
 	return ""
 }
 
+// InputRequest carries the text to read. min_version, when non-zero, is a
+// version returned by an earlier write: the read answers from state at least
+// that new, whichever server instance the write went through.
 type InputRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Input         string                 `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	MinVersion    int64                  `protobuf:"varint,2,opt,name=min_version,json=minVersion,proto3" json:"min_version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -111,6 +332,13 @@ func (x *InputRequest) GetInput() string {
 	return ""
 }
 
+func (x *InputRequest) GetMinVersion() int64 {
+	if x != nil {
+		return x.MinVersion
+	}
+	return 0
+}
+
 type EmptyRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -147,9 +375,13 @@ func (*EmptyRequest) Descriptor() ([]byte, []int) {
 	return file_acor_v1_acor_proto_rawDescGZIP(), []int{2}
 }
 
+// CountResponse reports how many keywords a write changed. version is the
+// collection version the write left behind, to pass as a later read's
+// min_version; SetWeights leaves it zero.
 type CountResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -191,6 +423,13 @@ func (x *CountResponse) GetCount() int64 {
 	return 0
 }
 
+func (x *CountResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type MatchesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Matches       []string               `protobuf:"bytes,1,rep,name=matches,proto3" json:"matches,omitempty"`
@@ -235,6 +474,173 @@ func (x *MatchesResponse) GetMatches() []string {
 	return nil
 }
 
+// FindAcrossRequest names the collections to search, all of them when empty.
+type FindAcrossRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Input         string                 `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	Collections   []string               `protobuf:"bytes,2,rep,name=collections,proto3" json:"collections,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindAcrossRequest) Reset() {
+	*x = FindAcrossRequest{}
+	mi := &file_acor_v1_acor_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindAcrossRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindAcrossRequest) ProtoMessage() {}
+
+func (x *FindAcrossRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindAcrossRequest.ProtoReflect.Descriptor instead.
+func (*FindAcrossRequest) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FindAcrossRequest) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *FindAcrossRequest) GetCollections() []string {
+	if x != nil {
+		return x.Collections
+	}
+	return nil
+}
+
+// CollectionMatch is one keyword occurrence, labeled with the collection that
+// holds the keyword. start and end are rune offsets, end exclusive.
+type CollectionMatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Collection    string                 `protobuf:"bytes,1,opt,name=collection,proto3" json:"collection,omitempty"`
+	Keyword       string                 `protobuf:"bytes,2,opt,name=keyword,proto3" json:"keyword,omitempty"`
+	Start         int64                  `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`
+	End           int64                  `protobuf:"varint,4,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectionMatch) Reset() {
+	*x = CollectionMatch{}
+	mi := &file_acor_v1_acor_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectionMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectionMatch) ProtoMessage() {}
+
+func (x *CollectionMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectionMatch.ProtoReflect.Descriptor instead.
+func (*CollectionMatch) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CollectionMatch) GetCollection() string {
+	if x != nil {
+		return x.Collection
+	}
+	return ""
+}
+
+func (x *CollectionMatch) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+func (x *CollectionMatch) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *CollectionMatch) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type FindAcrossResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Matches       []*CollectionMatch     `protobuf:"bytes,1,rep,name=matches,proto3" json:"matches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindAcrossResponse) Reset() {
+	*x = FindAcrossResponse{}
+	mi := &file_acor_v1_acor_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindAcrossResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindAcrossResponse) ProtoMessage() {}
+
+func (x *FindAcrossResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindAcrossResponse.ProtoReflect.Descriptor instead.
+func (*FindAcrossResponse) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FindAcrossResponse) GetMatches() []*CollectionMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
 // Positions holds the start offsets of one matched keyword. Needed because
 // proto3 maps cannot have a repeated value directly.
 type Positions struct {
@@ -246,7 +652,7 @@ type Positions struct {
 
 func (x *Positions) Reset() {
 	*x = Positions{}
-	mi := &file_acor_v1_acor_proto_msgTypes[5]
+	mi := &file_acor_v1_acor_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -258,7 +664,7 @@ func (x *Positions) String() string {
 func (*Positions) ProtoMessage() {}
 
 func (x *Positions) ProtoReflect() protoreflect.Message {
-	mi := &file_acor_v1_acor_proto_msgTypes[5]
+	mi := &file_acor_v1_acor_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -271,7 +677,7 @@ func (x *Positions) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Positions.ProtoReflect.Descriptor instead.
 func (*Positions) Descriptor() ([]byte, []int) {
-	return file_acor_v1_acor_proto_rawDescGZIP(), []int{5}
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Positions) GetPositions() []int64 {
@@ -290,7 +696,7 @@ type MatchIndexesResponse struct {
 
 func (x *MatchIndexesResponse) Reset() {
 	*x = MatchIndexesResponse{}
-	mi := &file_acor_v1_acor_proto_msgTypes[6]
+	mi := &file_acor_v1_acor_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -302,7 +708,7 @@ func (x *MatchIndexesResponse) String() string {
 func (*MatchIndexesResponse) ProtoMessage() {}
 
 func (x *MatchIndexesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_acor_v1_acor_proto_msgTypes[6]
+	mi := &file_acor_v1_acor_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -315,7 +721,7 @@ func (x *MatchIndexesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MatchIndexesResponse.ProtoReflect.Descriptor instead.
 func (*MatchIndexesResponse) Descriptor() ([]byte, []int) {
-	return file_acor_v1_acor_proto_rawDescGZIP(), []int{6}
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *MatchIndexesResponse) GetMatches() map[string]*Positions {
@@ -335,7 +741,7 @@ type InfoResponse struct {
 
 func (x *InfoResponse) Reset() {
 	*x = InfoResponse{}
-	mi := &file_acor_v1_acor_proto_msgTypes[7]
+	mi := &file_acor_v1_acor_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -347,7 +753,7 @@ func (x *InfoResponse) String() string {
 func (*InfoResponse) ProtoMessage() {}
 
 func (x *InfoResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_acor_v1_acor_proto_msgTypes[7]
+	mi := &file_acor_v1_acor_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -360,7 +766,7 @@ func (x *InfoResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InfoResponse.ProtoReflect.Descriptor instead.
 func (*InfoResponse) Descriptor() ([]byte, []int) {
-	return file_acor_v1_acor_proto_rawDescGZIP(), []int{7}
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *InfoResponse) GetKeywords() int64 {
@@ -378,15 +784,17 @@ func (x *InfoResponse) GetNodes() int64 {
 }
 
 type StatusResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Status string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	// version is set by Flush, as on CountResponse.
+	Version       int64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *StatusResponse) Reset() {
 	*x = StatusResponse{}
-	mi := &file_acor_v1_acor_proto_msgTypes[8]
+	mi := &file_acor_v1_acor_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -398,7 +806,7 @@ func (x *StatusResponse) String() string {
 func (*StatusResponse) ProtoMessage() {}
 
 func (x *StatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_acor_v1_acor_proto_msgTypes[8]
+	mi := &file_acor_v1_acor_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -411,7 +819,7 @@ func (x *StatusResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
 func (*StatusResponse) Descriptor() ([]byte, []int) {
-	return file_acor_v1_acor_proto_rawDescGZIP(), []int{8}
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *StatusResponse) GetStatus() string {
@@ -421,41 +829,822 @@ func (x *StatusResponse) GetStatus() string {
 	return ""
 }
 
-var File_acor_v1_acor_proto protoreflect.FileDescriptor
+func (x *StatusResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
 
-const file_acor_v1_acor_proto_rawDesc = "" +
-	"\n" +
-	"\x12acor/v1/acor.proto\x12\x0eacor.server.v1\"*\n" +
-	"\x0eKeywordRequest\x12\x18\n" +
-	"\akeyword\x18\x01 \x01(\tR\akeyword\"$\n" +
-	"\fInputRequest\x12\x14\n" +
-	"\x05input\x18\x01 \x01(\tR\x05input\"\x0e\n" +
-	"\fEmptyRequest\"%\n" +
-	"\rCountResponse\x12\x14\n" +
-	"\x05count\x18\x01 \x01(\x03R\x05count\"+\n" +
-	"\x0fMatchesResponse\x12\x18\n" +
-	"\amatches\x18\x01 \x03(\tR\amatches\")\n" +
-	"\tPositions\x12\x1c\n" +
-	"\tpositions\x18\x01 \x03(\x03R\tpositions\"\xba\x01\n" +
-	"\x14MatchIndexesResponse\x12K\n" +
-	"\amatches\x18\x01 \x03(\v21.acor.server.v1.MatchIndexesResponse.MatchesEntryR\amatches\x1aU\n" +
-	"\fMatchesEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12/\n" +
-	"\x05value\x18\x02 \x01(\v2\x19.acor.server.v1.PositionsR\x05value:\x028\x01\"@\n" +
-	"\fInfoResponse\x12\x1a\n" +
-	"\bkeywords\x18\x01 \x01(\x03R\bkeywords\x12\x14\n" +
-	"\x05nodes\x18\x02 \x01(\x03R\x05nodes\"(\n" +
-	"\x0eStatusResponse\x12\x16\n" +
-	"\x06status\x18\x01 \x01(\tR\x06status2\xd6\x04\n" +
-	"\x04Acor\x12D\n" +
-	"\x03Add\x12\x1e.acor.server.v1.KeywordRequest\x1a\x1d.acor.server.v1.CountResponse\x12G\n" +
-	"\x06Remove\x12\x1e.acor.server.v1.KeywordRequest\x1a\x1d.acor.server.v1.CountResponse\x12E\n" +
-	"\x04Find\x12\x1c.acor.server.v1.InputRequest\x1a\x1f.acor.server.v1.MatchesResponse\x12O\n" +
-	"\tFindIndex\x12\x1c.acor.server.v1.InputRequest\x1a$.acor.server.v1.MatchIndexesResponse\x12H\n" +
-	"\aSuggest\x12\x1c.acor.server.v1.InputRequest\x1a\x1f.acor.server.v1.MatchesResponse\x12R\n" +
-	"\fSuggestIndex\x12\x1c.acor.server.v1.InputRequest\x1a$.acor.server.v1.MatchIndexesResponse\x12B\n" +
-	"\x04Info\x12\x1c.acor.server.v1.EmptyRequest\x1a\x1c.acor.server.v1.InfoResponse\x12E\n" +
-	"\x05Flush\x12\x1c.acor.server.v1.EmptyRequest\x1a\x1e.acor.server.v1.StatusResponseB7Z5github.com/skyoo2003/acor/server/proto/acor/v1;acorv1b\x06proto3"
+// ScoreRequest carries the text to score and the acor.ScoreOptions to score it
+// with. Zero values mean the library defaults.
+type ScoreRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Input              string                 `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	DefaultWeight      float64                `protobuf:"fixed64,2,opt,name=default_weight,json=defaultWeight,proto3" json:"default_weight,omitempty"`
+	Decay              float64                `protobuf:"fixed64,3,opt,name=decay,proto3" json:"decay,omitempty"`
+	MaxOccurrences     int64                  `protobuf:"varint,4,opt,name=max_occurrences,json=maxOccurrences,proto3" json:"max_occurrences,omitempty"`
+	Threshold          float64                `protobuf:"fixed64,5,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	CategoryThresholds map[string]float64     `protobuf:"bytes,6,rep,name=category_thresholds,json=categoryThresholds,proto3" json:"category_thresholds,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ScoreRequest) Reset() {
+	*x = ScoreRequest{}
+	mi := &file_acor_v1_acor_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScoreRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScoreRequest) ProtoMessage() {}
+
+func (x *ScoreRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScoreRequest.ProtoReflect.Descriptor instead.
+func (*ScoreRequest) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ScoreRequest) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *ScoreRequest) GetDefaultWeight() float64 {
+	if x != nil {
+		return x.DefaultWeight
+	}
+	return 0
+}
+
+func (x *ScoreRequest) GetDecay() float64 {
+	if x != nil {
+		return x.Decay
+	}
+	return 0
+}
+
+func (x *ScoreRequest) GetMaxOccurrences() int64 {
+	if x != nil {
+		return x.MaxOccurrences
+	}
+	return 0
+}
+
+func (x *ScoreRequest) GetThreshold() float64 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *ScoreRequest) GetCategoryThresholds() map[string]float64 {
+	if x != nil {
+		return x.CategoryThresholds
+	}
+	return nil
+}
+
+type KeywordScore struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Weight        float64                `protobuf:"fixed64,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	Category      string                 `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
+	Score         float64                `protobuf:"fixed64,4,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeywordScore) Reset() {
+	*x = KeywordScore{}
+	mi := &file_acor_v1_acor_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeywordScore) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeywordScore) ProtoMessage() {}
+
+func (x *KeywordScore) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeywordScore.ProtoReflect.Descriptor instead.
+func (*KeywordScore) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *KeywordScore) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *KeywordScore) GetWeight() float64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *KeywordScore) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *KeywordScore) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type ScoreResponse struct {
+	state             protoimpl.MessageState   `protogen:"open.v1"`
+	Total             float64                  `protobuf:"fixed64,1,opt,name=total,proto3" json:"total,omitempty"`
+	Flagged           bool                     `protobuf:"varint,2,opt,name=flagged,proto3" json:"flagged,omitempty"`
+	FlaggedCategories []string                 `protobuf:"bytes,3,rep,name=flagged_categories,json=flaggedCategories,proto3" json:"flagged_categories,omitempty"`
+	Categories        map[string]float64       `protobuf:"bytes,4,rep,name=categories,proto3" json:"categories,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	Keywords          map[string]*KeywordScore `protobuf:"bytes,5,rep,name=keywords,proto3" json:"keywords,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ScoreResponse) Reset() {
+	*x = ScoreResponse{}
+	mi := &file_acor_v1_acor_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScoreResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScoreResponse) ProtoMessage() {}
+
+func (x *ScoreResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScoreResponse.ProtoReflect.Descriptor instead.
+func (*ScoreResponse) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ScoreResponse) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ScoreResponse) GetFlagged() bool {
+	if x != nil {
+		return x.Flagged
+	}
+	return false
+}
+
+func (x *ScoreResponse) GetFlaggedCategories() []string {
+	if x != nil {
+		return x.FlaggedCategories
+	}
+	return nil
+}
+
+func (x *ScoreResponse) GetCategories() map[string]float64 {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *ScoreResponse) GetKeywords() map[string]*KeywordScore {
+	if x != nil {
+		return x.Keywords
+	}
+	return nil
+}
+
+type KeywordWeight struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Weight        float64                `protobuf:"fixed64,1,opt,name=weight,proto3" json:"weight,omitempty"`
+	Category      string                 `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeywordWeight) Reset() {
+	*x = KeywordWeight{}
+	mi := &file_acor_v1_acor_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeywordWeight) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeywordWeight) ProtoMessage() {}
+
+func (x *KeywordWeight) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeywordWeight.ProtoReflect.Descriptor instead.
+func (*KeywordWeight) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *KeywordWeight) GetWeight() float64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *KeywordWeight) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+type SetWeightsRequest struct {
+	state         protoimpl.MessageState    `protogen:"open.v1"`
+	Weights       map[string]*KeywordWeight `protobuf:"bytes,1,rep,name=weights,proto3" json:"weights,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetWeightsRequest) Reset() {
+	*x = SetWeightsRequest{}
+	mi := &file_acor_v1_acor_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetWeightsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetWeightsRequest) ProtoMessage() {}
+
+func (x *SetWeightsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetWeightsRequest.ProtoReflect.Descriptor instead.
+func (*SetWeightsRequest) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SetWeightsRequest) GetWeights() map[string]*KeywordWeight {
+	if x != nil {
+		return x.Weights
+	}
+	return nil
+}
+
+type WeightsResponse struct {
+	state         protoimpl.MessageState    `protogen:"open.v1"`
+	Weights       map[string]*KeywordWeight `protobuf:"bytes,1,rep,name=weights,proto3" json:"weights,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WeightsResponse) Reset() {
+	*x = WeightsResponse{}
+	mi := &file_acor_v1_acor_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WeightsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WeightsResponse) ProtoMessage() {}
+
+func (x *WeightsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WeightsResponse.ProtoReflect.Descriptor instead.
+func (*WeightsResponse) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WeightsResponse) GetWeights() map[string]*KeywordWeight {
+	if x != nil {
+		return x.Weights
+	}
+	return nil
+}
+
+type AliasRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alias         string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AliasRequest) Reset() {
+	*x = AliasRequest{}
+	mi := &file_acor_v1_acor_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AliasRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AliasRequest) ProtoMessage() {}
+
+func (x *AliasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AliasRequest.ProtoReflect.Descriptor instead.
+func (*AliasRequest) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *AliasRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+// AliasResponse names the collection an alias points at.
+type AliasResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alias         string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Collection    string                 `protobuf:"bytes,2,opt,name=collection,proto3" json:"collection,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AliasResponse) Reset() {
+	*x = AliasResponse{}
+	mi := &file_acor_v1_acor_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AliasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AliasResponse) ProtoMessage() {}
+
+func (x *AliasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AliasResponse.ProtoReflect.Descriptor instead.
+func (*AliasResponse) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *AliasResponse) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *AliasResponse) GetCollection() string {
+	if x != nil {
+		return x.Collection
+	}
+	return ""
+}
+
+type DeleteAliasResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Deleted       bool                   `protobuf:"varint,1,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAliasResponse) Reset() {
+	*x = DeleteAliasResponse{}
+	mi := &file_acor_v1_acor_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAliasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAliasResponse) ProtoMessage() {}
+
+func (x *DeleteAliasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAliasResponse.ProtoReflect.Descriptor instead.
+func (*DeleteAliasResponse) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *DeleteAliasResponse) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+// WatchRequest starts a watch. from_version is the last version the client has
+// seen, to resume from; zero starts with a RESYNC at the current version.
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromVersion   int64                  `protobuf:"varint,1,opt,name=from_version,json=fromVersion,proto3" json:"from_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_acor_v1_acor_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *WatchRequest) GetFromVersion() int64 {
+	if x != nil {
+		return x.FromVersion
+	}
+	return 0
+}
+
+// ChangeEvent is one event of a watch.
+type ChangeEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Kind  ChangeEvent_Kind       `protobuf:"varint,1,opt,name=kind,proto3,enum=acor.server.v1.ChangeEvent_Kind" json:"kind,omitempty"`
+	// version is the collection version after the event, prev_version the one
+	// before it. A RESYNC has no prev_version.
+	Version     int64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	PrevVersion int64 `protobuf:"varint,3,opt,name=prev_version,json=prevVersion,proto3" json:"prev_version,omitempty"`
+	// op is the kind of write, as acor.HistoryEntry.Op names it, when known.
+	Op            string   `protobuf:"bytes,4,opt,name=op,proto3" json:"op,omitempty"`
+	Added         []string `protobuf:"bytes,5,rep,name=added,proto3" json:"added,omitempty"`
+	Removed       []string `protobuf:"bytes,6,rep,name=removed,proto3" json:"removed,omitempty"`
+	KeywordsKnown bool     `protobuf:"varint,7,opt,name=keywords_known,json=keywordsKnown,proto3" json:"keywords_known,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeEvent) Reset() {
+	*x = ChangeEvent{}
+	mi := &file_acor_v1_acor_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeEvent) ProtoMessage() {}
+
+func (x *ChangeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_acor_v1_acor_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeEvent.ProtoReflect.Descriptor instead.
+func (*ChangeEvent) Descriptor() ([]byte, []int) {
+	return file_acor_v1_acor_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ChangeEvent) GetKind() ChangeEvent_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return ChangeEvent_KIND_UNSPECIFIED
+}
+
+func (x *ChangeEvent) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ChangeEvent) GetPrevVersion() int64 {
+	if x != nil {
+		return x.PrevVersion
+	}
+	return 0
+}
+
+func (x *ChangeEvent) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *ChangeEvent) GetAdded() []string {
+	if x != nil {
+		return x.Added
+	}
+	return nil
+}
+
+func (x *ChangeEvent) GetRemoved() []string {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+func (x *ChangeEvent) GetKeywordsKnown() bool {
+	if x != nil {
+		return x.KeywordsKnown
+	}
+	return false
+}
+
+var File_acor_v1_acor_proto protoreflect.FileDescriptor
+
+const file_acor_v1_acor_proto_rawDesc = "" +
+	"\n" +
+	"\x12acor/v1/acor.proto\x12\x0eacor.server.v1\x1a\x1cgoogle/api/annotations.proto\"*\n" +
+	"\x0eKeywordRequest\x12\x18\n" +
+	"\akeyword\x18\x01 \x01(\tR\akeyword\"E\n" +
+	"\fInputRequest\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\tR\x05input\x12\x1f\n" +
+	"\vmin_version\x18\x02 \x01(\x03R\n" +
+	"minVersion\"\x0e\n" +
+	"\fEmptyRequest\"?\n" +
+	"\rCountResponse\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"+\n" +
+	"\x0fMatchesResponse\x12\x18\n" +
+	"\amatches\x18\x01 \x03(\tR\amatches\"K\n" +
+	"\x11FindAcrossRequest\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\tR\x05input\x12 \n" +
+	"\vcollections\x18\x02 \x03(\tR\vcollections\"s\n" +
+	"\x0fCollectionMatch\x12\x1e\n" +
+	"\n" +
+	"collection\x18\x01 \x01(\tR\n" +
+	"collection\x12\x18\n" +
+	"\akeyword\x18\x02 \x01(\tR\akeyword\x12\x14\n" +
+	"\x05start\x18\x03 \x01(\x03R\x05start\x12\x10\n" +
+	"\x03end\x18\x04 \x01(\x03R\x03end\"O\n" +
+	"\x12FindAcrossResponse\x129\n" +
+	"\amatches\x18\x01 \x03(\v2\x1f.acor.server.v1.CollectionMatchR\amatches\")\n" +
+	"\tPositions\x12\x1c\n" +
+	"\tpositions\x18\x01 \x03(\x03R\tpositions\"\xba\x01\n" +
+	"\x14MatchIndexesResponse\x12K\n" +
+	"\amatches\x18\x01 \x03(\v21.acor.server.v1.MatchIndexesResponse.MatchesEntryR\amatches\x1aU\n" +
+	"\fMatchesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12/\n" +
+	"\x05value\x18\x02 \x01(\v2\x19.acor.server.v1.PositionsR\x05value:\x028\x01\"@\n" +
+	"\fInfoResponse\x12\x1a\n" +
+	"\bkeywords\x18\x01 \x01(\x03R\bkeywords\x12\x14\n" +
+	"\x05nodes\x18\x02 \x01(\x03R\x05nodes\"B\n" +
+	"\x0eStatusResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"\xd6\x02\n" +
+	"\fScoreRequest\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\tR\x05input\x12%\n" +
+	"\x0edefault_weight\x18\x02 \x01(\x01R\rdefaultWeight\x12\x14\n" +
+	"\x05decay\x18\x03 \x01(\x01R\x05decay\x12'\n" +
+	"\x0fmax_occurrences\x18\x04 \x01(\x03R\x0emaxOccurrences\x12\x1c\n" +
+	"\tthreshold\x18\x05 \x01(\x01R\tthreshold\x12e\n" +
+	"\x13category_thresholds\x18\x06 \x03(\v24.acor.server.v1.ScoreRequest.CategoryThresholdsEntryR\x12categoryThresholds\x1aE\n" +
+	"\x17CategoryThresholdsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"n\n" +
+	"\fKeywordScore\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\x12\x16\n" +
+	"\x06weight\x18\x02 \x01(\x01R\x06weight\x12\x1a\n" +
+	"\bcategory\x18\x03 \x01(\tR\bcategory\x12\x14\n" +
+	"\x05score\x18\x04 \x01(\x01R\x05score\"\xa0\x03\n" +
+	"\rScoreResponse\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x01R\x05total\x12\x18\n" +
+	"\aflagged\x18\x02 \x01(\bR\aflagged\x12-\n" +
+	"\x12flagged_categories\x18\x03 \x03(\tR\x11flaggedCategories\x12M\n" +
+	"\n" +
+	"categories\x18\x04 \x03(\v2-.acor.server.v1.ScoreResponse.CategoriesEntryR\n" +
+	"categories\x12G\n" +
+	"\bkeywords\x18\x05 \x03(\v2+.acor.server.v1.ScoreResponse.KeywordsEntryR\bkeywords\x1a=\n" +
+	"\x0fCategoriesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\x1aY\n" +
+	"\rKeywordsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x122\n" +
+	"\x05value\x18\x02 \x01(\v2\x1c.acor.server.v1.KeywordScoreR\x05value:\x028\x01\"C\n" +
+	"\rKeywordWeight\x12\x16\n" +
+	"\x06weight\x18\x01 \x01(\x01R\x06weight\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\"\xb8\x01\n" +
+	"\x11SetWeightsRequest\x12H\n" +
+	"\aweights\x18\x01 \x03(\v2..acor.server.v1.SetWeightsRequest.WeightsEntryR\aweights\x1aY\n" +
+	"\fWeightsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x123\n" +
+	"\x05value\x18\x02 \x01(\v2\x1d.acor.server.v1.KeywordWeightR\x05value:\x028\x01\"\xb4\x01\n" +
+	"\x0fWeightsResponse\x12F\n" +
+	"\aweights\x18\x01 \x03(\v2,.acor.server.v1.WeightsResponse.WeightsEntryR\aweights\x1aY\n" +
+	"\fWeightsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x123\n" +
+	"\x05value\x18\x02 \x01(\v2\x1d.acor.server.v1.KeywordWeightR\x05value:\x028\x01\"$\n" +
+	"\fAliasRequest\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\"E\n" +
+	"\rAliasResponse\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12\x1e\n" +
+	"\n" +
+	"collection\x18\x02 \x01(\tR\n" +
+	"collection\"/\n" +
+	"\x13DeleteAliasResponse\x12\x18\n" +
+	"\adeleted\x18\x01 \x01(\bR\adeleted\"1\n" +
+	"\fWatchRequest\x12!\n" +
+	"\ffrom_version\x18\x01 \x01(\x03R\vfromVersion\"\x9d\x02\n" +
+	"\vChangeEvent\x124\n" +
+	"\x04kind\x18\x01 \x01(\x0e2 .acor.server.v1.ChangeEvent.KindR\x04kind\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12!\n" +
+	"\fprev_version\x18\x03 \x01(\x03R\vprevVersion\x12\x0e\n" +
+	"\x02op\x18\x04 \x01(\tR\x02op\x12\x14\n" +
+	"\x05added\x18\x05 \x03(\tR\x05added\x12\x18\n" +
+	"\aremoved\x18\x06 \x03(\tR\aremoved\x12%\n" +
+	"\x0ekeywords_known\x18\a \x01(\bR\rkeywordsKnown\"4\n" +
+	"\x04Kind\x12\x14\n" +
+	"\x10KIND_UNSPECIFIED\x10\x00\x12\n" +
+	"\n" +
+	"\x06CHANGE\x10\x01\x12\n" +
+	"\n" +
+	"\x06RESYNC\x10\x02*\x84\x04\n" +
+	"\vErrorReason\x12\x1c\n" +
+	"\x18ERROR_REASON_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fINVALID_BODY\x10\x01\x12\x12\n" +
+	"\x0eBODY_TOO_LARGE\x10\x02\x12\x16\n" +
+	"\x12METHOD_NOT_ALLOWED\x10\x03\x12\x11\n" +
+	"\rEMPTY_KEYWORD\x10\x04\x12\x12\n" +
+	"\x0eINVALID_WEIGHT\x10\x05\x12\x14\n" +
+	"\x10INVALID_ARGUMENT\x10\x06\x12\x16\n" +
+	"\x12UNKNOWN_COLLECTION\x10\a\x12\x13\n" +
+	"\x0fALIAS_NOT_FOUND\x10\b\x12\x12\n" +
+	"\x0eALIAS_CONFLICT\x10\t\x12\x10\n" +
+	"\fV1_READ_ONLY\x10\n" +
+	"\x12\r\n" +
+	"\tREAD_ONLY\x10\v\x12\x18\n" +
+	"\x14CONCURRENCY_CONFLICT\x10\f\x12\x17\n" +
+	"\x13SCAN_LIMIT_EXCEEDED\x10\r\x12\x0f\n" +
+	"\vUNSUPPORTED\x10\x0e\x12\x15\n" +
+	"\x11REDIS_UNAVAILABLE\x10\x0f\x12\x15\n" +
+	"\x11DEADLINE_EXCEEDED\x10\x10\x12\f\n" +
+	"\bINTERNAL\x10\x11\x12\x13\n" +
+	"\x0fUNAUTHENTICATED\x10\x12\x12\x15\n" +
+	"\x11PERMISSION_DENIED\x10\x13\x12\x10\n" +
+	"\fRATE_LIMITED\x10\x14\x12\x15\n" +
+	"\x11REQUEST_TOO_LARGE\x10\x15\x12\x17\n" +
+	"\x13CONCURRENCY_LIMITED\x10\x16\x12\f\n" +
+	"\bCANCELED\x10\x172\xba\f\n" +
+	"\x04Acor\x12X\n" +
+	"\x03Add\x12\x1e.acor.server.v1.KeywordRequest\x1a\x1d.acor.server.v1.CountResponse\"\x12\x82\xd3\xe4\x93\x02\f:\x01*\"\a/v1/add\x12^\n" +
+	"\x06Remove\x12\x1e.acor.server.v1.KeywordRequest\x1a\x1d.acor.server.v1.CountResponse\"\x15\x82\xd3\xe4\x93\x02\x0f:\x01*\"\n" +
+	"/v1/remove\x12Z\n" +
+	"\x04Find\x12\x1c.acor.server.v1.InputRequest\x1a\x1f.acor.server.v1.MatchesResponse\"\x13\x82\xd3\xe4\x93\x02\r:\x01*\"\b/v1/find\x12j\n" +
+	"\tFindIndex\x12\x1c.acor.server.v1.InputRequest\x1a$.acor.server.v1.MatchIndexesResponse\"\x19\x82\xd3\xe4\x93\x02\x13:\x01*\"\x0e/v1/find-index\x12o\n" +
+	"\n" +
+	"FindAcross\x12!.acor.server.v1.FindAcrossRequest\x1a\".acor.server.v1.FindAcrossResponse\"\x1a\x82\xd3\xe4\x93\x02\x14:\x01*\"\x0f/v1/find-across\x12`\n" +
+	"\aSuggest\x12\x1c.acor.server.v1.InputRequest\x1a\x1f.acor.server.v1.MatchesResponse\"\x16\x82\xd3\xe4\x93\x02\x10:\x01*\"\v/v1/suggest\x12p\n" +
+	"\fSuggestIndex\x12\x1c.acor.server.v1.InputRequest\x1a$.acor.server.v1.MatchIndexesResponse\"\x1c\x82\xd3\xe4\x93\x02\x16:\x01*\"\x11/v1/suggest-index\x12T\n" +
+	"\x04Info\x12\x1c.acor.server.v1.EmptyRequest\x1a\x1c.acor.server.v1.InfoResponse\"\x10\x82\xd3\xe4\x93\x02\n" +
+	"\x12\b/v1/info\x12X\n" +
+	"\x05Flush\x12\x1c.acor.server.v1.EmptyRequest\x1a\x1e.acor.server.v1.StatusResponse\"\x11\x82\xd3\xe4\x93\x02\v\"\t/v1/flush\x12Z\n" +
+	"\x05Score\x12\x1c.acor.server.v1.ScoreRequest\x1a\x1d.acor.server.v1.ScoreResponse\"\x14\x82\xd3\xe4\x93\x02\x0e:\x01*\"\t/v1/score\x12j\n" +
+	"\n" +
+	"SetWeights\x12!.acor.server.v1.SetWeightsRequest\x1a\x1d.acor.server.v1.CountResponse\"\x1a\x82\xd3\xe4\x93\x02\x14:\x01*\"\x0f/v1/set-weights\x12]\n" +
+	"\aWeights\x12\x1c.acor.server.v1.EmptyRequest\x1a\x1f.acor.server.v1.WeightsResponse\"\x13\x82\xd3\xe4\x93\x02\r\x12\v/v1/weights\x12a\n" +
+	"\bSetAlias\x12\x1c.acor.server.v1.AliasRequest\x1a\x1d.acor.server.v1.AliasResponse\"\x18\x82\xd3\xe4\x93\x02\x12:\x01*\"\r/v1/set-alias\x12i\n" +
+	"\fResolveAlias\x12\x1c.acor.server.v1.AliasRequest\x1a\x1d.acor.server.v1.AliasResponse\"\x1c\x82\xd3\xe4\x93\x02\x16:\x01*\"\x11/v1/resolve-alias\x12m\n" +
+	"\vDeleteAlias\x12\x1c.acor.server.v1.AliasRequest\x1a#.acor.server.v1.DeleteAliasResponse\"\x1b\x82\xd3\xe4\x93\x02\x15:\x01*\"\x10/v1/delete-alias\x12W\n" +
+	"\x05Watch\x12\x1c.acor.server.v1.WatchRequest\x1a\x1b.acor.server.v1.ChangeEvent\"\x11\x82\xd3\xe4\x93\x02\v\x12\t/v1/watch0\x01B7Z5github.com/skyoo2003/acor/server/proto/acor/v1;acorv1b\x06proto3"
 
 var (
 	file_acor_v1_acor_proto_rawDescOnce sync.Once
@@ -469,43 +1658,91 @@ func file_acor_v1_acor_proto_rawDescGZIP() []byte {
 	return file_acor_v1_acor_proto_rawDescData
 }
 
-var file_acor_v1_acor_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_acor_v1_acor_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_acor_v1_acor_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
 var file_acor_v1_acor_proto_goTypes = []any{
-	(*KeywordRequest)(nil),       // 0: acor.server.v1.KeywordRequest
-	(*InputRequest)(nil),         // 1: acor.server.v1.InputRequest
-	(*EmptyRequest)(nil),         // 2: acor.server.v1.EmptyRequest
-	(*CountResponse)(nil),        // 3: acor.server.v1.CountResponse
-	(*MatchesResponse)(nil),      // 4: acor.server.v1.MatchesResponse
-	(*Positions)(nil),            // 5: acor.server.v1.Positions
-	(*MatchIndexesResponse)(nil), // 6: acor.server.v1.MatchIndexesResponse
-	(*InfoResponse)(nil),         // 7: acor.server.v1.InfoResponse
-	(*StatusResponse)(nil),       // 8: acor.server.v1.StatusResponse
-	nil,                          // 9: acor.server.v1.MatchIndexesResponse.MatchesEntry
+	(ErrorReason)(0),             // 0: acor.server.v1.ErrorReason
+	(ChangeEvent_Kind)(0),        // 1: acor.server.v1.ChangeEvent.Kind
+	(*KeywordRequest)(nil),       // 2: acor.server.v1.KeywordRequest
+	(*InputRequest)(nil),         // 3: acor.server.v1.InputRequest
+	(*EmptyRequest)(nil),         // 4: acor.server.v1.EmptyRequest
+	(*CountResponse)(nil),        // 5: acor.server.v1.CountResponse
+	(*MatchesResponse)(nil),      // 6: acor.server.v1.MatchesResponse
+	(*FindAcrossRequest)(nil),    // 7: acor.server.v1.FindAcrossRequest
+	(*CollectionMatch)(nil),      // 8: acor.server.v1.CollectionMatch
+	(*FindAcrossResponse)(nil),   // 9: acor.server.v1.FindAcrossResponse
+	(*Positions)(nil),            // 10: acor.server.v1.Positions
+	(*MatchIndexesResponse)(nil), // 11: acor.server.v1.MatchIndexesResponse
+	(*InfoResponse)(nil),         // 12: acor.server.v1.InfoResponse
+	(*StatusResponse)(nil),       // 13: acor.server.v1.StatusResponse
+	(*ScoreRequest)(nil),         // 14: acor.server.v1.ScoreRequest
+	(*KeywordScore)(nil),         // 15: acor.server.v1.KeywordScore
+	(*ScoreResponse)(nil),        // 16: acor.server.v1.ScoreResponse
+	(*KeywordWeight)(nil),        // 17: acor.server.v1.KeywordWeight
+	(*SetWeightsRequest)(nil),    // 18: acor.server.v1.SetWeightsRequest
+	(*WeightsResponse)(nil),      // 19: acor.server.v1.WeightsResponse
+	(*AliasRequest)(nil),         // 20: acor.server.v1.AliasRequest
+	(*AliasResponse)(nil),        // 21: acor.server.v1.AliasResponse
+	(*DeleteAliasResponse)(nil),  // 22: acor.server.v1.DeleteAliasResponse
+	(*WatchRequest)(nil),         // 23: acor.server.v1.WatchRequest
+	(*ChangeEvent)(nil),          // 24: acor.server.v1.ChangeEvent
+	nil,                          // 25: acor.server.v1.MatchIndexesResponse.MatchesEntry
+	nil,                          // 26: acor.server.v1.ScoreRequest.CategoryThresholdsEntry
+	nil,                          // 27: acor.server.v1.ScoreResponse.CategoriesEntry
+	nil,                          // 28: acor.server.v1.ScoreResponse.KeywordsEntry
+	nil,                          // 29: acor.server.v1.SetWeightsRequest.WeightsEntry
+	nil,                          // 30: acor.server.v1.WeightsResponse.WeightsEntry
 }
 var file_acor_v1_acor_proto_depIdxs = []int32{
-	9,  // 0: acor.server.v1.MatchIndexesResponse.matches:type_name -> acor.server.v1.MatchIndexesResponse.MatchesEntry
-	5,  // 1: acor.server.v1.MatchIndexesResponse.MatchesEntry.value:type_name -> acor.server.v1.Positions
-	0,  // 2: acor.server.v1.Acor.Add:input_type -> acor.server.v1.KeywordRequest
-	0,  // 3: acor.server.v1.Acor.Remove:input_type -> acor.server.v1.KeywordRequest
-	1,  // 4: acor.server.v1.Acor.Find:input_type -> acor.server.v1.InputRequest
-	1,  // 5: acor.server.v1.Acor.FindIndex:input_type -> acor.server.v1.InputRequest
-	1,  // 6: acor.server.v1.Acor.Suggest:input_type -> acor.server.v1.InputRequest
-	1,  // 7: acor.server.v1.Acor.SuggestIndex:input_type -> acor.server.v1.InputRequest
-	2,  // 8: acor.server.v1.Acor.Info:input_type -> acor.server.v1.EmptyRequest
-	2,  // 9: acor.server.v1.Acor.Flush:input_type -> acor.server.v1.EmptyRequest
-	3,  // 10: acor.server.v1.Acor.Add:output_type -> acor.server.v1.CountResponse
-	3,  // 11: acor.server.v1.Acor.Remove:output_type -> acor.server.v1.CountResponse
-	4,  // 12: acor.server.v1.Acor.Find:output_type -> acor.server.v1.MatchesResponse
-	6,  // 13: acor.server.v1.Acor.FindIndex:output_type -> acor.server.v1.MatchIndexesResponse
-	4,  // 14: acor.server.v1.Acor.Suggest:output_type -> acor.server.v1.MatchesResponse
-	6,  // 15: acor.server.v1.Acor.SuggestIndex:output_type -> acor.server.v1.MatchIndexesResponse
-	7,  // 16: acor.server.v1.Acor.Info:output_type -> acor.server.v1.InfoResponse
-	8,  // 17: acor.server.v1.Acor.Flush:output_type -> acor.server.v1.StatusResponse
-	10, // [10:18] is the sub-list for method output_type
-	2,  // [2:10] is the sub-list for method input_type
-	2,  // [2:2] is the sub-list for extension type_name
-	2,  // [2:2] is the sub-list for extension extendee
-	0,  // [0:2] is the sub-list for field type_name
+	8,  // 0: acor.server.v1.FindAcrossResponse.matches:type_name -> acor.server.v1.CollectionMatch
+	25, // 1: acor.server.v1.MatchIndexesResponse.matches:type_name -> acor.server.v1.MatchIndexesResponse.MatchesEntry
+	26, // 2: acor.server.v1.ScoreRequest.category_thresholds:type_name -> acor.server.v1.ScoreRequest.CategoryThresholdsEntry
+	27, // 3: acor.server.v1.ScoreResponse.categories:type_name -> acor.server.v1.ScoreResponse.CategoriesEntry
+	28, // 4: acor.server.v1.ScoreResponse.keywords:type_name -> acor.server.v1.ScoreResponse.KeywordsEntry
+	29, // 5: acor.server.v1.SetWeightsRequest.weights:type_name -> acor.server.v1.SetWeightsRequest.WeightsEntry
+	30, // 6: acor.server.v1.WeightsResponse.weights:type_name -> acor.server.v1.WeightsResponse.WeightsEntry
+	1,  // 7: acor.server.v1.ChangeEvent.kind:type_name -> acor.server.v1.ChangeEvent.Kind
+	10, // 8: acor.server.v1.MatchIndexesResponse.MatchesEntry.value:type_name -> acor.server.v1.Positions
+	15, // 9: acor.server.v1.ScoreResponse.KeywordsEntry.value:type_name -> acor.server.v1.KeywordScore
+	17, // 10: acor.server.v1.SetWeightsRequest.WeightsEntry.value:type_name -> acor.server.v1.KeywordWeight
+	17, // 11: acor.server.v1.WeightsResponse.WeightsEntry.value:type_name -> acor.server.v1.KeywordWeight
+	2,  // 12: acor.server.v1.Acor.Add:input_type -> acor.server.v1.KeywordRequest
+	2,  // 13: acor.server.v1.Acor.Remove:input_type -> acor.server.v1.KeywordRequest
+	3,  // 14: acor.server.v1.Acor.Find:input_type -> acor.server.v1.InputRequest
+	3,  // 15: acor.server.v1.Acor.FindIndex:input_type -> acor.server.v1.InputRequest
+	7,  // 16: acor.server.v1.Acor.FindAcross:input_type -> acor.server.v1.FindAcrossRequest
+	3,  // 17: acor.server.v1.Acor.Suggest:input_type -> acor.server.v1.InputRequest
+	3,  // 18: acor.server.v1.Acor.SuggestIndex:input_type -> acor.server.v1.InputRequest
+	4,  // 19: acor.server.v1.Acor.Info:input_type -> acor.server.v1.EmptyRequest
+	4,  // 20: acor.server.v1.Acor.Flush:input_type -> acor.server.v1.EmptyRequest
+	14, // 21: acor.server.v1.Acor.Score:input_type -> acor.server.v1.ScoreRequest
+	18, // 22: acor.server.v1.Acor.SetWeights:input_type -> acor.server.v1.SetWeightsRequest
+	4,  // 23: acor.server.v1.Acor.Weights:input_type -> acor.server.v1.EmptyRequest
+	20, // 24: acor.server.v1.Acor.SetAlias:input_type -> acor.server.v1.AliasRequest
+	20, // 25: acor.server.v1.Acor.ResolveAlias:input_type -> acor.server.v1.AliasRequest
+	20, // 26: acor.server.v1.Acor.DeleteAlias:input_type -> acor.server.v1.AliasRequest
+	23, // 27: acor.server.v1.Acor.Watch:input_type -> acor.server.v1.WatchRequest
+	5,  // 28: acor.server.v1.Acor.Add:output_type -> acor.server.v1.CountResponse
+	5,  // 29: acor.server.v1.Acor.Remove:output_type -> acor.server.v1.CountResponse
+	6,  // 30: acor.server.v1.Acor.Find:output_type -> acor.server.v1.MatchesResponse
+	11, // 31: acor.server.v1.Acor.FindIndex:output_type -> acor.server.v1.MatchIndexesResponse
+	9,  // 32: acor.server.v1.Acor.FindAcross:output_type -> acor.server.v1.FindAcrossResponse
+	6,  // 33: acor.server.v1.Acor.Suggest:output_type -> acor.server.v1.MatchesResponse
+	11, // 34: acor.server.v1.Acor.SuggestIndex:output_type -> acor.server.v1.MatchIndexesResponse
+	12, // 35: acor.server.v1.Acor.Info:output_type -> acor.server.v1.InfoResponse
+	13, // 36: acor.server.v1.Acor.Flush:output_type -> acor.server.v1.StatusResponse
+	16, // 37: acor.server.v1.Acor.Score:output_type -> acor.server.v1.ScoreResponse
+	5,  // 38: acor.server.v1.Acor.SetWeights:output_type -> acor.server.v1.CountResponse
+	19, // 39: acor.server.v1.Acor.Weights:output_type -> acor.server.v1.WeightsResponse
+	21, // 40: acor.server.v1.Acor.SetAlias:output_type -> acor.server.v1.AliasResponse
+	21, // 41: acor.server.v1.Acor.ResolveAlias:output_type -> acor.server.v1.AliasResponse
+	22, // 42: acor.server.v1.Acor.DeleteAlias:output_type -> acor.server.v1.DeleteAliasResponse
+	24, // 43: acor.server.v1.Acor.Watch:output_type -> acor.server.v1.ChangeEvent
+	28, // [28:44] is the sub-list for method output_type
+	12, // [12:28] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
 }
 
 func init() { file_acor_v1_acor_proto_init() }
@@ -518,13 +1755,14 @@ type x struct{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_acor_v1_acor_proto_rawDesc), len(file_acor_v1_acor_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   10,
+			NumEnums:      2,
+			NumMessages:   29,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_acor_v1_acor_proto_goTypes,
 		DependencyIndexes: file_acor_v1_acor_proto_depIdxs,
+		EnumInfos:         file_acor_v1_acor_proto_enumTypes,
 		MessageInfos:      file_acor_v1_acor_proto_msgTypes,
 	}.Build()
 	File_acor_v1_acor_proto = out.File
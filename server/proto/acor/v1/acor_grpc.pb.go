@@ -25,27 +25,57 @@
 	Acor_Remove_FullMethodName       = "/acor.server.v1.Acor/Remove"
 	Acor_Find_FullMethodName         = "/acor.server.v1.Acor/Find"
 	Acor_FindIndex_FullMethodName    = "/acor.server.v1.Acor/FindIndex"
+	Acor_FindAcross_FullMethodName   = "/acor.server.v1.Acor/FindAcross"
 	Acor_Suggest_FullMethodName      = "/acor.server.v1.Acor/Suggest"
 	Acor_SuggestIndex_FullMethodName = "/acor.server.v1.Acor/SuggestIndex"
 	Acor_Info_FullMethodName         = "/acor.server.v1.Acor/Info"
 	Acor_Flush_FullMethodName        = "/acor.server.v1.Acor/Flush"
+	Acor_Score_FullMethodName        = "/acor.server.v1.Acor/Score"
+	Acor_SetWeights_FullMethodName   = "/acor.server.v1.Acor/SetWeights"
+	Acor_Weights_FullMethodName      = "/acor.server.v1.Acor/Weights"
+	Acor_SetAlias_FullMethodName     = "/acor.server.v1.Acor/SetAlias"
+	Acor_ResolveAlias_FullMethodName = "/acor.server.v1.Acor/ResolveAlias"
+	Acor_DeleteAlias_FullMethodName  = "/acor.server.v1.Acor/DeleteAlias"
+	Acor_Watch_FullMethodName        = "/acor.server.v1.Acor/Watch"
 )
 
 // AcorClient is the client API for Acor service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 //
-// Acor is the gRPC surface for an Aho-Corasick keyword collection. It mirrors
-// the HTTP/JSON adapter one-to-one.
+// Acor is the gRPC surface for an Aho-Corasick keyword collection. Each RPC's
+// google.api.http option is its route on the HTTP/JSON API: the server derives
+// its REST gateway, its OpenAPI document, and the routes its auth and rate-limit
+// middleware guard from these bindings.
+//
+// A failed RPC carries a google.rpc.Status whose code follows the failure's
+// ErrorReason, and whose details hold a google.rpc.ErrorInfo with that reason as
+// its reason and "acor.server.v1" as its domain. A reason worth retrying adds a
+// google.rpc.RetryInfo with the delay to wait first; a request the server
+// rejects as malformed adds a google.rpc.BadRequest naming the offending field.
 type AcorClient interface {
 	Add(ctx context.Context, in *KeywordRequest, opts ...grpc.CallOption) (*CountResponse, error)
 	Remove(ctx context.Context, in *KeywordRequest, opts ...grpc.CallOption) (*CountResponse, error)
 	Find(ctx context.Context, in *InputRequest, opts ...grpc.CallOption) (*MatchesResponse, error)
 	FindIndex(ctx context.Context, in *InputRequest, opts ...grpc.CallOption) (*MatchIndexesResponse, error)
+	// FindAcross searches several collections in one pass. It needs a service that
+	// searches across collections (server.Collections); other services answer
+	// UNIMPLEMENTED, and a collection the server does not hold INVALID_ARGUMENT.
+	FindAcross(ctx context.Context, in *FindAcrossRequest, opts ...grpc.CallOption) (*FindAcrossResponse, error)
 	Suggest(ctx context.Context, in *InputRequest, opts ...grpc.CallOption) (*MatchesResponse, error)
 	SuggestIndex(ctx context.Context, in *InputRequest, opts ...grpc.CallOption) (*MatchIndexesResponse, error)
 	Info(ctx context.Context, in *EmptyRequest, opts ...grpc.CallOption) (*InfoResponse, error)
 	Flush(ctx context.Context, in *EmptyRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+	Score(ctx context.Context, in *ScoreRequest, opts ...grpc.CallOption) (*ScoreResponse, error)
+	SetWeights(ctx context.Context, in *SetWeightsRequest, opts ...grpc.CallOption) (*CountResponse, error)
+	Weights(ctx context.Context, in *EmptyRequest, opts ...grpc.CallOption) (*WeightsResponse, error)
+	SetAlias(ctx context.Context, in *AliasRequest, opts ...grpc.CallOption) (*AliasResponse, error)
+	ResolveAlias(ctx context.Context, in *AliasRequest, opts ...grpc.CallOption) (*AliasResponse, error)
+	DeleteAlias(ctx context.Context, in *AliasRequest, opts ...grpc.CallOption) (*DeleteAliasResponse, error)
+	// Watch streams a ChangeEvent for each committed write to the collection until
+	// the client cancels. It needs a service that can watch its collection
+	// (server.Watcher); other services answer UNIMPLEMENTED.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error)
 }
 
 type acorClient struct {
@@ -96,6 +126,16 @@ func (c *acorClient) FindIndex(ctx context.Context, in *InputRequest, opts ...gr
 	return out, nil
 }
 
+func (c *acorClient) FindAcross(ctx context.Context, in *FindAcrossRequest, opts ...grpc.CallOption) (*FindAcrossResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindAcrossResponse)
+	err := c.cc.Invoke(ctx, Acor_FindAcross_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *acorClient) Suggest(ctx context.Context, in *InputRequest, opts ...grpc.CallOption) (*MatchesResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(MatchesResponse)
@@ -136,21 +176,122 @@ func (c *acorClient) Flush(ctx context.Context, in *EmptyRequest, opts ...grpc.C
 	return out, nil
 }
 
+func (c *acorClient) Score(ctx context.Context, in *ScoreRequest, opts ...grpc.CallOption) (*ScoreResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScoreResponse)
+	err := c.cc.Invoke(ctx, Acor_Score_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *acorClient) SetWeights(ctx context.Context, in *SetWeightsRequest, opts ...grpc.CallOption) (*CountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountResponse)
+	err := c.cc.Invoke(ctx, Acor_SetWeights_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *acorClient) Weights(ctx context.Context, in *EmptyRequest, opts ...grpc.CallOption) (*WeightsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WeightsResponse)
+	err := c.cc.Invoke(ctx, Acor_Weights_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *acorClient) SetAlias(ctx context.Context, in *AliasRequest, opts ...grpc.CallOption) (*AliasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AliasResponse)
+	err := c.cc.Invoke(ctx, Acor_SetAlias_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *acorClient) ResolveAlias(ctx context.Context, in *AliasRequest, opts ...grpc.CallOption) (*AliasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AliasResponse)
+	err := c.cc.Invoke(ctx, Acor_ResolveAlias_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *acorClient) DeleteAlias(ctx context.Context, in *AliasRequest, opts ...grpc.CallOption) (*DeleteAliasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteAliasResponse)
+	err := c.cc.Invoke(ctx, Acor_DeleteAlias_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *acorClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Acor_ServiceDesc.Streams[0], Acor_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, ChangeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Acor_WatchClient = grpc.ServerStreamingClient[ChangeEvent]
+
 // AcorServer is the server API for Acor service.
 // All implementations must embed UnimplementedAcorServer
 // for forward compatibility.
 //
-// Acor is the gRPC surface for an Aho-Corasick keyword collection. It mirrors
-// the HTTP/JSON adapter one-to-one.
+// Acor is the gRPC surface for an Aho-Corasick keyword collection. Each RPC's
+// google.api.http option is its route on the HTTP/JSON API: the server derives
+// its REST gateway, its OpenAPI document, and the routes its auth and rate-limit
+// middleware guard from these bindings.
+//
+// A failed RPC carries a google.rpc.Status whose code follows the failure's
+// ErrorReason, and whose details hold a google.rpc.ErrorInfo with that reason as
+// its reason and "acor.server.v1" as its domain. A reason worth retrying adds a
+// google.rpc.RetryInfo with the delay to wait first; a request the server
+// rejects as malformed adds a google.rpc.BadRequest naming the offending field.
 type AcorServer interface {
 	Add(context.Context, *KeywordRequest) (*CountResponse, error)
 	Remove(context.Context, *KeywordRequest) (*CountResponse, error)
 	Find(context.Context, *InputRequest) (*MatchesResponse, error)
 	FindIndex(context.Context, *InputRequest) (*MatchIndexesResponse, error)
+	// FindAcross searches several collections in one pass. It needs a service that
+	// searches across collections (server.Collections); other services answer
+	// UNIMPLEMENTED, and a collection the server does not hold INVALID_ARGUMENT.
+	FindAcross(context.Context, *FindAcrossRequest) (*FindAcrossResponse, error)
 	Suggest(context.Context, *InputRequest) (*MatchesResponse, error)
 	SuggestIndex(context.Context, *InputRequest) (*MatchIndexesResponse, error)
 	Info(context.Context, *EmptyRequest) (*InfoResponse, error)
 	Flush(context.Context, *EmptyRequest) (*StatusResponse, error)
+	Score(context.Context, *ScoreRequest) (*ScoreResponse, error)
+	SetWeights(context.Context, *SetWeightsRequest) (*CountResponse, error)
+	Weights(context.Context, *EmptyRequest) (*WeightsResponse, error)
+	SetAlias(context.Context, *AliasRequest) (*AliasResponse, error)
+	ResolveAlias(context.Context, *AliasRequest) (*AliasResponse, error)
+	DeleteAlias(context.Context, *AliasRequest) (*DeleteAliasResponse, error)
+	// Watch streams a ChangeEvent for each committed write to the collection until
+	// the client cancels. It needs a service that can watch its collection
+	// (server.Watcher); other services answer UNIMPLEMENTED.
+	Watch(*WatchRequest, grpc.ServerStreamingServer[ChangeEvent]) error
 	mustEmbedUnimplementedAcorServer()
 }
 
@@ -173,6 +314,9 @@ func (UnimplementedAcorServer) Find(context.Context, *InputRequest) (*MatchesRes
 func (UnimplementedAcorServer) FindIndex(context.Context, *InputRequest) (*MatchIndexesResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method FindIndex not implemented")
 }
+func (UnimplementedAcorServer) FindAcross(context.Context, *FindAcrossRequest) (*FindAcrossResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method FindAcross not implemented")
+}
 func (UnimplementedAcorServer) Suggest(context.Context, *InputRequest) (*MatchesResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Suggest not implemented")
 }
@@ -185,6 +329,27 @@ func (UnimplementedAcorServer) Info(context.Context, *EmptyRequest) (*InfoRespon
 func (UnimplementedAcorServer) Flush(context.Context, *EmptyRequest) (*StatusResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Flush not implemented")
 }
+func (UnimplementedAcorServer) Score(context.Context, *ScoreRequest) (*ScoreResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Score not implemented")
+}
+func (UnimplementedAcorServer) SetWeights(context.Context, *SetWeightsRequest) (*CountResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetWeights not implemented")
+}
+func (UnimplementedAcorServer) Weights(context.Context, *EmptyRequest) (*WeightsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Weights not implemented")
+}
+func (UnimplementedAcorServer) SetAlias(context.Context, *AliasRequest) (*AliasResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetAlias not implemented")
+}
+func (UnimplementedAcorServer) ResolveAlias(context.Context, *AliasRequest) (*AliasResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ResolveAlias not implemented")
+}
+func (UnimplementedAcorServer) DeleteAlias(context.Context, *AliasRequest) (*DeleteAliasResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteAlias not implemented")
+}
+func (UnimplementedAcorServer) Watch(*WatchRequest, grpc.ServerStreamingServer[ChangeEvent]) error {
+	return status.Error(codes.Unimplemented, "method Watch not implemented")
+}
 func (UnimplementedAcorServer) mustEmbedUnimplementedAcorServer() {}
 func (UnimplementedAcorServer) testEmbeddedByValue()              {}
 
@@ -278,6 +443,24 @@ func _Acor_FindIndex_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Acor_FindAcross_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindAcrossRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AcorServer).FindAcross(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Acor_FindAcross_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AcorServer).FindAcross(ctx, req.(*FindAcrossRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Acor_Suggest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(InputRequest)
 	if err := dec(in); err != nil {
@@ -350,6 +533,125 @@ func _Acor_Flush_Handler(srv interface{}, ctx context.Context, dec func(interfac
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Acor_Score_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AcorServer).Score(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Acor_Score_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AcorServer).Score(ctx, req.(*ScoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Acor_SetWeights_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetWeightsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AcorServer).SetWeights(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Acor_SetWeights_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AcorServer).SetWeights(ctx, req.(*SetWeightsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Acor_Weights_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmptyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AcorServer).Weights(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Acor_Weights_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AcorServer).Weights(ctx, req.(*EmptyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Acor_SetAlias_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AliasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AcorServer).SetAlias(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Acor_SetAlias_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AcorServer).SetAlias(ctx, req.(*AliasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Acor_ResolveAlias_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AliasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AcorServer).ResolveAlias(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Acor_ResolveAlias_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AcorServer).ResolveAlias(ctx, req.(*AliasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Acor_DeleteAlias_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AliasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AcorServer).DeleteAlias(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Acor_DeleteAlias_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AcorServer).DeleteAlias(ctx, req.(*AliasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Acor_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AcorServer).Watch(m, &grpc.GenericServerStream[WatchRequest, ChangeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Acor_WatchServer = grpc.ServerStreamingServer[ChangeEvent]
+
 // Acor_ServiceDesc is the grpc.ServiceDesc for Acor service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -373,6 +675,10 @@ func _Acor_Flush_Handler(srv interface{}, ctx context.Context, dec func(interfac
 			MethodName: "FindIndex",
 			Handler:    _Acor_FindIndex_Handler,
 		},
+		{
+			MethodName: "FindAcross",
+			Handler:    _Acor_FindAcross_Handler,
+		},
 		{
 			MethodName: "Suggest",
 			Handler:    _Acor_Suggest_Handler,
@@ -389,7 +695,37 @@ func _Acor_Flush_Handler(srv interface{}, ctx context.Context, dec func(interfac
 			MethodName: "Flush",
 			Handler:    _Acor_Flush_Handler,
 		},
+		{
+			MethodName: "Score",
+			Handler:    _Acor_Score_Handler,
+		},
+		{
+			MethodName: "SetWeights",
+			Handler:    _Acor_SetWeights_Handler,
+		},
+		{
+			MethodName: "Weights",
+			Handler:    _Acor_Weights_Handler,
+		},
+		{
+			MethodName: "SetAlias",
+			Handler:    _Acor_SetAlias_Handler,
+		},
+		{
+			MethodName: "ResolveAlias",
+			Handler:    _Acor_ResolveAlias_Handler,
+		},
+		{
+			MethodName: "DeleteAlias",
+			Handler:    _Acor_DeleteAlias_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Acor_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "acor/v1/acor.proto",
 }
@@ -0,0 +1,664 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	"github.com/skyoo2003/acor/server/auth"
+	"github.com/skyoo2003/acor/server/internal/resp"
+	"github.com/skyoo2003/acor/server/limit"
+)
+
+// ErrRESPServerClosed is returned by RESPServer.Serve after Shutdown or Close.
+var ErrRESPServerClosed = errors.New("server: RESP server closed")
+
+// ErrMatchesUnsupported is returned for ACOR.MATCHES on a collection whose
+// Service does not implement MatchFinder.
+var ErrMatchesUnsupported = errors.New("service does not report match spans")
+
+// MatchFinder is implemented by a Service that reports matches with their
+// spans, which ACOR.MATCHES needs. *acor.AhoCorasick does.
+type MatchFinder interface {
+	FindMatchesContext(ctx context.Context, text string, opts *acor.MatchOptions) ([]acor.Match, error)
+}
+
+// RESPOptions configures a RESPServer. The zero value admits every command and
+// limits none.
+type RESPOptions struct {
+	// Guard, when set, checks every ACOR.* command as AuthHTTPMiddleware checks
+	// the route of the same operation. A client authenticates with AUTH or
+	// HELLO's AUTH option: the password is an API key, or a bearer token when
+	// the username is "token". A TLS listener's verified client certificates
+	// are presented as well.
+	Guard *auth.Guard
+	// Limiter, when set, admits every ACOR.* command as RateLimitHTTPMiddleware
+	// admits the route of the same operation.
+	Limiter *limit.Limiter
+	// MaxArgBytes bounds each command argument; the HTTP handler's body limit,
+	// 1 MiB, when zero. A larger argument closes the connection.
+	MaxArgBytes int
+	// MaxCommandBytes bounds a command's arguments together, as the body limit
+	// bounds a whole HTTP request; the body limit or MaxArgBytes, whichever is
+	// larger, when zero. A larger command closes the connection. It is checked
+	// as the command arrives, before authentication or the Limiter sees it.
+	MaxCommandBytes int
+	// IdleTimeout closes a connection that sends no command for this long. Zero
+	// keeps idle connections open.
+	IdleTimeout time.Duration
+}
+
+// RESPServer serves collections over the Redis protocol, so redis-cli and any
+// Redis client library can reach them. Each ACOR.* command names its
+// collection first:
+//
+//	ACOR.ADD coll keyword [keyword ...]       integer: keywords added
+//	ACOR.REMOVE coll keyword [keyword ...]    integer: keywords removed
+//	ACOR.FIND coll text                       array of keywords, in scan order
+//	ACOR.FINDINDEX coll text                  map of keyword to start offsets
+//	ACOR.MATCHES coll text [LEFTMOST] [WHOLEWORD]
+//	                                          array of maps: keyword, start, end
+//	ACOR.SUGGEST coll prefix                  array of keywords
+//	ACOR.INFO coll                            map: keywords, nodes
+//	ACOR.FLUSH coll                           OK
+//
+// Offsets count runes, end exclusive, as acor.Match does. Clients choose RESP3
+// with HELLO 3; a RESP2 client gets each map as a flat array of keys and
+// values. A failed command replies with an error whose first word is its
+// ErrorReason name from acor.proto, such as UNKNOWN_COLLECTION or RATE_LIMITED.
+// PING, ECHO, HELLO, AUTH, SELECT 0, CLIENT, COMMAND, RESET, and QUIT are
+// answered as Redis would, for the benefit of client libraries.
+//
+// Serve a TLS listener from tls.NewListener for encryption.
+type RESPServer struct {
+	collections map[string]Service
+	opts        RESPOptions
+	nextID      atomic.Int64
+	// ctx is canceled by Close, abandoning the commands still running.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	closing   bool
+	listeners map[net.Listener]struct{}
+	conns     map[*respConn]struct{}
+	wg        sync.WaitGroup
+}
+
+// NewRESPServer returns a RESPServer for collections, keyed by the name
+// commands use for each. opts may be nil.
+func NewRESPServer(collections map[string]Service, opts *RESPOptions) *RESPServer {
+	s := &RESPServer{
+		collections: collections,
+		listeners:   make(map[net.Listener]struct{}),
+		conns:       make(map[*respConn]struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.MaxArgBytes <= 0 {
+		s.opts.MaxArgBytes = maxRequestBodyBytes
+	}
+	if s.opts.MaxCommandBytes <= 0 {
+		s.opts.MaxCommandBytes = max(maxRequestBodyBytes, s.opts.MaxArgBytes)
+	}
+	return s
+}
+
+// Serve accepts connections on l until Shutdown or Close, serving each on its
+// own goroutine. It returns ErrRESPServerClosed once stopped, or the error that
+// made Accept fail.
+func (s *RESPServer) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		return ErrRESPServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, l)
+		s.mu.Unlock()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closing := s.closing
+			s.mu.Unlock()
+			if closing {
+				return ErrRESPServerClosed
+			}
+			return err
+		}
+		c := &respConn{
+			server: s,
+			conn:   conn,
+			id:     s.nextID.Add(1),
+			r:      resp.NewReader(conn, s.opts.MaxArgBytes, s.opts.MaxCommandBytes),
+			w:      resp.NewWriter(conn),
+		}
+		s.mu.Lock()
+		if s.closing {
+			s.mu.Unlock()
+			_ = conn.Close()
+			return ErrRESPServerClosed
+		}
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+		go c.serve()
+	}
+}
+
+// Shutdown stops accepting connections and closes each open one once the
+// command it is running, if any, has replied. It returns when all are closed,
+// or with ctx's error once ctx is done, closing the rest outright.
+func (s *RESPServer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	for l := range s.listeners {
+		_ = l.Close()
+	}
+	// A connection waiting for a command wakes now. One running a command
+	// sees closing before it reads again.
+	for c := range s.conns {
+		_ = c.conn.SetReadDeadline(time.Now())
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		_ = s.Close()
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting connections and closes every open one at once,
+// abandoning the replies of commands still running.
+func (s *RESPServer) Close() error {
+	s.cancel()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closing = true
+	for l := range s.listeners {
+		_ = l.Close()
+	}
+	for c := range s.conns {
+		_ = c.conn.Close()
+	}
+	return nil
+}
+
+// respConn is one client connection and the state its commands set.
+type respConn struct {
+	server *RESPServer
+	conn   net.Conn
+	id     int64
+	r      *resp.Reader
+	w      *resp.Writer
+
+	creds auth.Credentials
+	name  string
+}
+
+func (c *respConn) serve() {
+	defer func() {
+		_ = c.conn.Close()
+		c.server.mu.Lock()
+		delete(c.server.conns, c)
+		c.server.mu.Unlock()
+		c.server.wg.Done()
+	}()
+	for {
+		if !c.awaitCommand() {
+			return
+		}
+		args, err := c.r.ReadCommand()
+		if err != nil {
+			if errors.Is(err, resp.ErrProtocol) {
+				c.w.Error("ERR", "Protocol error: "+strings.TrimPrefix(err.Error(), resp.ErrProtocol.Error()+": "))
+				_ = c.w.Flush()
+			}
+			return
+		}
+		quit := c.dispatch(args)
+		if c.r.Buffered() == 0 || quit {
+			if c.w.Flush() != nil || quit {
+				return
+			}
+		}
+	}
+}
+
+// awaitCommand sets the deadline for the next command to arrive, reporting
+// false once the server is shutting down. It holds the server's lock so that
+// Shutdown's deadline cannot be overwritten by an idle timeout set just after.
+func (c *respConn) awaitCommand() bool {
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+	if c.server.closing {
+		return false
+	}
+	var deadline time.Time
+	if t := c.server.opts.IdleTimeout; t > 0 {
+		deadline = time.Now().Add(t)
+	}
+	_ = c.conn.SetReadDeadline(deadline)
+	return true
+}
+
+// dispatch runs one command and writes its reply, reporting whether the
+// connection should close after it.
+func (c *respConn) dispatch(args []string) (quit bool) {
+	name := strings.ToUpper(args[0])
+	if cmd, ok := respCommands[name]; ok {
+		if n := len(args) - 2; n < cmd.minArgs || (cmd.maxArgs >= 0 && n > cmd.maxArgs) {
+			c.writeError(errArity(name))
+			return false
+		}
+		c.runACOR(name, cmd, args[1], args[2:])
+		return false
+	}
+	if name == "QUIT" {
+		c.w.Status("OK")
+		return true
+	}
+	if handle, ok := respConnCommands[name]; ok {
+		handle(c, args[1:])
+		return false
+	}
+	c.w.Error("ERR", fmt.Sprintf("unknown command '%s'", args[0]))
+	return false
+}
+
+// respConnCommands are the Redis commands a client library sends on its own,
+// while connecting or pooling, and expects answered as Redis would.
+var respConnCommands = map[string]func(c *respConn, args []string){
+	"PING": func(c *respConn, args []string) {
+		if len(args) > 0 {
+			c.w.Bulk(args[0])
+		} else {
+			c.w.Status("PONG")
+		}
+	},
+	"ECHO": func(c *respConn, args []string) {
+		if len(args) != 1 {
+			c.writeError(errArity("ECHO"))
+		} else {
+			c.w.Bulk(args[0])
+		}
+	},
+	"HELLO": (*respConn).hello,
+	"AUTH": func(c *respConn, args []string) {
+		if err := c.auth(args); err != nil {
+			c.writeError(err)
+		} else {
+			c.w.Status("OK")
+		}
+	},
+	"SELECT": func(c *respConn, args []string) {
+		if len(args) == 1 && args[0] == "0" {
+			c.w.Status("OK")
+		} else {
+			c.w.Error("ERR", "DB index is out of range")
+		}
+	},
+	"CLIENT":  (*respConn).client,
+	"COMMAND": (*respConn).command,
+	"RESET": func(c *respConn, _ []string) {
+		c.w.Proto, c.creds, c.name = 2, auth.Credentials{}, ""
+		c.w.Status("RESET")
+	},
+}
+
+// respError is a reply in Redis's own terms, for the failures a Redis client
+// library expects to see as Redis words them.
+type respError struct {
+	code, msg string
+}
+
+func (e *respError) Error() string { return e.code + " " + e.msg }
+
+func errArity(command string) error {
+	return &respError{"ERR", fmt.Sprintf("wrong number of arguments for '%s' command", strings.ToLower(command))}
+}
+
+// writeError replies with err's ErrorReason, and when waiting helps, how long
+// in whole seconds, as the HTTP handler's Retry-After header says.
+func (c *respConn) writeError(err error) {
+	var re *respError
+	if errors.As(err, &re) {
+		c.w.Error(re.code, re.msg)
+		return
+	}
+	e := classifyError(err)
+	msg := e.detail
+	if delay := e.class().retryDelay; delay > 0 {
+		msg += fmt.Sprintf("; retry after %ds", int(math.Ceil(delay.Seconds())))
+	}
+	c.w.Error(e.reason.String(), msg)
+}
+
+// hello handles HELLO [protover [AUTH username password] [SETNAME name]].
+func (c *respConn) hello(args []string) {
+	proto := c.w.Proto
+	if len(args) > 0 {
+		v, err := strconv.Atoi(args[0])
+		if err != nil || v < 2 || v > 3 {
+			c.w.Error("NOPROTO", "unsupported protocol version")
+			return
+		}
+		proto = v
+		args = args[1:]
+	}
+	name := c.name
+	for len(args) > 0 {
+		switch {
+		case strings.EqualFold(args[0], "AUTH") && len(args) >= 3:
+			if err := c.auth(args[1:3]); err != nil {
+				c.writeError(err)
+				return
+			}
+			args = args[3:]
+		case strings.EqualFold(args[0], "SETNAME") && len(args) >= 2:
+			name, args = args[1], args[2:]
+		default:
+			c.w.Error("ERR", fmt.Sprintf("syntax error in HELLO option '%s'", args[0]))
+			return
+		}
+	}
+	c.w.Proto, c.name = proto, name
+	c.w.Map(6)
+	c.w.Bulk("server")
+	c.w.Bulk("acor")
+	c.w.Bulk("proto")
+	c.w.Int(int64(proto))
+	c.w.Bulk("id")
+	c.w.Int(c.id)
+	c.w.Bulk("mode")
+	c.w.Bulk("standalone")
+	c.w.Bulk("role")
+	c.w.Bulk("master")
+	c.w.Bulk("modules")
+	c.w.Array(0)
+}
+
+// auth handles AUTH [username] password. The password is checked when it is
+// given, so a wrong one fails here rather than at the next command.
+func (c *respConn) auth(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errArity("AUTH")
+	}
+	guard := c.server.opts.Guard
+	if guard == nil {
+		return &respError{"ERR", "AUTH called without any authentication configured"}
+	}
+	var creds auth.Credentials
+	if password := args[len(args)-1]; len(args) == 2 && args[0] == "token" {
+		creds.Authorization = "Bearer " + password
+	} else {
+		creds.APIKey = password
+	}
+	if guard.Authenticator == nil {
+		return auth.ErrNoCredentials
+	}
+	if _, err := guard.Authenticator.Authenticate(c.server.ctx, &creds); err != nil {
+		if !errors.Is(err, auth.ErrNoCredentials) && !errors.Is(err, auth.ErrInvalidCredentials) {
+			err = fmt.Errorf("%w: %w", auth.ErrInvalidCredentials, err)
+		}
+		return err
+	}
+	c.creds = creds
+	return nil
+}
+
+// client handles the CLIENT subcommands client libraries send on connecting.
+func (c *respConn) client(args []string) {
+	sub := ""
+	if len(args) > 0 {
+		sub = strings.ToUpper(args[0])
+	}
+	switch {
+	case sub == "SETNAME" && len(args) == 2:
+		c.name = args[1]
+		c.w.Status("OK")
+	case sub == "GETNAME":
+		if c.name == "" {
+			c.w.Null()
+		} else {
+			c.w.Bulk(c.name)
+		}
+	case sub == "ID":
+		c.w.Int(c.id)
+	case sub == "SETINFO" && len(args) == 3:
+		c.w.Status("OK")
+	default:
+		c.w.Error("ERR", fmt.Sprintf("unknown subcommand or wrong number of arguments for '%s'", strings.Join(args, " ")))
+	}
+}
+
+// command handles COMMAND, which redis-cli asks for its completion hints. The
+// ACOR.* commands go without, so the replies are empty.
+func (c *respConn) command(args []string) {
+	switch {
+	case len(args) > 0 && strings.EqualFold(args[0], "COUNT"):
+		c.w.Int(int64(len(respCommands)))
+	case len(args) > 0 && strings.EqualFold(args[0], "DOCS"):
+		c.w.Map(0)
+	default:
+		c.w.Array(0)
+	}
+}
+
+// respCommand is one ACOR.* command. rpc names the Acor RPC whose auth route
+// and limits it shares, so a command and its HTTP route are guarded alike.
+type respCommand struct {
+	rpc string
+	// minArgs and maxArgs count the arguments after the collection; maxArgs is
+	// -1 for a keyword list, which the limiter weighs as a batch.
+	minArgs, maxArgs int
+	// text, when set, returns the text size the limiter weighs and the field
+	// that carries it.
+	text func(args []string) (bytes int, field string)
+	run  func(ctx context.Context, w *resp.Writer, service Service, args []string) error
+}
+
+func inputText(args []string) (int, string) { return len(args[0]), "input" }
+
+func keywordText(args []string) (int, string) {
+	n := 0
+	for _, kw := range args {
+		n += len(kw)
+	}
+	return n, "keyword"
+}
+
+var respCommands = map[string]respCommand{
+	"ACOR.ADD":       {rpc: "Add", minArgs: 1, maxArgs: -1, text: keywordText, run: respWrite(Service.AddContext)},
+	"ACOR.REMOVE":    {rpc: "Remove", minArgs: 1, maxArgs: -1, text: keywordText, run: respWrite(Service.RemoveContext)},
+	"ACOR.FIND":      {rpc: "Find", minArgs: 1, maxArgs: 1, text: inputText, run: respStrings(Service.FindContext)},
+	"ACOR.FINDINDEX": {rpc: "FindIndex", minArgs: 1, maxArgs: 1, text: inputText, run: respFindIndex},
+	"ACOR.MATCHES":   {rpc: "Find", minArgs: 1, maxArgs: 3, text: inputText, run: respMatches},
+	"ACOR.SUGGEST":   {rpc: "Suggest", minArgs: 1, maxArgs: 1, text: inputText, run: respStrings(Service.SuggestContext)},
+	"ACOR.INFO":      {rpc: "Info", run: respInfo},
+	"ACOR.FLUSH":     {rpc: "Flush", run: respFlush},
+}
+
+// runACOR admits an ACOR.* command through the guard and limiter, then runs it
+// against the collection it names.
+func (c *respConn) runACOR(name string, cmd respCommand, collection string, args []string) {
+	service, ok := c.server.collections[collection]
+	if !ok {
+		c.writeError(fmt.Errorf("%w: %q", ErrUnknownCollection, collection))
+		return
+	}
+	op := authRoutes[cmd.rpc]
+	ctx := c.server.ctx
+	identity := remoteHost(c.conn.RemoteAddr().String())
+	if guard := c.server.opts.Guard; guard != nil {
+		creds := c.creds
+		if tc, ok := c.conn.(*tls.Conn); ok {
+			creds.VerifiedChains = tc.ConnectionState().VerifiedChains
+		}
+		id, err := guard.Check(ctx, &auth.Request{
+			Credentials: creds,
+			Operation:   op,
+			Collections: []string{collection},
+			Transport:   "resp",
+			Target:      name,
+			RemoteAddr:  c.conn.RemoteAddr().String(),
+		})
+		if err != nil {
+			c.writeError(err)
+			return
+		}
+		ctx, identity = auth.NewContext(ctx, id), id.Subject
+	}
+	if limiter := c.server.opts.Limiter; limiter != nil {
+		req := &limit.Request{Operation: op.Name, Identity: identity, Collections: []string{collection}}
+		if cmd.text != nil {
+			req.TextBytes, req.TextField = cmd.text(args)
+		}
+		if cmd.maxArgs < 0 {
+			req.Batch, req.BatchField = len(args), "keyword"
+		}
+		release, err := limiter.Acquire(req)
+		if err != nil {
+			c.writeError(err)
+			return
+		}
+		defer release()
+	}
+	if err := cmd.run(ctx, c.w, service, args); err != nil {
+		c.writeError(err)
+	}
+}
+
+// respWrite runs a keyword write for each argument, replying with the total
+// count. It stops at the first failure, whose keywords before it stay written,
+// as a sequence of ACOR.ADD calls would leave them.
+func respWrite(write func(Service, context.Context, string) (int, error)) func(context.Context, *resp.Writer, Service, []string) error {
+	return func(ctx context.Context, w *resp.Writer, service Service, args []string) error {
+		total := 0
+		for _, kw := range args {
+			n, err := write(service, ctx, kw)
+			if err != nil {
+				return err
+			}
+			total += n
+		}
+		w.Int(int64(total))
+		return nil
+	}
+}
+
+func respStrings(read func(Service, context.Context, string) ([]string, error)) func(context.Context, *resp.Writer, Service, []string) error {
+	return func(ctx context.Context, w *resp.Writer, service Service, args []string) error {
+		list, err := read(service, ctx, args[0])
+		if err != nil {
+			return err
+		}
+		w.Strings(list)
+		return nil
+	}
+}
+
+func respFindIndex(ctx context.Context, w *resp.Writer, service Service, args []string) error {
+	index, err := service.FindIndexContext(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	keywords := make([]string, 0, len(index))
+	for kw := range index {
+		keywords = append(keywords, kw)
+	}
+	slices.Sort(keywords)
+	w.Map(len(keywords))
+	for _, kw := range keywords {
+		w.Bulk(kw)
+		w.Array(len(index[kw]))
+		for _, start := range index[kw] {
+			w.Int(int64(start))
+		}
+	}
+	return nil
+}
+
+// respMatches handles ACOR.MATCHES coll text [LEFTMOST] [WHOLEWORD]. The
+// options, in either order, are acor.MatchOptions' Kind and WholeWord.
+func respMatches(ctx context.Context, w *resp.Writer, service Service, args []string) error {
+	finder, ok := service.(MatchFinder)
+	if !ok {
+		return fmt.Errorf("%w: collection %q", ErrMatchesUnsupported, service.Collection())
+	}
+	opts := &acor.MatchOptions{}
+	for _, opt := range args[1:] {
+		switch strings.ToUpper(opt) {
+		case "LEFTMOST":
+			opts.Kind = acor.MatchKindLeftmostLongest
+		case "WHOLEWORD":
+			opts.WholeWord = true
+		default:
+			return &respError{"ERR", "syntax error"}
+		}
+	}
+	matches, err := finder.FindMatchesContext(ctx, args[0], opts)
+	if err != nil {
+		return err
+	}
+	w.Array(len(matches))
+	for _, m := range matches {
+		w.Map(3)
+		w.Bulk("keyword")
+		w.Bulk(m.Keyword)
+		w.Bulk("start")
+		w.Int(int64(m.Start))
+		w.Bulk("end")
+		w.Int(int64(m.End))
+	}
+	return nil
+}
+
+func respInfo(_ context.Context, w *resp.Writer, service Service, _ []string) error {
+	info, err := service.Info()
+	if err != nil {
+		return err
+	}
+	w.Map(2)
+	w.Bulk("keywords")
+	w.Int(int64(info.Keywords))
+	w.Bulk("nodes")
+	w.Int(int64(info.Nodes))
+	return nil
+}
+
+func respFlush(ctx context.Context, w *resp.Writer, service Service, _ []string) error {
+	if err := service.FlushContext(ctx); err != nil {
+		return err
+	}
+	w.Status("OK")
+	return nil
+}
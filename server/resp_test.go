@@ -0,0 +1,313 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	"github.com/skyoo2003/acor/server/limit"
+)
+
+// startRESPServer serves collections on a loopback port and returns its address.
+func startRESPServer(t *testing.T, collections map[string]Service, opts *RESPOptions) (*RESPServer, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewRESPServer(collections, opts)
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(l) }()
+	t.Cleanup(func() {
+		_ = server.Close()
+		if err := <-done; !errors.Is(err, ErrRESPServerClosed) {
+			t.Errorf("Serve = %v, want ErrRESPServerClosed", err)
+		}
+	})
+	return server, l.Addr().String()
+}
+
+// newRESPTestCollection returns an empty "rules" collection on miniredis.
+func newRESPTestCollection(t *testing.T) *acor.AhoCorasick {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	ac, err := acor.Create(&acor.AhoCorasickArgs{Addr: mr.Addr(), Name: "rules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ac.Close() })
+	return ac
+}
+
+func TestRESPServerCommands(t *testing.T) {
+	for _, proto := range []int{2, 3} {
+		t.Run(map[int]string{2: "RESP2", 3: "RESP3"}[proto], func(t *testing.T) {
+			collections := map[string]Service{"rules": newRESPTestCollection(t), "plain": &fakeService{collection: "plain"}}
+			_, addr := startRESPServer(t, collections, nil)
+			rdb := redis.NewClient(&redis.Options{Addr: addr, Protocol: proto})
+			defer rdb.Close()
+			ctx := context.Background()
+
+			do := func(args ...any) any {
+				t.Helper()
+				v, err := rdb.Do(ctx, args...).Result()
+				if err != nil {
+					t.Fatalf("%v: %v", args, err)
+				}
+				return v
+			}
+			// pairs flattens a reply map as RESP2 sends it, for comparing both.
+			pairs := func(v any) []any {
+				m, ok := v.(map[any]any)
+				if !ok {
+					return v.([]any)
+				}
+				var flat []any
+				for _, k := range []string{"keyword", "start", "end", "keywords", "nodes", "he", "her", "hers"} {
+					if val, ok := m[k]; ok {
+						flat = append(flat, k, val)
+					}
+				}
+				return flat
+			}
+
+			if got := do("ACOR.ADD", "rules", "he", "her", "hers"); got != int64(3) {
+				t.Errorf("ACOR.ADD = %v, want 3", got)
+			}
+			if got := do("acor.add", "rules", "he"); got != int64(0) {
+				t.Errorf("ACOR.ADD of a present keyword = %v, want 0", got)
+			}
+			if got := do("ACOR.FIND", "rules", "ushers"); !reflect.DeepEqual(got, []any{"he", "her", "hers"}) {
+				t.Errorf("ACOR.FIND = %v", got)
+			}
+			want := []any{"he", []any{int64(2)}, "her", []any{int64(2)}, "hers", []any{int64(2)}}
+			if got := pairs(do("ACOR.FINDINDEX", "rules", "ushers")); !reflect.DeepEqual(got, want) {
+				t.Errorf("ACOR.FINDINDEX = %v, want %v", got, want)
+			}
+			matches := do("ACOR.MATCHES", "rules", "ushers", "leftmost").([]any)
+			if len(matches) != 1 || !reflect.DeepEqual(pairs(matches[0]), []any{"keyword", "hers", "start", int64(2), "end", int64(6)}) {
+				t.Errorf("ACOR.MATCHES LEFTMOST = %v", matches)
+			}
+			if got := do("ACOR.SUGGEST", "rules", "her"); !reflect.DeepEqual(got, []any{"her", "hers"}) {
+				t.Errorf("ACOR.SUGGEST = %v", got)
+			}
+			if got := pairs(do("ACOR.INFO", "rules")); len(got) != 4 || got[1] != int64(3) {
+				t.Errorf("ACOR.INFO = %v", got)
+			}
+			if got := do("ACOR.REMOVE", "rules", "hers", "missing"); got != int64(1) {
+				t.Errorf("ACOR.REMOVE = %v, want 1", got)
+			}
+			if got := do("ACOR.FLUSH", "rules"); got != "OK" {
+				t.Errorf("ACOR.FLUSH = %v", got)
+			}
+			if got := do("ACOR.FIND", "rules", "ushers"); !reflect.DeepEqual(got, []any{}) {
+				t.Errorf("ACOR.FIND after ACOR.FLUSH = %v", got)
+			}
+
+			for _, tc := range []struct {
+				args []any
+				want string
+			}{
+				{[]any{"ACOR.FIND", "other", "x"}, `UNKNOWN_COLLECTION unknown collection: "other"`},
+				{[]any{"ACOR.MATCHES", "plain", "x"}, `UNSUPPORTED service does not report match spans: collection "plain"`},
+				{[]any{"ACOR.FIND", "rules"}, "ERR wrong number of arguments for 'acor.find' command"},
+				{[]any{"ACOR.INFO", "rules", "x"}, "ERR wrong number of arguments for 'acor.info' command"},
+				{[]any{"ACOR.MATCHES", "rules", "x", "NEAREST"}, "ERR syntax error"},
+				{[]any{"GET", "x"}, "ERR unknown command 'GET'"},
+			} {
+				err := rdb.Do(ctx, tc.args...).Err()
+				if err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+					t.Errorf("%v: err = %v, want prefix %q", tc.args, err, tc.want)
+				}
+			}
+			if err := rdb.Ping(ctx).Err(); err != nil {
+				t.Errorf("PING: %v", err)
+			}
+		})
+	}
+}
+
+func TestRESPServerAuth(t *testing.T) {
+	var logs bytes.Buffer
+	collections := map[string]Service{"rules": newRESPTestCollection(t)}
+	_, addr := startRESPServer(t, collections, &RESPOptions{Guard: testGuard(&logs)})
+	ctx := context.Background()
+
+	anonymous := redis.NewClient(&redis.Options{Addr: addr, Protocol: 3})
+	defer anonymous.Close()
+	if err := anonymous.Do(ctx, "ACOR.FIND", "rules", "he").Err(); err == nil || !strings.HasPrefix(err.Error(), "UNAUTHENTICATED ") {
+		t.Errorf("ACOR.FIND without AUTH: err = %v, want UNAUTHENTICATED", err)
+	}
+	if !strings.Contains(logs.String(), `"transport":"resp"`) || !strings.Contains(logs.String(), `"target":"ACOR.FIND"`) {
+		t.Errorf("audit record = %s, want transport resp and target ACOR.FIND", logs.String())
+	}
+	if err := anonymous.Do(ctx, "AUTH", "k-wrong").Err(); err == nil || !strings.HasPrefix(err.Error(), "UNAUTHENTICATED ") {
+		t.Errorf("AUTH k-wrong: err = %v, want UNAUTHENTICATED", err)
+	}
+
+	// HELLO's AUTH, which go-redis sends for a password, names the default user.
+	reader := redis.NewClient(&redis.Options{Addr: addr, Protocol: 3, Password: "k-read"})
+	defer reader.Close()
+	if err := reader.Do(ctx, "ACOR.FIND", "rules", "he").Err(); err != nil {
+		t.Errorf("ACOR.FIND as reader: %v", err)
+	}
+	if err := reader.Do(ctx, "ACOR.ADD", "rules", "he").Err(); err == nil || !strings.HasPrefix(err.Error(), "PERMISSION_DENIED ") {
+		t.Errorf("ACOR.ADD as reader: err = %v, want PERMISSION_DENIED", err)
+	}
+
+	// RESP2 clients authenticate with AUTH.
+	writer := redis.NewClient(&redis.Options{Addr: addr, Protocol: 2, Password: "k-write"})
+	defer writer.Close()
+	if err := writer.Do(ctx, "ACOR.ADD", "rules", "he").Err(); err != nil {
+		t.Errorf("ACOR.ADD as writer: %v", err)
+	}
+
+	_, unguarded := startRESPServer(t, collections, nil)
+	open := redis.NewClient(&redis.Options{Addr: unguarded, Protocol: 2})
+	defer open.Close()
+	if err := open.Do(ctx, "AUTH", "x").Err(); err == nil || !strings.HasPrefix(err.Error(), "ERR AUTH called without") {
+		t.Errorf("AUTH without a guard: err = %v", err)
+	}
+}
+
+func TestRESPServerLimits(t *testing.T) {
+	_, addr := startRESPServer(t, map[string]Service{"rules": newRESPTestCollection(t)},
+		&RESPOptions{Limiter: limit.New(testLimits, nil)})
+	rdb := redis.NewClient(&redis.Options{Addr: addr, Protocol: 3})
+	defer rdb.Close()
+	ctx := context.Background()
+
+	if err := rdb.Do(ctx, "ACOR.ADD", "rules", "keyword-too-long").Err(); err == nil || !strings.HasPrefix(err.Error(), "REQUEST_TOO_LARGE ") {
+		t.Errorf("oversized ACOR.ADD: err = %v, want REQUEST_TOO_LARGE", err)
+	}
+	var err error
+	for range 3 {
+		err = rdb.Do(ctx, "ACOR.FIND", "rules", "he").Err()
+	}
+	if err == nil || !strings.HasPrefix(err.Error(), "RATE_LIMITED ") || !strings.HasSuffix(err.Error(), "; retry after 60s") {
+		t.Errorf("third ACOR.FIND: err = %v, want RATE_LIMITED with a retry delay", err)
+	}
+}
+
+// TestRESPServerRawProtocol speaks to the server as telnet or a pipelining
+// client would.
+func TestRESPServerRawProtocol(t *testing.T) {
+	_, addr := startRESPServer(t, map[string]Service{"rules": newRESPTestCollection(t)}, nil)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// An inline command and a pipelined pair arrive in one write.
+	if _, err := conn.Write([]byte("ACOR.ADD rules he\r\n*3\r\n$9\r\nACOR.FIND\r\n$5\r\nrules\r\n$3\r\nshe\r\nPING\r\nQUIT\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	var got strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		got.WriteString(line)
+		if err != nil {
+			break
+		}
+	}
+	if want := ":1\r\n*1\r\n$2\r\nhe\r\n+PONG\r\n+OK\r\n"; got.String() != want {
+		t.Errorf("replies = %q, want %q", got.String(), want)
+	}
+
+	conn2, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn2.Close()
+	_ = conn2.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn2.Write([]byte("*1\r\n:1\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	line, _ := bufio.NewReader(conn2).ReadString('\n')
+	if !strings.HasPrefix(line, "-ERR Protocol error: ") {
+		t.Errorf("reply to a malformed command = %q, want a protocol error", line)
+	}
+}
+
+func TestRESPServerMaxCommandBytes(t *testing.T) {
+	_, addr := startRESPServer(t, map[string]Service{"rules": newRESPTestCollection(t)},
+		&RESPOptions{MaxArgBytes: 8, MaxCommandBytes: 20})
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	// Every argument is within MaxArgBytes; the fourth takes the command past 20.
+	if _, err := conn.Write([]byte("*4\r\n$8\r\nACOR.ADD\r\n$5\r\nrules\r\n$6\r\nkw-one\r\n$6\r\nkw-two\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+	if !strings.HasPrefix(line, "-ERR Protocol error: command larger than 20 bytes") {
+		t.Errorf("reply to an oversized command = %q, want a protocol error", line)
+	}
+}
+
+func TestRESPServerHello(t *testing.T) {
+	_, addr := startRESPServer(t, map[string]Service{"rules": newRESPTestCollection(t)}, nil)
+	rdb := redis.NewClient(&redis.Options{Addr: addr, Protocol: 2})
+	defer rdb.Close()
+	ctx := context.Background()
+
+	hello, err := rdb.Do(ctx, "HELLO", "3", "SETNAME", "tester").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := hello.(map[any]any); !ok || m["server"] != "acor" || m["proto"] != int64(3) {
+		t.Errorf("HELLO 3 = %#v", hello)
+	}
+	if err := rdb.Do(ctx, "HELLO", "4").Err(); err == nil || !strings.HasPrefix(err.Error(), "NOPROTO") {
+		t.Errorf("HELLO 4: err = %v, want NOPROTO", err)
+	}
+	if err := rdb.Do(ctx, "SELECT", "1").Err(); err == nil {
+		t.Error("SELECT 1 succeeded")
+	}
+}
+
+func TestRESPServerShutdown(t *testing.T) {
+	server, addr := startRESPServer(t, map[string]Service{"rules": newRESPTestCollection(t)}, nil)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// An idle connection is closed, and Shutdown returns, without a deadline.
+	if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	if line, _ := bufio.NewReader(conn).ReadString('\n'); line != "+PONG\r\n" {
+		t.Fatalf("PING = %q", line)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("connection still open after Shutdown")
+	}
+	if _, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		t.Error("listener still accepting after Shutdown")
+	}
+}
@@ -11,20 +11,34 @@
 	"net/http"
 	"time"
 
+	"google.golang.org/protobuf/reflect/protoreflect"
+
 	"github.com/skyoo2003/acor/pkg/acor"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
 )
 
 const defaultReadHeaderTimeout = 5 * time.Second
 
+// Service is the collection the server fronts; *acor.AhoCorasick implements it.
+// The methods that take a context are the ones read-your-writes tokens flow
+// through: writes record the version they commit in the context's
+// acor.VersionToken, and reads honor its acor.WithMinVersion floor.
 type Service interface {
-	Add(string) (int, error)
-	Remove(string) (int, error)
-	Find(string) ([]string, error)
-	FindIndex(string) (map[string][]int, error)
-	Suggest(string) ([]string, error)
-	SuggestIndex(string) (map[string][]int, error)
-	Flush() error
+	AddContext(context.Context, string) (int, error)
+	RemoveContext(context.Context, string) (int, error)
+	FindContext(context.Context, string) ([]string, error)
+	FindIndexContext(context.Context, string) (map[string][]int, error)
+	SuggestContext(context.Context, string) ([]string, error)
+	SuggestIndexContext(context.Context, string) (map[string][]int, error)
+	FlushContext(context.Context) error
 	Info() (*acor.AhoCorasickInfo, error)
+	Score(string, *acor.ScoreOptions) (*acor.ScoreResult, error)
+	SetWeights(map[string]acor.KeywordWeight) error
+	Weights() (map[string]acor.KeywordWeight, error)
+	SetAlias(string) error
+	ResolveAlias(string) (string, error)
+	DeleteAlias(string) (bool, error)
+	Collection() string
 }
 
 type API struct {
@@ -35,14 +49,22 @@ type KeywordRequest struct {
 	Keyword string `json:"keyword"`
 }
 
+// InputRequest carries the text to read. MinVersion, when non-zero, is a version
+// returned by an earlier write: the read answers from state at least that new,
+// whichever server instance the write went through.
 type InputRequest struct {
-	Input string `json:"input"`
+	Input      string `json:"input"`
+	MinVersion int64  `json:"min_version,omitempty"`
 }
 
 type EmptyRequest struct{}
 
+// CountResponse reports how many keywords a write changed. Version is the
+// collection version the write left behind, to pass as a later read's
+// min_version; it is omitted where no keywords are written (set-weights).
 type CountResponse struct {
-	Count int `json:"count"`
+	Count   int   `json:"count"`
+	Version int64 `json:"version,omitempty"`
 }
 
 type MatchesResponse struct {
@@ -58,30 +80,125 @@ type InfoResponse struct {
 	Nodes    int `json:"nodes"`
 }
 
+// StatusResponse reports success. Version is set by flush, as on CountResponse.
 type StatusResponse struct {
-	Status string `json:"status"`
+	Status  string `json:"status"`
+	Version int64  `json:"version,omitempty"`
+}
+
+// ScoreRequest mirrors acor.ScoreOptions plus the text to score.
+type ScoreRequest struct {
+	Input              string             `json:"input"`
+	DefaultWeight      float64            `json:"default_weight"`
+	Decay              float64            `json:"decay"`
+	MaxOccurrences     int                `json:"max_occurrences"`
+	Threshold          float64            `json:"threshold"`
+	CategoryThresholds map[string]float64 `json:"category_thresholds"`
+}
+
+type KeywordScore struct {
+	Count    int     `json:"count"`
+	Weight   float64 `json:"weight"`
+	Category string  `json:"category,omitempty"`
+	Score    float64 `json:"score"`
+}
+
+type ScoreResponse struct {
+	Total             float64                 `json:"total"`
+	Flagged           bool                    `json:"flagged"`
+	FlaggedCategories []string                `json:"flagged_categories"`
+	Categories        map[string]float64      `json:"categories"`
+	Keywords          map[string]KeywordScore `json:"keywords"`
+}
+
+type KeywordWeight struct {
+	Weight   float64 `json:"weight"`
+	Category string  `json:"category,omitempty"`
+}
+
+type SetWeightsRequest struct {
+	Weights map[string]KeywordWeight `json:"weights"`
+}
+
+type WeightsResponse struct {
+	Weights map[string]KeywordWeight `json:"weights"`
+}
+
+type AliasRequest struct {
+	Alias string `json:"alias"`
+}
+
+// AliasResponse names the collection an alias points at.
+type AliasResponse struct {
+	Alias      string `json:"alias"`
+	Collection string `json:"collection"`
+}
+
+type DeleteAliasResponse struct {
+	Deleted bool `json:"deleted"`
 }
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+// FindAcrossRequest names the collections to search, all of them when empty.
+type FindAcrossRequest struct {
+	Input       string   `json:"input"`
+	Collections []string `json:"collections,omitempty"`
+}
+
+// CollectionMatch is one keyword occurrence, labeled with the collection that
+// holds the keyword. Start and End are rune offsets, End exclusive.
+type CollectionMatch struct {
+	Collection string `json:"collection"`
+	Keyword    string `json:"keyword"`
+	Start      int    `json:"start"`
+	End        int    `json:"end"`
+}
+
+type FindAcrossResponse struct {
+	Matches []CollectionMatch `json:"matches"`
 }
 
 func NewAPI(service Service) *API {
 	return &API{service: service}
 }
 
+// NewHTTPHandler serves service over HTTP/JSON, at the route each RPC's
+// google.api.http option in acor.proto binds it to, plus /healthz and the
+// OpenAPI document at /openapi.json. The RPCs below have hand-written handlers;
+// the generated REST gateway serves any other bound RPC.
 func NewHTTPHandler(service Service) http.Handler {
 	api := NewAPI(service)
+	handlers := map[protoreflect.Name]http.HandlerFunc{
+		"Add":          api.handleAdd,
+		"Remove":       api.handleRemove,
+		"Find":         api.handleFind,
+		"FindIndex":    api.handleFindIndex,
+		"FindAcross":   api.handleFindAcross,
+		"Suggest":      api.handleSuggest,
+		"SuggestIndex": api.handleSuggestIndex,
+		"Info":         api.handleInfo,
+		"Flush":        api.handleFlush,
+		"Score":        api.handleScore,
+		"SetWeights":   api.handleSetWeights,
+		"Weights":      api.handleWeights,
+		"SetAlias":     api.handleSetAlias,
+		"ResolveAlias": api.handleResolveAlias,
+		"DeleteAlias":  api.handleDeleteAlias,
+		"Watch":        api.handleWatch,
+	}
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", api.handleHealth)
-	mux.HandleFunc("/v1/add", api.handleAdd)
-	mux.HandleFunc("/v1/remove", api.handleRemove)
-	mux.HandleFunc("/v1/find", api.handleFind)
-	mux.HandleFunc("/v1/find-index", api.handleFindIndex)
-	mux.HandleFunc("/v1/suggest", api.handleSuggest)
-	mux.HandleFunc("/v1/suggest-index", api.handleSuggestIndex)
-	mux.HandleFunc("/v1/info", api.handleInfo)
-	mux.HandleFunc("/v1/flush", api.handleFlush)
+	mux.HandleFunc("/openapi.json", handleOpenAPI)
+	var gateway http.Handler
+	for _, b := range httpBindings() {
+		if h, ok := handlers[b.rpc.Name()]; ok {
+			mux.HandleFunc(b.path, h)
+			continue
+		}
+		if gateway == nil {
+			gateway = newGateway(service)
+		}
+		mux.Handle(b.path, gateway)
+	}
 	return mux
 }
 
@@ -93,66 +210,89 @@ func NewHTTPServer(addr string, service Service) *http.Server {
 	}
 }
 
-func (api *API) Add(_ context.Context, req *KeywordRequest) (*CountResponse, error) {
+func (api *API) Add(ctx context.Context, req *KeywordRequest) (*CountResponse, error) {
 	if req == nil {
 		req = &KeywordRequest{}
 	}
-	count, err := api.service.Add(req.Keyword)
+	ctx, token := acor.WithVersionToken(serviceContext(ctx))
+	count, err := api.service.AddContext(ctx, req.Keyword)
 	if err != nil {
 		return nil, err
 	}
-	return &CountResponse{Count: count}, nil
+	return &CountResponse{Count: count, Version: token.Version()}, nil
 }
 
-func (api *API) Remove(_ context.Context, req *KeywordRequest) (*CountResponse, error) {
+func (api *API) Remove(ctx context.Context, req *KeywordRequest) (*CountResponse, error) {
 	if req == nil {
 		req = &KeywordRequest{}
 	}
-	count, err := api.service.Remove(req.Keyword)
+	ctx, token := acor.WithVersionToken(serviceContext(ctx))
+	count, err := api.service.RemoveContext(ctx, req.Keyword)
 	if err != nil {
 		return nil, err
 	}
-	return &CountResponse{Count: count}, nil
+	return &CountResponse{Count: count, Version: token.Version()}, nil
 }
 
-func (api *API) Find(_ context.Context, req *InputRequest) (*MatchesResponse, error) {
+func (api *API) Find(ctx context.Context, req *InputRequest) (*MatchesResponse, error) {
 	if req == nil {
 		req = &InputRequest{}
 	}
-	matches, err := api.service.Find(req.Input)
+	matches, err := api.service.FindContext(acor.WithMinVersion(ctx, req.MinVersion), req.Input)
 	if err != nil {
 		return nil, err
 	}
 	return &MatchesResponse{Matches: matches}, nil
 }
 
-func (api *API) FindIndex(_ context.Context, req *InputRequest) (*MatchIndexesResponse, error) {
+func (api *API) FindIndex(ctx context.Context, req *InputRequest) (*MatchIndexesResponse, error) {
 	if req == nil {
 		req = &InputRequest{}
 	}
-	matches, err := api.service.FindIndex(req.Input)
+	matches, err := api.service.FindIndexContext(acor.WithMinVersion(ctx, req.MinVersion), req.Input)
 	if err != nil {
 		return nil, err
 	}
 	return &MatchIndexesResponse{Matches: matches}, nil
 }
 
-func (api *API) Suggest(_ context.Context, req *InputRequest) (*MatchesResponse, error) {
+// FindAcross searches several collections in one pass. It needs a Service that
+// implements CrossFinder, and returns ErrFindAcrossUnsupported otherwise.
+func (api *API) FindAcross(ctx context.Context, req *FindAcrossRequest) (*FindAcrossResponse, error) {
+	if req == nil {
+		req = &FindAcrossRequest{}
+	}
+	finder, ok := api.service.(CrossFinder)
+	if !ok {
+		return nil, ErrFindAcrossUnsupported
+	}
+	matches, err := finder.FindAcrossContext(ctx, req.Collections, req.Input)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CollectionMatch, len(matches))
+	for i, m := range matches {
+		out[i] = CollectionMatch{Collection: m.Collection, Keyword: m.Keyword, Start: m.Start, End: m.End}
+	}
+	return &FindAcrossResponse{Matches: out}, nil
+}
+
+func (api *API) Suggest(ctx context.Context, req *InputRequest) (*MatchesResponse, error) {
 	if req == nil {
 		req = &InputRequest{}
 	}
-	matches, err := api.service.Suggest(req.Input)
+	matches, err := api.service.SuggestContext(acor.WithMinVersion(ctx, req.MinVersion), req.Input)
 	if err != nil {
 		return nil, err
 	}
 	return &MatchesResponse{Matches: matches}, nil
 }
 
-func (api *API) SuggestIndex(_ context.Context, req *InputRequest) (*MatchIndexesResponse, error) {
+func (api *API) SuggestIndex(ctx context.Context, req *InputRequest) (*MatchIndexesResponse, error) {
 	if req == nil {
 		req = &InputRequest{}
 	}
-	matches, err := api.service.SuggestIndex(req.Input)
+	matches, err := api.service.SuggestIndexContext(acor.WithMinVersion(ctx, req.MinVersion), req.Input)
 	if err != nil {
 		return nil, err
 	}
@@ -167,11 +307,107 @@ func (api *API) Info(_ context.Context, _ *EmptyRequest) (*InfoResponse, error)
 	return &InfoResponse{Keywords: info.Keywords, Nodes: info.Nodes}, nil
 }
 
-func (api *API) Flush(_ context.Context, _ *EmptyRequest) (*StatusResponse, error) {
-	if err := api.service.Flush(); err != nil {
+func (api *API) Flush(ctx context.Context, _ *EmptyRequest) (*StatusResponse, error) {
+	ctx, token := acor.WithVersionToken(serviceContext(ctx))
+	if err := api.service.FlushContext(ctx); err != nil {
+		return nil, err
+	}
+	return &StatusResponse{Status: "ok", Version: token.Version()}, nil
+}
+
+func (api *API) Score(_ context.Context, req *ScoreRequest) (*ScoreResponse, error) {
+	if req == nil {
+		req = &ScoreRequest{}
+	}
+	result, err := api.service.Score(req.Input, &acor.ScoreOptions{
+		DefaultWeight:      req.DefaultWeight,
+		Decay:              req.Decay,
+		MaxOccurrences:     req.MaxOccurrences,
+		Threshold:          req.Threshold,
+		CategoryThresholds: req.CategoryThresholds,
+	})
+	if err != nil {
+		return nil, err
+	}
+	keywords := make(map[string]KeywordScore, len(result.Keywords))
+	for kw, ks := range result.Keywords {
+		keywords[kw] = KeywordScore{Count: ks.Count, Weight: ks.Weight, Category: ks.Category, Score: ks.Score}
+	}
+	return &ScoreResponse{
+		Total:             result.Total,
+		Flagged:           result.Flagged,
+		FlaggedCategories: result.FlaggedCategories,
+		Categories:        result.Categories,
+		Keywords:          keywords,
+	}, nil
+}
+
+func (api *API) SetWeights(_ context.Context, req *SetWeightsRequest) (*CountResponse, error) {
+	if req == nil {
+		req = &SetWeightsRequest{}
+	}
+	weights := make(map[string]acor.KeywordWeight, len(req.Weights))
+	for kw, w := range req.Weights {
+		weights[kw] = acor.KeywordWeight{Weight: w.Weight, Category: w.Category}
+	}
+	if err := api.service.SetWeights(weights); err != nil {
+		return nil, err
+	}
+	return &CountResponse{Count: len(weights)}, nil
+}
+
+func (api *API) Weights(_ context.Context, _ *EmptyRequest) (*WeightsResponse, error) {
+	weights, err := api.service.Weights()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]KeywordWeight, len(weights))
+	for kw, w := range weights {
+		out[kw] = KeywordWeight{Weight: w.Weight, Category: w.Category}
+	}
+	return &WeightsResponse{Weights: out}, nil
+}
+
+// SetAlias points the alias at the collection the server serves.
+func (api *API) SetAlias(_ context.Context, req *AliasRequest) (*AliasResponse, error) {
+	if req == nil {
+		req = &AliasRequest{}
+	}
+	if err := api.service.SetAlias(req.Alias); err != nil {
+		return nil, err
+	}
+	return &AliasResponse{Alias: req.Alias, Collection: api.service.Collection()}, nil
+}
+
+func (api *API) ResolveAlias(_ context.Context, req *AliasRequest) (*AliasResponse, error) {
+	if req == nil {
+		req = &AliasRequest{}
+	}
+	collection, err := api.service.ResolveAlias(req.Alias)
+	if err != nil {
+		return nil, err
+	}
+	return &AliasResponse{Alias: req.Alias, Collection: collection}, nil
+}
+
+func (api *API) DeleteAlias(_ context.Context, req *AliasRequest) (*DeleteAliasResponse, error) {
+	if req == nil {
+		req = &AliasRequest{}
+	}
+	deleted, err := api.service.DeleteAlias(req.Alias)
+	if err != nil {
 		return nil, err
 	}
-	return &StatusResponse{Status: "ok"}, nil
+	return &DeleteAliasResponse{Deleted: deleted}, nil
+}
+
+// serviceContext is the context a write hands the Service: the request's
+// values, which carry its version token, without its cancellation. A write
+// abandoned after its commit would still have landed in Redis but skipped the
+// invalidation that tells other instances about it. Reads keep the request's
+// context, so a client that gives up stops them, min_version wait included.
+func serviceContext(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
 }
 
 func (api *API) handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -234,6 +470,19 @@ func (api *API) handleFindIndex(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, resp)
 }
 
+func (api *API) handleFindAcross(w http.ResponseWriter, r *http.Request) {
+	var req FindAcrossRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	resp, err := api.FindAcross(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (api *API) handleSuggest(w http.ResponseWriter, r *http.Request) {
 	req, ok := decodeInputRequest(w, r)
 	if !ok {
@@ -286,6 +535,84 @@ func (api *API) handleFlush(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, resp)
 }
 
+func (api *API) handleScore(w http.ResponseWriter, r *http.Request) {
+	var req ScoreRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	resp, err := api.Score(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (api *API) handleSetWeights(w http.ResponseWriter, r *http.Request) {
+	var req SetWeightsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	resp, err := api.SetWeights(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (api *API) handleWeights(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	resp, err := api.Weights(r.Context(), &EmptyRequest{})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (api *API) handleSetAlias(w http.ResponseWriter, r *http.Request) {
+	var req AliasRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	resp, err := api.SetAlias(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (api *API) handleResolveAlias(w http.ResponseWriter, r *http.Request) {
+	var req AliasRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	resp, err := api.ResolveAlias(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (api *API) handleDeleteAlias(w http.ResponseWriter, r *http.Request) {
+	var req AliasRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	resp, err := api.DeleteAlias(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
 const maxRequestBodyBytes = 1 << 20 // 1MB
 
 func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
@@ -314,11 +641,13 @@ func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
 func writeDecodeError(w http.ResponseWriter, err error, fallback string) {
 	var mbe *http.MaxBytesError
 	if errors.As(err, &mbe) {
-		writeJSON(w, http.StatusRequestEntityTooLarge,
-			&ErrorResponse{Error: fmt.Sprintf("request body must not be larger than %d bytes", mbe.Limit)})
+		writeProblem(w, &apiError{
+			reason: acorv1.ErrorReason_BODY_TOO_LARGE,
+			detail: fmt.Sprintf("request body must not be larger than %d bytes", mbe.Limit),
+		})
 		return
 	}
-	writeJSON(w, http.StatusBadRequest, &ErrorResponse{Error: fallback})
+	writeProblem(w, &apiError{reason: acorv1.ErrorReason_INVALID_BODY, detail: fallback})
 }
 
 func decodeKeywordRequest(w http.ResponseWriter, r *http.Request) (*KeywordRequest, bool) {
@@ -338,11 +667,13 @@ func decodeInputRequest(w http.ResponseWriter, r *http.Request) (*InputRequest,
 }
 
 func writeMethodNotAllowed(w http.ResponseWriter) {
-	writeJSON(w, http.StatusMethodNotAllowed, &ErrorResponse{Error: "method not allowed"})
+	writeProblem(w, &apiError{reason: acorv1.ErrorReason_METHOD_NOT_ALLOWED, detail: "method not allowed"})
 }
 
+// writeServiceError reports an error from the Service with the status its reason
+// maps to; see classifyError.
 func writeServiceError(w http.ResponseWriter, err error) {
-	writeJSON(w, http.StatusInternalServerError, &ErrorResponse{Error: err.Error()})
+	writeProblem(w, classifyError(err))
 }
 
 func writeJSON(w http.ResponseWriter, statusCode int, value interface{}) {
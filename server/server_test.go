@@ -42,9 +42,20 @@ type fakeService struct {
 	lastKeyword     string
 	lastInput       string
 	flushCalls      int
-}
-
-func (f *fakeService) Add(keyword string) (int, error) {
+	scoreResult     *acor.ScoreResult
+	lastScoreOpts   *acor.ScoreOptions
+	weights         map[string]acor.KeywordWeight
+	weightsErr      error
+	aliases         map[string]string
+	collection      string
+	version         int64
+	lastMinVersion  int64
+	lastCtxErr      error
+}
+
+func (f *fakeService) AddContext(ctx context.Context, keyword string) (int, error) {
+	acor.RecordVersion(ctx, f.version)
+	f.lastCtxErr = ctx.Err()
 	f.lastKeyword = keyword
 	if f.addErr != nil {
 		return 0, f.addErr
@@ -52,7 +63,8 @@ func (f *fakeService) Add(keyword string) (int, error) {
 	return f.addCount, nil
 }
 
-func (f *fakeService) Remove(keyword string) (int, error) {
+func (f *fakeService) RemoveContext(ctx context.Context, keyword string) (int, error) {
+	acor.RecordVersion(ctx, f.version)
 	f.lastKeyword = keyword
 	if f.removeErr != nil {
 		return 0, f.removeErr
@@ -60,7 +72,9 @@ func (f *fakeService) Remove(keyword string) (int, error) {
 	return f.removeCount, nil
 }
 
-func (f *fakeService) Find(input string) ([]string, error) {
+func (f *fakeService) FindContext(ctx context.Context, input string) ([]string, error) {
+	f.lastMinVersion = acor.MinVersion(ctx)
+	f.lastCtxErr = ctx.Err()
 	f.lastInput = input
 	if f.findErr != nil {
 		return nil, f.findErr
@@ -68,7 +82,8 @@ func (f *fakeService) Find(input string) ([]string, error) {
 	return f.findMatches, nil
 }
 
-func (f *fakeService) FindIndex(input string) (map[string][]int, error) {
+func (f *fakeService) FindIndexContext(ctx context.Context, input string) (map[string][]int, error) {
+	f.lastMinVersion = acor.MinVersion(ctx)
 	f.lastInput = input
 	if f.findIndexErr != nil {
 		return nil, f.findIndexErr
@@ -76,7 +91,8 @@ func (f *fakeService) FindIndex(input string) (map[string][]int, error) {
 	return f.findIndexes, nil
 }
 
-func (f *fakeService) Suggest(input string) ([]string, error) {
+func (f *fakeService) SuggestContext(ctx context.Context, input string) ([]string, error) {
+	f.lastMinVersion = acor.MinVersion(ctx)
 	f.lastInput = input
 	if f.suggestErr != nil {
 		return nil, f.suggestErr
@@ -84,7 +100,8 @@ func (f *fakeService) Suggest(input string) ([]string, error) {
 	return f.suggestMatches, nil
 }
 
-func (f *fakeService) SuggestIndex(input string) (map[string][]int, error) {
+func (f *fakeService) SuggestIndexContext(ctx context.Context, input string) (map[string][]int, error) {
+	f.lastMinVersion = acor.MinVersion(ctx)
 	f.lastInput = input
 	if f.suggestIndexErr != nil {
 		return nil, f.suggestIndexErr
@@ -92,7 +109,8 @@ func (f *fakeService) SuggestIndex(input string) (map[string][]int, error) {
 	return f.suggestIndexes, nil
 }
 
-func (f *fakeService) Flush() error {
+func (f *fakeService) FlushContext(ctx context.Context) error {
+	acor.RecordVersion(ctx, f.version)
 	f.flushCalls++
 	return f.flushErr
 }
@@ -104,6 +122,50 @@ func (f *fakeService) Info() (*acor.AhoCorasickInfo, error) {
 	return f.info, nil
 }
 
+func (f *fakeService) Score(input string, opts *acor.ScoreOptions) (*acor.ScoreResult, error) {
+	f.lastInput = input
+	f.lastScoreOpts = opts
+	return f.scoreResult, f.weightsErr
+}
+
+func (f *fakeService) SetWeights(weights map[string]acor.KeywordWeight) error {
+	if f.weightsErr != nil {
+		return f.weightsErr
+	}
+	f.weights = weights
+	return nil
+}
+
+func (f *fakeService) Weights() (map[string]acor.KeywordWeight, error) {
+	return f.weights, f.weightsErr
+}
+
+func (f *fakeService) SetAlias(alias string) error {
+	if f.aliases == nil {
+		f.aliases = make(map[string]string)
+	}
+	f.aliases[alias] = f.collection
+	return nil
+}
+
+func (f *fakeService) ResolveAlias(alias string) (string, error) {
+	collection, ok := f.aliases[alias]
+	if !ok {
+		return "", acor.ErrAliasNotFound
+	}
+	return collection, nil
+}
+
+func (f *fakeService) DeleteAlias(alias string) (bool, error) {
+	_, ok := f.aliases[alias]
+	delete(f.aliases, alias)
+	return ok, nil
+}
+
+func (f *fakeService) Collection() string {
+	return f.collection
+}
+
 func TestHTTPHandlerHealth(t *testing.T) {
 	server := httptest.NewServer(NewHTTPHandler(&fakeService{}))
 	defer server.Close()
@@ -285,6 +347,114 @@ func assertIndexes(t *testing.T, actual, expected []int) {
 	}
 }
 
+func TestHTTPHandlerScoreAndWeights(t *testing.T) {
+	service := &fakeService{scoreResult: &acor.ScoreResult{
+		Total:             4,
+		Flagged:           true,
+		FlaggedCategories: []string{"spam"},
+		Categories:        map[string]float64{"spam": 4},
+		Keywords:          map[string]acor.KeywordScore{"spam": {Count: 2, Weight: 2, Category: "spam", Score: 4}},
+	}}
+	server := httptest.NewServer(NewHTTPHandler(service))
+	defer server.Close()
+
+	var setBody CountResponse
+	doJSONRequest(t, http.MethodPost, server.URL+"/v1/set-weights",
+		SetWeightsRequest{Weights: map[string]KeywordWeight{"spam": {Weight: 2, Category: "spam"}}}, &setBody)
+	if setBody.Count != 1 || service.weights["spam"] != (acor.KeywordWeight{Weight: 2, Category: "spam"}) {
+		t.Fatalf("set-weights count = %d, stored %v", setBody.Count, service.weights)
+	}
+
+	var weightsBody WeightsResponse
+	doJSONRequest(t, http.MethodGet, server.URL+"/v1/weights", nil, &weightsBody)
+	if weightsBody.Weights["spam"] != (KeywordWeight{Weight: 2, Category: "spam"}) {
+		t.Fatalf("weights = %v", weightsBody.Weights)
+	}
+
+	var scoreBody ScoreResponse
+	doJSONRequest(t, http.MethodPost, server.URL+"/v1/score",
+		ScoreRequest{Input: "spam spam", Decay: 0.5, Threshold: 3, CategoryThresholds: map[string]float64{"spam": 1}},
+		&scoreBody)
+	if scoreBody.Total != 4 || !scoreBody.Flagged || scoreBody.Keywords["spam"].Count != 2 {
+		t.Fatalf("score = %+v", scoreBody)
+	}
+	opts := service.lastScoreOpts
+	if opts == nil || opts.Decay != 0.5 || opts.Threshold != 3 || opts.CategoryThresholds["spam"] != 1 {
+		t.Fatalf("score options did not reach the service: %+v", opts)
+	}
+}
+
+func TestHTTPHandlerAliases(t *testing.T) {
+	service := &fakeService{collection: "rules-20261017"}
+	server := httptest.NewServer(NewHTTPHandler(service))
+	defer server.Close()
+
+	var setBody AliasResponse
+	doJSONRequest(t, http.MethodPost, server.URL+"/v1/set-alias", AliasRequest{Alias: "rules"}, &setBody)
+	if setBody != (AliasResponse{Alias: "rules", Collection: "rules-20261017"}) {
+		t.Fatalf("set-alias = %+v", setBody)
+	}
+
+	var resolveBody AliasResponse
+	doJSONRequest(t, http.MethodPost, server.URL+"/v1/resolve-alias", AliasRequest{Alias: "rules"}, &resolveBody)
+	if resolveBody.Collection != "rules-20261017" {
+		t.Fatalf("resolve-alias = %+v", resolveBody)
+	}
+
+	var deleteBody DeleteAliasResponse
+	doJSONRequest(t, http.MethodPost, server.URL+"/v1/delete-alias", AliasRequest{Alias: "rules"}, &deleteBody)
+	if !deleteBody.Deleted {
+		t.Fatal("delete-alias reported the alias missing")
+	}
+	if _, ok := service.aliases["rules"]; ok {
+		t.Fatal("delete-alias left the alias in place")
+	}
+}
+
+func TestHTTPHandlerVersionTokens(t *testing.T) {
+	service := &fakeService{addCount: 1, version: 1792411200000000000}
+	server := httptest.NewServer(NewHTTPHandler(service))
+	defer server.Close()
+
+	var addBody CountResponse
+	doJSONRequest(t, http.MethodPost, server.URL+"/v1/add", KeywordRequest{Keyword: keywordHE}, &addBody)
+	if addBody != (CountResponse{Count: 1, Version: service.version}) {
+		t.Fatalf("add = %+v, want the committed version", addBody)
+	}
+	var flushBody StatusResponse
+	doJSONRequest(t, http.MethodPost, server.URL+"/v1/flush", EmptyRequest{}, &flushBody)
+	if flushBody.Version != service.version {
+		t.Fatalf("flush = %+v, want the committed version", flushBody)
+	}
+
+	for _, path := range []string{"/v1/find", "/v1/find-index", "/v1/suggest", "/v1/suggest-index"} {
+		service.lastMinVersion = 0
+		var body map[string]any
+		doJSONRequest(t, http.MethodPost, server.URL+path, InputRequest{Input: inputHEHE, MinVersion: addBody.Version}, &body)
+		if service.lastMinVersion != addBody.Version {
+			t.Errorf("%s: read at min version %d, want %d", path, service.lastMinVersion, addBody.Version)
+		}
+	}
+}
+
+func TestAPICancellationReachesReadsOnly(t *testing.T) {
+	service := &fakeService{addCount: 1}
+	api := NewAPI(service)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := api.Add(ctx, &KeywordRequest{Keyword: keywordHE}); err != nil {
+		t.Fatal(err)
+	}
+	if service.lastCtxErr != nil {
+		t.Errorf("add saw %v, want the write shielded from cancellation", service.lastCtxErr)
+	}
+	_, _ = api.Find(ctx, &InputRequest{Input: inputHEHE, MinVersion: 1})
+	if !errors.Is(service.lastCtxErr, context.Canceled) || service.lastMinVersion != 1 {
+		t.Errorf("find saw %v at min version %d, want the canceled request context", service.lastCtxErr, service.lastMinVersion)
+	}
+}
+
 func TestNewHTTPServer(t *testing.T) {
 	service := &fakeService{}
 	srv := NewHTTPServer("127.0.0.1:0", service)
@@ -615,6 +785,9 @@ func TestHTTPHandlerWrongMethods(t *testing.T) {
 		{"findIndex_wrong_method", "/v1/find-index", http.MethodGet},
 		{"suggest_wrong_method", "/v1/suggest", http.MethodGet},
 		{"suggestIndex_wrong_method", "/v1/suggest-index", http.MethodGet},
+		{"score_wrong_method", "/v1/score", http.MethodGet},
+		{"setWeights_wrong_method", "/v1/set-weights", http.MethodGet},
+		{"weights_wrong_method", "/v1/weights", http.MethodPost},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,276 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// ErrWatchUnsupported is returned by API.Watch when the Service does not
+// implement Watcher. The HTTP handler answers it with a 501 and the gRPC server
+// with Unimplemented.
+var ErrWatchUnsupported = errors.New("service does not watch its collection")
+
+// Watcher is implemented by a Service that can report writes to its collection;
+// *acor.AhoCorasick does, and a *Collections watches its primary.
+type Watcher interface {
+	// WatchContext returns a channel that receives a value after each write to
+	// the collection, and is closed when the watch ends.
+	WatchContext(ctx context.Context) (<-chan struct{}, error)
+	VersionContext(ctx context.Context) (int64, error)
+	HistoryContext(ctx context.Context, limit int) ([]acor.HistoryEntry, error)
+}
+
+// Event kinds of a ChangeEvent.
+const (
+	EventChange = "change"
+	EventResync = "resync"
+)
+
+// watchCatchUpLimit bounds how far back in the collection's history a watch
+// looks to bridge a gap: a client further behind than this is sent a resync.
+const watchCatchUpLimit = acor.DefaultHistoryMaxEntries
+
+// watchKeepAlive is how often an idle SSE stream sends a comment, so proxies
+// do not time the connection out.
+const watchKeepAlive = 15 * time.Second
+
+// WatchRequest starts a watch. FromVersion is the last version the client has
+// seen, to resume from; zero starts with a resync at the current version.
+type WatchRequest struct {
+	FromVersion int64 `json:"from_version"`
+}
+
+// ChangeEvent is one event of a watch. A "change" is one committed write, and
+// lists its keywords when KeywordsKnown is set: the write was recorded in the
+// collection's version history. A "resync" tells the client its state cannot be
+// brought up to date by changes; it reloads the collection, and the changes
+// that follow build on Version.
+type ChangeEvent struct {
+	Kind          string   `json:"kind"`
+	Version       int64    `json:"version"`
+	PrevVersion   int64    `json:"prev_version,omitempty"`
+	Op            string   `json:"op,omitempty"`
+	Added         []string `json:"added,omitempty"`
+	Removed       []string `json:"removed,omitempty"`
+	KeywordsKnown bool     `json:"keywords_known"`
+}
+
+// Watch calls send with an event for each write to the collection until ctx is
+// canceled, the watch ends, or send fails. It first brings a resuming client up
+// to date: with the changes since req.FromVersion when the history still holds
+// them, and with a resync otherwise. It needs a Service that implements Watcher,
+// and returns ErrWatchUnsupported otherwise.
+func (api *API) Watch(ctx context.Context, req *WatchRequest, send func(*ChangeEvent) error) error {
+	if req == nil {
+		req = &WatchRequest{}
+	}
+	w, ok := watcherOf(api.service)
+	if !ok {
+		return ErrWatchUnsupported
+	}
+	// Subscribe before reading the version, so a write landing between the two
+	// is reported rather than lost.
+	changes, err := w.WatchContext(ctx)
+	if err != nil {
+		return err
+	}
+	current, err := w.VersionContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	last, resuming := req.FromVersion, true
+	if last == 0 {
+		if err := send(&ChangeEvent{Kind: EventResync, Version: current}); err != nil {
+			return err
+		}
+		last = current
+	}
+	for {
+		if current != last {
+			events, err := changesBetween(ctx, w, last, current, resuming)
+			if err != nil {
+				return err
+			}
+			for _, e := range events {
+				if err := send(e); err != nil {
+					return err
+				}
+			}
+			last = current
+		}
+		resuming = false
+		if _, ok := <-changes; !ok {
+			return ctx.Err()
+		}
+		if current, err = w.VersionContext(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// changesBetween returns the events that take a client from version from to
+// version to, oldest first: one change per history entry when the history links
+// the two. Otherwise a client resuming from before the watch began gets a
+// resync, and one watching live a change without keywords: it saw every write
+// up to from, and only what they were is unrecorded.
+func changesBetween(ctx context.Context, w Watcher, from, to int64, resuming bool) ([]*ChangeEvent, error) {
+	// Most calls bridge a single write, so read a little history and widen the
+	// window only while the link to from is still beyond it.
+	for limit := 8; ; limit *= 2 {
+		limit = min(limit, watchCatchUpLimit)
+		entries, err := w.HistoryContext(ctx, limit)
+		if err != nil {
+			return nil, err
+		}
+		if events, ok := linkHistory(entries, from, to); ok {
+			return events, nil
+		}
+		if len(entries) < limit || limit == watchCatchUpLimit {
+			break
+		}
+	}
+	if resuming {
+		return []*ChangeEvent{{Kind: EventResync, Version: to}}, nil
+	}
+	return []*ChangeEvent{{Kind: EventChange, Version: to, PrevVersion: from}}, nil
+}
+
+// linkHistory follows entries, newest first, back from version to until one was
+// written over version from, and returns that run oldest first. It reports
+// false if a write in between is missing from entries.
+func linkHistory(entries []acor.HistoryEntry, from, to int64) ([]*ChangeEvent, bool) {
+	var run []*ChangeEvent
+	want := to
+	for _, e := range entries {
+		if e.Version != want {
+			if run != nil {
+				return nil, false
+			}
+			continue // written after to; the next read reports it
+		}
+		run = append(run, &ChangeEvent{
+			Kind: EventChange, Version: e.Version, PrevVersion: e.PrevVersion, Op: e.Op,
+			Added: e.Added, Removed: e.Removed, KeywordsKnown: true,
+		})
+		if e.PrevVersion == from {
+			for i, j := 0, len(run)-1; i < j; i, j = i+1, j-1 {
+				run[i], run[j] = run[j], run[i]
+			}
+			return run, true
+		}
+		want = e.PrevVersion
+	}
+	return nil, false
+}
+
+// watcherOf returns the Watcher behind service: the service itself, or the
+// primary of a *Collections.
+func watcherOf(service Service) (Watcher, bool) {
+	if c, ok := service.(*Collections); ok {
+		service = c.Service
+	}
+	w, ok := service.(Watcher)
+	return w, ok
+}
+
+// handleWatch serves a watch as Server-Sent Events: each event's type is its
+// kind, its id the version, and its data the ChangeEvent as JSON. A client
+// resumes with the from_version query parameter or, on an EventSource's own
+// reconnect, the Last-Event-ID header.
+func (api *API) handleWatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	req := &WatchRequest{}
+	from := r.URL.Query().Get("from_version")
+	if from == "" {
+		from = r.Header.Get("Last-Event-ID")
+	}
+	if from != "" {
+		v, err := strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			writeProblem(w, &apiError{reason: acorv1.ErrorReason_INVALID_ARGUMENT, detail: fmt.Sprintf("from_version: %v", err)})
+			return
+		}
+		req.FromVersion = v
+	}
+
+	rc := http.NewResponseController(w)
+	started := false
+	start := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		return rc.Flush()
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	// The watch blocks between writes, so keep-alives come from beside it and
+	// share its writer through events.
+	events := make(chan *ChangeEvent)
+	done := make(chan error, 1)
+	go func() {
+		done <- api.Watch(ctx, req, func(e *ChangeEvent) error {
+			select {
+			case events <- e:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	keepAlive := time.NewTicker(watchKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		var err error
+		select {
+		case e := <-events:
+			if err = start(); err == nil {
+				err = writeEvent(w, e)
+			}
+		case <-keepAlive.C:
+			if err = start(); err == nil {
+				_, err = fmt.Fprint(w, ": keep-alive\n\n")
+			}
+		case err = <-done:
+			if !started && err != nil && r.Context().Err() == nil {
+				writeServiceError(w, err)
+			}
+			return
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			cancel()
+			<-done
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e *ChangeEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\nid: %d\ndata: %s\n\n", e.Kind, e.Version, data)
+	return err
+}
@@ -0,0 +1,297 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/skyoo2003/acor/pkg/acor"
+	"github.com/skyoo2003/acor/server/limit"
+	acorv1 "github.com/skyoo2003/acor/server/proto/acor/v1"
+)
+
+// fakeWatchService is a Service whose collection starts at version 30 with
+// three recorded writes, 5 -> 10 -> 20 -> 30, and takes more through write.
+type fakeWatchService struct {
+	*fakeService
+	signal chan struct{}
+
+	mu      sync.Mutex
+	version int64
+	history []acor.HistoryEntry // newest first
+}
+
+func newFakeWatchService() *fakeWatchService {
+	return &fakeWatchService{
+		fakeService: &fakeService{collection: "rules"},
+		signal:      make(chan struct{}, 16),
+		version:     30,
+		history: []acor.HistoryEntry{
+			{Op: "remove", Version: 30, PrevVersion: 20, Removed: []string{"he"}},
+			{Op: "add", Version: 20, PrevVersion: 10, Added: []string{"she"}},
+			{Op: "add", Version: 10, PrevVersion: 5, Added: []string{"he"}},
+		},
+	}
+}
+
+// write commits version, recording it in the history when recorded is set.
+func (f *fakeWatchService) write(version int64, recorded bool, added ...string) {
+	f.mu.Lock()
+	if recorded {
+		e := acor.HistoryEntry{Op: "add", Version: version, PrevVersion: f.version, Added: added}
+		f.history = append([]acor.HistoryEntry{e}, f.history...)
+	}
+	f.version = version
+	f.mu.Unlock()
+	f.signal <- struct{}{}
+}
+
+func (f *fakeWatchService) WatchContext(ctx context.Context) (<-chan struct{}, error) {
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		for {
+			select {
+			case <-f.signal:
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes, nil
+}
+
+func (f *fakeWatchService) VersionContext(context.Context) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.version, nil
+}
+
+func (f *fakeWatchService) HistoryContext(_ context.Context, limit int) ([]acor.HistoryEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.history[:min(limit, len(f.history))], nil
+}
+
+// watchEvents runs API.Watch from version in the background and returns its
+// events as they come.
+func watchEvents(t *testing.T, service Service, from int64) <-chan *ChangeEvent {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	events := make(chan *ChangeEvent, 16)
+	go func() {
+		_ = NewAPI(service).Watch(ctx, &WatchRequest{FromVersion: from}, func(e *ChangeEvent) error {
+			events <- e
+			return nil
+		})
+	}()
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan *ChangeEvent) *ChangeEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+		return nil
+	}
+}
+
+func TestWatchResumesFromHistory(t *testing.T) {
+	service := newFakeWatchService()
+	events := watchEvents(t, service, 10)
+
+	for _, want := range []int64{20, 30} {
+		e := nextEvent(t, events)
+		if e.Kind != EventChange || e.Version != want || !e.KeywordsKnown {
+			t.Fatalf("catch-up event = %+v, want the change to %d", e, want)
+		}
+	}
+
+	service.write(40, true, "hers")
+	if e := nextEvent(t, events); e.Version != 40 || e.PrevVersion != 30 || len(e.Added) != 1 || e.Added[0] != "hers" {
+		t.Errorf("recorded write = %+v", e)
+	}
+	// A write made without history is still reported, without its keywords.
+	service.write(50, false)
+	if e := nextEvent(t, events); e.Kind != EventChange || e.Version != 50 || e.PrevVersion != 40 || e.KeywordsKnown {
+		t.Errorf("unrecorded write = %+v", e)
+	}
+}
+
+func TestWatchResyncs(t *testing.T) {
+	for name, from := range map[string]int64{
+		"from nothing":            0,
+		"from a trimmed version":  7,
+		"from an unknown version": 99,
+	} {
+		t.Run(name, func(t *testing.T) {
+			events := watchEvents(t, newFakeWatchService(), from)
+			if e := nextEvent(t, events); e.Kind != EventResync || e.Version != 30 {
+				t.Errorf("first event = %+v, want a resync at 30", e)
+			}
+		})
+	}
+
+	// A client already up to date gets nothing until the next write.
+	service := newFakeWatchService()
+	events := watchEvents(t, service, 30)
+	service.write(40, true)
+	if e := nextEvent(t, events); e.Kind != EventChange || e.Version != 40 {
+		t.Errorf("first event = %+v, want the change to 40", e)
+	}
+}
+
+func TestWatchSSE(t *testing.T) {
+	service := newFakeWatchService()
+	server := httptest.NewServer(NewHTTPHandler(service))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v1/watch", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "20")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	readEvent := func() (kind, id string, e ChangeEvent) {
+		t.Helper()
+		for lines.Scan() {
+			line := lines.Text()
+			switch {
+			case line == "":
+				return kind, id, e
+			case strings.HasPrefix(line, "event: "):
+				kind = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		t.Fatalf("stream ended: %v", lines.Err())
+		return
+	}
+
+	if kind, id, e := readEvent(); kind != EventChange || id != "30" || e.Removed[0] != "he" {
+		t.Errorf("catch-up event = %s %s %+v", kind, id, e)
+	}
+	service.write(40, true, "hers")
+	if kind, id, e := readEvent(); kind != EventChange || id != "40" || e.Added[0] != "hers" {
+		t.Errorf("live event = %s %s %+v", kind, id, e)
+	}
+}
+
+func TestWatchHTTPErrors(t *testing.T) {
+	for _, tc := range []struct {
+		service Service
+		target  string
+		want    int
+	}{
+		{&fakeService{}, "/v1/watch", http.StatusNotImplemented},
+		{newFakeWatchService(), "/v1/watch?from_version=soon", http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		NewHTTPHandler(tc.service).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.target, nil))
+		if rec.Code != tc.want {
+			t.Errorf("GET %s: status %d, want %d", tc.target, rec.Code, tc.want)
+		}
+	}
+	rec := httptest.NewRecorder()
+	NewHTTPHandler(newFakeWatchService()).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/watch", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /v1/watch: status %d, want 405", rec.Code)
+	}
+}
+
+func TestGRPCServerWatch(t *testing.T) {
+	service := newFakeWatchService()
+	client := newGRPCTestClient(t, service)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &acorv1.WatchRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := stream.Recv()
+	if err != nil || e.GetKind() != acorv1.ChangeEvent_RESYNC || e.GetVersion() != 30 {
+		t.Fatalf("first event = %v, %v; want a resync at 30", e, err)
+	}
+	service.write(40, true, "hers")
+	e, err = stream.Recv()
+	if err != nil || e.GetKind() != acorv1.ChangeEvent_CHANGE || e.GetVersion() != 40 || e.GetAdded()[0] != "hers" {
+		t.Errorf("live event = %v, %v", e, err)
+	}
+
+	unsupported, err := newGRPCTestClient(t, &fakeService{}).Watch(ctx, &acorv1.WatchRequest{})
+	if err == nil {
+		_, err = unsupported.Recv()
+	}
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("Watch on a service without Watcher: %v, want Unimplemented", err)
+	}
+}
+
+func TestWatchStreamInterceptors(t *testing.T) {
+	service := newFakeWatchService()
+	limiter := limit.New(limit.Config{Operations: map[string]limit.OperationLimits{"watch": {MaxConcurrent: 1}}}, nil)
+	client := newGRPCTestClient(t, service, grpc.ChainStreamInterceptor(
+		AuthStreamInterceptor(service, testGuard(new(bytes.Buffer))),
+		RateLimitStreamInterceptor(service, limiter),
+	))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watch := func(ctx context.Context) error {
+		stream, err := client.Watch(ctx, &acorv1.WatchRequest{})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		return err
+	}
+
+	if err := watch(ctx); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Watch without credentials: %v, want Unauthenticated", err)
+	}
+	reader := metadata.AppendToOutgoingContext(ctx, "x-api-key", "k-read")
+	if err := watch(reader); err != nil {
+		t.Fatalf("Watch as reader: %v", err)
+	}
+	// The first watch is still open and holds the only slot.
+	if err := watch(reader); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("second Watch: %v, want ResourceExhausted", err)
+	}
+}